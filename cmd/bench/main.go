@@ -0,0 +1,96 @@
+// Command bench runs the video processing pipeline against synthetic sources and prints
+// per-stage timings, CPU usage and peak scratch disk usage.
+//
+//	go run ./cmd/bench -durations 10s,1m -resolutions 1280x720,1920x1080
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"video-processing/models"
+	"video-processing/services/video"
+)
+
+func main() {
+	durations := flag.String("durations", "10s,1m", "comma separated source durations")
+	resolutions := flag.String("resolutions", "1280x720,1920x1080", "comma separated source resolutions")
+	workDir := flag.String("workdir", os.TempDir(), "scratch directory for the pipeline outputs")
+	threads := flag.Int("threads", 0, "ffmpeg threads per encode, 0 for ffmpeg's default")
+	presetFile := flag.String("preset", "", "JSON file of the transcoding preset to encode, the seeded default preset when empty")
+	flag.Parse()
+
+	preset, err := loadPreset(*presetFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	inputs, err := parseInputs(*durations, *resolutions)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, in := range inputs {
+		report, err := video.RunBenchmark(ctx, in, *workDir, *threads, preset)
+		if err != nil {
+			log.Fatalf("%s: %v", in, err)
+		}
+		fmt.Fprintf(w, "%s\twall\tcpu\tcores\n", in)
+		for _, s := range report.Stages {
+			fmt.Fprintf(w, "  %s\t%s\t%s\t%.2f\n", s.Name, s.Wall.Round(time.Millisecond), s.CPU.Round(time.Millisecond), s.Cores())
+		}
+		fmt.Fprintf(w, "  total\t%s\t%s\t%.2f peak\n", report.Wall.Round(time.Millisecond), report.CPU.Round(time.Millisecond), report.PeakCores)
+		fmt.Fprintf(w, "  peak disk\t%.1f MB\t\t\n\n", float64(report.PeakDiskBytes)/(1<<20))
+		w.Flush()
+	}
+}
+
+// loadPreset reads a preset in the format of the preset API
+func loadPreset(path string) (models.TranscodingPreset, error) {
+	if path == "" {
+		return models.DefaultPreset, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return models.TranscodingPreset{}, err
+	}
+	var req models.PresetRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		return models.TranscodingPreset{}, fmt.Errorf("invalid preset %s: %w", path, err)
+	}
+	if err := req.Validate(); err != nil {
+		return models.TranscodingPreset{}, fmt.Errorf("invalid preset %s: %w", path, err)
+	}
+	return models.TranscodingPreset{Name: req.Name, Variants: req.Variants, HLS: req.HLS}, nil
+}
+
+// parseInputs builds every combination of the given durations and WIDTHxHEIGHT resolutions
+func parseInputs(durations, resolutions string) ([]video.BenchInput, error) {
+	var inputs []video.BenchInput
+	for _, r := range strings.Split(resolutions, ",") {
+		var width, height int
+		if _, err := fmt.Sscanf(strings.TrimSpace(r), "%dx%d", &width, &height); err != nil || width <= 0 || height <= 0 {
+			return nil, fmt.Errorf("invalid resolution %q", r)
+		}
+		for _, d := range strings.Split(durations, ",") {
+			duration, err := time.ParseDuration(strings.TrimSpace(d))
+			if err != nil || duration <= 0 {
+				return nil, fmt.Errorf("invalid duration %q", d)
+			}
+			inputs = append(inputs, video.BenchInput{Duration: duration, Width: width, Height: height})
+		}
+	}
+	return inputs, nil
+}
@@ -11,6 +11,86 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+type DataExport struct {
+	ID         uuid.UUID          `json:"id"`
+	UserID     uuid.UUID          `json:"user_id"`
+	Status     string             `json:"status"`
+	Bucket     string             `json:"bucket"`
+	Key        string             `json:"key"`
+	SizeBytes  int64              `json:"size_bytes"`
+	Error      string             `json:"error"`
+	LeaseUntil pgtype.Timestamptz `json:"lease_until"`
+	CreatedAt  time.Time          `json:"created_at"`
+	FinishedAt pgtype.Timestamptz `json:"finished_at"`
+	ExpiresAt  pgtype.Timestamptz `json:"expires_at"`
+}
+
+type Notification struct {
+	ID        uuid.UUID          `json:"id"`
+	UserID    uuid.UUID          `json:"user_id"`
+	Kind      string             `json:"kind"`
+	VideoID   pgtype.UUID        `json:"video_id"`
+	ReadAt    pgtype.Timestamptz `json:"read_at"`
+	CreatedAt time.Time          `json:"created_at"`
+	Message   string             `json:"message"`
+}
+
+type NotificationPreference struct {
+	UserID     uuid.UUID `json:"user_id"`
+	Channels   []byte    `json:"channels"`
+	WebhookUrl string    `json:"webhook_url"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+type ReprocessRun struct {
+	ID            uuid.UUID          `json:"id"`
+	PresetID      pgtype.UUID        `json:"preset_id"`
+	UserID        pgtype.UUID        `json:"user_id"`
+	CreatedAfter  pgtype.Timestamptz `json:"created_after"`
+	CreatedBefore time.Time          `json:"created_before"`
+	RatePerMinute int32              `json:"rate_per_minute"`
+	Status        string             `json:"status"`
+	Total         int32              `json:"total"`
+	Enqueued      int32              `json:"enqueued"`
+	Succeeded     int32              `json:"succeeded"`
+	Failed        int32              `json:"failed"`
+	LastVideoID   uuid.UUID          `json:"last_video_id"`
+	LeaseUntil    pgtype.Timestamptz `json:"lease_until"`
+	Error         string             `json:"error"`
+	CreatedAt     time.Time          `json:"created_at"`
+	UpdatedAt     time.Time          `json:"updated_at"`
+	FinishedAt    pgtype.Timestamptz `json:"finished_at"`
+}
+
+type Subscription struct {
+	SubscriberID uuid.UUID `json:"subscriber_id"`
+	CreatorID    uuid.UUID `json:"creator_id"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type TranscodingPreset struct {
+	ID                uuid.UUID `json:"id"`
+	Name              string    `json:"name"`
+	Description       string    `json:"description"`
+	IsDefault         bool      `json:"is_default"`
+	Variants          []byte    `json:"variants"`
+	HlsSegmentSeconds int32     `json:"hls_segment_seconds"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+	RateControl       string    `json:"rate_control"`
+	Crf               int32     `json:"crf"`
+}
+
+type UsageMonthly struct {
+	UserID         uuid.UUID          `json:"user_id"`
+	Month          pgtype.Date        `json:"month"`
+	TranscodeMs    int64              `json:"transcode_ms"`
+	StorageBytes   int64              `json:"storage_bytes"`
+	DeliveryBytes  int64              `json:"delivery_bytes"`
+	EmitLeaseUntil pgtype.Timestamptz `json:"emit_lease_until"`
+	EmittedAt      pgtype.Timestamptz `json:"emitted_at"`
+}
+
 type User struct {
 	ID                uuid.UUID          `json:"id"`
 	FirstName         string             `json:"first_name"`
@@ -27,17 +107,150 @@ type User struct {
 }
 
 type Video struct {
-	ID            uuid.UUID          `json:"id"`
-	UserID        uuid.UUID          `json:"user_id"`
-	Title         string             `json:"title"`
-	Description   string             `json:"description"`
-	Bucket        string             `json:"bucket"`
-	Key           string             `json:"key"`
-	Status        string             `json:"status"`
-	FileSizeBytes int64              `json:"file_size_bytes"`
-	ContentType   string             `json:"content_type"`
-	CreatedAt     pgtype.Timestamptz `json:"created_at"`
-	UpdatedAt     pgtype.Timestamptz `json:"updated_at"`
+	ID                   uuid.UUID          `json:"id"`
+	UserID               uuid.UUID          `json:"user_id"`
+	Title                string             `json:"title"`
+	Description          string             `json:"description"`
+	Bucket               string             `json:"bucket"`
+	Key                  string             `json:"key"`
+	Status               string             `json:"status"`
+	FileSizeBytes        int64              `json:"file_size_bytes"`
+	ContentType          string             `json:"content_type"`
+	CreatedAt            pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt            pgtype.Timestamptz `json:"updated_at"`
+	ParentVideoID        pgtype.UUID        `json:"parent_video_id"`
+	Recipe               []byte             `json:"recipe"`
+	ColorPrimaries       pgtype.Text        `json:"color_primaries"`
+	ColorTransfer        pgtype.Text        `json:"color_transfer"`
+	ColorSpace           pgtype.Text        `json:"color_space"`
+	HdrFormat            pgtype.Text        `json:"hdr_format"`
+	Projection           pgtype.Text        `json:"projection"`
+	StereoMode           pgtype.Text        `json:"stereo_mode"`
+	Visibility           string             `json:"visibility"`
+	PublishedAt          pgtype.Timestamptz `json:"published_at"`
+	AgeRestricted        bool               `json:"age_restricted"`
+	PublishAt            pgtype.Timestamptz `json:"publish_at"`
+	ExpiresAt            pgtype.Timestamptz `json:"expires_at"`
+	PurgeOnExpiry        bool               `json:"purge_on_expiry"`
+	PlaybackPasswordHash pgtype.Text        `json:"playback_password_hash"`
+	SourceWidth          pgtype.Int4        `json:"source_width"`
+	SourceHeight         pgtype.Int4        `json:"source_height"`
+	DurationMs           pgtype.Int8        `json:"duration_ms"`
+	SourceCodec          pgtype.Text        `json:"source_codec"`
+	SourceFps            pgtype.Float8      `json:"source_fps"`
+	AudioChannels        pgtype.Int4        `json:"audio_channels"`
+	Container            pgtype.Text        `json:"container"`
+	FailureReason        pgtype.Text        `json:"failure_reason"`
+	MasterPlaylistKey    pgtype.Text        `json:"master_playlist_key"`
+}
+
+type VideoAsset struct {
+	ID          uuid.UUID          `json:"id"`
+	VideoID     uuid.UUID          `json:"video_id"`
+	Kind        string             `json:"kind"`
+	Bucket      string             `json:"bucket"`
+	Key         string             `json:"key"`
+	ContentType string             `json:"content_type"`
+	CreatedAt   pgtype.Timestamptz `json:"created_at"`
+}
+
+type VideoChapter struct {
+	ID        uuid.UUID          `json:"id"`
+	VideoID   uuid.UUID          `json:"video_id"`
+	Title     string             `json:"title"`
+	StartMs   int64              `json:"start_ms"`
+	EndMs     pgtype.Int8        `json:"end_ms"`
+	Source    string             `json:"source"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+}
+
+type VideoFingerprint struct {
+	VideoID     uuid.UUID          `json:"video_id"`
+	FrameHashes []int64            `json:"frame_hashes"`
+	CreatedAt   pgtype.Timestamptz `json:"created_at"`
+}
+
+type VideoKey struct {
+	VideoID   uuid.UUID          `json:"video_id"`
+	Key       []byte             `json:"key"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+}
+
+type VideoProbe struct {
+	VideoID  uuid.UUID `json:"video_id"`
+	Probe    []byte    `json:"probe"`
+	ProbedAt time.Time `json:"probed_at"`
+}
+
+type VideoProgress struct {
+	VideoID   uuid.UUID          `json:"video_id"`
+	Variant   string             `json:"variant"`
+	Percent   int16              `json:"percent"`
+	UpdatedAt pgtype.Timestamptz `json:"updated_at"`
+	StartedAt pgtype.Timestamptz `json:"started_at"`
+}
+
+type VideoPurgeFailure struct {
+	VideoID  uuid.UUID          `json:"video_id"`
+	RetryAt  pgtype.Timestamptz `json:"retry_at"`
+	Attempts int32              `json:"attempts"`
+}
+
+type VideoResultSize struct {
+	VideoID uuid.UUID `json:"video_id"`
+	Prefix  string    `json:"prefix"`
+	Bytes   int64     `json:"bytes"`
+}
+
+type VideoRenditionVersion struct {
+	ID        uuid.UUID `json:"id"`
+	VideoID   uuid.UUID `json:"video_id"`
+	Version   int32     `json:"version"`
+	Variants  []byte    `json:"variants"`
+	Assets    []byte    `json:"assets"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type VideoReport struct {
+	ID         uuid.UUID          `json:"id"`
+	VideoID    uuid.UUID          `json:"video_id"`
+	ReporterID uuid.UUID          `json:"reporter_id"`
+	Reason     string             `json:"reason"`
+	Details    string             `json:"details"`
+	Status     string             `json:"status"`
+	Action     pgtype.Text        `json:"action"`
+	ResolvedBy pgtype.UUID        `json:"resolved_by"`
+	ResolvedAt pgtype.Timestamptz `json:"resolved_at"`
+	CreatedAt  time.Time          `json:"created_at"`
+}
+
+type VideoSubtitle struct {
+	VideoID   uuid.UUID          `json:"video_id"`
+	Track     int32              `json:"track"`
+	Language  string             `json:"language"`
+	Label     string             `json:"label"`
+	Forced    bool               `json:"forced"`
+	Bucket    string             `json:"bucket"`
+	Key       string             `json:"key"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+}
+
+type VideoThumbnail struct {
+	VideoID   uuid.UUID          `json:"video_id"`
+	Position  int32              `json:"position"`
+	AtMs      int64              `json:"at_ms"`
+	Bucket    string             `json:"bucket"`
+	Key       string             `json:"key"`
+	IsPrimary bool               `json:"is_primary"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+}
+
+type VideoTranslation struct {
+	VideoID     uuid.UUID `json:"video_id"`
+	Language    string    `json:"language"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	UpdatedAt   time.Time `json:"updated_at"`
 }
 
 type VideoVariant struct {
@@ -53,4 +266,16 @@ type VideoVariant struct {
 	Width          pgtype.Int4        `json:"width"`
 	Height         pgtype.Int4        `json:"height"`
 	BitrateKbps    pgtype.Int4        `json:"bitrate_kbps"`
+	Vmaf           pgtype.Float8      `json:"vmaf"`
+	Psnr           pgtype.Float8      `json:"psnr"`
+	Format         string             `json:"format"`
+	AudioLanguages []string           `json:"audio_languages"`
+}
+
+type WatchHistory struct {
+	UserID     uuid.UUID `json:"user_id"`
+	VideoID    uuid.UUID `json:"video_id"`
+	PositionMs int64     `json:"position_ms"`
+	DurationMs int64     `json:"duration_ms"`
+	WatchedAt  time.Time `json:"watched_at"`
 }
@@ -133,6 +133,31 @@ func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error
 	return i, err
 }
 
+const listUserIDs = `-- name: ListUserIDs :many
+SELECT id FROM users ORDER BY created_at
+`
+
+// includes deleted users, whose objects are still stored
+func (q *Queries) ListUserIDs(ctx context.Context) ([]uuid.UUID, error) {
+	rows, err := q.db.Query(ctx, listUserIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const searchUsers = `-- name: SearchUsers :many
 SELECT id, first_name, middle_name, last_name, username, password, phone, email, profile_picture_url, created_at, updated_at, deleted_at FROM users WHERE first_name ILIKE $1 
 OR last_name ILIKE $1 
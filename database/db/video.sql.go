@@ -12,6 +12,99 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const clearVideoFailure = `-- name: ClearVideoFailure :exec
+UPDATE videos
+SET
+    status = 'pending',
+    failure_reason = NULL
+WHERE id = $1 AND status = 'failed'
+`
+
+// puts a rejected video back to pending once a later run accepts its source
+func (q *Queries) ClearVideoFailure(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.Exec(ctx, clearVideoFailure, id)
+	return err
+}
+
+const createDerivedVideo = `-- name: CreateDerivedVideo :one
+INSERT INTO videos (
+    user_id,
+    title,
+    description,
+    bucket,
+    key,
+    file_size_bytes,
+    content_type,
+    parent_video_id,
+    recipe
+) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id, user_id, title, description, bucket, key, status, file_size_bytes, content_type, created_at, updated_at, parent_video_id, recipe, color_primaries, color_transfer, color_space, hdr_format, projection, stereo_mode, visibility, published_at, age_restricted, publish_at, expires_at, purge_on_expiry, playback_password_hash, source_width, source_height, duration_ms, source_codec, source_fps, audio_channels, container, failure_reason, master_playlist_key
+`
+
+type CreateDerivedVideoParams struct {
+	UserID        uuid.UUID   `json:"user_id"`
+	Title         string      `json:"title"`
+	Description   string      `json:"description"`
+	Bucket        string      `json:"bucket"`
+	Key           string      `json:"key"`
+	FileSizeBytes int64       `json:"file_size_bytes"`
+	ContentType   string      `json:"content_type"`
+	ParentVideoID pgtype.UUID `json:"parent_video_id"`
+	Recipe        []byte      `json:"recipe"`
+}
+
+func (q *Queries) CreateDerivedVideo(ctx context.Context, arg CreateDerivedVideoParams) (Video, error) {
+	row := q.db.QueryRow(ctx, createDerivedVideo,
+		arg.UserID,
+		arg.Title,
+		arg.Description,
+		arg.Bucket,
+		arg.Key,
+		arg.FileSizeBytes,
+		arg.ContentType,
+		arg.ParentVideoID,
+		arg.Recipe,
+	)
+	var i Video
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Title,
+		&i.Description,
+		&i.Bucket,
+		&i.Key,
+		&i.Status,
+		&i.FileSizeBytes,
+		&i.ContentType,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.ParentVideoID,
+		&i.Recipe,
+		&i.ColorPrimaries,
+		&i.ColorTransfer,
+		&i.ColorSpace,
+		&i.HdrFormat,
+		&i.Projection,
+		&i.StereoMode,
+		&i.Visibility,
+		&i.PublishedAt,
+		&i.AgeRestricted,
+		&i.PublishAt,
+		&i.ExpiresAt,
+		&i.PurgeOnExpiry,
+		&i.PlaybackPasswordHash,
+		&i.SourceWidth,
+		&i.SourceHeight,
+		&i.DurationMs,
+		&i.SourceCodec,
+		&i.SourceFps,
+		&i.AudioChannels,
+		&i.Container,
+		&i.FailureReason,
+		&i.MasterPlaylistKey,
+	)
+	return i, err
+}
+
 const createVideo = `-- name: CreateVideo :one
 INSERT INTO videos (
     user_id,     
@@ -21,7 +114,7 @@ INSERT INTO videos (
     key,
     file_size_bytes,
     content_type
-) VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id, user_id, title, description, bucket, key, status, file_size_bytes, content_type, created_at, updated_at
+) VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id, user_id, title, description, bucket, key, status, file_size_bytes, content_type, created_at, updated_at, parent_video_id, recipe, color_primaries, color_transfer, color_space, hdr_format, projection, stereo_mode, visibility, published_at, age_restricted, publish_at, expires_at, purge_on_expiry, playback_password_hash, source_width, source_height, duration_ms, source_codec, source_fps, audio_channels, container, failure_reason, master_playlist_key
 `
 
 type CreateVideoParams struct {
@@ -57,58 +150,717 @@ func (q *Queries) CreateVideo(ctx context.Context, arg CreateVideoParams) (Video
 		&i.ContentType,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.ParentVideoID,
+		&i.Recipe,
+		&i.ColorPrimaries,
+		&i.ColorTransfer,
+		&i.ColorSpace,
+		&i.HdrFormat,
+		&i.Projection,
+		&i.StereoMode,
+		&i.Visibility,
+		&i.PublishedAt,
+		&i.AgeRestricted,
+		&i.PublishAt,
+		&i.ExpiresAt,
+		&i.PurgeOnExpiry,
+		&i.PlaybackPasswordHash,
+		&i.SourceWidth,
+		&i.SourceHeight,
+		&i.DurationMs,
+		&i.SourceCodec,
+		&i.SourceFps,
+		&i.AudioChannels,
+		&i.Container,
+		&i.FailureReason,
+		&i.MasterPlaylistKey,
+	)
+	return i, err
+}
+
+const createVideoChapter = `-- name: CreateVideoChapter :one
+INSERT INTO video_chapters (
+    video_id,
+    title,
+    start_ms,
+    end_ms,
+    source
+) VALUES ($1, $2, $3, $4, $5) RETURNING id, video_id, title, start_ms, end_ms, source, created_at
+`
+
+type CreateVideoChapterParams struct {
+	VideoID uuid.UUID   `json:"video_id"`
+	Title   string      `json:"title"`
+	StartMs int64       `json:"start_ms"`
+	EndMs   pgtype.Int8 `json:"end_ms"`
+	Source  string      `json:"source"`
+}
+
+func (q *Queries) CreateVideoChapter(ctx context.Context, arg CreateVideoChapterParams) (VideoChapter, error) {
+	row := q.db.QueryRow(ctx, createVideoChapter,
+		arg.VideoID,
+		arg.Title,
+		arg.StartMs,
+		arg.EndMs,
+		arg.Source,
+	)
+	var i VideoChapter
+	err := row.Scan(
+		&i.ID,
+		&i.VideoID,
+		&i.Title,
+		&i.StartMs,
+		&i.EndMs,
+		&i.Source,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const deferVideoPurge = `-- name: DeferVideoPurge :one
+INSERT INTO video_purge_failures (video_id, retry_at)
+VALUES ($1, $2)
+ON CONFLICT (video_id)
+DO UPDATE SET
+    retry_at = EXCLUDED.retry_at,
+    attempts = video_purge_failures.attempts + 1
+RETURNING attempts
+`
+
+type DeferVideoPurgeParams struct {
+	VideoID uuid.UUID          `json:"video_id"`
+	RetryAt pgtype.Timestamptz `json:"retry_at"`
+}
+
+// keeps a video whose purge failed out of ExpireDueVideos until retry_at
+func (q *Queries) DeferVideoPurge(ctx context.Context, arg DeferVideoPurgeParams) (int32, error) {
+	row := q.db.QueryRow(ctx, deferVideoPurge, arg.VideoID, arg.RetryAt)
+	var attempts int32
+	err := row.Scan(&attempts)
+	return attempts, err
+}
+
+const deleteVideo = `-- name: DeleteVideo :one
+DELETE FROM videos WHERE id = $1 RETURNING id, user_id, title, description, bucket, key, status, file_size_bytes, content_type, created_at, updated_at, parent_video_id, recipe, color_primaries, color_transfer, color_space, hdr_format, projection, stereo_mode, visibility, published_at, age_restricted, publish_at, expires_at, purge_on_expiry, playback_password_hash, source_width, source_height, duration_ms, source_codec, source_fps, audio_channels, container, failure_reason, master_playlist_key
+`
+
+func (q *Queries) DeleteVideo(ctx context.Context, id uuid.UUID) (Video, error) {
+	row := q.db.QueryRow(ctx, deleteVideo, id)
+	var i Video
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Title,
+		&i.Description,
+		&i.Bucket,
+		&i.Key,
+		&i.Status,
+		&i.FileSizeBytes,
+		&i.ContentType,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.ParentVideoID,
+		&i.Recipe,
+		&i.ColorPrimaries,
+		&i.ColorTransfer,
+		&i.ColorSpace,
+		&i.HdrFormat,
+		&i.Projection,
+		&i.StereoMode,
+		&i.Visibility,
+		&i.PublishedAt,
+		&i.AgeRestricted,
+		&i.PublishAt,
+		&i.ExpiresAt,
+		&i.PurgeOnExpiry,
+		&i.PlaybackPasswordHash,
+		&i.SourceWidth,
+		&i.SourceHeight,
+		&i.DurationMs,
+		&i.SourceCodec,
+		&i.SourceFps,
+		&i.AudioChannels,
+		&i.Container,
+		&i.FailureReason,
+		&i.MasterPlaylistKey,
+	)
+	return i, err
+}
+
+const deleteVideoChapters = `-- name: DeleteVideoChapters :exec
+DELETE FROM video_chapters WHERE video_id = $1
+`
+
+func (q *Queries) DeleteVideoChapters(ctx context.Context, videoID uuid.UUID) error {
+	_, err := q.db.Exec(ctx, deleteVideoChapters, videoID)
+	return err
+}
+
+const deleteVideoSubtitles = `-- name: DeleteVideoSubtitles :exec
+DELETE FROM video_subtitles WHERE video_id = $1
+`
+
+func (q *Queries) DeleteVideoSubtitles(ctx context.Context, videoID uuid.UUID) error {
+	_, err := q.db.Exec(ctx, deleteVideoSubtitles, videoID)
+	return err
+}
+
+const expireDueVideos = `-- name: ExpireDueVideos :many
+WITH due AS (
+    SELECT id
+    FROM videos
+    WHERE expires_at <= CURRENT_TIMESTAMP
+      AND NOT EXISTS (
+          SELECT 1 FROM video_purge_failures f
+          WHERE f.video_id = videos.id AND f.retry_at > CURRENT_TIMESTAMP
+      )
+    ORDER BY expires_at
+    LIMIT $1
+    FOR UPDATE SKIP LOCKED
+)
+UPDATE videos v
+SET
+    visibility = CASE WHEN v.visibility = 'public' THEN 'private' ELSE v.visibility END,
+    expires_at = CASE WHEN v.purge_on_expiry THEN v.expires_at ELSE NULL END
+FROM due
+WHERE v.id = due.id
+RETURNING v.id, v.user_id, v.bucket, v.key, v.purge_on_expiry
+`
+
+type ExpireDueVideosRow struct {
+	ID            uuid.UUID `json:"id"`
+	UserID        uuid.UUID `json:"user_id"`
+	Bucket        string    `json:"bucket"`
+	Key           string    `json:"key"`
+	PurgeOnExpiry bool      `json:"purge_on_expiry"`
+}
+
+// makes expired videos private; videos to purge keep expires_at until they are deleted
+func (q *Queries) ExpireDueVideos(ctx context.Context, limit int32) ([]ExpireDueVideosRow, error) {
+	rows, err := q.db.Query(ctx, expireDueVideos, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ExpireDueVideosRow
+	for rows.Next() {
+		var i ExpireDueVideosRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Bucket,
+			&i.Key,
+			&i.PurgeOnExpiry,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const failVideo = `-- name: FailVideo :exec
+UPDATE videos
+SET
+    status = 'failed',
+    failure_reason = $2
+WHERE id = $1
+`
+
+type FailVideoParams struct {
+	ID            uuid.UUID   `json:"id"`
+	FailureReason pgtype.Text `json:"failure_reason"`
+}
+
+// marks a video whose source the worker rejected as failed, with the reason for its owner
+func (q *Queries) FailVideo(ctx context.Context, arg FailVideoParams) error {
+	_, err := q.db.Exec(ctx, failVideo, arg.ID, arg.FailureReason)
+	return err
+}
+
+const getVideo = `-- name: GetVideo :one
+SELECT id, user_id, title, description, bucket, key, status, file_size_bytes, content_type, created_at, updated_at, parent_video_id, recipe, color_primaries, color_transfer, color_space, hdr_format, projection, stereo_mode, visibility, published_at, age_restricted, publish_at, expires_at, purge_on_expiry, playback_password_hash, source_width, source_height, duration_ms, source_codec, source_fps, audio_channels, container, failure_reason, master_playlist_key FROM videos WHERE id = $1
+`
+
+func (q *Queries) GetVideo(ctx context.Context, id uuid.UUID) (Video, error) {
+	row := q.db.QueryRow(ctx, getVideo, id)
+	var i Video
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Title,
+		&i.Description,
+		&i.Bucket,
+		&i.Key,
+		&i.Status,
+		&i.FileSizeBytes,
+		&i.ContentType,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.ParentVideoID,
+		&i.Recipe,
+		&i.ColorPrimaries,
+		&i.ColorTransfer,
+		&i.ColorSpace,
+		&i.HdrFormat,
+		&i.Projection,
+		&i.StereoMode,
+		&i.Visibility,
+		&i.PublishedAt,
+		&i.AgeRestricted,
+		&i.PublishAt,
+		&i.ExpiresAt,
+		&i.PurgeOnExpiry,
+		&i.PlaybackPasswordHash,
+		&i.SourceWidth,
+		&i.SourceHeight,
+		&i.DurationMs,
+		&i.SourceCodec,
+		&i.SourceFps,
+		&i.AudioChannels,
+		&i.Container,
+		&i.FailureReason,
+		&i.MasterPlaylistKey,
 	)
 	return i, err
 }
 
-const deleteVideo = `-- name: DeleteVideo :one
-DELETE FROM videos WHERE id = $1 RETURNING id, user_id, title, description, bucket, key, status, file_size_bytes, content_type, created_at, updated_at
+const getVideoByObject = `-- name: GetVideoByObject :one
+SELECT id, user_id, title, description, bucket, key, status, file_size_bytes, content_type, created_at, updated_at, parent_video_id, recipe, color_primaries, color_transfer, color_space, hdr_format, projection, stereo_mode, visibility, published_at, age_restricted, publish_at, expires_at, purge_on_expiry, playback_password_hash, source_width, source_height, duration_ms, source_codec, source_fps, audio_channels, container, failure_reason, master_playlist_key FROM videos WHERE bucket = $1 AND key = $2 LIMIT 1
+`
+
+type GetVideoByObjectParams struct {
+	Bucket string `json:"bucket"`
+	Key    string `json:"key"`
+}
+
+func (q *Queries) GetVideoByObject(ctx context.Context, arg GetVideoByObjectParams) (Video, error) {
+	row := q.db.QueryRow(ctx, getVideoByObject, arg.Bucket, arg.Key)
+	var i Video
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Title,
+		&i.Description,
+		&i.Bucket,
+		&i.Key,
+		&i.Status,
+		&i.FileSizeBytes,
+		&i.ContentType,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.ParentVideoID,
+		&i.Recipe,
+		&i.ColorPrimaries,
+		&i.ColorTransfer,
+		&i.ColorSpace,
+		&i.HdrFormat,
+		&i.Projection,
+		&i.StereoMode,
+		&i.Visibility,
+		&i.PublishedAt,
+		&i.AgeRestricted,
+		&i.PublishAt,
+		&i.ExpiresAt,
+		&i.PurgeOnExpiry,
+		&i.PlaybackPasswordHash,
+		&i.SourceWidth,
+		&i.SourceHeight,
+		&i.DurationMs,
+		&i.SourceCodec,
+		&i.SourceFps,
+		&i.AudioChannels,
+		&i.Container,
+		&i.FailureReason,
+		&i.MasterPlaylistKey,
+	)
+	return i, err
+}
+
+const getVideoProbe = `-- name: GetVideoProbe :one
+SELECT video_id, probe, probed_at FROM video_probes WHERE video_id = $1
+`
+
+func (q *Queries) GetVideoProbe(ctx context.Context, videoID uuid.UUID) (VideoProbe, error) {
+	row := q.db.QueryRow(ctx, getVideoProbe, videoID)
+	var i VideoProbe
+	err := row.Scan(
+		&i.VideoID,
+		&i.Probe,
+		&i.ProbedAt,
+	)
+	return i, err
+}
+
+const listAllUserVideos = `-- name: ListAllUserVideos :many
+SELECT id, user_id, title, description, bucket, key, status, file_size_bytes, content_type, created_at, updated_at, parent_video_id, recipe, color_primaries, color_transfer, color_space, hdr_format, projection, stereo_mode, visibility, published_at, age_restricted, publish_at, expires_at, purge_on_expiry, playback_password_hash, source_width, source_height, duration_ms, source_codec, source_fps, audio_channels, container, failure_reason, master_playlist_key FROM videos WHERE user_id = $1 ORDER BY created_at
+`
+
+// every video of a user, including removed ones
+func (q *Queries) ListAllUserVideos(ctx context.Context, userID uuid.UUID) ([]Video, error) {
+	rows, err := q.db.Query(ctx, listAllUserVideos, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Video
+	for rows.Next() {
+		var i Video
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Title,
+			&i.Description,
+			&i.Bucket,
+			&i.Key,
+			&i.Status,
+			&i.FileSizeBytes,
+			&i.ContentType,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.ParentVideoID,
+			&i.Recipe,
+			&i.ColorPrimaries,
+			&i.ColorTransfer,
+			&i.ColorSpace,
+			&i.HdrFormat,
+			&i.Projection,
+			&i.StereoMode,
+			&i.Visibility,
+			&i.PublishedAt,
+			&i.AgeRestricted,
+			&i.PublishAt,
+			&i.ExpiresAt,
+			&i.PurgeOnExpiry,
+			&i.PlaybackPasswordHash,
+			&i.SourceWidth,
+			&i.SourceHeight,
+			&i.DurationMs,
+			&i.SourceCodec,
+			&i.SourceFps,
+			&i.AudioChannels,
+			&i.Container,
+			&i.FailureReason,
+			&i.MasterPlaylistKey,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUserVideos = `-- name: ListUserVideos :many
+SELECT
+    v.id,
+    v.parent_video_id,
+    v.title,
+    v.description,
+    v.status,
+    v.visibility,
+    v.created_at,
+    v.duration_ms,
+    p.bucket AS preview_bucket,
+    p.key AS preview_key,
+    a.bucket AS animated_preview_bucket,
+    a.key AS animated_preview_key
+FROM videos v
+LEFT JOIN video_assets p ON p.video_id = v.id AND p.kind = 'preview'
+LEFT JOIN video_assets a ON a.video_id = v.id AND a.kind = 'animated_preview'
+WHERE v.user_id = $1 AND v.visibility <> 'removed'
+ORDER BY v.created_at DESC
+LIMIT $2 OFFSET $3
+`
+
+type ListUserVideosParams struct {
+	UserID uuid.UUID `json:"user_id"`
+	Limit  int32     `json:"limit"`
+	Offset int32     `json:"offset"`
+}
+
+type ListUserVideosRow struct {
+	ID                    uuid.UUID          `json:"id"`
+	ParentVideoID         pgtype.UUID        `json:"parent_video_id"`
+	Title                 string             `json:"title"`
+	Description           string             `json:"description"`
+	Status                string             `json:"status"`
+	Visibility            string             `json:"visibility"`
+	CreatedAt             pgtype.Timestamptz `json:"created_at"`
+	DurationMs            pgtype.Int8        `json:"duration_ms"`
+	PreviewBucket         pgtype.Text        `json:"preview_bucket"`
+	PreviewKey            pgtype.Text        `json:"preview_key"`
+	AnimatedPreviewBucket pgtype.Text        `json:"animated_preview_bucket"`
+	AnimatedPreviewKey    pgtype.Text        `json:"animated_preview_key"`
+}
+
+func (q *Queries) ListUserVideos(ctx context.Context, arg ListUserVideosParams) ([]ListUserVideosRow, error) {
+	rows, err := q.db.Query(ctx, listUserVideos, arg.UserID, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListUserVideosRow
+	for rows.Next() {
+		var i ListUserVideosRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.ParentVideoID,
+			&i.Title,
+			&i.Description,
+			&i.Status,
+			&i.Visibility,
+			&i.CreatedAt,
+			&i.DurationMs,
+			&i.PreviewBucket,
+			&i.PreviewKey,
+			&i.AnimatedPreviewBucket,
+			&i.AnimatedPreviewKey,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listVariantQualityReport = `-- name: ListVariantQualityReport :many
+SELECT
+    variant_name,
+    COUNT(*) AS videos,
+    AVG(bitrate_kbps)::float8 AS avg_bitrate_kbps,
+    AVG(vmaf)::float8 AS avg_vmaf,
+    MIN(vmaf)::float8 AS min_vmaf,
+    AVG(psnr)::float8 AS avg_psnr
+FROM video_variants
+WHERE vmaf IS NOT NULL AND psnr IS NOT NULL AND format = 'mp4'
+GROUP BY variant_name
+ORDER BY MAX(height) DESC, variant_name
+`
+
+type ListVariantQualityReportRow struct {
+	VariantName    string  `json:"variant_name"`
+	Videos         int64   `json:"videos"`
+	AvgBitrateKbps float64 `json:"avg_bitrate_kbps"`
+	AvgVmaf        float64 `json:"avg_vmaf"`
+	MinVmaf        float64 `json:"min_vmaf"`
+	AvgPsnr        float64 `json:"avg_psnr"`
+}
+
+func (q *Queries) ListVariantQualityReport(ctx context.Context) ([]ListVariantQualityReportRow, error) {
+	rows, err := q.db.Query(ctx, listVariantQualityReport)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListVariantQualityReportRow
+	for rows.Next() {
+		var i ListVariantQualityReportRow
+		if err := rows.Scan(
+			&i.VariantName,
+			&i.Videos,
+			&i.AvgBitrateKbps,
+			&i.AvgVmaf,
+			&i.MinVmaf,
+			&i.AvgPsnr,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listVideoAssets = `-- name: ListVideoAssets :many
+SELECT id, video_id, kind, bucket, key, content_type, created_at FROM video_assets WHERE video_id = $1 ORDER BY kind
+`
+
+func (q *Queries) ListVideoAssets(ctx context.Context, videoID uuid.UUID) ([]VideoAsset, error) {
+	rows, err := q.db.Query(ctx, listVideoAssets, videoID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []VideoAsset
+	for rows.Next() {
+		var i VideoAsset
+		if err := rows.Scan(
+			&i.ID,
+			&i.VideoID,
+			&i.Kind,
+			&i.Bucket,
+			&i.Key,
+			&i.ContentType,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listVideoChapters = `-- name: ListVideoChapters :many
+SELECT id, video_id, title, start_ms, end_ms, source, created_at FROM video_chapters WHERE video_id = $1 ORDER BY start_ms
+`
+
+func (q *Queries) ListVideoChapters(ctx context.Context, videoID uuid.UUID) ([]VideoChapter, error) {
+	rows, err := q.db.Query(ctx, listVideoChapters, videoID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []VideoChapter
+	for rows.Next() {
+		var i VideoChapter
+		if err := rows.Scan(
+			&i.ID,
+			&i.VideoID,
+			&i.Title,
+			&i.StartMs,
+			&i.EndMs,
+			&i.Source,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listVideoFingerprints = `-- name: ListVideoFingerprints :many
+SELECT f.video_id, f.frame_hashes, v.user_id, v.title
+FROM video_fingerprints f
+JOIN videos v ON v.id = f.video_id
+ORDER BY f.created_at
+`
+
+type ListVideoFingerprintsRow struct {
+	VideoID     uuid.UUID `json:"video_id"`
+	FrameHashes []int64   `json:"frame_hashes"`
+	UserID      uuid.UUID `json:"user_id"`
+	Title       string    `json:"title"`
+}
+
+func (q *Queries) ListVideoFingerprints(ctx context.Context) ([]ListVideoFingerprintsRow, error) {
+	rows, err := q.db.Query(ctx, listVideoFingerprints)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListVideoFingerprintsRow
+	for rows.Next() {
+		var i ListVideoFingerprintsRow
+		if err := rows.Scan(
+			&i.VideoID,
+			&i.FrameHashes,
+			&i.UserID,
+			&i.Title,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listVideoSubtitles = `-- name: ListVideoSubtitles :many
+SELECT video_id, track, language, label, forced, bucket, key, created_at FROM video_subtitles WHERE video_id = $1 ORDER BY track
 `
 
-func (q *Queries) DeleteVideo(ctx context.Context, id uuid.UUID) (Video, error) {
-	row := q.db.QueryRow(ctx, deleteVideo, id)
-	var i Video
-	err := row.Scan(
-		&i.ID,
-		&i.UserID,
-		&i.Title,
-		&i.Description,
-		&i.Bucket,
-		&i.Key,
-		&i.Status,
-		&i.FileSizeBytes,
-		&i.ContentType,
-		&i.CreatedAt,
-		&i.UpdatedAt,
-	)
-	return i, err
+func (q *Queries) ListVideoSubtitles(ctx context.Context, videoID uuid.UUID) ([]VideoSubtitle, error) {
+	rows, err := q.db.Query(ctx, listVideoSubtitles, videoID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []VideoSubtitle
+	for rows.Next() {
+		var i VideoSubtitle
+		if err := rows.Scan(
+			&i.VideoID,
+			&i.Track,
+			&i.Language,
+			&i.Label,
+			&i.Forced,
+			&i.Bucket,
+			&i.Key,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
-const getVideo = `-- name: GetVideo :one
-SELECT id, user_id, title, description, bucket, key, status, file_size_bytes, content_type, created_at, updated_at FROM videos WHERE id = $1
+const listVideoVariants = `-- name: ListVideoVariants :many
+SELECT id, video_id, variant_name, bucket, key, content_type, created_at, hls_playlist_key, thumbnail_key, width, height, bitrate_kbps, vmaf, psnr, format, audio_languages FROM video_variants WHERE video_id = $1 ORDER BY height DESC, variant_name, format
 `
 
-func (q *Queries) GetVideo(ctx context.Context, id uuid.UUID) (Video, error) {
-	row := q.db.QueryRow(ctx, getVideo, id)
-	var i Video
-	err := row.Scan(
-		&i.ID,
-		&i.UserID,
-		&i.Title,
-		&i.Description,
-		&i.Bucket,
-		&i.Key,
-		&i.Status,
-		&i.FileSizeBytes,
-		&i.ContentType,
-		&i.CreatedAt,
-		&i.UpdatedAt,
-	)
-	return i, err
+func (q *Queries) ListVideoVariants(ctx context.Context, videoID uuid.UUID) ([]VideoVariant, error) {
+	rows, err := q.db.Query(ctx, listVideoVariants, videoID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []VideoVariant
+	for rows.Next() {
+		var i VideoVariant
+		if err := rows.Scan(
+			&i.ID,
+			&i.VideoID,
+			&i.VariantName,
+			&i.Bucket,
+			&i.Key,
+			&i.ContentType,
+			&i.CreatedAt,
+			&i.HlsPlaylistKey,
+			&i.ThumbnailKey,
+			&i.Width,
+			&i.Height,
+			&i.BitrateKbps,
+			&i.Vmaf,
+			&i.Psnr,
+			&i.Format,
+			&i.AudioLanguages,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
 const listVideos = `-- name: ListVideos :many
-SELECT id, user_id, title, description, bucket, key, status, file_size_bytes, content_type, created_at, updated_at FROM videos ORDER BY created_at DESC
+SELECT id, user_id, title, description, bucket, key, status, file_size_bytes, content_type, created_at, updated_at, parent_video_id, recipe, color_primaries, color_transfer, color_space, hdr_format, projection, stereo_mode, visibility, published_at, age_restricted, publish_at, expires_at, purge_on_expiry, playback_password_hash, source_width, source_height, duration_ms, source_codec, source_fps, audio_channels, container, failure_reason, master_playlist_key FROM videos ORDER BY created_at DESC
 `
 
 func (q *Queries) ListVideos(ctx context.Context) ([]Video, error) {
@@ -132,6 +884,30 @@ func (q *Queries) ListVideos(ctx context.Context) ([]Video, error) {
 			&i.ContentType,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.ParentVideoID,
+			&i.Recipe,
+			&i.ColorPrimaries,
+			&i.ColorTransfer,
+			&i.ColorSpace,
+			&i.HdrFormat,
+			&i.Projection,
+			&i.StereoMode,
+			&i.Visibility,
+			&i.PublishedAt,
+			&i.AgeRestricted,
+			&i.PublishAt,
+			&i.ExpiresAt,
+			&i.PurgeOnExpiry,
+			&i.PlaybackPasswordHash,
+			&i.SourceWidth,
+			&i.SourceHeight,
+			&i.DurationMs,
+			&i.SourceCodec,
+			&i.SourceFps,
+			&i.AudioChannels,
+			&i.Container,
+			&i.FailureReason,
+			&i.MasterPlaylistKey,
 		); err != nil {
 			return nil, err
 		}
@@ -143,6 +919,52 @@ func (q *Queries) ListVideos(ctx context.Context) ([]Video, error) {
 	return items, nil
 }
 
+const publishDueVideos = `-- name: PublishDueVideos :many
+WITH due AS (
+    SELECT id, published_at IS NULL AS first_publication
+    FROM videos
+    WHERE publish_at <= CURRENT_TIMESTAMP AND visibility = 'private'
+    ORDER BY publish_at
+    LIMIT $1
+    FOR UPDATE SKIP LOCKED
+)
+UPDATE videos v
+SET
+    visibility = 'public',
+    published_at = COALESCE(v.published_at, CURRENT_TIMESTAMP),
+    publish_at = NULL
+FROM due
+WHERE v.id = due.id
+RETURNING v.id, v.user_id, due.first_publication
+`
+
+type PublishDueVideosRow struct {
+	ID               uuid.UUID `json:"id"`
+	UserID           uuid.UUID `json:"user_id"`
+	FirstPublication bool      `json:"first_publication"`
+}
+
+// publishes private videos whose publish time has come; instances skip each other's rows
+func (q *Queries) PublishDueVideos(ctx context.Context, limit int32) ([]PublishDueVideosRow, error) {
+	rows, err := q.db.Query(ctx, publishDueVideos, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PublishDueVideosRow
+	for rows.Next() {
+		var i PublishDueVideosRow
+		if err := rows.Scan(&i.ID, &i.UserID, &i.FirstPublication); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const saveProcessedVideoMetadata = `-- name: SaveProcessedVideoMetadata :one
 INSERT INTO video_variants (
     video_id,
@@ -154,9 +976,11 @@ INSERT INTO video_variants (
     thumbnail_key,
     width,
     height,
-    bitrate_kbps
-) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) 
-ON CONFLICT (video_id, variant_name) 
+    bitrate_kbps,
+    format,
+    audio_languages
+) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, COALESCE($12::text[], '{}')) 
+ON CONFLICT (video_id, variant_name, format) 
 DO UPDATE SET 
     bucket = EXCLUDED.bucket,
     key = EXCLUDED.key,
@@ -165,8 +989,9 @@ DO UPDATE SET
     thumbnail_key = EXCLUDED.thumbnail_key,
     width = EXCLUDED.width,
     height = EXCLUDED.height,
-    bitrate_kbps = EXCLUDED.bitrate_kbps
-RETURNING id, video_id, variant_name, bucket, key, content_type, created_at, hls_playlist_key, thumbnail_key, width, height, bitrate_kbps
+    bitrate_kbps = EXCLUDED.bitrate_kbps,
+    audio_languages = EXCLUDED.audio_languages
+RETURNING id, video_id, variant_name, bucket, key, content_type, created_at, hls_playlist_key, thumbnail_key, width, height, bitrate_kbps, vmaf, psnr, format, audio_languages
 `
 
 type SaveProcessedVideoMetadataParams struct {
@@ -180,6 +1005,8 @@ type SaveProcessedVideoMetadataParams struct {
 	Width          pgtype.Int4 `json:"width"`
 	Height         pgtype.Int4 `json:"height"`
 	BitrateKbps    pgtype.Int4 `json:"bitrate_kbps"`
+	Format         string      `json:"format"`
+	AudioLanguages []string    `json:"audio_languages"`
 }
 
 func (q *Queries) SaveProcessedVideoMetadata(ctx context.Context, arg SaveProcessedVideoMetadataParams) (VideoVariant, error) {
@@ -194,6 +1021,8 @@ func (q *Queries) SaveProcessedVideoMetadata(ctx context.Context, arg SaveProces
 		arg.Width,
 		arg.Height,
 		arg.BitrateKbps,
+		arg.Format,
+		arg.AudioLanguages,
 	)
 	var i VideoVariant
 	err := row.Scan(
@@ -209,10 +1038,322 @@ func (q *Queries) SaveProcessedVideoMetadata(ctx context.Context, arg SaveProces
 		&i.Width,
 		&i.Height,
 		&i.BitrateKbps,
+		&i.Vmaf,
+		&i.Psnr,
+		&i.Format,
+		&i.AudioLanguages,
+	)
+	return i, err
+}
+
+const saveVideoAsset = `-- name: SaveVideoAsset :one
+INSERT INTO video_assets (
+    video_id,
+    kind,
+    bucket,
+    key,
+    content_type
+) VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (video_id, kind)
+DO UPDATE SET
+    bucket = EXCLUDED.bucket,
+    key = EXCLUDED.key,
+    content_type = EXCLUDED.content_type
+RETURNING id, video_id, kind, bucket, key, content_type, created_at
+`
+
+type SaveVideoAssetParams struct {
+	VideoID     uuid.UUID `json:"video_id"`
+	Kind        string    `json:"kind"`
+	Bucket      string    `json:"bucket"`
+	Key         string    `json:"key"`
+	ContentType string    `json:"content_type"`
+}
+
+func (q *Queries) SaveVideoAsset(ctx context.Context, arg SaveVideoAssetParams) (VideoAsset, error) {
+	row := q.db.QueryRow(ctx, saveVideoAsset,
+		arg.VideoID,
+		arg.Kind,
+		arg.Bucket,
+		arg.Key,
+		arg.ContentType,
+	)
+	var i VideoAsset
+	err := row.Scan(
+		&i.ID,
+		&i.VideoID,
+		&i.Kind,
+		&i.Bucket,
+		&i.Key,
+		&i.ContentType,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const saveVideoFingerprint = `-- name: SaveVideoFingerprint :exec
+INSERT INTO video_fingerprints (
+    video_id,
+    frame_hashes
+) VALUES ($1, $2)
+ON CONFLICT (video_id)
+DO UPDATE SET
+    frame_hashes = EXCLUDED.frame_hashes,
+    created_at = CURRENT_TIMESTAMP
+`
+
+type SaveVideoFingerprintParams struct {
+	VideoID     uuid.UUID `json:"video_id"`
+	FrameHashes []int64   `json:"frame_hashes"`
+}
+
+func (q *Queries) SaveVideoFingerprint(ctx context.Context, arg SaveVideoFingerprintParams) error {
+	_, err := q.db.Exec(ctx, saveVideoFingerprint, arg.VideoID, arg.FrameHashes)
+	return err
+}
+
+const saveVideoProbe = `-- name: SaveVideoProbe :exec
+INSERT INTO video_probes (
+    video_id,
+    probe
+) VALUES ($1, $2)
+ON CONFLICT (video_id)
+DO UPDATE SET
+    probe = EXCLUDED.probe,
+    probed_at = CURRENT_TIMESTAMP
+`
+
+type SaveVideoProbeParams struct {
+	VideoID uuid.UUID `json:"video_id"`
+	Probe   []byte    `json:"probe"`
+}
+
+func (q *Queries) SaveVideoProbe(ctx context.Context, arg SaveVideoProbeParams) error {
+	_, err := q.db.Exec(ctx, saveVideoProbe, arg.VideoID, arg.Probe)
+	return err
+}
+
+const saveVideoSubtitle = `-- name: SaveVideoSubtitle :one
+INSERT INTO video_subtitles (
+    video_id,
+    track,
+    language,
+    label,
+    forced,
+    bucket,
+    key
+) VALUES ($1, $2, $3, $4, $5, $6, $7)
+ON CONFLICT (video_id, track)
+DO UPDATE SET
+    language = EXCLUDED.language,
+    label = EXCLUDED.label,
+    forced = EXCLUDED.forced,
+    bucket = EXCLUDED.bucket,
+    key = EXCLUDED.key,
+    created_at = CURRENT_TIMESTAMP
+RETURNING video_id, track, language, label, forced, bucket, key, created_at
+`
+
+type SaveVideoSubtitleParams struct {
+	VideoID  uuid.UUID `json:"video_id"`
+	Track    int32     `json:"track"`
+	Language string    `json:"language"`
+	Label    string    `json:"label"`
+	Forced   bool      `json:"forced"`
+	Bucket   string    `json:"bucket"`
+	Key      string    `json:"key"`
+}
+
+func (q *Queries) SaveVideoSubtitle(ctx context.Context, arg SaveVideoSubtitleParams) (VideoSubtitle, error) {
+	row := q.db.QueryRow(ctx, saveVideoSubtitle,
+		arg.VideoID,
+		arg.Track,
+		arg.Language,
+		arg.Label,
+		arg.Forced,
+		arg.Bucket,
+		arg.Key,
+	)
+	var i VideoSubtitle
+	err := row.Scan(
+		&i.VideoID,
+		&i.Track,
+		&i.Language,
+		&i.Label,
+		&i.Forced,
+		&i.Bucket,
+		&i.Key,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const setVideoMasterPlaylist = `-- name: SetVideoMasterPlaylist :exec
+UPDATE videos
+SET
+    master_playlist_key = $2
+WHERE id = $1
+`
+
+type SetVideoMasterPlaylistParams struct {
+	ID                uuid.UUID   `json:"id"`
+	MasterPlaylistKey pgtype.Text `json:"master_playlist_key"`
+}
+
+// points the video at the master playlist of its current rendition set
+func (q *Queries) SetVideoMasterPlaylist(ctx context.Context, arg SetVideoMasterPlaylistParams) error {
+	_, err := q.db.Exec(ctx, setVideoMasterPlaylist, arg.ID, arg.MasterPlaylistKey)
+	return err
+}
+
+const setVideoSchedule = `-- name: SetVideoSchedule :one
+UPDATE videos
+SET
+    publish_at = $1,
+    expires_at = $2,
+    purge_on_expiry = $3
+WHERE id = $4 RETURNING id, user_id, title, description, bucket, key, status, file_size_bytes, content_type, created_at, updated_at, parent_video_id, recipe, color_primaries, color_transfer, color_space, hdr_format, projection, stereo_mode, visibility, published_at, age_restricted, publish_at, expires_at, purge_on_expiry, playback_password_hash, source_width, source_height, duration_ms, source_codec, source_fps, audio_channels, container, failure_reason, master_playlist_key
+`
+
+type SetVideoScheduleParams struct {
+	PublishAt     pgtype.Timestamptz `json:"publish_at"`
+	ExpiresAt     pgtype.Timestamptz `json:"expires_at"`
+	PurgeOnExpiry bool               `json:"purge_on_expiry"`
+	ID            uuid.UUID          `json:"id"`
+}
+
+func (q *Queries) SetVideoSchedule(ctx context.Context, arg SetVideoScheduleParams) (Video, error) {
+	row := q.db.QueryRow(ctx, setVideoSchedule,
+		arg.PublishAt,
+		arg.ExpiresAt,
+		arg.PurgeOnExpiry,
+		arg.ID,
+	)
+	var i Video
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Title,
+		&i.Description,
+		&i.Bucket,
+		&i.Key,
+		&i.Status,
+		&i.FileSizeBytes,
+		&i.ContentType,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.ParentVideoID,
+		&i.Recipe,
+		&i.ColorPrimaries,
+		&i.ColorTransfer,
+		&i.ColorSpace,
+		&i.HdrFormat,
+		&i.Projection,
+		&i.StereoMode,
+		&i.Visibility,
+		&i.PublishedAt,
+		&i.AgeRestricted,
+		&i.PublishAt,
+		&i.ExpiresAt,
+		&i.PurgeOnExpiry,
+		&i.PlaybackPasswordHash,
+		&i.SourceWidth,
+		&i.SourceHeight,
+		&i.DurationMs,
+		&i.SourceCodec,
+		&i.SourceFps,
+		&i.AudioChannels,
+		&i.Container,
+		&i.FailureReason,
+		&i.MasterPlaylistKey,
+	)
+	return i, err
+}
+
+const setVideoVisibility = `-- name: SetVideoVisibility :one
+UPDATE videos
+SET
+    visibility = $1,
+    published_at = CASE WHEN $1 = 'public' THEN COALESCE(published_at, CURRENT_TIMESTAMP) ELSE published_at END,
+    publish_at = CASE WHEN $1 = 'public' THEN NULL ELSE publish_at END
+WHERE id = $2 RETURNING id, user_id, title, description, bucket, key, status, file_size_bytes, content_type, created_at, updated_at, parent_video_id, recipe, color_primaries, color_transfer, color_space, hdr_format, projection, stereo_mode, visibility, published_at, age_restricted, publish_at, expires_at, purge_on_expiry, playback_password_hash, source_width, source_height, duration_ms, source_codec, source_fps, audio_channels, container, failure_reason, master_playlist_key
+`
+
+type SetVideoVisibilityParams struct {
+	Visibility string    `json:"visibility"`
+	ID         uuid.UUID `json:"id"`
+}
+
+// published_at keeps the first publication, so publishing again does not notify again.
+// Publishing cancels a scheduled publication.
+func (q *Queries) SetVideoVisibility(ctx context.Context, arg SetVideoVisibilityParams) (Video, error) {
+	row := q.db.QueryRow(ctx, setVideoVisibility, arg.Visibility, arg.ID)
+	var i Video
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Title,
+		&i.Description,
+		&i.Bucket,
+		&i.Key,
+		&i.Status,
+		&i.FileSizeBytes,
+		&i.ContentType,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.ParentVideoID,
+		&i.Recipe,
+		&i.ColorPrimaries,
+		&i.ColorTransfer,
+		&i.ColorSpace,
+		&i.HdrFormat,
+		&i.Projection,
+		&i.StereoMode,
+		&i.Visibility,
+		&i.PublishedAt,
+		&i.AgeRestricted,
+		&i.PublishAt,
+		&i.ExpiresAt,
+		&i.PurgeOnExpiry,
+		&i.PlaybackPasswordHash,
+		&i.SourceWidth,
+		&i.SourceHeight,
+		&i.DurationMs,
+		&i.SourceCodec,
+		&i.SourceFps,
+		&i.AudioChannels,
+		&i.Container,
+		&i.FailureReason,
+		&i.MasterPlaylistKey,
 	)
 	return i, err
 }
 
+const updateVariantQuality = `-- name: UpdateVariantQuality :exec
+UPDATE video_variants
+SET
+    vmaf = $1,
+    psnr = $2
+WHERE video_id = $3 AND variant_name = $4 AND format = 'mp4'
+`
+
+type UpdateVariantQualityParams struct {
+	Vmaf        pgtype.Float8 `json:"vmaf"`
+	Psnr        pgtype.Float8 `json:"psnr"`
+	VideoID     uuid.UUID     `json:"video_id"`
+	VariantName string        `json:"variant_name"`
+}
+
+func (q *Queries) UpdateVariantQuality(ctx context.Context, arg UpdateVariantQualityParams) error {
+	_, err := q.db.Exec(ctx, updateVariantQuality,
+		arg.Vmaf,
+		arg.Psnr,
+		arg.VideoID,
+		arg.VariantName,
+	)
+	return err
+}
+
 const updateVideo = `-- name: UpdateVideo :one
 UPDATE videos
 SET 
@@ -222,7 +1363,7 @@ SET
     key = COALESCE(NULLIF($4, ''), key),
     file_size_bytes = COALESCE(NULLIF($5, 0), file_size_bytes),
     content_type = COALESCE(NULLIF($6, ''), content_type)
-WHERE id = $1 RETURNING id, user_id, title, description, bucket, key, status, file_size_bytes, content_type, created_at, updated_at
+WHERE id = $1 RETURNING id, user_id, title, description, bucket, key, status, file_size_bytes, content_type, created_at, updated_at, parent_video_id, recipe, color_primaries, color_transfer, color_space, hdr_format, projection, stereo_mode, visibility, published_at, age_restricted, publish_at, expires_at, purge_on_expiry, playback_password_hash, source_width, source_height, duration_ms, source_codec, source_fps, audio_channels, container, failure_reason, master_playlist_key
 `
 
 type UpdateVideoParams struct {
@@ -256,15 +1397,155 @@ func (q *Queries) UpdateVideo(ctx context.Context, arg UpdateVideoParams) (Video
 		&i.ContentType,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.ParentVideoID,
+		&i.Recipe,
+		&i.ColorPrimaries,
+		&i.ColorTransfer,
+		&i.ColorSpace,
+		&i.HdrFormat,
+		&i.Projection,
+		&i.StereoMode,
+		&i.Visibility,
+		&i.PublishedAt,
+		&i.AgeRestricted,
+		&i.PublishAt,
+		&i.ExpiresAt,
+		&i.PurgeOnExpiry,
+		&i.PlaybackPasswordHash,
+		&i.SourceWidth,
+		&i.SourceHeight,
+		&i.DurationMs,
+		&i.SourceCodec,
+		&i.SourceFps,
+		&i.AudioChannels,
+		&i.Container,
+		&i.FailureReason,
+		&i.MasterPlaylistKey,
 	)
 	return i, err
 }
 
+const updateVideoColorMetadata = `-- name: UpdateVideoColorMetadata :exec
+UPDATE videos
+SET
+    color_primaries = $1,
+    color_transfer = $2,
+    color_space = $3,
+    hdr_format = $4
+WHERE id = $5
+`
+
+type UpdateVideoColorMetadataParams struct {
+	ColorPrimaries pgtype.Text `json:"color_primaries"`
+	ColorTransfer  pgtype.Text `json:"color_transfer"`
+	ColorSpace     pgtype.Text `json:"color_space"`
+	HdrFormat      pgtype.Text `json:"hdr_format"`
+	ID             uuid.UUID   `json:"id"`
+}
+
+func (q *Queries) UpdateVideoColorMetadata(ctx context.Context, arg UpdateVideoColorMetadataParams) error {
+	_, err := q.db.Exec(ctx, updateVideoColorMetadata,
+		arg.ColorPrimaries,
+		arg.ColorTransfer,
+		arg.ColorSpace,
+		arg.HdrFormat,
+		arg.ID,
+	)
+	return err
+}
+
+const updateVideoFileSize = `-- name: UpdateVideoFileSize :exec
+UPDATE videos
+SET
+    file_size_bytes = $1
+WHERE id = $2
+`
+
+type UpdateVideoFileSizeParams struct {
+	FileSizeBytes int64     `json:"file_size_bytes"`
+	ID            uuid.UUID `json:"id"`
+}
+
+func (q *Queries) UpdateVideoFileSize(ctx context.Context, arg UpdateVideoFileSizeParams) error {
+	_, err := q.db.Exec(ctx, updateVideoFileSize, arg.FileSizeBytes, arg.ID)
+	return err
+}
+
+const updateVideoProjection = `-- name: UpdateVideoProjection :exec
+UPDATE videos
+SET
+    projection = $1,
+    stereo_mode = $2
+WHERE id = $3
+`
+
+type UpdateVideoProjectionParams struct {
+	Projection pgtype.Text `json:"projection"`
+	StereoMode pgtype.Text `json:"stereo_mode"`
+	ID         uuid.UUID   `json:"id"`
+}
+
+func (q *Queries) UpdateVideoProjection(ctx context.Context, arg UpdateVideoProjectionParams) error {
+	_, err := q.db.Exec(ctx, updateVideoProjection, arg.Projection, arg.StereoMode, arg.ID)
+	return err
+}
+
+const updateVideoSourceMetadata = `-- name: UpdateVideoSourceMetadata :exec
+UPDATE videos
+SET
+    duration_ms = $1,
+    source_codec = $2,
+    source_fps = $3,
+    audio_channels = $4,
+    container = $5
+WHERE id = $6
+`
+
+type UpdateVideoSourceMetadataParams struct {
+	DurationMs    pgtype.Int8   `json:"duration_ms"`
+	SourceCodec   pgtype.Text   `json:"source_codec"`
+	SourceFps     pgtype.Float8 `json:"source_fps"`
+	AudioChannels pgtype.Int4   `json:"audio_channels"`
+	Container     pgtype.Text   `json:"container"`
+	ID            uuid.UUID     `json:"id"`
+}
+
+func (q *Queries) UpdateVideoSourceMetadata(ctx context.Context, arg UpdateVideoSourceMetadataParams) error {
+	_, err := q.db.Exec(ctx, updateVideoSourceMetadata,
+		arg.DurationMs,
+		arg.SourceCodec,
+		arg.SourceFps,
+		arg.AudioChannels,
+		arg.Container,
+		arg.ID,
+	)
+	return err
+}
+
+const updateVideoSourceResolution = `-- name: UpdateVideoSourceResolution :exec
+UPDATE videos
+SET
+    source_width = $1,
+    source_height = $2
+WHERE id = $3
+`
+
+type UpdateVideoSourceResolutionParams struct {
+	SourceWidth  pgtype.Int4 `json:"source_width"`
+	SourceHeight pgtype.Int4 `json:"source_height"`
+	ID           uuid.UUID   `json:"id"`
+}
+
+func (q *Queries) UpdateVideoSourceResolution(ctx context.Context, arg UpdateVideoSourceResolutionParams) error {
+	_, err := q.db.Exec(ctx, updateVideoSourceResolution, arg.SourceWidth, arg.SourceHeight, arg.ID)
+	return err
+}
+
 const updateVideoStatus = `-- name: UpdateVideoStatus :one
 UPDATE videos
 SET 
     status = $1
-WHERE id = $2 RETURNING id, user_id, title, description, bucket, key, status, file_size_bytes, content_type, created_at, updated_at
+WHERE id = $2 RETURNING id, user_id, title, description, bucket, key, status, file_size_bytes, content_type, created_at, updated_at, parent_video_id, recipe, color_primaries, color_transfer, color_space, hdr_format, projection, stereo_mode, visibility, published_at, age_restricted, publish_at, expires_at, purge_on_expiry, playback_password_hash, source_width, source_height, duration_ms, source_codec, source_fps, audio_channels, container, failure_reason, master_playlist_key
 `
 
 type UpdateVideoStatusParams struct {
@@ -287,6 +1568,30 @@ func (q *Queries) UpdateVideoStatus(ctx context.Context, arg UpdateVideoStatusPa
 		&i.ContentType,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.ParentVideoID,
+		&i.Recipe,
+		&i.ColorPrimaries,
+		&i.ColorTransfer,
+		&i.ColorSpace,
+		&i.HdrFormat,
+		&i.Projection,
+		&i.StereoMode,
+		&i.Visibility,
+		&i.PublishedAt,
+		&i.AgeRestricted,
+		&i.PublishAt,
+		&i.ExpiresAt,
+		&i.PurgeOnExpiry,
+		&i.PlaybackPasswordHash,
+		&i.SourceWidth,
+		&i.SourceHeight,
+		&i.DurationMs,
+		&i.SourceCodec,
+		&i.SourceFps,
+		&i.AudioChannels,
+		&i.Container,
+		&i.FailureReason,
+		&i.MasterPlaylistKey,
 	)
 	return i, err
 }
@@ -0,0 +1,64 @@
+-- name: CreateDataExport :one
+-- no row when the user has an export in progress
+INSERT INTO data_exports (user_id) VALUES ($1)
+ON CONFLICT (user_id) WHERE status IN ('pending', 'running') DO NOTHING
+RETURNING *;
+
+-- name: GetActiveDataExport :one
+SELECT * FROM data_exports WHERE user_id = $1 AND status IN ('pending', 'running');
+
+-- name: GetDataExport :one
+SELECT * FROM data_exports WHERE id = $1 AND user_id = $2;
+
+-- name: ClaimDataExport :one
+-- takes over the oldest pending export, or one whose instance stopped writing it
+UPDATE data_exports
+SET
+    status = 'running',
+    lease_until = sqlc.arg('lease_until')::timestamptz
+WHERE id = (
+    SELECT id FROM data_exports
+    WHERE status = 'pending' OR (status = 'running' AND lease_until < CURRENT_TIMESTAMP)
+    ORDER BY created_at
+    LIMIT 1
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: CompleteDataExport :one
+UPDATE data_exports
+SET
+    status = 'completed',
+    bucket = $1,
+    key = $2,
+    size_bytes = $3,
+    expires_at = $4,
+    lease_until = NULL,
+    finished_at = CURRENT_TIMESTAMP
+WHERE id = $5 RETURNING *;
+
+-- name: FailDataExport :exec
+UPDATE data_exports
+SET
+    status = 'failed',
+    error = $1,
+    lease_until = NULL,
+    finished_at = CURRENT_TIMESTAMP
+WHERE id = $2;
+
+-- name: ExpireDataExports :many
+-- marks expired archives; instances skip each other's rows
+WITH due AS (
+    SELECT id
+    FROM data_exports
+    WHERE status = 'completed' AND expires_at <= CURRENT_TIMESTAMP
+    ORDER BY expires_at
+    LIMIT $1
+    FOR UPDATE SKIP LOCKED
+)
+UPDATE data_exports e
+SET
+    status = 'expired'
+FROM due
+WHERE e.id = due.id
+RETURNING e.id, e.bucket, e.key;
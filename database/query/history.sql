@@ -0,0 +1,41 @@
+-- name: SaveWatchPosition :one
+INSERT INTO watch_history (
+    user_id,
+    video_id,
+    position_ms,
+    duration_ms
+) VALUES ($1, $2, $3, $4)
+ON CONFLICT (user_id, video_id)
+DO UPDATE SET
+    position_ms = EXCLUDED.position_ms,
+    duration_ms = EXCLUDED.duration_ms,
+    watched_at = CURRENT_TIMESTAMP
+RETURNING *;
+
+-- name: GetWatchPosition :one
+SELECT * FROM watch_history WHERE user_id = $1 AND video_id = $2;
+
+-- name: ListWatchHistory :many
+SELECT
+    h.video_id,
+    v.title,
+    h.position_ms,
+    h.duration_ms,
+    h.watched_at,
+    p.bucket AS preview_bucket,
+    p.key AS preview_key,
+    a.bucket AS animated_preview_bucket,
+    a.key AS animated_preview_key
+FROM watch_history h
+JOIN videos v ON v.id = h.video_id
+LEFT JOIN video_assets p ON p.video_id = h.video_id AND p.kind = 'preview'
+LEFT JOIN video_assets a ON a.video_id = h.video_id AND a.kind = 'animated_preview'
+WHERE h.user_id = $1 AND (v.user_id = h.user_id OR v.visibility = 'public')
+ORDER BY h.watched_at DESC
+LIMIT $2 OFFSET $3;
+
+-- name: DeleteWatchHistoryEntry :exec
+DELETE FROM watch_history WHERE user_id = $1 AND video_id = $2;
+
+-- name: ClearWatchHistory :exec
+DELETE FROM watch_history WHERE user_id = $1;
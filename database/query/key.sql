@@ -0,0 +1,10 @@
+-- name: CreateVideoKey :one
+-- CreateVideoKey stores the HLS key of a video, or returns the key it already has so the
+-- segments of reprocessed and archived renditions share it
+INSERT INTO video_keys (video_id, key) VALUES ($1, $2)
+ON CONFLICT (video_id) DO UPDATE SET video_id = EXCLUDED.video_id
+RETURNING key;
+
+-- name: GetVideoKey :one
+SELECT key FROM video_keys WHERE video_id = $1;
+
@@ -0,0 +1,58 @@
+-- name: CreateVideoReport :one
+INSERT INTO video_reports (
+    video_id,
+    reporter_id,
+    reason,
+    details
+) VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: GetVideoReport :one
+SELECT * FROM video_reports WHERE id = $1;
+
+-- name: ListVideoReports :many
+-- the moderation queue, oldest report first
+SELECT
+    r.id,
+    r.video_id,
+    r.reporter_id,
+    r.reason,
+    r.details,
+    r.status,
+    r.action,
+    r.resolved_by,
+    r.resolved_at,
+    r.created_at,
+    v.user_id AS owner_id,
+    v.title,
+    v.visibility,
+    v.age_restricted
+FROM video_reports r
+JOIN videos v ON v.id = r.video_id
+WHERE r.status = $1
+ORDER BY r.created_at
+LIMIT $2 OFFSET $3;
+
+-- name: ResolveVideoReports :execrows
+-- closes every open report of the video
+UPDATE video_reports
+SET
+    status = sqlc.arg('status'),
+    action = sqlc.narg('action'),
+    resolved_by = sqlc.arg('resolved_by')::uuid,
+    resolved_at = CURRENT_TIMESTAMP
+WHERE video_id = sqlc.arg('video_id') AND status = 'open';
+
+-- name: SetVideoAgeRestricted :exec
+UPDATE videos SET age_restricted = $1 WHERE id = $2;
+
+-- name: CreateNotification :exec
+INSERT INTO notifications (
+    user_id,
+    kind,
+    video_id,
+    message
+) VALUES ($1, $2, $3, $4);
+
+-- name: ListUserReports :many
+SELECT * FROM video_reports WHERE reporter_id = $1 ORDER BY created_at;
@@ -0,0 +1,20 @@
+-- name: SetVideoPlaybackPassword :one
+UPDATE videos SET playback_password_hash = $1
+WHERE id = $2 RETURNING *;
+
+-- name: GetPlaybackFailures :one
+-- GetPlaybackFailures counts the failed attempts of a client in the window starting at window_start
+SELECT failures FROM playback_failures
+WHERE video_id = $1 AND client = $2 AND window_start >= $3;
+
+-- name: RecordPlaybackFailure :one
+-- RecordPlaybackFailure counts a failed attempt, starting a new window when the last one began
+-- before window_start
+INSERT INTO playback_failures (video_id, client) VALUES (sqlc.arg(video_id), sqlc.arg(client))
+ON CONFLICT (video_id, client) DO UPDATE SET
+    failures = CASE WHEN playback_failures.window_start < sqlc.arg(window_start) THEN 1 ELSE playback_failures.failures + 1 END,
+    window_start = CASE WHEN playback_failures.window_start < sqlc.arg(window_start) THEN CURRENT_TIMESTAMP ELSE playback_failures.window_start END
+RETURNING failures;
+
+-- name: ClearPlaybackFailures :exec
+DELETE FROM playback_failures WHERE video_id = $1 AND client = $2;
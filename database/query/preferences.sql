@@ -0,0 +1,27 @@
+-- name: GetNotificationPreferences :one
+-- GetNotificationPreferences returns the address and the preferences of a user, whose
+-- channels and webhook_url are null when the user kept the defaults
+SELECT u.email, p.channels, p.webhook_url
+FROM users u
+LEFT JOIN notification_preferences p ON p.user_id = u.id
+WHERE u.id = $1 AND u.deleted_at IS NULL;
+
+-- name: SetNotificationPreferences :one
+INSERT INTO notification_preferences (
+    user_id,
+    channels,
+    webhook_url
+) VALUES ($1, $2, $3)
+ON CONFLICT (user_id) DO UPDATE SET
+    channels = EXCLUDED.channels,
+    webhook_url = EXCLUDED.webhook_url,
+    updated_at = CURRENT_TIMESTAMP
+RETURNING *;
+
+-- name: ListSubscriberPreferences :many
+-- ListSubscriberPreferences returns the subscribers of a creator that changed their preferences
+SELECT s.subscriber_id, u.email, p.channels, p.webhook_url
+FROM subscriptions s
+JOIN notification_preferences p ON p.user_id = s.subscriber_id
+JOIN users u ON u.id = s.subscriber_id
+WHERE s.creator_id = $1 AND u.deleted_at IS NULL;
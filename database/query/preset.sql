@@ -0,0 +1,45 @@
+-- name: CreateTranscodingPreset :one
+INSERT INTO transcoding_presets (
+    name,
+    description,
+    variants,
+    hls_segment_seconds,
+    rate_control,
+    crf
+) VALUES ($1, $2, $3, $4, $5, $6) RETURNING *;
+
+-- name: GetTranscodingPreset :one
+SELECT * FROM transcoding_presets WHERE id = $1;
+
+-- name: GetTranscodingPresetByName :one
+SELECT * FROM transcoding_presets WHERE name = $1;
+
+-- name: GetDefaultTranscodingPreset :one
+SELECT * FROM transcoding_presets WHERE is_default LIMIT 1;
+
+-- name: ListTranscodingPresets :many
+SELECT * FROM transcoding_presets ORDER BY name;
+
+-- name: UpdateTranscodingPreset :one
+UPDATE transcoding_presets
+SET
+    name = $2,
+    description = $3,
+    variants = $4,
+    hls_segment_seconds = $5,
+    rate_control = $6,
+    crf = $7,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1
+RETURNING *;
+
+-- name: SetDefaultTranscodingPreset :exec
+-- one statement, so there is a default preset at all times
+UPDATE transcoding_presets
+SET
+    is_default = (id = $1),
+    updated_at = CURRENT_TIMESTAMP
+WHERE is_default OR id = $1;
+
+-- name: DeleteTranscodingPreset :exec
+DELETE FROM transcoding_presets WHERE id = $1;
@@ -0,0 +1,32 @@
+-- name: SaveVideoProgress :exec
+-- started_at stays at the first save of the run, see resetProgress
+INSERT INTO video_progress (
+    video_id,
+    variant,
+    percent
+) VALUES ($1, $2, $3)
+ON CONFLICT (video_id, variant)
+DO UPDATE SET
+    percent = EXCLUDED.percent,
+    updated_at = CURRENT_TIMESTAMP;
+
+-- name: ListVideoProgress :many
+SELECT * FROM video_progress WHERE video_id = $1 ORDER BY variant;
+
+-- name: ListVariantThroughput :many
+-- the seconds of source each variant encoded per second of work, over the variants completed in
+-- the last 30 days, so estimates follow changes of the workers
+SELECT
+    p.variant,
+    (SUM(v.duration_ms) / 1000.0 / SUM(EXTRACT(EPOCH FROM p.updated_at - p.started_at)))::float8 AS speed
+FROM video_progress p
+JOIN videos v ON v.id = p.video_id
+WHERE p.percent = 100
+  AND p.updated_at > CURRENT_TIMESTAMP - INTERVAL '30 days'
+  AND p.updated_at > p.started_at
+  AND v.duration_ms > 0
+GROUP BY p.variant;
+
+-- name: DeleteVideoProgress :exec
+-- clears the progress of an earlier run when the video is processed again
+DELETE FROM video_progress WHERE video_id = $1;
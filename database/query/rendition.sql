@@ -0,0 +1,128 @@
+-- name: ArchiveRenditions :one
+-- keeps the current variants and assets of a video as its next version; no row when the
+-- video has no variants yet
+INSERT INTO video_rendition_versions (video_id, version, variants, assets)
+SELECT
+    $1,
+    COALESCE((SELECT max(r.version) FROM video_rendition_versions r WHERE r.video_id = $1), 0) + 1,
+    (SELECT jsonb_agg(to_jsonb(vv) ORDER BY vv.variant_name, vv.format) FROM video_variants vv WHERE vv.video_id = $1),
+    COALESCE((SELECT jsonb_agg(to_jsonb(va) ORDER BY va.kind) FROM video_assets va WHERE va.video_id = $1), '[]')
+WHERE EXISTS (SELECT 1 FROM video_variants WHERE video_id = $1)
+RETURNING *;
+
+-- name: ListRenditionVersions :many
+SELECT * FROM video_rendition_versions WHERE video_id = $1 ORDER BY version DESC;
+
+-- name: GetRenditionVersion :one
+SELECT * FROM video_rendition_versions WHERE video_id = $1 AND version = $2;
+
+-- name: RestoreRenditionVersion :one
+-- makes the rows of a version the variants and assets of its video and deletes the version,
+-- which is current again. Rows the version does not have are deleted.
+WITH restored AS (
+    DELETE FROM video_rendition_versions
+    WHERE id = $1
+    RETURNING video_id, variants, assets
+), version_variants AS (
+    -- versions archived before variants had formats only hold MP4 rows
+    SELECT v.video_id, v.variant_name, COALESCE(v.format, 'mp4') AS format, v.bucket, v.key,
+        v.content_type, v.created_at, v.hls_playlist_key, v.thumbnail_key, v.width, v.height,
+        v.bitrate_kbps, v.vmaf, v.psnr, COALESCE(v.audio_languages, '{}') AS audio_languages
+    FROM restored, jsonb_populate_recordset(NULL::video_variants, restored.variants) v
+), version_assets AS (
+    SELECT a.*
+    FROM restored, jsonb_populate_recordset(NULL::video_assets, restored.assets) a
+), dropped_variants AS (
+    DELETE FROM video_variants vv
+    USING restored
+    WHERE vv.video_id = restored.video_id
+      AND (vv.variant_name, vv.format) NOT IN (SELECT variant_name, format FROM version_variants)
+    RETURNING vv.id
+), restored_variants AS (
+    INSERT INTO video_variants (
+        video_id, variant_name, format, bucket, key, content_type, created_at, hls_playlist_key,
+        thumbnail_key, width, height, bitrate_kbps, vmaf, psnr, audio_languages
+    )
+    SELECT
+        video_id, variant_name, format, bucket, key, content_type, created_at, hls_playlist_key,
+        thumbnail_key, width, height, bitrate_kbps, vmaf, psnr, audio_languages
+    FROM version_variants
+    ON CONFLICT (video_id, variant_name, format) DO UPDATE
+    SET
+        bucket = EXCLUDED.bucket,
+        key = EXCLUDED.key,
+        content_type = EXCLUDED.content_type,
+        created_at = EXCLUDED.created_at,
+        hls_playlist_key = EXCLUDED.hls_playlist_key,
+        thumbnail_key = EXCLUDED.thumbnail_key,
+        width = EXCLUDED.width,
+        height = EXCLUDED.height,
+        bitrate_kbps = EXCLUDED.bitrate_kbps,
+        vmaf = EXCLUDED.vmaf,
+        psnr = EXCLUDED.psnr,
+        audio_languages = EXCLUDED.audio_languages
+    RETURNING id
+), dropped_assets AS (
+    DELETE FROM video_assets va
+    USING restored
+    WHERE va.video_id = restored.video_id
+      AND va.kind NOT IN (SELECT kind FROM version_assets)
+    RETURNING va.id
+), restored_assets AS (
+    INSERT INTO video_assets (video_id, kind, bucket, key, content_type, created_at)
+    SELECT video_id, kind, bucket, key, content_type, created_at
+    FROM version_assets
+    ON CONFLICT (video_id, kind) DO UPDATE
+    SET
+        bucket = EXCLUDED.bucket,
+        key = EXCLUDED.key,
+        content_type = EXCLUDED.content_type,
+        created_at = EXCLUDED.created_at
+    RETURNING id
+), restored_master AS (
+    UPDATE videos v
+    SET master_playlist_key = (SELECT key FROM version_assets WHERE kind = 'master_playlist')
+    FROM restored
+    WHERE v.id = restored.video_id
+    RETURNING v.id
+)
+SELECT
+    (SELECT count(*) FROM restored_variants) AS variants,
+    (SELECT count(*) FROM restored_assets) AS assets;
+
+-- name: ExpireRenditionVersions :many
+-- deletes the versions replaced before created_at; instances skip each other's rows
+DELETE FROM video_rendition_versions
+WHERE id IN (
+    SELECT id
+    FROM video_rendition_versions
+    WHERE created_at <= $1
+    ORDER BY created_at
+    LIMIT $2
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: RenditionPrefixInUse :one
+-- reports whether the current rows or a version of a video still point under prefix
+SELECT
+    EXISTS (
+        SELECT 1 FROM video_variants
+        WHERE video_id = sqlc.arg('video_id')
+          AND (starts_with(key, sqlc.arg('prefix')::text)
+            OR starts_with(hls_playlist_key, sqlc.arg('prefix')::text)
+            OR starts_with(thumbnail_key, sqlc.arg('prefix')::text))
+    ) OR EXISTS (
+        SELECT 1 FROM video_assets
+        WHERE video_id = sqlc.arg('video_id') AND starts_with(key, sqlc.arg('prefix')::text)
+    ) OR EXISTS (
+        SELECT 1 FROM video_subtitles
+        WHERE video_id = sqlc.arg('video_id') AND starts_with(key, sqlc.arg('prefix')::text)
+    ) OR EXISTS (
+        SELECT 1 FROM video_thumbnails
+        WHERE video_id = sqlc.arg('video_id') AND starts_with(key, sqlc.arg('prefix')::text)
+    ) OR EXISTS (
+        SELECT 1
+        FROM video_rendition_versions r, jsonb_array_elements(r.variants || r.assets) item
+        WHERE r.video_id = sqlc.arg('video_id') AND starts_with(item->>'key', sqlc.arg('prefix')::text)
+    ) AS in_use;
@@ -0,0 +1,84 @@
+-- name: CreateReprocessRun :one
+INSERT INTO reprocess_runs (
+    preset_id,
+    user_id,
+    created_after,
+    created_before,
+    rate_per_minute,
+    total
+) VALUES ($1, $2, $3, $4, $5, $6) RETURNING *;
+
+-- name: GetReprocessRun :one
+SELECT * FROM reprocess_runs WHERE id = $1;
+
+-- name: ListReprocessRuns :many
+SELECT * FROM reprocess_runs ORDER BY created_at DESC;
+
+-- name: CountReprocessCandidates :one
+SELECT count(*) FROM videos
+WHERE (sqlc.narg('user_id')::uuid IS NULL OR user_id = sqlc.narg('user_id'))
+    AND (sqlc.narg('created_after')::timestamptz IS NULL OR created_at >= sqlc.narg('created_after'))
+    AND created_at < sqlc.arg('created_before')::timestamptz;
+
+-- name: ListReprocessCandidates :many
+SELECT id, user_id, bucket, key FROM videos
+WHERE (sqlc.narg('user_id')::uuid IS NULL OR user_id = sqlc.narg('user_id'))
+    AND (sqlc.narg('created_after')::timestamptz IS NULL OR created_at >= sqlc.narg('created_after'))
+    AND created_at < sqlc.arg('created_before')::timestamptz
+    AND id > sqlc.arg('after_id')
+ORDER BY id
+LIMIT sqlc.arg('limit');
+
+-- name: ClaimReprocessRun :one
+-- takes over the oldest running run no instance holds
+UPDATE reprocess_runs
+SET
+    lease_until = sqlc.arg('lease_until')::timestamptz,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = (
+    SELECT id FROM reprocess_runs
+    WHERE status = 'running' AND (lease_until IS NULL OR lease_until < CURRENT_TIMESTAMP)
+    ORDER BY created_at
+    LIMIT 1
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: AdvanceReprocessRun :one
+-- records a queued video and renews the lease; no row when the run was cancelled
+UPDATE reprocess_runs
+SET
+    last_video_id = sqlc.arg('last_video_id'),
+    enqueued = enqueued + 1,
+    lease_until = sqlc.arg('lease_until')::timestamptz,
+    error = '',
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = sqlc.arg('id') AND status = 'running'
+RETURNING *;
+
+-- name: ReleaseReprocessRun :exec
+-- gives up the lease so the run is retried, keeping why
+UPDATE reprocess_runs
+SET
+    lease_until = NULL,
+    error = $2,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1;
+
+-- name: FinishReprocessRun :one
+UPDATE reprocess_runs
+SET
+    status = $2,
+    lease_until = NULL,
+    finished_at = CURRENT_TIMESTAMP,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1 AND status = 'running'
+RETURNING *;
+
+-- name: RecordReprocessResult :exec
+UPDATE reprocess_runs
+SET
+    succeeded = succeeded + CASE WHEN sqlc.arg('failed')::boolean THEN 0 ELSE 1 END,
+    failed = failed + CASE WHEN sqlc.arg('failed')::boolean THEN 1 ELSE 0 END,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = sqlc.arg('id');
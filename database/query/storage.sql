@@ -0,0 +1,102 @@
+-- name: MoveUserObjects :one
+-- points the objects of a user stored under old_prefix of old_bucket at the same keys under
+-- new_prefix of new_bucket. One statement, so every reference moves or none does.
+WITH moved_videos AS (
+    UPDATE videos
+    SET
+        bucket = sqlc.arg('new_bucket')::text,
+        key = sqlc.arg('new_prefix')::text || substr(key, length(sqlc.arg('old_prefix')::text) + 1),
+        master_playlist_key = CASE WHEN starts_with(master_playlist_key, sqlc.arg('old_prefix')::text)
+            THEN sqlc.arg('new_prefix')::text || substr(master_playlist_key, length(sqlc.arg('old_prefix')::text) + 1)
+            ELSE master_playlist_key END
+    WHERE user_id = sqlc.arg('user_id')
+      AND bucket = sqlc.arg('old_bucket')::text
+      AND starts_with(key, sqlc.arg('old_prefix')::text)
+    RETURNING id
+), moved_variants AS (
+    UPDATE video_variants
+    SET
+        bucket = sqlc.arg('new_bucket')::text,
+        key = sqlc.arg('new_prefix')::text || substr(key, length(sqlc.arg('old_prefix')::text) + 1),
+        hls_playlist_key = CASE WHEN starts_with(hls_playlist_key, sqlc.arg('old_prefix')::text)
+            THEN sqlc.arg('new_prefix')::text || substr(hls_playlist_key, length(sqlc.arg('old_prefix')::text) + 1)
+            ELSE hls_playlist_key END,
+        thumbnail_key = CASE WHEN starts_with(thumbnail_key, sqlc.arg('old_prefix')::text)
+            THEN sqlc.arg('new_prefix')::text || substr(thumbnail_key, length(sqlc.arg('old_prefix')::text) + 1)
+            ELSE thumbnail_key END
+    WHERE video_id IN (SELECT id FROM videos WHERE user_id = sqlc.arg('user_id'))
+      AND bucket = sqlc.arg('old_bucket')::text
+      AND starts_with(key, sqlc.arg('old_prefix')::text)
+    RETURNING video_id
+), moved_assets AS (
+    UPDATE video_assets
+    SET
+        bucket = sqlc.arg('new_bucket')::text,
+        key = sqlc.arg('new_prefix')::text || substr(key, length(sqlc.arg('old_prefix')::text) + 1)
+    WHERE video_id IN (SELECT id FROM videos WHERE user_id = sqlc.arg('user_id'))
+      AND bucket = sqlc.arg('old_bucket')::text
+      AND starts_with(key, sqlc.arg('old_prefix')::text)
+    RETURNING video_id
+), moved_subtitles AS (
+    UPDATE video_subtitles
+    SET
+        bucket = sqlc.arg('new_bucket')::text,
+        key = sqlc.arg('new_prefix')::text || substr(key, length(sqlc.arg('old_prefix')::text) + 1)
+    WHERE video_id IN (SELECT id FROM videos WHERE user_id = sqlc.arg('user_id'))
+      AND bucket = sqlc.arg('old_bucket')::text
+      AND starts_with(key, sqlc.arg('old_prefix')::text)
+    RETURNING video_id
+), moved_thumbnails AS (
+    UPDATE video_thumbnails
+    SET
+        bucket = sqlc.arg('new_bucket')::text,
+        key = sqlc.arg('new_prefix')::text || substr(key, length(sqlc.arg('old_prefix')::text) + 1)
+    WHERE video_id IN (SELECT id FROM videos WHERE user_id = sqlc.arg('user_id'))
+      AND bucket = sqlc.arg('old_bucket')::text
+      AND starts_with(key, sqlc.arg('old_prefix')::text)
+    RETURNING video_id
+), moved_exports AS (
+    UPDATE data_exports
+    SET
+        bucket = sqlc.arg('new_bucket')::text,
+        key = sqlc.arg('new_prefix')::text || substr(key, length(sqlc.arg('old_prefix')::text) + 1)
+    WHERE user_id = sqlc.arg('user_id')
+      AND bucket = sqlc.arg('old_bucket')::text
+      AND starts_with(key, sqlc.arg('old_prefix')::text)
+    RETURNING id
+), moved_versions AS (
+    UPDATE video_rendition_versions r
+    SET
+        variants = (
+            SELECT jsonb_agg(CASE
+                WHEN item->>'bucket' = sqlc.arg('old_bucket')::text AND starts_with(item->>'key', sqlc.arg('old_prefix')::text)
+                THEN item || jsonb_strip_nulls(jsonb_build_object(
+                    'bucket', sqlc.arg('new_bucket')::text,
+                    'key', sqlc.arg('new_prefix')::text || substr(item->>'key', length(sqlc.arg('old_prefix')::text) + 1),
+                    'hls_playlist_key', CASE WHEN starts_with(item->>'hls_playlist_key', sqlc.arg('old_prefix')::text)
+                        THEN sqlc.arg('new_prefix')::text || substr(item->>'hls_playlist_key', length(sqlc.arg('old_prefix')::text) + 1) END,
+                    'thumbnail_key', CASE WHEN starts_with(item->>'thumbnail_key', sqlc.arg('old_prefix')::text)
+                        THEN sqlc.arg('new_prefix')::text || substr(item->>'thumbnail_key', length(sqlc.arg('old_prefix')::text) + 1) END))
+                ELSE item END ORDER BY n)
+            FROM jsonb_array_elements(r.variants) WITH ORDINALITY AS items(item, n)
+        ),
+        assets = COALESCE((
+            SELECT jsonb_agg(CASE
+                WHEN item->>'bucket' = sqlc.arg('old_bucket')::text AND starts_with(item->>'key', sqlc.arg('old_prefix')::text)
+                THEN item || jsonb_build_object(
+                    'bucket', sqlc.arg('new_bucket')::text,
+                    'key', sqlc.arg('new_prefix')::text || substr(item->>'key', length(sqlc.arg('old_prefix')::text) + 1))
+                ELSE item END ORDER BY n)
+            FROM jsonb_array_elements(r.assets) WITH ORDINALITY AS items(item, n)
+        ), '[]')
+    WHERE r.video_id IN (SELECT id FROM videos WHERE user_id = sqlc.arg('user_id'))
+    RETURNING r.id
+)
+SELECT
+    (SELECT count(*) FROM moved_videos) AS videos,
+    (SELECT count(*) FROM moved_variants) AS variants,
+    (SELECT count(*) FROM moved_assets) AS assets,
+    (SELECT count(*) FROM moved_subtitles) AS subtitles,
+    (SELECT count(*) FROM moved_thumbnails) AS thumbnails,
+    (SELECT count(*) FROM moved_exports) AS exports,
+    (SELECT count(*) FROM moved_versions) AS versions;
@@ -0,0 +1,58 @@
+-- name: Subscribe :execrows
+INSERT INTO subscriptions (
+    subscriber_id,
+    creator_id
+) VALUES ($1, $2)
+ON CONFLICT DO NOTHING;
+
+-- name: Unsubscribe :exec
+DELETE FROM subscriptions WHERE subscriber_id = $1 AND creator_id = $2;
+
+-- name: ListSubscriptions :many
+SELECT s.creator_id, u.username, s.created_at
+FROM subscriptions s
+JOIN users u ON u.id = s.creator_id
+WHERE s.subscriber_id = $1 AND u.deleted_at IS NULL
+ORDER BY s.created_at DESC;
+
+-- name: ListFeed :many
+SELECT
+    v.id,
+    v.user_id,
+    u.username,
+    v.title,
+    v.description,
+    v.published_at,
+    p.bucket AS preview_bucket,
+    p.key AS preview_key,
+    a.bucket AS animated_preview_bucket,
+    a.key AS animated_preview_key
+FROM subscriptions s
+JOIN videos v ON v.user_id = s.creator_id AND v.visibility = 'public' AND NOT v.age_restricted
+JOIN users u ON u.id = v.user_id
+LEFT JOIN video_assets p ON p.video_id = v.id AND p.kind = 'preview'
+LEFT JOIN video_assets a ON a.video_id = v.id AND a.kind = 'animated_preview'
+WHERE s.subscriber_id = $1 AND u.deleted_at IS NULL
+ORDER BY v.published_at DESC
+LIMIT $2 OFFSET $3;
+
+-- name: CreateVideoNotifications :execrows
+-- fans a published video out to the subscribers of its creator that get new videos in the app
+INSERT INTO notifications (user_id, kind, video_id)
+SELECT s.subscriber_id, 'new_video', sqlc.arg('video_id')::uuid
+FROM subscriptions s
+LEFT JOIN notification_preferences p ON p.user_id = s.subscriber_id
+WHERE s.creator_id = sqlc.arg('creator_id')
+    AND COALESCE(p.channels -> 'new_video', '["in_app"]') @> '["in_app"]';
+
+-- name: ListNotifications :many
+-- notifications of videos the user can no longer see are left out
+SELECT n.id, n.kind, n.video_id, v.title, v.user_id AS creator_id, n.message, n.read_at, n.created_at
+FROM notifications n
+LEFT JOIN videos v ON v.id = n.video_id
+WHERE n.user_id = $1 AND (v.id IS NULL OR v.visibility = 'public' OR v.user_id = n.user_id)
+ORDER BY n.created_at DESC
+LIMIT $2 OFFSET $3;
+
+-- name: MarkNotificationsRead :exec
+UPDATE notifications SET read_at = CURRENT_TIMESTAMP WHERE user_id = $1 AND read_at IS NULL;
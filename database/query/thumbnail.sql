@@ -0,0 +1,34 @@
+-- name: SaveVideoThumbnail :one
+-- the first thumbnail saved for a video becomes its primary one, later runs keep the owner's choice
+INSERT INTO video_thumbnails (
+    video_id,
+    position,
+    at_ms,
+    bucket,
+    key,
+    is_primary
+) VALUES ($1, $2, $3, $4, $5, NOT EXISTS (SELECT 1 FROM video_thumbnails WHERE video_id = $1 AND is_primary))
+ON CONFLICT (video_id, position)
+DO UPDATE SET
+    at_ms = EXCLUDED.at_ms,
+    bucket = EXCLUDED.bucket,
+    key = EXCLUDED.key,
+    created_at = CURRENT_TIMESTAMP
+RETURNING *;
+
+-- name: ListVideoThumbnails :many
+SELECT * FROM video_thumbnails WHERE video_id = $1 ORDER BY position;
+
+-- name: DeleteVideoThumbnailsFrom :exec
+-- drops the thumbnails of an earlier run past the ones of the current run
+DELETE FROM video_thumbnails WHERE video_id = $1 AND position >= $2;
+
+-- name: SetPrimaryVideoThumbnail :execrows
+-- makes the thumbnail at position the primary one, no rows change when there is none there
+UPDATE video_thumbnails
+SET is_primary = (position = sqlc.arg('position'))
+WHERE video_id = sqlc.arg('video_id')
+  AND EXISTS (
+    SELECT 1 FROM video_thumbnails
+    WHERE video_id = sqlc.arg('video_id') AND position = sqlc.arg('position')
+  );
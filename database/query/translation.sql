@@ -0,0 +1,22 @@
+-- name: SetVideoTranslation :one
+INSERT INTO video_translations (
+    video_id,
+    language,
+    title,
+    description
+) VALUES ($1, $2, $3, $4)
+ON CONFLICT (video_id, language) DO UPDATE SET
+    title = EXCLUDED.title,
+    description = EXCLUDED.description,
+    updated_at = CURRENT_TIMESTAMP
+RETURNING *;
+
+-- name: DeleteVideoTranslation :execrows
+DELETE FROM video_translations WHERE video_id = $1 AND language = $2;
+
+-- name: ListVideoTranslations :many
+SELECT * FROM video_translations WHERE video_id = $1 ORDER BY language;
+
+-- name: ListTranslationsOfVideos :many
+-- ListTranslationsOfVideos returns the translations of a page of videos at once
+SELECT * FROM video_translations WHERE video_id = ANY(sqlc.arg(video_ids)::uuid[]) ORDER BY video_id, language;
@@ -0,0 +1,80 @@
+-- name: RecordUsage :exec
+-- adds usage to the current month of a user
+INSERT INTO usage_monthly (user_id, month, transcode_ms, delivery_bytes)
+VALUES ($1, date_trunc('month', CURRENT_TIMESTAMP AT TIME ZONE 'UTC')::date, $2, $3)
+ON CONFLICT (user_id, month) DO UPDATE
+SET
+    transcode_ms = usage_monthly.transcode_ms + EXCLUDED.transcode_ms,
+    delivery_bytes = usage_monthly.delivery_bytes + EXCLUDED.delivery_bytes;
+
+-- name: RecordStorageHeld :exec
+-- raises the storage of the current month of a user to the bytes they hold now, their sources
+-- and the results of their processing runs, so a month is billed its peak
+INSERT INTO usage_monthly (user_id, month, storage_bytes)
+SELECT held.user_id, date_trunc('month', CURRENT_TIMESTAMP AT TIME ZONE 'UTC')::date, SUM(held.bytes)::bigint
+FROM (
+    SELECT v.user_id, v.file_size_bytes AS bytes FROM videos v WHERE v.user_id = $1
+    UNION ALL
+    SELECT v.user_id, r.bytes FROM video_result_sizes r JOIN videos v ON v.id = r.video_id WHERE v.user_id = $1
+) held
+GROUP BY held.user_id
+ON CONFLICT (user_id, month) DO UPDATE
+SET storage_bytes = GREATEST(usage_monthly.storage_bytes, EXCLUDED.storage_bytes);
+
+-- name: SnapshotStorageHeld :exec
+-- raises the storage of the current month of every user to the bytes they hold now, so
+-- months without uploads or processing are billed what stays stored
+INSERT INTO usage_monthly (user_id, month, storage_bytes)
+SELECT held.user_id, date_trunc('month', CURRENT_TIMESTAMP AT TIME ZONE 'UTC')::date, SUM(held.bytes)::bigint
+FROM (
+    SELECT v.user_id, v.file_size_bytes AS bytes FROM videos v
+    UNION ALL
+    SELECT v.user_id, r.bytes FROM video_result_sizes r JOIN videos v ON v.id = r.video_id
+) held
+GROUP BY held.user_id
+ON CONFLICT (user_id, month) DO UPDATE
+SET storage_bytes = GREATEST(usage_monthly.storage_bytes, EXCLUDED.storage_bytes);
+
+-- name: SetResultSize :exec
+-- records the bytes under the results prefix of a processing run of a video
+INSERT INTO video_result_sizes (video_id, prefix, bytes) VALUES ($1, $2, $3)
+ON CONFLICT (video_id, prefix) DO UPDATE SET bytes = EXCLUDED.bytes;
+
+-- name: DeleteResultSize :exec
+-- forgets the size of a processing run whose objects were deleted
+DELETE FROM video_result_sizes WHERE video_id = $1 AND prefix = $2;
+
+-- name: ListUserUsage :many
+SELECT * FROM usage_monthly
+WHERE user_id = sqlc.arg('user_id')
+  AND month BETWEEN sqlc.arg('from_month')::date AND sqlc.arg('to_month')::date
+ORDER BY month;
+
+-- name: ListMonthUsage :many
+SELECT * FROM usage_monthly WHERE month = $1 ORDER BY user_id;
+
+-- name: ClaimUsageToEmit :many
+-- leases up to max_rows rows of closed months that were not posted yet, oldest month first,
+-- skipping the rows another instance holds
+UPDATE usage_monthly u
+SET emit_lease_until = sqlc.arg('lease_until')::timestamptz
+FROM (
+    SELECT user_id, month
+    FROM usage_monthly
+    WHERE emitted_at IS NULL
+      AND month < date_trunc('month', CURRENT_TIMESTAMP AT TIME ZONE 'UTC')::date
+      AND (emit_lease_until IS NULL OR emit_lease_until < CURRENT_TIMESTAMP)
+    ORDER BY month, user_id
+    LIMIT sqlc.arg('max_rows')
+    FOR UPDATE SKIP LOCKED
+) due
+WHERE u.user_id = due.user_id AND u.month = due.month
+RETURNING u.*;
+
+-- name: MarkUsageEmitted :exec
+UPDATE usage_monthly u
+SET
+    emitted_at = CURRENT_TIMESTAMP,
+    emit_lease_until = NULL
+FROM unnest(sqlc.arg('user_ids')::uuid[], sqlc.arg('months')::date[]) AS emitted(user_id, month)
+WHERE u.user_id = emitted.user_id AND u.month = emitted.month;
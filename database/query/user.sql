@@ -36,3 +36,6 @@ WHERE id = $7 RETURNING *;
 -- name: DeleteUser :one
 DELETE FROM users WHERE id = $1 RETURNING *;
 
+-- name: ListUserIDs :many
+-- includes deleted users, whose objects are still stored
+SELECT id FROM users ORDER BY created_at;
@@ -12,6 +12,9 @@ INSERT INTO videos (
 -- name: GetVideo :one
 SELECT * FROM videos WHERE id = $1;
 
+-- name: GetVideoByObject :one
+SELECT * FROM videos WHERE bucket = $1 AND key = $2 LIMIT 1;
+
 -- name: ListVideos :many
 SELECT * FROM videos ORDER BY created_at DESC;
 
@@ -46,9 +49,11 @@ INSERT INTO video_variants (
     thumbnail_key,
     width,
     height,
-    bitrate_kbps
-) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) 
-ON CONFLICT (video_id, variant_name) 
+    bitrate_kbps,
+    format,
+    audio_languages
+) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, COALESCE($12::text[], '{}')) 
+ON CONFLICT (video_id, variant_name, format) 
 DO UPDATE SET 
     bucket = EXCLUDED.bucket,
     key = EXCLUDED.key,
@@ -57,5 +62,285 @@ DO UPDATE SET
     thumbnail_key = EXCLUDED.thumbnail_key,
     width = EXCLUDED.width,
     height = EXCLUDED.height,
-    bitrate_kbps = EXCLUDED.bitrate_kbps
-RETURNING *;
\ No newline at end of file
+    bitrate_kbps = EXCLUDED.bitrate_kbps,
+    audio_languages = EXCLUDED.audio_languages
+RETURNING *;
+-- name: SaveVideoAsset :one
+INSERT INTO video_assets (
+    video_id,
+    kind,
+    bucket,
+    key,
+    content_type
+) VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (video_id, kind)
+DO UPDATE SET
+    bucket = EXCLUDED.bucket,
+    key = EXCLUDED.key,
+    content_type = EXCLUDED.content_type
+RETURNING *;
+
+-- name: SetVideoMasterPlaylist :exec
+-- points the video at the master playlist of its current rendition set
+UPDATE videos
+SET
+    master_playlist_key = $2
+WHERE id = $1;
+
+-- name: ListVideoVariants :many
+SELECT * FROM video_variants WHERE video_id = $1 ORDER BY height DESC, variant_name, format;
+
+-- name: ListVideoAssets :many
+SELECT * FROM video_assets WHERE video_id = $1 ORDER BY kind;
+
+-- name: CreateVideoChapter :one
+INSERT INTO video_chapters (
+    video_id,
+    title,
+    start_ms,
+    end_ms,
+    source
+) VALUES ($1, $2, $3, $4, $5) RETURNING *;
+
+-- name: ListVideoChapters :many
+SELECT * FROM video_chapters WHERE video_id = $1 ORDER BY start_ms;
+
+-- name: DeleteVideoChapters :exec
+DELETE FROM video_chapters WHERE video_id = $1;
+
+-- name: SaveVideoSubtitle :one
+INSERT INTO video_subtitles (
+    video_id,
+    track,
+    language,
+    label,
+    forced,
+    bucket,
+    key
+) VALUES ($1, $2, $3, $4, $5, $6, $7)
+ON CONFLICT (video_id, track)
+DO UPDATE SET
+    language = EXCLUDED.language,
+    label = EXCLUDED.label,
+    forced = EXCLUDED.forced,
+    bucket = EXCLUDED.bucket,
+    key = EXCLUDED.key,
+    created_at = CURRENT_TIMESTAMP
+RETURNING *;
+
+-- name: ListVideoSubtitles :many
+SELECT * FROM video_subtitles WHERE video_id = $1 ORDER BY track;
+
+-- name: DeleteVideoSubtitles :exec
+DELETE FROM video_subtitles WHERE video_id = $1;
+
+-- name: CreateDerivedVideo :one
+INSERT INTO videos (
+    user_id,
+    title,
+    description,
+    bucket,
+    key,
+    file_size_bytes,
+    content_type,
+    parent_video_id,
+    recipe
+) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING *;
+
+-- name: UpdateVideoFileSize :exec
+UPDATE videos
+SET
+    file_size_bytes = $1
+WHERE id = $2;
+
+-- name: UpdateVideoColorMetadata :exec
+UPDATE videos
+SET
+    color_primaries = $1,
+    color_transfer = $2,
+    color_space = $3,
+    hdr_format = $4
+WHERE id = $5;
+
+-- name: UpdateVideoSourceMetadata :exec
+UPDATE videos
+SET
+    duration_ms = $1,
+    source_codec = $2,
+    source_fps = $3,
+    audio_channels = $4,
+    container = $5
+WHERE id = $6;
+
+-- name: UpdateVideoSourceResolution :exec
+UPDATE videos
+SET
+    source_width = $1,
+    source_height = $2
+WHERE id = $3;
+
+-- name: FailVideo :exec
+-- marks a video whose source the worker rejected as failed, with the reason for its owner
+UPDATE videos
+SET
+    status = 'failed',
+    failure_reason = $2
+WHERE id = $1;
+
+-- name: ClearVideoFailure :exec
+-- puts a rejected video back to pending once a later run accepts its source
+UPDATE videos
+SET
+    status = 'pending',
+    failure_reason = NULL
+WHERE id = $1 AND status = 'failed';
+
+-- name: SaveVideoFingerprint :exec
+INSERT INTO video_fingerprints (
+    video_id,
+    frame_hashes
+) VALUES ($1, $2)
+ON CONFLICT (video_id)
+DO UPDATE SET
+    frame_hashes = EXCLUDED.frame_hashes,
+    created_at = CURRENT_TIMESTAMP;
+
+-- name: SaveVideoProbe :exec
+INSERT INTO video_probes (
+    video_id,
+    probe
+) VALUES ($1, $2)
+ON CONFLICT (video_id)
+DO UPDATE SET
+    probe = EXCLUDED.probe,
+    probed_at = CURRENT_TIMESTAMP;
+
+-- name: GetVideoProbe :one
+SELECT * FROM video_probes WHERE video_id = $1;
+
+-- name: ListVideoFingerprints :many
+SELECT f.video_id, f.frame_hashes, v.user_id, v.title
+FROM video_fingerprints f
+JOIN videos v ON v.id = f.video_id
+ORDER BY f.created_at;
+
+-- name: UpdateVideoProjection :exec
+UPDATE videos
+SET
+    projection = $1,
+    stereo_mode = $2
+WHERE id = $3;
+
+-- name: UpdateVariantQuality :exec
+UPDATE video_variants
+SET
+    vmaf = $1,
+    psnr = $2
+WHERE video_id = $3 AND variant_name = $4 AND format = 'mp4';
+
+-- name: ListVariantQualityReport :many
+SELECT
+    variant_name,
+    COUNT(*) AS videos,
+    AVG(bitrate_kbps)::float8 AS avg_bitrate_kbps,
+    AVG(vmaf)::float8 AS avg_vmaf,
+    MIN(vmaf)::float8 AS min_vmaf,
+    AVG(psnr)::float8 AS avg_psnr
+FROM video_variants
+WHERE vmaf IS NOT NULL AND psnr IS NOT NULL AND format = 'mp4'
+GROUP BY variant_name
+ORDER BY MAX(height) DESC, variant_name;
+
+-- name: ListUserVideos :many
+SELECT
+    v.id,
+    v.parent_video_id,
+    v.title,
+    v.description,
+    v.status,
+    v.visibility,
+    v.created_at,
+    v.duration_ms,
+    p.bucket AS preview_bucket,
+    p.key AS preview_key,
+    a.bucket AS animated_preview_bucket,
+    a.key AS animated_preview_key
+FROM videos v
+LEFT JOIN video_assets p ON p.video_id = v.id AND p.kind = 'preview'
+LEFT JOIN video_assets a ON a.video_id = v.id AND a.kind = 'animated_preview'
+WHERE v.user_id = $1 AND v.visibility <> 'removed'
+ORDER BY v.created_at DESC
+LIMIT $2 OFFSET $3;
+
+-- name: SetVideoVisibility :one
+-- published_at keeps the first publication, so publishing again does not notify again.
+-- Publishing cancels a scheduled publication.
+UPDATE videos
+SET
+    visibility = $1,
+    published_at = CASE WHEN $1 = 'public' THEN COALESCE(published_at, CURRENT_TIMESTAMP) ELSE published_at END,
+    publish_at = CASE WHEN $1 = 'public' THEN NULL ELSE publish_at END
+WHERE id = $2 RETURNING *;
+
+-- name: SetVideoSchedule :one
+UPDATE videos
+SET
+    publish_at = $1,
+    expires_at = $2,
+    purge_on_expiry = $3
+WHERE id = $4 RETURNING *;
+
+-- name: PublishDueVideos :many
+-- publishes private videos whose publish time has come; instances skip each other's rows
+WITH due AS (
+    SELECT id, published_at IS NULL AS first_publication
+    FROM videos
+    WHERE publish_at <= CURRENT_TIMESTAMP AND visibility = 'private'
+    ORDER BY publish_at
+    LIMIT $1
+    FOR UPDATE SKIP LOCKED
+)
+UPDATE videos v
+SET
+    visibility = 'public',
+    published_at = COALESCE(v.published_at, CURRENT_TIMESTAMP),
+    publish_at = NULL
+FROM due
+WHERE v.id = due.id
+RETURNING v.id, v.user_id, due.first_publication;
+
+-- name: ExpireDueVideos :many
+-- makes expired videos private; videos to purge keep expires_at until they are deleted
+WITH due AS (
+    SELECT id
+    FROM videos
+    WHERE expires_at <= CURRENT_TIMESTAMP
+      AND NOT EXISTS (
+          SELECT 1 FROM video_purge_failures f
+          WHERE f.video_id = videos.id AND f.retry_at > CURRENT_TIMESTAMP
+      )
+    ORDER BY expires_at
+    LIMIT $1
+    FOR UPDATE SKIP LOCKED
+)
+UPDATE videos v
+SET
+    visibility = CASE WHEN v.visibility = 'public' THEN 'private' ELSE v.visibility END,
+    expires_at = CASE WHEN v.purge_on_expiry THEN v.expires_at ELSE NULL END
+FROM due
+WHERE v.id = due.id
+RETURNING v.id, v.user_id, v.bucket, v.key, v.purge_on_expiry;
+
+-- name: DeferVideoPurge :one
+-- keeps a video whose purge failed out of ExpireDueVideos until retry_at
+INSERT INTO video_purge_failures (video_id, retry_at)
+VALUES ($1, $2)
+ON CONFLICT (video_id)
+DO UPDATE SET
+    retry_at = EXCLUDED.retry_at,
+    attempts = video_purge_failures.attempts + 1
+RETURNING attempts;
+
+-- name: ListAllUserVideos :many
+-- every video of a user, including removed ones
+SELECT * FROM videos WHERE user_id = $1 ORDER BY created_at;
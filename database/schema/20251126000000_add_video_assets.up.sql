@@ -0,0 +1,11 @@
+-- Per-video artifacts that are produced once per video rather than once per variant
+CREATE TABLE video_assets (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    video_id UUID NOT NULL REFERENCES videos(id) ON DELETE CASCADE,
+    kind VARCHAR(50) NOT NULL, -- e.g., "waveform"
+    bucket VARCHAR(255) NOT NULL,
+    key VARCHAR(255) NOT NULL,
+    content_type VARCHAR(100) NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT video_assets_video_id_kind_key UNIQUE (video_id, kind)
+);
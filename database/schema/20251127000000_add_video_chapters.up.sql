@@ -0,0 +1,11 @@
+CREATE TABLE video_chapters (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    video_id UUID NOT NULL REFERENCES videos(id) ON DELETE CASCADE,
+    title VARCHAR(255) NOT NULL,
+    start_ms BIGINT NOT NULL,
+    end_ms BIGINT,
+    source VARCHAR(50) NOT NULL DEFAULT 'manual', -- manual, source
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX video_chapters_video_id_start_ms_idx ON video_chapters (video_id, start_ms);
@@ -0,0 +1,3 @@
+ALTER TABLE videos
+DROP COLUMN IF EXISTS parent_video_id,
+DROP COLUMN IF EXISTS recipe;
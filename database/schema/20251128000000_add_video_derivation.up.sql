@@ -0,0 +1,5 @@
+-- Videos rendered from another video (edits, clips, ...) point back to their parent
+-- and keep the recipe that produced them so the render can be reproduced.
+ALTER TABLE videos
+ADD COLUMN parent_video_id UUID REFERENCES videos(id) ON DELETE SET NULL,
+ADD COLUMN recipe JSONB;
@@ -0,0 +1,5 @@
+ALTER TABLE videos
+DROP COLUMN IF EXISTS color_primaries,
+DROP COLUMN IF EXISTS color_transfer,
+DROP COLUMN IF EXISTS color_space,
+DROP COLUMN IF EXISTS hdr_format;
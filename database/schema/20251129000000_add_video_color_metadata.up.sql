@@ -0,0 +1,6 @@
+-- Color metadata of the source video stream, as reported by ffprobe
+ALTER TABLE videos
+ADD COLUMN color_primaries VARCHAR(50),
+ADD COLUMN color_transfer VARCHAR(50),
+ADD COLUMN color_space VARCHAR(50),
+ADD COLUMN hdr_format VARCHAR(20); -- HDR10, HLG or NULL for SDR
@@ -0,0 +1,6 @@
+-- Perceptual fingerprint: dHashes of frames sampled evenly across the video
+CREATE TABLE video_fingerprints (
+    video_id UUID PRIMARY KEY REFERENCES videos(id) ON DELETE CASCADE,
+    frame_hashes BIGINT[] NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
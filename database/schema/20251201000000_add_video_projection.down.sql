@@ -0,0 +1,3 @@
+ALTER TABLE videos
+DROP COLUMN IF EXISTS projection,
+DROP COLUMN IF EXISTS stereo_mode;
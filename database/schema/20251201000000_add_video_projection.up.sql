@@ -0,0 +1,4 @@
+-- Spherical (360°/VR) metadata of the source video stream
+ALTER TABLE videos
+ADD COLUMN projection VARCHAR(50), -- equirectangular, cubemap, ... NULL for flat video
+ADD COLUMN stereo_mode VARCHAR(50); -- 2D, top and bottom, side by side
@@ -0,0 +1,3 @@
+ALTER TABLE video_variants
+DROP COLUMN IF EXISTS vmaf,
+DROP COLUMN IF EXISTS psnr;
@@ -0,0 +1,4 @@
+-- Objective quality of each rendition measured against the source, NULL until measured
+ALTER TABLE video_variants
+ADD COLUMN vmaf DOUBLE PRECISION,
+ADD COLUMN psnr DOUBLE PRECISION;
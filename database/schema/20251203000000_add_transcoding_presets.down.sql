@@ -0,0 +1 @@
+DROP TABLE IF EXISTS transcoding_presets;
@@ -0,0 +1,31 @@
+-- Named rendition ladders with their encoder and HLS settings. Uploads may name a preset,
+-- the default preset processes everything else.
+CREATE TABLE transcoding_presets (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(100) NOT NULL UNIQUE,
+    description VARCHAR NOT NULL DEFAULT '',
+    is_default BOOLEAN NOT NULL DEFAULT false,
+    variants JSONB NOT NULL, -- [{"name": "1080p", "width": 1920, "height": 1080, "bitrate": "4000k", ...}]
+    hls_segment_seconds INT NOT NULL DEFAULT 6,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- The ladder the worker used before presets existed
+INSERT INTO transcoding_presets (name, description, is_default, variants) VALUES (
+    'default',
+    'H.264 ladder from 1080p to 144p, with a 10-bit HEVC rung for HDR sources and a 9:16 family',
+    true,
+    '[
+        {"name": "1080p", "width": 1920, "height": 1080, "bitrate": "4000k"},
+        {"name": "720p", "width": 1280, "height": 720, "bitrate": "2000k"},
+        {"name": "480p", "width": 854, "height": 480, "bitrate": "1000k"},
+        {"name": "360p", "width": 640, "height": 360, "bitrate": "500k"},
+        {"name": "240p", "width": 426, "height": 240, "bitrate": "250k"},
+        {"name": "144p", "width": 256, "height": 144, "bitrate": "100k"},
+        {"name": "1080p-hdr", "width": 1920, "height": 1080, "codec": "hevc", "bitrate": "6000k", "hdr": true},
+        {"name": "1080p-vertical", "width": 1080, "height": 1920, "bitrate": "4500k", "vertical": true},
+        {"name": "720p-vertical", "width": 720, "height": 1280, "bitrate": "2500k", "vertical": true},
+        {"name": "480p-vertical", "width": 480, "height": 854, "bitrate": "1000k", "vertical": true}
+    ]'
+);
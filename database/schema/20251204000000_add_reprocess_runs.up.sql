@@ -0,0 +1,23 @@
+-- Bulk reprocessing runs. A run queues a processing job for every video matching its filter,
+-- in id order and at a bounded rate; last_video_id lets another instance resume it.
+CREATE TABLE reprocess_runs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    preset_id UUID REFERENCES transcoding_presets(id) ON DELETE SET NULL, -- NULL uses the default preset
+    user_id UUID, -- only videos of this user when set
+    created_after TIMESTAMPTZ,
+    created_before TIMESTAMPTZ NOT NULL, -- videos uploaded once the run started are left alone
+    rate_per_minute INT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'running', -- running, completed, cancelled
+    total INT NOT NULL,
+    enqueued INT NOT NULL DEFAULT 0,
+    succeeded INT NOT NULL DEFAULT 0,
+    failed INT NOT NULL DEFAULT 0,
+    last_video_id UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000000',
+    lease_until TIMESTAMPTZ, -- an instance is queueing the run until then
+    error VARCHAR NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    finished_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_reprocess_runs_status ON reprocess_runs(status);
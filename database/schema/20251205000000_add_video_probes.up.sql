@@ -0,0 +1,6 @@
+-- The ffprobe analysis of each source, kept for debugging playback complaints
+CREATE TABLE video_probes (
+    video_id UUID PRIMARY KEY REFERENCES videos(id) ON DELETE CASCADE,
+    probe JSONB NOT NULL,
+    probed_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
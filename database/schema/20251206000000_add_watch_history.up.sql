@@ -0,0 +1,11 @@
+-- Playback position of each video a user watched; the rows by watched_at are the watch history
+CREATE TABLE watch_history (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    video_id UUID NOT NULL REFERENCES videos(id) ON DELETE CASCADE,
+    position_ms BIGINT NOT NULL,
+    duration_ms BIGINT NOT NULL DEFAULT 0,
+    watched_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, video_id)
+);
+
+CREATE INDEX idx_watch_history_user_watched_at ON watch_history (user_id, watched_at DESC);
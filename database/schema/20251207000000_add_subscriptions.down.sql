@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS notifications;
+DROP TABLE IF EXISTS subscriptions;
+DROP INDEX IF EXISTS idx_videos_user_published_at;
+ALTER TABLE videos
+    DROP COLUMN IF EXISTS published_at,
+    DROP COLUMN IF EXISTS visibility;
@@ -0,0 +1,29 @@
+-- Videos are private to their owner until published; published_at orders the feeds
+ALTER TABLE videos
+    ADD COLUMN visibility VARCHAR(20) NOT NULL DEFAULT 'private', -- private, public
+    ADD COLUMN published_at TIMESTAMPTZ;
+
+CREATE INDEX idx_videos_user_published_at ON videos (user_id, published_at DESC) WHERE visibility = 'public';
+
+-- A subscriber follows the public videos of a creator
+CREATE TABLE subscriptions (
+    subscriber_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    creator_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (subscriber_id, creator_id),
+    CHECK (subscriber_id <> creator_id)
+);
+
+CREATE INDEX idx_subscriptions_creator ON subscriptions (creator_id);
+
+-- In-app notifications, written for every subscriber when a creator publishes a video
+CREATE TABLE notifications (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    kind VARCHAR(50) NOT NULL, -- new_video
+    video_id UUID REFERENCES videos(id) ON DELETE CASCADE,
+    read_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_notifications_user_created_at ON notifications (user_id, created_at DESC);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS video_reports;
+ALTER TABLE notifications DROP COLUMN IF EXISTS message;
+ALTER TABLE videos DROP COLUMN IF EXISTS age_restricted;
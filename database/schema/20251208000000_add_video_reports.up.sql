@@ -0,0 +1,22 @@
+-- Moderators hide (visibility 'hidden') or remove (visibility 'removed') reported videos,
+-- or restrict them to adults
+ALTER TABLE videos ADD COLUMN age_restricted BOOLEAN NOT NULL DEFAULT FALSE;
+
+ALTER TABLE notifications ADD COLUMN message TEXT NOT NULL DEFAULT '';
+
+CREATE TABLE video_reports (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    video_id UUID NOT NULL REFERENCES videos(id) ON DELETE CASCADE,
+    reporter_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    reason VARCHAR(50) NOT NULL,
+    details TEXT NOT NULL DEFAULT '',
+    status VARCHAR(20) NOT NULL DEFAULT 'open', -- open, resolved, dismissed
+    action VARCHAR(20), -- hide, age_restrict, remove; set when resolved
+    resolved_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    resolved_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- one open report per user and video
+CREATE UNIQUE INDEX idx_video_reports_open ON video_reports (video_id, reporter_id) WHERE status = 'open';
+CREATE INDEX idx_video_reports_status_created_at ON video_reports (status, created_at);
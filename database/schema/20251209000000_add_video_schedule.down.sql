@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_videos_expires_at;
+DROP INDEX IF EXISTS idx_videos_publish_at;
+ALTER TABLE videos
+    DROP COLUMN IF EXISTS purge_on_expiry,
+    DROP COLUMN IF EXISTS expires_at,
+    DROP COLUMN IF EXISTS publish_at;
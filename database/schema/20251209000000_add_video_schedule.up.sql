@@ -0,0 +1,9 @@
+-- Private videos with publish_at become public at that time; videos past expires_at are
+-- made private again, or deleted with their objects when purge_on_expiry is set
+ALTER TABLE videos
+    ADD COLUMN publish_at TIMESTAMPTZ,
+    ADD COLUMN expires_at TIMESTAMPTZ,
+    ADD COLUMN purge_on_expiry BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE INDEX idx_videos_publish_at ON videos (publish_at) WHERE publish_at IS NOT NULL;
+CREATE INDEX idx_videos_expires_at ON videos (expires_at) WHERE expires_at IS NOT NULL;
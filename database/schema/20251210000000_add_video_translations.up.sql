@@ -0,0 +1,9 @@
+-- Localized title and description of a video, one row per BCP 47 language tag
+CREATE TABLE video_translations (
+    video_id UUID NOT NULL REFERENCES videos(id) ON DELETE CASCADE,
+    language TEXT NOT NULL,
+    title TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (video_id, language)
+);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS playback_failures;
+ALTER TABLE videos DROP COLUMN IF EXISTS playback_password_hash;
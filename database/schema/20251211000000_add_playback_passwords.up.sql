@@ -0,0 +1,11 @@
+-- Videos with a playback password are played by anyone who knows it, signed in or not
+ALTER TABLE videos ADD COLUMN playback_password_hash TEXT;
+
+-- Failed playback password attempts per video and client, counted within a window
+CREATE TABLE playback_failures (
+    video_id UUID NOT NULL REFERENCES videos(id) ON DELETE CASCADE,
+    client TEXT NOT NULL,
+    failures INTEGER NOT NULL DEFAULT 1,
+    window_start TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (video_id, client)
+);
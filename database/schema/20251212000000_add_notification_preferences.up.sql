@@ -0,0 +1,8 @@
+-- Channels each kind of notification reaches a user on, as {"kind": ["in_app", ...]}.
+-- Kinds left out, and users without a row, get in-app notifications only.
+CREATE TABLE notification_preferences (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    channels JSONB NOT NULL DEFAULT '{}',
+    webhook_url TEXT NOT NULL DEFAULT '',
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
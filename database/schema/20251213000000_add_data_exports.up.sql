@@ -0,0 +1,19 @@
+-- Exports of all the data of a user. Any instance claims a pending export, writes its archive
+-- to the bucket of the user and deletes the archive once it expires.
+CREATE TABLE data_exports (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending', -- pending, running, completed, failed, expired
+    bucket VARCHAR NOT NULL DEFAULT '',
+    key VARCHAR NOT NULL DEFAULT '',
+    size_bytes BIGINT NOT NULL DEFAULT 0,
+    error VARCHAR NOT NULL DEFAULT '',
+    lease_until TIMESTAMPTZ, -- an instance is writing the archive until then
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    finished_at TIMESTAMPTZ,
+    expires_at TIMESTAMPTZ -- the archive is deleted then
+);
+
+-- a user has at most one export in progress
+CREATE UNIQUE INDEX idx_data_exports_active ON data_exports(user_id) WHERE status IN ('pending', 'running');
+CREATE INDEX idx_data_exports_status ON data_exports(status);
@@ -0,0 +1,15 @@
+-- Rendition sets replaced by reprocessing. Every processing run writes its outputs under a
+-- prefix of its own, so a version only keeps the variant and asset rows of the set it
+-- replaced; rolling back restores them. Versions and their objects are deleted once the
+-- retention period has passed.
+CREATE TABLE video_rendition_versions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    video_id UUID NOT NULL REFERENCES videos(id) ON DELETE CASCADE,
+    version INT NOT NULL,
+    variants JSONB NOT NULL, -- the video_variants rows of the set
+    assets JSONB NOT NULL, -- the video_assets rows of the set
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP, -- when the set was replaced
+    CONSTRAINT video_rendition_versions_video_id_version_key UNIQUE (video_id, version)
+);
+
+CREATE INDEX idx_video_rendition_versions_created_at ON video_rendition_versions(created_at);
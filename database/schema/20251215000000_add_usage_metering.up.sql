@@ -0,0 +1,14 @@
+-- Usage of each user per calendar month (UTC), added to as it happens. Closed months are
+-- posted to the billing webhook once; an instance leases the rows it is posting.
+CREATE TABLE usage_monthly (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    month DATE NOT NULL, -- first day of the month
+    transcode_ms BIGINT NOT NULL DEFAULT 0, -- source duration times the variants encoded
+    storage_bytes BIGINT NOT NULL DEFAULT 0, -- uploaded sources and processing results written
+    delivery_bytes BIGINT NOT NULL DEFAULT 0, -- bytes of the user's videos downloaded through the API
+    emit_lease_until TIMESTAMPTZ,
+    emitted_at TIMESTAMPTZ, -- when the closed month was posted to the billing webhook
+    PRIMARY KEY (user_id, month)
+);
+
+CREATE INDEX idx_usage_monthly_unemitted ON usage_monthly(month) WHERE emitted_at IS NULL;
@@ -0,0 +1,3 @@
+ALTER TABLE videos
+DROP COLUMN IF EXISTS source_width,
+DROP COLUMN IF EXISTS source_height;
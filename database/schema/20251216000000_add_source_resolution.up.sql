@@ -0,0 +1,4 @@
+-- Resolution of the source video stream, NULL until the worker probed it
+ALTER TABLE videos
+ADD COLUMN source_width INT,
+ADD COLUMN source_height INT;
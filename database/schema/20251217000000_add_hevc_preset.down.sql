@@ -0,0 +1 @@
+DELETE FROM transcoding_presets WHERE name = 'hevc';
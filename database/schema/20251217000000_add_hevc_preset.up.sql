@@ -0,0 +1,22 @@
+-- A ladder with an SDR HEVC rendition set next to the H.264 one. Players that decode HEVC
+-- pick its rungs from the CODECS of the master playlist, at about 60% of the H.264 bitrates.
+INSERT INTO transcoding_presets (name, description, variants) VALUES (
+    'hevc',
+    'The default ladder with an HEVC rendition set from 1080p to 360p for clients that decode it',
+    '[
+        {"name": "1080p", "width": 1920, "height": 1080, "bitrate": "4000k"},
+        {"name": "720p", "width": 1280, "height": 720, "bitrate": "2000k"},
+        {"name": "480p", "width": 854, "height": 480, "bitrate": "1000k"},
+        {"name": "360p", "width": 640, "height": 360, "bitrate": "500k"},
+        {"name": "240p", "width": 426, "height": 240, "bitrate": "250k"},
+        {"name": "144p", "width": 256, "height": 144, "bitrate": "100k"},
+        {"name": "1080p-hevc", "width": 1920, "height": 1080, "codec": "hevc", "bitrate": "2500k"},
+        {"name": "720p-hevc", "width": 1280, "height": 720, "codec": "hevc", "bitrate": "1200k"},
+        {"name": "480p-hevc", "width": 854, "height": 480, "codec": "hevc", "bitrate": "600k"},
+        {"name": "360p-hevc", "width": 640, "height": 360, "codec": "hevc", "bitrate": "300k"},
+        {"name": "1080p-hdr", "width": 1920, "height": 1080, "codec": "hevc", "bitrate": "6000k", "hdr": true},
+        {"name": "1080p-vertical", "width": 1080, "height": 1920, "bitrate": "4500k", "vertical": true},
+        {"name": "720p-vertical", "width": 720, "height": 1280, "bitrate": "2500k", "vertical": true},
+        {"name": "480p-vertical", "width": 480, "height": 854, "bitrate": "1000k", "vertical": true}
+    ]'
+) ON CONFLICT (name) DO NOTHING;
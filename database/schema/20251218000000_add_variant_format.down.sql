@@ -0,0 +1,8 @@
+DELETE FROM video_variants WHERE format <> 'mp4';
+
+ALTER TABLE video_variants
+DROP CONSTRAINT IF EXISTS video_variants_video_id_variant_name_format_key,
+ADD CONSTRAINT video_variants_video_id_variant_name_key UNIQUE (video_id, variant_name);
+
+ALTER TABLE video_variants
+DROP COLUMN IF EXISTS format;
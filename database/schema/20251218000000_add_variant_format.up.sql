@@ -0,0 +1,8 @@
+-- Container of each rendition file. A variant has one row per format, the MP4 one carrying
+-- the HLS playlist and thumbnail.
+ALTER TABLE video_variants
+ADD COLUMN format VARCHAR(10) NOT NULL DEFAULT 'mp4';
+
+ALTER TABLE video_variants
+DROP CONSTRAINT video_variants_video_id_variant_name_key,
+ADD CONSTRAINT video_variants_video_id_variant_name_format_key UNIQUE (video_id, variant_name, format);
@@ -0,0 +1,3 @@
+ALTER TABLE transcoding_presets
+DROP COLUMN IF EXISTS rate_control,
+DROP COLUMN IF EXISTS crf;
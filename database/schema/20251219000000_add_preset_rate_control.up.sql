@@ -0,0 +1,5 @@
+-- How the rungs of a preset are rate controlled: at their bitrate, or at constant quality
+-- capped by it. crf is the quality of rungs that set none, 0 for the encoder's default.
+ALTER TABLE transcoding_presets
+ADD COLUMN rate_control VARCHAR(10) NOT NULL DEFAULT 'bitrate',
+ADD COLUMN crf INT NOT NULL DEFAULT 0;
@@ -0,0 +1,2 @@
+ALTER TABLE video_variants
+DROP COLUMN IF EXISTS audio_languages;
@@ -0,0 +1,4 @@
+-- Languages of the audio tracks of each rendition file, in track order. "und" stands for a
+-- track without a language tag.
+ALTER TABLE video_variants
+ADD COLUMN audio_languages TEXT[] NOT NULL DEFAULT '{}';
@@ -0,0 +1,12 @@
+-- Text subtitle tracks of the source extracted to WebVTT sidecars, one row per track
+CREATE TABLE video_subtitles (
+    video_id UUID NOT NULL REFERENCES videos(id) ON DELETE CASCADE,
+    track INT NOT NULL, -- position among the subtitle streams of the source
+    language VARCHAR(20) NOT NULL, -- ISO 639-2 tag of the stream, "und" when untagged
+    label VARCHAR(255) NOT NULL DEFAULT '', -- title of the stream, e.g. "English (SDH)"
+    forced BOOLEAN NOT NULL DEFAULT FALSE, -- only subtitles foreign dialogue
+    bucket VARCHAR(255) NOT NULL,
+    key VARCHAR(255) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (video_id, track)
+);
@@ -0,0 +1,11 @@
+-- Thumbnails taken at several points of a video, the owner picks the primary one
+CREATE TABLE video_thumbnails (
+    video_id UUID NOT NULL REFERENCES videos(id) ON DELETE CASCADE,
+    position INT NOT NULL, -- order of the thumbnail along the video
+    at_ms BIGINT NOT NULL, -- where in the video the frame was taken
+    bucket VARCHAR(255) NOT NULL,
+    key VARCHAR(255) NOT NULL,
+    is_primary BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (video_id, position)
+);
@@ -0,0 +1,8 @@
+-- How far the variants of a video being processed are, for percent-complete reporting
+CREATE TABLE video_progress (
+    video_id UUID NOT NULL REFERENCES videos(id) ON DELETE CASCADE,
+    variant VARCHAR(50) NOT NULL,
+    percent SMALLINT NOT NULL CHECK (percent BETWEEN 0 AND 100),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (video_id, variant)
+);
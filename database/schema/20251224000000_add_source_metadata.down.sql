@@ -0,0 +1,6 @@
+ALTER TABLE videos
+DROP COLUMN IF EXISTS duration_ms,
+DROP COLUMN IF EXISTS source_codec,
+DROP COLUMN IF EXISTS source_fps,
+DROP COLUMN IF EXISTS audio_channels,
+DROP COLUMN IF EXISTS container;
@@ -0,0 +1,7 @@
+-- Technical metadata of the source, NULL until the worker probed it
+ALTER TABLE videos
+ADD COLUMN duration_ms BIGINT,
+ADD COLUMN source_codec VARCHAR(50),
+ADD COLUMN source_fps DOUBLE PRECISION,
+ADD COLUMN audio_channels INT,
+ADD COLUMN container VARCHAR(100);
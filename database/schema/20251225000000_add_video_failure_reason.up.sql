@@ -0,0 +1,2 @@
+-- Why the worker rejected the source of a failed video, NULL otherwise
+ALTER TABLE videos ADD COLUMN failure_reason TEXT;
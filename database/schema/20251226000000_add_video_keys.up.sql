@@ -0,0 +1,7 @@
+-- AES-128 keys of videos with encrypted HLS segments. They never reach the buckets the segments
+-- are served from; the API hands them to the viewers of the video.
+CREATE TABLE video_keys (
+    video_id UUID PRIMARY KEY REFERENCES videos(id) ON DELETE CASCADE,
+    key BYTEA NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1 @@
+ALTER TABLE video_progress DROP COLUMN IF EXISTS started_at;
@@ -0,0 +1,2 @@
+-- When the encode of a variant started, the rate its progress is extrapolated at for an ETA
+ALTER TABLE video_progress ADD COLUMN started_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP;
@@ -0,0 +1,7 @@
+-- Expired videos whose purge failed, left out of the expiry rounds until retry_at so they are
+-- not retried in a tight loop
+CREATE TABLE video_purge_failures (
+    video_id UUID PRIMARY KEY REFERENCES videos(id) ON DELETE CASCADE,
+    retry_at TIMESTAMPTZ NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 1
+);
@@ -0,0 +1,2 @@
+-- Key of the master playlist of the video's current rendition set, in the video's bucket
+ALTER TABLE videos ADD COLUMN master_playlist_key TEXT;
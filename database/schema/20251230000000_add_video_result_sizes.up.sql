@@ -0,0 +1,9 @@
+-- Bytes under the results prefix of each processing run of a video, kept until the run's
+-- objects are deleted. With the sources, they are the storage a user holds, which
+-- usage_monthly.storage_bytes records the peak of from now on.
+CREATE TABLE video_result_sizes (
+    video_id UUID NOT NULL REFERENCES videos(id) ON DELETE CASCADE,
+    prefix TEXT NOT NULL,
+    bytes BIGINT NOT NULL,
+    PRIMARY KEY (video_id, prefix)
+);
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_video_progress_done;
@@ -0,0 +1,2 @@
+-- Completed variants, which the ETAs of running ones are estimated from
+CREATE INDEX idx_video_progress_done ON video_progress(variant, updated_at) WHERE percent = 100;
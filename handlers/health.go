@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"net/http"
+	"video-processing/services/video"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Health interface {
+	Health(ctx *gin.Context)
+}
+
+type healthHandler struct {
+	capabilities video.Capabilities
+}
+
+func NewHealth(capabilities video.Capabilities) Health {
+	return &healthHandler{capabilities: capabilities}
+}
+
+// @Summary Service health
+// @Description Reports that the service is up, with the ffmpeg capabilities detected at startup
+// @Tags health
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Router /health [get]
+func (h *healthHandler) Health(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{
+		"ok": true,
+		"data": gin.H{
+			"status":       "ok",
+			"capabilities": h.capabilities,
+		},
+		"error": nil,
+	})
+}
@@ -16,6 +16,9 @@ import (
 
 type Middleware interface {
 	Authenticate() gin.HandlerFunc
+	// AuthenticateOptional authenticates requests with an access token and lets the others through
+	AuthenticateOptional() gin.HandlerFunc
+	Authorize() gin.HandlerFunc
 	Cors() gin.HandlerFunc
 	// BeforeWsConnection() gin.HandlerFunc
 	ErrorMiddleware() gin.HandlerFunc
@@ -79,12 +82,33 @@ func (m *middleware) Authenticate() gin.HandlerFunc {
 			ctx.Abort()
 			return
 		}
+		if payload.Purpose != "" {
+			ctx.Error(models.Error{
+				Code:        http.StatusUnauthorized,
+				Message:     "access denied",
+				Description: "token is not an access token",
+				Err:         fmt.Errorf("invalid access token: %s token", payload.Purpose),
+			})
+			ctx.Abort()
+			return
+		}
 
 		ctx.Set("user_id", payload.ID)
 		ctx.Next()
 	}
 }
 
+func (m *middleware) AuthenticateOptional() gin.HandlerFunc {
+	authenticate := m.Authenticate()
+	return func(ctx *gin.Context) {
+		if ctx.Request.Header.Get("Authorization") == "" {
+			ctx.Next()
+			return
+		}
+		authenticate(ctx)
+	}
+}
+
 func (m *middleware) Cors() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		ctx.Header("Access-Control-Allow-Origin", "*")
@@ -149,7 +173,8 @@ func (m *middleware) Authorize() gin.HandlerFunc {
 		obj := ctx.Request.URL.Path
 		act := ctx.Request.Method
 		dom := KnowDomain(obj)
-		result, err := m.enforcer.Enforce(user_id, dom, obj, act)
+		// casbin matchers only work with string subjects
+		result, err := m.enforcer.Enforce(fmt.Sprint(user_id), dom, obj, act)
 		if err != nil {
 			err := &models.Error{
 				Code:    http.StatusUnauthorized,
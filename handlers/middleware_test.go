@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"video-processing/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/o1egl/paseto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthenticateRejectsPlaybackTokens(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tm := utils.NewTokenManager("qwertyuiopasdfghjklzxcvbnm123456", time.Hour, *paseto.NewV2())
+	middleware := NewMiddleware(tm, nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	engine := gin.New()
+	engine.Use(middleware.ErrorMiddleware())
+	engine.GET("/user", middleware.Authenticate(), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	access, err := tm.CreateToken(utils.NewPayload(uuid.New(), time.Hour))
+	require.NoError(t, err)
+	playback, err := tm.CreateToken(utils.Payload{ID: uuid.New(), IssuedAt: time.Now(), Purpose: utils.PurposePlayback})
+	require.NoError(t, err)
+
+	for token, code := range map[string]int{access: http.StatusOK, playback: http.StatusUnauthorized} {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/user", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		engine.ServeHTTP(rec, req)
+		require.Equal(t, code, rec.Code)
+	}
+}
+
+func TestAuthenticateOptional(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tm := utils.NewTokenManager("qwertyuiopasdfghjklzxcvbnm123456", time.Hour, *paseto.NewV2())
+	middleware := NewMiddleware(tm, nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	engine := gin.New()
+	engine.Use(middleware.ErrorMiddleware())
+	var seen uuid.UUID
+	engine.GET("/key", middleware.AuthenticateOptional(), func(c *gin.Context) {
+		seen, _ = c.Value("user_id").(uuid.UUID)
+		c.Status(http.StatusOK)
+	})
+
+	// anonymous requests pass without a user
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/key", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, uuid.Nil, seen)
+
+	userID := uuid.New()
+	access, err := tm.CreateToken(utils.NewPayload(userID, time.Hour))
+	require.NoError(t, err)
+	rec = httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/key", nil)
+	req.Header.Set("Authorization", "Bearer "+access)
+	engine.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, userID, seen)
+
+	// a token that is sent must be an access token
+	playback, err := tm.CreateToken(utils.Payload{ID: uuid.New(), IssuedAt: time.Now(), Purpose: utils.PurposePlayback})
+	require.NoError(t, err)
+	rec = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/key", nil)
+	req.Header.Set("Authorization", "Bearer "+playback)
+	engine.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+}
@@ -1,9 +1,15 @@
 package handlers
 
 import (
+	"bytes"
 	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
 	"log/slog"
+	"mime"
 	"net/http"
+	"strconv"
 	"time"
 
 	"video-processing/models"
@@ -11,10 +17,76 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"golang.org/x/text/language"
 )
 
 type VideoProcessor interface {
 	Upload(ctx *gin.Context)
+	ListVideos(ctx *gin.Context)
+	GetVideo(ctx *gin.Context)
+	StreamProgress(ctx *gin.Context)
+	GetChapters(ctx *gin.Context)
+	SetChapters(ctx *gin.Context)
+	SetPrimaryThumbnail(ctx *gin.Context)
+	EditVideo(ctx *gin.Context)
+	ComposeOverlay(ctx *gin.Context)
+	CreateClip(ctx *gin.Context)
+	CreateAudiogram(ctx *gin.Context)
+	CreateSlideshow(ctx *gin.Context)
+	ChangeSpeed(ctx *gin.Context)
+	SetWatermark(ctx *gin.Context)
+	DeleteWatermark(ctx *gin.Context)
+	SetBumper(ctx *gin.Context)
+	DeleteBumper(ctx *gin.Context)
+	ListDuplicates(ctx *gin.Context)
+	QualityReport(ctx *gin.Context)
+	IngestEvents(ctx *gin.Context)
+	ListPresets(ctx *gin.Context)
+	GetPreset(ctx *gin.Context)
+	CreatePreset(ctx *gin.Context)
+	UpdatePreset(ctx *gin.Context)
+	DeletePreset(ctx *gin.Context)
+	StartReprocess(ctx *gin.Context)
+	ListReprocessRuns(ctx *gin.Context)
+	GetReprocessRun(ctx *gin.Context)
+	CancelReprocessRun(ctx *gin.Context)
+	ProbeVideo(ctx *gin.Context)
+	AdminProbeVideo(ctx *gin.Context)
+	ExtractFrame(ctx *gin.Context)
+	BoostJob(ctx *gin.Context)
+	SavePosition(ctx *gin.Context)
+	GetPosition(ctx *gin.Context)
+	ListHistory(ctx *gin.Context)
+	DeleteHistoryEntry(ctx *gin.Context)
+	ClearHistory(ctx *gin.Context)
+	SetVisibility(ctx *gin.Context)
+	Subscribe(ctx *gin.Context)
+	Unsubscribe(ctx *gin.Context)
+	ListSubscriptions(ctx *gin.Context)
+	Feed(ctx *gin.Context)
+	ListNotifications(ctx *gin.Context)
+	MarkNotificationsRead(ctx *gin.Context)
+	GetNotificationPreferences(ctx *gin.Context)
+	SetNotificationPreferences(ctx *gin.Context)
+	ExportData(ctx *gin.Context)
+	GetDataExport(ctx *gin.Context)
+	ReportVideo(ctx *gin.Context)
+	ListReports(ctx *gin.Context)
+	ModerateReport(ctx *gin.Context)
+	SetSchedule(ctx *gin.Context)
+	SetTranslation(ctx *gin.Context)
+	DeleteTranslation(ctx *gin.Context)
+	ListTranslations(ctx *gin.Context)
+	SetPlaybackPassword(ctx *gin.Context)
+	RemovePlaybackPassword(ctx *gin.Context)
+	AuthorizePlayback(ctx *gin.Context)
+	Playback(ctx *gin.Context)
+	GetVideoKey(ctx *gin.Context)
+	Download(ctx *gin.Context)
+	ListRenditionVersions(ctx *gin.Context)
+	RollbackRenditions(ctx *gin.Context)
+	GetUsage(ctx *gin.Context)
+	ExportUsage(ctx *gin.Context)
 }
 
 type videoHandler struct {
@@ -38,6 +110,19 @@ func NewVideoHandler(logger *slog.Logger, timeout time.Duration, services video.
 // @Param videos formData file true "Video file"
 // @Param title formData string true "Video title"
 // @Param description formData string true "Video description"
+// @Param preset formData string false "Transcoding preset name, the default preset when empty"
+// @Param burn_subtitle_track formData int false "Text subtitle track of the source to burn into the video"
+// @Param burn_subtitles formData file false "SRT file to burn into the video, single video uploads only"
+// @Param watermark_position formData string false "Watermark position: top-left, top-right, bottom-left, bottom-right or center"
+// @Param watermark_opacity formData number false "Watermark opacity, above 0 and up to 1"
+// @Param trim_start formData number false "Seconds cut from the beginning of the video"
+// @Param trim_end formData number false "Seconds into the video where it is cut off, 0 keeps it to the end"
+// @Param deinterlace formData string false "Deinterlacing: auto (default) detects interlaced sources, on or off override the detection"
+// @Param crop_black_bars formData bool false "Crop letterbox and pillarbox bars, so the variants hold only picture"
+// @Param denoise formData string false "Denoising of noisy low-light footage: off, light or strong; the configured mode when empty"
+// @Param stabilize formData bool false "Stabilize shaky handheld footage before encoding"
+// @Param skip_bumpers formData bool false "Leave out the intro and outro otherwise joined to the video"
+// @Param mode formData string false "Processing mode: fast, balanced or quality; the mode of the user's plan when empty"
 // @Success 200 {object} map[string]interface{} "Video uploaded successfully"
 // @Failure 400 {object} map[string]interface{} "Bad request"
 // @Failure 500 {object} map[string]interface{} "Internal server error"
@@ -75,3 +160,2434 @@ func (vh videoHandler) Upload(c *gin.Context) {
 		"error": nil,
 	})
 }
+
+// currentUser reads the authenticated user id.
+// On failure the error is attached to the context and ok is false.
+func currentUser(c *gin.Context) (uuid.UUID, bool) {
+	userID, ok := c.Value("user_id").(uuid.UUID)
+	if !ok {
+		c.Error(&models.Error{
+			Code:    http.StatusUnauthorized,
+			Message: "failed to get user_id from context",
+			Err:     fmt.Errorf("user_id not found in context"),
+		})
+	}
+	return userID, ok
+}
+
+// ownerAndVideoID reads the authenticated user id and the :id path parameter.
+// On failure the error is attached to the context and ok is false.
+func ownerAndVideoID(c *gin.Context) (userID, videoID uuid.UUID, ok bool) {
+	userID, ok = c.Value("user_id").(uuid.UUID)
+	if !ok {
+		c.Error(&models.Error{
+			Code:    http.StatusUnauthorized,
+			Message: "failed to get user_id from context",
+			Err:     fmt.Errorf("user_id not found in context"),
+		})
+		return uuid.Nil, uuid.Nil, false
+	}
+	videoID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.Error(&models.Error{
+			Code:    http.StatusBadRequest,
+			Message: "invalid video id",
+			Params:  fmt.Sprintf("id: %s", c.Param("id")),
+			Err:     errors.Join(err, models.ErrInvalidUUID),
+		})
+		return uuid.Nil, uuid.Nil, false
+	}
+	return userID, videoID, true
+}
+
+// anyLanguage is how ParseAcceptLanguage returns the * wildcard, which the original metadata serves
+var anyLanguage = language.Make("mul")
+
+// acceptLanguages reads the languages of the Accept-Language header, best first, and
+// marks the response as depending on it
+func acceptLanguages(c *gin.Context) []string {
+	c.Header("Vary", "Accept-Language")
+	tags, q, err := language.ParseAcceptLanguage(c.GetHeader("Accept-Language"))
+	if err != nil {
+		return nil
+	}
+	languages := make([]string, 0, len(tags))
+	for i, tag := range tags {
+		if q[i] > 0 && tag != language.Und && tag != anyLanguage {
+			languages = append(languages, tag.String())
+		}
+	}
+	return languages
+}
+
+// ListVideos lists the user's videos.
+// @Summary List videos
+// @Description List the user's videos, newest first, with a presigned URL of each video's short preview clip
+// @Tags video
+// @Produce json
+// @Param limit query int false "Page size (1-100), default 20"
+// @Param offset query int false "Number of videos to skip"
+// @Param Accept-Language header string false "Preferred languages of titles and descriptions"
+// @Success 200 {array} models.VideoSummary
+// @Failure 400 {object} map[string]any
+// @Router /v1/videos [get]
+// @Security BearerAuth
+func (vh videoHandler) ListVideos(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), vh.timeout)
+	defer cancel()
+	uid, ok := c.Value("user_id").(uuid.UUID)
+	if !ok {
+		c.Error(&models.Error{
+			Code:    http.StatusUnauthorized,
+			Message: "failed to get user_id from context",
+			Err:     fmt.Errorf("user_id not found in context"),
+		})
+		return
+	}
+	query := models.ListVideosQuery{Limit: 20}
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.Error(&models.Error{
+			Code:    http.StatusBadRequest,
+			Message: "failed to bind request data",
+			Err:     err,
+		})
+		return
+	}
+	query.Languages = acceptLanguages(c)
+	videos, err := vh.services.ListVideos(ctx, uid, query)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"ok":    true,
+		"data":  videos,
+		"error": nil,
+	})
+}
+
+// GetVideo returns a video with its variants, assets and chapters.
+// @Summary Get video
+// @Description Get a video owned by the user together with its player metadata
+// @Tags video
+// @Produce json
+// @Param id path string true "Video ID"
+// @Param Accept-Language header string false "Preferred languages of title and description"
+// @Success 200 {object} models.VideoDetail
+// @Failure 400 {object} map[string]any
+// @Failure 404 {object} map[string]any
+// @Router /v1/videos/{id} [get]
+// @Security BearerAuth
+func (vh videoHandler) GetVideo(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), vh.timeout)
+	defer cancel()
+	uid, videoID, ok := ownerAndVideoID(c)
+	if !ok {
+		return
+	}
+	detail, err := vh.services.GetVideo(ctx, uid, videoID, acceptLanguages(c))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"ok":    true,
+		"data":  detail,
+		"error": nil,
+	})
+}
+
+// progressInterval is how often StreamProgress sends the progress of a video
+var progressInterval = 2 * time.Second
+
+// StreamProgress streams the status and processing progress of a video.
+// @Summary Stream processing progress
+// @Description Server-sent events named progress, each with the status of the user's video and the progress and ETA of its variants, every 2 seconds.
+// @Description The stream ends once the video is processed and every variant is done, or with an error event.
+// @Tags video
+// @Produce text/event-stream
+// @Param id path string true "Video ID"
+// @Success 200 {object} models.JobStatus
+// @Failure 400 {object} map[string]any
+// @Failure 404 {object} map[string]any
+// @Router /v1/videos/{id}/progress [get]
+// @Security BearerAuth
+func (vh videoHandler) StreamProgress(c *gin.Context) {
+	uid, videoID, ok := ownerAndVideoID(c)
+	if !ok {
+		return
+	}
+	progress := func() (models.JobStatus, error) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), vh.timeout)
+		defer cancel()
+		return vh.services.GetProgress(ctx, uid, videoID)
+	}
+	// a video the user cannot see fails the request before the stream starts
+	status, err := progress()
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	ticker := time.NewTicker(progressInterval)
+	defer ticker.Stop()
+	c.Header("Cache-Control", "no-cache")
+	for {
+		c.SSEvent("progress", status)
+		c.Writer.Flush()
+		if status.Done() {
+			return
+		}
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-ticker.C:
+		}
+		if status, err = progress(); err != nil {
+			vh.logger.Error("failed to read video progress", "error", err, "videoID", videoID)
+			c.SSEvent("error", gin.H{"message": "failed to read the progress"})
+			return
+		}
+	}
+}
+
+// ProbeVideo returns the technical analysis of a video's source.
+// @Summary Inspect video source
+// @Description ffprobe analysis of the uploaded source: container, streams, codecs, bitrates, duration and color information.
+// @Description The analysis stored at processing time is returned; refresh=true probes the source again.
+// @Tags video
+// @Produce json
+// @Param id path string true "Video ID"
+// @Param refresh query bool false "Probe the source again instead of returning the stored analysis"
+// @Success 200 {object} models.ProbeReport
+// @Failure 400 {object} map[string]any
+// @Failure 404 {object} map[string]any
+// @Failure 422 {object} map[string]any
+// @Router /v1/videos/{id}/probe [get]
+// @Security BearerAuth
+func (vh videoHandler) ProbeVideo(c *gin.Context) {
+	uid, videoID, ok := ownerAndVideoID(c)
+	if !ok {
+		return
+	}
+	vh.probeVideo(c, uid, videoID)
+}
+
+// AdminProbeVideo returns the technical analysis of the source of any user's video.
+// @Summary Inspect any video source
+// @Description Admin variant of the video probe endpoint for debugging playback complaints about videos of other users
+// @Tags admin
+// @Produce json
+// @Param id path string true "Video ID"
+// @Param refresh query bool false "Probe the source again instead of returning the stored analysis"
+// @Success 200 {object} models.ProbeReport
+// @Failure 400 {object} map[string]any
+// @Failure 404 {object} map[string]any
+// @Failure 422 {object} map[string]any
+// @Router /v1/admin/videos/{id}/probe [get]
+// @Security BearerAuth
+func (vh videoHandler) AdminProbeVideo(c *gin.Context) {
+	videoID, ok := idParam(c, "invalid video id")
+	if !ok {
+		return
+	}
+	vh.probeVideo(c, uuid.Nil, videoID)
+}
+
+// ExtractFrame returns a still of a video at a given time.
+// @Summary Extract video frame
+// @Description Extracts the frame at t seconds as a JPEG from the stored source, or from the largest variant when the source is gone.
+// @Description Frames are stored, so repeated requests for the same moment are served without extracting again.
+// @Tags video
+// @Produce json
+// @Param id path string true "Video ID"
+// @Param t query number true "Time of the frame in seconds"
+// @Success 200 {object} models.VideoFrame
+// @Failure 400 {object} map[string]any
+// @Failure 404 {object} map[string]any
+// @Failure 422 {object} map[string]any
+// @Router /v1/videos/{id}/frame [get]
+// @Security BearerAuth
+func (vh videoHandler) ExtractFrame(c *gin.Context) {
+	uid, videoID, ok := ownerAndVideoID(c)
+	if !ok {
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), vh.timeout)
+	defer cancel()
+	var query models.FrameQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.Error(&models.Error{
+			Code:    http.StatusBadRequest,
+			Message: "failed to bind request data",
+			Err:     err,
+		})
+		return
+	}
+	frame, err := vh.services.ExtractFrame(ctx, uid, videoID, query)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"ok":    true,
+		"data":  frame,
+		"error": nil,
+	})
+}
+
+func (vh videoHandler) probeVideo(c *gin.Context, uid, videoID uuid.UUID) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), vh.timeout)
+	defer cancel()
+	refresh, _ := strconv.ParseBool(c.Query("refresh"))
+	report, err := vh.services.ProbeVideo(ctx, uid, videoID, refresh)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"ok":    true,
+		"data":  report,
+		"error": nil,
+	})
+}
+
+// GetChapters returns the chapters of a video.
+// @Summary Get video chapters
+// @Description Get the chapters of a video as JSON, or as a WebVTT chapters track with format=vtt
+// @Tags video
+// @Produce json
+// @Produce text/vtt
+// @Param id path string true "Video ID"
+// @Param format query string false "Response format: json (default) or vtt"
+// @Success 200 {array} models.Chapter
+// @Failure 400 {object} map[string]any
+// @Failure 404 {object} map[string]any
+// @Router /v1/videos/{id}/chapters [get]
+// @Security BearerAuth
+func (vh videoHandler) GetChapters(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), vh.timeout)
+	defer cancel()
+	uid, videoID, ok := ownerAndVideoID(c)
+	if !ok {
+		return
+	}
+	chapters, err := vh.services.GetChapters(ctx, uid, videoID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if c.Query("format") == "vtt" {
+		c.Data(http.StatusOK, "text/vtt; charset=utf-8", []byte(video.ChaptersToWebVTT(chapters)))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"ok":    true,
+		"data":  chapters,
+		"error": nil,
+	})
+}
+
+// SetChapters replaces the chapters of a video.
+// @Summary Set video chapters
+// @Description Replace all chapters of a video with the given list, ordered by start time in seconds
+// @Tags video
+// @Accept json
+// @Produce json
+// @Param id path string true "Video ID"
+// @Param chapters body models.SetChaptersRequest true "Chapters"
+// @Success 200 {array} models.Chapter
+// @Failure 400 {object} map[string]any
+// @Failure 404 {object} map[string]any
+// @Router /v1/videos/{id}/chapters [put]
+// @Security BearerAuth
+func (vh videoHandler) SetChapters(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), vh.timeout)
+	defer cancel()
+	uid, videoID, ok := ownerAndVideoID(c)
+	if !ok {
+		return
+	}
+	var req models.SetChaptersRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(&models.Error{
+			Code:    http.StatusBadRequest,
+			Message: "failed to bind request data",
+			Err:     err,
+		})
+		return
+	}
+	chapters, err := vh.services.SetChapters(ctx, uid, videoID, req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"ok":    true,
+		"data":  chapters,
+		"error": nil,
+	})
+}
+
+// SetPrimaryThumbnail picks the primary thumbnail of a video.
+// @Summary Set primary thumbnail
+// @Description Make one of the thumbnails taken along the video, listed in the video detail, its primary one
+// @Tags video
+// @Accept json
+// @Produce json
+// @Param id path string true "Video ID"
+// @Param thumbnail body models.PrimaryThumbnailRequest true "Position of the thumbnail"
+// @Success 200 {object} models.VideoDetail
+// @Failure 400 {object} map[string]any
+// @Failure 404 {object} map[string]any
+// @Router /v1/videos/{id}/thumbnails/primary [put]
+// @Security BearerAuth
+func (vh videoHandler) SetPrimaryThumbnail(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), vh.timeout)
+	defer cancel()
+	uid, videoID, ok := ownerAndVideoID(c)
+	if !ok {
+		return
+	}
+	var req models.PrimaryThumbnailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(&models.Error{
+			Code:    http.StatusBadRequest,
+			Message: "failed to bind request data",
+			Err:     err,
+		})
+		return
+	}
+	video, err := vh.services.SetPrimaryThumbnail(ctx, uid, videoID, req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"ok":    true,
+		"data":  video,
+		"error": nil,
+	})
+}
+
+// EditVideo renders an edited copy of a video.
+// @Summary Edit video
+// @Description Create a new video by applying speed, crop, rotation and volume operations to an existing one.
+// @Description The edited video is rendered and processed asynchronously; the recipe is stored on the new video.
+// @Tags video
+// @Accept json
+// @Produce json
+// @Param id path string true "Video ID"
+// @Param edit body models.EditRequest true "Edit operations"
+// @Success 202 {object} models.VideoDetail
+// @Failure 400 {object} map[string]any
+// @Failure 404 {object} map[string]any
+// @Router /v1/videos/{id}/edits [post]
+// @Security BearerAuth
+func (vh videoHandler) EditVideo(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), vh.timeout)
+	defer cancel()
+	uid, videoID, ok := ownerAndVideoID(c)
+	if !ok {
+		return
+	}
+	var req models.EditRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(&models.Error{
+			Code:    http.StatusBadRequest,
+			Message: "failed to bind request data",
+			Err:     err,
+		})
+		return
+	}
+	derived, err := vh.services.EditVideo(ctx, uid, videoID, req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{
+		"ok":    true,
+		"data":  derived,
+		"error": nil,
+	})
+}
+
+// ChangeSpeed renders a video at another playback speed.
+// @Summary Change video speed
+// @Description Create a new video playing an existing one at speed times its speed, from 0.1 (slow motion) to 100 (timelapse).
+// @Description The audio keeps its pitch and is kept between speeds 0.25 and 4, unless drop_audio is set.
+// @Description The new video is rendered and processed asynchronously; the recipe is stored on it.
+// @Tags video
+// @Accept json
+// @Produce json
+// @Param id path string true "Video ID"
+// @Param speed body models.SpeedRequest true "Speed factor"
+// @Success 202 {object} models.VideoDetail
+// @Failure 400 {object} map[string]any
+// @Failure 404 {object} map[string]any
+// @Router /v1/videos/{id}/derivatives [post]
+// @Security BearerAuth
+func (vh videoHandler) ChangeSpeed(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), vh.timeout)
+	defer cancel()
+	uid, videoID, ok := ownerAndVideoID(c)
+	if !ok {
+		return
+	}
+	var req models.SpeedRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(&models.Error{
+			Code:    http.StatusBadRequest,
+			Message: "failed to bind request data",
+			Err:     err,
+		})
+		return
+	}
+	derived, err := vh.services.ChangeSpeed(ctx, uid, videoID, req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{
+		"ok":    true,
+		"data":  derived,
+		"error": nil,
+	})
+}
+
+// CreateClip cuts a range of a video into a new video.
+// @Summary Create clip
+// @Description Create a child video holding the range between start and end of an existing one.
+// @Description The clip is cut from the stored source and processed asynchronously into variants of its own.
+// @Tags video
+// @Accept json
+// @Produce json
+// @Param id path string true "Video ID"
+// @Param clip body models.ClipRequest true "Range of the clip in seconds"
+// @Success 202 {object} models.VideoDetail
+// @Failure 400 {object} map[string]any
+// @Failure 404 {object} map[string]any
+// @Router /v1/videos/{id}/clips [post]
+// @Security BearerAuth
+func (vh videoHandler) CreateClip(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), vh.timeout)
+	defer cancel()
+	uid, videoID, ok := ownerAndVideoID(c)
+	if !ok {
+		return
+	}
+	var req models.ClipRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(&models.Error{
+			Code:    http.StatusBadRequest,
+			Message: "failed to bind request data",
+			Err:     err,
+		})
+		return
+	}
+	clip, err := vh.services.CreateClip(ctx, uid, videoID, req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{
+		"ok":    true,
+		"data":  clip,
+		"error": nil,
+	})
+}
+
+// ComposeOverlay renders a copy of a video with another video or an image composed over it.
+// @Summary Compose overlay
+// @Description Create a new video showing a secondary video or a PNG/JPEG image over an existing one (picture-in-picture, watermark, lower third).
+// @Description The overlay is placed at x/y, scaled relative to the base width and shown between start and end.
+// @Tags video
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path string true "Base video ID"
+// @Param overlay_video_id formData string false "Video to show over the base video"
+// @Param image formData file false "Image to show over the base video"
+// @Param x formData int false "Left edge in base video pixels"
+// @Param y formData int false "Top edge in base video pixels"
+// @Param scale formData number true "Overlay width as a share of the base width (0-1]"
+// @Param start formData number false "Seconds into the base video the overlay appears"
+// @Param end formData number false "Seconds into the base video the overlay disappears"
+// @Param title formData string false "Title of the new video"
+// @Success 202 {object} models.VideoDetail
+// @Failure 400 {object} map[string]any
+// @Failure 404 {object} map[string]any
+// @Router /v1/videos/{id}/overlays [post]
+// @Security BearerAuth
+func (vh videoHandler) ComposeOverlay(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), vh.timeout)
+	defer cancel()
+	uid, videoID, ok := ownerAndVideoID(c)
+	if !ok {
+		return
+	}
+	var req models.OverlayRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.Error(&models.Error{
+			Code:    http.StatusBadRequest,
+			Message: "failed to bind request data",
+			Err:     err,
+		})
+		return
+	}
+	derived, err := vh.services.ComposeOverlay(ctx, uid, videoID, req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{
+		"ok":    true,
+		"data":  derived,
+		"error": nil,
+	})
+}
+
+// CreateAudiogram turns an audio file into a video.
+// @Summary Create audiogram
+// @Description Upload an audio file and an optional PNG/JPEG image. A video showing the image, or the audio waveform
+// @Description when no image is given, is rendered for the length of the audio and processed like a regular upload.
+// @Tags video
+// @Accept multipart/form-data
+// @Produce json
+// @Param audio formData file true "Audio file"
+// @Param image formData file false "Still image shown for the whole audio"
+// @Param title formData string true "Video title"
+// @Param description formData string false "Video description"
+// @Success 202 {object} models.VideoDetail
+// @Failure 400 {object} map[string]any
+// @Router /v1/audiograms [post]
+// @Security BearerAuth
+func (vh videoHandler) CreateAudiogram(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), vh.timeout)
+	defer cancel()
+	uid, ok := c.Value("user_id").(uuid.UUID)
+	if !ok {
+		c.Error(&models.Error{
+			Code:    http.StatusUnauthorized,
+			Message: "failed to get user_id from context",
+			Err:     fmt.Errorf("user_id not found in context"),
+		})
+		return
+	}
+	var req models.AudiogramRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.Error(&models.Error{
+			Code:    http.StatusBadRequest,
+			Message: "failed to bind request data",
+			Err:     err,
+		})
+		return
+	}
+	video, err := vh.services.CreateAudiogram(ctx, uid, req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{
+		"ok":    true,
+		"data":  video,
+		"error": nil,
+	})
+}
+
+// CreateSlideshow turns a set of images into a video.
+// @Summary Create slideshow
+// @Description Upload PNG/JPEG images and an optional audio file. A video showing the images in upload order is rendered,
+// @Description over the audio when given, and processed like a regular upload. Without slide_seconds the images share
+// @Description the length of the audio, or are shown for 5 seconds each.
+// @Tags video
+// @Accept multipart/form-data
+// @Produce json
+// @Param images formData file true "Images, in the order they are shown"
+// @Param audio formData file false "Audio played under the images"
+// @Param slide_seconds formData number false "Seconds each image is shown, from 0.5 to 600"
+// @Param title formData string true "Video title"
+// @Param description formData string false "Video description"
+// @Success 202 {object} models.VideoDetail
+// @Failure 400 {object} map[string]any
+// @Router /v1/slideshows [post]
+// @Security BearerAuth
+func (vh videoHandler) CreateSlideshow(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), vh.timeout)
+	defer cancel()
+	uid, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	var req models.SlideshowRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.Error(&models.Error{
+			Code:    http.StatusBadRequest,
+			Message: "failed to bind request data",
+			Err:     err,
+		})
+		return
+	}
+	video, err := vh.services.CreateSlideshow(ctx, uid, req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{
+		"ok":    true,
+		"data":  video,
+		"error": nil,
+	})
+}
+
+// SetWatermark replaces the watermark of the user.
+// @Summary Set my watermark
+// @Description Upload a PNG composed onto every variant of the videos the user uploads from now on, in place of the platform watermark.
+// @Description Uploads place it with their watermark_position and watermark_opacity fields.
+// @Tags user
+// @Accept multipart/form-data
+// @Produce json
+// @Param image formData file true "PNG watermark, transparency is kept"
+// @Success 200 {object} map[string]any
+// @Failure 400 {object} map[string]any
+// @Router /v1/users/watermark [put]
+// @Security BearerAuth
+func (vh videoHandler) SetWatermark(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), vh.timeout)
+	defer cancel()
+	uid, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	var req models.WatermarkRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.Error(&models.Error{
+			Code:    http.StatusBadRequest,
+			Message: "failed to bind request data",
+			Err:     err,
+		})
+		return
+	}
+	if err := vh.services.SetWatermark(ctx, uid, req); err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"ok":    true,
+		"data":  nil,
+		"error": nil,
+	})
+}
+
+// DeleteWatermark removes the watermark of the user.
+// @Summary Delete my watermark
+// @Description Later uploads of the user get the platform watermark, if one is configured
+// @Tags user
+// @Produce json
+// @Success 200 {object} map[string]any
+// @Router /v1/users/watermark [delete]
+// @Security BearerAuth
+func (vh videoHandler) DeleteWatermark(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), vh.timeout)
+	defer cancel()
+	uid, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	if err := vh.services.DeleteWatermark(ctx, uid); err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"ok":    true,
+		"data":  nil,
+		"error": nil,
+	})
+}
+
+// SetBumper replaces the intro or the outro of the user.
+// @Summary Set my bumper
+// @Description Upload a clip joined to the start (intro) or the end (outro) of the videos the user uploads from now on, in place of the platform bumper.
+// @Description The clip is fitted to the size, frame rate and audio of each video.
+// @Tags user
+// @Accept multipart/form-data
+// @Produce json
+// @Param position path string true "intro or outro"
+// @Param video formData file true "Bumper clip"
+// @Success 200 {object} map[string]any
+// @Failure 400 {object} map[string]any
+// @Router /v1/users/bumpers/{position} [put]
+// @Security BearerAuth
+func (vh videoHandler) SetBumper(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), vh.timeout)
+	defer cancel()
+	uid, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	var req models.BumperRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.Error(&models.Error{
+			Code:    http.StatusBadRequest,
+			Message: "failed to bind request data",
+			Err:     err,
+		})
+		return
+	}
+	req.Position = c.Param("position")
+	if err := vh.services.SetBumper(ctx, uid, req); err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"ok":    true,
+		"data":  nil,
+		"error": nil,
+	})
+}
+
+// DeleteBumper removes the intro or the outro of the user.
+// @Summary Delete my bumper
+// @Description Later uploads of the user get the platform bumper, if one is configured
+// @Tags user
+// @Produce json
+// @Param position path string true "intro or outro"
+// @Success 200 {object} map[string]any
+// @Failure 400 {object} map[string]any
+// @Router /v1/users/bumpers/{position} [delete]
+// @Security BearerAuth
+func (vh videoHandler) DeleteBumper(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), vh.timeout)
+	defer cancel()
+	uid, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	if err := vh.services.DeleteBumper(ctx, uid, c.Param("position")); err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"ok":    true,
+		"data":  nil,
+		"error": nil,
+	})
+}
+
+// ListDuplicates reports near-duplicate videos.
+// @Summary Near-duplicate report
+// @Description Admin report of video pairs whose perceptual fingerprints match, for copyright and storage dedup workflows
+// @Tags admin
+// @Produce json
+// @Param min_similarity query number false "Minimum share of matching frames (0-1), default 0.8"
+// @Param cross_user query bool false "Only report pairs uploaded by different users, default true"
+// @Param limit query int false "Maximum number of pairs, default 100"
+// @Success 200 {array} models.DuplicateMatch
+// @Failure 400 {object} map[string]any
+// @Failure 401 {object} map[string]any
+// @Router /v1/admin/videos/duplicates [get]
+// @Security BearerAuth
+func (vh videoHandler) ListDuplicates(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), vh.timeout)
+	defer cancel()
+	query := models.DuplicateReportQuery{
+		MinSimilarity: 0.8,
+		CrossUserOnly: true,
+		Limit:         100,
+	}
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.Error(&models.Error{
+			Code:    http.StatusBadRequest,
+			Message: "failed to bind request data",
+			Err:     err,
+		})
+		return
+	}
+	matches, err := vh.services.FindDuplicates(ctx, query)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"ok":    true,
+		"data":  matches,
+		"error": nil,
+	})
+}
+
+// QualityReport reports the measured quality of each ladder rung.
+// @Summary Rendition quality report
+// @Description Admin report of average and worst VMAF and average PSNR per variant, measured against the sources.
+// @Description Only renditions processed with quality metrics enabled are included.
+// @Tags admin
+// @Produce json
+// @Success 200 {array} models.VariantQuality
+// @Failure 401 {object} map[string]any
+// @Router /v1/admin/quality [get]
+// @Security BearerAuth
+func (vh videoHandler) QualityReport(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), vh.timeout)
+	defer cancel()
+	report, err := vh.services.QualityReport(ctx)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"ok":    true,
+		"data":  report,
+		"error": nil,
+	})
+}
+
+// IngestEvents queues the videos a MinIO bucket notification reports.
+// @Summary Ingest bucket notification
+// @Description Webhook target for MinIO bucket notifications. Every video created in the ingest bucket
+// @Description is queued for processing; known objects, worker outputs and other files are skipped.
+// @Description The Authorization header must carry the configured ingest token.
+// @Tags videos
+// @Accept json
+// @Produce json
+// @Param event body models.S3Event true "Bucket notification"
+// @Success 200 {object} models.IngestResult
+// @Failure 400 {object} map[string]any
+// @Failure 401 {object} map[string]any
+// @Failure 404 {object} map[string]any
+// @Router /v1/ingest/events [post]
+func (vh videoHandler) IngestEvents(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), vh.timeout)
+	defer cancel()
+	var event models.S3Event
+	if err := c.ShouldBindJSON(&event); err != nil {
+		c.Error(&models.Error{
+			Code:    http.StatusBadRequest,
+			Message: "failed to bind request data",
+			Err:     err,
+		})
+		return
+	}
+	result, err := vh.services.Ingest(ctx, c.GetHeader("Authorization"), event)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"ok":    true,
+		"data":  result,
+		"error": nil,
+	})
+}
+
+// ListPresets lists the transcoding presets.
+// @Summary List transcoding presets
+// @Description Admin list of the transcoding presets videos can be processed with
+// @Tags admin
+// @Produce json
+// @Success 200 {array} models.TranscodingPreset
+// @Failure 401 {object} map[string]any
+// @Router /v1/admin/presets [get]
+// @Security BearerAuth
+func (vh videoHandler) ListPresets(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), vh.timeout)
+	defer cancel()
+	presets, err := vh.services.ListPresets(ctx)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"ok":    true,
+		"data":  presets,
+		"error": nil,
+	})
+}
+
+// GetPreset returns one transcoding preset.
+// @Summary Get transcoding preset
+// @Tags admin
+// @Produce json
+// @Param id path string true "Preset ID"
+// @Success 200 {object} models.TranscodingPreset
+// @Failure 400 {object} map[string]any
+// @Failure 404 {object} map[string]any
+// @Router /v1/admin/presets/{id} [get]
+// @Security BearerAuth
+func (vh videoHandler) GetPreset(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), vh.timeout)
+	defer cancel()
+	presetID, ok := presetIDParam(c)
+	if !ok {
+		return
+	}
+	preset, err := vh.services.GetPreset(ctx, presetID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"ok":    true,
+		"data":  preset,
+		"error": nil,
+	})
+}
+
+// CreatePreset adds a transcoding preset.
+// @Summary Create transcoding preset
+// @Description Add a named rendition ladder. Making it the default applies it to every upload that names no preset.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param preset body models.PresetRequest true "Preset"
+// @Success 201 {object} models.TranscodingPreset
+// @Failure 400 {object} map[string]any
+// @Failure 409 {object} map[string]any
+// @Router /v1/admin/presets [post]
+// @Security BearerAuth
+func (vh videoHandler) CreatePreset(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), vh.timeout)
+	defer cancel()
+	var req models.PresetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(&models.Error{
+			Code:    http.StatusBadRequest,
+			Message: "failed to bind request data",
+			Err:     err,
+		})
+		return
+	}
+	preset, err := vh.services.CreatePreset(ctx, req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{
+		"ok":    true,
+		"data":  preset,
+		"error": nil,
+	})
+}
+
+// UpdatePreset replaces a transcoding preset.
+// @Summary Update transcoding preset
+// @Description Replace a preset. Videos already processed keep their renditions; queued videos use the new ladder.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "Preset ID"
+// @Param preset body models.PresetRequest true "Preset"
+// @Success 200 {object} models.TranscodingPreset
+// @Failure 400 {object} map[string]any
+// @Failure 404 {object} map[string]any
+// @Failure 409 {object} map[string]any
+// @Router /v1/admin/presets/{id} [put]
+// @Security BearerAuth
+func (vh videoHandler) UpdatePreset(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), vh.timeout)
+	defer cancel()
+	presetID, ok := presetIDParam(c)
+	if !ok {
+		return
+	}
+	var req models.PresetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(&models.Error{
+			Code:    http.StatusBadRequest,
+			Message: "failed to bind request data",
+			Err:     err,
+		})
+		return
+	}
+	preset, err := vh.services.UpdatePreset(ctx, presetID, req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"ok":    true,
+		"data":  preset,
+		"error": nil,
+	})
+}
+
+// DeletePreset removes a transcoding preset.
+// @Summary Delete transcoding preset
+// @Description Remove a preset other than the default. Queued videos that named it are processed with the default preset.
+// @Tags admin
+// @Produce json
+// @Param id path string true "Preset ID"
+// @Success 200 {object} map[string]any
+// @Failure 404 {object} map[string]any
+// @Failure 409 {object} map[string]any
+// @Router /v1/admin/presets/{id} [delete]
+// @Security BearerAuth
+func (vh videoHandler) DeletePreset(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), vh.timeout)
+	defer cancel()
+	presetID, ok := presetIDParam(c)
+	if !ok {
+		return
+	}
+	if err := vh.services.DeletePreset(ctx, presetID); err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"ok":    true,
+		"data":  nil,
+		"error": nil,
+	})
+}
+
+func presetIDParam(c *gin.Context) (uuid.UUID, bool) {
+	return idParam(c, "invalid preset id")
+}
+
+// idParam parses the id path parameter, reporting message when it is not a UUID
+func idParam(c *gin.Context, message string) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.Error(&models.Error{
+			Code:    http.StatusBadRequest,
+			Message: message,
+			Params:  fmt.Sprintf("id: %s", c.Param("id")),
+			Err:     errors.Join(err, models.ErrInvalidUUID),
+		})
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// StartReprocess queues existing videos for processing again.
+// @Summary Start bulk reprocessing
+// @Description Admin run that reprocesses all videos, or the ones matching the filters, after the ladder or codec settings changed.
+// @Description Videos are queued at rate_per_minute (60 by default); the run survives restarts and resumes where it stopped.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param run body models.ReprocessRequest true "Filters, preset and rate"
+// @Success 202 {object} models.ReprocessRun
+// @Failure 400 {object} map[string]any
+// @Failure 404 {object} map[string]any
+// @Router /v1/admin/reprocess [post]
+// @Security BearerAuth
+func (vh videoHandler) StartReprocess(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), vh.timeout)
+	defer cancel()
+	var req models.ReprocessRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(&models.Error{
+			Code:    http.StatusBadRequest,
+			Message: "failed to bind request data",
+			Err:     err,
+		})
+		return
+	}
+	run, err := vh.services.StartReprocess(ctx, req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{
+		"ok":    true,
+		"data":  run,
+		"error": nil,
+	})
+}
+
+// ListReprocessRuns lists the bulk reprocessing runs.
+// @Summary List reprocess runs
+// @Description Admin list of reprocess runs with their progress, newest first
+// @Tags admin
+// @Produce json
+// @Success 200 {array} models.ReprocessRun
+// @Failure 401 {object} map[string]any
+// @Router /v1/admin/reprocess [get]
+// @Security BearerAuth
+func (vh videoHandler) ListReprocessRuns(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), vh.timeout)
+	defer cancel()
+	runs, err := vh.services.ListReprocessRuns(ctx)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"ok":    true,
+		"data":  runs,
+		"error": nil,
+	})
+}
+
+// GetReprocessRun returns the progress of a bulk reprocessing run.
+// @Summary Get reprocess run
+// @Tags admin
+// @Produce json
+// @Param id path string true "Run ID"
+// @Success 200 {object} models.ReprocessRun
+// @Failure 400 {object} map[string]any
+// @Failure 404 {object} map[string]any
+// @Router /v1/admin/reprocess/{id} [get]
+// @Security BearerAuth
+func (vh videoHandler) GetReprocessRun(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), vh.timeout)
+	defer cancel()
+	runID, ok := idParam(c, "invalid run id")
+	if !ok {
+		return
+	}
+	run, err := vh.services.GetReprocessRun(ctx, runID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"ok":    true,
+		"data":  run,
+		"error": nil,
+	})
+}
+
+// CancelReprocessRun stops a bulk reprocessing run.
+// @Summary Cancel reprocess run
+// @Description Stop queueing the videos of a run. Videos already queued are still processed.
+// @Tags admin
+// @Produce json
+// @Param id path string true "Run ID"
+// @Success 200 {object} models.ReprocessRun
+// @Failure 404 {object} map[string]any
+// @Failure 409 {object} map[string]any
+// @Router /v1/admin/reprocess/{id}/cancel [post]
+// @Security BearerAuth
+func (vh videoHandler) CancelReprocessRun(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), vh.timeout)
+	defer cancel()
+	runID, ok := idParam(c, "invalid run id")
+	if !ok {
+		return
+	}
+	run, err := vh.services.CancelReprocessRun(ctx, runID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"ok":    true,
+		"data":  run,
+		"error": nil,
+	})
+}
+
+// BoostJob moves the queued processing job of a video to the front of the queue.
+// @Summary Boost processing job
+// @Description Support escalation: the job of the video is processed before every other queued job.
+// @Description Only jobs still waiting in the queue can be boosted.
+// @Tags admin
+// @Produce json
+// @Param id path string true "Video ID"
+// @Success 200 {object} map[string]any
+// @Failure 404 {object} map[string]any
+// @Failure 409 {object} map[string]any
+// @Router /v1/admin/jobs/{id}/boost [post]
+// @Security BearerAuth
+func (vh videoHandler) BoostJob(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), vh.timeout)
+	defer cancel()
+	videoID, ok := idParam(c, "invalid video id")
+	if !ok {
+		return
+	}
+	if err := vh.services.BoostJob(ctx, videoID); err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"ok":    true,
+		"data":  gin.H{"video_id": videoID},
+		"error": nil,
+	})
+}
+
+// SavePosition records the playback position of a video for "continue watching".
+// @Summary Save playback position
+// @Description Record how far the user got in a video. Players report it periodically and on pause.
+// @Description Videos played to 95% count as finished and resume from the start.
+// @Tags history
+// @Accept json
+// @Produce json
+// @Param id path string true "Video ID"
+// @Param position body models.WatchPositionRequest true "Position and duration in milliseconds"
+// @Success 200 {object} models.WatchPosition
+// @Failure 400 {object} map[string]any
+// @Failure 404 {object} map[string]any
+// @Router /v1/videos/{id}/position [post]
+// @Security BearerAuth
+func (vh videoHandler) SavePosition(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), vh.timeout)
+	defer cancel()
+	uid, videoID, ok := ownerAndVideoID(c)
+	if !ok {
+		return
+	}
+	var req models.WatchPositionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(&models.Error{
+			Code:    http.StatusBadRequest,
+			Message: "failed to bind request data",
+			Err:     err,
+		})
+		return
+	}
+	position, err := vh.services.SavePosition(ctx, uid, videoID, req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"ok":    true,
+		"data":  position,
+		"error": nil,
+	})
+}
+
+// GetPosition returns where playback of a video resumes.
+// @Summary Get playback position
+// @Description Position to resume the video from, 0 when the user never watched it or finished it
+// @Tags history
+// @Produce json
+// @Param id path string true "Video ID"
+// @Success 200 {object} models.WatchPosition
+// @Failure 400 {object} map[string]any
+// @Failure 404 {object} map[string]any
+// @Router /v1/videos/{id}/position [get]
+// @Security BearerAuth
+func (vh videoHandler) GetPosition(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), vh.timeout)
+	defer cancel()
+	uid, videoID, ok := ownerAndVideoID(c)
+	if !ok {
+		return
+	}
+	position, err := vh.services.GetPosition(ctx, uid, videoID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"ok":    true,
+		"data":  position,
+		"error": nil,
+	})
+}
+
+// ListHistory lists the videos the user watched.
+// @Summary List watch history
+// @Description Videos the user watched, last watched first, with the position to resume from
+// @Tags history
+// @Produce json
+// @Param limit query int false "Page size (1-100), default 20"
+// @Param offset query int false "Number of entries to skip"
+// @Success 200 {array} models.WatchHistoryEntry
+// @Failure 400 {object} map[string]any
+// @Router /v1/history [get]
+// @Security BearerAuth
+func (vh videoHandler) ListHistory(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), vh.timeout)
+	defer cancel()
+	uid, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	query := models.ListVideosQuery{Limit: 20}
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.Error(&models.Error{
+			Code:    http.StatusBadRequest,
+			Message: "failed to bind request data",
+			Err:     err,
+		})
+		return
+	}
+	history, err := vh.services.ListHistory(ctx, uid, query)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"ok":    true,
+		"data":  history,
+		"error": nil,
+	})
+}
+
+// DeleteHistoryEntry removes a video from the watch history.
+// @Summary Remove video from watch history
+// @Description Forget that the user watched the video, including the position to resume from
+// @Tags history
+// @Produce json
+// @Param id path string true "Video ID"
+// @Success 200 {object} map[string]any
+// @Failure 400 {object} map[string]any
+// @Router /v1/history/{id} [delete]
+// @Security BearerAuth
+func (vh videoHandler) DeleteHistoryEntry(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), vh.timeout)
+	defer cancel()
+	uid, videoID, ok := ownerAndVideoID(c)
+	if !ok {
+		return
+	}
+	if err := vh.services.DeleteHistoryEntry(ctx, uid, videoID); err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"ok":    true,
+		"data":  nil,
+		"error": nil,
+	})
+}
+
+// ClearHistory removes every video from the watch history.
+// @Summary Clear watch history
+// @Description Forget every video the user watched and every playback position
+// @Tags history
+// @Produce json
+// @Success 200 {object} map[string]any
+// @Router /v1/history [delete]
+// @Security BearerAuth
+func (vh videoHandler) ClearHistory(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), vh.timeout)
+	defer cancel()
+	uid, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	if err := vh.services.ClearHistory(ctx, uid); err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"ok":    true,
+		"data":  nil,
+		"error": nil,
+	})
+}
+
+// SetVisibility publishes or unpublishes a video.
+// @Summary Set video visibility
+// @Description Public videos are shown to the subscribers of the owner in their feed; the first publication notifies them.
+// @Description Private videos are only visible to the owner.
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param id path string true "Video ID"
+// @Param visibility body models.SetVisibilityRequest true "private or public"
+// @Success 200 {object} models.VideoDetail
+// @Failure 400 {object} map[string]any
+// @Failure 404 {object} map[string]any
+// @Router /v1/videos/{id}/visibility [put]
+// @Security BearerAuth
+func (vh videoHandler) SetVisibility(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), vh.timeout)
+	defer cancel()
+	uid, videoID, ok := ownerAndVideoID(c)
+	if !ok {
+		return
+	}
+	var req models.SetVisibilityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(&models.Error{
+			Code:    http.StatusBadRequest,
+			Message: "failed to bind request data",
+			Err:     err,
+		})
+		return
+	}
+	video, err := vh.services.SetVisibility(ctx, uid, videoID, req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"ok":    true,
+		"data":  video,
+		"error": nil,
+	})
+}
+
+// Subscribe subscribes the user to a creator.
+// @Summary Subscribe to creator
+// @Description The public videos of the creator show up in the feed of the user, and new ones are notified
+// @Tags subscriptions
+// @Produce json
+// @Param id path string true "Creator (user) ID"
+// @Success 200 {object} map[string]any
+// @Failure 400 {object} map[string]any
+// @Failure 404 {object} map[string]any
+// @Router /v1/subscriptions/{id} [post]
+// @Security BearerAuth
+func (vh videoHandler) Subscribe(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), vh.timeout)
+	defer cancel()
+	uid, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	creatorID, ok := idParam(c, "invalid creator id")
+	if !ok {
+		return
+	}
+	if err := vh.services.Subscribe(ctx, uid, creatorID); err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"ok":    true,
+		"data":  nil,
+		"error": nil,
+	})
+}
+
+// Unsubscribe unsubscribes the user from a creator.
+// @Summary Unsubscribe from creator
+// @Tags subscriptions
+// @Produce json
+// @Param id path string true "Creator (user) ID"
+// @Success 200 {object} map[string]any
+// @Failure 400 {object} map[string]any
+// @Router /v1/subscriptions/{id} [delete]
+// @Security BearerAuth
+func (vh videoHandler) Unsubscribe(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), vh.timeout)
+	defer cancel()
+	uid, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	creatorID, ok := idParam(c, "invalid creator id")
+	if !ok {
+		return
+	}
+	if err := vh.services.Unsubscribe(ctx, uid, creatorID); err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"ok":    true,
+		"data":  nil,
+		"error": nil,
+	})
+}
+
+// ListSubscriptions lists the creators the user subscribed to.
+// @Summary List subscriptions
+// @Tags subscriptions
+// @Produce json
+// @Success 200 {array} models.Subscription
+// @Router /v1/subscriptions [get]
+// @Security BearerAuth
+func (vh videoHandler) ListSubscriptions(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), vh.timeout)
+	defer cancel()
+	uid, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	subscriptions, err := vh.services.ListSubscriptions(ctx, uid)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"ok":    true,
+		"data":  subscriptions,
+		"error": nil,
+	})
+}
+
+// Feed lists the new public videos of the creators the user subscribed to.
+// @Summary Subscription feed
+// @Description Public videos of subscribed creators, newest publication first
+// @Tags subscriptions
+// @Produce json
+// @Param limit query int false "Page size (1-100), default 20"
+// @Param offset query int false "Number of videos to skip"
+// @Param Accept-Language header string false "Preferred languages of titles and descriptions"
+// @Success 200 {array} models.FeedItem
+// @Failure 400 {object} map[string]any
+// @Router /v1/feed [get]
+// @Security BearerAuth
+func (vh videoHandler) Feed(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), vh.timeout)
+	defer cancel()
+	uid, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	query := models.ListVideosQuery{Limit: 20}
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.Error(&models.Error{
+			Code:    http.StatusBadRequest,
+			Message: "failed to bind request data",
+			Err:     err,
+		})
+		return
+	}
+	query.Languages = acceptLanguages(c)
+	feed, err := vh.services.Feed(ctx, uid, query)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"ok":    true,
+		"data":  feed,
+		"error": nil,
+	})
+}
+
+// ListNotifications lists the notifications of the user.
+// @Summary List notifications
+// @Description Notifications of the user, newest first, e.g. new videos of subscribed creators
+// @Tags subscriptions
+// @Produce json
+// @Param limit query int false "Page size (1-100), default 20"
+// @Param offset query int false "Number of notifications to skip"
+// @Success 200 {array} models.Notification
+// @Failure 400 {object} map[string]any
+// @Router /v1/notifications [get]
+// @Security BearerAuth
+func (vh videoHandler) ListNotifications(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), vh.timeout)
+	defer cancel()
+	uid, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	query := models.ListVideosQuery{Limit: 20}
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.Error(&models.Error{
+			Code:    http.StatusBadRequest,
+			Message: "failed to bind request data",
+			Err:     err,
+		})
+		return
+	}
+	notifications, err := vh.services.ListNotifications(ctx, uid, query)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"ok":    true,
+		"data":  notifications,
+		"error": nil,
+	})
+}
+
+// MarkNotificationsRead marks every notification of the user as read.
+// @Summary Mark notifications read
+// @Tags subscriptions
+// @Produce json
+// @Success 200 {object} map[string]any
+// @Router /v1/notifications/read [post]
+// @Security BearerAuth
+func (vh videoHandler) MarkNotificationsRead(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), vh.timeout)
+	defer cancel()
+	uid, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	if err := vh.services.MarkNotificationsRead(ctx, uid); err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"ok":    true,
+		"data":  nil,
+		"error": nil,
+	})
+}
+
+// GetNotificationPreferences returns the notification preferences of the user.
+// @Summary Get notification preferences
+// @Description The channels each kind of notification is delivered on; kinds the user did not choose for are delivered in the app
+// @Tags subscriptions
+// @Produce json
+// @Success 200 {object} models.NotificationPreferences
+// @Router /v1/notifications/preferences [get]
+// @Security BearerAuth
+func (vh videoHandler) GetNotificationPreferences(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), vh.timeout)
+	defer cancel()
+	uid, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	prefs, err := vh.services.GetNotificationPreferences(ctx, uid)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"ok":    true,
+		"data":  prefs,
+		"error": nil,
+	})
+}
+
+// SetNotificationPreferences replaces the notification preferences of the user.
+// @Summary Set notification preferences
+// @Description Choose the channels (in_app, email, webhook) of each kind of notification (processing_done, new_subscriber, new_video, moderation). An empty list mutes a kind. The webhook channel requires a webhook_url.
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param request body models.NotificationPreferences true "Notification preferences"
+// @Success 200 {object} models.NotificationPreferences
+// @Failure 400 {object} map[string]any
+// @Router /v1/notifications/preferences [put]
+// @Security BearerAuth
+func (vh videoHandler) SetNotificationPreferences(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), vh.timeout)
+	defer cancel()
+	uid, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	var req models.NotificationPreferences
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(&models.Error{
+			Code:    http.StatusBadRequest,
+			Message: "failed to bind request data",
+			Err:     err,
+		})
+		return
+	}
+	prefs, err := vh.services.SetNotificationPreferences(ctx, uid, req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"ok":    true,
+		"data":  prefs,
+		"error": nil,
+	})
+}
+
+// ExportData starts an export of all the data of the user.
+// @Summary Export my data
+// @Description Assemble the profile, videos with links to their originals, watch history, subscriptions, notifications and reports of the user into a zip archive.
+// @Description The archive is written in the background; poll the returned export for its download URL. While an export is in progress, that export is returned.
+// @Tags user
+// @Produce json
+// @Success 202 {object} models.DataExport
+// @Router /v1/users/export [post]
+// @Security BearerAuth
+func (vh videoHandler) ExportData(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), vh.timeout)
+	defer cancel()
+	uid, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	export, err := vh.services.ExportData(ctx, uid)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{
+		"ok":    true,
+		"data":  export,
+		"error": nil,
+	})
+}
+
+// GetDataExport returns the progress of a data export of the user.
+// @Summary Get data export
+// @Description Status of an export; completed exports carry a download URL that expires, poll again for a fresh one
+// @Tags user
+// @Produce json
+// @Param id path string true "Export ID"
+// @Success 200 {object} models.DataExport
+// @Failure 400 {object} map[string]any
+// @Failure 404 {object} map[string]any
+// @Router /v1/users/export/{id} [get]
+// @Security BearerAuth
+func (vh videoHandler) GetDataExport(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), vh.timeout)
+	defer cancel()
+	uid, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	exportID, ok := idParam(c, "invalid export id")
+	if !ok {
+		return
+	}
+	export, err := vh.services.GetDataExport(ctx, uid, exportID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"ok":    true,
+		"data":  export,
+		"error": nil,
+	})
+}
+
+// ReportVideo reports a video to the moderators.
+// @Summary Report video
+// @Description Report a video of another user for review. Each user has at most one open report per video.
+// @Tags moderation
+// @Accept json
+// @Produce json
+// @Param id path string true "Video ID"
+// @Param report body models.ReportRequest true "Reason code and details"
+// @Success 201 {object} models.VideoReport
+// @Failure 400 {object} map[string]any
+// @Failure 404 {object} map[string]any
+// @Failure 409 {object} map[string]any
+// @Router /v1/videos/{id}/report [post]
+// @Security BearerAuth
+func (vh videoHandler) ReportVideo(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), vh.timeout)
+	defer cancel()
+	uid, videoID, ok := ownerAndVideoID(c)
+	if !ok {
+		return
+	}
+	var req models.ReportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(&models.Error{
+			Code:    http.StatusBadRequest,
+			Message: "failed to bind request data",
+			Err:     err,
+		})
+		return
+	}
+	report, err := vh.services.ReportVideo(ctx, uid, videoID, req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{
+		"ok":    true,
+		"data":  report,
+		"error": nil,
+	})
+}
+
+// ListReports returns the moderation queue.
+// @Summary List video reports
+// @Description Reports with the current state of their video, oldest first. Open reports by default.
+// @Tags admin
+// @Produce json
+// @Param status query string false "open (default), resolved or dismissed"
+// @Param limit query int false "Page size (1-100), default 20"
+// @Param offset query int false "Number of reports to skip"
+// @Success 200 {array} models.VideoReport
+// @Failure 400 {object} map[string]any
+// @Router /v1/admin/reports [get]
+// @Security BearerAuth
+func (vh videoHandler) ListReports(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), vh.timeout)
+	defer cancel()
+	query := models.ReportQuery{Limit: 20}
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.Error(&models.Error{
+			Code:    http.StatusBadRequest,
+			Message: "failed to bind request data",
+			Err:     err,
+		})
+		return
+	}
+	reports, err := vh.services.ListReports(ctx, query)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"ok":    true,
+		"data":  reports,
+		"error": nil,
+	})
+}
+
+// ModerateReport acts on a reported video.
+// @Summary Resolve video report
+// @Description Hide, age-restrict or remove the video, or dismiss the report. Every open report of the video is closed
+// @Description and the owner is notified of the action.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "Report ID"
+// @Param moderation body models.ModerationRequest true "Action and note for the owner"
+// @Success 200 {object} models.VideoReport
+// @Failure 400 {object} map[string]any
+// @Failure 404 {object} map[string]any
+// @Failure 409 {object} map[string]any
+// @Router /v1/admin/reports/{id}/resolve [post]
+// @Security BearerAuth
+func (vh videoHandler) ModerateReport(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), vh.timeout)
+	defer cancel()
+	uid, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	reportID, ok := idParam(c, "invalid report id")
+	if !ok {
+		return
+	}
+	var req models.ModerationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(&models.Error{
+			Code:    http.StatusBadRequest,
+			Message: "failed to bind request data",
+			Err:     err,
+		})
+		return
+	}
+	report, err := vh.services.ModerateReport(ctx, uid, reportID, req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"ok":    true,
+		"data":  report,
+		"error": nil,
+	})
+}
+
+// SetSchedule schedules the publication and expiry of a video.
+// @Summary Schedule video publication
+// @Description The video becomes public at publish_at and private again at expires_at, or is deleted with all its objects when purge_on_expiry is set.
+// @Description Times are checked every 30 seconds; an empty body clears the schedule.
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param id path string true "Video ID"
+// @Param schedule body models.ScheduleRequest true "Publish and expiry times"
+// @Success 200 {object} models.VideoDetail
+// @Failure 400 {object} map[string]any
+// @Failure 404 {object} map[string]any
+// @Failure 409 {object} map[string]any
+// @Router /v1/videos/{id}/schedule [put]
+// @Security BearerAuth
+func (vh videoHandler) SetSchedule(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), vh.timeout)
+	defer cancel()
+	uid, videoID, ok := ownerAndVideoID(c)
+	if !ok {
+		return
+	}
+	var req models.ScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(&models.Error{
+			Code:    http.StatusBadRequest,
+			Message: "failed to bind request data",
+			Err:     err,
+		})
+		return
+	}
+	video, err := vh.services.SetSchedule(ctx, uid, videoID, req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"ok":    true,
+		"data":  video,
+		"error": nil,
+	})
+}
+
+// SetTranslation sets the title and description of a video in one language.
+// @Summary Translate video metadata
+// @Description Catalog responses use the translation that best matches the Accept-Language header of the caller.
+// @Tags video
+// @Accept json
+// @Produce json
+// @Param id path string true "Video ID"
+// @Param lang path string true "BCP 47 language tag, e.g. pt-BR"
+// @Param translation body models.TranslationRequest true "Title and description"
+// @Success 200 {object} models.VideoTranslation
+// @Failure 400 {object} map[string]any
+// @Failure 404 {object} map[string]any
+// @Router /v1/videos/{id}/translations/{lang} [put]
+// @Security BearerAuth
+func (vh videoHandler) SetTranslation(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), vh.timeout)
+	defer cancel()
+	uid, videoID, ok := ownerAndVideoID(c)
+	if !ok {
+		return
+	}
+	var req models.TranslationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(&models.Error{
+			Code:    http.StatusBadRequest,
+			Message: "failed to bind request data",
+			Err:     err,
+		})
+		return
+	}
+	translation, err := vh.services.SetTranslation(ctx, uid, videoID, c.Param("lang"), req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"ok":    true,
+		"data":  translation,
+		"error": nil,
+	})
+}
+
+// DeleteTranslation deletes the translation of a video in one language.
+// @Summary Delete video translation
+// @Tags video
+// @Produce json
+// @Param id path string true "Video ID"
+// @Param lang path string true "BCP 47 language tag"
+// @Success 200 {object} map[string]any
+// @Failure 400 {object} map[string]any
+// @Failure 404 {object} map[string]any
+// @Router /v1/videos/{id}/translations/{lang} [delete]
+// @Security BearerAuth
+func (vh videoHandler) DeleteTranslation(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), vh.timeout)
+	defer cancel()
+	uid, videoID, ok := ownerAndVideoID(c)
+	if !ok {
+		return
+	}
+	if err := vh.services.DeleteTranslation(ctx, uid, videoID, c.Param("lang")); err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"ok":    true,
+		"data":  nil,
+		"error": nil,
+	})
+}
+
+// ListTranslations lists the translations of a video.
+// @Summary List video translations
+// @Tags video
+// @Produce json
+// @Param id path string true "Video ID"
+// @Success 200 {array} models.VideoTranslation
+// @Failure 400 {object} map[string]any
+// @Failure 404 {object} map[string]any
+// @Router /v1/videos/{id}/translations [get]
+// @Security BearerAuth
+func (vh videoHandler) ListTranslations(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), vh.timeout)
+	defer cancel()
+	uid, videoID, ok := ownerAndVideoID(c)
+	if !ok {
+		return
+	}
+	translations, err := vh.services.ListTranslations(ctx, uid, videoID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"ok":    true,
+		"data":  translations,
+		"error": nil,
+	})
+}
+
+// SetPlaybackPassword protects a video with a password.
+// @Summary Set playback password
+// @Description Anyone who knows the password can play the video through the playback endpoints, without an account and even while the video is private.
+// @Tags playback
+// @Accept json
+// @Produce json
+// @Param id path string true "Video ID"
+// @Param password body models.PlaybackPasswordRequest true "Password, 4 to 72 characters"
+// @Success 200 {object} models.VideoDetail
+// @Failure 400 {object} map[string]any
+// @Failure 404 {object} map[string]any
+// @Router /v1/videos/{id}/password [put]
+// @Security BearerAuth
+func (vh videoHandler) SetPlaybackPassword(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), vh.timeout)
+	defer cancel()
+	uid, videoID, ok := ownerAndVideoID(c)
+	if !ok {
+		return
+	}
+	var req models.PlaybackPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(&models.Error{
+			Code:    http.StatusBadRequest,
+			Message: "failed to bind request data",
+			Err:     err,
+		})
+		return
+	}
+	video, err := vh.services.SetPlaybackPassword(ctx, uid, videoID, req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"ok":    true,
+		"data":  video,
+		"error": nil,
+	})
+}
+
+// RemovePlaybackPassword lifts the password of a video.
+// @Summary Remove playback password
+// @Tags playback
+// @Produce json
+// @Param id path string true "Video ID"
+// @Success 200 {object} models.VideoDetail
+// @Failure 400 {object} map[string]any
+// @Failure 404 {object} map[string]any
+// @Router /v1/videos/{id}/password [delete]
+// @Security BearerAuth
+func (vh videoHandler) RemovePlaybackPassword(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), vh.timeout)
+	defer cancel()
+	uid, videoID, ok := ownerAndVideoID(c)
+	if !ok {
+		return
+	}
+	video, err := vh.services.RemovePlaybackPassword(ctx, uid, videoID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"ok":    true,
+		"data":  video,
+		"error": nil,
+	})
+}
+
+// AuthorizePlayback issues a playback token.
+// @Summary Get playback token
+// @Description Issues a token for GET /v1/videos/{id}/playback. Public videos need no password; protected videos need theirs.
+// @Description After 5 wrong passwords a client is locked out of the video for 15 minutes.
+// @Tags playback
+// @Accept json
+// @Produce json
+// @Param id path string true "Video ID"
+// @Param request body models.PlaybackRequest false "Password of protected videos"
+// @Success 200 {object} models.PlaybackToken
+// @Failure 401 {object} map[string]any
+// @Failure 404 {object} map[string]any
+// @Failure 429 {object} map[string]any
+// @Router /v1/videos/{id}/playback [post]
+func (vh videoHandler) AuthorizePlayback(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), vh.timeout)
+	defer cancel()
+	videoID, ok := idParam(c, "invalid video id")
+	if !ok {
+		return
+	}
+	var req models.PlaybackRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.Error(&models.Error{
+				Code:    http.StatusBadRequest,
+				Message: "failed to bind request data",
+				Err:     err,
+			})
+			return
+		}
+	}
+	token, err := vh.services.AuthorizePlayback(ctx, videoID, c.ClientIP(), req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"ok":    true,
+		"data":  token,
+		"error": nil,
+	})
+}
+
+// Playback returns the playback URLs of a video.
+// @Summary Play video
+// @Description Presigned URLs of the variants of the video, for the holder of a playback token.
+// @Description Encrypted videos list the HLS playlists of their variants, and the key URL with the token in its query.
+// @Tags playback
+// @Produce json
+// @Param id path string true "Video ID"
+// @Param X-Playback-Token header string true "Token from POST /v1/videos/{id}/playback"
+// @Success 200 {object} models.Playback
+// @Failure 401 {object} map[string]any
+// @Failure 404 {object} map[string]any
+// @Router /v1/videos/{id}/playback [get]
+func (vh videoHandler) Playback(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), vh.timeout)
+	defer cancel()
+	videoID, ok := idParam(c, "invalid video id")
+	if !ok {
+		return
+	}
+	playback, err := vh.services.Playback(ctx, videoID, c.GetHeader("X-Playback-Token"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"ok":    true,
+		"data":  playback,
+		"error": nil,
+	})
+}
+
+// GetVideoKey returns the HLS key of a video.
+// @Summary Get video key
+// @Description The AES-128 key the HLS segments of an encrypted video are decrypted with, the URI of the EXT-X-KEY tags of its playlists.
+// @Description Players send the playback token of the video with the key request, as a header or, when they cannot set headers, in the query. Owners may send their access token instead.
+// @Tags playback
+// @Produce octet-stream
+// @Param id path string true "Video ID"
+// @Param X-Playback-Token header string false "Token from POST /v1/videos/{id}/playback"
+// @Param token query string false "The playback token, for players that cannot send headers"
+// @Success 200 {file} file
+// @Failure 400 {object} map[string]any
+// @Failure 401 {object} map[string]any
+// @Failure 404 {object} map[string]any
+// @Router /v1/videos/{id}/key [get]
+// @Security BearerAuth
+func (vh videoHandler) GetVideoKey(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), vh.timeout)
+	defer cancel()
+	videoID, ok := idParam(c, "invalid video id")
+	if !ok {
+		return
+	}
+	// anonymous viewers have no user, only a playback token
+	uid, _ := c.Value("user_id").(uuid.UUID)
+	// native HLS players fetch the key URI as it is, so the token may come in its query
+	token := c.GetHeader("X-Playback-Token")
+	if token == "" {
+		token = c.Query("token")
+	}
+	key, err := vh.services.GetVideoKey(ctx, uid, videoID, token)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	// the key must not outlive the viewer's access in shared caches
+	c.Header("Cache-Control", "private, no-store")
+	c.Data(http.StatusOK, "application/octet-stream", key)
+}
+
+// Download streams a video file through the API.
+// @Summary Download video
+// @Description Streams a variant of a video the user can see, or the source of the user's own video when no variant is given.
+// @Description The transfer is limited to the bandwidth of the user's delivery plan.
+// @Tags video
+// @Produce octet-stream
+// @Param id path string true "Video ID"
+// @Param variant query string false "Variant name, e.g. 720p"
+// @Param format query string false "Container of the variant, mp4 (default) or webm"
+// @Success 200 {file} file
+// @Failure 400 {object} map[string]any
+// @Failure 404 {object} map[string]any
+// @Router /v1/videos/{id}/download [get]
+// @Security BearerAuth
+func (vh videoHandler) Download(c *gin.Context) {
+	// no timeout: a throttled download lasts as long as the file takes at the user's rate
+	ctx := c.Request.Context()
+	uid, videoID, ok := ownerAndVideoID(c)
+	if !ok {
+		return
+	}
+	download, err := vh.services.Download(ctx, uid, videoID, c.Query("variant"), c.Query("format"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	defer download.Body.Close()
+	c.DataFromReader(http.StatusOK, download.Size, download.ContentType, download.Body, map[string]string{
+		"Content-Disposition": mime.FormatMediaType("attachment", map[string]string{"filename": download.Name}),
+	})
+}
+
+// ListRenditionVersions lists the rendition sets reprocessing replaced on a video.
+// @Summary List rendition versions
+// @Description Each reprocess keeps the variants and assets it replaced as a version, newest first, until the retention period ends.
+// @Tags video
+// @Produce json
+// @Param id path string true "Video ID"
+// @Success 200 {array} models.RenditionVersion
+// @Failure 400 {object} map[string]any
+// @Failure 404 {object} map[string]any
+// @Router /v1/videos/{id}/versions [get]
+// @Security BearerAuth
+func (vh videoHandler) ListRenditionVersions(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), vh.timeout)
+	defer cancel()
+	uid, videoID, ok := ownerAndVideoID(c)
+	if !ok {
+		return
+	}
+	versions, err := vh.services.ListRenditionVersions(ctx, uid, videoID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"ok":    true,
+		"data":  versions,
+		"error": nil,
+	})
+}
+
+// RollbackRenditions restores a rendition version of a video.
+// @Summary Roll back to a rendition version
+// @Description The variants and assets of the version become current again; the ones they replace are kept as a new version.
+// @Tags video
+// @Produce json
+// @Param id path string true "Video ID"
+// @Param version path int true "Version number"
+// @Success 200 {object} models.VideoDetail
+// @Failure 400 {object} map[string]any
+// @Failure 404 {object} map[string]any
+// @Router /v1/videos/{id}/versions/{version}/rollback [post]
+// @Security BearerAuth
+func (vh videoHandler) RollbackRenditions(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), vh.timeout)
+	defer cancel()
+	uid, videoID, ok := ownerAndVideoID(c)
+	if !ok {
+		return
+	}
+	version, err := strconv.ParseInt(c.Param("version"), 10, 32)
+	if err != nil || version < 1 {
+		c.Error(&models.Error{
+			Code:    http.StatusBadRequest,
+			Message: "invalid version",
+			Params:  fmt.Sprintf("version: %s", c.Param("version")),
+			Err:     errors.Join(fmt.Errorf("version must be a positive number"), models.ErrInvalidInputData),
+		})
+		return
+	}
+	video, err := vh.services.RollbackRenditions(ctx, uid, videoID, int32(version))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"ok":    true,
+		"data":  video,
+		"error": nil,
+	})
+}
+
+// GetUsage lists the metered usage of the user per month.
+// @Summary Get usage
+// @Description Transcoding minutes, bytes stored and bytes delivered per calendar month (UTC), oldest first. Months without usage are left out.
+// @Tags usage
+// @Produce json
+// @Param from query string false "First month, YYYY-MM; 11 months before to by default"
+// @Param to query string false "Last month, YYYY-MM; the current month by default"
+// @Success 200 {array} models.UsageMonth
+// @Failure 400 {object} map[string]any
+// @Router /v1/usage [get]
+// @Security BearerAuth
+func (vh videoHandler) GetUsage(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), vh.timeout)
+	defer cancel()
+	uid, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	var query models.UsageQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.Error(&models.Error{
+			Code:    http.StatusBadRequest,
+			Message: "failed to bind request data",
+			Err:     err,
+		})
+		return
+	}
+	usage, err := vh.services.GetUsage(ctx, uid, query)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"ok":    true,
+		"data":  usage,
+		"error": nil,
+	})
+}
+
+// ExportUsage exports the usage of every user in a month.
+// @Summary Export usage
+// @Description Admin export of the metered usage of all users in a month, as JSON or as a CSV file for billing.
+// @Tags admin
+// @Produce json
+// @Produce text/csv
+// @Param month query string false "Month, YYYY-MM; the previous month by default"
+// @Param format query string false "json (default) or csv"
+// @Success 200 {array} models.UsageMonth
+// @Failure 400 {object} map[string]any
+// @Router /v1/admin/usage [get]
+// @Security BearerAuth
+func (vh videoHandler) ExportUsage(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), vh.timeout)
+	defer cancel()
+	var query models.UsageExportQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.Error(&models.Error{
+			Code:    http.StatusBadRequest,
+			Message: "failed to bind request data",
+			Err:     err,
+		})
+		return
+	}
+	if query.Format != "" && query.Format != "json" && query.Format != "csv" {
+		c.Error(&models.Error{
+			Code:    http.StatusBadRequest,
+			Message: "invalid format",
+			Params:  fmt.Sprintf("format: %s", query.Format),
+			Err:     errors.Join(errors.New("format must be json or csv"), models.ErrInvalidInputData),
+		})
+		return
+	}
+	usage, err := vh.services.ExportUsage(ctx, query.Month)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if query.Format != "csv" {
+		c.JSON(http.StatusOK, gin.H{
+			"ok":    true,
+			"data":  usage,
+			"error": nil,
+		})
+		return
+	}
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write([]string{"user_id", "month", "transcoding_minutes", "storage_bytes", "delivery_bytes"})
+	for _, u := range usage {
+		w.Write([]string{
+			u.UserID.String(),
+			u.Month,
+			strconv.FormatFloat(u.TranscodingMinutes, 'f', -1, 64),
+			strconv.FormatInt(u.StorageBytes, 10),
+			strconv.FormatInt(u.DeliveryBytes, 10),
+		})
+	}
+	w.Flush()
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": "usage.csv"}))
+	c.Data(http.StatusOK, "text/csv; charset=utf-8", buf.Bytes())
+}
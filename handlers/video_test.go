@@ -0,0 +1,203 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"video-processing/mocks"
+	"video-processing/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestGetVideoHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctrl := gomock.NewController(t)
+	services := mocks.NewMockVideoProcessor(ctrl)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	handler := NewVideoHandler(logger, time.Second, services)
+
+	userID := uuid.New()
+	engine := gin.New()
+	engine.Use(NewMiddleware(nil, nil, logger).ErrorMiddleware())
+	engine.GET("/videos/:id", func(c *gin.Context) { c.Set("user_id", userID) }, handler.GetVideo)
+
+	found, missing := uuid.New(), uuid.New()
+	services.EXPECT().GetVideo(gomock.Any(), userID, found, []string{"fr-CH", "fr"}).Return(models.VideoDetail{ID: found, Title: "clip"}, nil)
+	services.EXPECT().GetVideo(gomock.Any(), userID, missing, gomock.Any()).Return(models.VideoDetail{}, models.Error{
+		Code:    http.StatusNotFound,
+		Message: "resource not found",
+		Err:     models.ErrResourceNotFound,
+	})
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/videos/"+found.String(), nil)
+	req.Header.Set("Accept-Language", "fr-CH, fr;q=0.9, *;q=0.5, de;q=0")
+	engine.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "Accept-Language", rec.Header().Get("Vary"))
+	var body struct {
+		OK   bool               `json:"ok"`
+		Data models.VideoDetail `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	require.True(t, body.OK)
+	require.Equal(t, "clip", body.Data.Title)
+
+	rec = httptest.NewRecorder()
+	engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/videos/"+missing.String(), nil))
+	require.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestDownloadHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctrl := gomock.NewController(t)
+	services := mocks.NewMockVideoProcessor(ctrl)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	handler := NewVideoHandler(logger, time.Second, services)
+
+	userID, videoID := uuid.New(), uuid.New()
+	engine := gin.New()
+	engine.Use(NewMiddleware(nil, nil, logger).ErrorMiddleware())
+	engine.GET("/videos/:id/download", func(c *gin.Context) { c.Set("user_id", userID) }, handler.Download)
+
+	services.EXPECT().Download(gomock.Any(), userID, videoID, "720p", "").Return(models.Download{
+		Name:        "720p.mp4",
+		ContentType: "video/mp4",
+		Size:        4,
+		Body:        io.NopCloser(strings.NewReader("data")),
+	}, nil)
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/videos/"+videoID.String()+"/download?variant=720p", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "video/mp4", rec.Header().Get("Content-Type"))
+	require.Equal(t, "4", rec.Header().Get("Content-Length"))
+	require.Equal(t, "attachment; filename=720p.mp4", rec.Header().Get("Content-Disposition"))
+	require.Equal(t, "data", rec.Body.String())
+}
+
+func TestGetVideoKeyHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctrl := gomock.NewController(t)
+	services := mocks.NewMockVideoProcessor(ctrl)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	handler := NewVideoHandler(logger, time.Second, services)
+
+	videoID := uuid.New()
+	engine := gin.New()
+	engine.Use(NewMiddleware(nil, nil, logger).ErrorMiddleware())
+	engine.GET("/videos/:id/key", handler.GetVideoKey)
+
+	// native HLS players cannot set headers, they fetch the key URI with the token in its query
+	services.EXPECT().GetVideoKey(gomock.Any(), uuid.Nil, videoID, "from-query").Return([]byte("0123456789abcdef"), nil)
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/videos/"+videoID.String()+"/key?token=from-query", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "0123456789abcdef", rec.Body.String())
+	require.Equal(t, "private, no-store", rec.Header().Get("Cache-Control"))
+
+	services.EXPECT().GetVideoKey(gomock.Any(), uuid.Nil, videoID, "from-header").Return([]byte("0123456789abcdef"), nil)
+	req := httptest.NewRequest(http.MethodGet, "/videos/"+videoID.String()+"/key", nil)
+	req.Header.Set("X-Playback-Token", "from-header")
+	rec = httptest.NewRecorder()
+	engine.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestStreamProgressHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctrl := gomock.NewController(t)
+	services := mocks.NewMockVideoProcessor(ctrl)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	handler := NewVideoHandler(logger, time.Second, services)
+
+	userID, videoID := uuid.New(), uuid.New()
+	engine := gin.New()
+	engine.Use(NewMiddleware(nil, nil, logger).ErrorMiddleware())
+	engine.GET("/videos/:id/progress", func(c *gin.Context) { c.Set("user_id", userID) }, handler.StreamProgress)
+	defer func(interval time.Duration) { progressInterval = interval }(progressInterval)
+	progressInterval = time.Millisecond
+
+	// events follow the run until it is over
+	gomock.InOrder(
+		services.EXPECT().GetProgress(gomock.Any(), userID, videoID).Return(models.JobStatus{
+			VideoID: videoID, Status: models.VideoPending, Progress: &models.VideoProgress{Percent: 40},
+		}, nil),
+		services.EXPECT().GetProgress(gomock.Any(), userID, videoID).Return(models.JobStatus{
+			VideoID: videoID, Status: models.VideoProcessed, Progress: &models.VideoProgress{Percent: 100},
+		}, nil),
+	)
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/videos/"+videoID.String()+"/progress", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Contains(t, rec.Header().Get("Content-Type"), "text/event-stream")
+	require.Equal(t, 2, strings.Count(rec.Body.String(), "event:progress"))
+	require.Contains(t, rec.Body.String(), `"status":"processed"`)
+
+	// a missing video fails before the stream starts
+	services.EXPECT().GetProgress(gomock.Any(), userID, videoID).Return(models.JobStatus{}, models.Error{Code: http.StatusNotFound, Message: "resource not found", Err: models.ErrResourceNotFound})
+	rec = httptest.NewRecorder()
+	engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/videos/"+videoID.String()+"/progress", nil))
+	require.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestRollbackRenditionsHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctrl := gomock.NewController(t)
+	services := mocks.NewMockVideoProcessor(ctrl)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	handler := NewVideoHandler(logger, time.Second, services)
+
+	userID, videoID := uuid.New(), uuid.New()
+	engine := gin.New()
+	engine.Use(NewMiddleware(nil, nil, logger).ErrorMiddleware())
+	engine.POST("/videos/:id/versions/:version/rollback", func(c *gin.Context) { c.Set("user_id", userID) }, handler.RollbackRenditions)
+
+	services.EXPECT().RollbackRenditions(gomock.Any(), userID, videoID, int32(2)).Return(models.VideoDetail{ID: videoID}, nil)
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/videos/"+videoID.String()+"/versions/2/rollback", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	// versions are numbered from 1; invalid ones never reach the service
+	for _, version := range []string{"0", "-1", "latest", "99999999999"} {
+		rec = httptest.NewRecorder()
+		engine.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/videos/"+videoID.String()+"/versions/"+version+"/rollback", nil))
+		require.GreaterOrEqual(t, rec.Code, http.StatusBadRequest, version)
+	}
+}
+
+func TestExportUsageHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctrl := gomock.NewController(t)
+	services := mocks.NewMockVideoProcessor(ctrl)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	handler := NewVideoHandler(logger, time.Second, services)
+
+	userID := uuid.New()
+	engine := gin.New()
+	engine.Use(NewMiddleware(nil, nil, logger).ErrorMiddleware())
+	engine.GET("/admin/usage", handler.ExportUsage)
+
+	services.EXPECT().ExportUsage(gomock.Any(), "2025-02").Return([]models.UsageMonth{
+		{UserID: userID, Month: "2025-02", TranscodingMinutes: 1.5, StorageBytes: 10, DeliveryBytes: 20},
+	}, nil)
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin/usage?month=2025-02&format=csv", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "text/csv; charset=utf-8", rec.Header().Get("Content-Type"))
+	require.Contains(t, rec.Header().Get("Content-Disposition"), "attachment")
+	require.Equal(t, "user_id,month,transcoding_minutes,storage_bytes,delivery_bytes\n"+userID.String()+",2025-02,1.5,10,20\n", rec.Body.String())
+
+	// unknown formats never reach the service
+	rec = httptest.NewRecorder()
+	engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin/usage?format=xml", nil))
+	require.GreaterOrEqual(t, rec.Code, http.StatusBadRequest)
+}
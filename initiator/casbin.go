@@ -36,16 +36,9 @@ func NewEnforcer(pool *pgxpool.Pool, log *slog.Logger, pth string) (*Enforcer, e
 	// Enable auto-save
 	enforcer.EnableAutoSave(true)
 
-	rules, err := readRulesFromCSV(filepath.Join(pth, "policy.csv"))
-	if err != nil {
+	if err := addRules(enforcer, pth); err != nil {
 		return nil, err
 	}
-	for _, r := range rules {
-		_, err = enforcer.AddPolicy(r[1:])
-		if err != nil {
-			return nil, err
-		}
-	}
 
 	// Load policy after adding initial rules
 	if err := enforcer.LoadPolicy(); err != nil {
@@ -59,6 +52,20 @@ func NewEnforcer(pool *pgxpool.Pool, log *slog.Logger, pth string) (*Enforcer, e
 	}, nil
 }
 
+// addRules adds the rules of policy.csv in the pth directory to enforcer
+func addRules(enforcer *casbin.Enforcer, pth string) error {
+	rules, err := readRulesFromCSV(filepath.Join(pth, "policy.csv"))
+	if err != nil {
+		return err
+	}
+	for _, r := range rules {
+		if _, err := enforcer.AddPolicy(r[1:]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func readRulesFromCSV(path string) ([][]string, error) {
 	cleanPath := filepath.Clean(path)
 	f, err := os.Open(cleanPath)
@@ -69,6 +76,7 @@ func readRulesFromCSV(path string) ([][]string, error) {
 	defer f.Close() //nolint: errcheck
 
 	csvReader := csv.NewReader(f)
+	csvReader.TrimLeadingSpace = true
 	rules, err := csvReader.ReadAll()
 	if err != nil {
 		return nil, fmt.Errorf("unable to read input file, error:%w", err)
@@ -0,0 +1,53 @@
+package initiator
+
+import (
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+	"video-processing/handlers"
+	"video-processing/routing"
+	"video-processing/services/video"
+
+	"github.com/casbin/casbin/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicyCoversAdminRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	enforcer, err := casbin.NewEnforcer("../config/model.conf")
+	require.NoError(t, err)
+	require.NoError(t, addRules(enforcer, "../config"))
+	admin, user := uuid.NewString(), uuid.NewString()
+	_, err = enforcer.AddGroupingPolicy(admin, "admin", "default")
+	require.NoError(t, err)
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	engine := gin.New()
+	routing.RegisterRoutes(engine, routing.Handlers{
+		UserHandler:   handlers.NewUser(nil),
+		VideoHandler:  handlers.NewVideoHandler(logger, time.Second, nil),
+		HealthHandler: handlers.NewHealth(video.Capabilities{}),
+		Middlewares:   handlers.NewMiddleware(nil, enforcer, logger),
+	})
+
+	// every admin route is open to admins, and to them only
+	var checked int
+	for _, route := range engine.Routes() {
+		if !strings.HasPrefix(route.Path, "/v1/admin/") {
+			continue
+		}
+		path := strings.ReplaceAll(route.Path, ":id", uuid.NewString())
+		allowed, err := enforcer.Enforce(admin, handlers.KnowDomain(path), path, route.Method)
+		require.NoError(t, err)
+		require.True(t, allowed, "%s %s", route.Method, route.Path)
+		allowed, err = enforcer.Enforce(user, handlers.KnowDomain(path), path, route.Method)
+		require.NoError(t, err)
+		require.False(t, allowed, "%s %s", route.Method, route.Path)
+		checked++
+	}
+	require.NotZero(t, checked)
+}
@@ -2,6 +2,7 @@ package initiator
 
 import (
 	"fmt"
+	"strings"
 	"video-processing/models"
 
 	"github.com/spf13/viper"
@@ -10,16 +11,20 @@ import (
 func LoadConfig(path string) (models.Config, error) {
 	var config models.Config
 
-	viper.AddConfigPath(path)     // folder where config.yaml is located
-	viper.SetConfigName("config") // name of file (without extension)
-	viper.SetConfigType("yaml")   // type of file
-	viper.AutomaticEnv()          // read from environment variables too
+	// a viper of its own, so that configs of other folders can be loaded next to this one
+	v := viper.New()
+	v.AddConfigPath(path)     // folder where config.yaml is located
+	v.SetConfigName("config") // name of file (without extension)
+	v.SetConfigType("yaml")   // type of file
+	v.AutomaticEnv()          // read from environment variables too
+	// nested keys map to underscored variables, e.g. processing.dry_run to PROCESSING_DRY_RUN
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
 
-	if err := viper.ReadInConfig(); err != nil {
+	if err := v.ReadInConfig(); err != nil {
 		return config, fmt.Errorf("error reading config file: %w", err)
 	}
 
-	if err := viper.Unmarshal(&config); err != nil {
+	if err := v.Unmarshal(&config); err != nil {
 		return config, fmt.Errorf("unable to decode config into struct: %w", err)
 	}
 
@@ -0,0 +1,69 @@
+package initiator
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"video-processing/models"
+	"video-processing/services/video"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/notification"
+	"github.com/redis/go-redis/v9"
+)
+
+// SetupIngest prepares the ingest bucket, subscribes it to the configured MinIO notification
+// target and starts watching the Redis list MinIO pushes events to. Nothing happens when no
+// ingest bucket is configured.
+func SetupIngest(logger *slog.Logger, config models.IngestConfig, store video.ObjectStore, redisClient *redis.Client, service video.VideoProcessor) error {
+	if config.Bucket == "" {
+		return nil
+	}
+	ctx := context.Background()
+	if err := seedBucket(ctx, store, config.Bucket); err != nil {
+		return fmt.Errorf("failed to create ingest bucket %s: %w", config.Bucket, err)
+	}
+
+	if config.TargetARN != "" {
+		if wrapped, ok := store.(interface{ Unwrap() video.ObjectStore }); ok {
+			store = wrapped.Unwrap()
+		}
+		client, ok := store.(*minio.Client)
+		if !ok {
+			return fmt.Errorf("ingest target %s needs the minio storage backend", config.TargetARN)
+		}
+		arn, err := notification.NewArnFromString(config.TargetARN)
+		if err != nil {
+			return fmt.Errorf("invalid ingest target: %w", err)
+		}
+		target := notification.NewConfig(arn)
+		target.AddEvents(notification.ObjectCreatedAll)
+		if config.Prefix != "" {
+			target.AddFilterPrefix(config.Prefix)
+		}
+		// the bucket notification is replaced as a whole, keep what other systems subscribed
+		current, err := client.GetBucketNotification(ctx, config.Bucket)
+		if err != nil {
+			return fmt.Errorf("failed to read notification of ingest bucket: %w", err)
+		}
+		current.RemoveQueueByArn(arn)
+		// MinIO targets are all queues (arn:minio:sqs:...), whatever service they deliver to
+		if !current.AddQueue(target) {
+			return fmt.Errorf("ingest target %s overlaps an existing notification of the bucket", config.TargetARN)
+		}
+		if err := client.SetBucketNotification(ctx, config.Bucket, current); err != nil {
+			return fmt.Errorf("failed to set notification of ingest bucket: %w", err)
+		}
+		logger.Info("✅ Ingest notification set", "bucket", config.Bucket, "prefix", config.Prefix, "target", config.TargetARN)
+	}
+
+	if config.RedisKey != "" {
+		go func() {
+			if err := service.ListenIngest(ctx, redisClient, config.RedisKey); err != nil {
+				logger.Error("❌ Ingest listener error", "error", err)
+			}
+		}()
+		logger.Info("✅ Watching ingest queue", "key", config.RedisKey)
+	}
+	return nil
+}
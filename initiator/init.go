@@ -10,6 +10,7 @@ import (
 	"video-processing/routing"
 	"video-processing/services/user"
 	"video-processing/services/video"
+	"video-processing/storage"
 	"video-processing/utils"
 
 	"github.com/gin-gonic/gin"
@@ -53,12 +54,35 @@ func Init() {
 	db := db.New(pool)
 	// init redis
 	redisClient := NewRedisClient(logger, config)
-	// init minio client
-	minioClient := InitMinio(logger, config)
+	// init object storage, MinIO or a local directory
+	objectStore, err := NewObjectStore(logger, config)
+	if err != nil {
+		log.Fatal(err)
+	}
+	// detect what ffmpeg can do and turn off what the configuration asks for in vain
+	if err := config.Processing.FFmpeg.Validate(); err != nil {
+		log.Fatal(err)
+	}
+	if err := config.Processing.Modes.Validate(); err != nil {
+		log.Fatal(err)
+	}
+	if err := config.Processing.HLSEncryption.Validate(); err != nil {
+		log.Fatal(err)
+	}
+	transcoder := video.NewExecTranscoder(config.Processing.FFmpeg)
+	capabilities, err := video.DetectCapabilities(context.Background(), transcoder)
+	if err != nil {
+		log.Fatal(err)
+	}
+	processing, err := capabilities.Apply(logger, config.Processing)
+	if err != nil {
+		log.Fatal(err)
+	}
+	logger.Info("✅ ffmpeg capabilities detected", "ffmpeg", capabilities.FFmpegVersion, "ffprobe", capabilities.FFprobeVersion, "encoders", capabilities.Encoders)
 	// init streamer
 	streamer := video.NewRedisStreamer("video_stream", logger, redisClient)
 	// init consumer and run it in a separate goroutine
-	consumer := video.NewRedisConsumer("video_stream", "video_group", "video_consumer_1", logger, redisClient, minioClient, db)
+	consumer := video.NewRedisConsumer("video_stream", "video_group", "video_consumer_1", logger, redisClient, objectStore, db, processing, transcoder, config.Notifications)
 	go func() {
 		if err := consumer.Consume(context.Background()); err != nil {
 			logger.Error("❌ Consumer error", "error", err)
@@ -66,22 +90,85 @@ func Init() {
 	}()
 
 	// services
-	userService := user.NewUser(*db, tm)
-	videoService := video.NewVideoProcessor(logger, minioClient, db, streamer, config.Minio.UrlExpiry)
+	userService := user.NewUser(db, tm)
+	if err := config.Delivery.Validate(); err != nil {
+		log.Fatal(err)
+	}
+	// playback tokens live as long as the presigned URLs they unlock
+	playbackTokens := utils.NewTokenManager(config.Token.Key, config.Minio.UrlExpiry, *paseto.NewV2())
+	videoService := video.NewVideoProcessor(logger, objectStore, db, streamer, transcoder, video.VideoProcessorOptions{
+		URLExpiry:      config.Minio.UrlExpiry,
+		Ingest:         config.Ingest,
+		PlaybackTokens: playbackTokens,
+		Notifications:  config.Notifications,
+		Layout:         storage.Layout{Bucket: config.Storage.Bucket},
+		Delivery:       config.Delivery,
+		Modes:          config.Processing.Modes,
+		HLSEncryption:  config.Processing.HLSEncryption,
+	})
+	// the ladders of the configuration replace the presets of the same name
+	if err := videoService.SyncPresets(context.Background(), config.Processing.Presets); err != nil {
+		log.Fatal(err)
+	}
+
+	// objects dropped into the ingest bucket are processed without the upload endpoint
+	if err := SetupIngest(logger, config.Ingest, objectStore, redisClient, videoService); err != nil {
+		log.Fatal(err)
+	}
+	// bulk reprocessing runs are queued by whichever instance holds them, and resumed after restarts
+	go func() {
+		if err := videoService.RunReprocessing(context.Background()); err != nil {
+			logger.Error("❌ Reprocessing error", "error", err)
+		}
+	}()
+	// scheduled publications and expiries are applied by every instance
+	go func() {
+		if err := videoService.RunScheduler(context.Background()); err != nil {
+			logger.Error("❌ Scheduler error", "error", err)
+		}
+	}()
+	// data exports are written by whichever instance claims them first
+	go func() {
+		if err := videoService.RunExports(context.Background()); err != nil {
+			logger.Error("❌ Data export error", "error", err)
+		}
+	}()
+	// replaced rendition sets are deleted by whichever instance expires them first
+	go func() {
+		if err := videoService.RunVersionCleanup(context.Background(), config.Processing.VersionRetention); err != nil {
+			logger.Error("❌ Rendition version cleanup error", "error", err)
+		}
+	}()
+	// storage is snapshot into the current month, and the usage of closed months is posted to
+	// billing by whichever instance claims it first
+	go func() {
+		if err := videoService.RunUsageEmitter(context.Background(), config.Billing); err != nil {
+			logger.Error("❌ Usage emitter error", "error", err)
+		}
+	}()
 
 	// http handlers
 	middlewares := handlers.NewMiddleware(tm, enforcer.Enforcer, logger)
 	userHandler := handlers.NewUser(userService)
 	videoHandler := handlers.NewVideoHandler(logger, config.Timeout.Duration, videoService)
+	healthHandler := handlers.NewHealth(capabilities)
 
 	engine := gin.New()
+	if err := engine.SetTrustedProxies(config.TrustedProxies); err != nil {
+		log.Fatal(err)
+	}
 	engine.Use(middlewares.ErrorMiddleware())
 	engine.Use(middlewares.Cors())
+	// the local backend hands out links to this route instead of presigned MinIO URLs
+	if config.Storage.Type == storage.TypeLocal {
+		engine.Static(storage.Route, config.Storage.Dir)
+	}
 	//register http routes
 	routing.RegisterRoutes(engine, routing.Handlers{
-		UserHandler:  userHandler,
-		VideoHandler: videoHandler,
-		Middlewares:  middlewares,
+		UserHandler:   userHandler,
+		VideoHandler:  videoHandler,
+		HealthHandler: healthHandler,
+		Middlewares:   middlewares,
 	})
 
 	// run server
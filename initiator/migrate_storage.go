@@ -0,0 +1,151 @@
+package initiator
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"video-processing/database/db"
+	"video-processing/models"
+	"video-processing/services/video"
+	"video-processing/storage"
+
+	"github.com/google/uuid"
+)
+
+// MigrateStorage copies the objects of one user, or of every user, from the storage of the
+// -from config to the storage of the -to config and points the database of -from at the copies.
+// The configs may differ in backend (storage.type, storage.dir and minio) and in layout
+// (storage.bucket). Stop the API and workers first so no upload lands behind the migration,
+// then start them on the -to storage.
+//
+//	go run . migrate-storage -to ./config/target [-from ./config] [-user <id>] [-delete]
+func MigrateStorage(args []string) {
+	flags := flag.NewFlagSet("migrate-storage", flag.ExitOnError)
+	from := flags.String("from", "./config", "folder of the config of the current storage")
+	to := flags.String("to", "", "folder of the config of the storage to migrate to")
+	user := flags.String("user", "", "id of the user to migrate, every user when empty")
+	remove := flags.Bool("delete", false, "remove the source objects once the database points at their copies")
+	flags.Parse(args)
+
+	if err := migrateStorage(*from, *to, *user, *remove); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func migrateStorage(from, to, user string, remove bool) error {
+	if to == "" {
+		return errors.New("the config folder of the destination storage is required, see -to")
+	}
+	logger := NewLogger()
+	fromConfig, err := LoadConfig(from)
+	if err != nil {
+		return err
+	}
+	toConfig, err := LoadConfig(to)
+	if err != nil {
+		return err
+	}
+	if sameStorage(fromConfig, toConfig) {
+		return errors.New("the source and destination storage are the same")
+	}
+	if toConfig.Storage.Bucket != "" && toConfig.Storage.Bucket == fromConfig.Ingest.Bucket {
+		return fmt.Errorf("the shared bucket %s is the ingest bucket, which would ingest every copy", toConfig.Storage.Bucket)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	dsn := fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=disable",
+		fromConfig.Database.User, fromConfig.Database.Password,
+		fromConfig.Database.Host, fromConfig.Database.Port,
+		fromConfig.Database.Name)
+	pool, err := NewPool(ctx, dsn)
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+	queries := db.New(pool)
+
+	users, err := migrationUsers(ctx, queries, user)
+	if err != nil {
+		return err
+	}
+	fromStore, err := NewObjectStore(logger, fromConfig)
+	if err != nil {
+		return err
+	}
+	toStore, err := NewObjectStore(logger, toConfig)
+	if err != nil {
+		return err
+	}
+	workDir, err := os.MkdirTemp("", "storage-migration-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(workDir)
+
+	migration := video.StorageMigration{
+		Logger:     logger,
+		DB:         queries,
+		From:       fromStore,
+		To:         toStore,
+		FromLayout: storage.Layout{Bucket: fromConfig.Storage.Bucket},
+		ToLayout:   storage.Layout{Bucket: toConfig.Storage.Bucket},
+		WorkDir:    workDir,
+		Delete:     remove,
+	}
+	var objects int
+	var size int64
+	for _, id := range users {
+		report, err := migration.MigrateUser(ctx, id)
+		if err != nil {
+			return fmt.Errorf("failed to migrate user %s: %w", id, err)
+		}
+		logger.Info("migrated user", "id", id, "objects", report.Objects, "bytes", report.Bytes,
+			"videos", report.Moved.Videos, "variants", report.Moved.Variants, "assets", report.Moved.Assets,
+			"subtitles", report.Moved.Subtitles, "thumbnails", report.Moved.Thumbnails, "exports", report.Moved.Exports,
+			"versions", report.Moved.Versions, "removed", report.Removed)
+		objects += report.Objects
+		size += report.Bytes
+	}
+	logger.Info("storage migration complete", "users", len(users), "objects", objects, "bytes", size)
+	return nil
+}
+
+// migrationUsers parses the user of the -user flag, or lists every user when it is empty
+func migrationUsers(ctx context.Context, queries *db.Queries, user string) ([]uuid.UUID, error) {
+	if user == "" {
+		users, err := queries.ListUserIDs(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list users: %w", err)
+		}
+		return users, nil
+	}
+	id, err := uuid.Parse(user)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user id %q: %w", user, err)
+	}
+	return []uuid.UUID{id}, nil
+}
+
+// sameStorage reports whether two configs put the objects of users in the same place
+func sameStorage(a, b models.Config) bool {
+	if a.Storage.Bucket != b.Storage.Bucket || storageType(a) != storageType(b) {
+		return false
+	}
+	if storageType(a) == storage.TypeLocal {
+		return filepath.Clean(a.Storage.Dir) == filepath.Clean(b.Storage.Dir)
+	}
+	return a.Minio.Endpoint == b.Minio.Endpoint
+}
+
+func storageType(config models.Config) string {
+	if config.Storage.Type == "" {
+		return storage.TypeMinio
+	}
+	return config.Storage.Type
+}
@@ -0,0 +1,207 @@
+package initiator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"log/slog"
+	"video-processing/database/db"
+	"video-processing/models"
+	"video-processing/services/video"
+	"video-processing/storage"
+	"video-processing/utils"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/minio/minio-go/v7"
+)
+
+// seedUser is a demo account created by the seed command
+type seedUser struct {
+	params db.CreateUserParams
+	admin  bool
+}
+
+var seedUsers = []seedUser{
+	{
+		params: db.CreateUserParams{
+			FirstName: "Admin",
+			LastName:  "Demo",
+			Phone:     "0911000001",
+			Username:  "admin",
+			Password:  "admin123",
+			Email:     "admin@example.com",
+		},
+		admin: true,
+	},
+	{
+		params: db.CreateUserParams{
+			FirstName: "Abebe",
+			LastName:  "Kebede",
+			Phone:     "0911000002",
+			Username:  "abebe",
+			Password:  "demo1234",
+			Email:     "abebe@example.com",
+		},
+	},
+}
+
+// seedVideos are created as already processed for every demo user without videos
+var seedVideos = []struct {
+	title, description string
+}{
+	{"Big Buck Bunny", "Demo video with a full rendition ladder"},
+	{"Sintel trailer", "Second demo video"},
+}
+
+var seedVariants = []struct {
+	name          string
+	width, height int32
+	bitrateKbps   int32
+}{
+	{"1080p", 1920, 1080, 4000},
+	{"720p", 1280, 720, 2000},
+	{"480p", 854, 480, 1000},
+}
+
+// Seed stands up a local or demo environment: it runs the migrations and creates demo users,
+// their buckets, the admin role assignment and processed-looking videos. Running it again only
+// adds what is missing. The videos have metadata only; their objects are not uploaded.
+func Seed() {
+	logger := NewLogger()
+	config, err := LoadConfig("./config")
+	if err != nil {
+		log.Fatal(err)
+	}
+	dsn := fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=disable",
+		config.Database.User, config.Database.Password,
+		config.Database.Host, config.Database.Port,
+		config.Database.Name)
+	ctx := context.Background()
+	pool, err := NewPool(ctx, dsn)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer pool.Close()
+	if err := RunMigrations("file://./database/schema", config.Database.Name, dsn); err != nil {
+		log.Fatal(err)
+	}
+	enforcer, err := NewEnforcer(pool, logger, "./config")
+	if err != nil {
+		log.Fatal(err)
+	}
+	queries := db.New(pool)
+	layout := storage.Layout{Bucket: config.Storage.Bucket}
+	objectStore, err := NewObjectStore(logger, config)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for _, su := range seedUsers {
+		u, err := seedAccount(ctx, queries, su.params)
+		if err != nil {
+			log.Fatal(err)
+		}
+		logger.Info("seeded user", "email", u.Email, "password", su.params.Password, "id", u.ID)
+
+		if su.admin {
+			if _, err := enforcer.AddGroupingPolicy(u.ID.String(), "admin", "default"); err != nil {
+				log.Fatal(err)
+			}
+		}
+		bucket, _ := layout.Root(u.ID)
+		if err := seedBucket(ctx, objectStore, bucket); err != nil {
+			log.Fatal(err)
+		}
+		if err := seedUserVideos(ctx, logger, queries, layout, u.ID); err != nil {
+			log.Fatal(err)
+		}
+	}
+	logger.Info("seed complete")
+}
+
+// seedAccount returns the user with the params' email, creating it when missing
+func seedAccount(ctx context.Context, queries *db.Queries, params db.CreateUserParams) (db.User, error) {
+	u, err := queries.GetUserByEmail(ctx, params.Email)
+	if err == nil {
+		return u, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return db.User{}, fmt.Errorf("failed to look up %s: %w", params.Email, err)
+	}
+	hash, err := utils.HashPassword(params.Password)
+	if err != nil {
+		return db.User{}, err
+	}
+	params.Password = hash
+	u, err = queries.CreateUser(ctx, params)
+	if err != nil {
+		return db.User{}, fmt.Errorf("failed to create %s: %w", params.Email, err)
+	}
+	return u, nil
+}
+
+func seedBucket(ctx context.Context, store video.ObjectStore, bucket string) error {
+	buckets, err := store.ListBuckets(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list buckets: %w", err)
+	}
+	for _, b := range buckets {
+		if b.Name == bucket {
+			return nil
+		}
+	}
+	return store.MakeBucket(ctx, bucket, minio.MakeBucketOptions{})
+}
+
+// seedUserVideos creates the demo videos with a processed rendition ladder for a user who has none
+func seedUserVideos(ctx context.Context, logger *slog.Logger, queries *db.Queries, layout storage.Layout, userID uuid.UUID) error {
+	existing, err := queries.ListUserVideos(ctx, db.ListUserVideosParams{UserID: userID, Limit: 1})
+	if err != nil {
+		return fmt.Errorf("failed to list videos: %w", err)
+	}
+	if len(existing) > 0 {
+		return nil
+	}
+	bucket, prefix := layout.Root(userID)
+	for _, sv := range seedVideos {
+		v, err := queries.CreateVideo(ctx, db.CreateVideoParams{
+			UserID:        userID,
+			Title:         sv.title,
+			Description:   sv.description,
+			Bucket:        bucket,
+			Key:           fmt.Sprintf("%sseed/%s.mp4", prefix, uuid.NewString()),
+			FileSizeBytes: 10 << 20,
+			ContentType:   "video/mp4",
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create video: %w", err)
+		}
+		results := fmt.Sprintf("%sprocessed/%s", prefix, uuid.NewString())
+		for _, variant := range seedVariants {
+			_, err := queries.SaveProcessedVideoMetadata(ctx, db.SaveProcessedVideoMetadataParams{
+				VideoID:        v.ID,
+				VariantName:    variant.name,
+				Bucket:         bucket,
+				Key:            fmt.Sprintf("%s/%s/%s.mp4", results, variant.name, variant.name),
+				ContentType:    "video/mp4",
+				HlsPlaylistKey: pgtype.Text{String: fmt.Sprintf("%s/%s/index.m3u8", results, variant.name), Valid: true},
+				ThumbnailKey:   pgtype.Text{String: fmt.Sprintf("%s/%s/%s-thumb.jpg", results, variant.name, variant.name), Valid: true},
+				Width:          pgtype.Int4{Int32: variant.width, Valid: true},
+				Height:         pgtype.Int4{Int32: variant.height, Valid: true},
+				BitrateKbps:    pgtype.Int4{Int32: variant.bitrateKbps, Valid: true},
+				Format:         video.FormatMP4,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to save variant %s: %w", variant.name, err)
+			}
+		}
+		if _, err := queries.UpdateVideoStatus(ctx, db.UpdateVideoStatusParams{Status: models.VideoProcessed, ID: v.ID}); err != nil {
+			return fmt.Errorf("failed to mark video processed: %w", err)
+		}
+		logger.Info("seeded video", "title", sv.title, "id", v.ID)
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package initiator
+
+import (
+	"fmt"
+	"log/slog"
+	"video-processing/models"
+	"video-processing/services/video"
+	"video-processing/storage"
+)
+
+// NewObjectStore returns the object storage backend selected by storage.type
+func NewObjectStore(logger *slog.Logger, config models.Config) (video.ObjectStore, error) {
+	switch config.Storage.Type {
+	case "", storage.TypeMinio:
+		sse, err := video.ServerSideEncryption(config.Storage.Encryption)
+		if err != nil {
+			return nil, err
+		}
+		if sse != nil {
+			logger.Info("✅ Server-side encryption enabled", "type", config.Storage.Encryption.Type)
+		}
+		return video.EncryptObjects(InitMinio(logger, config), sse), nil
+	case storage.TypeLocal:
+		if config.Storage.Encryption.Type != video.EncryptionNone {
+			return nil, fmt.Errorf("%s encryption needs the minio storage backend", config.Storage.Encryption.Type)
+		}
+		local, err := storage.NewLocal(config.Storage.Dir, config.Storage.BaseURL)
+		if err != nil {
+			return nil, err
+		}
+		logger.Info("✅ Local storage ready", "dir", local.Dir())
+		return local, nil
+	default:
+		return nil, fmt.Errorf("unknown storage type %q", config.Storage.Type)
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"os"
 	_ "video-processing/docs"
 	"video-processing/initiator"
 )
@@ -21,5 +22,15 @@ import (
 // @BasePath  /v1
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "seed":
+			initiator.Seed()
+			return
+		case "migrate-storage":
+			initiator.MigrateStorage(os.Args[2:])
+			return
+		}
+	}
 	initiator.Init()
 }
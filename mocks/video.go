@@ -0,0 +1,3241 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: video-processing/services/video (interfaces: ObjectStore,Broker,VideoRepo,Streamer,VideoProcessor)
+//
+// Generated by this command:
+//
+//	mockgen -destination=../../mocks/video.go -package=mocks . ObjectStore,Broker,VideoRepo,Streamer,VideoProcessor
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	io "io"
+	url "net/url"
+	reflect "reflect"
+	time "time"
+	db "video-processing/database/db"
+	models "video-processing/models"
+
+	uuid "github.com/google/uuid"
+	pgtype "github.com/jackc/pgx/v5/pgtype"
+	minio "github.com/minio/minio-go/v7"
+	redis "github.com/redis/go-redis/v9"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockObjectStore is a mock of ObjectStore interface.
+type MockObjectStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockObjectStoreMockRecorder
+	isgomock struct{}
+}
+
+// MockObjectStoreMockRecorder is the mock recorder for MockObjectStore.
+type MockObjectStoreMockRecorder struct {
+	mock *MockObjectStore
+}
+
+// NewMockObjectStore creates a new mock instance.
+func NewMockObjectStore(ctrl *gomock.Controller) *MockObjectStore {
+	mock := &MockObjectStore{ctrl: ctrl}
+	mock.recorder = &MockObjectStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockObjectStore) EXPECT() *MockObjectStoreMockRecorder {
+	return m.recorder
+}
+
+// FGetObject mocks base method.
+func (m *MockObjectStore) FGetObject(ctx context.Context, bucketName, objectName, filePath string, opts minio.GetObjectOptions) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FGetObject", ctx, bucketName, objectName, filePath, opts)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FGetObject indicates an expected call of FGetObject.
+func (mr *MockObjectStoreMockRecorder) FGetObject(ctx, bucketName, objectName, filePath, opts any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FGetObject", reflect.TypeOf((*MockObjectStore)(nil).FGetObject), ctx, bucketName, objectName, filePath, opts)
+}
+
+// FPutObject mocks base method.
+func (m *MockObjectStore) FPutObject(ctx context.Context, bucketName, objectName, filePath string, opts minio.PutObjectOptions) (minio.UploadInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FPutObject", ctx, bucketName, objectName, filePath, opts)
+	ret0, _ := ret[0].(minio.UploadInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FPutObject indicates an expected call of FPutObject.
+func (mr *MockObjectStoreMockRecorder) FPutObject(ctx, bucketName, objectName, filePath, opts any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FPutObject", reflect.TypeOf((*MockObjectStore)(nil).FPutObject), ctx, bucketName, objectName, filePath, opts)
+}
+
+// ListBuckets mocks base method.
+func (m *MockObjectStore) ListBuckets(ctx context.Context) ([]minio.BucketInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListBuckets", ctx)
+	ret0, _ := ret[0].([]minio.BucketInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListBuckets indicates an expected call of ListBuckets.
+func (mr *MockObjectStoreMockRecorder) ListBuckets(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListBuckets", reflect.TypeOf((*MockObjectStore)(nil).ListBuckets), ctx)
+}
+
+// ListObjects mocks base method.
+func (m *MockObjectStore) ListObjects(ctx context.Context, bucketName string, opts minio.ListObjectsOptions) <-chan minio.ObjectInfo {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListObjects", ctx, bucketName, opts)
+	ret0, _ := ret[0].(<-chan minio.ObjectInfo)
+	return ret0
+}
+
+// ListObjects indicates an expected call of ListObjects.
+func (mr *MockObjectStoreMockRecorder) ListObjects(ctx, bucketName, opts any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListObjects", reflect.TypeOf((*MockObjectStore)(nil).ListObjects), ctx, bucketName, opts)
+}
+
+// MakeBucket mocks base method.
+func (m *MockObjectStore) MakeBucket(ctx context.Context, bucketName string, opts minio.MakeBucketOptions) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MakeBucket", ctx, bucketName, opts)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MakeBucket indicates an expected call of MakeBucket.
+func (mr *MockObjectStoreMockRecorder) MakeBucket(ctx, bucketName, opts any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MakeBucket", reflect.TypeOf((*MockObjectStore)(nil).MakeBucket), ctx, bucketName, opts)
+}
+
+// PresignedGetObject mocks base method.
+func (m *MockObjectStore) PresignedGetObject(ctx context.Context, bucketName, objectName string, expires time.Duration, reqParams url.Values) (*url.URL, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PresignedGetObject", ctx, bucketName, objectName, expires, reqParams)
+	ret0, _ := ret[0].(*url.URL)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PresignedGetObject indicates an expected call of PresignedGetObject.
+func (mr *MockObjectStoreMockRecorder) PresignedGetObject(ctx, bucketName, objectName, expires, reqParams any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PresignedGetObject", reflect.TypeOf((*MockObjectStore)(nil).PresignedGetObject), ctx, bucketName, objectName, expires, reqParams)
+}
+
+// PutObject mocks base method.
+func (m *MockObjectStore) PutObject(ctx context.Context, bucketName, objectName string, reader io.Reader, size int64, opts minio.PutObjectOptions) (minio.UploadInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PutObject", ctx, bucketName, objectName, reader, size, opts)
+	ret0, _ := ret[0].(minio.UploadInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PutObject indicates an expected call of PutObject.
+func (mr *MockObjectStoreMockRecorder) PutObject(ctx, bucketName, objectName, reader, size, opts any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PutObject", reflect.TypeOf((*MockObjectStore)(nil).PutObject), ctx, bucketName, objectName, reader, size, opts)
+}
+
+// RemoveObject mocks base method.
+func (m *MockObjectStore) RemoveObject(ctx context.Context, bucketName, objectName string, opts minio.RemoveObjectOptions) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveObject", ctx, bucketName, objectName, opts)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveObject indicates an expected call of RemoveObject.
+func (mr *MockObjectStoreMockRecorder) RemoveObject(ctx, bucketName, objectName, opts any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveObject", reflect.TypeOf((*MockObjectStore)(nil).RemoveObject), ctx, bucketName, objectName, opts)
+}
+
+// StatObject mocks base method.
+func (m *MockObjectStore) StatObject(ctx context.Context, bucketName, objectName string, opts minio.StatObjectOptions) (minio.ObjectInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StatObject", ctx, bucketName, objectName, opts)
+	ret0, _ := ret[0].(minio.ObjectInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StatObject indicates an expected call of StatObject.
+func (mr *MockObjectStoreMockRecorder) StatObject(ctx, bucketName, objectName, opts any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StatObject", reflect.TypeOf((*MockObjectStore)(nil).StatObject), ctx, bucketName, objectName, opts)
+}
+
+// MockBroker is a mock of Broker interface.
+type MockBroker struct {
+	ctrl     *gomock.Controller
+	recorder *MockBrokerMockRecorder
+	isgomock struct{}
+}
+
+// MockBrokerMockRecorder is the mock recorder for MockBroker.
+type MockBrokerMockRecorder struct {
+	mock *MockBroker
+}
+
+// NewMockBroker creates a new mock instance.
+func NewMockBroker(ctrl *gomock.Controller) *MockBroker {
+	mock := &MockBroker{ctrl: ctrl}
+	mock.recorder = &MockBrokerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockBroker) EXPECT() *MockBrokerMockRecorder {
+	return m.recorder
+}
+
+// Eval mocks base method.
+func (m *MockBroker) Eval(ctx context.Context, script string, keys []string, args ...any) *redis.Cmd {
+	m.ctrl.T.Helper()
+	varargs := []any{ctx, script, keys}
+	for _, a := range args {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Eval", varargs...)
+	ret0, _ := ret[0].(*redis.Cmd)
+	return ret0
+}
+
+// Eval indicates an expected call of Eval.
+func (mr *MockBrokerMockRecorder) Eval(ctx, script, keys any, args ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{ctx, script, keys}, args...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Eval", reflect.TypeOf((*MockBroker)(nil).Eval), varargs...)
+}
+
+// XAck mocks base method.
+func (m *MockBroker) XAck(ctx context.Context, stream, group string, ids ...string) *redis.IntCmd {
+	m.ctrl.T.Helper()
+	varargs := []any{ctx, stream, group}
+	for _, a := range ids {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "XAck", varargs...)
+	ret0, _ := ret[0].(*redis.IntCmd)
+	return ret0
+}
+
+// XAck indicates an expected call of XAck.
+func (mr *MockBrokerMockRecorder) XAck(ctx, stream, group any, ids ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{ctx, stream, group}, ids...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "XAck", reflect.TypeOf((*MockBroker)(nil).XAck), varargs...)
+}
+
+// XAdd mocks base method.
+func (m *MockBroker) XAdd(ctx context.Context, a *redis.XAddArgs) *redis.StringCmd {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "XAdd", ctx, a)
+	ret0, _ := ret[0].(*redis.StringCmd)
+	return ret0
+}
+
+// XAdd indicates an expected call of XAdd.
+func (mr *MockBrokerMockRecorder) XAdd(ctx, a any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "XAdd", reflect.TypeOf((*MockBroker)(nil).XAdd), ctx, a)
+}
+
+// XGroupCreateMkStream mocks base method.
+func (m *MockBroker) XGroupCreateMkStream(ctx context.Context, stream, group, start string) *redis.StatusCmd {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "XGroupCreateMkStream", ctx, stream, group, start)
+	ret0, _ := ret[0].(*redis.StatusCmd)
+	return ret0
+}
+
+// XGroupCreateMkStream indicates an expected call of XGroupCreateMkStream.
+func (mr *MockBrokerMockRecorder) XGroupCreateMkStream(ctx, stream, group, start any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "XGroupCreateMkStream", reflect.TypeOf((*MockBroker)(nil).XGroupCreateMkStream), ctx, stream, group, start)
+}
+
+// XReadGroup mocks base method.
+func (m *MockBroker) XReadGroup(ctx context.Context, a *redis.XReadGroupArgs) *redis.XStreamSliceCmd {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "XReadGroup", ctx, a)
+	ret0, _ := ret[0].(*redis.XStreamSliceCmd)
+	return ret0
+}
+
+// XReadGroup indicates an expected call of XReadGroup.
+func (mr *MockBrokerMockRecorder) XReadGroup(ctx, a any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "XReadGroup", reflect.TypeOf((*MockBroker)(nil).XReadGroup), ctx, a)
+}
+
+// MockVideoRepo is a mock of VideoRepo interface.
+type MockVideoRepo struct {
+	ctrl     *gomock.Controller
+	recorder *MockVideoRepoMockRecorder
+	isgomock struct{}
+}
+
+// MockVideoRepoMockRecorder is the mock recorder for MockVideoRepo.
+type MockVideoRepoMockRecorder struct {
+	mock *MockVideoRepo
+}
+
+// NewMockVideoRepo creates a new mock instance.
+func NewMockVideoRepo(ctrl *gomock.Controller) *MockVideoRepo {
+	mock := &MockVideoRepo{ctrl: ctrl}
+	mock.recorder = &MockVideoRepoMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockVideoRepo) EXPECT() *MockVideoRepoMockRecorder {
+	return m.recorder
+}
+
+// AdvanceReprocessRun mocks base method.
+func (m *MockVideoRepo) AdvanceReprocessRun(ctx context.Context, arg db.AdvanceReprocessRunParams) (db.ReprocessRun, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AdvanceReprocessRun", ctx, arg)
+	ret0, _ := ret[0].(db.ReprocessRun)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AdvanceReprocessRun indicates an expected call of AdvanceReprocessRun.
+func (mr *MockVideoRepoMockRecorder) AdvanceReprocessRun(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AdvanceReprocessRun", reflect.TypeOf((*MockVideoRepo)(nil).AdvanceReprocessRun), ctx, arg)
+}
+
+// ArchiveRenditions mocks base method.
+func (m *MockVideoRepo) ArchiveRenditions(ctx context.Context, videoID uuid.UUID) (db.VideoRenditionVersion, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ArchiveRenditions", ctx, videoID)
+	ret0, _ := ret[0].(db.VideoRenditionVersion)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ArchiveRenditions indicates an expected call of ArchiveRenditions.
+func (mr *MockVideoRepoMockRecorder) ArchiveRenditions(ctx, videoID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ArchiveRenditions", reflect.TypeOf((*MockVideoRepo)(nil).ArchiveRenditions), ctx, videoID)
+}
+
+// ClaimDataExport mocks base method.
+func (m *MockVideoRepo) ClaimDataExport(ctx context.Context, leaseUntil time.Time) (db.DataExport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimDataExport", ctx, leaseUntil)
+	ret0, _ := ret[0].(db.DataExport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimDataExport indicates an expected call of ClaimDataExport.
+func (mr *MockVideoRepoMockRecorder) ClaimDataExport(ctx, leaseUntil any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimDataExport", reflect.TypeOf((*MockVideoRepo)(nil).ClaimDataExport), ctx, leaseUntil)
+}
+
+// ClaimReprocessRun mocks base method.
+func (m *MockVideoRepo) ClaimReprocessRun(ctx context.Context, leaseUntil time.Time) (db.ReprocessRun, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimReprocessRun", ctx, leaseUntil)
+	ret0, _ := ret[0].(db.ReprocessRun)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimReprocessRun indicates an expected call of ClaimReprocessRun.
+func (mr *MockVideoRepoMockRecorder) ClaimReprocessRun(ctx, leaseUntil any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimReprocessRun", reflect.TypeOf((*MockVideoRepo)(nil).ClaimReprocessRun), ctx, leaseUntil)
+}
+
+// ClaimUsageToEmit mocks base method.
+func (m *MockVideoRepo) ClaimUsageToEmit(ctx context.Context, arg db.ClaimUsageToEmitParams) ([]db.UsageMonthly, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimUsageToEmit", ctx, arg)
+	ret0, _ := ret[0].([]db.UsageMonthly)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimUsageToEmit indicates an expected call of ClaimUsageToEmit.
+func (mr *MockVideoRepoMockRecorder) ClaimUsageToEmit(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimUsageToEmit", reflect.TypeOf((*MockVideoRepo)(nil).ClaimUsageToEmit), ctx, arg)
+}
+
+// ClearPlaybackFailures mocks base method.
+func (m *MockVideoRepo) ClearPlaybackFailures(ctx context.Context, arg db.ClearPlaybackFailuresParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClearPlaybackFailures", ctx, arg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ClearPlaybackFailures indicates an expected call of ClearPlaybackFailures.
+func (mr *MockVideoRepoMockRecorder) ClearPlaybackFailures(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClearPlaybackFailures", reflect.TypeOf((*MockVideoRepo)(nil).ClearPlaybackFailures), ctx, arg)
+}
+
+// ClearVideoFailure mocks base method.
+func (m *MockVideoRepo) ClearVideoFailure(ctx context.Context, id uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClearVideoFailure", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ClearVideoFailure indicates an expected call of ClearVideoFailure.
+func (mr *MockVideoRepoMockRecorder) ClearVideoFailure(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClearVideoFailure", reflect.TypeOf((*MockVideoRepo)(nil).ClearVideoFailure), ctx, id)
+}
+
+// ClearWatchHistory mocks base method.
+func (m *MockVideoRepo) ClearWatchHistory(ctx context.Context, userID uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClearWatchHistory", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ClearWatchHistory indicates an expected call of ClearWatchHistory.
+func (mr *MockVideoRepoMockRecorder) ClearWatchHistory(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClearWatchHistory", reflect.TypeOf((*MockVideoRepo)(nil).ClearWatchHistory), ctx, userID)
+}
+
+// CompleteDataExport mocks base method.
+func (m *MockVideoRepo) CompleteDataExport(ctx context.Context, arg db.CompleteDataExportParams) (db.DataExport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompleteDataExport", ctx, arg)
+	ret0, _ := ret[0].(db.DataExport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CompleteDataExport indicates an expected call of CompleteDataExport.
+func (mr *MockVideoRepoMockRecorder) CompleteDataExport(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompleteDataExport", reflect.TypeOf((*MockVideoRepo)(nil).CompleteDataExport), ctx, arg)
+}
+
+// CountReprocessCandidates mocks base method.
+func (m *MockVideoRepo) CountReprocessCandidates(ctx context.Context, arg db.CountReprocessCandidatesParams) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountReprocessCandidates", ctx, arg)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountReprocessCandidates indicates an expected call of CountReprocessCandidates.
+func (mr *MockVideoRepoMockRecorder) CountReprocessCandidates(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountReprocessCandidates", reflect.TypeOf((*MockVideoRepo)(nil).CountReprocessCandidates), ctx, arg)
+}
+
+// CreateDataExport mocks base method.
+func (m *MockVideoRepo) CreateDataExport(ctx context.Context, userID uuid.UUID) (db.DataExport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateDataExport", ctx, userID)
+	ret0, _ := ret[0].(db.DataExport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateDataExport indicates an expected call of CreateDataExport.
+func (mr *MockVideoRepoMockRecorder) CreateDataExport(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateDataExport", reflect.TypeOf((*MockVideoRepo)(nil).CreateDataExport), ctx, userID)
+}
+
+// CreateDerivedVideo mocks base method.
+func (m *MockVideoRepo) CreateDerivedVideo(ctx context.Context, arg db.CreateDerivedVideoParams) (db.Video, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateDerivedVideo", ctx, arg)
+	ret0, _ := ret[0].(db.Video)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateDerivedVideo indicates an expected call of CreateDerivedVideo.
+func (mr *MockVideoRepoMockRecorder) CreateDerivedVideo(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateDerivedVideo", reflect.TypeOf((*MockVideoRepo)(nil).CreateDerivedVideo), ctx, arg)
+}
+
+// CreateNotification mocks base method.
+func (m *MockVideoRepo) CreateNotification(ctx context.Context, arg db.CreateNotificationParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateNotification", ctx, arg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateNotification indicates an expected call of CreateNotification.
+func (mr *MockVideoRepoMockRecorder) CreateNotification(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateNotification", reflect.TypeOf((*MockVideoRepo)(nil).CreateNotification), ctx, arg)
+}
+
+// CreateReprocessRun mocks base method.
+func (m *MockVideoRepo) CreateReprocessRun(ctx context.Context, arg db.CreateReprocessRunParams) (db.ReprocessRun, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateReprocessRun", ctx, arg)
+	ret0, _ := ret[0].(db.ReprocessRun)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateReprocessRun indicates an expected call of CreateReprocessRun.
+func (mr *MockVideoRepoMockRecorder) CreateReprocessRun(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateReprocessRun", reflect.TypeOf((*MockVideoRepo)(nil).CreateReprocessRun), ctx, arg)
+}
+
+// CreateTranscodingPreset mocks base method.
+func (m *MockVideoRepo) CreateTranscodingPreset(ctx context.Context, arg db.CreateTranscodingPresetParams) (db.TranscodingPreset, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateTranscodingPreset", ctx, arg)
+	ret0, _ := ret[0].(db.TranscodingPreset)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateTranscodingPreset indicates an expected call of CreateTranscodingPreset.
+func (mr *MockVideoRepoMockRecorder) CreateTranscodingPreset(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTranscodingPreset", reflect.TypeOf((*MockVideoRepo)(nil).CreateTranscodingPreset), ctx, arg)
+}
+
+// CreateVideo mocks base method.
+func (m *MockVideoRepo) CreateVideo(ctx context.Context, arg db.CreateVideoParams) (db.Video, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateVideo", ctx, arg)
+	ret0, _ := ret[0].(db.Video)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateVideo indicates an expected call of CreateVideo.
+func (mr *MockVideoRepoMockRecorder) CreateVideo(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateVideo", reflect.TypeOf((*MockVideoRepo)(nil).CreateVideo), ctx, arg)
+}
+
+// CreateVideoChapter mocks base method.
+func (m *MockVideoRepo) CreateVideoChapter(ctx context.Context, arg db.CreateVideoChapterParams) (db.VideoChapter, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateVideoChapter", ctx, arg)
+	ret0, _ := ret[0].(db.VideoChapter)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateVideoChapter indicates an expected call of CreateVideoChapter.
+func (mr *MockVideoRepoMockRecorder) CreateVideoChapter(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateVideoChapter", reflect.TypeOf((*MockVideoRepo)(nil).CreateVideoChapter), ctx, arg)
+}
+
+// CreateVideoKey mocks base method.
+func (m *MockVideoRepo) CreateVideoKey(ctx context.Context, arg db.CreateVideoKeyParams) ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateVideoKey", ctx, arg)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateVideoKey indicates an expected call of CreateVideoKey.
+func (mr *MockVideoRepoMockRecorder) CreateVideoKey(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateVideoKey", reflect.TypeOf((*MockVideoRepo)(nil).CreateVideoKey), ctx, arg)
+}
+
+// CreateVideoNotifications mocks base method.
+func (m *MockVideoRepo) CreateVideoNotifications(ctx context.Context, arg db.CreateVideoNotificationsParams) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateVideoNotifications", ctx, arg)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateVideoNotifications indicates an expected call of CreateVideoNotifications.
+func (mr *MockVideoRepoMockRecorder) CreateVideoNotifications(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateVideoNotifications", reflect.TypeOf((*MockVideoRepo)(nil).CreateVideoNotifications), ctx, arg)
+}
+
+// CreateVideoReport mocks base method.
+func (m *MockVideoRepo) CreateVideoReport(ctx context.Context, arg db.CreateVideoReportParams) (db.VideoReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateVideoReport", ctx, arg)
+	ret0, _ := ret[0].(db.VideoReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateVideoReport indicates an expected call of CreateVideoReport.
+func (mr *MockVideoRepoMockRecorder) CreateVideoReport(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateVideoReport", reflect.TypeOf((*MockVideoRepo)(nil).CreateVideoReport), ctx, arg)
+}
+
+// DeferVideoPurge mocks base method.
+func (m *MockVideoRepo) DeferVideoPurge(ctx context.Context, arg db.DeferVideoPurgeParams) (int32, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeferVideoPurge", ctx, arg)
+	ret0, _ := ret[0].(int32)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeferVideoPurge indicates an expected call of DeferVideoPurge.
+func (mr *MockVideoRepoMockRecorder) DeferVideoPurge(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeferVideoPurge", reflect.TypeOf((*MockVideoRepo)(nil).DeferVideoPurge), ctx, arg)
+}
+
+// DeleteResultSize mocks base method.
+func (m *MockVideoRepo) DeleteResultSize(ctx context.Context, arg db.DeleteResultSizeParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteResultSize", ctx, arg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteResultSize indicates an expected call of DeleteResultSize.
+func (mr *MockVideoRepoMockRecorder) DeleteResultSize(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteResultSize", reflect.TypeOf((*MockVideoRepo)(nil).DeleteResultSize), ctx, arg)
+}
+
+// DeleteTranscodingPreset mocks base method.
+func (m *MockVideoRepo) DeleteTranscodingPreset(ctx context.Context, id uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteTranscodingPreset", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteTranscodingPreset indicates an expected call of DeleteTranscodingPreset.
+func (mr *MockVideoRepoMockRecorder) DeleteTranscodingPreset(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteTranscodingPreset", reflect.TypeOf((*MockVideoRepo)(nil).DeleteTranscodingPreset), ctx, id)
+}
+
+// DeleteVideo mocks base method.
+func (m *MockVideoRepo) DeleteVideo(ctx context.Context, id uuid.UUID) (db.Video, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteVideo", ctx, id)
+	ret0, _ := ret[0].(db.Video)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteVideo indicates an expected call of DeleteVideo.
+func (mr *MockVideoRepoMockRecorder) DeleteVideo(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteVideo", reflect.TypeOf((*MockVideoRepo)(nil).DeleteVideo), ctx, id)
+}
+
+// DeleteVideoChapters mocks base method.
+func (m *MockVideoRepo) DeleteVideoChapters(ctx context.Context, videoID uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteVideoChapters", ctx, videoID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteVideoChapters indicates an expected call of DeleteVideoChapters.
+func (mr *MockVideoRepoMockRecorder) DeleteVideoChapters(ctx, videoID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteVideoChapters", reflect.TypeOf((*MockVideoRepo)(nil).DeleteVideoChapters), ctx, videoID)
+}
+
+// DeleteVideoProgress mocks base method.
+func (m *MockVideoRepo) DeleteVideoProgress(ctx context.Context, videoID uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteVideoProgress", ctx, videoID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteVideoProgress indicates an expected call of DeleteVideoProgress.
+func (mr *MockVideoRepoMockRecorder) DeleteVideoProgress(ctx, videoID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteVideoProgress", reflect.TypeOf((*MockVideoRepo)(nil).DeleteVideoProgress), ctx, videoID)
+}
+
+// DeleteVideoSubtitles mocks base method.
+func (m *MockVideoRepo) DeleteVideoSubtitles(ctx context.Context, videoID uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteVideoSubtitles", ctx, videoID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteVideoSubtitles indicates an expected call of DeleteVideoSubtitles.
+func (mr *MockVideoRepoMockRecorder) DeleteVideoSubtitles(ctx, videoID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteVideoSubtitles", reflect.TypeOf((*MockVideoRepo)(nil).DeleteVideoSubtitles), ctx, videoID)
+}
+
+// DeleteVideoThumbnailsFrom mocks base method.
+func (m *MockVideoRepo) DeleteVideoThumbnailsFrom(ctx context.Context, arg db.DeleteVideoThumbnailsFromParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteVideoThumbnailsFrom", ctx, arg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteVideoThumbnailsFrom indicates an expected call of DeleteVideoThumbnailsFrom.
+func (mr *MockVideoRepoMockRecorder) DeleteVideoThumbnailsFrom(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteVideoThumbnailsFrom", reflect.TypeOf((*MockVideoRepo)(nil).DeleteVideoThumbnailsFrom), ctx, arg)
+}
+
+// DeleteVideoTranslation mocks base method.
+func (m *MockVideoRepo) DeleteVideoTranslation(ctx context.Context, arg db.DeleteVideoTranslationParams) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteVideoTranslation", ctx, arg)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteVideoTranslation indicates an expected call of DeleteVideoTranslation.
+func (mr *MockVideoRepoMockRecorder) DeleteVideoTranslation(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteVideoTranslation", reflect.TypeOf((*MockVideoRepo)(nil).DeleteVideoTranslation), ctx, arg)
+}
+
+// DeleteWatchHistoryEntry mocks base method.
+func (m *MockVideoRepo) DeleteWatchHistoryEntry(ctx context.Context, arg db.DeleteWatchHistoryEntryParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteWatchHistoryEntry", ctx, arg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteWatchHistoryEntry indicates an expected call of DeleteWatchHistoryEntry.
+func (mr *MockVideoRepoMockRecorder) DeleteWatchHistoryEntry(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteWatchHistoryEntry", reflect.TypeOf((*MockVideoRepo)(nil).DeleteWatchHistoryEntry), ctx, arg)
+}
+
+// ExpireDataExports mocks base method.
+func (m *MockVideoRepo) ExpireDataExports(ctx context.Context, limit int32) ([]db.ExpireDataExportsRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExpireDataExports", ctx, limit)
+	ret0, _ := ret[0].([]db.ExpireDataExportsRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExpireDataExports indicates an expected call of ExpireDataExports.
+func (mr *MockVideoRepoMockRecorder) ExpireDataExports(ctx, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExpireDataExports", reflect.TypeOf((*MockVideoRepo)(nil).ExpireDataExports), ctx, limit)
+}
+
+// ExpireDueVideos mocks base method.
+func (m *MockVideoRepo) ExpireDueVideos(ctx context.Context, limit int32) ([]db.ExpireDueVideosRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExpireDueVideos", ctx, limit)
+	ret0, _ := ret[0].([]db.ExpireDueVideosRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExpireDueVideos indicates an expected call of ExpireDueVideos.
+func (mr *MockVideoRepoMockRecorder) ExpireDueVideos(ctx, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExpireDueVideos", reflect.TypeOf((*MockVideoRepo)(nil).ExpireDueVideos), ctx, limit)
+}
+
+// ExpireRenditionVersions mocks base method.
+func (m *MockVideoRepo) ExpireRenditionVersions(ctx context.Context, arg db.ExpireRenditionVersionsParams) ([]db.VideoRenditionVersion, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExpireRenditionVersions", ctx, arg)
+	ret0, _ := ret[0].([]db.VideoRenditionVersion)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExpireRenditionVersions indicates an expected call of ExpireRenditionVersions.
+func (mr *MockVideoRepoMockRecorder) ExpireRenditionVersions(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExpireRenditionVersions", reflect.TypeOf((*MockVideoRepo)(nil).ExpireRenditionVersions), ctx, arg)
+}
+
+// FailDataExport mocks base method.
+func (m *MockVideoRepo) FailDataExport(ctx context.Context, arg db.FailDataExportParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FailDataExport", ctx, arg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FailDataExport indicates an expected call of FailDataExport.
+func (mr *MockVideoRepoMockRecorder) FailDataExport(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FailDataExport", reflect.TypeOf((*MockVideoRepo)(nil).FailDataExport), ctx, arg)
+}
+
+// FailVideo mocks base method.
+func (m *MockVideoRepo) FailVideo(ctx context.Context, arg db.FailVideoParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FailVideo", ctx, arg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FailVideo indicates an expected call of FailVideo.
+func (mr *MockVideoRepoMockRecorder) FailVideo(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FailVideo", reflect.TypeOf((*MockVideoRepo)(nil).FailVideo), ctx, arg)
+}
+
+// FinishReprocessRun mocks base method.
+func (m *MockVideoRepo) FinishReprocessRun(ctx context.Context, arg db.FinishReprocessRunParams) (db.ReprocessRun, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FinishReprocessRun", ctx, arg)
+	ret0, _ := ret[0].(db.ReprocessRun)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FinishReprocessRun indicates an expected call of FinishReprocessRun.
+func (mr *MockVideoRepoMockRecorder) FinishReprocessRun(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FinishReprocessRun", reflect.TypeOf((*MockVideoRepo)(nil).FinishReprocessRun), ctx, arg)
+}
+
+// GetActiveDataExport mocks base method.
+func (m *MockVideoRepo) GetActiveDataExport(ctx context.Context, userID uuid.UUID) (db.DataExport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetActiveDataExport", ctx, userID)
+	ret0, _ := ret[0].(db.DataExport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetActiveDataExport indicates an expected call of GetActiveDataExport.
+func (mr *MockVideoRepoMockRecorder) GetActiveDataExport(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetActiveDataExport", reflect.TypeOf((*MockVideoRepo)(nil).GetActiveDataExport), ctx, userID)
+}
+
+// GetDataExport mocks base method.
+func (m *MockVideoRepo) GetDataExport(ctx context.Context, arg db.GetDataExportParams) (db.DataExport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDataExport", ctx, arg)
+	ret0, _ := ret[0].(db.DataExport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDataExport indicates an expected call of GetDataExport.
+func (mr *MockVideoRepoMockRecorder) GetDataExport(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDataExport", reflect.TypeOf((*MockVideoRepo)(nil).GetDataExport), ctx, arg)
+}
+
+// GetDefaultTranscodingPreset mocks base method.
+func (m *MockVideoRepo) GetDefaultTranscodingPreset(ctx context.Context) (db.TranscodingPreset, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDefaultTranscodingPreset", ctx)
+	ret0, _ := ret[0].(db.TranscodingPreset)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDefaultTranscodingPreset indicates an expected call of GetDefaultTranscodingPreset.
+func (mr *MockVideoRepoMockRecorder) GetDefaultTranscodingPreset(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDefaultTranscodingPreset", reflect.TypeOf((*MockVideoRepo)(nil).GetDefaultTranscodingPreset), ctx)
+}
+
+// GetNotificationPreferences mocks base method.
+func (m *MockVideoRepo) GetNotificationPreferences(ctx context.Context, userID uuid.UUID) (db.GetNotificationPreferencesRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetNotificationPreferences", ctx, userID)
+	ret0, _ := ret[0].(db.GetNotificationPreferencesRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetNotificationPreferences indicates an expected call of GetNotificationPreferences.
+func (mr *MockVideoRepoMockRecorder) GetNotificationPreferences(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNotificationPreferences", reflect.TypeOf((*MockVideoRepo)(nil).GetNotificationPreferences), ctx, userID)
+}
+
+// GetPlaybackFailures mocks base method.
+func (m *MockVideoRepo) GetPlaybackFailures(ctx context.Context, arg db.GetPlaybackFailuresParams) (int32, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPlaybackFailures", ctx, arg)
+	ret0, _ := ret[0].(int32)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPlaybackFailures indicates an expected call of GetPlaybackFailures.
+func (mr *MockVideoRepoMockRecorder) GetPlaybackFailures(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPlaybackFailures", reflect.TypeOf((*MockVideoRepo)(nil).GetPlaybackFailures), ctx, arg)
+}
+
+// GetRenditionVersion mocks base method.
+func (m *MockVideoRepo) GetRenditionVersion(ctx context.Context, arg db.GetRenditionVersionParams) (db.VideoRenditionVersion, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRenditionVersion", ctx, arg)
+	ret0, _ := ret[0].(db.VideoRenditionVersion)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRenditionVersion indicates an expected call of GetRenditionVersion.
+func (mr *MockVideoRepoMockRecorder) GetRenditionVersion(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRenditionVersion", reflect.TypeOf((*MockVideoRepo)(nil).GetRenditionVersion), ctx, arg)
+}
+
+// GetReprocessRun mocks base method.
+func (m *MockVideoRepo) GetReprocessRun(ctx context.Context, id uuid.UUID) (db.ReprocessRun, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetReprocessRun", ctx, id)
+	ret0, _ := ret[0].(db.ReprocessRun)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetReprocessRun indicates an expected call of GetReprocessRun.
+func (mr *MockVideoRepoMockRecorder) GetReprocessRun(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetReprocessRun", reflect.TypeOf((*MockVideoRepo)(nil).GetReprocessRun), ctx, id)
+}
+
+// GetTranscodingPreset mocks base method.
+func (m *MockVideoRepo) GetTranscodingPreset(ctx context.Context, id uuid.UUID) (db.TranscodingPreset, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTranscodingPreset", ctx, id)
+	ret0, _ := ret[0].(db.TranscodingPreset)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTranscodingPreset indicates an expected call of GetTranscodingPreset.
+func (mr *MockVideoRepoMockRecorder) GetTranscodingPreset(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTranscodingPreset", reflect.TypeOf((*MockVideoRepo)(nil).GetTranscodingPreset), ctx, id)
+}
+
+// GetTranscodingPresetByName mocks base method.
+func (m *MockVideoRepo) GetTranscodingPresetByName(ctx context.Context, name string) (db.TranscodingPreset, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTranscodingPresetByName", ctx, name)
+	ret0, _ := ret[0].(db.TranscodingPreset)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTranscodingPresetByName indicates an expected call of GetTranscodingPresetByName.
+func (mr *MockVideoRepoMockRecorder) GetTranscodingPresetByName(ctx, name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTranscodingPresetByName", reflect.TypeOf((*MockVideoRepo)(nil).GetTranscodingPresetByName), ctx, name)
+}
+
+// GetUser mocks base method.
+func (m *MockVideoRepo) GetUser(ctx context.Context, id uuid.UUID) (db.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUser", ctx, id)
+	ret0, _ := ret[0].(db.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUser indicates an expected call of GetUser.
+func (mr *MockVideoRepoMockRecorder) GetUser(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUser", reflect.TypeOf((*MockVideoRepo)(nil).GetUser), ctx, id)
+}
+
+// GetVideo mocks base method.
+func (m *MockVideoRepo) GetVideo(ctx context.Context, id uuid.UUID) (db.Video, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetVideo", ctx, id)
+	ret0, _ := ret[0].(db.Video)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetVideo indicates an expected call of GetVideo.
+func (mr *MockVideoRepoMockRecorder) GetVideo(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetVideo", reflect.TypeOf((*MockVideoRepo)(nil).GetVideo), ctx, id)
+}
+
+// GetVideoByObject mocks base method.
+func (m *MockVideoRepo) GetVideoByObject(ctx context.Context, arg db.GetVideoByObjectParams) (db.Video, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetVideoByObject", ctx, arg)
+	ret0, _ := ret[0].(db.Video)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetVideoByObject indicates an expected call of GetVideoByObject.
+func (mr *MockVideoRepoMockRecorder) GetVideoByObject(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetVideoByObject", reflect.TypeOf((*MockVideoRepo)(nil).GetVideoByObject), ctx, arg)
+}
+
+// GetVideoKey mocks base method.
+func (m *MockVideoRepo) GetVideoKey(ctx context.Context, videoID uuid.UUID) ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetVideoKey", ctx, videoID)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetVideoKey indicates an expected call of GetVideoKey.
+func (mr *MockVideoRepoMockRecorder) GetVideoKey(ctx, videoID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetVideoKey", reflect.TypeOf((*MockVideoRepo)(nil).GetVideoKey), ctx, videoID)
+}
+
+// GetVideoProbe mocks base method.
+func (m *MockVideoRepo) GetVideoProbe(ctx context.Context, videoID uuid.UUID) (db.VideoProbe, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetVideoProbe", ctx, videoID)
+	ret0, _ := ret[0].(db.VideoProbe)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetVideoProbe indicates an expected call of GetVideoProbe.
+func (mr *MockVideoRepoMockRecorder) GetVideoProbe(ctx, videoID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetVideoProbe", reflect.TypeOf((*MockVideoRepo)(nil).GetVideoProbe), ctx, videoID)
+}
+
+// GetVideoReport mocks base method.
+func (m *MockVideoRepo) GetVideoReport(ctx context.Context, id uuid.UUID) (db.VideoReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetVideoReport", ctx, id)
+	ret0, _ := ret[0].(db.VideoReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetVideoReport indicates an expected call of GetVideoReport.
+func (mr *MockVideoRepoMockRecorder) GetVideoReport(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetVideoReport", reflect.TypeOf((*MockVideoRepo)(nil).GetVideoReport), ctx, id)
+}
+
+// GetWatchPosition mocks base method.
+func (m *MockVideoRepo) GetWatchPosition(ctx context.Context, arg db.GetWatchPositionParams) (db.WatchHistory, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWatchPosition", ctx, arg)
+	ret0, _ := ret[0].(db.WatchHistory)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWatchPosition indicates an expected call of GetWatchPosition.
+func (mr *MockVideoRepoMockRecorder) GetWatchPosition(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWatchPosition", reflect.TypeOf((*MockVideoRepo)(nil).GetWatchPosition), ctx, arg)
+}
+
+// ListAllUserVideos mocks base method.
+func (m *MockVideoRepo) ListAllUserVideos(ctx context.Context, userID uuid.UUID) ([]db.Video, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAllUserVideos", ctx, userID)
+	ret0, _ := ret[0].([]db.Video)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAllUserVideos indicates an expected call of ListAllUserVideos.
+func (mr *MockVideoRepoMockRecorder) ListAllUserVideos(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAllUserVideos", reflect.TypeOf((*MockVideoRepo)(nil).ListAllUserVideos), ctx, userID)
+}
+
+// ListFeed mocks base method.
+func (m *MockVideoRepo) ListFeed(ctx context.Context, arg db.ListFeedParams) ([]db.ListFeedRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListFeed", ctx, arg)
+	ret0, _ := ret[0].([]db.ListFeedRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListFeed indicates an expected call of ListFeed.
+func (mr *MockVideoRepoMockRecorder) ListFeed(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListFeed", reflect.TypeOf((*MockVideoRepo)(nil).ListFeed), ctx, arg)
+}
+
+// ListMonthUsage mocks base method.
+func (m *MockVideoRepo) ListMonthUsage(ctx context.Context, month pgtype.Date) ([]db.UsageMonthly, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListMonthUsage", ctx, month)
+	ret0, _ := ret[0].([]db.UsageMonthly)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListMonthUsage indicates an expected call of ListMonthUsage.
+func (mr *MockVideoRepoMockRecorder) ListMonthUsage(ctx, month any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListMonthUsage", reflect.TypeOf((*MockVideoRepo)(nil).ListMonthUsage), ctx, month)
+}
+
+// ListNotifications mocks base method.
+func (m *MockVideoRepo) ListNotifications(ctx context.Context, arg db.ListNotificationsParams) ([]db.ListNotificationsRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListNotifications", ctx, arg)
+	ret0, _ := ret[0].([]db.ListNotificationsRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListNotifications indicates an expected call of ListNotifications.
+func (mr *MockVideoRepoMockRecorder) ListNotifications(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListNotifications", reflect.TypeOf((*MockVideoRepo)(nil).ListNotifications), ctx, arg)
+}
+
+// ListRenditionVersions mocks base method.
+func (m *MockVideoRepo) ListRenditionVersions(ctx context.Context, videoID uuid.UUID) ([]db.VideoRenditionVersion, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListRenditionVersions", ctx, videoID)
+	ret0, _ := ret[0].([]db.VideoRenditionVersion)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListRenditionVersions indicates an expected call of ListRenditionVersions.
+func (mr *MockVideoRepoMockRecorder) ListRenditionVersions(ctx, videoID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListRenditionVersions", reflect.TypeOf((*MockVideoRepo)(nil).ListRenditionVersions), ctx, videoID)
+}
+
+// ListReprocessCandidates mocks base method.
+func (m *MockVideoRepo) ListReprocessCandidates(ctx context.Context, arg db.ListReprocessCandidatesParams) ([]db.ListReprocessCandidatesRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListReprocessCandidates", ctx, arg)
+	ret0, _ := ret[0].([]db.ListReprocessCandidatesRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListReprocessCandidates indicates an expected call of ListReprocessCandidates.
+func (mr *MockVideoRepoMockRecorder) ListReprocessCandidates(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListReprocessCandidates", reflect.TypeOf((*MockVideoRepo)(nil).ListReprocessCandidates), ctx, arg)
+}
+
+// ListReprocessRuns mocks base method.
+func (m *MockVideoRepo) ListReprocessRuns(ctx context.Context) ([]db.ReprocessRun, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListReprocessRuns", ctx)
+	ret0, _ := ret[0].([]db.ReprocessRun)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListReprocessRuns indicates an expected call of ListReprocessRuns.
+func (mr *MockVideoRepoMockRecorder) ListReprocessRuns(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListReprocessRuns", reflect.TypeOf((*MockVideoRepo)(nil).ListReprocessRuns), ctx)
+}
+
+// ListSubscriberPreferences mocks base method.
+func (m *MockVideoRepo) ListSubscriberPreferences(ctx context.Context, creatorID uuid.UUID) ([]db.ListSubscriberPreferencesRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListSubscriberPreferences", ctx, creatorID)
+	ret0, _ := ret[0].([]db.ListSubscriberPreferencesRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListSubscriberPreferences indicates an expected call of ListSubscriberPreferences.
+func (mr *MockVideoRepoMockRecorder) ListSubscriberPreferences(ctx, creatorID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSubscriberPreferences", reflect.TypeOf((*MockVideoRepo)(nil).ListSubscriberPreferences), ctx, creatorID)
+}
+
+// ListSubscriptions mocks base method.
+func (m *MockVideoRepo) ListSubscriptions(ctx context.Context, subscriberID uuid.UUID) ([]db.ListSubscriptionsRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListSubscriptions", ctx, subscriberID)
+	ret0, _ := ret[0].([]db.ListSubscriptionsRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListSubscriptions indicates an expected call of ListSubscriptions.
+func (mr *MockVideoRepoMockRecorder) ListSubscriptions(ctx, subscriberID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSubscriptions", reflect.TypeOf((*MockVideoRepo)(nil).ListSubscriptions), ctx, subscriberID)
+}
+
+// ListTranscodingPresets mocks base method.
+func (m *MockVideoRepo) ListTranscodingPresets(ctx context.Context) ([]db.TranscodingPreset, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTranscodingPresets", ctx)
+	ret0, _ := ret[0].([]db.TranscodingPreset)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTranscodingPresets indicates an expected call of ListTranscodingPresets.
+func (mr *MockVideoRepoMockRecorder) ListTranscodingPresets(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTranscodingPresets", reflect.TypeOf((*MockVideoRepo)(nil).ListTranscodingPresets), ctx)
+}
+
+// ListTranslationsOfVideos mocks base method.
+func (m *MockVideoRepo) ListTranslationsOfVideos(ctx context.Context, videoIds []uuid.UUID) ([]db.VideoTranslation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTranslationsOfVideos", ctx, videoIds)
+	ret0, _ := ret[0].([]db.VideoTranslation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTranslationsOfVideos indicates an expected call of ListTranslationsOfVideos.
+func (mr *MockVideoRepoMockRecorder) ListTranslationsOfVideos(ctx, videoIds any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTranslationsOfVideos", reflect.TypeOf((*MockVideoRepo)(nil).ListTranslationsOfVideos), ctx, videoIds)
+}
+
+// ListUserIDs mocks base method.
+func (m *MockVideoRepo) ListUserIDs(ctx context.Context) ([]uuid.UUID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUserIDs", ctx)
+	ret0, _ := ret[0].([]uuid.UUID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListUserIDs indicates an expected call of ListUserIDs.
+func (mr *MockVideoRepoMockRecorder) ListUserIDs(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUserIDs", reflect.TypeOf((*MockVideoRepo)(nil).ListUserIDs), ctx)
+}
+
+// ListUserReports mocks base method.
+func (m *MockVideoRepo) ListUserReports(ctx context.Context, reporterID uuid.UUID) ([]db.VideoReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUserReports", ctx, reporterID)
+	ret0, _ := ret[0].([]db.VideoReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListUserReports indicates an expected call of ListUserReports.
+func (mr *MockVideoRepoMockRecorder) ListUserReports(ctx, reporterID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUserReports", reflect.TypeOf((*MockVideoRepo)(nil).ListUserReports), ctx, reporterID)
+}
+
+// ListUserUsage mocks base method.
+func (m *MockVideoRepo) ListUserUsage(ctx context.Context, arg db.ListUserUsageParams) ([]db.UsageMonthly, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUserUsage", ctx, arg)
+	ret0, _ := ret[0].([]db.UsageMonthly)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListUserUsage indicates an expected call of ListUserUsage.
+func (mr *MockVideoRepoMockRecorder) ListUserUsage(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUserUsage", reflect.TypeOf((*MockVideoRepo)(nil).ListUserUsage), ctx, arg)
+}
+
+// ListUserVideos mocks base method.
+func (m *MockVideoRepo) ListUserVideos(ctx context.Context, arg db.ListUserVideosParams) ([]db.ListUserVideosRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUserVideos", ctx, arg)
+	ret0, _ := ret[0].([]db.ListUserVideosRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListUserVideos indicates an expected call of ListUserVideos.
+func (mr *MockVideoRepoMockRecorder) ListUserVideos(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUserVideos", reflect.TypeOf((*MockVideoRepo)(nil).ListUserVideos), ctx, arg)
+}
+
+// ListVariantQualityReport mocks base method.
+func (m *MockVideoRepo) ListVariantQualityReport(ctx context.Context) ([]db.ListVariantQualityReportRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListVariantQualityReport", ctx)
+	ret0, _ := ret[0].([]db.ListVariantQualityReportRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListVariantQualityReport indicates an expected call of ListVariantQualityReport.
+func (mr *MockVideoRepoMockRecorder) ListVariantQualityReport(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListVariantQualityReport", reflect.TypeOf((*MockVideoRepo)(nil).ListVariantQualityReport), ctx)
+}
+
+// ListVariantThroughput mocks base method.
+func (m *MockVideoRepo) ListVariantThroughput(ctx context.Context) ([]db.ListVariantThroughputRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListVariantThroughput", ctx)
+	ret0, _ := ret[0].([]db.ListVariantThroughputRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListVariantThroughput indicates an expected call of ListVariantThroughput.
+func (mr *MockVideoRepoMockRecorder) ListVariantThroughput(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListVariantThroughput", reflect.TypeOf((*MockVideoRepo)(nil).ListVariantThroughput), ctx)
+}
+
+// ListVideoAssets mocks base method.
+func (m *MockVideoRepo) ListVideoAssets(ctx context.Context, videoID uuid.UUID) ([]db.VideoAsset, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListVideoAssets", ctx, videoID)
+	ret0, _ := ret[0].([]db.VideoAsset)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListVideoAssets indicates an expected call of ListVideoAssets.
+func (mr *MockVideoRepoMockRecorder) ListVideoAssets(ctx, videoID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListVideoAssets", reflect.TypeOf((*MockVideoRepo)(nil).ListVideoAssets), ctx, videoID)
+}
+
+// ListVideoChapters mocks base method.
+func (m *MockVideoRepo) ListVideoChapters(ctx context.Context, videoID uuid.UUID) ([]db.VideoChapter, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListVideoChapters", ctx, videoID)
+	ret0, _ := ret[0].([]db.VideoChapter)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListVideoChapters indicates an expected call of ListVideoChapters.
+func (mr *MockVideoRepoMockRecorder) ListVideoChapters(ctx, videoID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListVideoChapters", reflect.TypeOf((*MockVideoRepo)(nil).ListVideoChapters), ctx, videoID)
+}
+
+// ListVideoFingerprints mocks base method.
+func (m *MockVideoRepo) ListVideoFingerprints(ctx context.Context) ([]db.ListVideoFingerprintsRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListVideoFingerprints", ctx)
+	ret0, _ := ret[0].([]db.ListVideoFingerprintsRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListVideoFingerprints indicates an expected call of ListVideoFingerprints.
+func (mr *MockVideoRepoMockRecorder) ListVideoFingerprints(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListVideoFingerprints", reflect.TypeOf((*MockVideoRepo)(nil).ListVideoFingerprints), ctx)
+}
+
+// ListVideoProgress mocks base method.
+func (m *MockVideoRepo) ListVideoProgress(ctx context.Context, videoID uuid.UUID) ([]db.VideoProgress, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListVideoProgress", ctx, videoID)
+	ret0, _ := ret[0].([]db.VideoProgress)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListVideoProgress indicates an expected call of ListVideoProgress.
+func (mr *MockVideoRepoMockRecorder) ListVideoProgress(ctx, videoID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListVideoProgress", reflect.TypeOf((*MockVideoRepo)(nil).ListVideoProgress), ctx, videoID)
+}
+
+// ListVideoReports mocks base method.
+func (m *MockVideoRepo) ListVideoReports(ctx context.Context, arg db.ListVideoReportsParams) ([]db.ListVideoReportsRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListVideoReports", ctx, arg)
+	ret0, _ := ret[0].([]db.ListVideoReportsRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListVideoReports indicates an expected call of ListVideoReports.
+func (mr *MockVideoRepoMockRecorder) ListVideoReports(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListVideoReports", reflect.TypeOf((*MockVideoRepo)(nil).ListVideoReports), ctx, arg)
+}
+
+// ListVideoSubtitles mocks base method.
+func (m *MockVideoRepo) ListVideoSubtitles(ctx context.Context, videoID uuid.UUID) ([]db.VideoSubtitle, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListVideoSubtitles", ctx, videoID)
+	ret0, _ := ret[0].([]db.VideoSubtitle)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListVideoSubtitles indicates an expected call of ListVideoSubtitles.
+func (mr *MockVideoRepoMockRecorder) ListVideoSubtitles(ctx, videoID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListVideoSubtitles", reflect.TypeOf((*MockVideoRepo)(nil).ListVideoSubtitles), ctx, videoID)
+}
+
+// ListVideoThumbnails mocks base method.
+func (m *MockVideoRepo) ListVideoThumbnails(ctx context.Context, videoID uuid.UUID) ([]db.VideoThumbnail, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListVideoThumbnails", ctx, videoID)
+	ret0, _ := ret[0].([]db.VideoThumbnail)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListVideoThumbnails indicates an expected call of ListVideoThumbnails.
+func (mr *MockVideoRepoMockRecorder) ListVideoThumbnails(ctx, videoID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListVideoThumbnails", reflect.TypeOf((*MockVideoRepo)(nil).ListVideoThumbnails), ctx, videoID)
+}
+
+// ListVideoTranslations mocks base method.
+func (m *MockVideoRepo) ListVideoTranslations(ctx context.Context, videoID uuid.UUID) ([]db.VideoTranslation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListVideoTranslations", ctx, videoID)
+	ret0, _ := ret[0].([]db.VideoTranslation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListVideoTranslations indicates an expected call of ListVideoTranslations.
+func (mr *MockVideoRepoMockRecorder) ListVideoTranslations(ctx, videoID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListVideoTranslations", reflect.TypeOf((*MockVideoRepo)(nil).ListVideoTranslations), ctx, videoID)
+}
+
+// ListVideoVariants mocks base method.
+func (m *MockVideoRepo) ListVideoVariants(ctx context.Context, videoID uuid.UUID) ([]db.VideoVariant, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListVideoVariants", ctx, videoID)
+	ret0, _ := ret[0].([]db.VideoVariant)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListVideoVariants indicates an expected call of ListVideoVariants.
+func (mr *MockVideoRepoMockRecorder) ListVideoVariants(ctx, videoID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListVideoVariants", reflect.TypeOf((*MockVideoRepo)(nil).ListVideoVariants), ctx, videoID)
+}
+
+// ListWatchHistory mocks base method.
+func (m *MockVideoRepo) ListWatchHistory(ctx context.Context, arg db.ListWatchHistoryParams) ([]db.ListWatchHistoryRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListWatchHistory", ctx, arg)
+	ret0, _ := ret[0].([]db.ListWatchHistoryRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListWatchHistory indicates an expected call of ListWatchHistory.
+func (mr *MockVideoRepoMockRecorder) ListWatchHistory(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListWatchHistory", reflect.TypeOf((*MockVideoRepo)(nil).ListWatchHistory), ctx, arg)
+}
+
+// MarkNotificationsRead mocks base method.
+func (m *MockVideoRepo) MarkNotificationsRead(ctx context.Context, userID uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkNotificationsRead", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkNotificationsRead indicates an expected call of MarkNotificationsRead.
+func (mr *MockVideoRepoMockRecorder) MarkNotificationsRead(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkNotificationsRead", reflect.TypeOf((*MockVideoRepo)(nil).MarkNotificationsRead), ctx, userID)
+}
+
+// MarkUsageEmitted mocks base method.
+func (m *MockVideoRepo) MarkUsageEmitted(ctx context.Context, arg db.MarkUsageEmittedParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkUsageEmitted", ctx, arg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkUsageEmitted indicates an expected call of MarkUsageEmitted.
+func (mr *MockVideoRepoMockRecorder) MarkUsageEmitted(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkUsageEmitted", reflect.TypeOf((*MockVideoRepo)(nil).MarkUsageEmitted), ctx, arg)
+}
+
+// MoveUserObjects mocks base method.
+func (m *MockVideoRepo) MoveUserObjects(ctx context.Context, arg db.MoveUserObjectsParams) (db.MoveUserObjectsRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MoveUserObjects", ctx, arg)
+	ret0, _ := ret[0].(db.MoveUserObjectsRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MoveUserObjects indicates an expected call of MoveUserObjects.
+func (mr *MockVideoRepoMockRecorder) MoveUserObjects(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MoveUserObjects", reflect.TypeOf((*MockVideoRepo)(nil).MoveUserObjects), ctx, arg)
+}
+
+// PublishDueVideos mocks base method.
+func (m *MockVideoRepo) PublishDueVideos(ctx context.Context, limit int32) ([]db.PublishDueVideosRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PublishDueVideos", ctx, limit)
+	ret0, _ := ret[0].([]db.PublishDueVideosRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PublishDueVideos indicates an expected call of PublishDueVideos.
+func (mr *MockVideoRepoMockRecorder) PublishDueVideos(ctx, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PublishDueVideos", reflect.TypeOf((*MockVideoRepo)(nil).PublishDueVideos), ctx, limit)
+}
+
+// RecordPlaybackFailure mocks base method.
+func (m *MockVideoRepo) RecordPlaybackFailure(ctx context.Context, arg db.RecordPlaybackFailureParams) (int32, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordPlaybackFailure", ctx, arg)
+	ret0, _ := ret[0].(int32)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RecordPlaybackFailure indicates an expected call of RecordPlaybackFailure.
+func (mr *MockVideoRepoMockRecorder) RecordPlaybackFailure(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordPlaybackFailure", reflect.TypeOf((*MockVideoRepo)(nil).RecordPlaybackFailure), ctx, arg)
+}
+
+// RecordReprocessResult mocks base method.
+func (m *MockVideoRepo) RecordReprocessResult(ctx context.Context, arg db.RecordReprocessResultParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordReprocessResult", ctx, arg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecordReprocessResult indicates an expected call of RecordReprocessResult.
+func (mr *MockVideoRepoMockRecorder) RecordReprocessResult(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordReprocessResult", reflect.TypeOf((*MockVideoRepo)(nil).RecordReprocessResult), ctx, arg)
+}
+
+// RecordStorageHeld mocks base method.
+func (m *MockVideoRepo) RecordStorageHeld(ctx context.Context, userID uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordStorageHeld", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecordStorageHeld indicates an expected call of RecordStorageHeld.
+func (mr *MockVideoRepoMockRecorder) RecordStorageHeld(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordStorageHeld", reflect.TypeOf((*MockVideoRepo)(nil).RecordStorageHeld), ctx, userID)
+}
+
+// RecordUsage mocks base method.
+func (m *MockVideoRepo) RecordUsage(ctx context.Context, arg db.RecordUsageParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordUsage", ctx, arg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecordUsage indicates an expected call of RecordUsage.
+func (mr *MockVideoRepoMockRecorder) RecordUsage(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordUsage", reflect.TypeOf((*MockVideoRepo)(nil).RecordUsage), ctx, arg)
+}
+
+// ReleaseReprocessRun mocks base method.
+func (m *MockVideoRepo) ReleaseReprocessRun(ctx context.Context, arg db.ReleaseReprocessRunParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReleaseReprocessRun", ctx, arg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReleaseReprocessRun indicates an expected call of ReleaseReprocessRun.
+func (mr *MockVideoRepoMockRecorder) ReleaseReprocessRun(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleaseReprocessRun", reflect.TypeOf((*MockVideoRepo)(nil).ReleaseReprocessRun), ctx, arg)
+}
+
+// RenditionPrefixInUse mocks base method.
+func (m *MockVideoRepo) RenditionPrefixInUse(ctx context.Context, arg db.RenditionPrefixInUseParams) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RenditionPrefixInUse", ctx, arg)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RenditionPrefixInUse indicates an expected call of RenditionPrefixInUse.
+func (mr *MockVideoRepoMockRecorder) RenditionPrefixInUse(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RenditionPrefixInUse", reflect.TypeOf((*MockVideoRepo)(nil).RenditionPrefixInUse), ctx, arg)
+}
+
+// ResolveVideoReports mocks base method.
+func (m *MockVideoRepo) ResolveVideoReports(ctx context.Context, arg db.ResolveVideoReportsParams) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResolveVideoReports", ctx, arg)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ResolveVideoReports indicates an expected call of ResolveVideoReports.
+func (mr *MockVideoRepoMockRecorder) ResolveVideoReports(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResolveVideoReports", reflect.TypeOf((*MockVideoRepo)(nil).ResolveVideoReports), ctx, arg)
+}
+
+// RestoreRenditionVersion mocks base method.
+func (m *MockVideoRepo) RestoreRenditionVersion(ctx context.Context, id uuid.UUID) (db.RestoreRenditionVersionRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreRenditionVersion", ctx, id)
+	ret0, _ := ret[0].(db.RestoreRenditionVersionRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RestoreRenditionVersion indicates an expected call of RestoreRenditionVersion.
+func (mr *MockVideoRepoMockRecorder) RestoreRenditionVersion(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreRenditionVersion", reflect.TypeOf((*MockVideoRepo)(nil).RestoreRenditionVersion), ctx, id)
+}
+
+// SaveProcessedVideoMetadata mocks base method.
+func (m *MockVideoRepo) SaveProcessedVideoMetadata(ctx context.Context, arg db.SaveProcessedVideoMetadataParams) (db.VideoVariant, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveProcessedVideoMetadata", ctx, arg)
+	ret0, _ := ret[0].(db.VideoVariant)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SaveProcessedVideoMetadata indicates an expected call of SaveProcessedVideoMetadata.
+func (mr *MockVideoRepoMockRecorder) SaveProcessedVideoMetadata(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveProcessedVideoMetadata", reflect.TypeOf((*MockVideoRepo)(nil).SaveProcessedVideoMetadata), ctx, arg)
+}
+
+// SaveVideoAsset mocks base method.
+func (m *MockVideoRepo) SaveVideoAsset(ctx context.Context, arg db.SaveVideoAssetParams) (db.VideoAsset, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveVideoAsset", ctx, arg)
+	ret0, _ := ret[0].(db.VideoAsset)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SaveVideoAsset indicates an expected call of SaveVideoAsset.
+func (mr *MockVideoRepoMockRecorder) SaveVideoAsset(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveVideoAsset", reflect.TypeOf((*MockVideoRepo)(nil).SaveVideoAsset), ctx, arg)
+}
+
+// SaveVideoFingerprint mocks base method.
+func (m *MockVideoRepo) SaveVideoFingerprint(ctx context.Context, arg db.SaveVideoFingerprintParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveVideoFingerprint", ctx, arg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveVideoFingerprint indicates an expected call of SaveVideoFingerprint.
+func (mr *MockVideoRepoMockRecorder) SaveVideoFingerprint(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveVideoFingerprint", reflect.TypeOf((*MockVideoRepo)(nil).SaveVideoFingerprint), ctx, arg)
+}
+
+// SaveVideoProbe mocks base method.
+func (m *MockVideoRepo) SaveVideoProbe(ctx context.Context, arg db.SaveVideoProbeParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveVideoProbe", ctx, arg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveVideoProbe indicates an expected call of SaveVideoProbe.
+func (mr *MockVideoRepoMockRecorder) SaveVideoProbe(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveVideoProbe", reflect.TypeOf((*MockVideoRepo)(nil).SaveVideoProbe), ctx, arg)
+}
+
+// SaveVideoProgress mocks base method.
+func (m *MockVideoRepo) SaveVideoProgress(ctx context.Context, arg db.SaveVideoProgressParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveVideoProgress", ctx, arg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveVideoProgress indicates an expected call of SaveVideoProgress.
+func (mr *MockVideoRepoMockRecorder) SaveVideoProgress(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveVideoProgress", reflect.TypeOf((*MockVideoRepo)(nil).SaveVideoProgress), ctx, arg)
+}
+
+// SaveVideoSubtitle mocks base method.
+func (m *MockVideoRepo) SaveVideoSubtitle(ctx context.Context, arg db.SaveVideoSubtitleParams) (db.VideoSubtitle, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveVideoSubtitle", ctx, arg)
+	ret0, _ := ret[0].(db.VideoSubtitle)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SaveVideoSubtitle indicates an expected call of SaveVideoSubtitle.
+func (mr *MockVideoRepoMockRecorder) SaveVideoSubtitle(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveVideoSubtitle", reflect.TypeOf((*MockVideoRepo)(nil).SaveVideoSubtitle), ctx, arg)
+}
+
+// SaveVideoThumbnail mocks base method.
+func (m *MockVideoRepo) SaveVideoThumbnail(ctx context.Context, arg db.SaveVideoThumbnailParams) (db.VideoThumbnail, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveVideoThumbnail", ctx, arg)
+	ret0, _ := ret[0].(db.VideoThumbnail)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SaveVideoThumbnail indicates an expected call of SaveVideoThumbnail.
+func (mr *MockVideoRepoMockRecorder) SaveVideoThumbnail(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveVideoThumbnail", reflect.TypeOf((*MockVideoRepo)(nil).SaveVideoThumbnail), ctx, arg)
+}
+
+// SaveWatchPosition mocks base method.
+func (m *MockVideoRepo) SaveWatchPosition(ctx context.Context, arg db.SaveWatchPositionParams) (db.WatchHistory, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveWatchPosition", ctx, arg)
+	ret0, _ := ret[0].(db.WatchHistory)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SaveWatchPosition indicates an expected call of SaveWatchPosition.
+func (mr *MockVideoRepoMockRecorder) SaveWatchPosition(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveWatchPosition", reflect.TypeOf((*MockVideoRepo)(nil).SaveWatchPosition), ctx, arg)
+}
+
+// SetDefaultTranscodingPreset mocks base method.
+func (m *MockVideoRepo) SetDefaultTranscodingPreset(ctx context.Context, id uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetDefaultTranscodingPreset", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetDefaultTranscodingPreset indicates an expected call of SetDefaultTranscodingPreset.
+func (mr *MockVideoRepoMockRecorder) SetDefaultTranscodingPreset(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetDefaultTranscodingPreset", reflect.TypeOf((*MockVideoRepo)(nil).SetDefaultTranscodingPreset), ctx, id)
+}
+
+// SetNotificationPreferences mocks base method.
+func (m *MockVideoRepo) SetNotificationPreferences(ctx context.Context, arg db.SetNotificationPreferencesParams) (db.NotificationPreference, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetNotificationPreferences", ctx, arg)
+	ret0, _ := ret[0].(db.NotificationPreference)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetNotificationPreferences indicates an expected call of SetNotificationPreferences.
+func (mr *MockVideoRepoMockRecorder) SetNotificationPreferences(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetNotificationPreferences", reflect.TypeOf((*MockVideoRepo)(nil).SetNotificationPreferences), ctx, arg)
+}
+
+// SetPrimaryVideoThumbnail mocks base method.
+func (m *MockVideoRepo) SetPrimaryVideoThumbnail(ctx context.Context, arg db.SetPrimaryVideoThumbnailParams) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetPrimaryVideoThumbnail", ctx, arg)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetPrimaryVideoThumbnail indicates an expected call of SetPrimaryVideoThumbnail.
+func (mr *MockVideoRepoMockRecorder) SetPrimaryVideoThumbnail(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetPrimaryVideoThumbnail", reflect.TypeOf((*MockVideoRepo)(nil).SetPrimaryVideoThumbnail), ctx, arg)
+}
+
+// SetResultSize mocks base method.
+func (m *MockVideoRepo) SetResultSize(ctx context.Context, arg db.SetResultSizeParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetResultSize", ctx, arg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetResultSize indicates an expected call of SetResultSize.
+func (mr *MockVideoRepoMockRecorder) SetResultSize(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetResultSize", reflect.TypeOf((*MockVideoRepo)(nil).SetResultSize), ctx, arg)
+}
+
+// SetVideoAgeRestricted mocks base method.
+func (m *MockVideoRepo) SetVideoAgeRestricted(ctx context.Context, arg db.SetVideoAgeRestrictedParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetVideoAgeRestricted", ctx, arg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetVideoAgeRestricted indicates an expected call of SetVideoAgeRestricted.
+func (mr *MockVideoRepoMockRecorder) SetVideoAgeRestricted(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetVideoAgeRestricted", reflect.TypeOf((*MockVideoRepo)(nil).SetVideoAgeRestricted), ctx, arg)
+}
+
+// SetVideoMasterPlaylist mocks base method.
+func (m *MockVideoRepo) SetVideoMasterPlaylist(ctx context.Context, arg db.SetVideoMasterPlaylistParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetVideoMasterPlaylist", ctx, arg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetVideoMasterPlaylist indicates an expected call of SetVideoMasterPlaylist.
+func (mr *MockVideoRepoMockRecorder) SetVideoMasterPlaylist(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetVideoMasterPlaylist", reflect.TypeOf((*MockVideoRepo)(nil).SetVideoMasterPlaylist), ctx, arg)
+}
+
+// SetVideoPlaybackPassword mocks base method.
+func (m *MockVideoRepo) SetVideoPlaybackPassword(ctx context.Context, arg db.SetVideoPlaybackPasswordParams) (db.Video, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetVideoPlaybackPassword", ctx, arg)
+	ret0, _ := ret[0].(db.Video)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetVideoPlaybackPassword indicates an expected call of SetVideoPlaybackPassword.
+func (mr *MockVideoRepoMockRecorder) SetVideoPlaybackPassword(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetVideoPlaybackPassword", reflect.TypeOf((*MockVideoRepo)(nil).SetVideoPlaybackPassword), ctx, arg)
+}
+
+// SetVideoSchedule mocks base method.
+func (m *MockVideoRepo) SetVideoSchedule(ctx context.Context, arg db.SetVideoScheduleParams) (db.Video, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetVideoSchedule", ctx, arg)
+	ret0, _ := ret[0].(db.Video)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetVideoSchedule indicates an expected call of SetVideoSchedule.
+func (mr *MockVideoRepoMockRecorder) SetVideoSchedule(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetVideoSchedule", reflect.TypeOf((*MockVideoRepo)(nil).SetVideoSchedule), ctx, arg)
+}
+
+// SetVideoTranslation mocks base method.
+func (m *MockVideoRepo) SetVideoTranslation(ctx context.Context, arg db.SetVideoTranslationParams) (db.VideoTranslation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetVideoTranslation", ctx, arg)
+	ret0, _ := ret[0].(db.VideoTranslation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetVideoTranslation indicates an expected call of SetVideoTranslation.
+func (mr *MockVideoRepoMockRecorder) SetVideoTranslation(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetVideoTranslation", reflect.TypeOf((*MockVideoRepo)(nil).SetVideoTranslation), ctx, arg)
+}
+
+// SetVideoVisibility mocks base method.
+func (m *MockVideoRepo) SetVideoVisibility(ctx context.Context, arg db.SetVideoVisibilityParams) (db.Video, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetVideoVisibility", ctx, arg)
+	ret0, _ := ret[0].(db.Video)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetVideoVisibility indicates an expected call of SetVideoVisibility.
+func (mr *MockVideoRepoMockRecorder) SetVideoVisibility(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetVideoVisibility", reflect.TypeOf((*MockVideoRepo)(nil).SetVideoVisibility), ctx, arg)
+}
+
+// SnapshotStorageHeld mocks base method.
+func (m *MockVideoRepo) SnapshotStorageHeld(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SnapshotStorageHeld", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SnapshotStorageHeld indicates an expected call of SnapshotStorageHeld.
+func (mr *MockVideoRepoMockRecorder) SnapshotStorageHeld(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SnapshotStorageHeld", reflect.TypeOf((*MockVideoRepo)(nil).SnapshotStorageHeld), ctx)
+}
+
+// Subscribe mocks base method.
+func (m *MockVideoRepo) Subscribe(ctx context.Context, arg db.SubscribeParams) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Subscribe", ctx, arg)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Subscribe indicates an expected call of Subscribe.
+func (mr *MockVideoRepoMockRecorder) Subscribe(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Subscribe", reflect.TypeOf((*MockVideoRepo)(nil).Subscribe), ctx, arg)
+}
+
+// Unsubscribe mocks base method.
+func (m *MockVideoRepo) Unsubscribe(ctx context.Context, arg db.UnsubscribeParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Unsubscribe", ctx, arg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Unsubscribe indicates an expected call of Unsubscribe.
+func (mr *MockVideoRepoMockRecorder) Unsubscribe(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Unsubscribe", reflect.TypeOf((*MockVideoRepo)(nil).Unsubscribe), ctx, arg)
+}
+
+// UpdateTranscodingPreset mocks base method.
+func (m *MockVideoRepo) UpdateTranscodingPreset(ctx context.Context, arg db.UpdateTranscodingPresetParams) (db.TranscodingPreset, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateTranscodingPreset", ctx, arg)
+	ret0, _ := ret[0].(db.TranscodingPreset)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateTranscodingPreset indicates an expected call of UpdateTranscodingPreset.
+func (mr *MockVideoRepoMockRecorder) UpdateTranscodingPreset(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateTranscodingPreset", reflect.TypeOf((*MockVideoRepo)(nil).UpdateTranscodingPreset), ctx, arg)
+}
+
+// UpdateVariantQuality mocks base method.
+func (m *MockVideoRepo) UpdateVariantQuality(ctx context.Context, arg db.UpdateVariantQualityParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateVariantQuality", ctx, arg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateVariantQuality indicates an expected call of UpdateVariantQuality.
+func (mr *MockVideoRepoMockRecorder) UpdateVariantQuality(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateVariantQuality", reflect.TypeOf((*MockVideoRepo)(nil).UpdateVariantQuality), ctx, arg)
+}
+
+// UpdateVideoColorMetadata mocks base method.
+func (m *MockVideoRepo) UpdateVideoColorMetadata(ctx context.Context, arg db.UpdateVideoColorMetadataParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateVideoColorMetadata", ctx, arg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateVideoColorMetadata indicates an expected call of UpdateVideoColorMetadata.
+func (mr *MockVideoRepoMockRecorder) UpdateVideoColorMetadata(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateVideoColorMetadata", reflect.TypeOf((*MockVideoRepo)(nil).UpdateVideoColorMetadata), ctx, arg)
+}
+
+// UpdateVideoFileSize mocks base method.
+func (m *MockVideoRepo) UpdateVideoFileSize(ctx context.Context, arg db.UpdateVideoFileSizeParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateVideoFileSize", ctx, arg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateVideoFileSize indicates an expected call of UpdateVideoFileSize.
+func (mr *MockVideoRepoMockRecorder) UpdateVideoFileSize(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateVideoFileSize", reflect.TypeOf((*MockVideoRepo)(nil).UpdateVideoFileSize), ctx, arg)
+}
+
+// UpdateVideoProjection mocks base method.
+func (m *MockVideoRepo) UpdateVideoProjection(ctx context.Context, arg db.UpdateVideoProjectionParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateVideoProjection", ctx, arg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateVideoProjection indicates an expected call of UpdateVideoProjection.
+func (mr *MockVideoRepoMockRecorder) UpdateVideoProjection(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateVideoProjection", reflect.TypeOf((*MockVideoRepo)(nil).UpdateVideoProjection), ctx, arg)
+}
+
+// UpdateVideoSourceMetadata mocks base method.
+func (m *MockVideoRepo) UpdateVideoSourceMetadata(ctx context.Context, arg db.UpdateVideoSourceMetadataParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateVideoSourceMetadata", ctx, arg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateVideoSourceMetadata indicates an expected call of UpdateVideoSourceMetadata.
+func (mr *MockVideoRepoMockRecorder) UpdateVideoSourceMetadata(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateVideoSourceMetadata", reflect.TypeOf((*MockVideoRepo)(nil).UpdateVideoSourceMetadata), ctx, arg)
+}
+
+// UpdateVideoSourceResolution mocks base method.
+func (m *MockVideoRepo) UpdateVideoSourceResolution(ctx context.Context, arg db.UpdateVideoSourceResolutionParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateVideoSourceResolution", ctx, arg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateVideoSourceResolution indicates an expected call of UpdateVideoSourceResolution.
+func (mr *MockVideoRepoMockRecorder) UpdateVideoSourceResolution(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateVideoSourceResolution", reflect.TypeOf((*MockVideoRepo)(nil).UpdateVideoSourceResolution), ctx, arg)
+}
+
+// UpdateVideoStatus mocks base method.
+func (m *MockVideoRepo) UpdateVideoStatus(ctx context.Context, arg db.UpdateVideoStatusParams) (db.Video, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateVideoStatus", ctx, arg)
+	ret0, _ := ret[0].(db.Video)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateVideoStatus indicates an expected call of UpdateVideoStatus.
+func (mr *MockVideoRepoMockRecorder) UpdateVideoStatus(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateVideoStatus", reflect.TypeOf((*MockVideoRepo)(nil).UpdateVideoStatus), ctx, arg)
+}
+
+// MockStreamer is a mock of Streamer interface.
+type MockStreamer struct {
+	ctrl     *gomock.Controller
+	recorder *MockStreamerMockRecorder
+	isgomock struct{}
+}
+
+// MockStreamerMockRecorder is the mock recorder for MockStreamer.
+type MockStreamerMockRecorder struct {
+	mock *MockStreamer
+}
+
+// NewMockStreamer creates a new mock instance.
+func NewMockStreamer(ctrl *gomock.Controller) *MockStreamer {
+	mock := &MockStreamer{ctrl: ctrl}
+	mock.recorder = &MockStreamerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockStreamer) EXPECT() *MockStreamerMockRecorder {
+	return m.recorder
+}
+
+// Prioritize mocks base method.
+func (m *MockStreamer) Prioritize(ctx context.Context, videoID string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Prioritize", ctx, videoID)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Prioritize indicates an expected call of Prioritize.
+func (mr *MockStreamerMockRecorder) Prioritize(ctx, videoID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Prioritize", reflect.TypeOf((*MockStreamer)(nil).Prioritize), ctx, videoID)
+}
+
+// Stream mocks base method.
+func (m *MockStreamer) Stream(ctx context.Context, values map[string]any) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Stream", ctx, values)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Stream indicates an expected call of Stream.
+func (mr *MockStreamerMockRecorder) Stream(ctx, values any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Stream", reflect.TypeOf((*MockStreamer)(nil).Stream), ctx, values)
+}
+
+// MockVideoProcessor is a mock of VideoProcessor interface.
+type MockVideoProcessor struct {
+	ctrl     *gomock.Controller
+	recorder *MockVideoProcessorMockRecorder
+	isgomock struct{}
+}
+
+// MockVideoProcessorMockRecorder is the mock recorder for MockVideoProcessor.
+type MockVideoProcessorMockRecorder struct {
+	mock *MockVideoProcessor
+}
+
+// NewMockVideoProcessor creates a new mock instance.
+func NewMockVideoProcessor(ctrl *gomock.Controller) *MockVideoProcessor {
+	mock := &MockVideoProcessor{ctrl: ctrl}
+	mock.recorder = &MockVideoProcessorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockVideoProcessor) EXPECT() *MockVideoProcessorMockRecorder {
+	return m.recorder
+}
+
+// AuthorizePlayback mocks base method.
+func (m *MockVideoProcessor) AuthorizePlayback(ctx context.Context, videoID uuid.UUID, client string, req models.PlaybackRequest) (models.PlaybackToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AuthorizePlayback", ctx, videoID, client, req)
+	ret0, _ := ret[0].(models.PlaybackToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AuthorizePlayback indicates an expected call of AuthorizePlayback.
+func (mr *MockVideoProcessorMockRecorder) AuthorizePlayback(ctx, videoID, client, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AuthorizePlayback", reflect.TypeOf((*MockVideoProcessor)(nil).AuthorizePlayback), ctx, videoID, client, req)
+}
+
+// BoostJob mocks base method.
+func (m *MockVideoProcessor) BoostJob(ctx context.Context, videoID uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BoostJob", ctx, videoID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// BoostJob indicates an expected call of BoostJob.
+func (mr *MockVideoProcessorMockRecorder) BoostJob(ctx, videoID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BoostJob", reflect.TypeOf((*MockVideoProcessor)(nil).BoostJob), ctx, videoID)
+}
+
+// CancelReprocessRun mocks base method.
+func (m *MockVideoProcessor) CancelReprocessRun(ctx context.Context, id uuid.UUID) (models.ReprocessRun, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelReprocessRun", ctx, id)
+	ret0, _ := ret[0].(models.ReprocessRun)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CancelReprocessRun indicates an expected call of CancelReprocessRun.
+func (mr *MockVideoProcessorMockRecorder) CancelReprocessRun(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelReprocessRun", reflect.TypeOf((*MockVideoProcessor)(nil).CancelReprocessRun), ctx, id)
+}
+
+// ChangeSpeed mocks base method.
+func (m *MockVideoProcessor) ChangeSpeed(ctx context.Context, userID, videoID uuid.UUID, req models.SpeedRequest) (models.VideoDetail, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChangeSpeed", ctx, userID, videoID, req)
+	ret0, _ := ret[0].(models.VideoDetail)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChangeSpeed indicates an expected call of ChangeSpeed.
+func (mr *MockVideoProcessorMockRecorder) ChangeSpeed(ctx, userID, videoID, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChangeSpeed", reflect.TypeOf((*MockVideoProcessor)(nil).ChangeSpeed), ctx, userID, videoID, req)
+}
+
+// ClearHistory mocks base method.
+func (m *MockVideoProcessor) ClearHistory(ctx context.Context, userID uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClearHistory", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ClearHistory indicates an expected call of ClearHistory.
+func (mr *MockVideoProcessorMockRecorder) ClearHistory(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClearHistory", reflect.TypeOf((*MockVideoProcessor)(nil).ClearHistory), ctx, userID)
+}
+
+// ComposeOverlay mocks base method.
+func (m *MockVideoProcessor) ComposeOverlay(ctx context.Context, userID, videoID uuid.UUID, req models.OverlayRequest) (models.VideoDetail, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ComposeOverlay", ctx, userID, videoID, req)
+	ret0, _ := ret[0].(models.VideoDetail)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ComposeOverlay indicates an expected call of ComposeOverlay.
+func (mr *MockVideoProcessorMockRecorder) ComposeOverlay(ctx, userID, videoID, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ComposeOverlay", reflect.TypeOf((*MockVideoProcessor)(nil).ComposeOverlay), ctx, userID, videoID, req)
+}
+
+// CreateAudiogram mocks base method.
+func (m *MockVideoProcessor) CreateAudiogram(ctx context.Context, userID uuid.UUID, req models.AudiogramRequest) (models.VideoDetail, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAudiogram", ctx, userID, req)
+	ret0, _ := ret[0].(models.VideoDetail)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateAudiogram indicates an expected call of CreateAudiogram.
+func (mr *MockVideoProcessorMockRecorder) CreateAudiogram(ctx, userID, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAudiogram", reflect.TypeOf((*MockVideoProcessor)(nil).CreateAudiogram), ctx, userID, req)
+}
+
+// CreateBucket mocks base method.
+func (m *MockVideoProcessor) CreateBucket(ctx context.Context, bucketName string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateBucket", ctx, bucketName)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateBucket indicates an expected call of CreateBucket.
+func (mr *MockVideoProcessorMockRecorder) CreateBucket(ctx, bucketName any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateBucket", reflect.TypeOf((*MockVideoProcessor)(nil).CreateBucket), ctx, bucketName)
+}
+
+// CreateClip mocks base method.
+func (m *MockVideoProcessor) CreateClip(ctx context.Context, userID, videoID uuid.UUID, req models.ClipRequest) (models.VideoDetail, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateClip", ctx, userID, videoID, req)
+	ret0, _ := ret[0].(models.VideoDetail)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateClip indicates an expected call of CreateClip.
+func (mr *MockVideoProcessorMockRecorder) CreateClip(ctx, userID, videoID, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateClip", reflect.TypeOf((*MockVideoProcessor)(nil).CreateClip), ctx, userID, videoID, req)
+}
+
+// CreatePreset mocks base method.
+func (m *MockVideoProcessor) CreatePreset(ctx context.Context, req models.PresetRequest) (models.TranscodingPreset, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreatePreset", ctx, req)
+	ret0, _ := ret[0].(models.TranscodingPreset)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreatePreset indicates an expected call of CreatePreset.
+func (mr *MockVideoProcessorMockRecorder) CreatePreset(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePreset", reflect.TypeOf((*MockVideoProcessor)(nil).CreatePreset), ctx, req)
+}
+
+// CreateSlideshow mocks base method.
+func (m *MockVideoProcessor) CreateSlideshow(ctx context.Context, userID uuid.UUID, req models.SlideshowRequest) (models.VideoDetail, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateSlideshow", ctx, userID, req)
+	ret0, _ := ret[0].(models.VideoDetail)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateSlideshow indicates an expected call of CreateSlideshow.
+func (mr *MockVideoProcessorMockRecorder) CreateSlideshow(ctx, userID, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSlideshow", reflect.TypeOf((*MockVideoProcessor)(nil).CreateSlideshow), ctx, userID, req)
+}
+
+// DeleteBumper mocks base method.
+func (m *MockVideoProcessor) DeleteBumper(ctx context.Context, userID uuid.UUID, position string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteBumper", ctx, userID, position)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteBumper indicates an expected call of DeleteBumper.
+func (mr *MockVideoProcessorMockRecorder) DeleteBumper(ctx, userID, position any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteBumper", reflect.TypeOf((*MockVideoProcessor)(nil).DeleteBumper), ctx, userID, position)
+}
+
+// DeleteHistoryEntry mocks base method.
+func (m *MockVideoProcessor) DeleteHistoryEntry(ctx context.Context, userID, videoID uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteHistoryEntry", ctx, userID, videoID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteHistoryEntry indicates an expected call of DeleteHistoryEntry.
+func (mr *MockVideoProcessorMockRecorder) DeleteHistoryEntry(ctx, userID, videoID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteHistoryEntry", reflect.TypeOf((*MockVideoProcessor)(nil).DeleteHistoryEntry), ctx, userID, videoID)
+}
+
+// DeletePreset mocks base method.
+func (m *MockVideoProcessor) DeletePreset(ctx context.Context, id uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeletePreset", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeletePreset indicates an expected call of DeletePreset.
+func (mr *MockVideoProcessorMockRecorder) DeletePreset(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePreset", reflect.TypeOf((*MockVideoProcessor)(nil).DeletePreset), ctx, id)
+}
+
+// DeleteTranslation mocks base method.
+func (m *MockVideoProcessor) DeleteTranslation(ctx context.Context, userID, videoID uuid.UUID, lang string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteTranslation", ctx, userID, videoID, lang)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteTranslation indicates an expected call of DeleteTranslation.
+func (mr *MockVideoProcessorMockRecorder) DeleteTranslation(ctx, userID, videoID, lang any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteTranslation", reflect.TypeOf((*MockVideoProcessor)(nil).DeleteTranslation), ctx, userID, videoID, lang)
+}
+
+// DeleteWatermark mocks base method.
+func (m *MockVideoProcessor) DeleteWatermark(ctx context.Context, userID uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteWatermark", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteWatermark indicates an expected call of DeleteWatermark.
+func (mr *MockVideoProcessorMockRecorder) DeleteWatermark(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteWatermark", reflect.TypeOf((*MockVideoProcessor)(nil).DeleteWatermark), ctx, userID)
+}
+
+// Download mocks base method.
+func (m *MockVideoProcessor) Download(ctx context.Context, userID, videoID uuid.UUID, variant, format string) (models.Download, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Download", ctx, userID, videoID, variant, format)
+	ret0, _ := ret[0].(models.Download)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Download indicates an expected call of Download.
+func (mr *MockVideoProcessorMockRecorder) Download(ctx, userID, videoID, variant, format any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Download", reflect.TypeOf((*MockVideoProcessor)(nil).Download), ctx, userID, videoID, variant, format)
+}
+
+// EditVideo mocks base method.
+func (m *MockVideoProcessor) EditVideo(ctx context.Context, userID, videoID uuid.UUID, req models.EditRequest) (models.VideoDetail, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EditVideo", ctx, userID, videoID, req)
+	ret0, _ := ret[0].(models.VideoDetail)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EditVideo indicates an expected call of EditVideo.
+func (mr *MockVideoProcessorMockRecorder) EditVideo(ctx, userID, videoID, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EditVideo", reflect.TypeOf((*MockVideoProcessor)(nil).EditVideo), ctx, userID, videoID, req)
+}
+
+// ExportData mocks base method.
+func (m *MockVideoProcessor) ExportData(ctx context.Context, userID uuid.UUID) (models.DataExport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExportData", ctx, userID)
+	ret0, _ := ret[0].(models.DataExport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExportData indicates an expected call of ExportData.
+func (mr *MockVideoProcessorMockRecorder) ExportData(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportData", reflect.TypeOf((*MockVideoProcessor)(nil).ExportData), ctx, userID)
+}
+
+// ExportUsage mocks base method.
+func (m *MockVideoProcessor) ExportUsage(ctx context.Context, month string) ([]models.UsageMonth, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExportUsage", ctx, month)
+	ret0, _ := ret[0].([]models.UsageMonth)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExportUsage indicates an expected call of ExportUsage.
+func (mr *MockVideoProcessorMockRecorder) ExportUsage(ctx, month any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportUsage", reflect.TypeOf((*MockVideoProcessor)(nil).ExportUsage), ctx, month)
+}
+
+// ExtractFrame mocks base method.
+func (m *MockVideoProcessor) ExtractFrame(ctx context.Context, userID, videoID uuid.UUID, query models.FrameQuery) (models.VideoFrame, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExtractFrame", ctx, userID, videoID, query)
+	ret0, _ := ret[0].(models.VideoFrame)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExtractFrame indicates an expected call of ExtractFrame.
+func (mr *MockVideoProcessorMockRecorder) ExtractFrame(ctx, userID, videoID, query any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExtractFrame", reflect.TypeOf((*MockVideoProcessor)(nil).ExtractFrame), ctx, userID, videoID, query)
+}
+
+// Feed mocks base method.
+func (m *MockVideoProcessor) Feed(ctx context.Context, userID uuid.UUID, query models.ListVideosQuery) ([]models.FeedItem, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Feed", ctx, userID, query)
+	ret0, _ := ret[0].([]models.FeedItem)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Feed indicates an expected call of Feed.
+func (mr *MockVideoProcessorMockRecorder) Feed(ctx, userID, query any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Feed", reflect.TypeOf((*MockVideoProcessor)(nil).Feed), ctx, userID, query)
+}
+
+// FindDuplicates mocks base method.
+func (m *MockVideoProcessor) FindDuplicates(ctx context.Context, query models.DuplicateReportQuery) ([]models.DuplicateMatch, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindDuplicates", ctx, query)
+	ret0, _ := ret[0].([]models.DuplicateMatch)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindDuplicates indicates an expected call of FindDuplicates.
+func (mr *MockVideoProcessorMockRecorder) FindDuplicates(ctx, query any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindDuplicates", reflect.TypeOf((*MockVideoProcessor)(nil).FindDuplicates), ctx, query)
+}
+
+// GetChapters mocks base method.
+func (m *MockVideoProcessor) GetChapters(ctx context.Context, userID, videoID uuid.UUID) ([]models.Chapter, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetChapters", ctx, userID, videoID)
+	ret0, _ := ret[0].([]models.Chapter)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetChapters indicates an expected call of GetChapters.
+func (mr *MockVideoProcessorMockRecorder) GetChapters(ctx, userID, videoID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetChapters", reflect.TypeOf((*MockVideoProcessor)(nil).GetChapters), ctx, userID, videoID)
+}
+
+// GetDataExport mocks base method.
+func (m *MockVideoProcessor) GetDataExport(ctx context.Context, userID, exportID uuid.UUID) (models.DataExport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDataExport", ctx, userID, exportID)
+	ret0, _ := ret[0].(models.DataExport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDataExport indicates an expected call of GetDataExport.
+func (mr *MockVideoProcessorMockRecorder) GetDataExport(ctx, userID, exportID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDataExport", reflect.TypeOf((*MockVideoProcessor)(nil).GetDataExport), ctx, userID, exportID)
+}
+
+// GetNotificationPreferences mocks base method.
+func (m *MockVideoProcessor) GetNotificationPreferences(ctx context.Context, userID uuid.UUID) (models.NotificationPreferences, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetNotificationPreferences", ctx, userID)
+	ret0, _ := ret[0].(models.NotificationPreferences)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetNotificationPreferences indicates an expected call of GetNotificationPreferences.
+func (mr *MockVideoProcessorMockRecorder) GetNotificationPreferences(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNotificationPreferences", reflect.TypeOf((*MockVideoProcessor)(nil).GetNotificationPreferences), ctx, userID)
+}
+
+// GetPosition mocks base method.
+func (m *MockVideoProcessor) GetPosition(ctx context.Context, userID, videoID uuid.UUID) (models.WatchPosition, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPosition", ctx, userID, videoID)
+	ret0, _ := ret[0].(models.WatchPosition)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPosition indicates an expected call of GetPosition.
+func (mr *MockVideoProcessorMockRecorder) GetPosition(ctx, userID, videoID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPosition", reflect.TypeOf((*MockVideoProcessor)(nil).GetPosition), ctx, userID, videoID)
+}
+
+// GetPreset mocks base method.
+func (m *MockVideoProcessor) GetPreset(ctx context.Context, id uuid.UUID) (models.TranscodingPreset, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPreset", ctx, id)
+	ret0, _ := ret[0].(models.TranscodingPreset)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPreset indicates an expected call of GetPreset.
+func (mr *MockVideoProcessorMockRecorder) GetPreset(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPreset", reflect.TypeOf((*MockVideoProcessor)(nil).GetPreset), ctx, id)
+}
+
+// GetProgress mocks base method.
+func (m *MockVideoProcessor) GetProgress(ctx context.Context, userID, videoID uuid.UUID) (models.JobStatus, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetProgress", ctx, userID, videoID)
+	ret0, _ := ret[0].(models.JobStatus)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetProgress indicates an expected call of GetProgress.
+func (mr *MockVideoProcessorMockRecorder) GetProgress(ctx, userID, videoID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProgress", reflect.TypeOf((*MockVideoProcessor)(nil).GetProgress), ctx, userID, videoID)
+}
+
+// GetReprocessRun mocks base method.
+func (m *MockVideoProcessor) GetReprocessRun(ctx context.Context, id uuid.UUID) (models.ReprocessRun, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetReprocessRun", ctx, id)
+	ret0, _ := ret[0].(models.ReprocessRun)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetReprocessRun indicates an expected call of GetReprocessRun.
+func (mr *MockVideoProcessorMockRecorder) GetReprocessRun(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetReprocessRun", reflect.TypeOf((*MockVideoProcessor)(nil).GetReprocessRun), ctx, id)
+}
+
+// GetUsage mocks base method.
+func (m *MockVideoProcessor) GetUsage(ctx context.Context, userID uuid.UUID, query models.UsageQuery) ([]models.UsageMonth, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUsage", ctx, userID, query)
+	ret0, _ := ret[0].([]models.UsageMonth)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUsage indicates an expected call of GetUsage.
+func (mr *MockVideoProcessorMockRecorder) GetUsage(ctx, userID, query any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUsage", reflect.TypeOf((*MockVideoProcessor)(nil).GetUsage), ctx, userID, query)
+}
+
+// GetVideo mocks base method.
+func (m *MockVideoProcessor) GetVideo(ctx context.Context, userID, videoID uuid.UUID, languages []string) (models.VideoDetail, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetVideo", ctx, userID, videoID, languages)
+	ret0, _ := ret[0].(models.VideoDetail)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetVideo indicates an expected call of GetVideo.
+func (mr *MockVideoProcessorMockRecorder) GetVideo(ctx, userID, videoID, languages any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetVideo", reflect.TypeOf((*MockVideoProcessor)(nil).GetVideo), ctx, userID, videoID, languages)
+}
+
+// GetVideoKey mocks base method.
+func (m *MockVideoProcessor) GetVideoKey(ctx context.Context, userID, videoID uuid.UUID, token string) ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetVideoKey", ctx, userID, videoID, token)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetVideoKey indicates an expected call of GetVideoKey.
+func (mr *MockVideoProcessorMockRecorder) GetVideoKey(ctx, userID, videoID, token any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetVideoKey", reflect.TypeOf((*MockVideoProcessor)(nil).GetVideoKey), ctx, userID, videoID, token)
+}
+
+// Ingest mocks base method.
+func (m *MockVideoProcessor) Ingest(ctx context.Context, authToken string, event models.S3Event) (models.IngestResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Ingest", ctx, authToken, event)
+	ret0, _ := ret[0].(models.IngestResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Ingest indicates an expected call of Ingest.
+func (mr *MockVideoProcessorMockRecorder) Ingest(ctx, authToken, event any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Ingest", reflect.TypeOf((*MockVideoProcessor)(nil).Ingest), ctx, authToken, event)
+}
+
+// ListBuckets mocks base method.
+func (m *MockVideoProcessor) ListBuckets(ctx context.Context) ([]minio.BucketInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListBuckets", ctx)
+	ret0, _ := ret[0].([]minio.BucketInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListBuckets indicates an expected call of ListBuckets.
+func (mr *MockVideoProcessorMockRecorder) ListBuckets(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListBuckets", reflect.TypeOf((*MockVideoProcessor)(nil).ListBuckets), ctx)
+}
+
+// ListHistory mocks base method.
+func (m *MockVideoProcessor) ListHistory(ctx context.Context, userID uuid.UUID, query models.ListVideosQuery) ([]models.WatchHistoryEntry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListHistory", ctx, userID, query)
+	ret0, _ := ret[0].([]models.WatchHistoryEntry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListHistory indicates an expected call of ListHistory.
+func (mr *MockVideoProcessorMockRecorder) ListHistory(ctx, userID, query any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListHistory", reflect.TypeOf((*MockVideoProcessor)(nil).ListHistory), ctx, userID, query)
+}
+
+// ListNotifications mocks base method.
+func (m *MockVideoProcessor) ListNotifications(ctx context.Context, userID uuid.UUID, query models.ListVideosQuery) ([]models.Notification, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListNotifications", ctx, userID, query)
+	ret0, _ := ret[0].([]models.Notification)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListNotifications indicates an expected call of ListNotifications.
+func (mr *MockVideoProcessorMockRecorder) ListNotifications(ctx, userID, query any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListNotifications", reflect.TypeOf((*MockVideoProcessor)(nil).ListNotifications), ctx, userID, query)
+}
+
+// ListPresets mocks base method.
+func (m *MockVideoProcessor) ListPresets(ctx context.Context) ([]models.TranscodingPreset, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPresets", ctx)
+	ret0, _ := ret[0].([]models.TranscodingPreset)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListPresets indicates an expected call of ListPresets.
+func (mr *MockVideoProcessorMockRecorder) ListPresets(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPresets", reflect.TypeOf((*MockVideoProcessor)(nil).ListPresets), ctx)
+}
+
+// ListRenditionVersions mocks base method.
+func (m *MockVideoProcessor) ListRenditionVersions(ctx context.Context, userID, videoID uuid.UUID) ([]models.RenditionVersion, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListRenditionVersions", ctx, userID, videoID)
+	ret0, _ := ret[0].([]models.RenditionVersion)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListRenditionVersions indicates an expected call of ListRenditionVersions.
+func (mr *MockVideoProcessorMockRecorder) ListRenditionVersions(ctx, userID, videoID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListRenditionVersions", reflect.TypeOf((*MockVideoProcessor)(nil).ListRenditionVersions), ctx, userID, videoID)
+}
+
+// ListReports mocks base method.
+func (m *MockVideoProcessor) ListReports(ctx context.Context, query models.ReportQuery) ([]models.VideoReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListReports", ctx, query)
+	ret0, _ := ret[0].([]models.VideoReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListReports indicates an expected call of ListReports.
+func (mr *MockVideoProcessorMockRecorder) ListReports(ctx, query any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListReports", reflect.TypeOf((*MockVideoProcessor)(nil).ListReports), ctx, query)
+}
+
+// ListReprocessRuns mocks base method.
+func (m *MockVideoProcessor) ListReprocessRuns(ctx context.Context) ([]models.ReprocessRun, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListReprocessRuns", ctx)
+	ret0, _ := ret[0].([]models.ReprocessRun)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListReprocessRuns indicates an expected call of ListReprocessRuns.
+func (mr *MockVideoProcessorMockRecorder) ListReprocessRuns(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListReprocessRuns", reflect.TypeOf((*MockVideoProcessor)(nil).ListReprocessRuns), ctx)
+}
+
+// ListSubscriptions mocks base method.
+func (m *MockVideoProcessor) ListSubscriptions(ctx context.Context, userID uuid.UUID) ([]models.Subscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListSubscriptions", ctx, userID)
+	ret0, _ := ret[0].([]models.Subscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListSubscriptions indicates an expected call of ListSubscriptions.
+func (mr *MockVideoProcessorMockRecorder) ListSubscriptions(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSubscriptions", reflect.TypeOf((*MockVideoProcessor)(nil).ListSubscriptions), ctx, userID)
+}
+
+// ListTranslations mocks base method.
+func (m *MockVideoProcessor) ListTranslations(ctx context.Context, userID, videoID uuid.UUID) ([]models.VideoTranslation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTranslations", ctx, userID, videoID)
+	ret0, _ := ret[0].([]models.VideoTranslation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTranslations indicates an expected call of ListTranslations.
+func (mr *MockVideoProcessorMockRecorder) ListTranslations(ctx, userID, videoID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTranslations", reflect.TypeOf((*MockVideoProcessor)(nil).ListTranslations), ctx, userID, videoID)
+}
+
+// ListVideos mocks base method.
+func (m *MockVideoProcessor) ListVideos(ctx context.Context, userID uuid.UUID, query models.ListVideosQuery) ([]models.VideoSummary, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListVideos", ctx, userID, query)
+	ret0, _ := ret[0].([]models.VideoSummary)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListVideos indicates an expected call of ListVideos.
+func (mr *MockVideoProcessorMockRecorder) ListVideos(ctx, userID, query any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListVideos", reflect.TypeOf((*MockVideoProcessor)(nil).ListVideos), ctx, userID, query)
+}
+
+// ListenIngest mocks base method.
+func (m *MockVideoProcessor) ListenIngest(ctx context.Context, queue *redis.Client, key string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListenIngest", ctx, queue, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ListenIngest indicates an expected call of ListenIngest.
+func (mr *MockVideoProcessorMockRecorder) ListenIngest(ctx, queue, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListenIngest", reflect.TypeOf((*MockVideoProcessor)(nil).ListenIngest), ctx, queue, key)
+}
+
+// MarkNotificationsRead mocks base method.
+func (m *MockVideoProcessor) MarkNotificationsRead(ctx context.Context, userID uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkNotificationsRead", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkNotificationsRead indicates an expected call of MarkNotificationsRead.
+func (mr *MockVideoProcessorMockRecorder) MarkNotificationsRead(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkNotificationsRead", reflect.TypeOf((*MockVideoProcessor)(nil).MarkNotificationsRead), ctx, userID)
+}
+
+// ModerateReport mocks base method.
+func (m *MockVideoProcessor) ModerateReport(ctx context.Context, moderatorID, reportID uuid.UUID, req models.ModerationRequest) (models.VideoReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ModerateReport", ctx, moderatorID, reportID, req)
+	ret0, _ := ret[0].(models.VideoReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ModerateReport indicates an expected call of ModerateReport.
+func (mr *MockVideoProcessorMockRecorder) ModerateReport(ctx, moderatorID, reportID, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ModerateReport", reflect.TypeOf((*MockVideoProcessor)(nil).ModerateReport), ctx, moderatorID, reportID, req)
+}
+
+// Playback mocks base method.
+func (m *MockVideoProcessor) Playback(ctx context.Context, videoID uuid.UUID, token string) (models.Playback, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Playback", ctx, videoID, token)
+	ret0, _ := ret[0].(models.Playback)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Playback indicates an expected call of Playback.
+func (mr *MockVideoProcessorMockRecorder) Playback(ctx, videoID, token any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Playback", reflect.TypeOf((*MockVideoProcessor)(nil).Playback), ctx, videoID, token)
+}
+
+// ProbeVideo mocks base method.
+func (m *MockVideoProcessor) ProbeVideo(ctx context.Context, userID, videoID uuid.UUID, refresh bool) (models.ProbeReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ProbeVideo", ctx, userID, videoID, refresh)
+	ret0, _ := ret[0].(models.ProbeReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ProbeVideo indicates an expected call of ProbeVideo.
+func (mr *MockVideoProcessorMockRecorder) ProbeVideo(ctx, userID, videoID, refresh any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ProbeVideo", reflect.TypeOf((*MockVideoProcessor)(nil).ProbeVideo), ctx, userID, videoID, refresh)
+}
+
+// QualityReport mocks base method.
+func (m *MockVideoProcessor) QualityReport(ctx context.Context) ([]models.VariantQuality, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "QualityReport", ctx)
+	ret0, _ := ret[0].([]models.VariantQuality)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// QualityReport indicates an expected call of QualityReport.
+func (mr *MockVideoProcessorMockRecorder) QualityReport(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QualityReport", reflect.TypeOf((*MockVideoProcessor)(nil).QualityReport), ctx)
+}
+
+// RemovePlaybackPassword mocks base method.
+func (m *MockVideoProcessor) RemovePlaybackPassword(ctx context.Context, userID, videoID uuid.UUID) (models.VideoDetail, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemovePlaybackPassword", ctx, userID, videoID)
+	ret0, _ := ret[0].(models.VideoDetail)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RemovePlaybackPassword indicates an expected call of RemovePlaybackPassword.
+func (mr *MockVideoProcessorMockRecorder) RemovePlaybackPassword(ctx, userID, videoID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemovePlaybackPassword", reflect.TypeOf((*MockVideoProcessor)(nil).RemovePlaybackPassword), ctx, userID, videoID)
+}
+
+// ReportVideo mocks base method.
+func (m *MockVideoProcessor) ReportVideo(ctx context.Context, userID, videoID uuid.UUID, req models.ReportRequest) (models.VideoReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReportVideo", ctx, userID, videoID, req)
+	ret0, _ := ret[0].(models.VideoReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReportVideo indicates an expected call of ReportVideo.
+func (mr *MockVideoProcessorMockRecorder) ReportVideo(ctx, userID, videoID, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReportVideo", reflect.TypeOf((*MockVideoProcessor)(nil).ReportVideo), ctx, userID, videoID, req)
+}
+
+// RollbackRenditions mocks base method.
+func (m *MockVideoProcessor) RollbackRenditions(ctx context.Context, userID, videoID uuid.UUID, version int32) (models.VideoDetail, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RollbackRenditions", ctx, userID, videoID, version)
+	ret0, _ := ret[0].(models.VideoDetail)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RollbackRenditions indicates an expected call of RollbackRenditions.
+func (mr *MockVideoProcessorMockRecorder) RollbackRenditions(ctx, userID, videoID, version any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RollbackRenditions", reflect.TypeOf((*MockVideoProcessor)(nil).RollbackRenditions), ctx, userID, videoID, version)
+}
+
+// RunExports mocks base method.
+func (m *MockVideoProcessor) RunExports(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RunExports", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RunExports indicates an expected call of RunExports.
+func (mr *MockVideoProcessorMockRecorder) RunExports(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RunExports", reflect.TypeOf((*MockVideoProcessor)(nil).RunExports), ctx)
+}
+
+// RunReprocessing mocks base method.
+func (m *MockVideoProcessor) RunReprocessing(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RunReprocessing", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RunReprocessing indicates an expected call of RunReprocessing.
+func (mr *MockVideoProcessorMockRecorder) RunReprocessing(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RunReprocessing", reflect.TypeOf((*MockVideoProcessor)(nil).RunReprocessing), ctx)
+}
+
+// RunScheduler mocks base method.
+func (m *MockVideoProcessor) RunScheduler(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RunScheduler", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RunScheduler indicates an expected call of RunScheduler.
+func (mr *MockVideoProcessorMockRecorder) RunScheduler(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RunScheduler", reflect.TypeOf((*MockVideoProcessor)(nil).RunScheduler), ctx)
+}
+
+// RunUsageEmitter mocks base method.
+func (m *MockVideoProcessor) RunUsageEmitter(ctx context.Context, config models.BillingConfig) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RunUsageEmitter", ctx, config)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RunUsageEmitter indicates an expected call of RunUsageEmitter.
+func (mr *MockVideoProcessorMockRecorder) RunUsageEmitter(ctx, config any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RunUsageEmitter", reflect.TypeOf((*MockVideoProcessor)(nil).RunUsageEmitter), ctx, config)
+}
+
+// RunVersionCleanup mocks base method.
+func (m *MockVideoProcessor) RunVersionCleanup(ctx context.Context, retention time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RunVersionCleanup", ctx, retention)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RunVersionCleanup indicates an expected call of RunVersionCleanup.
+func (mr *MockVideoProcessorMockRecorder) RunVersionCleanup(ctx, retention any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RunVersionCleanup", reflect.TypeOf((*MockVideoProcessor)(nil).RunVersionCleanup), ctx, retention)
+}
+
+// SavePosition mocks base method.
+func (m *MockVideoProcessor) SavePosition(ctx context.Context, userID, videoID uuid.UUID, req models.WatchPositionRequest) (models.WatchPosition, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SavePosition", ctx, userID, videoID, req)
+	ret0, _ := ret[0].(models.WatchPosition)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SavePosition indicates an expected call of SavePosition.
+func (mr *MockVideoProcessorMockRecorder) SavePosition(ctx, userID, videoID, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SavePosition", reflect.TypeOf((*MockVideoProcessor)(nil).SavePosition), ctx, userID, videoID, req)
+}
+
+// SetBumper mocks base method.
+func (m *MockVideoProcessor) SetBumper(ctx context.Context, userID uuid.UUID, req models.BumperRequest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetBumper", ctx, userID, req)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetBumper indicates an expected call of SetBumper.
+func (mr *MockVideoProcessorMockRecorder) SetBumper(ctx, userID, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetBumper", reflect.TypeOf((*MockVideoProcessor)(nil).SetBumper), ctx, userID, req)
+}
+
+// SetChapters mocks base method.
+func (m *MockVideoProcessor) SetChapters(ctx context.Context, userID, videoID uuid.UUID, req models.SetChaptersRequest) ([]models.Chapter, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetChapters", ctx, userID, videoID, req)
+	ret0, _ := ret[0].([]models.Chapter)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetChapters indicates an expected call of SetChapters.
+func (mr *MockVideoProcessorMockRecorder) SetChapters(ctx, userID, videoID, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetChapters", reflect.TypeOf((*MockVideoProcessor)(nil).SetChapters), ctx, userID, videoID, req)
+}
+
+// SetNotificationPreferences mocks base method.
+func (m *MockVideoProcessor) SetNotificationPreferences(ctx context.Context, userID uuid.UUID, prefs models.NotificationPreferences) (models.NotificationPreferences, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetNotificationPreferences", ctx, userID, prefs)
+	ret0, _ := ret[0].(models.NotificationPreferences)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetNotificationPreferences indicates an expected call of SetNotificationPreferences.
+func (mr *MockVideoProcessorMockRecorder) SetNotificationPreferences(ctx, userID, prefs any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetNotificationPreferences", reflect.TypeOf((*MockVideoProcessor)(nil).SetNotificationPreferences), ctx, userID, prefs)
+}
+
+// SetPlaybackPassword mocks base method.
+func (m *MockVideoProcessor) SetPlaybackPassword(ctx context.Context, userID, videoID uuid.UUID, req models.PlaybackPasswordRequest) (models.VideoDetail, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetPlaybackPassword", ctx, userID, videoID, req)
+	ret0, _ := ret[0].(models.VideoDetail)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetPlaybackPassword indicates an expected call of SetPlaybackPassword.
+func (mr *MockVideoProcessorMockRecorder) SetPlaybackPassword(ctx, userID, videoID, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetPlaybackPassword", reflect.TypeOf((*MockVideoProcessor)(nil).SetPlaybackPassword), ctx, userID, videoID, req)
+}
+
+// SetPrimaryThumbnail mocks base method.
+func (m *MockVideoProcessor) SetPrimaryThumbnail(ctx context.Context, userID, videoID uuid.UUID, req models.PrimaryThumbnailRequest) (models.VideoDetail, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetPrimaryThumbnail", ctx, userID, videoID, req)
+	ret0, _ := ret[0].(models.VideoDetail)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetPrimaryThumbnail indicates an expected call of SetPrimaryThumbnail.
+func (mr *MockVideoProcessorMockRecorder) SetPrimaryThumbnail(ctx, userID, videoID, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetPrimaryThumbnail", reflect.TypeOf((*MockVideoProcessor)(nil).SetPrimaryThumbnail), ctx, userID, videoID, req)
+}
+
+// SetSchedule mocks base method.
+func (m *MockVideoProcessor) SetSchedule(ctx context.Context, userID, videoID uuid.UUID, req models.ScheduleRequest) (models.VideoDetail, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetSchedule", ctx, userID, videoID, req)
+	ret0, _ := ret[0].(models.VideoDetail)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetSchedule indicates an expected call of SetSchedule.
+func (mr *MockVideoProcessorMockRecorder) SetSchedule(ctx, userID, videoID, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetSchedule", reflect.TypeOf((*MockVideoProcessor)(nil).SetSchedule), ctx, userID, videoID, req)
+}
+
+// SetTranslation mocks base method.
+func (m *MockVideoProcessor) SetTranslation(ctx context.Context, userID, videoID uuid.UUID, lang string, req models.TranslationRequest) (models.VideoTranslation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetTranslation", ctx, userID, videoID, lang, req)
+	ret0, _ := ret[0].(models.VideoTranslation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetTranslation indicates an expected call of SetTranslation.
+func (mr *MockVideoProcessorMockRecorder) SetTranslation(ctx, userID, videoID, lang, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetTranslation", reflect.TypeOf((*MockVideoProcessor)(nil).SetTranslation), ctx, userID, videoID, lang, req)
+}
+
+// SetVisibility mocks base method.
+func (m *MockVideoProcessor) SetVisibility(ctx context.Context, userID, videoID uuid.UUID, req models.SetVisibilityRequest) (models.VideoDetail, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetVisibility", ctx, userID, videoID, req)
+	ret0, _ := ret[0].(models.VideoDetail)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetVisibility indicates an expected call of SetVisibility.
+func (mr *MockVideoProcessorMockRecorder) SetVisibility(ctx, userID, videoID, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetVisibility", reflect.TypeOf((*MockVideoProcessor)(nil).SetVisibility), ctx, userID, videoID, req)
+}
+
+// SetWatermark mocks base method.
+func (m *MockVideoProcessor) SetWatermark(ctx context.Context, userID uuid.UUID, req models.WatermarkRequest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetWatermark", ctx, userID, req)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetWatermark indicates an expected call of SetWatermark.
+func (mr *MockVideoProcessorMockRecorder) SetWatermark(ctx, userID, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetWatermark", reflect.TypeOf((*MockVideoProcessor)(nil).SetWatermark), ctx, userID, req)
+}
+
+// StartReprocess mocks base method.
+func (m *MockVideoProcessor) StartReprocess(ctx context.Context, req models.ReprocessRequest) (models.ReprocessRun, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StartReprocess", ctx, req)
+	ret0, _ := ret[0].(models.ReprocessRun)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StartReprocess indicates an expected call of StartReprocess.
+func (mr *MockVideoProcessorMockRecorder) StartReprocess(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartReprocess", reflect.TypeOf((*MockVideoProcessor)(nil).StartReprocess), ctx, req)
+}
+
+// Subscribe mocks base method.
+func (m *MockVideoProcessor) Subscribe(ctx context.Context, userID, creatorID uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Subscribe", ctx, userID, creatorID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Subscribe indicates an expected call of Subscribe.
+func (mr *MockVideoProcessorMockRecorder) Subscribe(ctx, userID, creatorID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Subscribe", reflect.TypeOf((*MockVideoProcessor)(nil).Subscribe), ctx, userID, creatorID)
+}
+
+// SyncPresets mocks base method.
+func (m *MockVideoProcessor) SyncPresets(ctx context.Context, presets []models.PresetRequest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SyncPresets", ctx, presets)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SyncPresets indicates an expected call of SyncPresets.
+func (mr *MockVideoProcessorMockRecorder) SyncPresets(ctx, presets any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SyncPresets", reflect.TypeOf((*MockVideoProcessor)(nil).SyncPresets), ctx, presets)
+}
+
+// Unsubscribe mocks base method.
+func (m *MockVideoProcessor) Unsubscribe(ctx context.Context, userID, creatorID uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Unsubscribe", ctx, userID, creatorID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Unsubscribe indicates an expected call of Unsubscribe.
+func (mr *MockVideoProcessorMockRecorder) Unsubscribe(ctx, userID, creatorID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Unsubscribe", reflect.TypeOf((*MockVideoProcessor)(nil).Unsubscribe), ctx, userID, creatorID)
+}
+
+// UpdatePreset mocks base method.
+func (m *MockVideoProcessor) UpdatePreset(ctx context.Context, id uuid.UUID, req models.PresetRequest) (models.TranscodingPreset, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePreset", ctx, id, req)
+	ret0, _ := ret[0].(models.TranscodingPreset)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdatePreset indicates an expected call of UpdatePreset.
+func (mr *MockVideoProcessorMockRecorder) UpdatePreset(ctx, id, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePreset", reflect.TypeOf((*MockVideoProcessor)(nil).UpdatePreset), ctx, id, req)
+}
+
+// Upload mocks base method.
+func (m *MockVideoProcessor) Upload(ctx context.Context, userID uuid.UUID, req models.UploadVideoRequest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Upload", ctx, userID, req)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Upload indicates an expected call of Upload.
+func (mr *MockVideoProcessorMockRecorder) Upload(ctx, userID, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Upload", reflect.TypeOf((*MockVideoProcessor)(nil).Upload), ctx, userID, req)
+}
@@ -1,6 +1,13 @@
 package models
 
-import "time"
+import (
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
 
 type Config struct {
 	Database struct {
@@ -27,6 +34,19 @@ type Config struct {
 		SecretKey string        `mapstructure:"secret_key"`
 		UrlExpiry time.Duration `mapstructure:"url_expiry"`
 	} `mapstructure:"minio"`
+	// Storage selects where objects are kept: "minio" (the default) or "local"
+	Storage struct {
+		Type string `mapstructure:"type"`
+		// Dir holds the objects of the local backend, one subdirectory per bucket
+		Dir string `mapstructure:"dir"`
+		// BaseURL is the address of the API; local object URLs point at its static storage route
+		BaseURL string `mapstructure:"base_url"`
+		// Bucket keeps the objects of every user in one bucket under a "<user id>/" prefix;
+		// empty gives every user a bucket of their own
+		Bucket string `mapstructure:"bucket"`
+		// Encryption requests server-side encryption of every stored object, MinIO only
+		Encryption EncryptionConfig `mapstructure:"encryption"`
+	} `mapstructure:"storage"`
 	Redis struct {
 		Host     string `mapstructure:"host"`
 		Port     string `mapstructure:"port"`
@@ -36,4 +56,511 @@ type Config struct {
 	Timeout struct {
 		Duration time.Duration `mapstructure:"duration"`
 	} `mapstructure:"timeout"`
+	// TrustedProxies are the reverse proxies whose X-Forwarded-For header gives the client
+	// address, e.g. for limiting playback password attempts. Empty trusts no proxy.
+	TrustedProxies []string           `mapstructure:"trusted_proxies"`
+	Processing     ProcessingConfig   `mapstructure:"processing"`
+	Ingest         IngestConfig       `mapstructure:"ingest"`
+	Notifications  NotificationConfig `mapstructure:"notifications"`
+	Delivery       DeliveryConfig     `mapstructure:"delivery"`
+	Billing        BillingConfig      `mapstructure:"billing"`
+}
+
+// DeliveryConfig limits the bandwidth the API streams video files to users with. Every user
+// has one token bucket per instance, shared by all of their downloads.
+type DeliveryConfig struct {
+	// Plans maps plan names to their rate in KB per second, 0 leaves a plan unlimited
+	Plans map[string]int64 `mapstructure:"plans"`
+	// DefaultPlan is the plan of users without one of their own, empty leaves them unlimited
+	DefaultPlan string `mapstructure:"default_plan"`
+	// Users maps user ids to their plan
+	Users map[string]string `mapstructure:"users"`
+	// BurstKB is how much a user may receive at once before the rate applies, one second
+	// of the rate when unset
+	BurstKB int64 `mapstructure:"burst_kb"`
+}
+
+// PlanOf returns the plan of a user, DefaultPlan unless the user has one of their own
+func (c DeliveryConfig) PlanOf(userID uuid.UUID) string {
+	if plan, ok := c.Users[userID.String()]; ok {
+		return plan
+	}
+	return c.DefaultPlan
+}
+
+// Validate refuses negative rates and plans that are not defined
+func (c DeliveryConfig) Validate() error {
+	for plan, rate := range c.Plans {
+		if rate < 0 {
+			return fmt.Errorf("delivery plan %s has a negative rate", plan)
+		}
+	}
+	if c.BurstKB < 0 {
+		return fmt.Errorf("delivery burst must not be negative")
+	}
+	if _, ok := c.Plans[c.DefaultPlan]; c.DefaultPlan != "" && !ok {
+		return fmt.Errorf("default delivery plan %s is not defined", c.DefaultPlan)
+	}
+	for user, plan := range c.Users {
+		if _, err := uuid.Parse(user); err != nil {
+			return fmt.Errorf("delivery plan of invalid user id %s", user)
+		}
+		if _, ok := c.Plans[plan]; !ok {
+			return fmt.Errorf("delivery plan %s of user %s is not defined", plan, user)
+		}
+	}
+	return nil
+}
+
+// BillingConfig posts the metered usage of every user to an external billing system once a
+// month is over
+type BillingConfig struct {
+	// WebhookURL receives the usage of closed months; nothing is posted while it is empty
+	WebhookURL string `mapstructure:"webhook_url"`
+	// Secret signs each post with the hex HMAC-SHA256 of its body in the X-Signature header
+	Secret string `mapstructure:"secret"`
+	// Timeout bounds a post, 30 seconds when unset
+	Timeout time.Duration `mapstructure:"timeout"`
+}
+
+// NotificationConfig sets up the delivery of notifications outside the app
+type NotificationConfig struct {
+	// SMTP sends email notifications, which are dropped while Host is empty
+	SMTP struct {
+		Host     string `mapstructure:"host"`
+		Port     int    `mapstructure:"port"`
+		Username string `mapstructure:"username"`
+		Password string `mapstructure:"password"`
+		From     string `mapstructure:"from"`
+	} `mapstructure:"smtp"`
+	// WebhookTimeout bounds the delivery to a webhook of a user, 10 seconds when unset
+	WebhookTimeout time.Duration `mapstructure:"webhook_timeout"`
+	// AllowPrivateWebhooks lets user webhooks reach loopback and private addresses, for development
+	AllowPrivateWebhooks bool `mapstructure:"allow_private_webhooks"`
+}
+
+// EncryptionConfig selects the server-side encryption of stored objects
+type EncryptionConfig struct {
+	// Type is "sse-s3" for server-managed keys, "sse-kms" for a KMS key, or empty for none
+	Type string `mapstructure:"type"`
+	// KMSKeyID is the KMS key that encrypts objects with sse-kms
+	KMSKeyID string `mapstructure:"kms_key_id"`
+	// KMSContext is an optional encryption context sent with sse-kms
+	KMSContext map[string]string `mapstructure:"kms_context"`
+}
+
+// IngestConfig lets objects that other systems drop into a bucket be processed without going
+// through the upload endpoint. MinIO reports new objects through a bucket notification, either
+// to the ingest webhook of the API or to a Redis list the worker watches.
+type IngestConfig struct {
+	// Bucket is the ingest bucket, empty disables ingestion
+	Bucket string `mapstructure:"bucket"`
+	// Prefix limits ingestion to keys below it, e.g. "incoming/"
+	Prefix string `mapstructure:"prefix"`
+	// OwnerID is the user that owns ingested videos whose key does not start with a user ID
+	OwnerID string `mapstructure:"owner_id"`
+	// Token must be sent by MinIO as the webhook auth token, the webhook is disabled without it
+	Token string `mapstructure:"token"`
+	// TargetARN is a notification target configured on the MinIO server, e.g.
+	// arn:minio:sqs::ingest:webhook. When set, the notification is put on the bucket at startup.
+	TargetARN string `mapstructure:"target_arn"`
+	// RedisKey is the list a MinIO Redis target in access format pushes events to, empty disables it
+	RedisKey string `mapstructure:"redis_key"`
+}
+
+// ProcessingConfig tunes the video processing worker
+type ProcessingConfig struct {
+	// QualityMetrics scores every rendition against its source with VMAF and PSNR.
+	// Needs an ffmpeg built with libvmaf and roughly doubles processing time.
+	QualityMetrics bool `mapstructure:"quality_metrics"`
+	// VerticalVariants adds a 9:16 variant family for landscape sources
+	VerticalVariants bool `mapstructure:"vertical_variants"`
+	// VerticalCrop places the 9:16 window: "center", or "smart" to follow the most detailed part of the picture
+	VerticalCrop string `mapstructure:"vertical_crop"`
+	// ExtractCaptions also writes embedded CEA-608/708 captions to a WebVTT sidecar
+	ExtractCaptions bool `mapstructure:"extract_captions"`
+	// ExtractSubtitles converts the text subtitle tracks of the source to WebVTT sidecars
+	ExtractSubtitles bool `mapstructure:"extract_subtitles"`
+	// StreamSource lets ffmpeg read sources from presigned MinIO URLs instead of downloading them first
+	StreamSource bool `mapstructure:"stream_source"`
+	// MaxParallelVariants bounds how many variants of a video are encoded at once, 0 encodes all
+	// together. The CPU cores are split evenly between the concurrent encodes.
+	MaxParallelVariants int `mapstructure:"max_parallel_variants"`
+	// SourceCacheDir keeps downloaded sources for later jobs of the same video, empty disables the cache
+	SourceCacheDir string `mapstructure:"source_cache_dir"`
+	// SourceCacheSizeMB bounds the source cache, least recently used sources are evicted first
+	SourceCacheSizeMB int64 `mapstructure:"source_cache_size_mb"`
+	// ThumbnailAt is where variant thumbnails are taken: seconds into the video ("5", "2.5"),
+	// a share of its duration ("10%"), or "auto" (default) to pick a representative, non-black
+	// frame from the 30 seconds after 10% of the video
+	ThumbnailAt string `mapstructure:"thumbnail_at"`
+	// ThumbnailCandidates are where the thumbnails the owner picks the primary one from are
+	// taken, in the format of ThumbnailAt. 10%, 30% and 60% when unset.
+	ThumbnailCandidates []string `mapstructure:"thumbnail_candidates"`
+	// ScratchDir is where job working directories are created, e.g. a fast NVMe disk or a tmpfs.
+	// Empty uses the system temp directory.
+	ScratchDir string `mapstructure:"scratch_dir"`
+	// ScratchSizeMB bounds the scratch space of all running jobs together, 0 disables the limit.
+	// The worker stops taking jobs while the budget is used up.
+	ScratchSizeMB int64 `mapstructure:"scratch_size_mb"`
+	// ChunkedMinDuration splits sources at least this long into chunks that are transcoded in
+	// parallel and joined per variant, 0 never chunks. MaxParallelVariants then bounds the
+	// chunk encodes running at once rather than the variants.
+	ChunkedMinDuration time.Duration `mapstructure:"chunked_min_duration"`
+	// ChunkDuration is the length of the chunks, one minute when unset
+	ChunkDuration time.Duration `mapstructure:"chunk_duration"`
+	// Encoder encodes the H.264 variants: "libx264" (default) on the CPU, "h264_nvenc" on an
+	// NVIDIA GPU, "h264_vaapi" on an Intel or AMD GPU or "h264_qsv" on Intel Quick Sync. Falls
+	// back to libx264 at startup when ffmpeg cannot encode with it, and per variant when an
+	// encode fails.
+	Encoder string `mapstructure:"encoder"`
+	// DisableHDRVariant keeps HDR sources to the tone mapped SDR ladder instead of adding a
+	// 10-bit HEVC variant. Turned on at startup when ffmpeg lacks libx265.
+	DisableHDRVariant bool `mapstructure:"disable_hdr_variant"`
+	// WebM also encodes every SDR variant into a VP9 and Opus WebM file next to its MP4, for
+	// browsers that prefer it. Roughly doubles encoding time. Turned off at startup when ffmpeg
+	// lacks libvpx-vp9 or libopus.
+	WebM bool `mapstructure:"webm"`
+	// AudioRendition encodes the audio of every video into a 128k AAC m4a, stored as the
+	// "audio" variant and packaged as the audio-only fallback of the master playlists
+	AudioRendition bool `mapstructure:"audio_rendition"`
+	// AudioMP3 also encodes the audio into an mp3 for players without AAC. Turned off at
+	// startup when ffmpeg lacks libmp3lame.
+	AudioMP3 bool `mapstructure:"audio_mp3"`
+	// AnimatedPreview encodes three seconds from the middle of every video into a looping
+	// animated WebP for hover previews in listings. Turned off at startup when ffmpeg lacks
+	// libwebp.
+	AnimatedPreview bool `mapstructure:"animated_preview"`
+	// FrameRate caps the frame rate of the variants, in frames per second, so 120fps screen
+	// recordings do not take twice the bitrate of 60fps ones. Faster sources keep every nth
+	// frame where that lands close below it. "passthrough" (default) keeps every source's rate.
+	FrameRate string `mapstructure:"frame_rate"`
+	// HLSStreamCopy cuts the HLS segments of the H.264 variants out of their MP4 without
+	// re-encoding it, which relies on the keyframes the transcode puts at segment boundaries.
+	// Off, the MP4 is encoded a second time while segmenting. Remuxed variants are always
+	// re-encoded, their keyframes are the source's.
+	HLSStreamCopy bool `mapstructure:"hls_stream_copy"`
+	// HLSSegmentType is the container of the HLS segments of the H.264 variants: "mpegts"
+	// (default) or "fmp4", CMAF segments with an init.mp4 that carry less overhead. HEVC
+	// variants are always fMP4, and the audio-only rendition always MPEG-TS.
+	HLSSegmentType string `mapstructure:"hls_segment_type"`
+	// HLSEncryption encrypts the HLS segments of every video with a key of its own
+	HLSEncryption HLSEncryptionConfig `mapstructure:"hls_encryption"`
+	// DRM packages the renditions of the jobs whose processing mode asks for it with CENC
+	// encryption for Widevine, PlayReady and FairPlay
+	DRM DRMConfig `mapstructure:"drm"`
+	// SinglePass encodes the variants of a video in one ffmpeg run that decodes the source once,
+	// instead of a decode per variant. Watermarked, remuxed and chunked variants are still
+	// encoded on their own, and a failed run falls back to encoding every variant on its own.
+	SinglePass bool `mapstructure:"single_pass"`
+	// SceneChapters places chapters at the scene changes of videos that have none from their
+	// owner or their source, for the chapter markers of players. Adds a decode of the source
+	// to every job.
+	SceneChapters bool `mapstructure:"scene_chapters"`
+	// SceneThreshold is the scene score, from 0 to 1, a cut must reach to start a chapter, 0.4
+	// when unset
+	SceneThreshold float64 `mapstructure:"scene_threshold"`
+	// SceneChapterMin is the shortest chapter scene changes make, one minute when unset
+	SceneChapterMin time.Duration `mapstructure:"scene_chapter_min"`
+	// SurroundAudio keeps the 5.1 and 7.1 audio of surround sources in the largest variants
+	// instead of downmixing it to stereo. The other variants carry the stereo downmix.
+	SurroundAudio bool `mapstructure:"surround_audio"`
+	// SurroundVariants is how many of the largest variants keep surround audio, 1 when unset
+	SurroundVariants int `mapstructure:"surround_variants"`
+	// Denoise is the denoise mode of uploads that do not ask for one: "off" (default), "light"
+	// or "strong", see DenoiseModes
+	Denoise string `mapstructure:"denoise"`
+	// Stabilization lets uploads ask for their shaky handheld footage to be stabilized with
+	// vidstab before the variants are encoded. Adds two passes over the source to those jobs.
+	// Turned off at startup when ffmpeg lacks the vidstab filters.
+	Stabilization bool `mapstructure:"stabilization"`
+	// StabilizeShakiness is how shaky sources are taken to be, from 1 to 10, 5 when unset
+	StabilizeShakiness int `mapstructure:"stabilize_shakiness"`
+	// StabilizeSmoothing is the number of frames before and after each frame the camera path
+	// is averaged over, 10 when unset. Higher values are steadier but lag behind pans.
+	StabilizeSmoothing int `mapstructure:"stabilize_smoothing"`
+	// ProxyFirst encodes and publishes a small variant of newly uploaded videos before the rest
+	// of the ladder, and marks them playable, so they can be watched within moments
+	ProxyFirst bool `mapstructure:"proxy_first"`
+	// ProxyVariant names the variant published first, "360p" when unset. Without a variant of
+	// that name the smallest regular variant of the job is.
+	ProxyVariant string `mapstructure:"proxy_variant"`
+	// LowLatency publishes the proxy variant of newly uploaded videos as low-latency HLS while
+	// it encodes, instead of once it is done. It takes the place of ProxyFirst.
+	LowLatency LowLatencyConfig `mapstructure:"low_latency"`
+	// SourceCodecs are the video codecs, as ffprobe names them, sources are accepted in. Sources
+	// in other codecs fail before anything is encoded. Empty accepts every codec ffprobe knows.
+	SourceCodecs []string `mapstructure:"source_codecs"`
+	// PerTitle fits the bitrates of the ladder to each source. A few excerpts are encoded at
+	// constant quality first, and the bitrate they take scales the preset bitrates.
+	PerTitle bool `mapstructure:"per_title"`
+	// Presets are written to the database at startup, replacing the presets of the same name,
+	// so the ladder is changed without recompiling or calling the preset API
+	Presets []PresetRequest `mapstructure:"presets"`
+	// MaxJobsPerUser caps the jobs of one user running at once across all workers, 0 disables
+	// the limit. Jobs over it are parked in a delayed queue and streamed again after UserLimitDelay.
+	MaxJobsPerUser int `mapstructure:"max_jobs_per_user"`
+	// UserLimitDelay is how long parked jobs wait, 30 seconds when unset
+	UserLimitDelay time.Duration `mapstructure:"user_limit_delay"`
+	// VersionRetention is how long the rendition sets replaced by reprocessing are kept for
+	// rollbacks before their objects are deleted, 7 days when unset
+	VersionRetention time.Duration `mapstructure:"version_retention"`
+	// Watermark is composed onto every variant of every video, unless the owner uploaded a
+	// watermark of their own
+	Watermark WatermarkConfig `mapstructure:"watermark"`
+	// Bumpers are joined to the start and the end of every video, unless the owner uploaded
+	// bumpers of their own
+	Bumpers BumpersConfig `mapstructure:"bumpers"`
+	// Hooks run external commands or webhooks at points of the pipeline
+	Hooks []HookConfig `mapstructure:"hooks"`
+	// FFmpeg limits the CPU the ffmpeg processes of the worker take
+	FFmpeg FFmpegConfig `mapstructure:"ffmpeg"`
+	// Modes sets what the fast, balanced and quality processing modes do and which mode the
+	// jobs of a user get
+	Modes ModesConfig `mapstructure:"modes"`
+	// DryRun makes the worker log the plan of every job, its ffmpeg commands, object keys and
+	// database writes, instead of executing it. Jobs are still acknowledged. PROCESSING_DRY_RUN=true
+	// turns it on from the environment.
+	DryRun bool `mapstructure:"dry_run"`
+}
+
+// FFmpegConfig limits the ffmpeg processes of a worker, so a large job leaves CPU to an API
+// running on the same host
+type FFmpegConfig struct {
+	// Threads are the cores the encodes of a job share, split between the variants encoded at
+	// once. 0 uses every core.
+	Threads int `mapstructure:"threads"`
+	// Nice is the niceness ffmpeg runs at, from 0 to 19. Higher values yield the CPU to other
+	// processes sooner.
+	Nice int `mapstructure:"nice"`
+	// Cgroup is a cgroup v2 directory ffmpeg is started in, e.g. one with a lower cpu.weight or
+	// a cpu.max quota. Linux only, empty starts ffmpeg in the cgroup of the worker.
+	Cgroup string `mapstructure:"cgroup"`
+	// MaxProcesses bounds the ffmpeg processes of the worker running at once, across all of its
+	// jobs, 0 disables the limit. ffprobe is not counted.
+	MaxProcesses int `mapstructure:"max_processes"`
+}
+
+// Validate refuses negative limits and niceness out of range
+func (c FFmpegConfig) Validate() error {
+	if c.Threads < 0 {
+		return fmt.Errorf("ffmpeg threads must not be negative")
+	}
+	if c.Nice < 0 || c.Nice > 19 {
+		return fmt.Errorf("ffmpeg niceness must be between 0 and 19, got %d", c.Nice)
+	}
+	if c.MaxProcesses < 0 {
+		return fmt.Errorf("ffmpeg max processes must not be negative")
+	}
+	return nil
+}
+
+// ModesConfig configures the ProcessingModes jobs are processed in
+type ModesConfig struct {
+	Fast     ModeConfig `mapstructure:"fast"`
+	Balanced ModeConfig `mapstructure:"balanced"`
+	Quality  ModeConfig `mapstructure:"quality"`
+	// Default is the mode of jobs that name none and whose user's plan has none, balanced
+	// when empty
+	Default string `mapstructure:"default"`
+	// Plans maps delivery plans, the tiers of users, to the mode of their uploads, see
+	// DeliveryConfig
+	Plans map[string]string `mapstructure:"plans"`
+}
+
+// ModeConfig is what the jobs of a processing mode do differently. The zero value processes
+// jobs the way they are processed without modes.
+type ModeConfig struct {
+	// Preset names the transcoding preset, and with it the ladder, of the uploads that name
+	// none. Empty keeps the default preset.
+	Preset string `mapstructure:"preset"`
+	// EncoderPreset replaces the x264/x265 speed preset of every variant, e.g. veryfast or
+	// slow. Empty keeps the presets of the variants.
+	EncoderPreset string `mapstructure:"encoder_preset"`
+	// TwoPass encodes the libx264 variants that target a bitrate in two passes, which spends
+	// the bitrate where the picture needs it at about twice the encoding time
+	TwoPass bool `mapstructure:"two_pass"`
+	// DRM packages the variants into encrypted HLS and DASH next to the clear renditions, see
+	// ProcessingConfig.DRM
+	DRM bool `mapstructure:"drm"`
+}
+
+// Mode returns the configuration of a processing mode, the default mode's for an empty or
+// unknown name
+func (c ModesConfig) Mode(name string) ModeConfig {
+	switch name {
+	case ModeFast:
+		return c.Fast
+	case ModeBalanced:
+		return c.Balanced
+	case ModeQuality:
+		return c.Quality
+	}
+	if name != c.DefaultMode() {
+		return c.Mode(c.DefaultMode())
+	}
+	return c.Balanced
+}
+
+// DefaultMode is the mode of jobs that name none, for users without a mode of their own
+func (c ModesConfig) DefaultMode() string {
+	if c.Default != "" {
+		return c.Default
+	}
+	return ModeBalanced
+}
+
+// ModeOf returns the mode of the jobs of users on plan that name none
+func (c ModesConfig) ModeOf(plan string) string {
+	if mode, ok := c.Plans[plan]; ok {
+		return mode
+	}
+	return c.DefaultMode()
+}
+
+// Validate refuses unknown modes and encoder presets
+func (c ModesConfig) Validate() error {
+	valid := func(mode string) bool { return slices.Contains(ProcessingModes, interface{}(mode)) }
+	if c.Default != "" && !valid(c.Default) {
+		return fmt.Errorf("unknown default processing mode %s", c.Default)
+	}
+	for plan, mode := range c.Plans {
+		if !valid(mode) {
+			return fmt.Errorf("unknown processing mode %s of plan %s", mode, plan)
+		}
+	}
+	for name, mode := range map[string]ModeConfig{ModeFast: c.Fast, ModeBalanced: c.Balanced, ModeQuality: c.Quality} {
+		if mode.EncoderPreset != "" && !slices.Contains(EncoderPresets, interface{}(mode.EncoderPreset)) {
+			return fmt.Errorf("unknown encoder preset %s of processing mode %s", mode.EncoderPreset, name)
+		}
+	}
+	return nil
+}
+
+// Corners a watermark is placed in, or the center of the picture
+const (
+	WatermarkTopLeft     = "top-left"
+	WatermarkTopRight    = "top-right"
+	WatermarkBottomLeft  = "bottom-left"
+	WatermarkBottomRight = "bottom-right"
+	WatermarkCenter      = "center"
+)
+
+// WatermarkPositions are the valid watermark positions
+var WatermarkPositions = []interface{}{WatermarkTopLeft, WatermarkTopRight, WatermarkBottomLeft, WatermarkBottomRight, WatermarkCenter}
+
+// WatermarkConfig is the platform-wide watermark and the defaults of per-user watermarks
+type WatermarkConfig struct {
+	// Bucket and Key locate the PNG in MinIO, an empty Key leaves videos without a platform watermark
+	Bucket string `mapstructure:"bucket"`
+	Key    string `mapstructure:"key"`
+	// Position is one of the WatermarkPositions, bottom-right when empty
+	Position string `mapstructure:"position"`
+	// Opacity of the watermark from 0 to 1, opaque when 0
+	Opacity float64 `mapstructure:"opacity"`
+	// Scale is the watermark width as a share of the video width, 0.15 when 0
+	Scale float64 `mapstructure:"scale"`
+}
+
+// HLSEncryptionConfig encrypts HLS segments with AES-128. The keys are kept in the database,
+// encrypted with the key encryption key, and served by the API to the viewers of a video.
+type HLSEncryptionConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// KeyURL is the address of the API the key URIs in the playlists start with, e.g.
+	// https://api.example.com; empty gives root relative URIs, for playlists served by the API
+	KeyURL string `mapstructure:"key_url"`
+	// KeyEncryptionKey is the hex encoded 32 byte AES-256 key the stored keys are encrypted
+	// with. The API needs it as long as encrypted videos are served, even once disabled.
+	KeyEncryptionKey string `mapstructure:"key_encryption_key"`
+}
+
+// Validate checks the key encryption key, which encryption needs
+func (c HLSEncryptionConfig) Validate() error {
+	if c.KeyEncryptionKey == "" {
+		if c.Enabled {
+			return fmt.Errorf("hls encryption needs a key encryption key")
+		}
+		return nil
+	}
+	if key, err := hex.DecodeString(c.KeyEncryptionKey); err != nil || len(key) != 32 {
+		return fmt.Errorf("hls key encryption key must be 32 hex encoded bytes")
+	}
+	return nil
+}
+
+// LowLatencyConfig is the "publish while processing" mode: the proxy variant is encoded into
+// short fMP4 parts, and its playlist is uploaded with every part, so players can start at the
+// live edge while the rest of the job runs
+type LowLatencyConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// PartDuration is the length of the partial segments, the EXT-X-PART-INF part target, one
+	// second when unset. Every part starts with a keyframe.
+	PartDuration time.Duration `mapstructure:"part_duration"`
+	// PartsPerSegment is how many parts make a full segment, 4 when unset
+	PartsPerSegment int `mapstructure:"parts_per_segment"`
+}
+
+// DRMConfig is the DRM packaging stage, which runs Shaka Packager over the variant MP4s
+type DRMConfig struct {
+	// Packager is the Shaka Packager binary, "packager" from the PATH when empty
+	Packager string `mapstructure:"packager"`
+	// Scheme is the CENC protection scheme: "cbcs" (default), the SAMPLE-AES that FairPlay and
+	// Widevine both play, or "cenc", AES-CTR for Widevine and PlayReady
+	Scheme string `mapstructure:"scheme"`
+	// Systems are the DRM systems the manifests signal: widevine, playready and fairplay. Empty
+	// signals Widevine and FairPlay under cbcs, Widevine and PlayReady under cenc.
+	Systems []string `mapstructure:"systems"`
+	// KeyProvider hands out the content key of every video
+	KeyProvider DRMKeyProviderConfig `mapstructure:"key_provider"`
+}
+
+// DRMKeyProviderConfig selects where content keys come from. A "static" provider encrypts
+// every video with the configured key; an "http" provider asks a key server for the key of
+// each video. An empty Type leaves DRM packaging off.
+type DRMKeyProviderConfig struct {
+	Type string `mapstructure:"type"`
+	// KeyID and Key are the hex encoded 16 byte key of the static provider
+	KeyID string `mapstructure:"key_id"`
+	Key   string `mapstructure:"key"`
+	// KeyURI is the URI of the static key in HLS playlists, e.g. the skd:// URI of FairPlay
+	KeyURI string `mapstructure:"key_uri"`
+	// URL is the key server of the http provider, Token is sent to it as a bearer token
+	URL   string `mapstructure:"url"`
+	Token string `mapstructure:"token"`
+	// Timeout bounds a key request, 10 seconds when unset
+	Timeout time.Duration `mapstructure:"timeout"`
+}
+
+// Positions of a bumper in the video
+const (
+	BumperIntro = "intro"
+	BumperOutro = "outro"
+)
+
+// BumperPositions are the valid bumper positions
+var BumperPositions = []interface{}{BumperIntro, BumperOutro}
+
+// BumpersConfig is the platform-wide intro and outro
+type BumpersConfig struct {
+	Intro BumperConfig `mapstructure:"intro"`
+	Outro BumperConfig `mapstructure:"outro"`
+}
+
+// BumperConfig locates a bumper clip in MinIO, an empty Key leaves videos without it
+type BumperConfig struct {
+	Bucket string `mapstructure:"bucket"`
+	Key    string `mapstructure:"key"`
+}
+
+// HookConfig runs a custom step at a point of the processing pipeline: after_download,
+// after_variant, before_metadata_save or after_completion. The step is either a command,
+// which gets the event as JSON on stdin, or a webhook the event is posted to.
+type HookConfig struct {
+	Point   string   `mapstructure:"point"`
+	Command []string `mapstructure:"command"`
+	URL     string   `mapstructure:"url"`
+	// Timeout bounds a single run, 30 seconds when unset
+	Timeout time.Duration `mapstructure:"timeout"`
+	// Required hooks fail the job or variant when they fail, others are only logged
+	Required bool `mapstructure:"required"`
 }
@@ -0,0 +1,60 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Statuses of a data export
+const (
+	ExportPending   = "pending"
+	ExportRunning   = "running"
+	ExportCompleted = "completed"
+	ExportFailed    = "failed"
+	ExportExpired   = "expired" // the archive was deleted
+)
+
+// DataExport is the progress of an export of all the data of a user. DownloadURL is set once
+// the archive is written; it expires after a while, polling again returns a fresh one.
+type DataExport struct {
+	ID          uuid.UUID  `json:"id"`
+	Status      string     `json:"status"`
+	SizeBytes   int64      `json:"size_bytes,omitempty"`
+	DownloadURL string     `json:"download_url,omitempty"` // presigned URL of the zip archive
+	Error       string     `json:"error,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	FinishedAt  *time.Time `json:"finished_at,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"` // the archive is deleted then
+}
+
+// ExportManifest is manifest.json of an export archive
+type ExportManifest struct {
+	ExportID  uuid.UUID `json:"export_id"`
+	UserID    uuid.UUID `json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+	// LinksExpireAt is when the links to the originals in videos.json stop working
+	LinksExpireAt time.Time `json:"links_expire_at"`
+	Files         []string  `json:"files"`
+}
+
+// ExportedProfile is the account of a user as exported, without the password hash
+type ExportedProfile struct {
+	ID                uuid.UUID `json:"id"`
+	FirstName         string    `json:"first_name"`
+	MiddleName        string    `json:"middle_name"`
+	LastName          string    `json:"last_name"`
+	Username          string    `json:"username"`
+	Email             string    `json:"email"`
+	Phone             string    `json:"phone"`
+	ProfilePictureURL string    `json:"profile_picture_url,omitempty"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+// ExportedVideo is a video of a user as exported, including removed ones
+type ExportedVideo struct {
+	VideoDetail
+	Translations []VideoTranslation `json:"translations"`
+	OriginalURL  string             `json:"original_url"` // presigned URL of the uploaded source
+}
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/google/uuid"
+)
+
+// WatchedFraction is how much of a video must be played for it to count as watched
+const WatchedFraction = 0.95
+
+// WatchPositionRequest reports how far the user got in a video
+type WatchPositionRequest struct {
+	PositionMs int64 `json:"position_ms"`
+	DurationMs int64 `json:"duration_ms"` // length of the video as the player sees it, 0 when unknown
+}
+
+func (r WatchPositionRequest) Validate() error {
+	err := validation.ValidateStruct(&r,
+		validation.Field(&r.PositionMs, validation.Min(int64(0))),
+		validation.Field(&r.DurationMs, validation.Min(int64(0))),
+		validation.Field(&r.PositionMs, validation.When(r.DurationMs > 0, validation.Max(r.DurationMs).Error("must not be past duration_ms"))),
+	)
+	if err == nil {
+		return nil
+	}
+	return errors.Join(err, ErrInvalidInputData)
+}
+
+// WatchPosition is where playback of a video resumes. Finished videos resume from the start.
+type WatchPosition struct {
+	VideoID    uuid.UUID `json:"video_id"`
+	PositionMs int64     `json:"position_ms"`
+	DurationMs int64     `json:"duration_ms"`
+	Finished   bool      `json:"finished"`
+	WatchedAt  time.Time `json:"watched_at"`
+}
+
+// WatchHistoryEntry is a video the user watched, last watched first
+type WatchHistoryEntry struct {
+	WatchPosition
+	Title              string `json:"title"`
+	PreviewURL         string `json:"preview_url,omitempty"`
+	AnimatedPreviewURL string `json:"animated_preview_url,omitempty"`
+}
+
+// Finished reports whether playback reached the end of the video
+func Finished(positionMs, durationMs int64) bool {
+	return durationMs > 0 && float64(positionMs) >= WatchedFraction*float64(durationMs)
+}
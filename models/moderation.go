@@ -0,0 +1,104 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/google/uuid"
+)
+
+// Reasons a video can be reported for
+var ReportReasons = []interface{}{"spam", "sexual_content", "violence", "harassment", "hate_speech", "copyright", "child_safety", "other"}
+
+// Statuses of a report
+const (
+	ReportOpen      = "open"
+	ReportResolved  = "resolved"  // a moderator acted on the video
+	ReportDismissed = "dismissed" // a moderator left the video as it is
+)
+
+// Actions a moderator takes on a reported video
+const (
+	ModerationHide        = "hide"         // only the owner sees the video
+	ModerationAgeRestrict = "age_restrict" // the video is left out of feeds and flagged for players
+	ModerationRemove      = "remove"       // nobody sees the video
+	ModerationDismiss     = "dismiss"      // the reports are closed without action
+)
+
+// ReportRequest reports a video to the moderators
+type ReportRequest struct {
+	Reason  string `json:"reason"`
+	Details string `json:"details"`
+}
+
+func (r ReportRequest) Validate() error {
+	err := validation.ValidateStruct(&r,
+		validation.Field(&r.Reason, validation.Required, validation.In(ReportReasons...)),
+		validation.Field(&r.Details, validation.Length(0, 2000),
+			validation.When(r.Reason == "other", validation.Required.Error("describe the problem for reason other"))),
+	)
+	if err == nil {
+		return nil
+	}
+	return errors.Join(err, ErrInvalidInputData)
+}
+
+// ModerationRequest resolves the open reports of a video with an action
+type ModerationRequest struct {
+	Action string `json:"action"`
+	Note   string `json:"note"` // shown to the owner of the video
+}
+
+func (r ModerationRequest) Validate() error {
+	err := validation.ValidateStruct(&r,
+		validation.Field(&r.Action, validation.Required, validation.In(ModerationHide, ModerationAgeRestrict, ModerationRemove, ModerationDismiss)),
+		validation.Field(&r.Note, validation.Length(0, 1000)),
+	)
+	if err == nil {
+		return nil
+	}
+	return errors.Join(err, ErrInvalidInputData)
+}
+
+// ReportQuery pages through the moderation queue
+type ReportQuery struct {
+	Status string `form:"status"`
+	Limit  int    `form:"limit"`
+	Offset int    `form:"offset"`
+}
+
+func (q ReportQuery) Validate() error {
+	err := validation.ValidateStruct(&q,
+		validation.Field(&q.Status, validation.In(ReportOpen, ReportResolved, ReportDismissed)),
+		validation.Field(&q.Limit, validation.Min(1), validation.Max(100)),
+		validation.Field(&q.Offset, validation.Min(0)),
+	)
+	if err == nil {
+		return nil
+	}
+	return errors.Join(err, ErrInvalidInputData)
+}
+
+// VideoReport is a report of a video. Video is only set in the moderation queue.
+type VideoReport struct {
+	ID         uuid.UUID      `json:"id"`
+	VideoID    uuid.UUID      `json:"video_id"`
+	ReporterID uuid.UUID      `json:"reporter_id"`
+	Reason     string         `json:"reason"`
+	Details    string         `json:"details,omitempty"`
+	Status     string         `json:"status"`
+	Action     string         `json:"action,omitempty"`
+	ResolvedBy *uuid.UUID     `json:"resolved_by,omitempty"`
+	ResolvedAt *time.Time     `json:"resolved_at,omitempty"`
+	CreatedAt  time.Time      `json:"created_at"`
+	Video      *ReportedVideo `json:"video,omitempty"`
+}
+
+// ReportedVideo is the state of a reported video
+type ReportedVideo struct {
+	OwnerID       uuid.UUID `json:"owner_id"`
+	Title         string    `json:"title"`
+	Visibility    string    `json:"visibility"`
+	AgeRestricted bool      `json:"age_restricted"`
+}
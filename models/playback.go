@@ -0,0 +1,76 @@
+package models
+
+import (
+	"errors"
+	"io"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/google/uuid"
+)
+
+// PlaybackPasswordRequest sets the password asked before playing a video
+type PlaybackPasswordRequest struct {
+	Password string `json:"password"`
+}
+
+func (r PlaybackPasswordRequest) Validate() error {
+	err := validation.ValidateStruct(&r,
+		// bcrypt ignores what follows the first 72 bytes
+		validation.Field(&r.Password, validation.Required, validation.Length(4, 72)),
+	)
+	if err == nil {
+		return nil
+	}
+	return errors.Join(err, ErrInvalidInputData)
+}
+
+// PlaybackRequest asks for a playback token, with the password of protected videos
+type PlaybackRequest struct {
+	Password string `json:"password"`
+}
+
+// PlaybackToken lets anyone holding it play one video until it expires
+type PlaybackToken struct {
+	Token string `json:"token"`
+}
+
+// Playback holds presigned URLs of the variants and subtitles of a video, valid until ExpiresAt
+type Playback struct {
+	VideoID   uuid.UUID          `json:"video_id"`
+	Title     string             `json:"title"`
+	Variants  []PlaybackVariant  `json:"variants"`
+	Subtitles []PlaybackSubtitle `json:"subtitles"`
+	ExpiresAt time.Time          `json:"expires_at"`
+	// KeyURL is the HLS key of an encrypted video with the playback token in its query, for
+	// players that cannot send the token as a header
+	KeyURL string `json:"key_url,omitempty"`
+}
+
+type PlaybackVariant struct {
+	Name        string `json:"name"`
+	Width       int32  `json:"width"`
+	Height      int32  `json:"height"`
+	BitrateKbps int32  `json:"bitrate_kbps"`
+	Format      string `json:"format"`       // mp4 or webm, m4a or mp3 for the audio variant, hls when encrypted
+	ContentType string `json:"content_type"` // for the type of a <source> element
+	URL         string `json:"url"`
+	// AudioLanguages are the languages of the file's audio tracks, for a language picker
+	AudioLanguages []string `json:"audio_languages,omitempty"`
+}
+
+// PlaybackSubtitle is a WebVTT subtitle track, for the <track> elements of a player
+type PlaybackSubtitle struct {
+	Language string `json:"language"`        // e.g. "eng", "und" when the source did not tag it
+	Label    string `json:"label,omitempty"` // title of the source track
+	Forced   bool   `json:"forced"`          // only subtitles foreign dialogue
+	URL      string `json:"url"`
+}
+
+// Download is a video file streamed through the API; the caller closes Body
+type Download struct {
+	Name        string
+	ContentType string
+	Size        int64
+	Body        io.ReadCloser
+}
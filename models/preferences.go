@@ -0,0 +1,127 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/google/uuid"
+)
+
+// Channels notifications are delivered on
+const (
+	ChannelInApp   = "in_app"  // listed by the notifications endpoint
+	ChannelEmail   = "email"   // mailed to the address of the account
+	ChannelWebhook = "webhook" // posted as a NotificationEvent to the webhook of the user
+)
+
+var (
+	NotificationKinds    = []string{NotificationProcessingDone, NotificationNewSubscriber, NotificationNewVideo, NotificationModeration}
+	NotificationChannels = []string{ChannelInApp, ChannelEmail, ChannelWebhook}
+)
+
+// NotificationPreferences chooses the channels of each kind of notification. Kinds left
+// out are delivered in the app only; an empty list mutes a kind.
+type NotificationPreferences struct {
+	Channels   map[string][]string `json:"channels"`
+	WebhookURL string              `json:"webhook_url,omitempty"`
+}
+
+// Validate checks the preferences. Webhooks must be public https URLs, unless
+// allowPrivateWebhooks lets them reach plain http and internal hosts, as deliveries then do.
+func (p NotificationPreferences) Validate(allowPrivateWebhooks bool) error {
+	err := validation.ValidateStruct(&p,
+		validation.Field(&p.Channels, validation.By(func(interface{}) error {
+			for kind, channels := range p.Channels {
+				if !slices.Contains(NotificationKinds, kind) {
+					return fmt.Errorf("unknown kind %q", kind)
+				}
+				for _, channel := range channels {
+					if !slices.Contains(NotificationChannels, channel) {
+						return fmt.Errorf("unknown channel %q of %s", channel, kind)
+					}
+				}
+			}
+			return nil
+		})),
+		validation.Field(&p.WebhookURL,
+			validation.When(p.uses(ChannelWebhook), validation.Required.Error("is required by the webhook channel")),
+			validation.By(func(interface{}) error {
+				if p.WebhookURL == "" {
+					return nil
+				}
+				u, err := url.Parse(p.WebhookURL)
+				if allowPrivateWebhooks {
+					if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Hostname() == "" {
+						return errors.New("must be an http or https URL")
+					}
+					return nil
+				}
+				if err != nil || u.Scheme != "https" || u.Hostname() == "" {
+					return errors.New("must be an https URL")
+				}
+				host := strings.ToLower(u.Hostname())
+				if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+					return errors.New("must not point at the local host")
+				}
+				if ip := net.ParseIP(host); ip != nil && !PublicIP(ip) {
+					return errors.New("must point at a public address")
+				}
+				return nil
+			})),
+	)
+	if err == nil {
+		return nil
+	}
+	return errors.Join(err, ErrInvalidInputData)
+}
+
+// sharedAddressSpace is the carrier-grade NAT range, internal to providers like private ranges
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// PublicIP reports whether ip is a public unicast address. Webhooks of users may only reach
+// those, never loopback, private, link-local (e.g. cloud metadata), shared or multicast ones.
+func PublicIP(ip net.IP) bool {
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || sharedAddressSpace.Contains(ip))
+}
+
+// ChannelsOf returns the channels a kind of notification is delivered on
+func (p NotificationPreferences) ChannelsOf(kind string) []string {
+	if channels, ok := p.Channels[kind]; ok {
+		return channels
+	}
+	return []string{ChannelInApp}
+}
+
+// WithDefaults lists the channels of every kind, including the ones left to the default
+func (p NotificationPreferences) WithDefaults() NotificationPreferences {
+	channels := make(map[string][]string, len(NotificationKinds))
+	for _, kind := range NotificationKinds {
+		channels[kind] = p.ChannelsOf(kind)
+	}
+	return NotificationPreferences{Channels: channels, WebhookURL: p.WebhookURL}
+}
+
+func (p NotificationPreferences) uses(channel string) bool {
+	for _, channels := range p.Channels {
+		if slices.Contains(channels, channel) {
+			return true
+		}
+	}
+	return false
+}
+
+// NotificationEvent is a notification as mailed and posted to webhooks
+type NotificationEvent struct {
+	Kind      string     `json:"kind"`
+	UserID    uuid.UUID  `json:"user_id"`
+	VideoID   *uuid.UUID `json:"video_id,omitempty"`
+	Message   string     `json:"message"`
+	CreatedAt time.Time  `json:"created_at"`
+}
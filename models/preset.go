@@ -0,0 +1,216 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/google/uuid"
+)
+
+// Video codecs of preset variants
+const (
+	CodecH264 = "h264"
+	CodecHEVC = "hevc"
+)
+
+// Rate control modes of presets
+const (
+	RateControlBitrate = "bitrate"
+	RateControlCRF     = "crf"
+)
+
+// AudioVariantName is the variant of the audio-only renditions, which ladders cannot use
+const AudioVariantName = "audio"
+
+// x264 and x265 speed presets a variant may use
+var EncoderPresets = []interface{}{"ultrafast", "superfast", "veryfast", "faster", "fast", "medium", "slow", "slower", "veryslow"}
+
+// H.264 profiles a variant may be restricted to, for devices that decode no more
+const (
+	ProfileBaseline = "baseline"
+	ProfileMain     = "main"
+	ProfileHigh     = "high"
+)
+
+var H264Profiles = []interface{}{ProfileBaseline, ProfileMain, ProfileHigh}
+
+// H.264 levels a variant may be restricted to
+var H264Levels = []interface{}{"1", "1.1", "1.2", "1.3", "2", "2.1", "2.2", "3", "3.1", "3.2", "4", "4.1", "4.2", "5", "5.1", "5.2", "6", "6.1", "6.2"}
+
+// x264 tunes a variant may use, x265 only knows HEVCTunes
+var (
+	EncoderTunes = []interface{}{"film", "animation", "grain", "stillimage", "fastdecode", "zerolatency"}
+	HEVCTunes    = []interface{}{"animation", "grain", "fastdecode", "zerolatency"}
+)
+
+var (
+	variantNamePattern = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+	bitratePattern     = regexp.MustCompile(`^[1-9][0-9]*k$`)
+)
+
+// DefaultPreset is the default preset the migrations seed, for tools that run without a database
+var DefaultPreset = TranscodingPreset{
+	Name:        "default",
+	Description: "H.264 ladder from 1080p to 144p, with a 10-bit HEVC rung for HDR sources and a 9:16 family",
+	IsDefault:   true,
+	Variants: []PresetVariant{
+		{Name: "1080p", Width: 1920, Height: 1080, Bitrate: "4000k"},
+		{Name: "720p", Width: 1280, Height: 720, Bitrate: "2000k"},
+		{Name: "480p", Width: 854, Height: 480, Bitrate: "1000k"},
+		{Name: "360p", Width: 640, Height: 360, Bitrate: "500k"},
+		{Name: "240p", Width: 426, Height: 240, Bitrate: "250k"},
+		{Name: "144p", Width: 256, Height: 144, Bitrate: "100k"},
+		{Name: "1080p-hdr", Width: 1920, Height: 1080, Codec: CodecHEVC, Bitrate: "6000k", HDR: true},
+		{Name: "1080p-vertical", Width: 1080, Height: 1920, Bitrate: "4500k", Vertical: true},
+		{Name: "720p-vertical", Width: 720, Height: 1280, Bitrate: "2500k", Vertical: true},
+		{Name: "480p-vertical", Width: 480, Height: 854, Bitrate: "1000k", Vertical: true},
+	},
+	HLS:         HLSOptions{SegmentSeconds: 6},
+	RateControl: RateControlOptions{Mode: RateControlBitrate},
+}
+
+// TranscodingPreset is a named rendition ladder with its encoder and HLS settings.
+// Videos are processed with the preset named at upload, or the default preset.
+type TranscodingPreset struct {
+	ID          uuid.UUID          `json:"id"`
+	Name        string             `json:"name"`
+	Description string             `json:"description"`
+	IsDefault   bool               `json:"is_default"`
+	Variants    []PresetVariant    `json:"variants"`
+	HLS         HLSOptions         `json:"hls"`
+	RateControl RateControlOptions `json:"rate_control"`
+	CreatedAt   time.Time          `json:"created_at"`
+	UpdatedAt   time.Time          `json:"updated_at"`
+}
+
+// PresetVariant is one rung of a preset's ladder
+type PresetVariant struct {
+	Name   string `json:"name" mapstructure:"name"` // directory and playlist name, e.g. "1080p"
+	Width  int    `json:"width" mapstructure:"width"`
+	Height int    `json:"height" mapstructure:"height"`
+	Codec  string `json:"codec,omitempty" mapstructure:"codec"` // h264 (default) or hevc
+	// Bitrate is the target video bitrate, e.g. "4000k". With CRF it caps the bitrate instead.
+	Bitrate string `json:"bitrate" mapstructure:"bitrate"`
+	// CRF encodes at constant quality (0-51, lower is better), 0 encodes at the target bitrate
+	CRF           int    `json:"crf,omitempty" mapstructure:"crf"`
+	EncoderPreset string `json:"encoder_preset,omitempty" mapstructure:"encoder_preset"` // x264/x265 speed preset, "fast" by default
+	// Profile and Level restrict H.264 rungs for older devices, e.g. baseline and 3.0 for the
+	// lowest rungs. Empty leaves them to the encoder.
+	Profile string `json:"profile,omitempty" mapstructure:"profile"`
+	Level   string `json:"level,omitempty" mapstructure:"level"`
+	// Tune adapts the encoder to the content, e.g. "animation" or "grain"; hardware encoders ignore it
+	Tune string `json:"tune,omitempty" mapstructure:"tune"`
+	// HDR rungs are only encoded for HDR sources, as 10-bit HEVC keeping the HDR signal
+	HDR bool `json:"hdr,omitempty" mapstructure:"hdr"`
+	// Vertical rungs are 9:16 crops of landscape sources, encoded when vertical variants are enabled
+	Vertical bool `json:"vertical,omitempty" mapstructure:"vertical"`
+}
+
+// RateControlOptions select how the rungs of a preset spend their bits. Fixed bitrates waste
+// bits on simple content and starve complex scenes; constant quality spends what each scene
+// needs, up to the rung's bitrate.
+type RateControlOptions struct {
+	// Mode is "bitrate" (default) to encode the rungs at their bitrate unless they set a crf, or
+	// "crf" to encode every rung at constant quality capped by its bitrate
+	Mode string `json:"mode" mapstructure:"mode"`
+	// CRF is the quality of the rungs without a crf of their own in crf mode; 0 uses 23 for
+	// H.264 and 28 for HEVC
+	CRF int `json:"crf,omitempty" mapstructure:"crf"`
+}
+
+// HLSOptions tunes how the variants are packaged
+type HLSOptions struct {
+	SegmentSeconds int `json:"segment_seconds" mapstructure:"segment_seconds"` // target segment length, 6 by default
+}
+
+// PresetRequest creates or replaces a preset
+type PresetRequest struct {
+	Name        string             `json:"name" mapstructure:"name"`
+	Description string             `json:"description" mapstructure:"description"`
+	IsDefault   bool               `json:"is_default" mapstructure:"is_default"`
+	Variants    []PresetVariant    `json:"variants" mapstructure:"variants"`
+	HLS         HLSOptions         `json:"hls" mapstructure:"hls"`
+	RateControl RateControlOptions `json:"rate_control" mapstructure:"rate_control"`
+}
+
+func (p PresetRequest) Validate() error {
+	err := validation.ValidateStruct(&p,
+		validation.Field(&p.Name, validation.Required.Error("name is required"), validation.Length(1, 100)),
+		validation.Field(&p.Variants, validation.Required.Error("at least one variant is required")),
+		validation.Field(&p.HLS),
+		validation.Field(&p.RateControl),
+	)
+	if err == nil {
+		err = p.validateVariants()
+	}
+	if err == nil {
+		return nil
+	}
+	return errors.Join(err, ErrInvalidInputData)
+}
+
+func (p PresetRequest) validateVariants() error {
+	names := map[string]bool{}
+	regular := false
+	for i, v := range p.Variants {
+		if err := v.Validate(); err != nil {
+			return fmt.Errorf("variants[%d]: %w", i, err)
+		}
+		if names[v.Name] {
+			return fmt.Errorf("variants[%d]: duplicate name %q", i, v.Name)
+		}
+		names[v.Name] = true
+		regular = regular || !v.HDR && !v.Vertical
+	}
+	if !regular {
+		return errors.New("at least one variant must be neither hdr nor vertical")
+	}
+	return nil
+}
+
+func (v PresetVariant) Validate() error {
+	hevc := v.HDR || v.Codec == CodecHEVC
+	return validation.ValidateStruct(&v,
+		validation.Field(&v.Name, validation.Required, validation.Length(1, 50),
+			validation.Match(variantNamePattern).Error("must only contain letters, digits, - and _"),
+			validation.NotIn(AudioVariantName).Error("is reserved for the audio-only renditions")),
+		// yuv420p needs even dimensions
+		validation.Field(&v.Width, validation.Required, validation.Min(16), validation.Max(7680), validation.By(even)),
+		validation.Field(&v.Height, validation.Required, validation.Min(16), validation.Max(7680), validation.By(even)),
+		validation.Field(&v.Codec, validation.In(CodecH264, CodecHEVC),
+			validation.When(v.HDR, validation.In(CodecHEVC).Error("hdr variants are encoded as hevc"))),
+		validation.Field(&v.Bitrate, validation.Required, validation.Match(bitratePattern).Error("must be kilobits like 4000k")),
+		validation.Field(&v.CRF, validation.Min(0), validation.Max(51)),
+		validation.Field(&v.EncoderPreset, validation.In(EncoderPresets...)),
+		validation.Field(&v.Profile, validation.In(H264Profiles...),
+			validation.When(hevc, validation.Empty.Error("only applies to h264 variants"))),
+		validation.Field(&v.Level, validation.In(H264Levels...),
+			validation.When(hevc, validation.Empty.Error("only applies to h264 variants"))),
+		validation.Field(&v.Tune, validation.In(EncoderTunes...),
+			validation.When(hevc, validation.In(HEVCTunes...).Error("is not a tune of x265"))),
+		validation.Field(&v.HDR, validation.When(v.HDR && v.Vertical, validation.Empty.Error("a variant cannot be both hdr and vertical"))),
+	)
+}
+
+func (h HLSOptions) Validate() error {
+	return validation.ValidateStruct(&h,
+		validation.Field(&h.SegmentSeconds, validation.Min(1), validation.Max(60)),
+	)
+}
+
+func (r RateControlOptions) Validate() error {
+	return validation.ValidateStruct(&r,
+		validation.Field(&r.Mode, validation.In(RateControlBitrate, RateControlCRF)),
+		validation.Field(&r.CRF, validation.Min(0), validation.Max(51)),
+	)
+}
+
+func even(value interface{}) error {
+	if n, _ := value.(int); n%2 != 0 {
+		return errors.New("must be even")
+	}
+	return nil
+}
@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// RenditionVersion is a rendition set a reprocess replaced. It is kept for the retention
+// period of the processing config, and the video can be rolled back to it until then.
+type RenditionVersion struct {
+	Version    int32             `json:"version"`
+	ReplacedAt time.Time         `json:"replaced_at"`
+	Variants   []VideoVariant    `json:"variants"`
+	Assets     map[string]string `json:"assets"` // asset kind -> object key
+}
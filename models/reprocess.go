@@ -0,0 +1,66 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/google/uuid"
+)
+
+// Statuses of a reprocess run
+const (
+	ReprocessRunning   = "running"
+	ReprocessCompleted = "completed" // every matching video was queued
+	ReprocessCancelled = "cancelled"
+)
+
+// DefaultReprocessRate is how many videos a run queues per minute when the request sets none
+const DefaultReprocessRate = 60
+
+// ReprocessRequest starts a run that processes existing videos again, e.g. after the
+// default preset changed. Without filters every video uploaded so far is reprocessed.
+type ReprocessRequest struct {
+	PresetID      *uuid.UUID `json:"preset_id"`      // preset to process with, the default preset when empty
+	UserID        *uuid.UUID `json:"user_id"`        // only videos of this user
+	CreatedAfter  *time.Time `json:"created_after"`  // only videos uploaded at or after
+	CreatedBefore *time.Time `json:"created_before"` // only videos uploaded before, now by default
+	RatePerMinute int        `json:"rate_per_minute"`
+}
+
+func (r ReprocessRequest) Validate() error {
+	err := validation.ValidateStruct(&r,
+		validation.Field(&r.RatePerMinute, validation.Min(0), validation.Max(10000)),
+		validation.Field(&r.CreatedBefore, validation.When(r.CreatedAfter != nil && r.CreatedBefore != nil,
+			validation.By(func(interface{}) error {
+				if !r.CreatedBefore.After(*r.CreatedAfter) {
+					return errors.New("must be after created_after")
+				}
+				return nil
+			}))),
+	)
+	if err == nil {
+		return nil
+	}
+	return errors.Join(err, ErrInvalidInputData)
+}
+
+// ReprocessRun is the progress of a bulk reprocessing run. Enqueued counts the videos
+// queued so far; Succeeded and Failed count the jobs the workers finished.
+type ReprocessRun struct {
+	ID            uuid.UUID  `json:"id"`
+	PresetID      *uuid.UUID `json:"preset_id,omitempty"`
+	UserID        *uuid.UUID `json:"user_id,omitempty"`
+	CreatedAfter  *time.Time `json:"created_after,omitempty"`
+	CreatedBefore time.Time  `json:"created_before"`
+	RatePerMinute int        `json:"rate_per_minute"`
+	Status        string     `json:"status"`
+	Total         int        `json:"total"`
+	Enqueued      int        `json:"enqueued"`
+	Succeeded     int        `json:"succeeded"`
+	Failed        int        `json:"failed"`
+	Error         string     `json:"error,omitempty"` // why queueing last stalled, cleared once it resumes
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+	FinishedAt    *time.Time `json:"finished_at,omitempty"`
+}
@@ -0,0 +1,37 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+)
+
+// ScheduleRequest sets when a video is published and when it expires. A private video
+// becomes public at PublishAt; at ExpiresAt it is made private again, or deleted with
+// all its objects when PurgeOnExpiry is set. Empty times clear the schedule.
+type ScheduleRequest struct {
+	PublishAt     *time.Time `json:"publish_at"`
+	ExpiresAt     *time.Time `json:"expires_at"`
+	PurgeOnExpiry bool       `json:"purge_on_expiry"`
+}
+
+func (r ScheduleRequest) Validate() error {
+	err := validation.ValidateStruct(&r,
+		validation.Field(&r.ExpiresAt, validation.When(r.ExpiresAt != nil,
+			validation.By(func(interface{}) error {
+				if !r.ExpiresAt.After(time.Now()) {
+					return errors.New("must be in the future")
+				}
+				if r.PublishAt != nil && !r.ExpiresAt.After(*r.PublishAt) {
+					return errors.New("must be after publish_at")
+				}
+				return nil
+			}))),
+		validation.Field(&r.PurgeOnExpiry, validation.When(r.ExpiresAt == nil, validation.Empty.Error("needs expires_at"))),
+	)
+	if err == nil {
+		return nil
+	}
+	return errors.Join(err, ErrInvalidInputData)
+}
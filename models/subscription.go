@@ -0,0 +1,72 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/google/uuid"
+)
+
+// Visibilities of a video
+const (
+	VisibilityPrivate = "private" // only the owner sees the video, the default
+	VisibilityPublic  = "public"  // shown in the feeds of the owner's subscribers
+	VisibilityHidden  = "hidden"  // hidden by a moderator, only the owner sees the video
+	VisibilityRemoved = "removed" // removed by a moderator, nobody sees the video
+)
+
+// Kinds of notifications
+const (
+	NotificationNewVideo       = "new_video"       // a creator the user subscribed to published a video
+	NotificationModeration     = "moderation"      // a moderator acted on a video of the user
+	NotificationProcessingDone = "processing_done" // a video of the user finished processing
+	NotificationNewSubscriber  = "new_subscriber"  // another user subscribed to the user
+)
+
+// SetVisibilityRequest publishes or unpublishes a video
+type SetVisibilityRequest struct {
+	Visibility string `json:"visibility"`
+}
+
+func (r SetVisibilityRequest) Validate() error {
+	err := validation.ValidateStruct(&r,
+		validation.Field(&r.Visibility, validation.Required, validation.In(VisibilityPrivate, VisibilityPublic)),
+	)
+	if err == nil {
+		return nil
+	}
+	return errors.Join(err, ErrInvalidInputData)
+}
+
+// Subscription is a creator the user follows
+type Subscription struct {
+	CreatorID    uuid.UUID `json:"creator_id"`
+	Username     string    `json:"username"`
+	SubscribedAt time.Time `json:"subscribed_at"`
+}
+
+// FeedItem is a public video of a subscribed creator, newest publication first
+type FeedItem struct {
+	ID                 uuid.UUID `json:"id"`
+	CreatorID          uuid.UUID `json:"creator_id"`
+	Username           string    `json:"username"`
+	Title              string    `json:"title"`
+	Description        string    `json:"description"`
+	Language           string    `json:"language,omitempty"`
+	PublishedAt        time.Time `json:"published_at"`
+	PreviewURL         string    `json:"preview_url,omitempty"`
+	AnimatedPreviewURL string    `json:"animated_preview_url,omitempty"`
+}
+
+// Notification is an in-app notification of the user
+type Notification struct {
+	ID        uuid.UUID  `json:"id"`
+	Kind      string     `json:"kind"`
+	VideoID   *uuid.UUID `json:"video_id,omitempty"`
+	Title     string     `json:"title,omitempty"` // title of the video
+	CreatorID *uuid.UUID `json:"creator_id,omitempty"`
+	Message   string     `json:"message,omitempty"`
+	Read      bool       `json:"read"`
+	CreatedAt time.Time  `json:"created_at"`
+}
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/google/uuid"
+	"golang.org/x/text/language"
+)
+
+// TranslationRequest sets the title and description of a video in one language
+type TranslationRequest struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+}
+
+func (r TranslationRequest) Validate() error {
+	err := validation.ValidateStruct(&r,
+		validation.Field(&r.Title, validation.Required.Error("title is required"), validation.Length(1, 255)),
+		validation.Field(&r.Description, validation.Length(0, 5000)),
+	)
+	if err == nil {
+		return nil
+	}
+	return errors.Join(err, ErrInvalidInputData)
+}
+
+// CanonicalLanguage returns the canonical form of a BCP 47 language tag, e.g. pt-BR for pt_br
+func CanonicalLanguage(tag string) (string, error) {
+	t, err := language.Parse(tag)
+	if err != nil || t == language.Und {
+		return "", errors.Join(errors.New("language must be a BCP 47 tag such as en or pt-BR"), ErrInvalidInputData)
+	}
+	return t.String(), nil
+}
+
+// VideoTranslation is the title and description of a video in one language
+type VideoTranslation struct {
+	VideoID     uuid.UUID `json:"video_id"`
+	Language    string    `json:"language"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UsageMonthLayout is how months are written in usage queries and reports
+const UsageMonthLayout = "2006-01"
+
+// UsageMonth is the metered usage of a user in a calendar month (UTC). Storage counts the
+// bytes of the sources uploaded and the processing results written during the month, and
+// delivery the bytes of the user's videos downloaded through the API.
+type UsageMonth struct {
+	UserID             uuid.UUID `json:"user_id"`
+	Month              string    `json:"month"` // e.g. 2025-11
+	TranscodingMinutes float64   `json:"transcoding_minutes"`
+	StorageBytes       int64     `json:"storage_bytes"`
+	DeliveryBytes      int64     `json:"delivery_bytes"`
+}
+
+// UsageQuery bounds a usage listing, both months included
+type UsageQuery struct {
+	From string `form:"from"` // YYYY-MM, 11 months before To when empty
+	To   string `form:"to"`   // YYYY-MM, the current month when empty
+}
+
+// UsageExportQuery selects the month and the format of a usage export
+type UsageExportQuery struct {
+	Month  string `form:"month"`  // YYYY-MM, the previous month when empty
+	Format string `form:"format"` // json (default) or csv
+}
+
+// UsageRecord is the usage of a user in a closed month as posted to the billing webhook.
+// The idempotency key is the same when a post is retried.
+type UsageRecord struct {
+	UsageMonth
+	IdempotencyKey string `json:"idempotency_key"`
+}
+
+// UsageReport is the body of a post to the billing webhook
+type UsageReport struct {
+	Records []UsageRecord `json:"records"`
+	SentAt  time.Time     `json:"sent_at"`
+}
@@ -1,22 +1,672 @@
 package models
 
 import (
+	"errors"
+	"fmt"
 	"mime/multipart"
+	"path/filepath"
+	"strings"
+	"time"
 
 	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/go-ozzo/ozzo-validation/v4/is"
+	"github.com/google/uuid"
 )
 
 type UploadVideoRequest struct {
 	Title       string                  `form:"title" binding:"required"`
 	Description string                  `form:"description" binding:"required"`
 	Videos      []*multipart.FileHeader `form:"videos" binding:"required"`
+	// Preset names the transcoding preset, the default preset when empty
+	Preset string `form:"preset"`
+	// BurnSubtitleTrack burns a text subtitle track of the source into the video, counted
+	// among the subtitle streams from 0
+	BurnSubtitleTrack *int `form:"burn_subtitle_track"`
+	// BurnSubtitles burns an SRT file into the video instead, for a single video
+	BurnSubtitles *multipart.FileHeader `form:"burn_subtitles"`
+	// WatermarkPosition and WatermarkOpacity override the configured placement of the watermark
+	WatermarkPosition string   `form:"watermark_position"`
+	WatermarkOpacity  *float64 `form:"watermark_opacity"`
+	// TrimStart and TrimEnd keep only that range of the video, in seconds. A TrimEnd of 0
+	// keeps the video to its end.
+	TrimStart float64 `form:"trim_start"`
+	TrimEnd   float64 `form:"trim_end"`
+	// Deinterlace is one of the DeinterlaceModes, auto when empty
+	Deinterlace string `form:"deinterlace"`
+	// CropBlackBars crops the letterbox or pillarbox bars of the video, so the variants hold only picture
+	CropBlackBars bool `form:"crop_black_bars"`
+	// Denoise is one of the DenoiseModes, the configured mode when empty
+	Denoise string `form:"denoise"`
+	// Stabilize steadies the picture of shaky handheld footage before it is encoded
+	Stabilize bool `form:"stabilize"`
+	// SkipBumpers leaves out the intro and outro otherwise joined to the video
+	SkipBumpers bool `form:"skip_bumpers"`
+	// Mode is one of the ProcessingModes, the mode of the user's plan when empty
+	Mode string `form:"mode"`
 }
 
-func (u *UploadVideoRequest) Validate() error {
+// Processing modes of a job, trading encoding time for quality. What each does is set in
+// ModesConfig.
+const (
+	ModeFast     = "fast"
+	ModeBalanced = "balanced"
+	ModeQuality  = "quality"
+)
+
+// ProcessingModes are the valid processing modes
+var ProcessingModes = []interface{}{ModeFast, ModeBalanced, ModeQuality}
+
+// Statuses of a video
+const (
+	VideoPending = "pending"
+	// VideoPlayable videos play from their proxy variant while the rest of the ladder encodes
+	VideoPlayable  = "playable"
+	VideoProcessed = "processed"
+	VideoFailed    = "failed"
+)
 
+// Deinterlace modes of an upload: auto deinterlaces the sources found interlaced, on and off
+// override the detection
+const (
+	DeinterlaceAuto = "auto"
+	DeinterlaceOn   = "on"
+	DeinterlaceOff  = "off"
+)
+
+// DeinterlaceModes are the valid deinterlace modes
+var DeinterlaceModes = []interface{}{DeinterlaceAuto, DeinterlaceOn, DeinterlaceOff}
+
+// Denoise modes of an upload: light removes fine grain with hqdn3d, strong also the heavy
+// noise of low-light footage with nlmeans, at a much higher encoding cost
+const (
+	DenoiseOff    = "off"
+	DenoiseLight  = "light"
+	DenoiseStrong = "strong"
+)
+
+// DenoiseModes are the valid denoise modes
+var DenoiseModes = []interface{}{DenoiseOff, DenoiseLight, DenoiseStrong}
+
+func (u *UploadVideoRequest) Validate() error {
+	if u.BurnSubtitleTrack != nil && u.BurnSubtitles != nil {
+		return errors.Join(errors.New("burn_subtitle_track and burn_subtitles are exclusive"), ErrInvalidInputData)
+	}
 	return validation.ValidateStruct(u,
 		validation.Field(&u.Title, validation.Required.Error("title is required")),
 		validation.Field(&u.Description, validation.Required.Error("description is required")),
-		validation.Field(&u.Videos, validation.Required.Error("at least one video is required")),
+		validation.Field(&u.Videos, validation.Required.Error("at least one video is required"),
+			validation.When(u.BurnSubtitles != nil, validation.Length(1, 1).Error("burn_subtitles applies to a single video"))),
+		validation.Field(&u.BurnSubtitleTrack, validation.Min(0)),
+		validation.Field(&u.BurnSubtitles, validation.When(u.BurnSubtitles != nil, validation.By(isSRT))),
+		validation.Field(&u.WatermarkPosition, validation.In(WatermarkPositions...)),
+		validation.Field(&u.WatermarkOpacity, validation.Min(0.0).Exclusive(), validation.Max(1.0)),
+		validation.Field(&u.TrimStart, validation.Min(0.0)),
+		validation.Field(&u.TrimEnd, validation.When(u.TrimEnd != 0,
+			validation.Min(u.TrimStart).Exclusive().Error("trim_end must be after trim_start"))),
+		validation.Field(&u.Deinterlace, validation.In(DeinterlaceModes...)),
+		validation.Field(&u.Denoise, validation.In(DenoiseModes...)),
+		validation.Field(&u.Mode, validation.In(ProcessingModes...)),
+	)
+}
+
+// WatermarkRequest replaces the watermark of the user, composed onto their future videos
+type WatermarkRequest struct {
+	Image *multipart.FileHeader `form:"image"` // PNG, transparency is kept
+}
+
+func (w WatermarkRequest) Validate() error {
+	err := validation.ValidateStruct(&w,
+		validation.Field(&w.Image, validation.Required.Error("image is required")),
 	)
+	if err == nil {
+		return nil
+	}
+	return errors.Join(err, ErrInvalidInputData)
+}
+
+// BumperRequest replaces the intro or the outro of the user, joined to their future videos
+type BumperRequest struct {
+	Position string                `form:"-"` // one of BumperPositions, from the path
+	Video    *multipart.FileHeader `form:"video"`
+}
+
+func (b BumperRequest) Validate() error {
+	err := validation.ValidateStruct(&b,
+		validation.Field(&b.Position, validation.Required, validation.In(BumperPositions...)),
+		validation.Field(&b.Video, validation.Required.Error("video is required")),
+	)
+	if err == nil {
+		return nil
+	}
+	return errors.Join(err, ErrInvalidInputData)
+}
+
+// isSRT accepts an uploaded SubRip file, judged by its extension since browsers send
+// no reliable type for it
+func isSRT(value interface{}) error {
+	fh, _ := value.(*multipart.FileHeader)
+	if fh == nil || !strings.EqualFold(filepath.Ext(fh.Filename), ".srt") {
+		return errors.New("must be an .srt file")
+	}
+	return nil
+}
+
+type Chapter struct {
+	Title string  `json:"title"`
+	Start float64 `json:"start"`         // seconds from the beginning of the video
+	End   float64 `json:"end,omitempty"` // seconds, only known for chapters taken from the source
+}
+
+type SetChaptersRequest struct {
+	Chapters []Chapter `json:"chapters"`
+}
+
+func (s SetChaptersRequest) Validate() error {
+	for i, c := range s.Chapters {
+		err := validation.ValidateStruct(&c,
+			validation.Field(&c.Title, validation.Required.Error("title is required"), validation.Length(1, 255)),
+			validation.Field(&c.Start, validation.Min(0.0).Error("start must not be negative")),
+		)
+		if err != nil {
+			return errors.Join(fmt.Errorf("chapter %d: %w", i, err), ErrInvalidInputData)
+		}
+		if i > 0 && c.Start <= s.Chapters[i-1].Start {
+			return errors.Join(fmt.Errorf("chapter %d: start must be after the previous chapter", i), ErrInvalidInputData)
+		}
+	}
+	return nil
+}
+
+type VideoVariant struct {
+	Name           string   `json:"name"`
+	Width          int32    `json:"width"`
+	Height         int32    `json:"height"`
+	BitrateKbps    int32    `json:"bitrate_kbps"`
+	Key            string   `json:"key"`
+	HlsPlaylistKey string   `json:"hls_playlist_key"`
+	ThumbnailKey   string   `json:"thumbnail_key"`
+	Format         string   `json:"format"`         // mp4, webm for the VP9 copy, m4a or mp3 for the audio variant
+	VMAF           *float64 `json:"vmaf,omitempty"` // quality against the source, 0-100
+	PSNR           *float64 `json:"psnr,omitempty"` // luma PSNR against the source, dB
+	// AudioLanguages are the languages of the file's audio tracks in order, "und" when untagged
+	AudioLanguages []string `json:"audio_languages,omitempty"`
+}
+
+type VideoDetail struct {
+	ID            uuid.UUID  `json:"id"`
+	ParentVideoID *uuid.UUID `json:"parent_video_id,omitempty"`
+	Recipe        *Recipe    `json:"recipe,omitempty"`
+	Title         string     `json:"title"`
+	Description   string     `json:"description"`
+	Language      string     `json:"language,omitempty"`       // language of title and description when translated
+	Status        string     `json:"status"`                   // pending, playable, processed or failed
+	FailureReason string     `json:"failure_reason,omitempty"` // why the source was rejected, for failed videos
+	Visibility    string     `json:"visibility"`
+	PublishedAt   *time.Time `json:"published_at,omitempty"`
+	AgeRestricted bool       `json:"age_restricted"`
+	PublishAt     *time.Time `json:"publish_at,omitempty"` // scheduled publication
+	ExpiresAt     *time.Time `json:"expires_at,omitempty"`
+	PurgeOnExpiry bool       `json:"purge_on_expiry,omitempty"`
+	// PasswordProtected videos are played with a password, see the playback endpoint
+	PasswordProtected bool   `json:"password_protected"`
+	Bucket            string `json:"bucket"`
+	FileSizeBytes     int64  `json:"file_size_bytes"`
+	ContentType       string `json:"content_type"`
+	// SourceWidth and SourceHeight are the resolution of the source, 0 until it is processed
+	SourceWidth  int32 `json:"source_width,omitempty"`
+	SourceHeight int32 `json:"source_height,omitempty"`
+	// DurationSeconds, SourceCodec, SourceFPS, AudioChannels and Container describe the source
+	// as ffprobe saw it, empty until it is processed
+	DurationSeconds float64           `json:"duration_seconds,omitempty"`
+	SourceCodec     string            `json:"source_codec,omitempty"`
+	SourceFPS       float64           `json:"source_fps,omitempty"`
+	AudioChannels   int32             `json:"audio_channels,omitempty"`
+	Container       string            `json:"container,omitempty"` // ffprobe's format name, e.g. "mov,mp4,m4a,3gp,3g2,mj2"
+	CreatedAt       time.Time         `json:"created_at"`
+	Variants        []VideoVariant    `json:"variants"`
+	Assets          map[string]string `json:"assets"` // asset kind -> object key
+	Chapters        []Chapter         `json:"chapters"`
+	Thumbnails      []VideoThumbnail  `json:"thumbnails"`
+	Color           *ColorInfo        `json:"color,omitempty"`
+	Spherical       *SphericalInfo    `json:"spherical,omitempty"` // set for 360°/VR videos
+	// Progress is how far the latest processing run got, nil before the first one
+	Progress *VideoProgress `json:"progress,omitempty"`
+}
+
+// VideoProgress is the transcode progress of a video's variants
+type VideoProgress struct {
+	Percent  int               `json:"percent"` // average of the variants
+	Variants []VariantProgress `json:"variants"`
+	// ETA is when the last of the variants being encoded should be done, absent while one of
+	// them cannot be estimated yet
+	ETA *time.Time `json:"eta,omitempty"`
+}
+
+// VariantProgress is the share of a variant transcoded so far, 100 once it is processed
+type VariantProgress struct {
+	Variant   string    `json:"variant"`
+	Percent   int       `json:"percent"`
+	UpdatedAt time.Time `json:"updated_at"`
+	// ETA applies the speed past encodes of the variant went at to the rest of the source, or
+	// without those extrapolates the rate of the variant since its encode started. Absent when
+	// it is done, or has neither history nor advanced yet.
+	ETA *time.Time `json:"eta,omitempty"`
+}
+
+// JobStatus is the status of a video and the progress of its latest processing run, as
+// streamed while it is processed
+type JobStatus struct {
+	VideoID  uuid.UUID      `json:"video_id"`
+	Status   string         `json:"status"`
+	Progress *VideoProgress `json:"progress,omitempty"`
+}
+
+// Done reports whether the processing run is over: the video is processed or failed and
+// every variant that started is done. Reprocessed videos stay processed while their variants
+// run again.
+func (s JobStatus) Done() bool {
+	finished := s.Status == VideoProcessed || s.Status == VideoFailed
+	return finished && (s.Progress == nil || s.Progress.Percent == 100)
+}
+
+// VideoThumbnail is one of the thumbnails taken along a video, the owner picks the primary one
+type VideoThumbnail struct {
+	Position int32   `json:"position"`
+	At       float64 `json:"at"` // seconds into the video
+	Key      string  `json:"key"`
+	Primary  bool    `json:"primary"`
+}
+
+// PrimaryThumbnailRequest picks the primary thumbnail of a video by its position
+type PrimaryThumbnailRequest struct {
+	Position *int `json:"position"`
+}
+
+func (r PrimaryThumbnailRequest) Validate() error {
+	err := validation.ValidateStruct(&r,
+		validation.Field(&r.Position, validation.NotNil, validation.Min(0)),
+	)
+	if err == nil {
+		return nil
+	}
+	return errors.Join(err, ErrInvalidInputData)
+}
+
+// VideoSummary is a video as shown in listings
+type VideoSummary struct {
+	ID                 uuid.UUID  `json:"id"`
+	ParentVideoID      *uuid.UUID `json:"parent_video_id,omitempty"`
+	Title              string     `json:"title"`
+	Description        string     `json:"description"`
+	Language           string     `json:"language,omitempty"`
+	Status             string     `json:"status"`
+	Visibility         string     `json:"visibility"`
+	CreatedAt          time.Time  `json:"created_at"`
+	DurationSeconds    float64    `json:"duration_seconds,omitempty"`     // 0 until the source is processed
+	PreviewURL         string     `json:"preview_url,omitempty"`          // presigned URL of the short preview clip
+	AnimatedPreviewURL string     `json:"animated_preview_url,omitempty"` // presigned URL of the looping animated WebP played on hover
+}
+
+type ListVideosQuery struct {
+	Limit  int `form:"limit"`
+	Offset int `form:"offset"`
+	// Languages are the preferred languages of the caller from Accept-Language, best first
+	Languages []string `form:"-"`
+}
+
+func (q ListVideosQuery) Validate() error {
+	err := validation.ValidateStruct(&q,
+		validation.Field(&q.Limit, validation.Min(1), validation.Max(100)),
+		validation.Field(&q.Offset, validation.Min(0)),
+	)
+	if err == nil {
+		return nil
+	}
+	return errors.Join(err, ErrInvalidInputData)
+}
+
+// SphericalInfo describes how a 360°/VR video must be projected by the player
+type SphericalInfo struct {
+	Projection string `json:"projection"`
+	StereoMode string `json:"stereo_mode,omitempty"`
+}
+
+// ColorInfo describes the color signal of the source video
+type ColorInfo struct {
+	Primaries string `json:"primaries,omitempty"`
+	Transfer  string `json:"transfer,omitempty"`
+	Space     string `json:"space,omitempty"`
+	HDRFormat string `json:"hdr_format,omitempty"` // HDR10 or HLG, empty for SDR
+}
+
+// Recipe types stored on derived videos
+const (
+	RecipeTypeEdit      = "edit"
+	RecipeTypeOverlay   = "overlay"
+	RecipeTypeAudiogram = "audiogram"
+	RecipeTypeClip      = "clip"
+	RecipeTypeSlideshow = "slideshow"
+	RecipeTypeSpeed     = "speed"
+)
+
+// Recipe describes how a derived video was rendered from its parent video
+type Recipe struct {
+	Type      string            `json:"type"`
+	Edit      *EditRequest      `json:"edit,omitempty"`
+	Overlay   *OverlayRequest   `json:"overlay,omitempty"`
+	Audiogram *AudiogramRequest `json:"audiogram,omitempty"`
+	Clip      *ClipRequest      `json:"clip,omitempty"`
+	Slideshow *SlideshowRequest `json:"slideshow,omitempty"`
+	Speed     *SpeedRequest     `json:"speed,omitempty"`
+}
+
+type CropRect struct {
+	X      int `json:"x"`
+	Y      int `json:"y"`
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+type EditRequest struct {
+	Title  string    `json:"title,omitempty"`  // defaults to the parent title
+	Speed  float64   `json:"speed,omitempty"`  // playback speed factor between 0.25 and 4
+	Crop   *CropRect `json:"crop,omitempty"`   // rectangle in source pixels
+	Rotate int       `json:"rotate,omitempty"` // clockwise degrees: 90, 180 or 270
+	Volume *float64  `json:"volume,omitempty"` // gain factor between 0 (mute) and 10
+}
+
+func (e EditRequest) Validate() error {
+	if e.Speed == 0 && e.Crop == nil && e.Rotate == 0 && e.Volume == nil {
+		return errors.Join(errors.New("at least one edit operation is required"), ErrInvalidInputData)
+	}
+	err := validation.ValidateStruct(&e,
+		validation.Field(&e.Title, validation.Length(0, 255)),
+		validation.Field(&e.Speed, validation.When(e.Speed != 0,
+			validation.Min(0.25), validation.Max(4.0))),
+		validation.Field(&e.Rotate, validation.In(0, 90, 180, 270).Error("rotate must be 90, 180 or 270")),
+		validation.Field(&e.Volume, validation.When(e.Volume != nil,
+			validation.Min(0.0), validation.Max(10.0))),
+		validation.Field(&e.Crop),
+	)
+	if err == nil {
+		return nil
+	}
+	return errors.Join(err, ErrInvalidInputData)
+}
+
+func (c CropRect) Validate() error {
+	return validation.ValidateStruct(&c,
+		validation.Field(&c.X, validation.Min(0)),
+		validation.Field(&c.Y, validation.Min(0)),
+		validation.Field(&c.Width, validation.Required, validation.Min(2)),
+		validation.Field(&c.Height, validation.Required, validation.Min(2)),
+	)
+}
+
+// OverlayRequest composes a secondary video or image over a base video.
+// Exactly one of OverlayVideoID and Image is set.
+type OverlayRequest struct {
+	Title          string                `form:"title" json:"title,omitempty"`                       // defaults to the base title
+	OverlayVideoID string                `form:"overlay_video_id" json:"overlay_video_id,omitempty"` // another video of the user
+	Image          *multipart.FileHeader `form:"image" json:"-"`                                     // PNG or JPEG, transparency is kept
+	X              int                   `form:"x" json:"x"`                                         // left edge in base video pixels
+	Y              int                   `form:"y" json:"y"`                                         // top edge in base video pixels
+	Scale          float64               `form:"scale" json:"scale"`                                 // overlay width as a share of the base width
+	Start          float64               `form:"start" json:"start,omitempty"`                       // seconds into the base video
+	End            float64               `form:"end" json:"end,omitempty"`                           // seconds, 0 shows the overlay until the end
+
+	// resolved by the service, the worker reads the overlay source from here
+	OverlayBucket string `form:"-" json:"overlay_bucket,omitempty"`
+	OverlayKey    string `form:"-" json:"overlay_key,omitempty"`
+	IsImage       bool   `form:"-" json:"is_image,omitempty"`
+}
+
+func (o OverlayRequest) Validate() error {
+	if (o.OverlayVideoID == "") == (o.Image == nil) {
+		return errors.Join(errors.New("either overlay_video_id or image is required"), ErrInvalidInputData)
+	}
+	err := validation.ValidateStruct(&o,
+		validation.Field(&o.Title, validation.Length(0, 255)),
+		validation.Field(&o.OverlayVideoID, validation.When(o.OverlayVideoID != "", is.UUID)),
+		validation.Field(&o.X, validation.Min(0)),
+		validation.Field(&o.Y, validation.Min(0)),
+		validation.Field(&o.Scale, validation.Required, validation.Min(0.01), validation.Max(1.0)),
+		validation.Field(&o.Start, validation.Min(0.0)),
+		validation.Field(&o.End, validation.When(o.End != 0,
+			validation.Min(o.Start).Exclusive().Error("end must be after start"))),
+	)
+	if err == nil {
+		return nil
+	}
+	return errors.Join(err, ErrInvalidInputData)
+}
+
+// ClipRequest cuts a range out of a video into a new video
+type ClipRequest struct {
+	Title string  `json:"title,omitempty"` // defaults to the parent title
+	Start float64 `json:"start"`           // seconds into the parent video
+	End   float64 `json:"end"`             // seconds, after start
+}
+
+func (c ClipRequest) Validate() error {
+	err := validation.ValidateStruct(&c,
+		validation.Field(&c.Title, validation.Length(0, 255)),
+		validation.Field(&c.Start, validation.Min(0.0)),
+		validation.Field(&c.End, validation.Required.Error("end is required"),
+			validation.Min(c.Start).Exclusive().Error("end must be after start")),
+	)
+	if err == nil {
+		return nil
+	}
+	return errors.Join(err, ErrInvalidInputData)
+}
+
+// SpeedRequest renders a video at another playback speed into a new video, from timelapses of
+// long recordings to slow motion
+type SpeedRequest struct {
+	Title string  `json:"title,omitempty"` // defaults to the parent title
+	Speed float64 `json:"speed"`           // factor from 0.1 (10 times slower) to 100 (100 times faster)
+	// DropAudio leaves the audio out, which happens anyway beyond the speeds audio stays
+	// intelligible at
+	DropAudio bool `json:"drop_audio,omitempty"`
+}
+
+func (s SpeedRequest) Validate() error {
+	err := validation.ValidateStruct(&s,
+		validation.Field(&s.Title, validation.Length(0, 255)),
+		validation.Field(&s.Speed, validation.Required.Error("speed is required"),
+			validation.Min(0.1), validation.Max(100.0),
+			validation.NotIn(1.0).Error("speed 1 leaves the video as it is")),
+	)
+	if err == nil {
+		return nil
+	}
+	return errors.Join(err, ErrInvalidInputData)
+}
+
+// AudiogramRequest turns an audio file into a video showing a still image,
+// or the audio waveform when no image is given.
+type AudiogramRequest struct {
+	Title       string                `form:"title" json:"-"`
+	Description string                `form:"description" json:"-"`
+	Audio       *multipart.FileHeader `form:"audio" json:"-"`
+	Image       *multipart.FileHeader `form:"image" json:"-"`
+
+	// set by the service once the files are stored
+	ImageKey string `form:"-" json:"image_key,omitempty"`
+}
+
+func (a AudiogramRequest) Validate() error {
+	err := validation.ValidateStruct(&a,
+		validation.Field(&a.Title, validation.Required.Error("title is required"), validation.Length(1, 255)),
+		validation.Field(&a.Audio, validation.Required.Error("audio is required")),
+	)
+	if err == nil {
+		return nil
+	}
+	return errors.Join(err, ErrInvalidInputData)
+}
+
+// MaxSlides caps the images of a slideshow
+const MaxSlides = 200
+
+// SlideshowRequest turns a set of images into a video showing them one after the other, over
+// an optional audio track.
+type SlideshowRequest struct {
+	Title       string                  `form:"title" json:"-"`
+	Description string                  `form:"description" json:"-"`
+	Images      []*multipart.FileHeader `form:"images" json:"-"`
+	Audio       *multipart.FileHeader   `form:"audio" json:"-"`
+	// SlideSeconds is how long each image is shown. When 0 the images share the length of the
+	// audio, or are shown for 5 seconds each without audio.
+	SlideSeconds float64 `form:"slide_seconds" json:"slide_seconds,omitempty"`
+
+	// set by the service once the files are stored
+	ImageKeys []string `form:"-" json:"image_keys,omitempty"`
+	AudioKey  string   `form:"-" json:"audio_key,omitempty"`
+}
+
+func (s SlideshowRequest) Validate() error {
+	err := validation.ValidateStruct(&s,
+		validation.Field(&s.Title, validation.Required.Error("title is required"), validation.Length(1, 255)),
+		validation.Field(&s.Images, validation.Required.Error("images are required"),
+			validation.Length(1, MaxSlides).Error(fmt.Sprintf("at most %d images are allowed", MaxSlides))),
+		validation.Field(&s.SlideSeconds, validation.When(s.SlideSeconds != 0, validation.Min(0.5), validation.Max(600.0))),
+	)
+	if err == nil {
+		return nil
+	}
+	return errors.Join(err, ErrInvalidInputData)
+}
+
+type DuplicateVideo struct {
+	ID     uuid.UUID `json:"id"`
+	UserID uuid.UUID `json:"user_id"`
+	Title  string    `json:"title"`
+}
+
+// DuplicateMatch is a pair of videos whose perceptual fingerprints match
+type DuplicateMatch struct {
+	Video      DuplicateVideo `json:"video"`
+	Duplicate  DuplicateVideo `json:"duplicate"`
+	Similarity float64        `json:"similarity"` // share of matching frames, 0-1
+}
+
+// VariantQuality aggregates the measured quality of one rung of the ladder
+type VariantQuality struct {
+	Variant        string  `json:"variant"`
+	Videos         int64   `json:"videos"` // number of measured renditions
+	AvgBitrateKbps float64 `json:"avg_bitrate_kbps"`
+	AvgVMAF        float64 `json:"avg_vmaf"`
+	MinVMAF        float64 `json:"min_vmaf"`
+	AvgPSNR        float64 `json:"avg_psnr"`
+}
+
+type DuplicateReportQuery struct {
+	MinSimilarity float64 `form:"min_similarity"`
+	CrossUserOnly bool    `form:"cross_user"`
+	Limit         int     `form:"limit"`
+}
+
+func (q DuplicateReportQuery) Validate() error {
+	err := validation.ValidateStruct(&q,
+		validation.Field(&q.MinSimilarity, validation.Min(0.0), validation.Max(1.0)),
+		validation.Field(&q.Limit, validation.Min(0), validation.Max(1000)),
+	)
+	if err == nil {
+		return nil
+	}
+	return errors.Join(err, ErrInvalidInputData)
+}
+
+// ProbeReport is the technical analysis of a video's source as reported by ffprobe
+type ProbeReport struct {
+	VideoID  uuid.UUID           `json:"video_id"`
+	ProbedAt time.Time           `json:"probed_at"`
+	Format   ProbeReportFormat   `json:"format"`
+	Streams  []ProbeReportStream `json:"streams"`
+}
+
+// ProbeReportFormat describes the container of the source
+type ProbeReportFormat struct {
+	Name            string  `json:"name"` // ffprobe format names, e.g. "mov,mp4,m4a,3gp,3g2,mj2"
+	DurationSeconds float64 `json:"duration_seconds"`
+	BitrateKbps     int64   `json:"bitrate_kbps"`
+	SizeBytes       int64   `json:"size_bytes"`
+}
+
+// ProbeReportStream describes one stream of the source. Fields that do not apply to the
+// stream type, or that the container does not signal, are left out.
+type ProbeReportStream struct {
+	Index          int     `json:"index"`
+	Type           string  `json:"type"` // video, audio, subtitle, data
+	Codec          string  `json:"codec"`
+	Profile        string  `json:"profile,omitempty"`
+	BitrateKbps    int64   `json:"bitrate_kbps,omitempty"`
+	Width          int     `json:"width,omitempty"`
+	Height         int     `json:"height,omitempty"`
+	PixelFormat    string  `json:"pixel_format,omitempty"`
+	FrameRate      float64 `json:"frame_rate,omitempty"`
+	ColorRange     string  `json:"color_range,omitempty"`
+	ColorSpace     string  `json:"color_space,omitempty"`
+	ColorTransfer  string  `json:"color_transfer,omitempty"`
+	ColorPrimaries string  `json:"color_primaries,omitempty"`
+	HDRFormat      string  `json:"hdr_format,omitempty"`
+	SampleRate     int     `json:"sample_rate,omitempty"`
+	Channels       int     `json:"channels,omitempty"`
+	ChannelLayout  string  `json:"channel_layout,omitempty"`
+	Language       string  `json:"language,omitempty"`
+	AttachedPic    bool    `json:"attached_pic,omitempty"` // embedded cover art rather than video
+}
+
+// FrameQuery picks the moment of a video a frame is extracted from
+type FrameQuery struct {
+	At *float64 `form:"t"` // seconds into the video
+}
+
+func (q FrameQuery) Validate() error {
+	err := validation.ValidateStruct(&q,
+		validation.Field(&q.At, validation.NotNil.Error("t is required"), validation.Min(0.0)),
+	)
+	if err == nil {
+		return nil
+	}
+	return errors.Join(err, ErrInvalidInputData)
+}
+
+// VideoFrame is a JPEG of a single frame of a video, stored for later requests of the same moment
+type VideoFrame struct {
+	At     float64 `json:"at"`     // seconds into the video
+	Source string  `json:"source"` // "source", or the name of the variant the frame was taken from
+	Key    string  `json:"key"`
+	URL    string  `json:"url"` // presigned URL of the JPEG
+}
+
+// S3Event is a bucket notification as MinIO sends it to webhook and queue targets
+type S3Event struct {
+	EventName string          `json:"EventName"`
+	Key       string          `json:"Key"`
+	Records   []S3EventRecord `json:"Records"`
+}
+
+// S3EventRecord is one object event of a bucket notification
+type S3EventRecord struct {
+	EventName string `json:"eventName"`
+	S3        struct {
+		Bucket struct {
+			Name string `json:"name"`
+		} `json:"bucket"`
+		Object struct {
+			Key         string `json:"key"` // URL encoded
+			Size        int64  `json:"size"`
+			ContentType string `json:"contentType"`
+			ETag        string `json:"eTag"`
+		} `json:"object"`
+	} `json:"s3"`
+}
+
+// IngestResult reports what an ingest notification led to
+type IngestResult struct {
+	Queued  []uuid.UUID `json:"queued"`
+	Skipped int         `json:"skipped"`
 }
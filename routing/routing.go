@@ -10,9 +10,10 @@ import (
 )
 
 type Handlers struct {
-	UserHandler  handlers.User
-	VideoHandler handlers.VideoProcessor
-	Middlewares  handlers.Middleware
+	UserHandler   handlers.User
+	VideoHandler  handlers.VideoProcessor
+	HealthHandler handlers.Health
+	Middlewares   handlers.Middleware
 }
 
 func RegisterRoutes(engine *gin.Engine, handlers Handlers) {
@@ -28,6 +29,12 @@ func RegisterRoutes(engine *gin.Engine, handlers Handlers) {
 			handler:     ginSwagger.WrapHandler(swaggerFiles.Handler),
 			middlewares: nil,
 		},
+		{
+			method:      http.MethodGet,
+			path:        "/health",
+			handler:     handlers.HealthHandler.Health,
+			middlewares: nil,
+		},
 		{
 			method:      http.MethodPost,
 			path:        "/register",
@@ -64,6 +71,396 @@ func RegisterRoutes(engine *gin.Engine, handlers Handlers) {
 			handler:     handlers.VideoHandler.Upload,
 			middlewares: []gin.HandlerFunc{handlers.Middlewares.Authenticate()},
 		},
+		{
+			method:      http.MethodGet,
+			path:        "/videos",
+			handler:     handlers.VideoHandler.ListVideos,
+			middlewares: []gin.HandlerFunc{handlers.Middlewares.Authenticate()},
+		},
+		{
+			method:      http.MethodGet,
+			path:        "/videos/:id",
+			handler:     handlers.VideoHandler.GetVideo,
+			middlewares: []gin.HandlerFunc{handlers.Middlewares.Authenticate()},
+		},
+		{
+			method:      http.MethodGet,
+			path:        "/videos/:id/progress",
+			handler:     handlers.VideoHandler.StreamProgress,
+			middlewares: []gin.HandlerFunc{handlers.Middlewares.Authenticate()},
+		},
+		{
+			method:      http.MethodGet,
+			path:        "/videos/:id/probe",
+			handler:     handlers.VideoHandler.ProbeVideo,
+			middlewares: []gin.HandlerFunc{handlers.Middlewares.Authenticate()},
+		},
+		{
+			method:      http.MethodGet,
+			path:        "/videos/:id/frame",
+			handler:     handlers.VideoHandler.ExtractFrame,
+			middlewares: []gin.HandlerFunc{handlers.Middlewares.Authenticate()},
+		},
+		{
+			method:      http.MethodGet,
+			path:        "/videos/:id/position",
+			handler:     handlers.VideoHandler.GetPosition,
+			middlewares: []gin.HandlerFunc{handlers.Middlewares.Authenticate()},
+		},
+		{
+			method:      http.MethodPost,
+			path:        "/videos/:id/position",
+			handler:     handlers.VideoHandler.SavePosition,
+			middlewares: []gin.HandlerFunc{handlers.Middlewares.Authenticate()},
+		},
+		{
+			method:      http.MethodGet,
+			path:        "/history",
+			handler:     handlers.VideoHandler.ListHistory,
+			middlewares: []gin.HandlerFunc{handlers.Middlewares.Authenticate()},
+		},
+		{
+			method:      http.MethodDelete,
+			path:        "/history",
+			handler:     handlers.VideoHandler.ClearHistory,
+			middlewares: []gin.HandlerFunc{handlers.Middlewares.Authenticate()},
+		},
+		{
+			method:      http.MethodDelete,
+			path:        "/history/:id",
+			handler:     handlers.VideoHandler.DeleteHistoryEntry,
+			middlewares: []gin.HandlerFunc{handlers.Middlewares.Authenticate()},
+		},
+		{
+			method:      http.MethodPut,
+			path:        "/videos/:id/visibility",
+			handler:     handlers.VideoHandler.SetVisibility,
+			middlewares: []gin.HandlerFunc{handlers.Middlewares.Authenticate()},
+		},
+		{
+			method:      http.MethodPut,
+			path:        "/videos/:id/schedule",
+			handler:     handlers.VideoHandler.SetSchedule,
+			middlewares: []gin.HandlerFunc{handlers.Middlewares.Authenticate()},
+		},
+		{
+			method:      http.MethodGet,
+			path:        "/videos/:id/translations",
+			handler:     handlers.VideoHandler.ListTranslations,
+			middlewares: []gin.HandlerFunc{handlers.Middlewares.Authenticate()},
+		},
+		{
+			method:      http.MethodPut,
+			path:        "/videos/:id/translations/:lang",
+			handler:     handlers.VideoHandler.SetTranslation,
+			middlewares: []gin.HandlerFunc{handlers.Middlewares.Authenticate()},
+		},
+		{
+			method:      http.MethodDelete,
+			path:        "/videos/:id/translations/:lang",
+			handler:     handlers.VideoHandler.DeleteTranslation,
+			middlewares: []gin.HandlerFunc{handlers.Middlewares.Authenticate()},
+		},
+		{
+			method:      http.MethodPut,
+			path:        "/videos/:id/password",
+			handler:     handlers.VideoHandler.SetPlaybackPassword,
+			middlewares: []gin.HandlerFunc{handlers.Middlewares.Authenticate()},
+		},
+		{
+			method:      http.MethodDelete,
+			path:        "/videos/:id/password",
+			handler:     handlers.VideoHandler.RemovePlaybackPassword,
+			middlewares: []gin.HandlerFunc{handlers.Middlewares.Authenticate()},
+		},
+		{
+			method:      http.MethodPost,
+			path:        "/videos/:id/playback",
+			handler:     handlers.VideoHandler.AuthorizePlayback,
+			middlewares: nil,
+		},
+		{
+			method:      http.MethodGet,
+			path:        "/videos/:id/playback",
+			handler:     handlers.VideoHandler.Playback,
+			middlewares: nil,
+		},
+		{
+			method:      http.MethodGet,
+			path:        "/videos/:id/key",
+			handler:     handlers.VideoHandler.GetVideoKey,
+			middlewares: []gin.HandlerFunc{handlers.Middlewares.AuthenticateOptional()},
+		},
+		{
+			method:      http.MethodGet,
+			path:        "/videos/:id/download",
+			handler:     handlers.VideoHandler.Download,
+			middlewares: []gin.HandlerFunc{handlers.Middlewares.Authenticate()},
+		},
+		{
+			method:      http.MethodGet,
+			path:        "/videos/:id/versions",
+			handler:     handlers.VideoHandler.ListRenditionVersions,
+			middlewares: []gin.HandlerFunc{handlers.Middlewares.Authenticate()},
+		},
+		{
+			method:      http.MethodPost,
+			path:        "/videos/:id/versions/:version/rollback",
+			handler:     handlers.VideoHandler.RollbackRenditions,
+			middlewares: []gin.HandlerFunc{handlers.Middlewares.Authenticate()},
+		},
+		{
+			method:      http.MethodGet,
+			path:        "/usage",
+			handler:     handlers.VideoHandler.GetUsage,
+			middlewares: []gin.HandlerFunc{handlers.Middlewares.Authenticate()},
+		},
+		{
+			method:      http.MethodGet,
+			path:        "/subscriptions",
+			handler:     handlers.VideoHandler.ListSubscriptions,
+			middlewares: []gin.HandlerFunc{handlers.Middlewares.Authenticate()},
+		},
+		{
+			method:      http.MethodPost,
+			path:        "/subscriptions/:id",
+			handler:     handlers.VideoHandler.Subscribe,
+			middlewares: []gin.HandlerFunc{handlers.Middlewares.Authenticate()},
+		},
+		{
+			method:      http.MethodDelete,
+			path:        "/subscriptions/:id",
+			handler:     handlers.VideoHandler.Unsubscribe,
+			middlewares: []gin.HandlerFunc{handlers.Middlewares.Authenticate()},
+		},
+		{
+			method:      http.MethodGet,
+			path:        "/feed",
+			handler:     handlers.VideoHandler.Feed,
+			middlewares: []gin.HandlerFunc{handlers.Middlewares.Authenticate()},
+		},
+		{
+			method:      http.MethodGet,
+			path:        "/notifications",
+			handler:     handlers.VideoHandler.ListNotifications,
+			middlewares: []gin.HandlerFunc{handlers.Middlewares.Authenticate()},
+		},
+		{
+			method:      http.MethodPost,
+			path:        "/notifications/read",
+			handler:     handlers.VideoHandler.MarkNotificationsRead,
+			middlewares: []gin.HandlerFunc{handlers.Middlewares.Authenticate()},
+		},
+		{
+			method:      http.MethodGet,
+			path:        "/notifications/preferences",
+			handler:     handlers.VideoHandler.GetNotificationPreferences,
+			middlewares: []gin.HandlerFunc{handlers.Middlewares.Authenticate()},
+		},
+		{
+			method:      http.MethodPut,
+			path:        "/notifications/preferences",
+			handler:     handlers.VideoHandler.SetNotificationPreferences,
+			middlewares: []gin.HandlerFunc{handlers.Middlewares.Authenticate()},
+		},
+		{
+			method:      http.MethodPut,
+			path:        "/users/watermark",
+			handler:     handlers.VideoHandler.SetWatermark,
+			middlewares: []gin.HandlerFunc{handlers.Middlewares.Authenticate()},
+		},
+		{
+			method:      http.MethodDelete,
+			path:        "/users/watermark",
+			handler:     handlers.VideoHandler.DeleteWatermark,
+			middlewares: []gin.HandlerFunc{handlers.Middlewares.Authenticate()},
+		},
+		{
+			method:      http.MethodPut,
+			path:        "/users/bumpers/:position",
+			handler:     handlers.VideoHandler.SetBumper,
+			middlewares: []gin.HandlerFunc{handlers.Middlewares.Authenticate()},
+		},
+		{
+			method:      http.MethodDelete,
+			path:        "/users/bumpers/:position",
+			handler:     handlers.VideoHandler.DeleteBumper,
+			middlewares: []gin.HandlerFunc{handlers.Middlewares.Authenticate()},
+		},
+		{
+			method:      http.MethodPost,
+			path:        "/users/export",
+			handler:     handlers.VideoHandler.ExportData,
+			middlewares: []gin.HandlerFunc{handlers.Middlewares.Authenticate()},
+		},
+		{
+			method:      http.MethodGet,
+			path:        "/users/export/:id",
+			handler:     handlers.VideoHandler.GetDataExport,
+			middlewares: []gin.HandlerFunc{handlers.Middlewares.Authenticate()},
+		},
+		{
+			method:      http.MethodPost,
+			path:        "/videos/:id/report",
+			handler:     handlers.VideoHandler.ReportVideo,
+			middlewares: []gin.HandlerFunc{handlers.Middlewares.Authenticate()},
+		},
+		{
+			method:      http.MethodGet,
+			path:        "/videos/:id/chapters",
+			handler:     handlers.VideoHandler.GetChapters,
+			middlewares: []gin.HandlerFunc{handlers.Middlewares.Authenticate()},
+		},
+		{
+			method:      http.MethodPut,
+			path:        "/videos/:id/chapters",
+			handler:     handlers.VideoHandler.SetChapters,
+			middlewares: []gin.HandlerFunc{handlers.Middlewares.Authenticate()},
+		},
+		{
+			method:      http.MethodPut,
+			path:        "/videos/:id/thumbnails/primary",
+			handler:     handlers.VideoHandler.SetPrimaryThumbnail,
+			middlewares: []gin.HandlerFunc{handlers.Middlewares.Authenticate()},
+		},
+		{
+			method:      http.MethodPost,
+			path:        "/videos/:id/edits",
+			handler:     handlers.VideoHandler.EditVideo,
+			middlewares: []gin.HandlerFunc{handlers.Middlewares.Authenticate()},
+		},
+		{
+			method:      http.MethodPost,
+			path:        "/videos/:id/derivatives",
+			handler:     handlers.VideoHandler.ChangeSpeed,
+			middlewares: []gin.HandlerFunc{handlers.Middlewares.Authenticate()},
+		},
+		{
+			method:      http.MethodPost,
+			path:        "/videos/:id/clips",
+			handler:     handlers.VideoHandler.CreateClip,
+			middlewares: []gin.HandlerFunc{handlers.Middlewares.Authenticate()},
+		},
+		{
+			method:      http.MethodPost,
+			path:        "/videos/:id/overlays",
+			handler:     handlers.VideoHandler.ComposeOverlay,
+			middlewares: []gin.HandlerFunc{handlers.Middlewares.Authenticate()},
+		},
+		{
+			method:      http.MethodPost,
+			path:        "/audiograms",
+			handler:     handlers.VideoHandler.CreateAudiogram,
+			middlewares: []gin.HandlerFunc{handlers.Middlewares.Authenticate()},
+		},
+		{
+			method:      http.MethodPost,
+			path:        "/slideshows",
+			handler:     handlers.VideoHandler.CreateSlideshow,
+			middlewares: []gin.HandlerFunc{handlers.Middlewares.Authenticate()},
+		},
+		{
+			method:      http.MethodGet,
+			path:        "/admin/videos/duplicates",
+			handler:     handlers.VideoHandler.ListDuplicates,
+			middlewares: []gin.HandlerFunc{handlers.Middlewares.Authenticate(), handlers.Middlewares.Authorize()},
+		},
+		{
+			method:      http.MethodGet,
+			path:        "/admin/videos/:id/probe",
+			handler:     handlers.VideoHandler.AdminProbeVideo,
+			middlewares: []gin.HandlerFunc{handlers.Middlewares.Authenticate(), handlers.Middlewares.Authorize()},
+		},
+		{
+			method:      http.MethodGet,
+			path:        "/admin/quality",
+			handler:     handlers.VideoHandler.QualityReport,
+			middlewares: []gin.HandlerFunc{handlers.Middlewares.Authenticate(), handlers.Middlewares.Authorize()},
+		},
+		{
+			method:      http.MethodGet,
+			path:        "/admin/presets",
+			handler:     handlers.VideoHandler.ListPresets,
+			middlewares: []gin.HandlerFunc{handlers.Middlewares.Authenticate(), handlers.Middlewares.Authorize()},
+		},
+		{
+			method:      http.MethodPost,
+			path:        "/admin/presets",
+			handler:     handlers.VideoHandler.CreatePreset,
+			middlewares: []gin.HandlerFunc{handlers.Middlewares.Authenticate(), handlers.Middlewares.Authorize()},
+		},
+		{
+			method:      http.MethodGet,
+			path:        "/admin/presets/:id",
+			handler:     handlers.VideoHandler.GetPreset,
+			middlewares: []gin.HandlerFunc{handlers.Middlewares.Authenticate(), handlers.Middlewares.Authorize()},
+		},
+		{
+			method:      http.MethodPut,
+			path:        "/admin/presets/:id",
+			handler:     handlers.VideoHandler.UpdatePreset,
+			middlewares: []gin.HandlerFunc{handlers.Middlewares.Authenticate(), handlers.Middlewares.Authorize()},
+		},
+		{
+			method:      http.MethodDelete,
+			path:        "/admin/presets/:id",
+			handler:     handlers.VideoHandler.DeletePreset,
+			middlewares: []gin.HandlerFunc{handlers.Middlewares.Authenticate(), handlers.Middlewares.Authorize()},
+		},
+		{
+			method:      http.MethodGet,
+			path:        "/admin/usage",
+			handler:     handlers.VideoHandler.ExportUsage,
+			middlewares: []gin.HandlerFunc{handlers.Middlewares.Authenticate(), handlers.Middlewares.Authorize()},
+		},
+		{
+			method:      http.MethodGet,
+			path:        "/admin/reprocess",
+			handler:     handlers.VideoHandler.ListReprocessRuns,
+			middlewares: []gin.HandlerFunc{handlers.Middlewares.Authenticate(), handlers.Middlewares.Authorize()},
+		},
+		{
+			method:      http.MethodPost,
+			path:        "/admin/reprocess",
+			handler:     handlers.VideoHandler.StartReprocess,
+			middlewares: []gin.HandlerFunc{handlers.Middlewares.Authenticate(), handlers.Middlewares.Authorize()},
+		},
+		{
+			method:      http.MethodGet,
+			path:        "/admin/reprocess/:id",
+			handler:     handlers.VideoHandler.GetReprocessRun,
+			middlewares: []gin.HandlerFunc{handlers.Middlewares.Authenticate(), handlers.Middlewares.Authorize()},
+		},
+		{
+			method:      http.MethodPost,
+			path:        "/admin/reprocess/:id/cancel",
+			handler:     handlers.VideoHandler.CancelReprocessRun,
+			middlewares: []gin.HandlerFunc{handlers.Middlewares.Authenticate(), handlers.Middlewares.Authorize()},
+		},
+		{
+			method:      http.MethodPost,
+			path:        "/admin/jobs/:id/boost",
+			handler:     handlers.VideoHandler.BoostJob,
+			middlewares: []gin.HandlerFunc{handlers.Middlewares.Authenticate(), handlers.Middlewares.Authorize()},
+		},
+		{
+			method:      http.MethodGet,
+			path:        "/admin/reports",
+			handler:     handlers.VideoHandler.ListReports,
+			middlewares: []gin.HandlerFunc{handlers.Middlewares.Authenticate(), handlers.Middlewares.Authorize()},
+		},
+		{
+			method:      http.MethodPost,
+			path:        "/admin/reports/:id/resolve",
+			handler:     handlers.VideoHandler.ModerateReport,
+			middlewares: []gin.HandlerFunc{handlers.Middlewares.Authenticate(), handlers.Middlewares.Authorize()},
+		},
+		{
+			method:      http.MethodPost,
+			path:        "/ingest/events",
+			handler:     handlers.VideoHandler.IngestEvents,
+			middlewares: nil,
+		},
 	}
 	group := engine.Group("v1")
 	group.Use(handlers.Middlewares.Cors())
@@ -0,0 +1,36 @@
+package user_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"video-processing/database/db"
+	"video-processing/mocks"
+	"video-processing/models"
+	"video-processing/services/user"
+	"video-processing/utils"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+// TestLoginWrongPassword runs against a mocked repository and needs no database
+func TestLoginWrongPassword(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	repo := mocks.NewMockUserRepo(ctrl)
+	u := user.NewUser(repo, nil)
+
+	hash, err := utils.HashPassword("correct-password1")
+	require.NoError(t, err)
+	repo.EXPECT().GetUserByEmail(gomock.Any(), "abebe@example.com").Return(db.User{
+		ID:       uuid.New(),
+		Email:    "abebe@example.com",
+		Password: hash,
+	}, nil)
+
+	_, err = u.Login(context.Background(), models.LoginRequest{Email: "abebe@example.com", Password: "wrong-password1"})
+	var e models.Error
+	require.ErrorAs(t, err, &e)
+	require.Equal(t, http.StatusUnauthorized, e.Code)
+}
@@ -0,0 +1,19 @@
+package user
+
+import (
+	"context"
+	"video-processing/database/db"
+
+	"github.com/google/uuid"
+)
+
+//go:generate mockgen -destination=../../mocks/user.go -package=mocks . UserRepo,UserService
+
+// UserRepo holds the user queries used by the user service
+type UserRepo interface {
+	CreateUser(ctx context.Context, arg db.CreateUserParams) (db.User, error)
+	GetUser(ctx context.Context, id uuid.UUID) (db.User, error)
+	GetUserByEmail(ctx context.Context, email string) (db.User, error)
+	SearchUsers(ctx context.Context, firstName string) ([]db.User, error)
+	UpdateUser(ctx context.Context, arg db.UpdateUserParams) (db.User, error)
+}
@@ -21,11 +21,11 @@ type UserService interface {
 }
 
 type user struct {
-	db           db.Queries
+	db           UserRepo
 	tokenManager utils.TokenManager
 }
 
-func NewUser(db db.Queries, tm utils.TokenManager) UserService {
+func NewUser(db UserRepo, tm utils.TokenManager) UserService {
 	return &user{
 		db:           db,
 		tokenManager: tm,
@@ -0,0 +1,260 @@
+package video
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"video-processing/database/db"
+	"video-processing/models"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Containers of the audio-only renditions, rows of the models.AudioVariantName variant
+const (
+	FormatM4A = "m4a"
+	FormatMP3 = "mp3"
+)
+
+// audioBitrate is the bitrate of the audio-only renditions, the AAC bitrate of the video variants
+const audioBitrate = "128k"
+
+// audioCodecs is the RFC 6381 codec of the audio-only HLS rendition, AAC-LC
+const audioCodecs = "mp4a.40.2"
+
+// audioGroup is the GROUP-ID of the audio tracks in master playlists
+const audioGroup = "audio"
+
+// audioVariant is the master playlist entry of the audio-only rendition
+var audioVariant = Variant{Name: models.AudioVariantName, Bitrate: audioBitrate, AudioOnly: true}
+
+// audioTrack is an audio track of a source with several, listed in the master playlists
+type audioTrack struct {
+	Language string
+	// URI is the audio-only playlist of the track relative to the master playlist, empty
+	// for the first track, which the video variants carry
+	URI string
+}
+
+// trackMapArgs keeps every audio track of a source with several, each with its language tag.
+// Without maps ffmpeg keeps a single audio track.
+func trackMapArgs(languages []string) []string {
+	if len(languages) < 2 {
+		return nil
+	}
+	return []string{"-map", "0:V:0", "-map", "0:a"}
+}
+
+// audioTrackNames are the NAMEs of the tracks in the master playlists, which must differ
+// within a group: the language, numbered when several tracks share it
+func audioTrackNames(tracks []audioTrack) []string {
+	count := map[string]int{}
+	for _, t := range tracks {
+		count[t.Language]++
+	}
+	seen := map[string]int{}
+	names := make([]string, len(tracks))
+	for i, t := range tracks {
+		seen[t.Language]++
+		names[i] = t.Language
+		if count[t.Language] > 1 {
+			names[i] = fmt.Sprintf("%s %d", t.Language, seen[t.Language])
+		}
+	}
+	return names
+}
+
+// audioPlaylistName is the playlist of a track in the audio directory, index.m3u8 for the
+// first one and track_N.m3u8 for the others
+func audioPlaylistName(track int) string {
+	if track == 0 {
+		return "index.m3u8"
+	}
+	return fmt.Sprintf("track_%d.m3u8", track)
+}
+
+// transcodeToM4A encodes the audio of the source into a stereo AAC m4a, with the index up
+// front so podcast players start before the whole file is downloaded. Every track is kept.
+func transcodeToM4A(ctx context.Context, t Transcoder, sourcePath, m4aPath string) error {
+	// ffmpeg -y -i input -map 0:a -vn -sn -dn -c:a aac -b:a 128k -ac 2 -movflags +faststart audio.m4a
+	args := []string{
+		"-y",
+		"-nostdin",
+		"-i", sourcePath,
+		"-map", "0:a",
+		"-vn", "-sn", "-dn",
+		"-c:a", "aac",
+		"-b:a", audioBitrate,
+		"-ac", "2",
+		"-movflags", "+faststart",
+		m4aPath,
+	}
+	if err := t.Run(ctx, args...); err != nil {
+		return fmt.Errorf("ffmpeg m4a error: %w", err)
+	}
+	return nil
+}
+
+// transcodeToMP3 encodes the first audio track of the source into a stereo mp3, which holds
+// a single track. It is encoded from the source rather than the m4a so the audio is not
+// compressed twice.
+func transcodeToMP3(ctx context.Context, t Transcoder, sourcePath, mp3Path string) error {
+	// ffmpeg -y -i input -map 0:a:0 -vn -sn -dn -c:a libmp3lame -b:a 128k -ac 2 audio.mp3
+	args := []string{
+		"-y",
+		"-nostdin",
+		"-i", sourcePath,
+		"-map", "0:a:0",
+		"-vn", "-sn", "-dn",
+		"-c:a", "libmp3lame",
+		"-b:a", audioBitrate,
+		"-ac", "2",
+		mp3Path,
+	}
+	if err := t.Run(ctx, args...); err != nil {
+		return fmt.Errorf("ffmpeg mp3 error: %w", err)
+	}
+	return nil
+}
+
+// generateAudioHLS packages a track of the m4a as audio-only HLS without re-encoding it,
+// encrypted with the key of keyInfo when set
+func generateAudioHLS(ctx context.Context, t Transcoder, m4aPath, outDir string, track int, keyInfo string) error {
+	segments := "segment_%03d.ts"
+	if track > 0 {
+		segments = fmt.Sprintf("track_%d_%%03d.ts", track)
+	}
+	args := []string{
+		"-y",
+		"-nostdin",
+		"-i", m4aPath,
+		"-map", fmt.Sprintf("0:a:%d", track),
+		"-c:a", "copy",
+		"-hls_time", strconv.Itoa(defaultSegmentSeconds),
+		"-hls_playlist_type", "vod",
+	}
+	args = append(args, hlsKeyArgs(keyInfo)...)
+	args = append(args,
+		"-hls_segment_filename", filepath.Join(outDir, segments),
+		filepath.Join(outDir, audioPlaylistName(track)),
+	)
+	if err := t.Run(ctx, args...); err != nil {
+		return fmt.Errorf("ffmpeg audio hls error: %w", err)
+	}
+	return nil
+}
+
+// processAudio encodes the audio-only renditions of a source with audio. The m4a row carries
+// the audio HLS playlist; the mp3 row, when enabled, is stored next to it. The tracks after
+// the first of a source with several are packaged as the alternate audio of the master
+// playlists.
+func (rc *redisConsumer) processAudio(ctx context.Context, task ProcessingTask, resultChan chan<- ProcessingResult) {
+	result := ProcessingResult{
+		Variant: audioVariant,
+		VideoID: task.VideoID,
+		WorkDir: task.WorkDir,
+		Success: true,
+		Codecs:  audioCodecs,
+	}
+	fail := func(err error) {
+		result.Success = false
+		result.Error = err
+		resultChan <- result
+	}
+
+	videoUUID, err := uuid.Parse(task.VideoID)
+	if err != nil {
+		fail(fmt.Errorf("invalid video ID: %w", err))
+		return
+	}
+	audioDir := filepath.Join(task.WorkDir, models.AudioVariantName)
+	if err := os.MkdirAll(audioDir, 0o755); err != nil {
+		fail(fmt.Errorf("failed to create audio directory: %w", err))
+		return
+	}
+	destPrefix := filepath.ToSlash(filepath.Join(task.DestPrefix, models.AudioVariantName))
+
+	m4aPath := filepath.Join(audioDir, models.AudioVariantName+".m4a")
+	if err := transcodeToM4A(ctx, rc.transcoder, task.SourcePath, m4aPath); err != nil {
+		fail(fmt.Errorf("audio transcode failed: %w", err))
+		return
+	}
+	if err := generateAudioHLS(ctx, rc.transcoder, m4aPath, audioDir, 0, task.KeyInfo); err != nil {
+		fail(fmt.Errorf("audio HLS generation failed: %w", err))
+		return
+	}
+	if len(task.AudioLanguages) > 1 {
+		result.AudioTracks = []audioTrack{{Language: task.AudioLanguages[0]}}
+		for track := 1; track < len(task.AudioLanguages); track++ {
+			if err := generateAudioHLS(ctx, rc.transcoder, m4aPath, audioDir, track, task.KeyInfo); err != nil {
+				// players keep the first track
+				rc.logger.Warn("audio track HLS generation failed", "error", err, "track", track, "videoID", task.VideoID)
+				continue
+			}
+			result.AudioTracks = append(result.AudioTracks, audioTrack{
+				Language: task.AudioLanguages[track],
+				URI:      models.AudioVariantName + "/" + audioPlaylistName(track),
+			})
+		}
+	}
+
+	// the mp3 is optional like the WebM renditions
+	mp3Path := filepath.Join(audioDir, models.AudioVariantName+".mp3")
+	mp3 := false
+	if rc.processing.AudioMP3 {
+		if err := transcodeToMP3(ctx, rc.transcoder, task.SourcePath, mp3Path); err != nil {
+			rc.logger.Warn("mp3 encode failed", "error", err, "videoID", task.VideoID)
+		} else {
+			mp3 = true
+		}
+	}
+
+	files, err := filepath.Glob(filepath.Join(audioDir, "*"))
+	if err != nil {
+		fail(fmt.Errorf("failed to list audio files: %w", err))
+		return
+	}
+	for _, file := range files {
+		if file == mp3Path && !mp3 {
+			continue
+		}
+		ext := filepath.Ext(file)
+		result.Files = append(result.Files, UploadTask{
+			SourcePath:  file,
+			ObjectKey:   filepath.ToSlash(filepath.Join(destPrefix, filepath.Base(file))),
+			ContentType: mimeTypeByExt(ext),
+			Bucket:      task.Bucket,
+		})
+	}
+
+	bitrate, _ := strconv.ParseInt(strings.TrimSuffix(audioBitrate, "k"), 10, 32)
+	result.Metadata = db.SaveProcessedVideoMetadataParams{
+		VideoID:        videoUUID,
+		VariantName:    models.AudioVariantName,
+		Bucket:         task.Bucket,
+		Key:            filepath.ToSlash(filepath.Join(destPrefix, models.AudioVariantName+".m4a")),
+		ContentType:    mimeTypeByExt(".m4a"),
+		HlsPlaylistKey: pgtype.Text{String: filepath.ToSlash(filepath.Join(destPrefix, "index.m3u8")), Valid: true},
+		BitrateKbps:    pgtype.Int4{Int32: int32(bitrate), Valid: true},
+		Format:         FormatM4A,
+		AudioLanguages: task.AudioLanguages,
+	}
+	if mp3 {
+		metadata := result.Metadata
+		metadata.Key = filepath.ToSlash(filepath.Join(destPrefix, models.AudioVariantName+".mp3"))
+		metadata.ContentType = mimeTypeByExt(".mp3")
+		metadata.HlsPlaylistKey = pgtype.Text{}
+		metadata.Format = FormatMP3
+		if len(task.AudioLanguages) > 1 {
+			metadata.AudioLanguages = task.AudioLanguages[:1]
+		}
+		result.MP3 = &metadata
+	}
+
+	rc.logger.Info("prepared audio rendition", "videoID", task.VideoID, "mp3", mp3)
+	resultChan <- result
+}
@@ -0,0 +1,166 @@
+package video
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"path"
+	"strings"
+	"testing"
+	"video-processing/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProcessAudio(t *testing.T) {
+	fake := NewFakeTranscoder()
+	rc := &redisConsumer{
+		logger:     slog.New(slog.NewTextHandler(io.Discard, nil)),
+		transcoder: fake,
+		processing: models.ProcessingConfig{AudioRendition: true, AudioMP3: true},
+	}
+	task := ProcessingTask{
+		WorkDir:    t.TempDir(),
+		SourcePath: "source.mp4",
+		DestPrefix: "processed/job",
+		Bucket:     "videos",
+		VideoID:    uuid.NewString(),
+	}
+	run := func() ProcessingResult {
+		results := make(chan ProcessingResult, 1)
+		rc.processAudio(context.Background(), task, results)
+		return <-results
+	}
+	keys := func(files []UploadTask) map[string]string {
+		types := map[string]string{}
+		for _, f := range files {
+			types[f.ObjectKey] = f.ContentType
+		}
+		return types
+	}
+
+	result := run()
+	require.True(t, result.Success, "%v", result.Error)
+	require.True(t, result.Variant.AudioOnly)
+	require.Equal(t, audioCodecs, result.Codecs)
+	require.Equal(t, map[string]string{
+		"processed/job/audio/audio.m4a":      "audio/mp4",
+		"processed/job/audio/audio.mp3":      "audio/mpeg",
+		"processed/job/audio/index.m3u8":     "application/vnd.apple.mpegurl",
+		"processed/job/audio/segment_000.ts": "video/mp2t",
+	}, keys(result.Files))
+	require.Equal(t, models.AudioVariantName, result.Metadata.VariantName)
+	require.Equal(t, FormatM4A, result.Metadata.Format)
+	require.Equal(t, "processed/job/audio/index.m3u8", result.Metadata.HlsPlaylistKey.String)
+	require.Equal(t, int32(128), result.Metadata.BitrateKbps.Int32)
+	require.False(t, result.Metadata.Width.Valid)
+	require.NotNil(t, result.MP3)
+	require.Equal(t, FormatMP3, result.MP3.Format)
+	require.Equal(t, "processed/job/audio/audio.mp3", result.MP3.Key)
+	require.False(t, result.MP3.HlsPlaylistKey.Valid)
+
+	// the HLS rendition is packaged from the m4a without re-encoding
+	var packaged bool
+	for _, call := range fake.Calls() {
+		if call[len(call)-1] == path.Join(task.WorkDir, "audio", "index.m3u8") {
+			packaged = true
+			require.Contains(t, call, path.Join(task.WorkDir, "audio", "audio.m4a"))
+			require.Contains(t, call, "copy")
+		}
+	}
+	require.True(t, packaged)
+
+	// a failed mp3 leaves the m4a
+	task.WorkDir = t.TempDir()
+	fake.FailOn = "libmp3lame"
+	result = run()
+	require.True(t, result.Success, "%v", result.Error)
+	require.Nil(t, result.MP3)
+	require.NotContains(t, keys(result.Files), "processed/job/audio/audio.mp3")
+
+	// without the m4a there is no audio rendition
+	task.WorkDir = t.TempDir()
+	fake.FailOn = "aac"
+	result = run()
+	require.False(t, result.Success)
+}
+
+func TestMasterPlaylistAudio(t *testing.T) {
+	results := []ProcessingResult{
+		{Variant: Variant{Name: "720p", Width: 1280, Height: 720, Bitrate: "2000k"}, Codecs: "avc1.64001f,mp4a.40.2"},
+		{Variant: audioVariant, Codecs: audioCodecs},
+	}
+	want := "#EXTM3U\n#EXT-X-VERSION:4\n#EXT-X-INDEPENDENT-SEGMENTS\n" +
+		"#EXT-X-STREAM-INF:BANDWIDTH=2128000,RESOLUTION=1280x720,CODECS=\"avc1.64001f,mp4a.40.2\"\n720p/index.m3u8\n" +
+		"#EXT-X-STREAM-INF:BANDWIDTH=128000,CODECS=\"mp4a.40.2\"\naudio/index.m3u8\n"
+	require.Equal(t, want, masterPlaylist(results))
+}
+
+func TestAudioLanguages(t *testing.T) {
+	probe := ProbeResult{Streams: []ProbeStream{
+		{CodecType: "video"},
+		{CodecType: "audio", Tags: map[string]string{"language": "eng"}},
+		{CodecType: "subtitle", Tags: map[string]string{"language": "deu"}},
+		{CodecType: "audio", Tags: map[string]string{"language": "FRA"}},
+		{CodecType: "audio"},
+	}}
+	require.Equal(t, []string{"eng", "fra", "und"}, probe.AudioLanguages())
+	require.Empty(t, ProbeResult{}.AudioLanguages())
+	require.Equal(t, []string{"eng", "fra", "und 1", "und 2"}, audioTrackNames([]audioTrack{
+		{Language: "eng"}, {Language: "fra"}, {Language: "und"}, {Language: "und"},
+	}))
+}
+
+func TestProcessAudioTracks(t *testing.T) {
+	fake := NewFakeTranscoder()
+	rc := &redisConsumer{logger: slog.New(slog.NewTextHandler(io.Discard, nil)), transcoder: fake}
+	task := ProcessingTask{
+		WorkDir:        t.TempDir(),
+		SourcePath:     "source.mkv",
+		DestPrefix:     "processed/job",
+		Bucket:         "videos",
+		VideoID:        uuid.NewString(),
+		AudioLanguages: []string{"eng", "spa", "und"},
+	}
+	results := make(chan ProcessingResult, 1)
+	rc.processAudio(context.Background(), task, results)
+	result := <-results
+	require.True(t, result.Success, "%v", result.Error)
+	require.Equal(t, []audioTrack{
+		{Language: "eng"},
+		{Language: "spa", URI: "audio/track_1.m3u8"},
+		{Language: "und", URI: "audio/track_2.m3u8"},
+	}, result.AudioTracks)
+	require.Equal(t, []string{"eng", "spa", "und"}, result.Metadata.AudioLanguages)
+	var keys []string
+	for _, f := range result.Files {
+		keys = append(keys, f.ObjectKey)
+	}
+	require.Subset(t, keys, []string{"processed/job/audio/track_1.m3u8", "processed/job/audio/track_1_000.ts", "processed/job/audio/track_2.m3u8"})
+
+	// the master playlist lists the tracks in an audio group the variants refer to
+	want := "#EXTM3U\n#EXT-X-VERSION:4\n#EXT-X-INDEPENDENT-SEGMENTS\n" +
+		"#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID=\"audio\",NAME=\"eng\",LANGUAGE=\"eng\",DEFAULT=YES,AUTOSELECT=YES\n" +
+		"#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID=\"audio\",NAME=\"spa\",LANGUAGE=\"spa\",DEFAULT=NO,AUTOSELECT=YES,URI=\"audio/track_1.m3u8\"\n" +
+		"#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID=\"audio\",NAME=\"und\",DEFAULT=NO,AUTOSELECT=YES,URI=\"audio/track_2.m3u8\"\n" +
+		"#EXT-X-STREAM-INF:BANDWIDTH=2128000,RESOLUTION=1280x720,AUDIO=\"audio\"\n720p/index.m3u8\n" +
+		"#EXT-X-STREAM-INF:BANDWIDTH=128000,CODECS=\"mp4a.40.2\",AUDIO=\"audio\"\naudio/index.m3u8\n"
+	require.Equal(t, want, masterPlaylist([]ProcessingResult{
+		{Variant: Variant{Name: "720p", Width: 1280, Height: 720, Bitrate: "2000k"}},
+		result,
+	}))
+}
+
+func TestTranscodeKeepsAudioTracks(t *testing.T) {
+	fake := NewFakeTranscoder()
+	task := ProcessingTask{Variant: testLadder.regular[1], SourcePath: "source.mkv", AudioLanguages: []string{"eng", "jpn"}}
+	require.NoError(t, transcodeToMP4(context.Background(), fake, task, path.Join(t.TempDir(), "720p.mp4")))
+	require.Contains(t, strings.Join(fake.Calls()[0], " "), "-map 0:V:0 -map 0:a -c:a aac")
+
+	// a single track is left to ffmpeg's stream selection
+	fake = NewFakeTranscoder()
+	task.AudioLanguages = []string{"eng"}
+	require.NoError(t, transcodeToMP4(context.Background(), fake, task, path.Join(t.TempDir(), "720p.mp4")))
+	require.NotContains(t, fake.Calls()[0], "-map")
+}
@@ -0,0 +1,75 @@
+package video
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+)
+
+// audiogram frame size, matching the top rung of the ladder
+const (
+	audiogramWidth  = 1920
+	audiogramHeight = 1080
+)
+
+// audiogramArgs builds the ffmpeg arguments that turn audioPath into a video.
+// With an image the picture is letterboxed into the frame and held for the length of
+// the audio, otherwise the audio waveform is drawn.
+func audiogramArgs(audioPath, imagePath, outPath string) []string {
+	args := []string{"-y", "-nostdin"}
+	if imagePath != "" {
+		args = append(args,
+			"-loop", "1",
+			"-framerate", "2",
+			"-i", imagePath,
+			"-i", audioPath,
+			"-vf", fmt.Sprintf("scale=%[1]d:%[2]d:force_original_aspect_ratio=decrease,pad=%[1]d:%[2]d:(ow-iw)/2:(oh-ih)/2,format=yuv420p", audiogramWidth, audiogramHeight),
+			"-map", "0:v",
+			"-map", "1:a",
+			"-tune", "stillimage",
+			"-shortest",
+		)
+	} else {
+		args = append(args,
+			"-i", audioPath,
+			"-filter_complex", fmt.Sprintf("[0:a]showwaves=s=%dx%d:mode=cline:rate=25:colors=white,format=yuv420p[v]", audiogramWidth, audiogramHeight),
+			"-map", "[v]",
+			"-map", "0:a",
+		)
+	}
+	return append(args,
+		"-c:v", "libx264",
+		"-crf", "18",
+		"-preset", "fast",
+		"-c:a", "aac",
+		"-movflags", "+faststart",
+		outPath,
+	)
+}
+
+// renderAudiogram writes an H.264/AAC MP4 built from an audio file and an optional image
+func renderAudiogram(ctx context.Context, t Transcoder, audioPath, imagePath, outPath string) error {
+	if err := t.Run(ctx, audiogramArgs(audioPath, imagePath, outPath)...); err != nil {
+		return fmt.Errorf("ffmpeg audiogram error: %w", err)
+	}
+	return nil
+}
+
+// RenderAudiogram renders the video of an audiogram job from its audio source and image,
+// then processes it like an uploaded video.
+func (rc *redisConsumer) RenderAudiogram(ctx context.Context, values map[string]interface{}) error {
+	return rc.renderDerived(ctx, values, func(ctx context.Context, job derivedJob) error {
+		a := job.Recipe.Audiogram
+		if a == nil {
+			return fmt.Errorf("audiogram job carries no audiogram recipe")
+		}
+		var imagePath string
+		if a.ImageKey != "" {
+			imagePath = filepath.Join(job.WorkDir, "image"+filepath.Ext(a.ImageKey))
+			if err := downloadFromMinio(ctx, rc.mc, job.Bucket, a.ImageKey, imagePath); err != nil {
+				return fmt.Errorf("failed to download audiogram image: %w", err)
+			}
+		}
+		return renderAudiogram(ctx, rc.transcoder, job.SourcePath, imagePath, job.OutPath)
+	})
+}
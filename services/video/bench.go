@@ -0,0 +1,208 @@
+package video
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+	"video-processing/models"
+)
+
+// benchDiskSampleInterval is how often the scratch directory is measured while a benchmark runs
+const benchDiskSampleInterval = 250 * time.Millisecond
+
+// BenchInput describes the synthetic source a pipeline benchmark runs against
+type BenchInput struct {
+	Duration time.Duration
+	Width    int
+	Height   int
+}
+
+func (in BenchInput) String() string {
+	return fmt.Sprintf("%dx%d/%s", in.Width, in.Height, in.Duration)
+}
+
+// BenchStage is the cost of one pipeline stage
+type BenchStage struct {
+	Name string
+	Wall time.Duration
+	CPU  time.Duration // user and system time spent in ffmpeg/ffprobe
+}
+
+// Cores is the average number of cores the stage kept busy
+func (s BenchStage) Cores() float64 {
+	if s.Wall <= 0 {
+		return 0
+	}
+	return s.CPU.Seconds() / s.Wall.Seconds()
+}
+
+// BenchReport is the outcome of one pipeline benchmark
+type BenchReport struct {
+	Input         BenchInput
+	Stages        []BenchStage
+	Wall          time.Duration
+	CPU           time.Duration
+	PeakCores     float64 // highest average core usage of any stage
+	PeakDiskBytes int64   // largest size the scratch directory reached
+}
+
+// RunBenchmark runs the processing stages of the worker one after another against a
+// synthetic source and reports the time spent in each. Nothing touches MinIO, Redis or
+// the database; all outputs stay in a scratch directory under workDir that is removed
+// afterwards. Stages run sequentially so their CPU usage can be told apart. The regular
+// variants of preset are encoded.
+func RunBenchmark(ctx context.Context, in BenchInput, workDir string, threads int, preset models.TranscodingPreset) (BenchReport, error) {
+	report := BenchReport{Input: in}
+	dir, err := os.MkdirTemp(workDir, "bench-*")
+	if err != nil {
+		return report, fmt.Errorf("failed to create bench directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	t := NewExecTranscoder(models.FFmpegConfig{})
+	sourcePath := filepath.Join(dir, "source.mp4")
+	if err := synthesizeSource(ctx, t, in, sourcePath); err != nil {
+		return report, err
+	}
+
+	stopSampling := make(chan struct{})
+	var sampling sync.WaitGroup
+	sampling.Add(1)
+	go func() {
+		defer sampling.Done()
+		report.PeakDiskBytes = samplePeakDiskUsage(dir, stopSampling)
+	}()
+	defer func() {
+		close(stopSampling)
+		sampling.Wait()
+	}()
+
+	stage := func(name string, fn func() error) error {
+		cpuBefore := childCPUTime()
+		start := time.Now()
+		if err := fn(); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		s := BenchStage{Name: name, Wall: time.Since(start), CPU: childCPUTime() - cpuBefore}
+		report.Stages = append(report.Stages, s)
+		report.Wall += s.Wall
+		report.CPU += s.CPU
+		report.PeakCores = max(report.PeakCores, s.Cores())
+		return nil
+	}
+
+	var duration float64
+	err = stage("probe", func() error {
+		probe, err := probeSource(ctx, t, sourcePath)
+		duration = probe.Duration()
+		return err
+	})
+	if err != nil {
+		return report, err
+	}
+
+	thumbnailAt, _ := thumbnailOffset(defaultThumbnailAt, duration)
+	for _, v := range newLadder(preset).regular {
+		task := ProcessingTask{Variant: v, WorkDir: dir, SourcePath: sourcePath, Threads: threads}
+		varDir := filepath.Join(dir, v.Name)
+		if err := os.MkdirAll(varDir, 0o755); err != nil {
+			return report, fmt.Errorf("failed to create variant directory: %w", err)
+		}
+		mp4Path := filepath.Join(varDir, v.Name+".mp4")
+		if err := stage("transcode "+v.Name, func() error { return transcodeToMP4(ctx, t, task, mp4Path) }); err != nil {
+			return report, err
+		}
+		if err := stage("hls "+v.Name, func() error { return generateHLS(ctx, t, mp4Path, varDir, v, threads, hlsOptions{StreamCopy: true}) }); err != nil {
+			return report, err
+		}
+		thumbPath := filepath.Join(varDir, v.Name+"-thumb.jpg")
+		if err := stage("thumbnail "+v.Name, func() error { return generateThumbnail(ctx, t, mp4Path, thumbPath, thumbnailAt) }); err != nil {
+			return report, err
+		}
+	}
+
+	steps := []struct {
+		name string
+		fn   func() error
+	}{
+		{"waveform", func() error { return generateWaveform(ctx, t, sourcePath, filepath.Join(dir, waveformFileName)) }},
+		{"preview", func() error {
+			return generatePreview(ctx, t, sourcePath, filepath.Join(dir, previewFileName), duration, "")
+		}},
+		{"fingerprint", func() error { _, err := generateFingerprint(ctx, t, sourcePath, duration); return err }},
+	}
+	for _, s := range steps {
+		if err := stage(s.name, s.fn); err != nil {
+			return report, err
+		}
+	}
+	return report, nil
+}
+
+// synthesizeSource encodes a test pattern with a sine tone, so benchmarks need no sample media
+func synthesizeSource(ctx context.Context, t Transcoder, in BenchInput, outPath string) error {
+	seconds := fmt.Sprintf("%.3f", in.Duration.Seconds())
+	// ffmpeg -f lavfi -i testsrc2=size=WxH:rate=30:duration=D -f lavfi -i sine=frequency=440:duration=D
+	//   -c:v libx264 -preset ultrafast -pix_fmt yuv420p -c:a aac out.mp4
+	args := []string{
+		"-y",
+		"-nostdin",
+		"-v", "error",
+		"-f", "lavfi", "-i", fmt.Sprintf("testsrc2=size=%dx%d:rate=30:duration=%s", in.Width, in.Height, seconds),
+		"-f", "lavfi", "-i", fmt.Sprintf("sine=frequency=440:duration=%s", seconds),
+		"-c:v", "libx264",
+		"-preset", "ultrafast",
+		"-pix_fmt", "yuv420p",
+		"-c:a", "aac",
+		"-shortest",
+		outPath,
+	}
+	if err := t.Run(ctx, args...); err != nil {
+		return fmt.Errorf("ffmpeg synthesize error: %w", err)
+	}
+	return nil
+}
+
+// samplePeakDiskUsage measures dir until stop is closed and returns the largest size seen
+func samplePeakDiskUsage(dir string, stop <-chan struct{}) int64 {
+	ticker := time.NewTicker(benchDiskSampleInterval)
+	defer ticker.Stop()
+	peak := dirSize(dir)
+	for {
+		select {
+		case <-stop:
+			return max(peak, dirSize(dir))
+		case <-ticker.C:
+			peak = max(peak, dirSize(dir))
+		}
+	}
+}
+
+// dirSize sums the sizes of the regular files under dir. Files vanishing during the walk are ignored.
+func dirSize(dir string) int64 {
+	var size int64
+	filepath.WalkDir(dir, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		if info, err := d.Info(); err == nil {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size
+}
+
+// childCPUTime is the user and system time used by all finished child processes so far
+func childCPUTime() time.Duration {
+	var ru syscall.Rusage
+	if err := syscall.Getrusage(syscall.RUSAGE_CHILDREN, &ru); err != nil {
+		return 0
+	}
+	return time.Duration(ru.Utime.Nano() + ru.Stime.Nano())
+}
@@ -0,0 +1,47 @@
+package video
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+	"video-processing/models"
+
+	"github.com/stretchr/testify/require"
+)
+
+// BenchmarkPipeline runs the full processing pipeline against synthetic sources.
+// go test -run '^$' -bench Pipeline -benchtime 1x ./services/video
+func BenchmarkPipeline(b *testing.B) {
+	if _, err := exec.LookPath("ffmpeg"); err != nil {
+		b.Skip("ffmpeg not installed")
+	}
+	inputs := []BenchInput{
+		{Duration: 10 * time.Second, Width: 1280, Height: 720},
+		{Duration: 10 * time.Second, Width: 1920, Height: 1080},
+		{Duration: 60 * time.Second, Width: 1920, Height: 1080},
+	}
+	for _, in := range inputs {
+		b.Run(in.String(), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				report, err := RunBenchmark(context.Background(), in, b.TempDir(), 0, models.DefaultPreset)
+				if err != nil {
+					b.Fatal(err)
+				}
+				b.ReportMetric(report.CPU.Seconds(), "cpu-s/op")
+				b.ReportMetric(report.PeakCores, "peak-cores")
+				b.ReportMetric(float64(report.PeakDiskBytes)/(1<<20), "peak-disk-MB")
+			}
+		})
+	}
+}
+
+func TestDirSize(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "720p"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "source.mp4"), make([]byte, 100), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "720p", "seg_000.ts"), make([]byte, 50), 0o644))
+	require.Equal(t, int64(150), dirSize(dir))
+}
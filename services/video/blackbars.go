@@ -0,0 +1,151 @@
+package video
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const (
+	cropDetectKey = "lavfi.cropdetect."
+	// cropDetectFrames is the number of frames sampled over the video to find the picture
+	cropDetectFrames = 60
+	// cropDetectLimit is the brightness, as a share of the full range so it holds for 10-bit
+	// sources as well, below which rows and columns count as black
+	cropDetectLimit = 0.094
+	// minBarShare is the share of the width or height the bars must take before they are
+	// cropped, so a few dark pixels at the edge of the picture are left alone
+	minBarShare = 0.02
+)
+
+// cropArea is the part of the frame that holds the picture, in the pixels of the displayed frame
+type cropArea struct {
+	Width, Height, X, Y int
+}
+
+// filter cuts the area out of the frame
+func (c cropArea) filter() string {
+	return fmt.Sprintf("crop=%d:%d:%d:%d", c.Width, c.Height, c.X, c.Y)
+}
+
+// cropDetectArgs print the picture area cropdetect finds in cropDetectFrames frames spread over
+// the video. cropdetect keeps growing the area over the frames it sees, so dark scenes do not
+// shrink it and the last frame reports the area of the whole video.
+func cropDetectArgs(inputPath string, durationSeconds float64) []string {
+	return []string{
+		"-nostdin",
+		"-v", "error",
+		"-i", inputPath,
+		"-map", "0:V:0",
+		"-an",
+		"-sn",
+		"-vf", fmt.Sprintf("fps=%d/%.3f,cropdetect=limit=%s:round=2:skip=0:reset=0,metadata=mode=print:file=-",
+			cropDetectFrames, durationSeconds, formatFactor(cropDetectLimit)),
+		"-frames:v", strconv.Itoa(cropDetectFrames),
+		"-f", "null",
+		"-",
+	}
+}
+
+// parseCropDetect returns the last area the metadata filter printed for cropdetect
+func parseCropDetect(r io.Reader) (cropArea, error) {
+	var area cropArea
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(strings.TrimSpace(scanner.Text()), "=")
+		if !ok {
+			continue
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			continue
+		}
+		switch key {
+		case cropDetectKey + "w":
+			area.Width = n
+		case cropDetectKey + "h":
+			area.Height = n
+		case cropDetectKey + "x":
+			area.X = n
+		case cropDetectKey + "y":
+			area.Y = n
+		}
+	}
+	return area, scanner.Err()
+}
+
+// blackBars returns the area to crop a width x height frame to, and false when the bars are too
+// thin to bother or the area makes no sense. An area of less than half the frame either way
+// is the detection failing on a dark video rather than bars.
+func blackBars(area cropArea, width, height int) (cropArea, bool) {
+	if width <= 0 || height <= 0 || area.Width <= 0 || area.Height <= 0 ||
+		area.X < 0 || area.Y < 0 || area.X+area.Width > width || area.Y+area.Height > height {
+		return cropArea{}, false
+	}
+	if area.Width*2 < width || area.Height*2 < height {
+		return cropArea{}, false
+	}
+	if float64(width-area.Width) < float64(width)*minBarShare && float64(height-area.Height) < float64(height)*minBarShare {
+		return cropArea{}, false
+	}
+	return area, true
+}
+
+// detectBlackBars finds the letterbox or pillarbox bars of a width x height source
+func detectBlackBars(ctx context.Context, t Transcoder, inputPath string, durationSeconds float64, width, height int) (cropArea, bool, error) {
+	if durationSeconds <= 0 {
+		return cropArea{}, false, fmt.Errorf("unknown source duration")
+	}
+	var area cropArea
+	err := t.Stream(ctx, func(r io.Reader) (err error) {
+		area, err = parseCropDetect(r)
+		return err
+	}, cropDetectArgs(inputPath, durationSeconds)...)
+	if err != nil {
+		return cropArea{}, false, fmt.Errorf("ffmpeg cropdetect error: %w", err)
+	}
+	area, ok := blackBars(area, width, height)
+	return area, ok, nil
+}
+
+// cropChain puts the crop of the task's black bars in front of the video filter chain vf, so
+// tone mapping and scaling only see the picture
+func cropChain(vf string, task ProcessingTask) string {
+	if task.Crop == nil {
+		return vf
+	}
+	return task.Crop.filter() + "," + vf
+}
+
+// fitLadder fits the regular variants to the aspect of a cropped width x height picture, so a
+// letterboxed film is not stretched to 16:9 again. Each variant keeps the side that bounds the
+// picture and the other shrinks, so no variant grows. Vertical variants crop a window of their
+// own and keep their size.
+func fitLadder(variants []Variant, width, height int) []Variant {
+	if width <= 0 || height <= 0 {
+		return variants
+	}
+	even := func(f float64) int { return max(2, int(f/2+0.5)*2) }
+	fitted := make([]Variant, len(variants))
+	for i, v := range variants {
+		if !v.Vertical {
+			if width*v.Height > height*v.Width {
+				v.Height = even(float64(v.Width) * float64(height) / float64(width))
+			} else {
+				v.Width = even(float64(v.Height) * float64(width) / float64(height))
+			}
+		}
+		fitted[i] = v
+	}
+	return fitted
+}
+
+// cropRequested is true when the job asks for the black bars of its source to be cropped
+func cropRequested(values map[string]interface{}) bool {
+	s, _ := values["crop_black_bars"].(string)
+	on, _ := strconv.ParseBool(s)
+	return on
+}
@@ -0,0 +1,84 @@
+package video
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCropDetect(t *testing.T) {
+	out := "frame:0    pts:0       pts_time:0\n" +
+		cropDetectKey + "x1=0\n" + cropDetectKey + "w=1920\n" + cropDetectKey + "h=1072\n" + cropDetectKey + "x=0\n" + cropDetectKey + "y=4\n" +
+		"frame:1    pts:90000   pts_time:1\n" +
+		cropDetectKey + "w=1920\n" + cropDetectKey + "h=800\n" + cropDetectKey + "x=0\n" + cropDetectKey + "y=140\n"
+	area, err := parseCropDetect(strings.NewReader(out))
+	require.NoError(t, err)
+	require.Equal(t, cropArea{Width: 1920, Height: 800, X: 0, Y: 140}, area)
+}
+
+func TestBlackBars(t *testing.T) {
+	// a 2.39:1 film letterboxed into 1080p
+	area, ok := blackBars(cropArea{Width: 1920, Height: 800, Y: 140}, 1920, 1080)
+	require.True(t, ok)
+	require.Equal(t, "crop=1920:800:0:140", area.filter())
+	// a 4:3 picture pillarboxed into 1080p
+	_, ok = blackBars(cropArea{Width: 1440, Height: 1080, X: 240}, 1920, 1080)
+	require.True(t, ok)
+
+	// a few dark rows at the edge are left alone
+	_, ok = blackBars(cropArea{Width: 1920, Height: 1072, Y: 4}, 1920, 1080)
+	require.False(t, ok)
+	// a mostly dark video is not cropped to its bright corner
+	_, ok = blackBars(cropArea{Width: 640, Height: 360, X: 100, Y: 100}, 1920, 1080)
+	require.False(t, ok)
+	_, ok = blackBars(cropArea{Width: 1920, Height: 800, Y: 400}, 1920, 1080)
+	require.False(t, ok)
+	_, ok = blackBars(cropArea{}, 1920, 1080)
+	require.False(t, ok)
+}
+
+func TestFitLadder(t *testing.T) {
+	variants := append(slices.Clone(testLadder.regular[:3]), testLadder.vertical[0])
+	var sizes []string
+	for _, v := range fitLadder(variants, 1920, 800) {
+		sizes = append(sizes, fmt.Sprintf("%s=%dx%d", v.Name, v.Width, v.Height))
+	}
+	require.Equal(t, []string{"1080p=1920x800", "720p=1280x534", "480p=854x356", "1080p-vertical=1080x1920"}, sizes)
+
+	// pillarboxed pictures keep the height
+	fitted := fitLadder(testLadder.regular[:1], 1440, 1080)
+	require.Equal(t, 1440, fitted[0].Width)
+	require.Equal(t, 1080, fitted[0].Height)
+}
+
+func TestTranscodeToMP4Crop(t *testing.T) {
+	fake := NewFakeTranscoder()
+	task := ProcessingTask{Variant: Variant{Name: "720p", Width: 1280, Height: 534, Bitrate: "2000k"}, SourcePath: "source.mp4",
+		Crop: &cropArea{Width: 1920, Height: 800, Y: 140}, Deinterlace: true, HDRFormat: HDRFormatHDR10}
+	require.NoError(t, transcodeToMP4(context.Background(), fake, task, path.Join(t.TempDir(), "720p.mp4")))
+	// the bars go after deinterlacing, before tone mapping and scaling
+	require.Contains(t, fake.Calls()[0], "yadif,crop=1920:800:0:140,"+toneMapFilter+",scale=1280:534")
+
+	// the ladder crops once for every variant
+	args := strings.Join(ladderArgs([]ProcessingTask{task}, []string{"720p.mp4"}), " ")
+	require.Contains(t, args, "[0:V:0]yadif,crop=1920:800:0:140,split=1")
+	require.Equal(t, 1, strings.Count(args, "crop="))
+}
+
+func TestDetectBlackBars(t *testing.T) {
+	fake := NewFakeTranscoder()
+	fake.StreamOutput = []byte(cropDetectKey + "w=1440\n" + cropDetectKey + "h=1080\n" + cropDetectKey + "x=240\n" + cropDetectKey + "y=0\n")
+	area, ok, err := detectBlackBars(context.Background(), fake, "source.mp4", 120, 1920, 1080)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, cropArea{Width: 1440, Height: 1080, X: 240}, area)
+	require.Contains(t, fake.Calls()[0], "fps=60/120.000,cropdetect=limit=0.094:round=2:skip=0:reset=0,metadata=mode=print:file=-")
+
+	_, _, err = detectBlackBars(context.Background(), fake, "source.mp4", 0, 1920, 1080)
+	require.Error(t, err)
+}
@@ -0,0 +1,275 @@
+package video
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"video-processing/models"
+
+	"github.com/google/uuid"
+	"github.com/minio/minio-go/v7"
+)
+
+// bumperKeys are where the bumpers of a user are stored in their storage, by position
+var bumperKeys = map[string]string{
+	models.BumperIntro: "branding/intro",
+	models.BumperOutro: "branding/outro",
+}
+
+// Audio of the joined video when the source does not say
+const (
+	defaultBumperSampleRate = "48000"
+	defaultBumperLayout     = "stereo"
+)
+
+// bumper is an intro or outro clip downloaded for a job
+type bumper struct {
+	Path  string
+	Probe ProbeResult
+}
+
+// skipBumpers is true when the job asks for its video without intro and outro
+func skipBumpers(values map[string]interface{}) bool {
+	s, _ := values["skip_bumpers"].(string)
+	skip, _ := strconv.ParseBool(s)
+	return skip
+}
+
+// bumperAudio returns the sample rate and channel layout of the first audio track of the
+// source, which the audio of the bumpers is converted to
+func bumperAudio(probe ProbeResult) (sampleRate, layout string) {
+	sampleRate, layout = defaultBumperSampleRate, defaultBumperLayout
+	for _, s := range probe.Streams {
+		if s.CodecType != "audio" {
+			continue
+		}
+		if s.SampleRate != "" {
+			sampleRate = s.SampleRate
+		}
+		if s.ChannelLayout != "" {
+			layout = s.ChannelLayout
+		}
+		break
+	}
+	return sampleRate, layout
+}
+
+// bumperArgs join intro, the source and outro, either bumper nil for none, into a Matroska
+// file. The concat filter needs its segments alike, so every segment is scaled and padded to
+// the displayed size of the source, at its frame rate, and the audio is converted to the sample
+// rate and layout of the first audio track of the source. Bumpers without audio get silence,
+// and sources without audio drop the audio of the bumpers. The joined video is encoded close to
+// lossless like a trim, see cutVideoArgs, and its audio kept lossless as FLAC.
+func bumperArgs(probe ProbeResult, sourcePath, outPath string, intro, outro *bumper) []string {
+	stream, _ := probe.VideoStream()
+	width, height := stream.DisplaySize()
+	video := fmt.Sprintf("scale=%d:%d:force_original_aspect_ratio=decrease,pad=%d:%d:(ow-iw)/2:(oh-ih)/2,setsar=1", width, height, width, height)
+	if rate := stream.RFrameRate; rate != "" && rate != "0/0" {
+		video += ",fps=" + rate
+	}
+	video += ",format=yuv420p"
+	hasAudio := probe.HasAudio()
+	sampleRate, layout := bumperAudio(probe)
+	audio := fmt.Sprintf("aformat=sample_rates=%s:channel_layouts=%s", sampleRate, layout)
+
+	args := []string{"-y", "-nostdin"}
+	var graph []string
+	var pads string
+	input, sourceInput := 0, 0
+	segment := func(path string, segmentProbe ProbeResult, prefix string) {
+		args = append(args, "-i", path)
+		graph = append(graph, fmt.Sprintf("[%d:V:0]%s%s[v%d]", input, prefix, video, input))
+		pads += fmt.Sprintf("[v%d]", input)
+		if hasAudio {
+			if segmentProbe.HasAudio() {
+				graph = append(graph, fmt.Sprintf("[%d:a:0]%s[a%d]", input, audio, input))
+			} else {
+				graph = append(graph, fmt.Sprintf("anullsrc=r=%s:cl=%s,atrim=duration=%s,%s[a%d]",
+					sampleRate, layout, formatFactor(segmentProbe.Duration()), audio, input))
+			}
+			pads += fmt.Sprintf("[a%d]", input)
+		}
+		input++
+	}
+	if intro != nil {
+		segment(intro.Path, intro.Probe, "")
+	}
+	sourceInput = input
+	segment(sourcePath, probe, deinterlacePrefix(probe))
+	if outro != nil {
+		segment(outro.Path, outro.Probe, "")
+	}
+
+	streams := 0
+	if hasAudio {
+		streams = 1
+	}
+	concat := fmt.Sprintf("%sconcat=n=%d:v=1:a=%d[v]", pads, input, streams)
+	if hasAudio {
+		concat += "[a]"
+	}
+	args = append(args, "-filter_complex", strings.Join(append(graph, concat), ";"), "-map", "[v]")
+	if hasAudio {
+		args = append(args, "-map", "[a]")
+	}
+	// the chapters of the source would be off by the length of the intro
+	args = append(args, "-map_metadata", strconv.Itoa(sourceInput), "-map_chapters", "-1")
+	args = append(args, cutVideoArgs(ProbeResult{}, "")...)
+	if hasAudio {
+		args = append(args, "-c:a", "flac")
+	}
+	return append(args, outPath)
+}
+
+// bumperLocation is where the bumper of the job at position is stored: the owner's when the job
+// names one, otherwise the platform's. An empty key means the job has none.
+func (rc *redisConsumer) bumperLocation(values map[string]interface{}, position string) (bucket, key string) {
+	if userKey, _ := values[position+"_key"].(string); userKey != "" {
+		bucket, _ = values[position+"_bucket"].(string)
+		return bucket, userKey
+	}
+	config := rc.processing.Bumpers.Intro
+	if position == models.BumperOutro {
+		config = rc.processing.Bumpers.Outro
+	}
+	return config.Bucket, config.Key
+}
+
+// loadBumper fetches the bumper of the job at position into workDir. Nil when there is none or
+// it cannot be fetched or read, which is logged.
+func (rc *redisConsumer) loadBumper(ctx context.Context, values map[string]interface{}, position, workDir string) *bumper {
+	bucket, key := rc.bumperLocation(values, position)
+	if key == "" {
+		return nil
+	}
+	b := &bumper{Path: filepath.Join(workDir, position)}
+	if err := downloadFromMinio(ctx, rc.mc, bucket, key, b.Path); err != nil {
+		rc.logger.Error("failed to download bumper, processing without it", "error", err, "position", position, "key", key, "videoID", values["video_id"])
+		return nil
+	}
+	probe, err := probeSource(ctx, rc.transcoder, b.Path)
+	if err == nil {
+		err = validateSource(probe, nil)
+	}
+	if err != nil {
+		rc.logger.Error("unusable bumper, processing without it", "error", err, "position", position, "key", key, "videoID", values["video_id"])
+		return nil
+	}
+	b.Probe = probe
+	return b
+}
+
+// attachBumpers joins the intro and the outro of the job to the source and returns the path of
+// the joined video, which the rest of the job reads in place of the source. The source is
+// returned as it is when the job has no bumpers or skips them, when it cannot be probed, which
+// checkSource reports, for HDR and 360° sources, whose picture the SDR bumpers would spoil, and
+// when the join fails, which is logged.
+func (rc *redisConsumer) attachBumpers(ctx context.Context, values map[string]interface{}, sourcePath, workDir string) string {
+	if skipBumpers(values) {
+		return sourcePath
+	}
+	_, introKey := rc.bumperLocation(values, models.BumperIntro)
+	_, outroKey := rc.bumperLocation(values, models.BumperOutro)
+	if introKey == "" && outroKey == "" {
+		return sourcePath
+	}
+	videoID := values["video_id"]
+	probe, err := probeSource(ctx, rc.transcoder, sourcePath)
+	if err != nil {
+		return sourcePath
+	}
+	stream, ok := probe.VideoStream()
+	if !ok {
+		return sourcePath
+	}
+	if _, _, spherical := stream.Spherical(); spherical || stream.HDRFormat() != "" {
+		rc.logger.Info("HDR or 360° source, processing without bumpers", "videoID", videoID)
+		return sourcePath
+	}
+	intro := rc.loadBumper(ctx, values, models.BumperIntro, workDir)
+	outro := rc.loadBumper(ctx, values, models.BumperOutro, workDir)
+	if intro == nil && outro == nil {
+		return sourcePath
+	}
+
+	rc.logger.Info("joining bumpers", "videoID", videoID, "intro", intro != nil, "outro", outro != nil)
+	outPath := filepath.Join(workDir, "bumpered.mkv")
+	if err := rc.transcoder.Run(ctx, bumperArgs(probe, sourcePath, outPath, intro, outro)...); err != nil {
+		rc.logger.Error("failed to join bumpers, processing without them", "error", fmt.Errorf("ffmpeg bumper error: %w", err), "videoID", videoID)
+		return sourcePath
+	}
+	return outPath
+}
+
+// SetBumper stores the intro or the outro of the user, joined to the videos they upload from
+// now on
+func (vp *videoProcessor) SetBumper(ctx context.Context, userID uuid.UUID, req models.BumperRequest) error {
+	params := fmt.Sprintf("userID: %v, position: %v", userID, req.Position)
+	if err := req.Validate(); err != nil {
+		return models.Error{
+			Code:    http.StatusBadRequest,
+			Message: "invalid input data",
+			Params:  params,
+			Err:     err,
+		}
+	}
+	if contentType := req.Video.Header.Get("Content-Type"); !strings.HasPrefix(contentType, "video/") {
+		return models.Error{
+			Code:    http.StatusBadRequest,
+			Message: "invalid input data",
+			Params:  params,
+			Err:     errors.Join(fmt.Errorf("unsupported bumper type %q, want a video", contentType), models.ErrInvalidInputData),
+		}
+	}
+	bucket, key := vp.layout.Place(userID, bumperKeys[req.Position])
+	if err := vp.ensureBucket(ctx, bucket); err != nil {
+		return err
+	}
+	return vp.storeFormFile(ctx, bucket, key, req.Video)
+}
+
+// DeleteBumper removes the intro or the outro of the user; their later videos get the platform
+// bumper, if any
+func (vp *videoProcessor) DeleteBumper(ctx context.Context, userID uuid.UUID, position string) error {
+	params := fmt.Sprintf("userID: %v, position: %v", userID, position)
+	key, ok := bumperKeys[position]
+	if !ok {
+		return models.Error{
+			Code:    http.StatusBadRequest,
+			Message: "invalid input data",
+			Params:  params,
+			Err:     errors.Join(fmt.Errorf("unknown bumper position %q, want intro or outro", position), models.ErrInvalidInputData),
+		}
+	}
+	bucket, key := vp.layout.Place(userID, key)
+	if err := vp.minioClient.RemoveObject(ctx, bucket, key, minio.RemoveObjectOptions{}); err != nil && !missingObject(err) {
+		return models.Error{
+			Code:        http.StatusInternalServerError,
+			Message:     "internal server error",
+			Description: "failed to delete bumper",
+			Params:      params,
+			Err:         err,
+		}
+	}
+	return nil
+}
+
+// addBumpers names the intro and the outro of the owner in job when they uploaded them
+func (vp *videoProcessor) addBumpers(ctx context.Context, userID uuid.UUID, job map[string]interface{}) error {
+	for _, position := range []string{models.BumperIntro, models.BumperOutro} {
+		bucket, key := vp.layout.Place(userID, bumperKeys[position])
+		if _, err := vp.minioClient.StatObject(ctx, bucket, key, minio.StatObjectOptions{}); err != nil {
+			if missingObject(err) {
+				continue
+			}
+			return fmt.Errorf("failed to look up %s: %w", position, err)
+		}
+		job[position+"_bucket"] = bucket
+		job[position+"_key"] = key
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package video
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"path"
+	"strings"
+	"testing"
+	"video-processing/mocks"
+	"video-processing/models"
+
+	"github.com/google/uuid"
+	"github.com/minio/minio-go/v7"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestBumperArgs(t *testing.T) {
+	source := ProbeResult{
+		Format: ProbeFormat{Duration: "60"},
+		Streams: []ProbeStream{
+			{CodecType: "video", CodecName: "h264", Width: 1920, Height: 1080, RFrameRate: "30000/1001"},
+			{CodecType: "audio", CodecName: "aac", SampleRate: "44100", ChannelLayout: "5.1"},
+		},
+	}
+	intro := &bumper{Path: "intro", Probe: ProbeResult{
+		Format:  ProbeFormat{Duration: "4"},
+		Streams: []ProbeStream{{CodecType: "video", CodecName: "h264", Width: 1280, Height: 720}, {CodecType: "audio", CodecName: "aac"}},
+	}}
+	outro := &bumper{Path: "outro", Probe: ProbeResult{
+		Format:  ProbeFormat{Duration: "2.5"},
+		Streams: []ProbeStream{{CodecType: "video", CodecName: "png", Width: 1080, Height: 1080}},
+	}}
+
+	video := "scale=1920:1080:force_original_aspect_ratio=decrease,pad=1920:1080:(ow-iw)/2:(oh-ih)/2,setsar=1,fps=30000/1001,format=yuv420p"
+	audio := "aformat=sample_rates=44100:channel_layouts=5.1"
+	args := bumperArgs(source, "source.mp4", "bumpered.mkv", intro, outro)
+	require.Equal(t, []string{"-i", "intro", "-i", "source.mp4", "-i", "outro"}, args[2:8])
+	require.Equal(t, strings.Join([]string{
+		"[0:V:0]" + video + "[v0]",
+		"[0:a:0]" + audio + "[a0]",
+		"[1:V:0]" + video + "[v1]",
+		"[1:a:0]" + audio + "[a1]",
+		"[2:V:0]" + video + "[v2]",
+		// the outro has no audio of its own
+		"anullsrc=r=44100:cl=5.1,atrim=duration=2.5," + audio + "[a2]",
+		"[v0][a0][v1][a1][v2][a2]concat=n=3:v=1:a=1[v][a]",
+	}, ";"), args[9])
+	require.Equal(t, []string{"-map", "[v]", "-map", "[a]", "-map_metadata", "1", "-map_chapters", "-1"}, args[10:18])
+	require.Equal(t, []string{"-c:a", "flac", "bumpered.mkv"}, args[len(args)-3:])
+
+	// silent sources drop the audio of the bumpers
+	source.Streams = source.Streams[:1]
+	args = bumperArgs(source, "source.mp4", "bumpered.mkv", nil, intro)
+	require.Equal(t, "[0:V:0]"+video+"[v0];[1:V:0]"+video+"[v1];[v0][v1]concat=n=2:v=1:a=0[v]", args[7])
+	require.Contains(t, strings.Join(args, " "), "-map_metadata 0")
+	require.NotContains(t, args, "flac")
+}
+
+func TestAttachBumpers(t *testing.T) {
+	store := mocks.NewMockObjectStore(gomock.NewController(t))
+	fake := NewFakeTranscoder()
+	fake.ProbeOutput = []byte(`{"streams":[{"codec_type":"video","codec_name":"h264","width":1280,"height":720},{"codec_type":"audio","codec_name":"aac"}],"format":{"duration":"30.0"}}`)
+	rc := &redisConsumer{
+		logger:     slog.New(slog.NewTextHandler(io.Discard, nil)),
+		mc:         store,
+		transcoder: fake,
+		processing: models.ProcessingConfig{Bumpers: models.BumpersConfig{
+			Intro: models.BumperConfig{Bucket: "branding", Key: "intro.mp4"},
+		}},
+	}
+	workDir := t.TempDir()
+	ctx := context.Background()
+
+	// the platform intro and the owner's outro
+	store.EXPECT().FGetObject(gomock.Any(), "branding", "intro.mp4", path.Join(workDir, models.BumperIntro), gomock.Any())
+	store.EXPECT().FGetObject(gomock.Any(), "user", bumperKeys[models.BumperOutro], path.Join(workDir, models.BumperOutro), gomock.Any())
+	values := map[string]interface{}{"outro_bucket": "user", "outro_key": bumperKeys[models.BumperOutro]}
+	require.Equal(t, path.Join(workDir, "bumpered.mkv"), rc.attachBumpers(ctx, values, "source.mp4", workDir))
+	require.Len(t, fake.Calls(), 4)
+	require.Contains(t, strings.Join(fake.Calls()[3], " "), "[v0][a0][v1][a1][v2][a2]concat=n=3:v=1:a=1[v][a]")
+	require.FileExists(t, path.Join(workDir, "bumpered.mkv"))
+
+	// uploads can leave them out
+	values["skip_bumpers"] = "true"
+	require.Equal(t, "source.mp4", rc.attachBumpers(ctx, values, "source.mp4", workDir))
+	require.Len(t, fake.Calls(), 4)
+
+	// HDR sources are not joined to SDR bumpers
+	fake.ProbeOutput = []byte(`{"streams":[{"codec_type":"video","codec_name":"hevc","width":3840,"height":2160,"color_transfer":"smpte2084"}],"format":{"duration":"30.0"}}`)
+	require.Equal(t, "source.mp4", rc.attachBumpers(ctx, map[string]interface{}{}, "source.mp4", workDir))
+	require.Len(t, fake.Calls(), 5)
+}
+
+func TestAddBumpers(t *testing.T) {
+	store := mocks.NewMockObjectStore(gomock.NewController(t))
+	vp := &videoProcessor{minioClient: store}
+	userID := uuid.New()
+
+	job := map[string]interface{}{}
+	store.EXPECT().StatObject(gomock.Any(), userID.String(), bumperKeys[models.BumperIntro], gomock.Any()).Return(minio.ObjectInfo{}, minio.ErrorResponse{Code: "NoSuchKey"})
+	store.EXPECT().StatObject(gomock.Any(), userID.String(), bumperKeys[models.BumperOutro], gomock.Any()).Return(minio.ObjectInfo{}, nil)
+	require.NoError(t, vp.addBumpers(context.Background(), userID, job))
+	require.Equal(t, map[string]interface{}{"outro_bucket": userID.String(), "outro_key": bumperKeys[models.BumperOutro]}, job)
+
+	// an unknown position is rejected before anything is removed
+	var apiErr models.Error
+	require.ErrorAs(t, vp.DeleteBumper(context.Background(), userID, "middle"), &apiErr)
+	require.Equal(t, http.StatusBadRequest, apiErr.Code)
+}
@@ -0,0 +1,159 @@
+package video
+
+import (
+	"container/list"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+)
+
+// sourceCache keeps downloaded sources on local disk, keyed by object checksum, so retries,
+// reprocessing and derived jobs of the same video skip the download. The least recently
+// used sources are evicted once the cache grows beyond maxBytes.
+//
+// Jobs get hard links to cached files, so evicting a source never pulls it away from a job
+// that is still reading it.
+type sourceCache struct {
+	dir      string
+	maxBytes int64
+
+	mu      sync.Mutex
+	size    int64
+	lru     *list.List // front is most recently used, values are *cacheEntry
+	entries map[string]*list.Element
+}
+
+type cacheEntry struct {
+	key  string
+	size int64
+}
+
+// newSourceCache opens the cache in dir, picking up the sources cached by earlier runs
+func newSourceCache(dir string, maxBytes int64) (*sourceCache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create source cache dir: %w", err)
+	}
+	c := &sourceCache{
+		dir:      dir,
+		maxBytes: maxBytes,
+		lru:      list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read source cache dir: %w", err)
+	}
+	infos := make([]os.FileInfo, 0, len(files))
+	for _, f := range files {
+		if info, err := f.Info(); err == nil && info.Mode().IsRegular() {
+			infos = append(infos, info)
+		}
+	}
+	// oldest first, so the most recently used ends up at the front
+	sort.Slice(infos, func(i, j int) bool { return infos[i].ModTime().Before(infos[j].ModTime()) })
+	for _, info := range infos {
+		c.entries[info.Name()] = c.lru.PushFront(&cacheEntry{key: info.Name(), size: info.Size()})
+		c.size += info.Size()
+	}
+	c.evict()
+	return c, nil
+}
+
+var cacheKeyUnsafe = regexp.MustCompile(`[^A-Za-z0-9-]`)
+
+// sourceCacheKey derives the cache key of an object from its checksum (the ETag) and size
+func sourceCacheKey(etag string, size int64) string {
+	return fmt.Sprintf("%s-%d", cacheKeyUnsafe.ReplaceAllString(etag, ""), size)
+}
+
+// Get links the cached source into destPath and reports whether it was cached
+func (c *sourceCache) Get(key, destPath string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.entries[key]
+	if !ok {
+		return false
+	}
+	cached := filepath.Join(c.dir, key)
+	if err := linkOrCopy(cached, destPath); err != nil {
+		// the file is gone or unreadable, forget it
+		c.remove(el)
+		return false
+	}
+	c.lru.MoveToFront(el)
+	now := time.Now()
+	_ = os.Chtimes(cached, now, now)
+	return true
+}
+
+// Put adds the downloaded source at srcPath to the cache and evicts old sources as needed.
+// Sources larger than the whole cache are not cached.
+func (c *sourceCache) Put(key, srcPath string) error {
+	info, err := os.Stat(srcPath)
+	if err != nil {
+		return err
+	}
+	if info.Size() > c.maxBytes {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[key]; ok {
+		c.lru.MoveToFront(el)
+		return nil
+	}
+	if err := linkOrCopy(srcPath, filepath.Join(c.dir, key)); err != nil {
+		return fmt.Errorf("failed to cache source: %w", err)
+	}
+	c.entries[key] = c.lru.PushFront(&cacheEntry{key: key, size: info.Size()})
+	c.size += info.Size()
+	c.evict()
+	return nil
+}
+
+// evict drops least recently used sources until the cache fits its size limit
+func (c *sourceCache) evict() {
+	for c.size > c.maxBytes {
+		el := c.lru.Back()
+		if el == nil {
+			return
+		}
+		c.remove(el)
+	}
+}
+
+func (c *sourceCache) remove(el *list.Element) {
+	entry := c.lru.Remove(el).(*cacheEntry)
+	delete(c.entries, entry.key)
+	c.size -= entry.size
+	_ = os.Remove(filepath.Join(c.dir, entry.key))
+}
+
+// linkOrCopy hard links src to dst, copying when both are on different filesystems
+func linkOrCopy(src, dst string) error {
+	if err := os.Link(src, dst); err == nil {
+		return nil
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return err
+	}
+	return out.Close()
+}
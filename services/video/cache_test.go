@@ -0,0 +1,48 @@
+package video
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSourceCache(t *testing.T) {
+	cacheDir := filepath.Join(t.TempDir(), "cache")
+	workDir := t.TempDir()
+	source := func(name string, size int) string {
+		path := filepath.Join(workDir, name)
+		require.NoError(t, os.WriteFile(path, make([]byte, size), 0o644))
+		return path
+	}
+
+	cache, err := newSourceCache(cacheDir, 100)
+	require.NoError(t, err)
+	require.False(t, cache.Get("a-40", filepath.Join(workDir, "miss.mp4")))
+
+	require.NoError(t, cache.Put("a-40", source("a.mp4", 40)))
+	require.NoError(t, cache.Put("b-40", source("b.mp4", 40)))
+	// a becomes the most recently used, so b is evicted when c arrives
+	require.True(t, cache.Get("a-40", filepath.Join(workDir, "a-hit.mp4")))
+	require.NoError(t, cache.Put("c-40", source("c.mp4", 40)))
+
+	require.False(t, cache.Get("b-40", filepath.Join(workDir, "b-hit.mp4")))
+	require.True(t, cache.Get("c-40", filepath.Join(workDir, "c-hit.mp4")))
+	require.NoFileExists(t, filepath.Join(cacheDir, "b-40"))
+
+	// too large to ever fit
+	require.NoError(t, cache.Put("d-200", source("d.mp4", 200)))
+	require.NoFileExists(t, filepath.Join(cacheDir, "d-200"))
+
+	// a restarted worker picks up what is on disk
+	reopened, err := newSourceCache(cacheDir, 100)
+	require.NoError(t, err)
+	require.Equal(t, int64(80), reopened.size)
+	require.True(t, reopened.Get("a-40", filepath.Join(workDir, "a-again.mp4")))
+}
+
+func TestSourceCacheKey(t *testing.T) {
+	require.Equal(t, "d41d8cd98f00b204e9800998ecf8427e-0", sourceCacheKey(`"d41d8cd98f00b204e9800998ecf8427e"`, 0))
+	require.Equal(t, "9b2cf535f27731c974343645a3985328-3-1048576", sourceCacheKey("9b2cf535f27731c974343645a3985328-3", 1048576))
+}
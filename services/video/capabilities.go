@@ -0,0 +1,178 @@
+package video
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"sort"
+	"strings"
+	"video-processing/models"
+)
+
+// Encoders and filters the pipeline uses or may use. Only the required ones are needed to run at all.
+var (
+	requiredEncoders = []string{"libx264", "aac"}
+	optionalEncoders = []string{"libx265", "h264_nvenc", "hevc_nvenc", "h264_vaapi", "h264_qsv", "libsvtav1", "libvpx-vp9", "libopus", "libmp3lame", "libwebp"}
+	optionalFilters  = []string{"libvmaf", "zscale", "tonemap", "subtitles", "vidstabdetect", "vidstabtransform"}
+	// hardwareEncoders only count as available when a trial encode succeeds
+	hardwareEncoders = []string{EncoderNVENC, EncoderVAAPI, EncoderQSV}
+)
+
+// Capabilities is what the installed ffmpeg can do, as far as the pipeline cares
+type Capabilities struct {
+	FFmpegVersion  string          `json:"ffmpeg_version"`
+	FFprobeVersion string          `json:"ffprobe_version"`
+	Encoders       map[string]bool `json:"encoders"`
+	Filters        map[string]bool `json:"filters"`
+	// Disabled lists the configured features turned off for lack of a capability
+	Disabled []string `json:"disabled,omitempty"`
+}
+
+// DetectCapabilities asks ffmpeg and ffprobe for their versions, encoders and filters, and
+// tries the hardware encoders. It fails when either binary cannot be run.
+func DetectCapabilities(ctx context.Context, t Transcoder) (Capabilities, error) {
+	caps := Capabilities{Encoders: map[string]bool{}, Filters: map[string]bool{}}
+
+	out, err := t.Probe(ctx, "-version")
+	if err != nil {
+		return caps, fmt.Errorf("ffprobe unavailable: %w", err)
+	}
+	caps.FFprobeVersion = parseVersion(string(out))
+
+	err = t.Stream(ctx, func(r io.Reader) error {
+		b, err := io.ReadAll(r)
+		caps.FFmpegVersion = parseVersion(string(b))
+		return err
+	}, "-hide_banner", "-version")
+	if err != nil {
+		return caps, fmt.Errorf("ffmpeg unavailable: %w", err)
+	}
+
+	var encoders, filters []string
+	if err := t.Stream(ctx, func(r io.Reader) error { encoders, err = parseCodecList(r); return err }, "-hide_banner", "-encoders"); err != nil {
+		return caps, fmt.Errorf("failed to list ffmpeg encoders: %w", err)
+	}
+	if err := t.Stream(ctx, func(r io.Reader) error { filters, err = parseCodecList(r); return err }, "-hide_banner", "-filters"); err != nil {
+		return caps, fmt.Errorf("failed to list ffmpeg filters: %w", err)
+	}
+	available := func(names []string) map[string]bool {
+		set := make(map[string]bool, len(names))
+		for _, n := range names {
+			set[n] = true
+		}
+		return set
+	}
+	encoderSet, filterSet := available(encoders), available(filters)
+	for _, e := range append(append([]string{}, requiredEncoders...), optionalEncoders...) {
+		caps.Encoders[e] = encoderSet[e]
+	}
+	for _, f := range optionalFilters {
+		caps.Filters[f] = filterSet[f]
+	}
+	for _, e := range hardwareEncoders {
+		if caps.Encoders[e] {
+			caps.Encoders[e] = encoderWorks(ctx, t, videoEncoderFor(e))
+		}
+	}
+	return caps, nil
+}
+
+// Apply checks the processing configuration against the capabilities. It fails when a
+// required encoder is missing and turns off optional features that cannot work, logging
+// each of them.
+func (c *Capabilities) Apply(logger *slog.Logger, processing models.ProcessingConfig) (models.ProcessingConfig, error) {
+	var missing []string
+	for _, e := range requiredEncoders {
+		if !c.Encoders[e] {
+			missing = append(missing, e)
+		}
+	}
+	if len(missing) > 0 {
+		return processing, fmt.Errorf("ffmpeg %s lacks required encoders: %s", c.FFmpegVersion, strings.Join(missing, ", "))
+	}
+
+	disable := func(feature, reason string) {
+		logger.Warn("disabling processing feature", "feature", feature, "reason", reason)
+		c.Disabled = append(c.Disabled, feature)
+	}
+	if processing.Encoder != "" {
+		enc, ok := videoEncoders[processing.Encoder]
+		if !ok {
+			return processing, fmt.Errorf("unknown encoder %q, want one of %s, %s, %s, %s", processing.Encoder, EncoderX264, EncoderNVENC, EncoderVAAPI, EncoderQSV)
+		}
+		if enc.hardware() && !c.Encoders[processing.Encoder] {
+			disable(processing.Encoder, "ffmpeg built without it or its device failed a trial encode, using libx264")
+			processing.Encoder = EncoderX264
+		}
+	}
+	if processing.QualityMetrics && !c.Filters["libvmaf"] {
+		processing.QualityMetrics = false
+		disable("quality_metrics", "ffmpeg built without libvmaf")
+	}
+	if !processing.DisableHDRVariant && !c.Encoders["libx265"] {
+		processing.DisableHDRVariant = true
+		disable("hdr_variant", "ffmpeg built without libx265")
+	}
+	if processing.WebM && (!c.Encoders["libvpx-vp9"] || !c.Encoders["libopus"]) {
+		processing.WebM = false
+		disable("webm", "ffmpeg built without libvpx-vp9 or libopus")
+	}
+	if processing.AudioMP3 && !c.Encoders["libmp3lame"] {
+		processing.AudioMP3 = false
+		disable("audio_mp3", "ffmpeg built without libmp3lame")
+	}
+	if processing.AnimatedPreview && !c.Encoders["libwebp"] {
+		processing.AnimatedPreview = false
+		disable("animated_preview", "ffmpeg built without libwebp")
+	}
+	if processing.Stabilization && (!c.Filters["vidstabdetect"] || !c.Filters["vidstabtransform"]) {
+		processing.Stabilization = false
+		disable("stabilization", "ffmpeg built without libvidstab")
+	}
+	if !c.Filters["zscale"] || !c.Filters["tonemap"] {
+		logger.Warn("ffmpeg cannot tone map, HDR sources will fail to process", "reason", "zscale or tonemap filter missing")
+	}
+	if !c.Filters["subtitles"] {
+		logger.Warn("ffmpeg cannot burn in subtitles, uploads asking for it will fail to process", "reason", "subtitles filter missing, built without libass")
+	}
+	return processing, nil
+}
+
+// parseVersion returns the version from the banner of ffmpeg or ffprobe -version,
+// e.g. "6.1.1-3ubuntu5" from "ffmpeg version 6.1.1-3ubuntu5 Copyright ..."
+func parseVersion(banner string) string {
+	line, _, _ := strings.Cut(banner, "\n")
+	fields := strings.Fields(line)
+	for i, f := range fields {
+		if f == "version" && i+1 < len(fields) {
+			return fields[i+1]
+		}
+	}
+	return strings.TrimSpace(line)
+}
+
+// parseCodecList reads the names from ffmpeg -encoders or -filters output: the legend is
+// followed by one entry per line, a column of flags and then the name.
+func parseCodecList(r io.Reader) ([]string, error) {
+	var names []string
+	legend := true
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if legend {
+			// encoders end their legend with a dashed line, filters with the "|" direction key
+			if strings.HasPrefix(line, "---") || strings.HasPrefix(line, "| =") {
+				legend = false
+			}
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) >= 2 {
+			names = append(names, fields[1])
+		}
+	}
+	sort.Strings(names)
+	return names, scanner.Err()
+}
@@ -0,0 +1,125 @@
+package video
+
+import (
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"video-processing/models"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseVersion(t *testing.T) {
+	require.Equal(t, "6.1.1-3ubuntu5", parseVersion("ffmpeg version 6.1.1-3ubuntu5 Copyright (c) 2000-2023 the FFmpeg developers\nbuilt with gcc 13\n"))
+	require.Equal(t, "n7.0", parseVersion("ffprobe version n7.0 Copyright (c) 2007-2024"))
+}
+
+func TestParseCodecList(t *testing.T) {
+	encoders := `Encoders:
+ V..... = Video
+ A..... = Audio
+ ------
+ V....D libx264              libx264 H.264 / AVC / MPEG-4 AVC / MPEG-4 part 10 (codec h264)
+ V....D h264_nvenc           NVIDIA NVENC H.264 encoder (codec h264)
+ A....D aac                  AAC (Advanced Audio Coding)
+`
+	names, err := parseCodecList(strings.NewReader(encoders))
+	require.NoError(t, err)
+	require.Equal(t, []string{"aac", "h264_nvenc", "libx264"}, names)
+
+	filters := `Filters:
+  T.. = Timeline support
+  A = Audio input/output
+  | = Source or sink filter
+ ... abench            A->A       Benchmark part of a filtergraph.
+ TSC zscale            V->V       Apply resizing, colorspace and bit depth conversion.
+`
+	names, err = parseCodecList(strings.NewReader(filters))
+	require.NoError(t, err)
+	require.Equal(t, []string{"abench", "zscale"}, names)
+}
+
+func TestCapabilitiesApply(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	caps := Capabilities{Encoders: map[string]bool{"libx264": true}, Filters: map[string]bool{}}
+	_, err := caps.Apply(logger, models.ProcessingConfig{})
+	require.ErrorContains(t, err, "aac")
+
+	caps = Capabilities{
+		Encoders: map[string]bool{"libx264": true, "aac": true},
+		Filters:  map[string]bool{"zscale": true, "tonemap": true},
+	}
+	processing, err := caps.Apply(logger, models.ProcessingConfig{QualityMetrics: true, MaxParallelVariants: 2})
+	require.NoError(t, err)
+	require.False(t, processing.QualityMetrics)
+	require.True(t, processing.DisableHDRVariant)
+	require.Equal(t, 2, processing.MaxParallelVariants)
+	require.Equal(t, []string{"quality_metrics", "hdr_variant"}, caps.Disabled)
+
+	caps = Capabilities{
+		Encoders: map[string]bool{"libx264": true, "aac": true, "libx265": true},
+		Filters:  map[string]bool{"libvmaf": true},
+	}
+	processing, err = caps.Apply(logger, models.ProcessingConfig{QualityMetrics: true})
+	require.NoError(t, err)
+	require.True(t, processing.QualityMetrics)
+	require.False(t, processing.DisableHDRVariant)
+	require.Empty(t, caps.Disabled)
+
+	// hardware encoders fall back to libx264 without a device, unknown encoders are refused
+	processing, err = caps.Apply(logger, models.ProcessingConfig{Encoder: EncoderNVENC})
+	require.NoError(t, err)
+	require.Equal(t, EncoderX264, processing.Encoder)
+	require.Equal(t, []string{EncoderNVENC}, caps.Disabled)
+	caps.Encoders[EncoderNVENC] = true
+	processing, err = caps.Apply(logger, models.ProcessingConfig{Encoder: EncoderNVENC})
+	require.NoError(t, err)
+	require.Equal(t, EncoderNVENC, processing.Encoder)
+	_, err = caps.Apply(logger, models.ProcessingConfig{Encoder: "h264_amf"})
+	require.ErrorContains(t, err, "h264_amf")
+
+	// WebM needs both VP9 and Opus
+	caps.Disabled = nil
+	caps.Encoders["libvpx-vp9"] = true
+	processing, err = caps.Apply(logger, models.ProcessingConfig{WebM: true})
+	require.NoError(t, err)
+	require.False(t, processing.WebM)
+	require.Equal(t, []string{"webm"}, caps.Disabled)
+	caps.Encoders["libopus"] = true
+	processing, err = caps.Apply(logger, models.ProcessingConfig{WebM: true})
+	require.NoError(t, err)
+	require.True(t, processing.WebM)
+
+	// mp3 audio needs libmp3lame, the m4a one the native aac encoder
+	caps.Disabled = nil
+	processing, err = caps.Apply(logger, models.ProcessingConfig{AudioRendition: true, AudioMP3: true})
+	require.NoError(t, err)
+	require.True(t, processing.AudioRendition)
+	require.False(t, processing.AudioMP3)
+	require.Equal(t, []string{"audio_mp3"}, caps.Disabled)
+
+	// the animated preview needs libwebp
+	caps.Disabled = nil
+	processing, err = caps.Apply(logger, models.ProcessingConfig{AnimatedPreview: true})
+	require.NoError(t, err)
+	require.False(t, processing.AnimatedPreview)
+	require.Equal(t, []string{"animated_preview"}, caps.Disabled)
+	caps.Encoders["libwebp"] = true
+	processing, err = caps.Apply(logger, models.ProcessingConfig{AnimatedPreview: true})
+	require.NoError(t, err)
+	require.True(t, processing.AnimatedPreview)
+
+	// stabilization needs both vidstab filters
+	caps.Disabled = nil
+	caps.Filters["vidstabdetect"] = true
+	processing, err = caps.Apply(logger, models.ProcessingConfig{Stabilization: true})
+	require.NoError(t, err)
+	require.False(t, processing.Stabilization)
+	require.Equal(t, []string{"stabilization"}, caps.Disabled)
+	caps.Filters["vidstabtransform"] = true
+	processing, err = caps.Apply(logger, models.ProcessingConfig{Stabilization: true})
+	require.NoError(t, err)
+	require.True(t, processing.Stabilization)
+}
@@ -0,0 +1,80 @@
+package video
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+const (
+	captionsFileName = "captions.vtt"
+	// closedCaptionsGroup is the GROUP-ID of the embedded captions in master playlists
+	closedCaptionsGroup = "cc"
+)
+
+// extractCaptions decodes the CEA-608/708 captions embedded in the video stream into WebVTT.
+// The captions are only reachable as a subcc output of the movie source filter.
+func extractCaptions(ctx context.Context, t Transcoder, inputPath, outPath string) error {
+	args := []string{
+		"-y",
+		"-nostdin",
+		"-f", "lavfi",
+		"-i", fmt.Sprintf("movie=filename=%s[out0+subcc]", escapeFilterPath(inputPath)),
+		"-map", "0:s",
+		"-c:s", "webvtt",
+		outPath,
+	}
+	if err := t.Run(ctx, args...); err != nil {
+		return fmt.Errorf("ffmpeg caption extraction error: %w", err)
+	}
+	return nil
+}
+
+// escapeFilterPath escapes a file path for use as a filter option inside a filtergraph:
+// once for the option parser and once more for the filtergraph parser.
+func escapeFilterPath(path string) string {
+	return escapeChars(escapeChars(path, `\':`), `\'[],;`)
+}
+
+func escapeChars(s, special string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune(special, r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// processCaptions extracts the embedded captions of the source into a WebVTT sidecar and
+// queues it for upload. Failures are logged only; the captions stay in the renditions.
+func (rc *redisConsumer) processCaptions(ctx context.Context, task ProcessingTask, uploadCh chan<- UploadTask) {
+	outPath := filepath.Join(task.WorkDir, captionsFileName)
+	if err := extractCaptions(ctx, rc.transcoder, task.SourcePath, outPath); err != nil {
+		rc.logger.Warn("caption extraction failed", "error", err, "videoID", task.VideoID)
+		return
+	}
+
+	upload := UploadTask{
+		SourcePath:  outPath,
+		ObjectKey:   filepath.ToSlash(filepath.Join(task.DestPrefix, captionsFileName)),
+		ContentType: mimeTypeByExt(".vtt"),
+		Bucket:      task.Bucket,
+	}
+	select {
+	case <-ctx.Done():
+		return
+	case uploadCh <- upload:
+	}
+
+	videoUUID, err := uuid.Parse(task.VideoID)
+	if err != nil {
+		rc.logger.Error("invalid video ID for captions", "error", err, "videoID", task.VideoID)
+		return
+	}
+	rc.saveVideoAsset(ctx, videoUUID, AssetKindCaptions, upload)
+}
@@ -0,0 +1,24 @@
+package video
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEscapeFilterPath(t *testing.T) {
+	require.Equal(t, "/tmp/video-job-1/source.mp4", escapeFilterPath("/tmp/video-job-1/source.mp4"))
+	require.Equal(t, `/tmp/a\\:b/it\\\'s\,1.mp4`, escapeFilterPath(`/tmp/a:b/it's,1.mp4`))
+}
+
+func TestMasterPlaylistClosedCaptions(t *testing.T) {
+	results := []ProcessingResult{
+		{Variant: testLadder.regular[1], ClosedCaptions: true},
+		{Variant: testLadder.hdr[0]},
+	}
+	want := "#EXTM3U\n#EXT-X-VERSION:4\n#EXT-X-INDEPENDENT-SEGMENTS\n" +
+		"#EXT-X-MEDIA:TYPE=CLOSED-CAPTIONS,GROUP-ID=\"cc\",NAME=\"CC1\",INSTREAM-ID=\"CC1\",DEFAULT=YES,AUTOSELECT=YES\n" +
+		"#EXT-X-STREAM-INF:BANDWIDTH=2128000,RESOLUTION=1280x720,CLOSED-CAPTIONS=\"cc\"\n720p/index.m3u8\n" +
+		"#EXT-X-STREAM-INF:BANDWIDTH=6128000,RESOLUTION=1920x1080\n1080p-hdr/index.m3u8\n"
+	require.Equal(t, want, masterPlaylist(results))
+}
@@ -0,0 +1,22 @@
+package video
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// joinCgroup makes cmd start in the cgroup v2 directory dir, a no-op when dir is empty. leave
+// closes the directory and must be called after the command has run.
+func joinCgroup(cmd *exec.Cmd, dir string) (leave func(), err error) {
+	if dir == "" {
+		return func() {}, nil
+	}
+	f, err := os.Open(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open ffmpeg cgroup: %w", err)
+	}
+	cmd.SysProcAttr = &syscall.SysProcAttr{UseCgroupFD: true, CgroupFD: int(f.Fd())}
+	return func() { f.Close() }, nil
+}
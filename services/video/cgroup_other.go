@@ -0,0 +1,16 @@
+//go:build !linux
+
+package video
+
+import (
+	"errors"
+	"os/exec"
+)
+
+// joinCgroup fails for any dir but the empty one, cgroups only exist on Linux
+func joinCgroup(cmd *exec.Cmd, dir string) (leave func(), err error) {
+	if dir != "" {
+		return nil, errors.New("ffmpeg cgroups are only supported on Linux")
+	}
+	return func() {}, nil
+}
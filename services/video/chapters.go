@@ -0,0 +1,105 @@
+package video
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+	"video-processing/database/db"
+	"video-processing/models"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Chapter sources stored in video_chapters.source
+const (
+	ChapterSourceManual = "manual"
+	ChapterSourceSource = "source"
+)
+
+// vttOpenEnd is used as the end of the last cue when the video length is unknown;
+// players clamp cues to the media duration.
+const vttOpenEnd = 99*time.Hour + 59*time.Minute + 59*time.Second
+
+func convertDbChapterToModelChapter(c db.VideoChapter) models.Chapter {
+	chapter := models.Chapter{
+		Title: c.Title,
+		Start: float64(c.StartMs) / 1000,
+	}
+	if c.EndMs.Valid {
+		chapter.End = float64(c.EndMs.Int64) / 1000
+	}
+	return chapter
+}
+
+// ChaptersToWebVTT renders chapters as a WebVTT chapters track.
+// Each cue ends where the next chapter starts.
+func ChaptersToWebVTT(chapters []models.Chapter) string {
+	var b strings.Builder
+	b.WriteString("WEBVTT\n")
+	for i, c := range chapters {
+		end := vttOpenEnd
+		switch {
+		case i+1 < len(chapters):
+			end = secondsToDuration(chapters[i+1].Start)
+		case c.End > c.Start:
+			end = secondsToDuration(c.End)
+		}
+		fmt.Fprintf(&b, "\n%d\n%s --> %s\n%s\n", i+1, formatVTTTimestamp(secondsToDuration(c.Start)), formatVTTTimestamp(end), c.Title)
+	}
+	return b.String()
+}
+
+func secondsToDuration(s float64) time.Duration {
+	return time.Duration(math.Round(s * float64(time.Second)))
+}
+
+// formatVTTTimestamp formats d as hh:mm:ss.ttt
+func formatVTTTimestamp(d time.Duration) string {
+	ms := d.Milliseconds()
+	return fmt.Sprintf("%02d:%02d:%02d.%03d", ms/3600000, ms/60000%60, ms/1000%60, ms%1000)
+}
+
+// saveSourceChapters stores the chapters embedded in the source file, unless
+// the owner already defined chapters for the video.
+func (rc *redisConsumer) saveSourceChapters(ctx context.Context, videoID uuid.UUID, probe ProbeResult) {
+	if len(probe.Chapters) == 0 {
+		return
+	}
+	existing, err := rc.db.ListVideoChapters(ctx, videoID)
+	if err != nil {
+		rc.logger.Error("failed to list video chapters", "error", err, "videoID", videoID)
+		return
+	}
+	if len(existing) > 0 {
+		rc.logger.Info("video already has chapters, skipping source chapters", "videoID", videoID)
+		return
+	}
+
+	for i, pc := range probe.Chapters {
+		start, err := parseProbeSeconds(pc.StartTime)
+		if err != nil {
+			rc.logger.Warn("invalid chapter start time", "error", err, "videoID", videoID, "chapter", pc.ID)
+			continue
+		}
+		arg := db.CreateVideoChapterParams{
+			VideoID: videoID,
+			Title:   pc.Tags["title"],
+			StartMs: int64(math.Round(start * 1000)),
+			Source:  ChapterSourceSource,
+		}
+		if arg.Title == "" {
+			arg.Title = fmt.Sprintf("Chapter %d", i+1)
+		}
+		if end, err := parseProbeSeconds(pc.EndTime); err == nil {
+			arg.EndMs = pgtype.Int8{Int64: int64(math.Round(end * 1000)), Valid: true}
+		}
+		if _, err := rc.db.CreateVideoChapter(ctx, arg); err != nil {
+			rc.logger.Error("failed to save source chapter", "error", err, "videoID", videoID)
+			return
+		}
+	}
+	rc.logger.Info("saved source chapters", "videoID", videoID, "count", len(probe.Chapters))
+}
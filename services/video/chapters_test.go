@@ -0,0 +1,45 @@
+package video_test
+
+import (
+	"testing"
+	"video-processing/models"
+	"video-processing/services/video"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestChaptersToWebVTT(t *testing.T) {
+	testCases := []struct {
+		name     string
+		chapters []models.Chapter
+		want     string
+	}{
+		{
+			name:     "no chapters",
+			chapters: nil,
+			want:     "WEBVTT\n",
+		},
+		{
+			name: "cues end at the next chapter",
+			chapters: []models.Chapter{
+				{Title: "Intro", Start: 0},
+				{Title: "Main", Start: 65.5, End: 3725.25},
+			},
+			want: "WEBVTT\n" +
+				"\n1\n00:00:00.000 --> 00:01:05.500\nIntro\n" +
+				"\n2\n00:01:05.500 --> 01:02:05.250\nMain\n",
+		},
+		{
+			name: "last cue without end stays open",
+			chapters: []models.Chapter{
+				{Title: "Only", Start: 1},
+			},
+			want: "WEBVTT\n\n1\n00:00:01.000 --> 99:59:59.000\nOnly\n",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, video.ChaptersToWebVTT(tc.chapters))
+		})
+	}
+}
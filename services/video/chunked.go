@@ -0,0 +1,161 @@
+package video
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// defaultChunkSeconds is the chunk length used when only the chunking threshold is configured
+const defaultChunkSeconds = 60
+
+// chunkRange is a time slice of the source that is transcoded on its own
+type chunkRange struct {
+	Start    float64
+	Duration float64 // 0 runs to the end of the source
+}
+
+// splitChunks cuts a source of durationSeconds into chunks of chunkSeconds. A remainder
+// shorter than half a chunk is added to the last chunk instead of becoming a tiny chunk
+// of its own. The last chunk always runs to the end so nothing is lost to rounding in
+// the probed duration.
+func splitChunks(durationSeconds, chunkSeconds float64) []chunkRange {
+	if durationSeconds <= 0 || chunkSeconds <= 0 {
+		return nil
+	}
+	var chunks []chunkRange
+	for start := 0.0; durationSeconds-start >= 1.5*chunkSeconds; start += chunkSeconds {
+		chunks = append(chunks, chunkRange{Start: start, Duration: chunkSeconds})
+	}
+	return append(chunks, chunkRange{Start: float64(len(chunks)) * chunkSeconds})
+}
+
+// transcodeChunked transcodes the chunks of the source in parallel and joins them into mp4Path.
+// Only the video is chunked: the audio is encoded in one piece, as AAC encoder priming at every
+// chunk boundary would be audible as clicks. Every chunk encode takes one of the task's slots.
+func transcodeChunked(ctx context.Context, t Transcoder, task ProcessingTask, mp4Path string) error {
+	chunkDir := filepath.Join(filepath.Dir(mp4Path), "chunks")
+	if err := os.MkdirAll(chunkDir, 0o755); err != nil {
+		return fmt.Errorf("failed to create chunk directory: %w", err)
+	}
+	defer os.RemoveAll(chunkDir)
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+	fail := func(err error) {
+		mu.Lock()
+		errs = append(errs, err)
+		mu.Unlock()
+	}
+
+	chunkPaths := make([]string, len(task.Chunks))
+	// the variant has transcoded the seconds its chunks have together
+	chunkSeconds := make([]float64, len(task.Chunks))
+	for i, chunk := range task.Chunks {
+		chunkPaths[i] = filepath.Join(chunkDir, fmt.Sprintf("chunk_%03d.mp4", i))
+		chunkTask := task
+		chunkTask.Chunk = &chunk
+		if task.Progress != nil {
+			// the chunks report from their own goroutines, the variant is told one total at a time
+			chunkTask.Progress = func(seconds float64) {
+				mu.Lock()
+				defer mu.Unlock()
+				chunkSeconds[i] = seconds
+				var total float64
+				for _, s := range chunkSeconds {
+					total += s
+				}
+				task.Progress(total)
+			}
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if task.Slots != nil {
+				task.Slots <- struct{}{}
+				defer func() { <-task.Slots }()
+			}
+			if err := transcodeToMP4(ctx, t, chunkTask, chunkPaths[i]); err != nil {
+				fail(fmt.Errorf("chunk %d: %w", i, err))
+			}
+		}(i)
+	}
+
+	audioPath := filepath.Join(chunkDir, "audio.m4a")
+	if task.HasAudio {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := encodeAudio(ctx, t, task.SourcePath, audioPath, task.AudioLanguages, task.Variant.AudioChannels); err != nil {
+				fail(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	listPath := filepath.Join(chunkDir, "chunks.txt")
+	if err := os.WriteFile(listPath, []byte(concatList(chunkPaths)), 0o644); err != nil {
+		return fmt.Errorf("failed to write chunk list: %w", err)
+	}
+
+	// ffmpeg -f concat -safe 0 -i chunks.txt -i audio.m4a -map 0:v -map 1:a -c copy output.mp4
+	args := []string{
+		"-y",
+		"-nostdin",
+		"-f", "concat",
+		"-safe", "0",
+		"-i", listPath,
+	}
+	if task.HasAudio {
+		args = append(args, "-i", audioPath, "-map", "0:v", "-map", "1:a")
+	}
+	args = append(args, "-c", "copy")
+	if task.Spherical {
+		args = append(args, "-strict", "unofficial")
+	}
+	args = append(args, mp4Path)
+	if err := t.Run(ctx, args...); err != nil {
+		return fmt.Errorf("ffmpeg concat error: %w", err)
+	}
+	return nil
+}
+
+// encodeAudio encodes the audio of the source the way transcodeToMP4 does, every track of it,
+// with channels of surround audio or the stereo downmix for 0
+func encodeAudio(ctx context.Context, t Transcoder, inputPath, outPath string, languages []string, channels int) error {
+	// ffmpeg -y -i input -vn -c:a aac -ac 2 -ar 44100 audio.m4a
+	args := []string{
+		"-y",
+		"-nostdin",
+		"-i", inputPath,
+		"-vn",
+	}
+	if len(languages) > 1 {
+		args = append(args, "-map", "0:a")
+	}
+	args = append(args, audioArgs(channels)...)
+	args = append(args, outPath)
+	if err := t.Run(ctx, args...); err != nil {
+		return fmt.Errorf("ffmpeg audio error: %w", err)
+	}
+	return nil
+}
+
+// concatList renders the input file of ffmpeg's concat demuxer
+func concatList(paths []string) string {
+	var b strings.Builder
+	for _, p := range paths {
+		fmt.Fprintf(&b, "file '%s'\n", strings.ReplaceAll(p, "'", `'\''`))
+	}
+	return b.String()
+}
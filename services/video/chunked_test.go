@@ -0,0 +1,27 @@
+package video
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitChunks(t *testing.T) {
+	require.Nil(t, splitChunks(0, 60))
+	require.Equal(t, []chunkRange{{Start: 0}}, splitChunks(80, 60))
+	require.Equal(t, []chunkRange{
+		{Start: 0, Duration: 60},
+		{Start: 60},
+	}, splitChunks(90, 60))
+	// the 20s remainder is merged into the last chunk
+	require.Equal(t, []chunkRange{
+		{Start: 0, Duration: 60},
+		{Start: 60, Duration: 60},
+		{Start: 120},
+	}, splitChunks(200, 60))
+}
+
+func TestConcatList(t *testing.T) {
+	got := concatList([]string{"/tmp/job/720p/chunks/chunk_000.mp4", "/tmp/it's/chunk_001.mp4"})
+	require.Equal(t, "file '/tmp/job/720p/chunks/chunk_000.mp4'\nfile '/tmp/it'\\''s/chunk_001.mp4'\n", got)
+}
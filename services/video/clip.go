@@ -0,0 +1,81 @@
+package video
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"video-processing/models"
+
+	"github.com/google/uuid"
+)
+
+// clipArgs cuts the range of the clip out of sourcePath into an MP4 that keeps every audio
+// track and the text subtitle tracks as mov_text. The video is encoded like a trimmed source.
+func clipArgs(probe ProbeResult, sourcePath, outPath string, c models.ClipRequest, hdrFormat string) []string {
+	args := []string{
+		"-y",
+		"-nostdin",
+		"-ss", fmt.Sprintf("%.3f", c.Start),
+		"-t", fmt.Sprintf("%.3f", c.End-c.Start),
+		"-i", sourcePath,
+		"-map", "0:V:0",
+		"-map", "0:a?",
+	}
+	tracks, _ := subtitleTracks(probe)
+	for _, t := range tracks {
+		args = append(args, "-map", fmt.Sprintf("0:s:%d", t.Track))
+	}
+	args = append(args, cutVideoArgs(probe, hdrFormat)...)
+	if hdrFormat != "" {
+		args = append(args, "-tag:v", "hvc1")
+	}
+	args = append(args, "-c:a", "aac")
+	if len(tracks) > 0 {
+		args = append(args, "-c:s", "mov_text")
+	}
+	return append(args, "-movflags", "+faststart", outPath)
+}
+
+// RenderClip cuts the clip of the recipe out of the parent's source and processes it as a
+// video of its own
+func (rc *redisConsumer) RenderClip(ctx context.Context, values map[string]interface{}) error {
+	return rc.renderDerived(ctx, values, func(ctx context.Context, job derivedJob) error {
+		c := job.Recipe.Clip
+		if c == nil {
+			return fmt.Errorf("clip job carries no clip recipe")
+		}
+		var hdrFormat string
+		probe, err := probeSource(ctx, rc.transcoder, job.SourcePath)
+		if err != nil {
+			rc.logger.Warn("source probe failed, clipping as SDR", "error", err, "videoID", job.VideoID)
+		} else if stream, ok := probe.VideoStream(); ok {
+			hdrFormat = stream.HDRFormat()
+		}
+		if err := rc.transcoder.Run(ctx, clipArgs(probe, job.SourcePath, job.OutPath, *c, hdrFormat)...); err != nil {
+			return fmt.Errorf("ffmpeg clip error: %w", err)
+		}
+		return nil
+	})
+}
+
+// CreateClip creates a child video holding a range of the given one. The clip is cut from
+// the stored source and gets variants of its own.
+func (vp *videoProcessor) CreateClip(ctx context.Context, userID, videoID uuid.UUID, req models.ClipRequest) (models.VideoDetail, error) {
+	params := fmt.Sprintf("userID: %v, videoID: %v, req: %v", userID, videoID, req)
+	if err := req.Validate(); err != nil {
+		return models.VideoDetail{}, models.Error{
+			Code:    http.StatusBadRequest,
+			Message: "invalid input data",
+			Params:  params,
+			Err:     err,
+		}
+	}
+	parent, err := vp.getOwnedVideo(ctx, userID, videoID)
+	if err != nil {
+		return models.VideoDetail{}, err
+	}
+	return vp.createDerivedVideo(ctx, parent, req.Title, models.Recipe{
+		Type: models.RecipeTypeClip,
+		Clip: &req,
+	}, JobTypeClip)
+}
@@ -0,0 +1,35 @@
+package video
+
+import (
+	"strings"
+	"testing"
+	"video-processing/models"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClipArgs(t *testing.T) {
+	probe := ProbeResult{Streams: []ProbeStream{
+		{CodecType: "video", CodecName: "h264"},
+		{CodecType: "audio", CodecName: "aac"},
+		{CodecType: "subtitle", CodecName: "hdmv_pgs_subtitle"},
+		{CodecType: "subtitle", CodecName: "subrip"},
+	}}
+	clip := models.ClipRequest{Start: 90, End: 105.5}
+	require.Equal(t, "-y -nostdin -ss 90.000 -t 15.500 -i source.mkv -map 0:V:0 -map 0:a? -map 0:s:1 "+
+		"-c:v libx264 -crf 18 -preset fast -c:a aac -c:s mov_text -movflags +faststart derived.mp4",
+		strings.Join(clipArgs(probe, "source.mkv", "derived.mp4", clip, ""), " "))
+
+	// HDR clips stay HEVC, tagged for Apple players
+	args := strings.Join(clipArgs(ProbeResult{}, "source.mkv", "derived.mp4", clip, HDRFormatHDR10), " ")
+	require.Contains(t, args, "-c:v libx265")
+	require.Contains(t, args, "-tag:v hvc1")
+	require.NotContains(t, args, "mov_text")
+}
+
+func TestClipRequestValidate(t *testing.T) {
+	require.NoError(t, models.ClipRequest{Start: 0, End: 10}.Validate())
+	require.Error(t, models.ClipRequest{Start: 10, End: 10}.Validate())
+	require.Error(t, models.ClipRequest{Start: 5}.Validate())
+	require.Error(t, models.ClipRequest{Start: -1, End: 5}.Validate())
+}
@@ -0,0 +1,106 @@
+package video
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+)
+
+// aacCodec is the RFC 6381 codec of the AAC-LC audio every variant carries
+const aacCodec = "mp4a.40.2"
+
+// h264Profiles maps the H.264 profiles ffprobe reports to their profile_idc and constraint
+// flags, the first two bytes of an avc1 codec
+var h264Profiles = map[string]string{
+	"Constrained Baseline": "42E0",
+	"Baseline":             "4200",
+	"Main":                 "4D40",
+	"High":                 "6400",
+	"High 10":              "6E00",
+}
+
+// renditionCodecs returns the RFC 6381 codecs of a packaged variant for the CODECS attribute
+// of master playlists, e.g. "avc1.64001F,mp4a.40.2". The video codec is read from what players
+// get: the first TS segment of H.264 variants, the init segment of fMP4 packaged ones, the MP4
+// HEVC and encrypted segments are cut from. When the probe fails, the profile and level the
+// encoders pick for the ladder are assumed.
+func renditionCodecs(ctx context.Context, t Transcoder, v Variant, hlsDir, mp4Path string, hasAudio bool, packaging hlsOptions) string {
+	path := filepath.Join(hlsDir, "segment_000.ts")
+	switch {
+	case v.hevc() || packaging.KeyInfo != "":
+		path = mp4Path
+	case packaging.FMP4:
+		path = filepath.Join(hlsDir, "init.mp4")
+	}
+	codec := fallbackVideoCodec(v)
+	if stream, err := probeVideoCodec(ctx, t, path); err == nil {
+		if c, ok := videoCodec(stream); ok {
+			codec = c
+		}
+	}
+	if hasAudio {
+		return codec + "," + aacCodec
+	}
+	return codec
+}
+
+func probeVideoCodec(ctx context.Context, t Transcoder, path string) (ProbeStream, error) {
+	// ffprobe -v error -select_streams v:0 -show_entries stream=codec_name,codec_type,profile,level -print_format json input
+	out, err := t.Probe(ctx,
+		"-v", "error",
+		"-select_streams", "v:0",
+		"-show_entries", "stream=codec_name,codec_type,profile,level",
+		"-print_format", "json",
+		path,
+	)
+	if err != nil {
+		return ProbeStream{}, fmt.Errorf("ffprobe error: %w", err)
+	}
+	var result ProbeResult
+	if err := json.Unmarshal(out, &result); err != nil {
+		return ProbeStream{}, fmt.Errorf("failed to decode ffprobe output: %w", err)
+	}
+	stream, ok := result.VideoStream()
+	if !ok {
+		return ProbeStream{}, fmt.Errorf("no video stream in %s", path)
+	}
+	return stream, nil
+}
+
+// videoCodec turns the codec, profile and level of a stream into an avc1 or hvc1 codec
+func videoCodec(s ProbeStream) (string, bool) {
+	if s.Level <= 0 {
+		return "", false
+	}
+	switch s.CodecName {
+	case "h264":
+		profile, ok := h264Profiles[s.Profile]
+		if !ok {
+			return "", false
+		}
+		return fmt.Sprintf("avc1.%s%02X", profile, s.Level), true
+	case "hevc":
+		// general profile space, profile, compatibility flags, tier and level, then the
+		// progressive, non-packed, frame only constraint flags
+		switch s.Profile {
+		case "Main":
+			return fmt.Sprintf("hvc1.1.6.L%d.B0", s.Level), true
+		case "Main 10":
+			return fmt.Sprintf("hvc1.2.4.L%d.B0", s.Level), true
+		}
+	}
+	return "", false
+}
+
+// fallbackVideoCodec is the codec of v at the profile its encoder uses and level 4.0, which
+// covers the 1080p ladder
+func fallbackVideoCodec(v Variant) string {
+	switch {
+	case v.HDR:
+		return "hvc1.2.4.L120.B0"
+	case v.hevc():
+		return "hvc1.1.6.L120.B0"
+	}
+	return "avc1.640028"
+}
@@ -0,0 +1,58 @@
+package video
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestVideoCodec(t *testing.T) {
+	testCases := []struct {
+		stream ProbeStream
+		want   string
+	}{
+		{stream: ProbeStream{CodecName: "h264", Profile: "High", Level: 31}, want: "avc1.64001F"},
+		{stream: ProbeStream{CodecName: "h264", Profile: "Main", Level: 40}, want: "avc1.4D4028"},
+		{stream: ProbeStream{CodecName: "h264", Profile: "Constrained Baseline", Level: 30}, want: "avc1.42E01E"},
+		{stream: ProbeStream{CodecName: "hevc", Profile: "Main", Level: 93}, want: "hvc1.1.6.L93.B0"},
+		{stream: ProbeStream{CodecName: "hevc", Profile: "Main 10", Level: 150}, want: "hvc1.2.4.L150.B0"},
+		{stream: ProbeStream{CodecName: "h264", Profile: "High 4:4:4 Predictive", Level: 40}},
+		{stream: ProbeStream{CodecName: "h264", Profile: "High"}},
+		{stream: ProbeStream{CodecName: "vp9", Profile: "Profile 0", Level: 40}},
+	}
+	for _, tc := range testCases {
+		codec, ok := videoCodec(tc.stream)
+		require.Equal(t, tc.want != "", ok, "%+v", tc.stream)
+		require.Equal(t, tc.want, codec)
+	}
+}
+
+func TestRenditionCodecs(t *testing.T) {
+	fake := NewFakeTranscoder()
+	fake.ProbeOutput = []byte(`{"streams":[{"codec_name":"hevc","codec_type":"video","profile":"Main","level":123}]}`)
+	hevc := Variant{Name: "1080p-hevc", Width: 1920, Height: 1080, Codec: "hevc", Bitrate: "2500k"}
+	require.Equal(t, "hvc1.1.6.L123.B0,mp4a.40.2", renditionCodecs(context.Background(), fake, hevc, "/work/1080p-hevc", "/work/1080p-hevc/1080p-hevc.mp4", true, hlsOptions{}))
+	// HEVC segments are probed through the MP4 they are copied from, H.264 ones directly
+	require.Equal(t, "/work/1080p-hevc/1080p-hevc.mp4", fake.Calls()[0][len(fake.Calls()[0])-1])
+	// fMP4 packaged H.264 variants carry the codec in their init segment
+	renditionCodecs(context.Background(), fake, testLadder.regular[0], "/work/1080p", "/work/1080p/1080p.mp4", false, hlsOptions{FMP4: true})
+	require.Equal(t, "/work/1080p/init.mp4", fake.Calls()[1][len(fake.Calls()[1])-1])
+
+	// renditions that cannot be probed get the codecs they are encoded with
+	fake.FailOn = "/work/"
+	require.Equal(t, "avc1.640028", renditionCodecs(context.Background(), fake, testLadder.regular[0], "/work/1080p", "/work/1080p/1080p.mp4", false, hlsOptions{}))
+	require.Equal(t, "hvc1.2.4.L120.B0", renditionCodecs(context.Background(), fake, testLadder.hdr[0], "/work/hdr", "/work/hdr/1080p-hdr.mp4", false, hlsOptions{}))
+}
+
+func TestMasterPlaylistCodecs(t *testing.T) {
+	results := []ProcessingResult{
+		{Variant: Variant{Name: "1080p", Width: 1920, Height: 1080, Bitrate: "4000k"}, Codecs: "avc1.640028,mp4a.40.2", IFrameBandwidth: 500000},
+		{Variant: Variant{Name: "1080p-hevc", Width: 1920, Height: 1080, Codec: "hevc", Bitrate: "2500k"}, Codecs: "hvc1.1.6.L120.B0,mp4a.40.2"},
+	}
+	want := "#EXTM3U\n#EXT-X-VERSION:4\n#EXT-X-INDEPENDENT-SEGMENTS\n" +
+		"#EXT-X-STREAM-INF:BANDWIDTH=4128000,RESOLUTION=1920x1080,CODECS=\"avc1.640028,mp4a.40.2\"\n1080p/index.m3u8\n" +
+		"#EXT-X-STREAM-INF:BANDWIDTH=2628000,RESOLUTION=1920x1080,CODECS=\"hvc1.1.6.L120.B0,mp4a.40.2\"\n1080p-hevc/index.m3u8\n" +
+		"#EXT-X-I-FRAME-STREAM-INF:BANDWIDTH=500000,RESOLUTION=1920x1080,CODECS=\"avc1.640028\",URI=\"1080p/iframe.m3u8\"\n"
+	require.Equal(t, want, masterPlaylist(results))
+}
@@ -0,0 +1,110 @@
+package video
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// Vertical crop modes for ProcessingConfig.VerticalCrop
+const (
+	VerticalCropCenter = "center"
+	VerticalCropSmart  = "smart"
+)
+
+const (
+	// cropFocusFrames is the number of frames sampled to find the crop focus
+	cropFocusFrames = 32
+	// frames are analysed at this size, a 16:9 source keeps its aspect
+	cropFocusWidth  = 64
+	cropFocusHeight = 36
+)
+
+// verticalCropFilter cuts a full-height 9:16 window out of the frame, centered on
+// focusX (a share of the source width) as far as the frame edges allow.
+func verticalCropFilter(focusX float64) string {
+	return fmt.Sprintf("crop=w=trunc(ih*9/16/2)*2:h=ih:x=clip(iw*%s-ow/2\\,0\\,iw-ow):y=0", formatFactor(math.Round(focusX*1000)/1000))
+}
+
+// columnEnergy accumulates the gradient magnitude of each column over gray frames of
+// cropFocusWidth x cropFocusHeight read from r. Detailed regions (faces, text, subjects in
+// focus) score high, flat backgrounds and blurred areas low.
+func columnEnergy(r io.Reader) ([]float64, error) {
+	br := bufio.NewReader(r)
+	frame := make([]byte, cropFocusWidth*cropFocusHeight)
+	energy := make([]float64, cropFocusWidth)
+	for {
+		if _, err := io.ReadFull(br, frame); err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				return energy, nil
+			}
+			return nil, fmt.Errorf("failed to read frame: %w", err)
+		}
+		for y := 0; y < cropFocusHeight-1; y++ {
+			for x := 0; x < cropFocusWidth-1; x++ {
+				p := int(frame[y*cropFocusWidth+x])
+				dx := p - int(frame[y*cropFocusWidth+x+1])
+				dy := p - int(frame[(y+1)*cropFocusWidth+x])
+				energy[x] += math.Abs(float64(dx)) + math.Abs(float64(dy))
+			}
+		}
+	}
+}
+
+// cropFocus returns the center, as a share of the width, of the window of the given
+// number of columns that holds the most energy. Without any detail it returns the center.
+func cropFocus(energy []float64, window int) float64 {
+	if window <= 0 || window >= len(energy) {
+		return 0.5
+	}
+	var sum, total float64
+	for i := 0; i < window; i++ {
+		sum += energy[i]
+	}
+	for _, e := range energy {
+		total += e
+	}
+	if total == 0 {
+		return 0.5
+	}
+	best, bestStart := sum, 0
+	for start := 1; start+window <= len(energy); start++ {
+		sum += energy[start+window-1] - energy[start-1]
+		if sum > best {
+			best, bestStart = sum, start
+		}
+	}
+	return (float64(bestStart) + float64(window)/2) / float64(len(energy))
+}
+
+// detectCropFocus samples frames over the video and finds the horizontal focus for a
+// 9:16 crop of a width x height source.
+func detectCropFocus(ctx context.Context, t Transcoder, inputPath string, durationSeconds float64, width, height int) (float64, error) {
+	if durationSeconds <= 0 || width <= 0 || height <= 0 {
+		return 0.5, fmt.Errorf("unknown source duration or size")
+	}
+	args := []string{
+		"-nostdin",
+		"-v", "error",
+		"-i", inputPath,
+		"-an",
+		"-vf", fmt.Sprintf("fps=%d/%.3f,scale=%d:%d:flags=area,format=gray", cropFocusFrames, durationSeconds, cropFocusWidth, cropFocusHeight),
+		"-frames:v", fmt.Sprint(cropFocusFrames),
+		"-f", "rawvideo",
+		"pipe:1",
+	}
+	var energy []float64
+	err := t.Stream(ctx, func(r io.Reader) (err error) {
+		energy, err = columnEnergy(r)
+		return err
+	}, args...)
+	if err != nil {
+		return 0.5, fmt.Errorf("ffmpeg crop focus error: %w", err)
+	}
+	// the crop window is full height, so its width in analysis columns follows the source aspect
+	window := int(math.Round(float64(cropFocusWidth) * float64(height) * 9 / 16 / float64(width)))
+	return cropFocus(energy, window), nil
+}
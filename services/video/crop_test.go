@@ -0,0 +1,51 @@
+package video
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCropFocus(t *testing.T) {
+	testCases := []struct {
+		name   string
+		energy []float64
+		window int
+		want   float64
+	}{
+		{
+			name:   "flat frame stays centered",
+			energy: make([]float64, 8),
+			window: 4,
+			want:   0.5,
+		},
+		{
+			name:   "detail on the left",
+			energy: []float64{5, 9, 7, 1, 0, 0, 1, 0},
+			window: 2,
+			want:   0.25,
+		},
+		{
+			name:   "detail at the right edge",
+			energy: []float64{0, 0, 0, 0, 1, 2, 8, 9},
+			window: 4,
+			want:   0.75,
+		},
+		{
+			name:   "window as wide as the frame",
+			energy: []float64{9, 0, 0, 0},
+			window: 4,
+			want:   0.5,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.InDelta(t, tc.want, cropFocus(tc.energy, tc.window), 1e-9)
+		})
+	}
+}
+
+func TestVerticalCropFilter(t *testing.T) {
+	require.Equal(t, `crop=w=trunc(ih*9/16/2)*2:h=ih:x=clip(iw*0.5-ow/2\,0\,iw-ow):y=0`, verticalCropFilter(0.5))
+	require.Equal(t, `crop=w=trunc(ih*9/16/2)*2:h=ih:x=clip(iw*0.333-ow/2\,0\,iw-ow):y=0`, verticalCropFilter(1.0/3))
+}
@@ -0,0 +1,133 @@
+package video
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"video-processing/models"
+)
+
+const (
+	// deinterlaceFilter turns every frame of an interlaced source into a progressive one,
+	// keeping the frame rate
+	deinterlaceFilter = "yadif"
+	// idetFrames is the number of frames idet inspects on sources whose field order is unknown
+	idetFrames = 300
+	idetKey    = "lavfi.idet.multiple.current_frame"
+)
+
+// Interlaced reports whether the field order marks the stream interlaced. known is false when
+// the container does not say, as with many MPEG-4 Part 2 and older AVI sources.
+func (s ProbeStream) Interlaced() (interlaced, known bool) {
+	switch s.FieldOrder {
+	case "tt", "bb", "tb", "bt":
+		return true, true
+	case "progressive":
+		return false, true
+	}
+	return false, false
+}
+
+// idetCounts are the frames idet classified, by the multiple frame detection
+type idetCounts struct {
+	TFF, BFF, Progressive, Undetermined int
+}
+
+// interlaced is true when the interlaced frames outnumber the progressive ones and make up at
+// least a quarter of the inspected frames, so a few misdetections in static scenes do not count
+func (c idetCounts) interlaced() bool {
+	fields := c.TFF + c.BFF
+	return fields > c.Progressive && fields*4 >= fields+c.Progressive+c.Undetermined
+}
+
+// idetArgs run idet over idetFrames frames from offset from and print the verdict on every
+// frame to stdout
+func idetArgs(inputPath string, from float64) []string {
+	return []string{
+		"-nostdin",
+		"-v", "error",
+		"-ss", fmt.Sprintf("%.3f", from),
+		"-i", inputPath,
+		"-map", "0:V:0",
+		"-an",
+		"-sn",
+		"-vf", "idet,metadata=mode=print:key=" + idetKey + ":file=-",
+		"-frames:v", strconv.Itoa(idetFrames),
+		"-f", "null",
+		"-",
+	}
+}
+
+// parseIdet counts the idet verdicts printed by the metadata filter
+func parseIdet(r io.Reader) (idetCounts, error) {
+	var c idetCounts
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		verdict, ok := strings.CutPrefix(strings.TrimSpace(scanner.Text()), idetKey+"=")
+		if !ok {
+			continue
+		}
+		switch verdict {
+		case "tff":
+			c.TFF++
+		case "bff":
+			c.BFF++
+		case "progressive":
+			c.Progressive++
+		default:
+			c.Undetermined++
+		}
+	}
+	return c, scanner.Err()
+}
+
+// detectInterlacing looks for combing in the frames of the source with idet. It starts a tenth
+// into the video, past intros and black leaders that tell nothing.
+func detectInterlacing(ctx context.Context, t Transcoder, inputPath string, durationSeconds float64) (bool, error) {
+	var counts idetCounts
+	err := t.Stream(ctx, func(r io.Reader) (err error) {
+		counts, err = parseIdet(r)
+		return err
+	}, idetArgs(inputPath, durationSeconds/10)...)
+	if err != nil {
+		return false, fmt.Errorf("ffmpeg idet error: %w", err)
+	}
+	return counts.interlaced(), nil
+}
+
+// deinterlaceChain puts the deinterlacer in front of the video filter chain vf, so cropping,
+// tone mapping and scaling work on whole frames
+func deinterlaceChain(vf string, task ProcessingTask) string {
+	if !task.Deinterlace {
+		return vf
+	}
+	return deinterlaceFilter + "," + vf
+}
+
+// shouldDeinterlace decides whether the variants of the job are deinterlaced: as the job's
+// deinterlace mode says, otherwise when the source is interlaced. Sources that do not state
+// their field order are checked with idet.
+func (rc *redisConsumer) shouldDeinterlace(ctx context.Context, values map[string]interface{}, sourcePath string, probe ProbeResult) bool {
+	switch mode, _ := values["deinterlace"].(string); mode {
+	case models.DeinterlaceOn:
+		return true
+	case models.DeinterlaceOff:
+		return false
+	}
+	stream, ok := probe.VideoStream()
+	if !ok {
+		return false
+	}
+	if interlaced, known := stream.Interlaced(); known {
+		return interlaced
+	}
+	interlaced, err := detectInterlacing(ctx, rc.transcoder, sourcePath, probe.Duration())
+	if err != nil {
+		rc.logger.Warn("interlace detection failed, keeping frames as they are", "error", err, "videoID", values["video_id"])
+		return false
+	}
+	return interlaced
+}
@@ -0,0 +1,76 @@
+package video
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"path"
+	"strings"
+	"testing"
+	"video-processing/models"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestProbeStreamInterlaced(t *testing.T) {
+	for order, want := range map[string][2]bool{
+		"tt":          {true, true},
+		"bb":          {true, true},
+		"progressive": {false, true},
+		"unknown":     {false, false},
+		"":            {false, false},
+	} {
+		interlaced, known := ProbeStream{FieldOrder: order}.Interlaced()
+		require.Equal(t, want, [2]bool{interlaced, known}, order)
+	}
+}
+
+func TestParseIdet(t *testing.T) {
+	out := "frame:0    pts:0       pts_time:0\n" + idetKey + "=undetermined\n" +
+		"frame:1    pts:1       pts_time:0.04\n" + idetKey + "=tff\n" +
+		"frame:2    pts:2       pts_time:0.08\n" + idetKey + "=tff\n" +
+		"frame:3    pts:3       pts_time:0.12\n" + idetKey + "=progressive\n"
+	counts, err := parseIdet(strings.NewReader(out))
+	require.NoError(t, err)
+	require.Equal(t, idetCounts{TFF: 2, Progressive: 1, Undetermined: 1}, counts)
+	require.True(t, counts.interlaced())
+
+	// a few combed frames among static ones are not enough
+	require.False(t, idetCounts{TFF: 20, Progressive: 10, Undetermined: 270}.interlaced())
+	require.False(t, idetCounts{BFF: 100, Progressive: 200}.interlaced())
+}
+
+func TestShouldDeinterlace(t *testing.T) {
+	fake := NewFakeTranscoder()
+	rc := &redisConsumer{logger: slog.New(slog.NewTextHandler(io.Discard, nil)), transcoder: fake}
+	ctx := context.Background()
+	flagged := ProbeResult{Streams: []ProbeStream{{CodecType: "video", FieldOrder: "tt"}}}
+	unknown := ProbeResult{Streams: []ProbeStream{{CodecType: "video"}}, Format: ProbeFormat{Duration: "60"}}
+
+	require.True(t, rc.shouldDeinterlace(ctx, map[string]interface{}{}, "source.ts", flagged))
+	require.False(t, rc.shouldDeinterlace(ctx, map[string]interface{}{"deinterlace": models.DeinterlaceOff}, "source.ts", flagged))
+	require.Empty(t, fake.Calls())
+
+	// sources that do not say are checked with idet
+	fake.StreamOutput = []byte(idetKey + "=bff\n" + idetKey + "=bff\n")
+	require.True(t, rc.shouldDeinterlace(ctx, map[string]interface{}{}, "source.avi", unknown))
+	require.Equal(t, "-nostdin -v error -ss 6.000 -i source.avi -map 0:V:0 -an -sn "+
+		"-vf idet,metadata=mode=print:key=lavfi.idet.multiple.current_frame:file=- -frames:v 300 -f null -",
+		strings.Join(fake.Calls()[0], " "))
+
+	fake.StreamOutput = nil
+	require.False(t, rc.shouldDeinterlace(ctx, map[string]interface{}{}, "source.avi", unknown))
+	require.True(t, rc.shouldDeinterlace(ctx, map[string]interface{}{"deinterlace": models.DeinterlaceOn}, "source.avi", unknown))
+}
+
+func TestTranscodeToMP4Deinterlace(t *testing.T) {
+	fake := NewFakeTranscoder()
+	task := ProcessingTask{Variant: testLadder.regular[1], SourcePath: "source.ts", Deinterlace: true}
+	require.NoError(t, transcodeToMP4(context.Background(), fake, task, path.Join(t.TempDir(), "720p.mp4")))
+	require.Contains(t, fake.Calls()[0], "yadif,scale=1280:720")
+
+	// the deinterlacer runs before tone mapping
+	task.HDRFormat = HDRFormatHDR10
+	require.NoError(t, transcodeToMP4(context.Background(), fake, task, path.Join(t.TempDir(), "720p.mp4")))
+	require.Contains(t, fake.Calls()[1], "yadif,"+toneMapFilter+",scale=1280:720")
+}
@@ -0,0 +1,126 @@
+package video
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"video-processing/models"
+
+	"github.com/google/uuid"
+	"github.com/minio/minio-go/v7"
+)
+
+// objectOpener is implemented by stores that stream objects, like the local backend
+type objectOpener interface {
+	OpenObject(ctx context.Context, bucketName, objectName string, opts minio.GetObjectOptions) (io.ReadCloser, minio.ObjectInfo, error)
+}
+
+// openObject opens an object of the store for reading; the caller closes it
+func openObject(ctx context.Context, store ObjectStore, bucketName, objectName string, opts minio.GetObjectOptions) (io.ReadCloser, minio.ObjectInfo, error) {
+	switch s := store.(type) {
+	case objectOpener:
+		return s.OpenObject(ctx, bucketName, objectName, opts)
+	case *minio.Client:
+		object, err := s.GetObject(ctx, bucketName, objectName, opts)
+		if err != nil {
+			return nil, minio.ObjectInfo{}, err
+		}
+		// the object is only requested by the first read or stat
+		info, err := object.Stat()
+		if err != nil {
+			object.Close()
+			return nil, minio.ObjectInfo{}, err
+		}
+		return object, info, nil
+	}
+	return nil, minio.ObjectInfo{}, fmt.Errorf("%T cannot stream objects", store)
+}
+
+// Download opens a variant of a video the user can see, in format, MP4 when empty, or the source
+// of their own video when variant is empty. Videos behind a playback password are downloaded by
+// their owner only, and so are the clear variants of encrypted videos. The file is read no
+// faster than the bandwidth of the user's plan.
+func (vp *videoProcessor) Download(ctx context.Context, userID, videoID uuid.UUID, variant, format string) (models.Download, error) {
+	params := fmt.Sprintf("userID: %v, videoID: %v, variant: %v, format: %v", userID, videoID, variant, format)
+	video, err := vp.getUnlockedVideo(ctx, userID, videoID)
+	if err != nil {
+		return models.Download{}, err
+	}
+	bucket, key := video.Bucket, video.Key
+	if variant == "" {
+		if video.UserID != userID {
+			return models.Download{}, models.Error{
+				Code:    http.StatusBadRequest,
+				Message: "invalid input data",
+				Params:  params,
+				Err:     errors.Join(errors.New("only the owner downloads the source, choose a variant"), models.ErrInvalidInputData),
+			}
+		}
+	} else {
+		// the variant files are not encrypted, only the HLS segments are
+		if video.UserID != userID {
+			encrypted, err := vp.isEncrypted(ctx, videoID)
+			if err != nil {
+				return models.Download{}, err
+			}
+			if encrypted {
+				return models.Download{}, models.Error{
+					Code:    http.StatusNotFound,
+					Message: "resource not found",
+					Params:  params,
+					Err:     models.ErrResourceNotFound,
+				}
+			}
+		}
+		variants, err := vp.db.ListVideoVariants(ctx, videoID)
+		if err != nil {
+			return models.Download{}, models.IndentifyDbError(err).AddParams(params)
+		}
+		if format == "" {
+			format = FormatMP4
+		}
+		found := false
+		for _, v := range variants {
+			if v.VariantName == variant && v.Format == format {
+				bucket, key, found = v.Bucket, v.Key, true
+				break
+			}
+		}
+		if !found {
+			return models.Download{}, models.Error{
+				Code:    http.StatusNotFound,
+				Message: "resource not found",
+				Params:  params,
+				Err:     models.ErrResourceNotFound,
+			}
+		}
+	}
+
+	body, info, err := openObject(ctx, vp.minioClient, bucket, key, minio.GetObjectOptions{})
+	if err != nil {
+		if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+			return models.Download{}, models.Error{
+				Code:    http.StatusNotFound,
+				Message: "resource not found",
+				Params:  params,
+				Err:     models.ErrResourceNotFound,
+			}
+		}
+		return models.Download{}, models.Error{
+			Code:        http.StatusInternalServerError,
+			Message:     "internal server error",
+			Description: "failed to open video file",
+			Params:      params,
+			Err:         fmt.Errorf("failed to open %s/%s: %w", bucket, key, err),
+		}
+	}
+	return models.Download{
+		Name:        path.Base(key),
+		ContentType: info.ContentType,
+		Size:        info.Size,
+		Body:        vp.bandwidth.limit(ctx, userID, vp.meterDelivery(ctx, video.UserID, body)),
+	}, nil
+}
@@ -0,0 +1,139 @@
+package video
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"testing"
+	"video-processing/database/db"
+	"video-processing/mocks"
+	"video-processing/models"
+	"video-processing/storage"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/minio/minio-go/v7"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestDownload(t *testing.T) {
+	store, err := storage.NewLocal(t.TempDir(), "http://localhost:8888")
+	require.NoError(t, err)
+	repo := mocks.NewMockVideoRepo(gomock.NewController(t))
+	owner, viewer, videoID := uuid.New(), uuid.New(), uuid.New()
+	delivery := models.DeliveryConfig{Plans: map[string]int64{"free": 256}, DefaultPlan: "free"}
+	vp := &videoProcessor{logger: slog.New(slog.NewTextHandler(io.Discard, nil)), db: repo, minioClient: store, bandwidth: newBandwidthLimiter(delivery)}
+	bucket := owner.String()
+	putObjects(t, store, bucket, map[string]string{"clip.mp4": "source", "processed/run/720p/720p.mp4": "rendition", "processed/run/720p/720p.webm": "vp9"})
+	video := db.Video{ID: videoID, UserID: owner, Bucket: bucket, Key: "clip.mp4", Visibility: models.VisibilityPublic}
+	variants := []db.VideoVariant{
+		{VideoID: videoID, VariantName: "720p", Bucket: bucket, Key: "processed/run/720p/720p.mp4", Format: FormatMP4},
+		{VideoID: videoID, VariantName: "720p", Bucket: bucket, Key: "processed/run/720p/720p.webm", Format: FormatWebM},
+	}
+	var e models.Error
+
+	repo.EXPECT().GetVideo(gomock.Any(), videoID).Return(video, nil).AnyTimes()
+	repo.EXPECT().ListVideoVariants(gomock.Any(), videoID).Return(variants, nil).AnyTimes()
+	repo.EXPECT().GetVideoKey(gomock.Any(), videoID).Return(nil, pgx.ErrNoRows).AnyTimes()
+	// delivery is billed to the owner, whoever downloads
+	repo.EXPECT().RecordUsage(gomock.Any(), db.RecordUsageParams{UserID: owner, DeliveryBytes: int64(len("rendition"))})
+	repo.EXPECT().RecordUsage(gomock.Any(), db.RecordUsageParams{UserID: owner, DeliveryBytes: int64(len("source"))})
+	repo.EXPECT().RecordUsage(gomock.Any(), db.RecordUsageParams{UserID: owner, DeliveryBytes: int64(len("vp9"))})
+
+	download, err := vp.Download(context.Background(), viewer, videoID, "720p", "")
+	require.NoError(t, err)
+	require.Equal(t, "720p.mp4", download.Name)
+	require.Equal(t, "video/mp4", download.ContentType)
+	require.EqualValues(t, len("rendition"), download.Size)
+	require.IsType(t, &throttledReader{}, download.Body)
+	data, err := io.ReadAll(download.Body)
+	require.NoError(t, err)
+	require.Equal(t, "rendition", string(data))
+	require.NoError(t, download.Body.Close())
+
+	// other formats of a variant are picked by name
+	download, err = vp.Download(context.Background(), viewer, videoID, "720p", FormatWebM)
+	require.NoError(t, err)
+	require.Equal(t, "720p.webm", download.Name)
+	data, _ = io.ReadAll(download.Body)
+	download.Body.Close()
+	require.Equal(t, "vp9", string(data))
+
+	// the source is for the owner only
+	_, err = vp.Download(context.Background(), viewer, videoID, "", "")
+	require.ErrorAs(t, err, &e)
+	require.Equal(t, http.StatusBadRequest, e.Code)
+	download, err = vp.Download(context.Background(), owner, videoID, "", "")
+	require.NoError(t, err)
+	data, _ = io.ReadAll(download.Body)
+	download.Body.Close()
+	require.Equal(t, "source", string(data))
+
+	_, err = vp.Download(context.Background(), viewer, videoID, "1080p", "")
+	require.ErrorAs(t, err, &e)
+	require.Equal(t, http.StatusNotFound, e.Code)
+
+	// renditions missing from storage are not found either
+	require.NoError(t, store.RemoveObject(context.Background(), bucket, "processed/run/720p/720p.mp4", minio.RemoveObjectOptions{}))
+	_, err = vp.Download(context.Background(), viewer, videoID, "720p", "")
+	require.ErrorAs(t, err, &e)
+	require.Equal(t, http.StatusNotFound, e.Code)
+	require.Contains(t, e.Params, "720p")
+}
+
+func TestDownloadPasswordProtected(t *testing.T) {
+	store, err := storage.NewLocal(t.TempDir(), "")
+	require.NoError(t, err)
+	repo := mocks.NewMockVideoRepo(gomock.NewController(t))
+	owner, viewer, videoID := uuid.New(), uuid.New(), uuid.New()
+	vp := &videoProcessor{logger: slog.New(slog.NewTextHandler(io.Discard, nil)), db: repo, minioClient: store, bandwidth: newBandwidthLimiter(models.DeliveryConfig{})}
+	bucket := owner.String()
+	putObjects(t, store, bucket, map[string]string{"processed/run/720p/720p.mp4": "rendition"})
+	video := db.Video{ID: videoID, UserID: owner, Bucket: bucket, Key: "clip.mp4", Visibility: models.VisibilityPublic,
+		PlaybackPasswordHash: pgtype.Text{String: "hash", Valid: true}}
+	repo.EXPECT().GetVideo(gomock.Any(), videoID).Return(video, nil).AnyTimes()
+	repo.EXPECT().ListVideoVariants(gomock.Any(), videoID).Return([]db.VideoVariant{
+		{VideoID: videoID, VariantName: "720p", Bucket: bucket, Key: "processed/run/720p/720p.mp4", Format: FormatMP4},
+	}, nil).AnyTimes()
+	repo.EXPECT().RecordUsage(gomock.Any(), gomock.Any()).AnyTimes()
+
+	// a public video behind a password is not downloaded by viewers
+	var e models.Error
+	_, err = vp.Download(context.Background(), viewer, videoID, "720p", "")
+	require.ErrorAs(t, err, &e)
+	require.Equal(t, http.StatusNotFound, e.Code)
+
+	download, err := vp.Download(context.Background(), owner, videoID, "720p", "")
+	require.NoError(t, err)
+	require.NoError(t, download.Body.Close())
+}
+
+func TestDownloadEncrypted(t *testing.T) {
+	store, err := storage.NewLocal(t.TempDir(), "")
+	require.NoError(t, err)
+	repo := mocks.NewMockVideoRepo(gomock.NewController(t))
+	owner, viewer, videoID := uuid.New(), uuid.New(), uuid.New()
+	vp := &videoProcessor{logger: slog.New(slog.NewTextHandler(io.Discard, nil)), db: repo, minioClient: store, bandwidth: newBandwidthLimiter(models.DeliveryConfig{})}
+	bucket := owner.String()
+	putObjects(t, store, bucket, map[string]string{"processed/run/720p/720p.mp4": "rendition"})
+	video := db.Video{ID: videoID, UserID: owner, Bucket: bucket, Key: "clip.mp4", Visibility: models.VisibilityPublic}
+	repo.EXPECT().GetVideo(gomock.Any(), videoID).Return(video, nil).AnyTimes()
+	repo.EXPECT().GetVideoKey(gomock.Any(), videoID).Return([]byte("wrapped"), nil).AnyTimes()
+	repo.EXPECT().ListVideoVariants(gomock.Any(), videoID).Return([]db.VideoVariant{
+		{VideoID: videoID, VariantName: "720p", Bucket: bucket, Key: "processed/run/720p/720p.mp4", Format: FormatMP4},
+	}, nil).AnyTimes()
+	repo.EXPECT().RecordUsage(gomock.Any(), gomock.Any()).AnyTimes()
+
+	// the variant MP4 is clear, it would bypass the encryption of the segments
+	var e models.Error
+	_, err = vp.Download(context.Background(), viewer, videoID, "720p", "")
+	require.ErrorAs(t, err, &e)
+	require.Equal(t, http.StatusNotFound, e.Code)
+
+	download, err := vp.Download(context.Background(), owner, videoID, "720p", "")
+	require.NoError(t, err)
+	require.NoError(t, download.Body.Close())
+}
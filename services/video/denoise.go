@@ -0,0 +1,50 @@
+package video
+
+import (
+	"fmt"
+	"video-processing/models"
+)
+
+// Denoise filters of the denoise modes. hqdn3d is cheap and removes the grain of dim rooms.
+// nlmeans also cleans the blotchy chroma noise of low-light phone footage, at several times the
+// cost, so it runs after scaling where it has fewer pixels to compare.
+const (
+	denoiseLightFilter  = "hqdn3d=4:3:6:4.5"
+	denoiseStrongFilter = "nlmeans=s=3:p=7:r=9"
+)
+
+// denoiseFilter is the filter of denoise mode, empty for off
+func denoiseFilter(mode string) (string, error) {
+	switch mode {
+	case "", models.DenoiseOff:
+		return "", nil
+	case models.DenoiseLight:
+		return denoiseLightFilter, nil
+	case models.DenoiseStrong:
+		return denoiseStrongFilter, nil
+	}
+	return "", fmt.Errorf("denoise must be %s, %s or %s, got %q", models.DenoiseOff, models.DenoiseLight, models.DenoiseStrong, mode)
+}
+
+// denoiseChain appends the task's denoiser to the scaled video filter chain vf, before
+// subtitles and watermarks are drawn so their edges stay sharp
+func denoiseChain(vf string, task ProcessingTask) string {
+	if task.Denoise == "" {
+		return vf
+	}
+	return vf + "," + task.Denoise
+}
+
+// jobDenoise returns the denoise filter of the job: the one the upload asked for, otherwise
+// the configured one
+func (rc *redisConsumer) jobDenoise(values map[string]interface{}) string {
+	mode, _ := values["denoise"].(string)
+	if mode == "" {
+		mode = rc.processing.Denoise
+	}
+	filter, err := denoiseFilter(mode)
+	if err != nil {
+		rc.logger.Warn("invalid denoise mode, keeping the noise", "error", err, "videoID", values["video_id"])
+	}
+	return filter
+}
@@ -0,0 +1,38 @@
+package video
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"path"
+	"testing"
+	"video-processing/models"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestJobDenoise(t *testing.T) {
+	rc := &redisConsumer{logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	require.Empty(t, rc.jobDenoise(map[string]interface{}{}))
+	require.Equal(t, denoiseStrongFilter, rc.jobDenoise(map[string]interface{}{"denoise": models.DenoiseStrong}))
+
+	// the configured mode applies unless the upload asked for another
+	rc.processing.Denoise = models.DenoiseLight
+	require.Equal(t, denoiseLightFilter, rc.jobDenoise(map[string]interface{}{}))
+	require.Empty(t, rc.jobDenoise(map[string]interface{}{"denoise": models.DenoiseOff}))
+
+	rc.processing.Denoise = "heavy"
+	require.Empty(t, rc.jobDenoise(map[string]interface{}{}))
+}
+
+func TestTranscodeToMP4Denoise(t *testing.T) {
+	fake := NewFakeTranscoder()
+	task := ProcessingTask{Variant: testLadder.regular[2], SourcePath: "source.mp4", Denoise: denoiseLightFilter}
+	require.NoError(t, transcodeToMP4(context.Background(), fake, task, path.Join(t.TempDir(), "480p.mp4")))
+	require.Contains(t, fake.Calls()[0], "scale=854:480,hqdn3d=4:3:6:4.5")
+
+	// burned in subtitles are drawn on the denoised picture
+	task.BurnSubtitles = "subtitles=burn.srt"
+	require.NoError(t, transcodeToMP4(context.Background(), fake, task, path.Join(t.TempDir(), "480p.mp4")))
+	require.Contains(t, fake.Calls()[1], "scale=854:480,hqdn3d=4:3:6:4.5,subtitles=burn.srt")
+}
@@ -0,0 +1,125 @@
+package video
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"video-processing/database/db"
+	"video-processing/models"
+
+	"github.com/google/uuid"
+	"github.com/minio/minio-go/v7"
+)
+
+// derivedJob is the input of a derived video render
+type derivedJob struct {
+	VideoID    string
+	Bucket     string
+	WorkDir    string
+	SourcePath string // ffmpeg input of the job source, usually the parent's source video
+	OutPath    string // where the render must write its MP4
+	Recipe     models.Recipe
+}
+
+// renderDerived downloads the source object of a derived video job, lets render produce the
+// derived video, stores the result as the derived video's source and then runs it through
+// the regular processing pipeline.
+func (rc *redisConsumer) renderDerived(ctx context.Context, values map[string]interface{}, render func(context.Context, derivedJob) error) error {
+	bucket := values["bucket"].(string)
+	sourceObj := values["key"].(string)
+	videoID := values["video_id"].(string)
+	outputKey := values["output_key"].(string)
+	params := fmt.Sprintf("bucket: %v, source: %v, videoID: %v", bucket, sourceObj, videoID)
+
+	var recipe models.Recipe
+	if err := json.Unmarshal([]byte(values["recipe"].(string)), &recipe); err != nil {
+		return models.Error{
+			Code:        http.StatusBadRequest,
+			Message:     "invalid recipe",
+			Description: "derived video job carries no valid recipe",
+			Params:      params,
+			Err:         fmt.Errorf("failed to decode recipe: %w", err),
+		}
+	}
+	videoUUID, err := uuid.Parse(videoID)
+	if err != nil {
+		return models.Error{
+			Code:    http.StatusBadRequest,
+			Message: "invalid video id",
+			Params:  params,
+			Err:     err,
+		}
+	}
+
+	workDir, release, err := rc.acquireWorkDir(ctx, bucket, sourceObj, "video-derived-*")
+	if err != nil {
+		return workDirError(err, params)
+	}
+	defer release()
+
+	sourcePath, err := rc.fetchSource(ctx, bucket, sourceObj, workDir)
+	if err != nil {
+		return models.Error{
+			Code:        http.StatusInternalServerError,
+			Message:     "download failed",
+			Description: "failed to download source object",
+			Params:      params,
+			Err:         err,
+		}
+	}
+
+	outPath := filepath.Join(workDir, "derived.mp4")
+	rc.logger.Info("rendering derived video", "videoID", videoID, "recipe", values["recipe"])
+	err = render(ctx, derivedJob{
+		VideoID:    videoID,
+		Bucket:     bucket,
+		WorkDir:    workDir,
+		SourcePath: sourcePath,
+		OutPath:    outPath,
+		Recipe:     recipe,
+	})
+	if err != nil {
+		return models.Error{
+			Code:        http.StatusInternalServerError,
+			Message:     "internal server error",
+			Description: fmt.Sprintf("failed to render %s", recipe.Type),
+			Params:      params,
+			Err:         err,
+		}
+	}
+
+	info, err := rc.mc.FPutObject(ctx, bucket, outputKey, outPath, minio.PutObjectOptions{
+		ContentType: "video/mp4",
+	})
+	if err != nil {
+		return models.Error{
+			Code:        http.StatusInternalServerError,
+			Message:     "internal server error",
+			Description: "failed to upload rendered video",
+			Params:      params,
+			Err:         fmt.Errorf("FPutObject %s: %w", outputKey, err),
+		}
+	}
+	if err := rc.db.UpdateVideoFileSize(ctx, db.UpdateVideoFileSizeParams{
+		FileSizeBytes: info.Size,
+		ID:            videoUUID,
+	}); err != nil {
+		rc.logger.Error("failed to update derived video size", "error", err, "videoID", videoID)
+	}
+	if owner, err := uuid.Parse(fmt.Sprint(values["user_id"])); err == nil {
+		recordStorage(ctx, rc.logger, rc.db, owner)
+	}
+
+	// the render is uploaded, free its scratch space before the derived video is processed
+	release()
+	return rc.ProcessVideo(ctx, map[string]interface{}{
+		"bucket":    bucket,
+		"key":       outputKey,
+		"video_id":  videoID,
+		"user_id":   values["user_id"],
+		"preset_id": values["preset_id"],
+		"mode":      values["mode"],
+	})
+}
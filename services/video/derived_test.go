@@ -0,0 +1,59 @@
+package video
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"video-processing/mocks"
+	"video-processing/storage"
+
+	"github.com/google/uuid"
+	"github.com/minio/minio-go/v7"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestDerivedRenderKeepsOwnerPrefix(t *testing.T) {
+	ctx := context.Background()
+	store, err := storage.NewLocal(t.TempDir(), "")
+	require.NoError(t, err)
+	require.NoError(t, store.MakeBucket(ctx, "shared", minio.MakeBucketOptions{}))
+	owner := uuid.NewString()
+	_, err = store.PutObject(ctx, "shared", owner+"/source.mp4", strings.NewReader("source"), 6, minio.PutObjectOptions{})
+	require.NoError(t, err)
+
+	fake := NewFakeTranscoder()
+	fake.ProbeOutput = []byte(`{"streams":[{"codec_type":"video","codec_name":"h264","width":1280,"height":720}],"format":{"duration":"30.0"}}`)
+	repo := mocks.NewMockVideoRepo(gomock.NewController(t))
+	repo.EXPECT().GetDefaultTranscodingPreset(gomock.Any()).Return(defaultPresetRow(t), nil).AnyTimes()
+	rc := &redisConsumer{
+		logger:     slog.New(slog.NewTextHandler(io.Discard, nil)),
+		mc:         store,
+		db:         repo,
+		transcoder: fake,
+		scratch:    &scratchSpace{dir: t.TempDir()},
+	}
+
+	// in a shared bucket the renditions of a clip stay under the prefix of its owner
+	plan, err := rc.planJob(ctx, map[string]interface{}{
+		"type":       JobTypeClip,
+		"bucket":     "shared",
+		"key":        owner + "/source.mp4",
+		"output_key": owner + "/derived/clip.mp4",
+		"video_id":   uuid.NewString(),
+		"user_id":    owner,
+		"recipe":     `{"type":"clip","clip":{"start":2,"end":12}}`,
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, plan.Uploads)
+	var renditions int
+	for _, u := range plan.Uploads {
+		require.True(t, strings.HasPrefix(u.Key, owner+"/"), u.Key)
+		if strings.HasPrefix(u.Key, owner+"/"+processedPrefix) {
+			renditions++
+		}
+	}
+	require.NotZero(t, renditions)
+}
@@ -0,0 +1,354 @@
+package video
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"video-processing/models"
+
+	"github.com/google/uuid"
+)
+
+// CENC protection schemes of processing.drm.scheme
+const (
+	DRMSchemeCBCS = "cbcs"
+	DRMSchemeCENC = "cenc"
+)
+
+// DRM systems of processing.drm.systems
+const (
+	DRMWidevine  = "widevine"
+	DRMPlayReady = "playready"
+	DRMFairPlay  = "fairplay"
+)
+
+// Content key providers of processing.drm.key_provider.type
+const (
+	KeyProviderStatic = "static"
+	KeyProviderHTTP   = "http"
+)
+
+// defaultKeyTimeout bounds a request to a key server without a configured timeout
+const defaultKeyTimeout = 10 * time.Second
+
+// packagerSystems are the names Shaka Packager's --protection_systems knows the DRM systems by
+var packagerSystems = map[string]string{
+	DRMWidevine:  "Widevine",
+	DRMPlayReady: "PlayReady",
+	DRMFairPlay:  "FairPlay",
+}
+
+// ContentKey is the CENC key the renditions of a video are encrypted with
+type ContentKey struct {
+	KeyID []byte
+	Key   []byte
+	// URI is the key URI of the HLS playlists, e.g. an skd:// URI for FairPlay; empty leaves
+	// it to the packager
+	URI string
+}
+
+// KeyProvider hands out the content keys of videos, e.g. from the key server of a DRM vendor,
+// which also serves the licenses players ask for
+type KeyProvider interface {
+	ContentKey(ctx context.Context, videoID uuid.UUID) (ContentKey, error)
+}
+
+// NewKeyProvider builds the key provider the configuration asks for, nil when none
+func NewKeyProvider(config models.DRMKeyProviderConfig) (KeyProvider, error) {
+	switch config.Type {
+	case "":
+		return nil, nil
+	case KeyProviderStatic:
+		key, err := parseContentKey(config.KeyID, config.Key, config.KeyURI)
+		if err != nil {
+			return nil, err
+		}
+		return staticKeyProvider{key: key}, nil
+	case KeyProviderHTTP:
+		if config.URL == "" {
+			return nil, fmt.Errorf("%s key provider needs a url", KeyProviderHTTP)
+		}
+		timeout := config.Timeout
+		if timeout <= 0 {
+			timeout = defaultKeyTimeout
+		}
+		return &httpKeyProvider{url: config.URL, token: config.Token, client: &http.Client{Timeout: timeout}}, nil
+	}
+	return nil, fmt.Errorf("unknown key provider %q", config.Type)
+}
+
+// parseContentKey decodes a hex encoded key id and key of 16 bytes each
+func parseContentKey(keyID, key, uri string) (ContentKey, error) {
+	id, err := hex.DecodeString(keyID)
+	if err != nil || len(id) != 16 {
+		return ContentKey{}, fmt.Errorf("key id must be 16 hex encoded bytes")
+	}
+	k, err := hex.DecodeString(key)
+	if err != nil || len(k) != 16 {
+		return ContentKey{}, fmt.Errorf("key must be 16 hex encoded bytes")
+	}
+	return ContentKey{KeyID: id, Key: k, URI: uri}, nil
+}
+
+// staticKeyProvider encrypts every video with the same key
+type staticKeyProvider struct {
+	key ContentKey
+}
+
+func (p staticKeyProvider) ContentKey(ctx context.Context, videoID uuid.UUID) (ContentKey, error) {
+	return p.key, nil
+}
+
+// httpKeyProvider posts {"video_id": "..."} to a key server, which answers with the hex encoded
+// key_id and key of the video and optionally its key_uri
+type httpKeyProvider struct {
+	url    string
+	token  string
+	client *http.Client
+}
+
+func (p *httpKeyProvider) ContentKey(ctx context.Context, videoID uuid.UUID) (ContentKey, error) {
+	payload, err := json.Marshal(map[string]string{"video_id": videoID.String()})
+	if err != nil {
+		return ContentKey{}, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(payload))
+	if err != nil {
+		return ContentKey{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if p.token != "" {
+		req.Header.Set("Authorization", "Bearer "+p.token)
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return ContentKey{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return ContentKey{}, fmt.Errorf("key server answered %s", resp.Status)
+	}
+	var body struct {
+		KeyID  string `json:"key_id"`
+		Key    string `json:"key"`
+		KeyURI string `json:"key_uri"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return ContentKey{}, fmt.Errorf("invalid key server answer: %w", err)
+	}
+	return parseContentKey(body.KeyID, body.Key, body.KeyURI)
+}
+
+// Packager runs Shaka Packager. Like Transcoder, it lets the DRM stage be tested on machines
+// without the binary.
+type Packager interface {
+	// Package runs the packager with args. Errors carry its output.
+	Package(ctx context.Context, args ...string) error
+}
+
+// ExecPackager runs the Shaka Packager binary
+type ExecPackager struct {
+	binary string
+}
+
+// NewExecPackager runs binary, "packager" from the PATH when empty
+func NewExecPackager(binary string) Packager {
+	if binary == "" {
+		binary = "packager"
+	}
+	return ExecPackager{binary: binary}
+}
+
+func (p ExecPackager) Package(ctx context.Context, args ...string) error {
+	out, err := exec.CommandContext(ctx, p.binary, args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%w, output: %s", err, string(out))
+	}
+	return nil
+}
+
+// drmSystems returns the packager names of the DRM systems the manifests signal under scheme.
+// FairPlay only plays cbcs.
+func drmSystems(scheme string, systems []string) ([]string, error) {
+	switch scheme {
+	case "", DRMSchemeCBCS:
+		if len(systems) == 0 {
+			systems = []string{DRMWidevine, DRMFairPlay}
+		}
+	case DRMSchemeCENC:
+		if len(systems) == 0 {
+			systems = []string{DRMWidevine, DRMPlayReady}
+		}
+		if slices.Contains(systems, DRMFairPlay) {
+			return nil, fmt.Errorf("%s needs the %s scheme", DRMFairPlay, DRMSchemeCBCS)
+		}
+	default:
+		return nil, fmt.Errorf("drm scheme must be %s or %s, got %q", DRMSchemeCBCS, DRMSchemeCENC, scheme)
+	}
+	names := make([]string, 0, len(systems))
+	for _, system := range systems {
+		name, ok := packagerSystems[system]
+		if !ok {
+			return nil, fmt.Errorf("unknown drm system %q", system)
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+// drmStream is a rendition the packager encrypts into a directory of its own
+type drmStream struct {
+	Path  string // MP4 or m4a the stream is read from
+	Name  string // directory of its segments and playlist
+	Audio bool
+}
+
+// drmPackagerArgs builds the Shaka Packager arguments that encrypt streams with key into CMAF
+// segments under outDir, listed by master.m3u8 for HLS and manifest.mpd for DASH. Both
+// manifests share the segments, and every segment is encrypted, none is left in the clear.
+func drmPackagerArgs(streams []drmStream, outDir string, key ContentKey, scheme string, systems []string, segmentSeconds int) []string {
+	var args []string
+	for _, s := range streams {
+		kind := "video"
+		if s.Audio {
+			kind = "audio"
+		}
+		descriptor := fmt.Sprintf("in=%s,stream=%s,init_segment=%s,segment_template=%s,playlist_name=%s",
+			s.Path, kind,
+			filepath.Join(outDir, s.Name, "init.mp4"),
+			filepath.Join(outDir, s.Name, "segment_$Number%03d$.m4s"),
+			s.Name+"/index.m3u8")
+		if s.Audio {
+			descriptor += ",hls_group_id=audio,hls_name=" + s.Name
+		}
+		args = append(args, descriptor)
+	}
+	if scheme == "" {
+		scheme = DRMSchemeCBCS
+	}
+	args = append(args,
+		"--protection_scheme", scheme,
+		"--enable_raw_key_encryption",
+		"--keys", fmt.Sprintf("key_id=%s:key=%s", hex.EncodeToString(key.KeyID), hex.EncodeToString(key.Key)),
+		"--protection_systems", strings.Join(systems, ","),
+	)
+	if key.URI != "" {
+		args = append(args, "--hls_key_uri", key.URI)
+	}
+	return append(args,
+		"--clear_lead", "0",
+		"--segment_duration", strconv.Itoa(segmentSeconds),
+		"--hls_playlist_type", "VOD",
+		"--hls_master_playlist_output", filepath.Join(outDir, "master.m3u8"),
+		"--mpd_output", filepath.Join(outDir, "manifest.mpd"),
+	)
+}
+
+// drmStage packages the renditions of premium jobs with DRM
+type drmStage struct {
+	config   models.DRMConfig
+	systems  []string
+	keys     KeyProvider
+	packager Packager
+}
+
+// newDRMStage returns the DRM stage the configuration sets up, nil without a key provider
+func newDRMStage(config models.DRMConfig, packager Packager) (*drmStage, error) {
+	keys, err := NewKeyProvider(config.KeyProvider)
+	if err != nil || keys == nil {
+		return nil, err
+	}
+	systems, err := drmSystems(config.Scheme, config.Systems)
+	if err != nil {
+		return nil, err
+	}
+	return &drmStage{config: config, systems: systems, keys: keys, packager: packager}, nil
+}
+
+// publishDRM encrypts the variants of videos and the audio into HLS and DASH under the drm
+// directory of the results, and stores the manifests as the drm_playlist and drm_manifest
+// assets. The audio is read from the audio-only rendition, or from the first variant when the
+// job has none. Failures are logged; the clear renditions are published either way.
+func (rc *redisConsumer) publishDRM(ctx context.Context, task ProcessingTask, videos, audio []ProcessingResult, hasAudio bool, uploadCh chan<- UploadTask) {
+	if rc.drm == nil {
+		rc.logger.Error("DRM packaging requested but no key provider is configured", "videoID", task.VideoID)
+		return
+	}
+	videoUUID, err := uuid.Parse(task.VideoID)
+	if err != nil {
+		rc.logger.Error("invalid video ID for DRM packaging", "error", err, "videoID", task.VideoID)
+		return
+	}
+	key, err := rc.drm.keys.ContentKey(ctx, videoUUID)
+	if err != nil {
+		rc.logger.Error("failed to get the content key", "error", err, "videoID", task.VideoID)
+		return
+	}
+
+	streams := make([]drmStream, 0, len(videos)+1)
+	for _, v := range videos {
+		streams = append(streams, drmStream{Path: variantMP4Path(ProcessingTask{WorkDir: v.WorkDir, Variant: v.Variant}), Name: v.Variant.Name})
+	}
+	switch {
+	case len(audio) > 0:
+		m4aPath := filepath.Join(audio[0].WorkDir, models.AudioVariantName, models.AudioVariantName+".m4a")
+		streams = append(streams, drmStream{Path: m4aPath, Name: models.AudioVariantName, Audio: true})
+	case hasAudio && len(streams) > 0:
+		streams = append(streams, drmStream{Path: streams[0].Path, Name: models.AudioVariantName, Audio: true})
+	}
+
+	outDir := filepath.Join(task.WorkDir, "drm")
+	if err := os.MkdirAll(outDir, 0o755); err != nil {
+		rc.logger.Error("failed to create DRM directory", "error", err, "videoID", task.VideoID)
+		return
+	}
+	args := drmPackagerArgs(streams, outDir, key, rc.drm.config.Scheme, rc.drm.systems, videos[0].Variant.segmentSeconds())
+	if err := rc.drm.packager.Package(ctx, args...); err != nil {
+		rc.logger.Error("DRM packaging failed", "error", err, "videoID", task.VideoID)
+		return
+	}
+
+	destPrefix := filepath.ToSlash(filepath.Join(task.DestPrefix, "drm"))
+	err = filepath.WalkDir(outDir, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(outDir, path)
+		if err != nil {
+			return err
+		}
+		file := UploadTask{
+			SourcePath:  path,
+			ObjectKey:   filepath.ToSlash(filepath.Join(destPrefix, rel)),
+			ContentType: mimeTypeByExt(filepath.Ext(path)),
+			Bucket:      task.Bucket,
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case uploadCh <- file:
+		}
+		switch rel {
+		case "master.m3u8":
+			rc.saveVideoAsset(ctx, videoUUID, AssetKindDRMPlaylist, file)
+		case "manifest.mpd":
+			rc.saveVideoAsset(ctx, videoUUID, AssetKindDRMManifest, file)
+		}
+		return nil
+	})
+	if err != nil {
+		rc.logger.Error("failed to upload DRM renditions", "error", err, "videoID", task.VideoID)
+		return
+	}
+	rc.logger.Info("published DRM renditions", "videoID", task.VideoID, "streams", len(streams))
+}
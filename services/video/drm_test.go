@@ -0,0 +1,173 @@
+package video
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"video-processing/database/db"
+	"video-processing/mocks"
+	"video-processing/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+const (
+	testKeyID = "00112233445566778899aabbccddeeff"
+	testKey   = "ffeeddccbbaa99887766554433221100"
+)
+
+// fakePackager records its runs and writes the manifests and a segment per stream
+type fakePackager struct {
+	mu    sync.Mutex
+	calls [][]string
+}
+
+func (p *fakePackager) Package(ctx context.Context, args ...string) error {
+	p.mu.Lock()
+	p.calls = append(p.calls, args)
+	p.mu.Unlock()
+	for i, arg := range args {
+		if _, rest, ok := strings.Cut(arg, "init_segment="); ok {
+			init, _, _ := strings.Cut(rest, ",")
+			if err := os.MkdirAll(filepath.Dir(init), 0o755); err != nil {
+				return err
+			}
+			if err := os.WriteFile(init, []byte("fake init"), 0o644); err != nil {
+				return err
+			}
+		}
+		if (arg == "--hls_master_playlist_output" || arg == "--mpd_output") && i+1 < len(args) {
+			if err := os.WriteFile(args[i+1], []byte("fake manifest"), 0o644); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func TestDRMSystems(t *testing.T) {
+	systems, err := drmSystems("", nil)
+	require.NoError(t, err)
+	require.Equal(t, []string{"Widevine", "FairPlay"}, systems)
+	systems, err = drmSystems(DRMSchemeCENC, nil)
+	require.NoError(t, err)
+	require.Equal(t, []string{"Widevine", "PlayReady"}, systems)
+
+	// FairPlay cannot play AES-CTR
+	_, err = drmSystems(DRMSchemeCENC, []string{DRMWidevine, DRMFairPlay})
+	require.Error(t, err)
+	_, err = drmSystems("cbc1", nil)
+	require.Error(t, err)
+	_, err = drmSystems(DRMSchemeCBCS, []string{"clearkey"})
+	require.Error(t, err)
+}
+
+func TestDRMPackagerArgs(t *testing.T) {
+	key, err := parseContentKey(testKeyID, testKey, "skd://key")
+	require.NoError(t, err)
+	args := drmPackagerArgs([]drmStream{
+		{Path: "/work/1080p/1080p.mp4", Name: "1080p"},
+		{Path: "/work/audio/audio.m4a", Name: "audio", Audio: true},
+	}, "/work/drm", key, "", []string{"Widevine", "FairPlay"}, 4)
+	require.Equal(t, []string{
+		"in=/work/1080p/1080p.mp4,stream=video,init_segment=/work/drm/1080p/init.mp4,segment_template=/work/drm/1080p/segment_$Number%03d$.m4s,playlist_name=1080p/index.m3u8",
+		"in=/work/audio/audio.m4a,stream=audio,init_segment=/work/drm/audio/init.mp4,segment_template=/work/drm/audio/segment_$Number%03d$.m4s,playlist_name=audio/index.m3u8,hls_group_id=audio,hls_name=audio",
+		"--protection_scheme", "cbcs",
+		"--enable_raw_key_encryption",
+		"--keys", "key_id=" + testKeyID + ":key=" + testKey,
+		"--protection_systems", "Widevine,FairPlay",
+		"--hls_key_uri", "skd://key",
+		"--clear_lead", "0",
+		"--segment_duration", "4",
+		"--hls_playlist_type", "VOD",
+		"--hls_master_playlist_output", "/work/drm/master.m3u8",
+		"--mpd_output", "/work/drm/manifest.mpd",
+	}, args)
+}
+
+func TestNewKeyProvider(t *testing.T) {
+	provider, err := NewKeyProvider(models.DRMKeyProviderConfig{})
+	require.NoError(t, err)
+	require.Nil(t, provider)
+	_, err = NewKeyProvider(models.DRMKeyProviderConfig{Type: KeyProviderStatic, KeyID: testKeyID, Key: "abcd"})
+	require.Error(t, err)
+	_, err = NewKeyProvider(models.DRMKeyProviderConfig{Type: KeyProviderHTTP})
+	require.Error(t, err)
+	_, err = NewKeyProvider(models.DRMKeyProviderConfig{Type: "vault"})
+	require.Error(t, err)
+
+	// key servers are asked for the key of each video
+	videoID := uuid.New()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		var body map[string]string
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		require.Equal(t, videoID.String(), body["video_id"])
+		json.NewEncoder(w).Encode(map[string]string{"key_id": testKeyID, "key": testKey})
+	}))
+	defer server.Close()
+	provider, err = NewKeyProvider(models.DRMKeyProviderConfig{Type: KeyProviderHTTP, URL: server.URL, Token: "secret"})
+	require.NoError(t, err)
+	key, err := provider.ContentKey(context.Background(), videoID)
+	require.NoError(t, err)
+	require.Len(t, key.KeyID, 16)
+	require.Len(t, key.Key, 16)
+	require.Empty(t, key.URI)
+}
+
+func TestPublishDRM(t *testing.T) {
+	repo := mocks.NewMockVideoRepo(gomock.NewController(t))
+	packager := &fakePackager{}
+	stage, err := newDRMStage(models.DRMConfig{KeyProvider: models.DRMKeyProviderConfig{
+		Type: KeyProviderStatic, KeyID: testKeyID, Key: testKey,
+	}}, packager)
+	require.NoError(t, err)
+	rc := &redisConsumer{logger: slog.New(slog.NewTextHandler(io.Discard, nil)), db: repo, drm: stage}
+	workDir := t.TempDir()
+	videoID := uuid.New()
+	task := ProcessingTask{WorkDir: workDir, DestPrefix: "processed/job", Bucket: "videos", VideoID: videoID.String()}
+	videos := []ProcessingResult{
+		{Variant: Variant{Name: "1080p", SegmentSeconds: 4}, WorkDir: workDir},
+		{Variant: Variant{Name: "720p", SegmentSeconds: 4}, WorkDir: workDir},
+	}
+
+	repo.EXPECT().SaveVideoAsset(gomock.Any(), db.SaveVideoAssetParams{
+		VideoID: videoID, Kind: AssetKindDRMPlaylist, Bucket: "videos", Key: "processed/job/drm/master.m3u8", ContentType: "application/vnd.apple.mpegurl",
+	})
+	repo.EXPECT().SaveVideoAsset(gomock.Any(), db.SaveVideoAssetParams{
+		VideoID: videoID, Kind: AssetKindDRMManifest, Bucket: "videos", Key: "processed/job/drm/manifest.mpd", ContentType: "application/dash+xml",
+	})
+	uploadCh := make(chan UploadTask, 10)
+	// without an audio-only rendition the audio comes from the first variant
+	rc.publishDRM(context.Background(), task, videos, nil, true, uploadCh)
+	close(uploadCh)
+
+	require.Len(t, packager.calls, 1)
+	require.Contains(t, packager.calls[0][2], "in="+filepath.Join(workDir, "1080p", "1080p.mp4")+",stream=audio")
+	var keys []string
+	for file := range uploadCh {
+		keys = append(keys, file.ObjectKey)
+	}
+	require.ElementsMatch(t, []string{
+		"processed/job/drm/1080p/init.mp4",
+		"processed/job/drm/720p/init.mp4",
+		"processed/job/drm/audio/init.mp4",
+		"processed/job/drm/master.m3u8",
+		"processed/job/drm/manifest.mpd",
+	}, keys)
+
+	// jobs asking for DRM without a key provider keep their clear renditions only
+	rc.drm = nil
+	rc.publishDRM(context.Background(), task, videos, nil, true, make(chan UploadTask, 10))
+	require.Len(t, packager.calls, 1)
+}
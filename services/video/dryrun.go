@@ -0,0 +1,332 @@
+package video
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"video-processing/database/db"
+
+	"github.com/google/uuid"
+	"github.com/minio/minio-go/v7"
+)
+
+// JobPlan is what a job would do, resolved by a dry run without encoding, uploading or
+// writing anything
+type JobPlan struct {
+	Job map[string]interface{} `json:"job"`
+	// Commands are the ffmpeg commands that are not tied to a variant, in the order they were issued
+	Commands [][]string `json:"commands"`
+	// VariantCommands are the ffmpeg commands per variant name
+	VariantCommands map[string][][]string `json:"variant_commands"`
+	Uploads         []PlannedUpload       `json:"uploads"`
+	Writes          []PlannedWrite        `json:"writes"`
+	Error           string                `json:"error,omitempty"`
+}
+
+// PlannedUpload is an object a job would store
+type PlannedUpload struct {
+	Bucket      string `json:"bucket"`
+	Key         string `json:"key"`
+	ContentType string `json:"content_type,omitempty"`
+}
+
+// PlannedWrite is a database write a job would make
+type PlannedWrite struct {
+	Query  string      `json:"query"`
+	Params interface{} `json:"params"`
+}
+
+// jobPlanner collects a JobPlan from the jobs of a dry run
+type jobPlanner struct {
+	mu   sync.Mutex
+	plan JobPlan
+	// objects are the planned uploads, so later steps of the same job can read them back
+	objects map[string]bool
+}
+
+func (p *jobPlanner) upload(bucket, key, contentType string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.plan.Uploads = append(p.plan.Uploads, PlannedUpload{Bucket: bucket, Key: key, ContentType: contentType})
+	p.objects[bucket+"/"+key] = true
+}
+
+func (p *jobPlanner) planned(bucket, key string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.objects[bucket+"/"+key]
+}
+
+func (p *jobPlanner) write(query string, params interface{}) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.plan.Writes = append(p.plan.Writes, PlannedWrite{Query: query, Params: params})
+}
+
+// dryRun logs the plan of a job instead of executing it
+func (rc *redisConsumer) dryRun(ctx context.Context, values map[string]interface{}) error {
+	plan, err := rc.planJob(ctx, values)
+	rc.logger.Info("dry run plan", "plan", plan)
+	return err
+}
+
+// planJob resolves what a job would do. Sources are still downloaded and probed, as the
+// ladder depends on them; every ffmpeg command that would encode is recorded and fabricates
+// a placeholder output so the pipeline carries on.
+func (rc *redisConsumer) planJob(ctx context.Context, values map[string]interface{}) (JobPlan, error) {
+	planner := &jobPlanner{plan: JobPlan{Job: values}, objects: map[string]bool{}}
+	fake := NewFakeTranscoder()
+
+	dry := *rc
+	dry.processing.DryRun = false
+	dry.transcoder = &planTranscoder{FakeTranscoder: fake, probe: rc.transcoder}
+	dry.mc = &planStore{ObjectStore: rc.mc, planner: planner}
+	dry.db = &planRepo{VideoRepo: rc.db, planner: planner}
+	dry.sources = nil // placeholder outputs must not end up in the source cache
+	dry.hooks = nil   // custom steps would act on placeholder outputs
+	dry.notifier = nil
+	err := dry.handleJob(ctx, values)
+
+	plan := planner.plan
+	var names []string
+	if l, ladderErr := rc.loadLadder(ctx, values); ladderErr == nil {
+		for _, v := range l.all() {
+			names = append(names, v.Name)
+		}
+	}
+	plan.Commands, plan.VariantCommands = groupCommands(fake.Calls(), names)
+	sort.Slice(plan.Uploads, func(i, j int) bool { return plan.Uploads[i].Key < plan.Uploads[j].Key })
+	if err != nil {
+		plan.Error = err.Error()
+	}
+	return plan, err
+}
+
+// groupCommands prefixes the recorded commands with ffmpeg and sorts out the ones that
+// write into the directory of one of the named variants. The order of concurrent commands
+// is not deterministic.
+func groupCommands(calls [][]string, variantNames []string) ([][]string, map[string][][]string) {
+	names := map[string]bool{}
+	for _, name := range variantNames {
+		names[name] = true
+	}
+	var common [][]string
+	byVariant := map[string][][]string{}
+	for _, args := range calls {
+		cmd := append([]string{"ffmpeg"}, args...)
+		variant := ""
+		for _, a := range args {
+			for _, dir := range strings.Split(filepath.ToSlash(a), "/") {
+				if names[dir] {
+					variant = dir
+				}
+			}
+		}
+		if variant == "" {
+			common = append(common, cmd)
+		} else {
+			byVariant[variant] = append(byVariant[variant], cmd)
+		}
+	}
+	return common, byVariant
+}
+
+// planTranscoder records ffmpeg commands instead of running them but still runs ffprobe,
+// whose output decides what the job does
+type planTranscoder struct {
+	*FakeTranscoder
+	probe Transcoder
+}
+
+func (t *planTranscoder) Probe(ctx context.Context, args ...string) ([]byte, error) {
+	return t.probe.Probe(ctx, args...)
+}
+
+// planStore records uploads instead of storing objects and reads everything else through
+type planStore struct {
+	ObjectStore
+	planner *jobPlanner
+}
+
+func (s *planStore) MakeBucket(ctx context.Context, bucketName string, opts minio.MakeBucketOptions) error {
+	return nil
+}
+
+func (s *planStore) PutObject(ctx context.Context, bucketName, objectName string, reader io.Reader, size int64, opts minio.PutObjectOptions) (minio.UploadInfo, error) {
+	s.planner.upload(bucketName, objectName, opts.ContentType)
+	return minio.UploadInfo{Bucket: bucketName, Key: objectName, Size: size}, nil
+}
+
+func (s *planStore) FPutObject(ctx context.Context, bucketName, objectName, filePath string, opts minio.PutObjectOptions) (minio.UploadInfo, error) {
+	s.planner.upload(bucketName, objectName, opts.ContentType)
+	var size int64
+	if info, err := os.Stat(filePath); err == nil {
+		size = info.Size()
+	}
+	return minio.UploadInfo{Bucket: bucketName, Key: objectName, Size: size}, nil
+}
+
+// FGetObject serves objects planned earlier in the job, e.g. a rendered derived video, as empty files
+func (s *planStore) FGetObject(ctx context.Context, bucketName, objectName, filePath string, opts minio.GetObjectOptions) error {
+	if s.planner.planned(bucketName, objectName) {
+		return os.WriteFile(filePath, nil, 0o644)
+	}
+	return s.ObjectStore.FGetObject(ctx, bucketName, objectName, filePath, opts)
+}
+
+func (s *planStore) StatObject(ctx context.Context, bucketName, objectName string, opts minio.StatObjectOptions) (minio.ObjectInfo, error) {
+	if s.planner.planned(bucketName, objectName) {
+		return minio.ObjectInfo{Key: objectName}, nil
+	}
+	return s.ObjectStore.StatObject(ctx, bucketName, objectName, opts)
+}
+
+// planRepo records the writes of the worker instead of making them and reads everything else through
+type planRepo struct {
+	VideoRepo
+	planner *jobPlanner
+}
+
+func (r *planRepo) CreateVideo(ctx context.Context, arg db.CreateVideoParams) (db.Video, error) {
+	r.planner.write("CreateVideo", arg)
+	return db.Video{ID: uuid.New(), UserID: arg.UserID, Bucket: arg.Bucket, Key: arg.Key}, nil
+}
+
+func (r *planRepo) CreateDerivedVideo(ctx context.Context, arg db.CreateDerivedVideoParams) (db.Video, error) {
+	r.planner.write("CreateDerivedVideo", arg)
+	return db.Video{ID: uuid.New(), UserID: arg.UserID, Bucket: arg.Bucket, Key: arg.Key}, nil
+}
+
+func (r *planRepo) UpdateVideoFileSize(ctx context.Context, arg db.UpdateVideoFileSizeParams) error {
+	r.planner.write("UpdateVideoFileSize", arg)
+	return nil
+}
+
+func (r *planRepo) UpdateVideoColorMetadata(ctx context.Context, arg db.UpdateVideoColorMetadataParams) error {
+	r.planner.write("UpdateVideoColorMetadata", arg)
+	return nil
+}
+
+func (r *planRepo) UpdateVideoProjection(ctx context.Context, arg db.UpdateVideoProjectionParams) error {
+	r.planner.write("UpdateVideoProjection", arg)
+	return nil
+}
+
+func (r *planRepo) UpdateVideoSourceResolution(ctx context.Context, arg db.UpdateVideoSourceResolutionParams) error {
+	r.planner.write("UpdateVideoSourceResolution", arg)
+	return nil
+}
+
+func (r *planRepo) UpdateVideoSourceMetadata(ctx context.Context, arg db.UpdateVideoSourceMetadataParams) error {
+	r.planner.write("UpdateVideoSourceMetadata", arg)
+	return nil
+}
+
+func (r *planRepo) FailVideo(ctx context.Context, arg db.FailVideoParams) error {
+	r.planner.write("FailVideo", arg)
+	return nil
+}
+
+func (r *planRepo) ClearVideoFailure(ctx context.Context, id uuid.UUID) error {
+	r.planner.write("ClearVideoFailure", id)
+	return nil
+}
+
+func (r *planRepo) UpdateVideoStatus(ctx context.Context, arg db.UpdateVideoStatusParams) (db.Video, error) {
+	r.planner.write("UpdateVideoStatus", arg)
+	return db.Video{ID: arg.ID, Status: arg.Status}, nil
+}
+
+func (r *planRepo) SaveProcessedVideoMetadata(ctx context.Context, arg db.SaveProcessedVideoMetadataParams) (db.VideoVariant, error) {
+	r.planner.write("SaveProcessedVideoMetadata", arg)
+	return db.VideoVariant{VideoID: arg.VideoID, VariantName: arg.VariantName}, nil
+}
+
+func (r *planRepo) UpdateVariantQuality(ctx context.Context, arg db.UpdateVariantQualityParams) error {
+	r.planner.write("UpdateVariantQuality", arg)
+	return nil
+}
+
+func (r *planRepo) ArchiveRenditions(ctx context.Context, videoID uuid.UUID) (db.VideoRenditionVersion, error) {
+	r.planner.write("ArchiveRenditions", videoID)
+	return db.VideoRenditionVersion{VideoID: videoID}, nil
+}
+
+func (r *planRepo) RecordUsage(ctx context.Context, arg db.RecordUsageParams) error {
+	r.planner.write("RecordUsage", arg)
+	return nil
+}
+
+func (r *planRepo) SetResultSize(ctx context.Context, arg db.SetResultSizeParams) error {
+	r.planner.write("SetResultSize", arg)
+	return nil
+}
+
+func (r *planRepo) RecordStorageHeld(ctx context.Context, userID uuid.UUID) error {
+	r.planner.write("RecordStorageHeld", userID)
+	return nil
+}
+
+func (r *planRepo) SaveVideoAsset(ctx context.Context, arg db.SaveVideoAssetParams) (db.VideoAsset, error) {
+	r.planner.write("SaveVideoAsset", arg)
+	return db.VideoAsset{VideoID: arg.VideoID, Kind: arg.Kind, Bucket: arg.Bucket, Key: arg.Key}, nil
+}
+
+func (r *planRepo) SetVideoMasterPlaylist(ctx context.Context, arg db.SetVideoMasterPlaylistParams) error {
+	r.planner.write("SetVideoMasterPlaylist", arg)
+	return nil
+}
+
+func (r *planRepo) CreateVideoChapter(ctx context.Context, arg db.CreateVideoChapterParams) (db.VideoChapter, error) {
+	r.planner.write("CreateVideoChapter", arg)
+	return db.VideoChapter{VideoID: arg.VideoID}, nil
+}
+
+func (r *planRepo) DeleteVideoChapters(ctx context.Context, videoID uuid.UUID) error {
+	r.planner.write("DeleteVideoChapters", videoID)
+	return nil
+}
+
+func (r *planRepo) SaveVideoSubtitle(ctx context.Context, arg db.SaveVideoSubtitleParams) (db.VideoSubtitle, error) {
+	r.planner.write("SaveVideoSubtitle", arg)
+	return db.VideoSubtitle{VideoID: arg.VideoID, Track: arg.Track}, nil
+}
+
+func (r *planRepo) DeleteVideoSubtitles(ctx context.Context, videoID uuid.UUID) error {
+	r.planner.write("DeleteVideoSubtitles", videoID)
+	return nil
+}
+
+func (r *planRepo) SaveVideoThumbnail(ctx context.Context, arg db.SaveVideoThumbnailParams) (db.VideoThumbnail, error) {
+	r.planner.write("SaveVideoThumbnail", arg)
+	return db.VideoThumbnail{VideoID: arg.VideoID, Position: arg.Position}, nil
+}
+
+func (r *planRepo) DeleteVideoThumbnailsFrom(ctx context.Context, arg db.DeleteVideoThumbnailsFromParams) error {
+	r.planner.write("DeleteVideoThumbnailsFrom", arg)
+	return nil
+}
+
+func (r *planRepo) SaveVideoProgress(ctx context.Context, arg db.SaveVideoProgressParams) error {
+	r.planner.write("SaveVideoProgress", arg)
+	return nil
+}
+
+func (r *planRepo) DeleteVideoProgress(ctx context.Context, videoID uuid.UUID) error {
+	r.planner.write("DeleteVideoProgress", videoID)
+	return nil
+}
+
+func (r *planRepo) SaveVideoFingerprint(ctx context.Context, arg db.SaveVideoFingerprintParams) error {
+	r.planner.write("SaveVideoFingerprint", arg)
+	return nil
+}
+
+func (r *planRepo) SaveVideoProbe(ctx context.Context, arg db.SaveVideoProbeParams) error {
+	r.planner.write("SaveVideoProbe", arg)
+	return nil
+}
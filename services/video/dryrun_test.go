@@ -0,0 +1,78 @@
+package video
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"video-processing/database/db"
+	"video-processing/mocks"
+	"video-processing/storage"
+
+	"github.com/google/uuid"
+	"github.com/minio/minio-go/v7"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestPlanJobExecutesNothing(t *testing.T) {
+	ctx := context.Background()
+	store, err := storage.NewLocal(t.TempDir(), "")
+	require.NoError(t, err)
+	require.NoError(t, store.MakeBucket(ctx, "b1", minio.MakeBucketOptions{}))
+	_, err = store.PutObject(ctx, "b1", "in.mp4", strings.NewReader("source"), 6, minio.PutObjectOptions{})
+	require.NoError(t, err)
+
+	fake := NewFakeTranscoder()
+	fake.ProbeOutput = []byte(`{"streams":[{"codec_type":"video","codec_name":"hevc","width":1920,"height":1080}],"format":{"duration":"30.0"}}`)
+	scratch := t.TempDir()
+	repo := mocks.NewMockVideoRepo(gomock.NewController(t)) // fails on any other call
+	repo.EXPECT().GetDefaultTranscodingPreset(gomock.Any()).Return(defaultPresetRow(t), nil).AnyTimes()
+	rc := &redisConsumer{
+		logger:     slog.New(slog.NewTextHandler(io.Discard, nil)),
+		mc:         store,
+		db:         repo,
+		transcoder: fake,
+		scratch:    &scratchSpace{dir: scratch},
+	}
+	rc.processing.DryRun = true
+
+	videoID := uuid.NewString()
+	plan, err := rc.planJob(ctx, map[string]interface{}{"bucket": "b1", "key": "in.mp4", "video_id": videoID})
+	require.NoError(t, err)
+
+	// ffmpeg never ran, only the probes did, and nothing was stored
+	for _, call := range fake.Calls() {
+		require.Contains(t, call, "-print_format", "only ffprobe may run: %v", call)
+	}
+	buckets, err := store.ListBuckets(ctx)
+	require.NoError(t, err)
+	require.Len(t, buckets, 1)
+	entries, err := os.ReadDir(filepath.Join(store.Dir(), "b1"))
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+
+	var saved []string
+	for _, w := range plan.Writes {
+		if w.Query == "SaveProcessedVideoMetadata" {
+			saved = append(saved, w.Params.(db.SaveProcessedVideoMetadataParams).VariantName)
+		}
+	}
+	require.Len(t, saved, len(testLadder.regular))
+	for _, v := range testLadder.regular {
+		require.NotEmpty(t, plan.VariantCommands[v.Name], v.Name)
+		require.Equal(t, "ffmpeg", plan.VariantCommands[v.Name][0][0])
+	}
+
+	var playlists int
+	for _, u := range plan.Uploads {
+		require.Equal(t, "b1", u.Bucket)
+		if strings.HasSuffix(u.Key, "/index.m3u8") {
+			playlists++
+		}
+	}
+	require.Equal(t, len(testLadder.regular), playlists)
+}
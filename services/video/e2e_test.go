@@ -0,0 +1,317 @@
+//go:build e2e
+
+package video_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime/multipart"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+	"video-processing/database/db"
+	"video-processing/initiator"
+	"video-processing/models"
+	"video-processing/services/video"
+	"video-processing/utils"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/minio/minio-go/v7"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+)
+
+// e2eTimeout bounds a whole upload → queue → processing run
+const e2eTimeout = 5 * time.Minute
+
+// e2eEnv holds the services of the docker compose stack an end-to-end test runs against
+type e2eEnv struct {
+	queries *db.Queries
+	redis   *redis.Client
+	minio   *minio.Client
+	logger  *slog.Logger
+}
+
+// newE2EEnv connects to Postgres, Redis and MinIO as configured in config/config.yaml and
+// creates a throwaway database. The test is skipped when ffmpeg or a service is missing.
+func newE2EEnv(t *testing.T) e2eEnv {
+	t.Helper()
+	for _, bin := range []string{"ffmpeg", "ffprobe"} {
+		if _, err := exec.LookPath(bin); err != nil {
+			t.Skipf("%s not installed", bin)
+		}
+	}
+	config, err := initiator.LoadConfig("../../config")
+	require.NoError(t, err)
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	ctx := context.Background()
+
+	dsn := func(name string) string {
+		return fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=disable",
+			config.Database.User, config.Database.Password,
+			config.Database.Host, config.Database.Port, name)
+	}
+	conn, err := pgx.Connect(ctx, dsn("postgres"))
+	if err != nil {
+		t.Skipf("postgres unavailable: %v", err)
+	}
+	dbName := "e2e_" + strings.ToLower(utils.RandomString(10))
+	_, err = conn.Exec(ctx, fmt.Sprintf("CREATE DATABASE \"%s\"", dbName))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		conn.Exec(context.Background(), fmt.Sprintf("DROP DATABASE IF EXISTS \"%s\" WITH (FORCE)", dbName))
+		conn.Close(context.Background())
+	})
+	require.NoError(t, initiator.RunMigrations("file://../../database/schema", dbName, dsn(dbName)))
+	pool, err := initiator.NewPool(ctx, dsn(dbName))
+	require.NoError(t, err)
+	t.Cleanup(pool.Close)
+
+	rdb := initiator.NewRedisClient(logger, config)
+	if err := rdb.Ping(ctx).Err(); err != nil {
+		t.Skipf("redis unavailable: %v", err)
+	}
+	t.Cleanup(func() { rdb.Close() })
+
+	mc := initiator.InitMinio(logger, config)
+	if mc == nil {
+		t.Skip("minio client could not be created")
+	}
+	if _, err := mc.ListBuckets(ctx); err != nil {
+		t.Skipf("minio unavailable: %v", err)
+	}
+
+	return e2eEnv{queries: db.New(pool), redis: rdb, minio: mc, logger: logger}
+}
+
+// uploadFile wraps a file on disk into the multipart header the upload handler receives
+func uploadFile(t *testing.T, filePath, contentType string) *multipart.FileHeader {
+	t.Helper()
+	data, err := os.ReadFile(filePath)
+	require.NoError(t, err)
+
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	part, err := w.CreatePart(map[string][]string{
+		"Content-Disposition": {fmt.Sprintf(`form-data; name="videos"; filename="%s"`, filepath.Base(filePath))},
+		"Content-Type":        {contentType},
+	})
+	require.NoError(t, err)
+	_, err = part.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	form, err := multipart.NewReader(&body, w.Boundary()).ReadForm(int64(len(data)) + 1<<20)
+	require.NoError(t, err)
+	t.Cleanup(func() { form.RemoveAll() })
+	require.Len(t, form.File["videos"], 1)
+	return form.File["videos"][0]
+}
+
+// waitForJobs blocks until the consumer group has read and acknowledged n jobs
+func waitForJobs(t *testing.T, ctx context.Context, rdb *redis.Client, stream, group string, n int64) {
+	t.Helper()
+	for {
+		groups, err := rdb.XInfoGroups(ctx, stream).Result()
+		if err == nil {
+			for _, g := range groups {
+				if g.Name == group && g.EntriesRead >= n && g.Pending == 0 {
+					return
+				}
+			}
+		}
+		select {
+		case <-ctx.Done():
+			t.Fatalf("timed out waiting for %d processed jobs", n)
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+// readObject returns the content of a stored object
+func readObject(t *testing.T, ctx context.Context, mc *minio.Client, bucket, key string) []byte {
+	t.Helper()
+	obj, err := mc.GetObject(ctx, bucket, key, minio.GetObjectOptions{})
+	require.NoError(t, err)
+	defer obj.Close()
+	data, err := io.ReadAll(obj)
+	require.NoError(t, err, "reading %s", key)
+	return data
+}
+
+// playlistURIs parses a media or master playlist and returns the URIs it references.
+// It fails the test unless the playlist is well-formed enough for a player to load it.
+func playlistURIs(t *testing.T, playlist []byte) []string {
+	t.Helper()
+	scanner := bufio.NewScanner(bytes.NewReader(playlist))
+	require.True(t, scanner.Scan(), "empty playlist")
+	require.Equal(t, "#EXTM3U", strings.TrimSpace(scanner.Text()))
+
+	var uris []string
+	var expectURI bool
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "":
+		case strings.HasPrefix(line, "#EXTINF:"):
+			duration, _, _ := strings.Cut(strings.TrimPrefix(line, "#EXTINF:"), ",")
+			_, err := strconv.ParseFloat(duration, 64)
+			require.NoError(t, err, "bad segment duration in %q", line)
+			expectURI = true
+		case strings.HasPrefix(line, "#EXT-X-STREAM-INF:"):
+			require.Contains(t, line, "BANDWIDTH=")
+			expectURI = true
+		case strings.HasPrefix(line, "#"):
+		default:
+			require.True(t, expectURI, "URI %q without a preceding tag", line)
+			uris = append(uris, line)
+			expectURI = false
+		}
+	}
+	require.NoError(t, scanner.Err())
+	require.False(t, expectURI, "playlist ends before the last URI")
+	return uris
+}
+
+func TestE2EUploadProcessVideo(t *testing.T) {
+	env := newE2EEnv(t)
+	ctx, cancel := context.WithTimeout(context.Background(), e2eTimeout)
+	defer cancel()
+
+	// a short synthetic source: color bars with a tone, no sample media needed
+	workDir := t.TempDir()
+	sourcePath := filepath.Join(workDir, "testsrc.mp4")
+	input := video.BenchInput{Duration: 4 * time.Second, Width: 1920, Height: 1080}
+	require.NoError(t, video.SynthesizeSource(ctx, video.NewExecTranscoder(models.FFmpegConfig{}), input, sourcePath))
+
+	user, err := env.queries.CreateUser(ctx, db.CreateUserParams{
+		FirstName: "Test",
+		LastName:  "User",
+		Phone:     "0911000000",
+		Username:  "e2e" + utils.RandomString(6),
+		Password:  "not-a-hash",
+		Email:     fmt.Sprintf("e2e-%s@example.com", uuid.NewString()),
+	})
+	require.NoError(t, err)
+	bucket := user.ID.String()
+	t.Cleanup(func() { removeBucket(env.minio, bucket) })
+
+	// a stream and group of its own so runs do not pick up each other's jobs
+	stream := "e2e-" + uuid.NewString()
+	group := "e2e-workers"
+	t.Cleanup(func() { env.redis.Del(context.Background(), stream) })
+
+	processing := models.ProcessingConfig{ScratchDir: t.TempDir()}
+	consumer := video.NewRedisConsumer(stream, group, "e2e-worker", env.logger, env.redis, env.minio, env.queries, processing, video.NewExecTranscoder(models.FFmpegConfig{}), models.NotificationConfig{})
+	consumerCtx, stopConsumer := context.WithCancel(ctx)
+	consumed := make(chan error, 1)
+	go func() { consumed <- consumer.Consume(consumerCtx) }()
+	defer func() {
+		stopConsumer()
+		<-consumed
+	}()
+	// Consume creates the group from the end of the stream, so it must exist before the upload
+	require.Eventually(t, func() bool {
+		groups, err := env.redis.XInfoGroups(ctx, stream).Result()
+		return err == nil && len(groups) == 1
+	}, 10*time.Second, 100*time.Millisecond)
+
+	// upload → queue
+	streamer := video.NewRedisStreamer(stream, env.logger, env.redis)
+	vp := video.NewVideoProcessor(env.logger, env.minio, env.queries, streamer, video.NewExecTranscoder(models.FFmpegConfig{}), video.VideoProcessorOptions{URLExpiry: time.Hour})
+	err = vp.Upload(ctx, user.ID, models.UploadVideoRequest{
+		Title:       "e2e",
+		Description: "synthetic test video",
+		Videos:      []*multipart.FileHeader{uploadFile(t, sourcePath, "video/mp4")},
+	})
+	require.NoError(t, err)
+
+	videos, err := env.queries.ListUserVideos(ctx, db.ListUserVideosParams{UserID: user.ID, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, videos, 1)
+	videoID := videos[0].ID
+
+	// queue → processing
+	waitForJobs(t, ctx, env.redis, stream, group, 1)
+
+	// the source is as large as the largest variant, so none is skipped
+	stored, err := env.queries.GetVideo(ctx, videoID)
+	require.NoError(t, err)
+	require.Equal(t, int32(input.Width), stored.SourceWidth.Int32)
+	require.Equal(t, int32(input.Height), stored.SourceHeight.Int32)
+
+	rows, err := env.queries.ListVideoVariants(ctx, videoID)
+	require.NoError(t, err)
+	byName := make(map[string]db.VideoVariant, len(rows))
+	for _, row := range rows {
+		byName[row.VariantName] = row
+	}
+	require.Len(t, byName, len(video.Variants), "one metadata row per variant")
+
+	for _, v := range video.Variants {
+		t.Run(v.Name, func(t *testing.T) {
+			row, ok := byName[v.Name]
+			require.True(t, ok, "no metadata row")
+			require.Equal(t, bucket, row.Bucket)
+			require.Equal(t, int32(v.Width), row.Width.Int32)
+			require.Equal(t, int32(v.Height), row.Height.Int32)
+			kbps, _ := strconv.Atoi(strings.TrimSuffix(v.Bitrate, "k"))
+			require.Equal(t, int32(kbps), row.BitrateKbps.Int32)
+
+			_, err := env.minio.StatObject(ctx, bucket, row.Key, minio.StatObjectOptions{})
+			require.NoError(t, err, "variant MP4 %s", row.Key)
+			require.True(t, row.ThumbnailKey.Valid)
+			_, err = env.minio.StatObject(ctx, bucket, row.ThumbnailKey.String, minio.StatObjectOptions{})
+			require.NoError(t, err, "thumbnail %s", row.ThumbnailKey.String)
+
+			require.True(t, row.HlsPlaylistKey.Valid)
+			playlist := readObject(t, ctx, env.minio, bucket, row.HlsPlaylistKey.String)
+			require.Contains(t, string(playlist), "#EXT-X-ENDLIST")
+			segments := playlistURIs(t, playlist)
+			require.NotEmpty(t, segments)
+			dir := path.Dir(row.HlsPlaylistKey.String)
+			for _, segment := range segments {
+				info, err := env.minio.StatObject(ctx, bucket, path.Join(dir, segment), minio.StatObjectOptions{})
+				require.NoError(t, err, "segment %s", segment)
+				require.Positive(t, info.Size)
+			}
+		})
+	}
+
+	// the master playlist lists every variant
+	assets, err := env.queries.ListVideoAssets(ctx, videoID)
+	require.NoError(t, err)
+	var masterKey string
+	for _, a := range assets {
+		if a.Kind == video.AssetKindMasterPlaylist {
+			masterKey = a.Key
+		}
+	}
+	require.NotEmpty(t, masterKey, "no master playlist recorded")
+	row, err := env.queries.GetVideo(ctx, videoID)
+	require.NoError(t, err)
+	require.Equal(t, masterKey, row.MasterPlaylistKey.String, "the video row names another master playlist")
+	master := playlistURIs(t, readObject(t, ctx, env.minio, bucket, masterKey))
+	for _, v := range video.Variants {
+		require.Contains(t, master, v.Name+"/index.m3u8")
+	}
+}
+
+// removeBucket deletes a bucket created by a test together with its objects
+func removeBucket(mc *minio.Client, bucket string) {
+	ctx := context.Background()
+	objects := mc.ListObjects(ctx, bucket, minio.ListObjectsOptions{Recursive: true})
+	for range mc.RemoveObjects(ctx, bucket, objects, minio.RemoveObjectsOptions{}) {
+	}
+	mc.RemoveBucket(ctx, bucket)
+}
@@ -0,0 +1,92 @@
+package video
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"video-processing/models"
+)
+
+// buildEditFilters translates an edit recipe into ffmpeg video and audio filter chains.
+// Crop is applied first so its rectangle is always in source coordinates.
+func buildEditFilters(e models.EditRequest) (videoFilters, audioFilters []string) {
+	if e.Crop != nil {
+		videoFilters = append(videoFilters, fmt.Sprintf("crop=%d:%d:%d:%d", e.Crop.Width, e.Crop.Height, e.Crop.X, e.Crop.Y))
+	}
+	switch e.Rotate {
+	case 90:
+		videoFilters = append(videoFilters, "transpose=clock")
+	case 180:
+		videoFilters = append(videoFilters, "hflip", "vflip")
+	case 270:
+		videoFilters = append(videoFilters, "transpose=cclock")
+	}
+	if e.Speed != 0 && e.Speed != 1 {
+		videoFilters = append(videoFilters, fmt.Sprintf("setpts=PTS/%s", formatFactor(e.Speed)))
+		audioFilters = append(audioFilters, atempoChain(e.Speed)...)
+	}
+	if e.Volume != nil {
+		audioFilters = append(audioFilters, fmt.Sprintf("volume=%s", formatFactor(*e.Volume)))
+	}
+	return videoFilters, audioFilters
+}
+
+// atempoChain splits a tempo factor into atempo filters that each stay within
+// the 0.5-2.0 range every ffmpeg version supports.
+func atempoChain(factor float64) []string {
+	var chain []string
+	for factor > 2 {
+		chain = append(chain, "atempo=2")
+		factor /= 2
+	}
+	for factor < 0.5 {
+		chain = append(chain, "atempo=0.5")
+		factor /= 0.5
+	}
+	return append(chain, fmt.Sprintf("atempo=%s", formatFactor(factor)))
+}
+
+func formatFactor(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// renderEdit applies the edit recipe to inputPath and writes an H.264/AAC MP4 to outPath.
+func renderEdit(ctx context.Context, t Transcoder, inputPath, outPath string, e models.EditRequest) error {
+	videoFilters, audioFilters := buildEditFilters(e)
+	args := []string{
+		"-y",
+		"-nostdin",
+		"-i", inputPath,
+	}
+	if len(videoFilters) > 0 {
+		args = append(args, "-vf", strings.Join(videoFilters, ","))
+	}
+	if len(audioFilters) > 0 {
+		args = append(args, "-af", strings.Join(audioFilters, ","))
+	}
+	// the result is a new source for the ladder, so keep it close to lossless
+	args = append(args,
+		"-c:v", "libx264",
+		"-crf", "18",
+		"-preset", "fast",
+		"-c:a", "aac",
+		"-movflags", "+faststart",
+		outPath,
+	)
+	if err := t.Run(ctx, args...); err != nil {
+		return fmt.Errorf("ffmpeg edit error: %w", err)
+	}
+	return nil
+}
+
+// RenderEdit renders a derived video from its parent's source object, stores the result
+// as the derived video's source and then runs it through the regular processing pipeline.
+func (rc *redisConsumer) RenderEdit(ctx context.Context, values map[string]interface{}) error {
+	return rc.renderDerived(ctx, values, func(ctx context.Context, job derivedJob) error {
+		if job.Recipe.Edit == nil {
+			return fmt.Errorf("edit job carries no edit recipe")
+		}
+		return renderEdit(ctx, rc.transcoder, job.SourcePath, job.OutPath, *job.Recipe.Edit)
+	})
+}
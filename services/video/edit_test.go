@@ -0,0 +1,48 @@
+package video
+
+import (
+	"testing"
+	"video-processing/models"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildEditFilters(t *testing.T) {
+	mute := 0.0
+	testCases := []struct {
+		name      string
+		edit      models.EditRequest
+		wantVideo []string
+		wantAudio []string
+	}{
+		{
+			name:      "crop then rotate",
+			edit:      models.EditRequest{Crop: &models.CropRect{X: 10, Y: 20, Width: 640, Height: 360}, Rotate: 90},
+			wantVideo: []string{"crop=640:360:10:20", "transpose=clock"},
+		},
+		{
+			name:      "fast forward beyond atempo range",
+			edit:      models.EditRequest{Speed: 4},
+			wantVideo: []string{"setpts=PTS/4"},
+			wantAudio: []string{"atempo=2", "atempo=2"},
+		},
+		{
+			name:      "slow motion and mute",
+			edit:      models.EditRequest{Speed: 0.25, Volume: &mute},
+			wantVideo: []string{"setpts=PTS/0.25"},
+			wantAudio: []string{"atempo=0.5", "atempo=0.5", "volume=0"},
+		},
+		{
+			name:      "upside down",
+			edit:      models.EditRequest{Rotate: 180},
+			wantVideo: []string{"hflip", "vflip"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			videoFilters, audioFilters := buildEditFilters(tc.edit)
+			require.Equal(t, tc.wantVideo, videoFilters)
+			require.Equal(t, tc.wantAudio, audioFilters)
+		})
+	}
+}
@@ -0,0 +1,252 @@
+package video
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"video-processing/models"
+)
+
+// H.264 encoders for ProcessingConfig.Encoder
+const (
+	EncoderX264  = "libx264"
+	EncoderNVENC = "h264_nvenc"
+	EncoderVAAPI = "h264_vaapi"
+	EncoderQSV   = "h264_qsv"
+)
+
+// vaapiDevice is the render node VAAPI encodes run on, the first GPU of the machine
+const vaapiDevice = "/dev/dri/renderD128"
+
+// videoEncoder is a backend that encodes the H.264 variants. It turns a variant into ffmpeg
+// flags, so backends are added without touching the pipeline.
+type videoEncoder interface {
+	// hardware reports whether the backend runs on a device, whose failed encodes are retried on libx264
+	hardware() bool
+	// inputArgs go before the input, e.g. to open the device
+	inputArgs() []string
+	// filter completes the CPU filter chain vf, e.g. to convert or upload the frames for the device
+	filter(vf string) string
+	// codecArgs select the encoder, its rate control and its speed preset for v
+	codecArgs(v Variant) []string
+	// captionArgs carry embedded CEA-608/708 captions into the output
+	captionArgs() []string
+	// keyframeArgs start a closed GOP at every HLS segment boundary, see forceKeyFramesArgs
+	keyframeArgs(segmentSeconds int) []string
+}
+
+// forceKeyFramesArgs put a keyframe every segmentSeconds of output time, whatever the frame
+// rate. Every variant then starts its segments at the same instants and players switch
+// between them at any boundary. Scene cut keyframes are left out where the encoder allows,
+// since they add keyframes mid-segment.
+func forceKeyFramesArgs(segmentSeconds int) []string {
+	return []string{"-force_key_frames", fmt.Sprintf("expr:gte(t,n_forced*%d)", segmentSeconds)}
+}
+
+// videoEncoders are the H.264 backends by ffmpeg encoder name
+var videoEncoders = map[string]videoEncoder{
+	EncoderX264:  x264Encoder{},
+	EncoderNVENC: nvencEncoder{},
+	EncoderVAAPI: vaapiEncoder{},
+	EncoderQSV:   qsvEncoder{},
+}
+
+// videoEncoderFor returns the backend of an encoder name, libx264 when it is empty or unknown
+func videoEncoderFor(name string) videoEncoder {
+	if enc, ok := videoEncoders[name]; ok {
+		return enc
+	}
+	return x264Encoder{}
+}
+
+// x264Encoder encodes on the CPU
+type x264Encoder struct{}
+
+func (x264Encoder) hardware() bool          { return false }
+func (x264Encoder) inputArgs() []string     { return nil }
+func (x264Encoder) filter(vf string) string { return vf }
+func (x264Encoder) captionArgs() []string   { return []string{"-a53cc", "1"} }
+
+func (x264Encoder) keyframeArgs(segmentSeconds int) []string {
+	return append(forceKeyFramesArgs(segmentSeconds), "-sc_threshold", "0")
+}
+
+func (x264Encoder) codecArgs(v Variant) []string {
+	args := append([]string{"-c:v", EncoderX264}, x26xRateArgs(v)...)
+	args = append(args, tuneArgs(v)...)
+	return append(args, h264ProfileArgs(v.Profile, v.Level)...)
+}
+
+// x265Encoder encodes the HEVC variants on the CPU, the HDR one needs its x265 params. It is
+// not selectable, since the backends only replace the H.264 encoder.
+type x265Encoder struct{}
+
+func (x265Encoder) hardware() bool          { return false }
+func (x265Encoder) inputArgs() []string     { return nil }
+func (x265Encoder) filter(vf string) string { return vf }
+func (x265Encoder) captionArgs() []string   { return nil }
+
+// keyframeArgs keep x265's scene cuts, turning them off takes x265 params that the HDR
+// variant already sets
+func (x265Encoder) keyframeArgs(segmentSeconds int) []string {
+	return append(forceKeyFramesArgs(segmentSeconds), "-forced-idr", "1")
+}
+
+func (x265Encoder) codecArgs(v Variant) []string {
+	args := append([]string{"-c:v", "libx265", "-tag:v", "hvc1"}, x26xRateArgs(v)...)
+	return append(args, tuneArgs(v)...)
+}
+
+// x26xRateArgs returns the rate control and preset flags of libx264 and libx265
+func x26xRateArgs(v Variant) []string {
+	if v.CRF > 0 {
+		// constant quality, capped at the variant bitrate so the playlist bandwidth holds
+		args := append([]string{"-crf", strconv.Itoa(v.CRF)}, crfCapArgs(v)...)
+		return append(args, "-preset", v.encoderPreset())
+	}
+	return []string{"-b:v", v.Bitrate, "-preset", v.encoderPreset()}
+}
+
+// tuneArgs tune libx264 and libx265 to the content of v
+func tuneArgs(v Variant) []string {
+	if v.Tune == "" {
+		return nil
+	}
+	return []string{"-tune", v.Tune}
+}
+
+// h264ProfileArgs restrict the stream to profile and level, each left to the encoder when empty
+func h264ProfileArgs(profile, level string) []string {
+	var args []string
+	if profile != "" {
+		args = append(args, "-profile:v", profile)
+	}
+	if level != "" {
+		args = append(args, "-level", level)
+	}
+	return args
+}
+
+// nvencEncoder encodes on an NVIDIA GPU. NVENC encodes 8-bit H.264 only, so 10-bit sources
+// are converted on the way.
+type nvencEncoder struct{}
+
+func (nvencEncoder) hardware() bool          { return true }
+func (nvencEncoder) inputArgs() []string     { return nil }
+func (nvencEncoder) filter(vf string) string { return vf + ",format=yuv420p" }
+func (nvencEncoder) captionArgs() []string   { return []string{"-a53cc", "1"} }
+
+// nvencPresets maps the x264 speed presets of variants to NVENC's p1 (fastest) to p7 (slowest)
+var nvencPresets = map[string]string{
+	"ultrafast": "p1",
+	"superfast": "p1",
+	"veryfast":  "p2",
+	"faster":    "p3",
+	"fast":      "p4",
+	"medium":    "p5",
+	"slow":      "p6",
+	"slower":    "p7",
+	"veryslow":  "p7",
+}
+
+func (nvencEncoder) keyframeArgs(segmentSeconds int) []string {
+	return append(forceKeyFramesArgs(segmentSeconds), "-forced-idr", "1", "-no-scenecut", "1")
+}
+
+func (nvencEncoder) codecArgs(v Variant) []string {
+	args := []string{"-c:v", EncoderNVENC}
+	if v.CRF > 0 {
+		// NVENC's constant quality mode, capped like the CRF encodes of libx264
+		args = append(args, "-rc", "vbr", "-cq", strconv.Itoa(v.CRF), "-b:v", "0")
+		args = append(args, crfCapArgs(v)...)
+	} else {
+		args = append(args, "-b:v", v.Bitrate)
+	}
+	preset, ok := nvencPresets[v.encoderPreset()]
+	if !ok {
+		preset = nvencPresets["fast"]
+	}
+	args = append(args, "-preset", preset)
+	return append(args, h264ProfileArgs(v.Profile, v.Level)...)
+}
+
+// vaapiEncoder encodes on an Intel or AMD GPU through VAAPI. The frames are filtered on the
+// CPU and uploaded to the device. VAAPI writes the A53 captions it finds by default.
+type vaapiEncoder struct{}
+
+func (vaapiEncoder) hardware() bool          { return true }
+func (vaapiEncoder) inputArgs() []string     { return []string{"-vaapi_device", vaapiDevice} }
+func (vaapiEncoder) filter(vf string) string { return vf + ",format=nv12,hwupload" }
+func (vaapiEncoder) captionArgs() []string   { return nil }
+
+// keyframeArgs rely on VAAPI turning forced keyframes into IDR frames
+func (vaapiEncoder) keyframeArgs(segmentSeconds int) []string {
+	return forceKeyFramesArgs(segmentSeconds)
+}
+
+func (vaapiEncoder) codecArgs(v Variant) []string {
+	args := []string{"-c:v", EncoderVAAPI}
+	if v.CRF > 0 {
+		// quality defined variable bitrate, VAAPI's closest to a capped CRF
+		args = append(args, "-rc_mode", "QVBR", "-global_quality", strconv.Itoa(v.CRF), "-b:v", v.Bitrate)
+		args = append(args, crfCapArgs(v)...)
+	} else {
+		args = append(args, "-rc_mode", "VBR", "-b:v", v.Bitrate)
+	}
+	// VAAPI only encodes the constrained baseline profile
+	profile := v.Profile
+	if profile == models.ProfileBaseline {
+		profile = "constrained_baseline"
+	}
+	return append(args, h264ProfileArgs(profile, v.Level)...)
+}
+
+// qsvEncoder encodes on Intel Quick Sync Video, which takes the frames from system memory
+// and knows the x264 speed presets by name.
+type qsvEncoder struct{}
+
+func (qsvEncoder) hardware() bool          { return true }
+func (qsvEncoder) inputArgs() []string     { return nil }
+func (qsvEncoder) filter(vf string) string { return vf + ",format=nv12" }
+func (qsvEncoder) captionArgs() []string   { return []string{"-a53cc", "1"} }
+
+func (qsvEncoder) keyframeArgs(segmentSeconds int) []string {
+	return append(forceKeyFramesArgs(segmentSeconds), "-forced_idr", "1")
+}
+
+func (qsvEncoder) codecArgs(v Variant) []string {
+	preset := v.encoderPreset()
+	switch preset {
+	case "ultrafast", "superfast":
+		preset = "veryfast"
+	}
+	args := []string{"-c:v", EncoderQSV}
+	if v.CRF > 0 {
+		// intelligent constant quality, capped like the CRF encodes of libx264
+		args = append(args, "-global_quality", strconv.Itoa(v.CRF))
+		args = append(args, crfCapArgs(v)...)
+	} else {
+		args = append(args, "-b:v", v.Bitrate)
+	}
+	args = append(args, "-preset", preset)
+	// QSV takes the level as its level_idc, 31 for 3.1
+	level := v.Level
+	if level != "" {
+		major, minor, _ := strings.Cut(level, ".")
+		level = major + cmp.Or(minor, "0")
+	}
+	return append(args, h264ProfileArgs(v.Profile, level)...)
+}
+
+// encoderWorks encodes a few blank frames with a backend. Hardware encoders are listed by
+// every ffmpeg built with them, whether or not the machine has the hardware.
+func encoderWorks(ctx context.Context, t Transcoder, enc videoEncoder) bool {
+	args := append([]string{"-hide_banner", "-nostdin"}, enc.inputArgs()...)
+	args = append(args, "-f", "lavfi", "-i", "color=black:size=256x256:duration=0.2")
+	args = append(args, "-vf", enc.filter("scale=256:256"))
+	args = append(args, enc.codecArgs(Variant{Bitrate: "1000k"})...)
+	args = append(args, "-f", "null", "-")
+	return t.Run(ctx, args...) == nil
+}
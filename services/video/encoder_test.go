@@ -0,0 +1,186 @@
+package video
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTranscodeEncoders(t *testing.T) {
+	dir := t.TempDir()
+	bitrate := Variant{Name: "720p", Width: 1280, Height: 720, Bitrate: "2500k"}
+	crf := Variant{Name: "1080p", Width: 1920, Height: 1080, Bitrate: "5000k", CRF: 23, EncoderPreset: "slow", SegmentSeconds: 4}
+	baseline := Variant{Name: "240p", Width: 426, Height: 240, Bitrate: "250k", Profile: "baseline", Level: "3", Tune: "animation"}
+	testCases := []struct {
+		name    string
+		encoder string
+		variant Variant
+		want    string
+	}{
+		{
+			name:    "libx264 by default",
+			variant: bitrate,
+			want:    "-i in.mp4 -vf scale=1280:720 -c:v libx264 -b:v 2500k -preset fast -pix_fmt yuv420p -force_key_frames expr:gte(t,n_forced*6) -sc_threshold 0",
+		},
+		{
+			name:    "libx264 constant quality",
+			encoder: EncoderX264,
+			variant: crf,
+			want:    "-vf scale=1920:1080 -c:v libx264 -crf 23 -maxrate 5000k -bufsize 10000k -preset slow -pix_fmt yuv420p -force_key_frames expr:gte(t,n_forced*4) -sc_threshold 0",
+		},
+		{
+			name:    "libx264 baseline",
+			variant: baseline,
+			want:    "-c:v libx264 -b:v 250k -preset fast -tune animation -profile:v baseline -level 3 -pix_fmt yuv420p",
+		},
+		{
+			name:    "nvenc",
+			encoder: EncoderNVENC,
+			variant: bitrate,
+			want:    "-vf scale=1280:720,format=yuv420p -c:v h264_nvenc -b:v 2500k -preset p4 -force_key_frames expr:gte(t,n_forced*6) -forced-idr 1 -no-scenecut 1",
+		},
+		{
+			name:    "nvenc constant quality",
+			encoder: EncoderNVENC,
+			variant: crf,
+			want:    "-c:v h264_nvenc -rc vbr -cq 23 -b:v 0 -maxrate 5000k -bufsize 10000k -preset p6",
+		},
+		{
+			name:    "vaapi",
+			encoder: EncoderVAAPI,
+			variant: bitrate,
+			want:    "-vaapi_device /dev/dri/renderD128 -y -nostdin -i in.mp4 -vf scale=1280:720,format=nv12,hwupload -c:v h264_vaapi -rc_mode VBR -b:v 2500k",
+		},
+		{
+			name:    "vaapi constant quality",
+			encoder: EncoderVAAPI,
+			variant: crf,
+			want:    "-c:v h264_vaapi -rc_mode QVBR -global_quality 23 -b:v 5000k -maxrate 5000k -bufsize 10000k",
+		},
+		{
+			name:    "nvenc baseline without tune",
+			encoder: EncoderNVENC,
+			variant: baseline,
+			want:    "-c:v h264_nvenc -b:v 250k -preset p4 -profile:v baseline -level 3 -force_key_frames",
+		},
+		{
+			name:    "vaapi baseline",
+			encoder: EncoderVAAPI,
+			variant: baseline,
+			want:    "-c:v h264_vaapi -rc_mode VBR -b:v 250k -profile:v constrained_baseline -level 3 -force_key_frames",
+		},
+		{
+			name:    "qsv level",
+			encoder: EncoderQSV,
+			variant: Variant{Name: "480p", Width: 854, Height: 480, Bitrate: "1000k", Profile: "main", Level: "3.1"},
+			want:    "-c:v h264_qsv -b:v 1000k -preset fast -profile:v main -level 31 -force_key_frames",
+		},
+		{
+			name:    "qsv",
+			encoder: EncoderQSV,
+			variant: crf,
+			want:    "-vf scale=1920:1080,format=nv12 -c:v h264_qsv -global_quality 23 -maxrate 5000k -bufsize 10000k -preset slow -force_key_frames expr:gte(t,n_forced*4) -forced_idr 1",
+		},
+		{
+			name:    "hevc stays on libx265",
+			encoder: EncoderNVENC,
+			variant: Variant{Name: "hdr", Width: 1920, Height: 1080, Bitrate: "6000k", HDR: true},
+			want:    "-c:v libx265 -tag:v hvc1 -b:v 6000k -preset fast",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fake := NewFakeTranscoder()
+			task := ProcessingTask{Variant: tc.variant, SourcePath: "in.mp4", Encoder: tc.encoder}
+			require.NoError(t, transcodeToMP4(context.Background(), fake, task, filepath.Join(dir, tc.variant.Name+".mp4")))
+			args := strings.Join(fake.Calls()[0], " ")
+			require.Contains(t, args, tc.want)
+			require.Equal(t, 1, strings.Count(args, "-c:v "))
+		})
+	}
+}
+
+func TestGenerateHLS(t *testing.T) {
+	// the segments are cut where the transcode put its keyframes
+	fake := NewFakeTranscoder()
+	v := Variant{Name: "720p", Width: 1280, Height: 720, Bitrate: "2500k", SegmentSeconds: 4}
+	require.NoError(t, generateHLS(context.Background(), fake, "720p.mp4", t.TempDir(), v, 0, hlsOptions{StreamCopy: true}))
+	require.Contains(t, strings.Join(fake.Calls()[0], " "), "-i 720p.mp4 -map 0:v:0 -map 0:a:0? -c copy -hls_time 4 ")
+
+	// re-encoded segments keep the keyframes
+	require.NoError(t, generateHLS(context.Background(), fake, "720p.mp4", t.TempDir(), v, 0, hlsOptions{}))
+	require.Contains(t, strings.Join(fake.Calls()[1], " "), "-force_key_frames expr:gte(t,n_forced*4) -sc_threshold 0 -hls_time 4 ")
+
+	// fMP4 segments share an init segment
+	dir := t.TempDir()
+	require.NoError(t, generateHLS(context.Background(), fake, "720p.mp4", dir, v, 0, hlsOptions{StreamCopy: true, FMP4: true}))
+	require.Contains(t, strings.Join(fake.Calls()[2], " "), "-hls_segment_type fmp4 -hls_fmp4_init_filename init.mp4 -hls_segment_filename "+filepath.Join(dir, "segment_%03d.m4s"))
+	require.FileExists(t, filepath.Join(dir, "segment_000.m4s"))
+
+	_, err := fmp4Segments("webm")
+	require.Error(t, err)
+}
+
+func TestProcessVariantFallsBackToX264(t *testing.T) {
+	fake := NewFakeTranscoder()
+	fake.FailOn = EncoderVAAPI
+	fake.ProbeOutput = []byte(`{"packets":[{"pts_time":"0.000000","duration_time":"0.040000","pos":"0","flags":"K__"}]}`)
+	rc := &redisConsumer{logger: slog.New(slog.NewTextHandler(io.Discard, nil)), transcoder: fake}
+
+	task := ProcessingTask{
+		Variant:    testLadder.regular[1],
+		WorkDir:    t.TempDir(),
+		SourcePath: "source.mp4",
+		DestPrefix: "processed/job",
+		VideoID:    uuid.NewString(),
+		Encoder:    EncoderVAAPI,
+	}
+	results := make(chan ProcessingResult, 1)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	rc.processVariant(context.Background(), task, results, make(chan UploadTask, 100), &wg)
+	result := <-results
+	require.True(t, result.Success, "%v", result.Error)
+	calls := fake.Calls()
+	require.Contains(t, calls[0], EncoderVAAPI)
+	require.Contains(t, calls[1], EncoderX264)
+	require.NotContains(t, calls[1], "-vaapi_device")
+
+	// libx264 failures are not retried
+	fake.FailOn = EncoderX264
+	task.Encoder = ""
+	wg.Add(1)
+	rc.processVariant(context.Background(), task, results, make(chan UploadTask, 100), &wg)
+	result = <-results
+	require.False(t, result.Success)
+	require.Len(t, fake.Calls(), len(calls)+1)
+}
+
+func TestDetectHardwareEncoders(t *testing.T) {
+	fake := NewFakeTranscoder()
+	fake.StreamOutput = []byte(" ------\n V....D libx264 H.264\n V....D h264_nvenc NVIDIA NVENC H.264 encoder\n V....D h264_vaapi H.264/AVC (VAAPI)\n A....D aac AAC\n")
+	caps, err := DetectCapabilities(context.Background(), fake)
+	require.NoError(t, err)
+	require.True(t, caps.Encoders[EncoderNVENC])
+	require.True(t, caps.Encoders[EncoderVAAPI])
+	require.False(t, caps.Encoders[EncoderQSV])
+	// only the listed hardware encoders are tried
+	tried := slices.DeleteFunc(fake.Calls(), func(args []string) bool { return !slices.Contains(args, "null") })
+	require.Len(t, tried, 2)
+
+	// listed by ffmpeg, but no device to encode with
+	fake.FailOn = EncoderNVENC
+	caps, err = DetectCapabilities(context.Background(), fake)
+	require.NoError(t, err)
+	require.False(t, caps.Encoders[EncoderNVENC])
+	require.True(t, caps.Encoders[EncoderVAAPI])
+	require.True(t, caps.Encoders[EncoderX264])
+}
@@ -0,0 +1,60 @@
+package video
+
+import (
+	"context"
+	"testing"
+	"video-processing/mocks"
+	"video-processing/models"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/encrypt"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestServerSideEncryption(t *testing.T) {
+	sse, err := ServerSideEncryption(models.EncryptionConfig{})
+	require.NoError(t, err)
+	require.Nil(t, sse)
+
+	sse, err = ServerSideEncryption(models.EncryptionConfig{Type: EncryptionSSES3})
+	require.NoError(t, err)
+	require.Equal(t, encrypt.S3, sse.Type())
+
+	_, err = ServerSideEncryption(models.EncryptionConfig{Type: EncryptionSSEKMS})
+	require.ErrorContains(t, err, "kms_key_id")
+
+	sse, err = ServerSideEncryption(models.EncryptionConfig{Type: EncryptionSSEKMS, KMSKeyID: "videos", KMSContext: map[string]string{"app": "video"}})
+	require.NoError(t, err)
+	require.Equal(t, encrypt.KMS, sse.Type())
+
+	_, err = ServerSideEncryption(models.EncryptionConfig{Type: "sse-x"})
+	require.Error(t, err)
+}
+
+func TestEncryptObjects(t *testing.T) {
+	inner := mocks.NewMockObjectStore(gomock.NewController(t))
+	require.Same(t, inner, EncryptObjects(inner, nil))
+
+	sse := encrypt.NewSSE()
+	store := EncryptObjects(inner, sse)
+	ctx := context.Background()
+
+	inner.EXPECT().FPutObject(ctx, "b", "k", "/tmp/f", minio.PutObjectOptions{ContentType: "video/mp4", ServerSideEncryption: sse})
+	_, err := store.FPutObject(ctx, "b", "k", "/tmp/f", minio.PutObjectOptions{ContentType: "video/mp4"})
+	require.NoError(t, err)
+
+	inner.EXPECT().FGetObject(ctx, "b", "k", "/tmp/f", minio.GetObjectOptions{ServerSideEncryption: sse})
+	require.NoError(t, store.FGetObject(ctx, "b", "k", "/tmp/f", minio.GetObjectOptions{}))
+
+	inner.EXPECT().StatObject(ctx, "b", "k", minio.StatObjectOptions{ServerSideEncryption: sse})
+	_, err = store.StatObject(ctx, "b", "k", minio.StatObjectOptions{})
+	require.NoError(t, err)
+
+	// options of the caller are kept
+	kms, err := encrypt.NewSSEKMS("other", nil)
+	require.NoError(t, err)
+	inner.EXPECT().PutObject(ctx, "b", "k", nil, int64(1), minio.PutObjectOptions{ServerSideEncryption: kms})
+	_, err = store.PutObject(ctx, "b", "k", nil, 1, minio.PutObjectOptions{ServerSideEncryption: kms})
+	require.NoError(t, err)
+}
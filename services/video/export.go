@@ -0,0 +1,350 @@
+package video
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+	"video-processing/database/db"
+	"video-processing/models"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/minio/minio-go/v7"
+)
+
+const (
+	// exportPollInterval is how often idle instances look for exports to write
+	exportPollInterval = 10 * time.Second
+	// exportLease is how long an export stays with an instance before another one retries it
+	exportLease = 30 * time.Minute
+	// exportRetention is how long archives are kept; presigned URLs cannot outlive 7 days,
+	// so neither can the links to the originals inside the archive
+	exportRetention = 7 * 24 * time.Hour
+	// exportPage bounds the rows read from the database at once
+	exportPage = 500
+	// exportExpiryBatch is how many archives one query expires
+	exportExpiryBatch = 100
+)
+
+// ExportData starts an export of all the data of the user, written by RunExports of any
+// instance. While an export is in progress, that export is returned instead.
+func (vp *videoProcessor) ExportData(ctx context.Context, userID uuid.UUID) (models.DataExport, error) {
+	params := fmt.Sprintf("userID: %v", userID)
+	row, err := vp.db.CreateDataExport(ctx, userID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		row, err = vp.db.GetActiveDataExport(ctx, userID)
+	}
+	if err != nil {
+		return models.DataExport{}, models.IndentifyDbError(err).AddParams(params)
+	}
+	return vp.dataExportFromRow(ctx, row)
+}
+
+// GetDataExport returns the progress of an export of the user, with a download URL once done
+func (vp *videoProcessor) GetDataExport(ctx context.Context, userID, exportID uuid.UUID) (models.DataExport, error) {
+	params := fmt.Sprintf("userID: %v, exportID: %v", userID, exportID)
+	row, err := vp.db.GetDataExport(ctx, db.GetDataExportParams{ID: exportID, UserID: userID})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.DataExport{}, models.Error{
+			Code:    http.StatusNotFound,
+			Message: "resource not found",
+			Params:  params,
+			Err:     models.ErrResourceNotFound,
+		}
+	}
+	if err != nil {
+		return models.DataExport{}, models.IndentifyDbError(err).AddParams(params)
+	}
+	return vp.dataExportFromRow(ctx, row)
+}
+
+func (vp *videoProcessor) dataExportFromRow(ctx context.Context, row db.DataExport) (models.DataExport, error) {
+	export := models.DataExport{
+		ID:        row.ID,
+		Status:    row.Status,
+		SizeBytes: row.SizeBytes,
+		Error:     row.Error,
+		CreatedAt: row.CreatedAt,
+	}
+	if row.FinishedAt.Valid {
+		export.FinishedAt = &row.FinishedAt.Time
+	}
+	if row.ExpiresAt.Valid {
+		export.ExpiresAt = &row.ExpiresAt.Time
+	}
+	if row.Status == models.ExportCompleted {
+		url, err := vp.getVideoURL(ctx, row.Bucket, row.Key, vp.urlExpiry)
+		if err != nil {
+			return models.DataExport{}, err
+		}
+		export.DownloadURL = url
+	}
+	return export, nil
+}
+
+// RunExports writes the archives of pending exports and deletes expired ones until ctx is
+// done. Every instance may run it: an export is leased to one instance at a time, and the
+// exports of stopped instances are written again once their lease expires.
+func (vp *videoProcessor) RunExports(ctx context.Context) error {
+	ticker := time.NewTicker(exportPollInterval)
+	defer ticker.Stop()
+	for {
+		vp.expireExports(ctx)
+		export, err := vp.db.ClaimDataExport(ctx, time.Now().Add(exportLease))
+		switch {
+		case err == nil:
+			vp.export(ctx, export)
+		case errors.Is(err, pgx.ErrNoRows):
+		case ctx.Err() != nil:
+			return ctx.Err()
+		default:
+			vp.logger.Error("failed to claim data export", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// export writes the archive of a claimed export to the bucket of the user
+func (vp *videoProcessor) export(ctx context.Context, export db.DataExport) {
+	bucket, key := vp.layout.Place(export.UserID, fmt.Sprintf("exports/%s.zip", export.ID))
+	expiresAt := time.Now().Add(exportRetention)
+	size, err := vp.writeExport(ctx, export, bucket, key, expiresAt)
+	if err == nil {
+		_, err = vp.db.CompleteDataExport(ctx, db.CompleteDataExportParams{
+			Bucket:    bucket,
+			Key:       key,
+			SizeBytes: size,
+			ExpiresAt: pgtype.Timestamptz{Time: expiresAt, Valid: true},
+			ID:        export.ID,
+		})
+	}
+	if ctx.Err() != nil {
+		return // the lease runs out and the export is written again
+	}
+	if err != nil {
+		vp.logger.Error("data export failed", "error", err, "exportID", export.ID, "userID", export.UserID)
+		if err := vp.db.FailDataExport(ctx, db.FailDataExportParams{Error: err.Error(), ID: export.ID}); err != nil {
+			vp.logger.Error("failed to record failed data export", "error", err, "exportID", export.ID)
+		}
+		return
+	}
+	vp.logger.Info("data export completed", "exportID", export.ID, "userID", export.UserID, "sizeBytes", size)
+}
+
+// writeExport assembles the archive of an export in a temporary file and uploads it
+func (vp *videoProcessor) writeExport(ctx context.Context, export db.DataExport, bucket, key string, linksExpireAt time.Time) (int64, error) {
+	userID := export.UserID
+	documents := []struct {
+		name    string
+		collect func() (interface{}, error)
+	}{
+		{"profile.json", func() (interface{}, error) { return vp.exportProfile(ctx, userID) }},
+		{"videos.json", func() (interface{}, error) { return vp.exportVideos(ctx, userID, time.Until(linksExpireAt)) }},
+		{"watch_history.json", func() (interface{}, error) { return vp.exportHistory(ctx, userID) }},
+		{"subscriptions.json", func() (interface{}, error) { return vp.ListSubscriptions(ctx, userID) }},
+		{"notifications.json", func() (interface{}, error) { return vp.exportNotifications(ctx, userID) }},
+		{"notification_preferences.json", func() (interface{}, error) { return vp.GetNotificationPreferences(ctx, userID) }},
+		{"reports.json", func() (interface{}, error) { return vp.exportReports(ctx, userID) }},
+	}
+
+	file, err := os.CreateTemp("", "export-*.zip")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	archive := zip.NewWriter(file)
+	manifest := models.ExportManifest{
+		ExportID:      export.ID,
+		UserID:        userID,
+		CreatedAt:     time.Now(),
+		LinksExpireAt: linksExpireAt,
+	}
+	for _, doc := range documents {
+		data, err := doc.collect()
+		if err != nil {
+			return 0, fmt.Errorf("failed to export %s: %w", doc.name, err)
+		}
+		if err := writeJSON(archive, doc.name, data); err != nil {
+			return 0, err
+		}
+		manifest.Files = append(manifest.Files, doc.name)
+	}
+	if err := writeJSON(archive, "manifest.json", manifest); err != nil {
+		return 0, err
+	}
+	if err := archive.Close(); err != nil {
+		return 0, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		return 0, err
+	}
+
+	if err := vp.ensureBucket(ctx, bucket); err != nil {
+		return 0, err
+	}
+	_, err = vp.minioClient.FPutObject(ctx, bucket, key, file.Name(), minio.PutObjectOptions{ContentType: "application/zip"})
+	if err != nil {
+		return 0, fmt.Errorf("failed to upload archive: %w", err)
+	}
+	return info.Size(), nil
+}
+
+func writeJSON(archive *zip.Writer, name string, data interface{}) error {
+	w, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(data)
+}
+
+func (vp *videoProcessor) exportProfile(ctx context.Context, userID uuid.UUID) (models.ExportedProfile, error) {
+	user, err := vp.db.GetUser(ctx, userID)
+	if err != nil {
+		return models.ExportedProfile{}, err
+	}
+	return models.ExportedProfile{
+		ID:                user.ID,
+		FirstName:         user.FirstName,
+		MiddleName:        user.MiddleName,
+		LastName:          user.LastName,
+		Username:          user.Username,
+		Email:             user.Email,
+		Phone:             user.Phone,
+		ProfilePictureURL: user.ProfilePictureUrl.String,
+		CreatedAt:         user.CreatedAt,
+		UpdatedAt:         user.UpdatedAt,
+	}, nil
+}
+
+// exportVideos lists every video of the user with links to the originals valid for linkExpiry
+func (vp *videoProcessor) exportVideos(ctx context.Context, userID uuid.UUID, linkExpiry time.Duration) ([]models.ExportedVideo, error) {
+	videos, err := vp.db.ListAllUserVideos(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	exported := make([]models.ExportedVideo, 0, len(videos))
+	for _, video := range videos {
+		variants, err := vp.db.ListVideoVariants(ctx, video.ID)
+		if err != nil {
+			return nil, err
+		}
+		assets, err := vp.db.ListVideoAssets(ctx, video.ID)
+		if err != nil {
+			return nil, err
+		}
+		chapters, err := vp.db.ListVideoChapters(ctx, video.ID)
+		if err != nil {
+			return nil, err
+		}
+		translations, err := vp.db.ListVideoTranslations(ctx, video.ID)
+		if err != nil {
+			return nil, err
+		}
+		original, err := vp.getVideoURL(ctx, video.Bucket, video.Key, linkExpiry)
+		if err != nil {
+			return nil, err
+		}
+
+		v := models.ExportedVideo{
+			VideoDetail:  convertDbVideoToVideoDetail(video),
+			Translations: make([]models.VideoTranslation, 0, len(translations)),
+			OriginalURL:  original,
+		}
+		v.Variants = make([]models.VideoVariant, 0, len(variants))
+		for _, variant := range variants {
+			v.Variants = append(v.Variants, variantFromRow(variant))
+		}
+		v.Assets = make(map[string]string, len(assets))
+		for _, a := range assets {
+			v.Assets[a.Kind] = a.Key
+		}
+		v.Chapters = make([]models.Chapter, 0, len(chapters))
+		for _, c := range chapters {
+			v.Chapters = append(v.Chapters, convertDbChapterToModelChapter(c))
+		}
+		for _, t := range translations {
+			v.Translations = append(v.Translations, translationFromRow(t))
+		}
+		exported = append(exported, v)
+	}
+	return exported, nil
+}
+
+func (vp *videoProcessor) exportHistory(ctx context.Context, userID uuid.UUID) ([]models.WatchHistoryEntry, error) {
+	history := []models.WatchHistoryEntry{}
+	for offset := int32(0); ; offset += exportPage {
+		rows, err := vp.db.ListWatchHistory(ctx, db.ListWatchHistoryParams{UserID: userID, Limit: exportPage, Offset: offset})
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			history = append(history, models.WatchHistoryEntry{
+				WatchPosition: watchPositionFromRow(row.VideoID, row.PositionMs, row.DurationMs, row.WatchedAt),
+				Title:         row.Title,
+			})
+		}
+		if len(rows) < exportPage {
+			return history, nil
+		}
+	}
+}
+
+func (vp *videoProcessor) exportNotifications(ctx context.Context, userID uuid.UUID) ([]models.Notification, error) {
+	notifications := []models.Notification{}
+	for offset := int32(0); ; offset += exportPage {
+		rows, err := vp.db.ListNotifications(ctx, db.ListNotificationsParams{UserID: userID, Limit: exportPage, Offset: offset})
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			notifications = append(notifications, notificationFromRow(row))
+		}
+		if len(rows) < exportPage {
+			return notifications, nil
+		}
+	}
+}
+
+// exportReports lists the reports the user filed
+func (vp *videoProcessor) exportReports(ctx context.Context, userID uuid.UUID) ([]models.VideoReport, error) {
+	rows, err := vp.db.ListUserReports(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	reports := make([]models.VideoReport, 0, len(rows))
+	for _, row := range rows {
+		reports = append(reports, reportFromRow(row))
+	}
+	return reports, nil
+}
+
+// expireExports deletes the archives of expired exports
+func (vp *videoProcessor) expireExports(ctx context.Context) {
+	rows, err := vp.db.ExpireDataExports(ctx, exportExpiryBatch)
+	if err != nil {
+		if ctx.Err() == nil {
+			vp.logger.Error("failed to expire data exports", "error", err)
+		}
+		return
+	}
+	for _, row := range rows {
+		if err := vp.minioClient.RemoveObject(ctx, row.Bucket, row.Key, minio.RemoveObjectOptions{}); err != nil {
+			vp.logger.Error("failed to delete expired export archive", "error", err, "exportID", row.ID, "bucket", row.Bucket, "key", row.Key)
+		}
+	}
+}
@@ -0,0 +1,11 @@
+//go:build e2e
+
+package video
+
+import "video-processing/models"
+
+// Internals the end-to-end tests in package video_test rely on
+var (
+	Variants         = newLadder(models.DefaultPreset).regular // the migrations seed the default preset
+	SynthesizeSource = synthesizeSource
+)
@@ -0,0 +1,169 @@
+package video
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+	"video-processing/database/db"
+	"video-processing/mocks"
+	"video-processing/models"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/minio/minio-go/v7"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func newExportProcessor(t *testing.T) (*videoProcessor, *mocks.MockVideoRepo, *mocks.MockObjectStore) {
+	ctrl := gomock.NewController(t)
+	repo := mocks.NewMockVideoRepo(ctrl)
+	store := mocks.NewMockObjectStore(ctrl)
+	vp := &videoProcessor{logger: slog.New(slog.NewTextHandler(io.Discard, nil)), db: repo, minioClient: store, urlExpiry: time.Hour}
+	return vp, repo, store
+}
+
+// readArchive decodes a JSON file of a zip archive
+func readArchive(t *testing.T, archive *zip.ReadCloser, name string, v interface{}) {
+	f, err := archive.Open(name)
+	require.NoError(t, err)
+	defer f.Close()
+	require.NoError(t, json.NewDecoder(f).Decode(v))
+}
+
+func TestExportWritesArchive(t *testing.T) {
+	vp, repo, store := newExportProcessor(t)
+	userID, videoID, exportID := uuid.New(), uuid.New(), uuid.New()
+	bucket, key := userID.String(), "exports/"+exportID.String()+".zip"
+	original, _ := url.Parse("https://minio.example.com/original")
+
+	repo.EXPECT().GetUser(gomock.Any(), userID).Return(db.User{ID: userID, Username: "ana", Email: "ana@example.com", Password: "hash"}, nil)
+	repo.EXPECT().ListAllUserVideos(gomock.Any(), userID).Return([]db.Video{
+		{ID: videoID, UserID: userID, Title: "clip", Bucket: bucket, Key: "clip.mp4", Visibility: models.VisibilityRemoved},
+	}, nil)
+	repo.EXPECT().ListVideoVariants(gomock.Any(), videoID).Return([]db.VideoVariant{{VariantName: "720p", Key: "processed/720p.mp4"}}, nil)
+	repo.EXPECT().ListVideoAssets(gomock.Any(), videoID).Return(nil, nil)
+	repo.EXPECT().ListVideoChapters(gomock.Any(), videoID).Return(nil, nil)
+	repo.EXPECT().ListVideoTranslations(gomock.Any(), videoID).Return([]db.VideoTranslation{{VideoID: videoID, Language: "fr", Title: "extrait"}}, nil)
+	store.EXPECT().
+		PresignedGetObject(gomock.Any(), bucket, "clip.mp4", gomock.Any(), gomock.Nil()).
+		DoAndReturn(func(_ context.Context, _, _ string, expiry time.Duration, _ url.Values) (*url.URL, error) {
+			require.InDelta(t, exportRetention.Seconds(), expiry.Seconds(), 60)
+			return original, nil
+		})
+	repo.EXPECT().ListWatchHistory(gomock.Any(), db.ListWatchHistoryParams{UserID: userID, Limit: exportPage}).
+		Return([]db.ListWatchHistoryRow{{VideoID: videoID, Title: "clip", PositionMs: 1000, DurationMs: 60000}}, nil)
+	repo.EXPECT().ListSubscriptions(gomock.Any(), userID).Return(nil, nil)
+	repo.EXPECT().ListNotifications(gomock.Any(), db.ListNotificationsParams{UserID: userID, Limit: exportPage}).Return(nil, nil)
+	repo.EXPECT().GetNotificationPreferences(gomock.Any(), userID).Return(db.GetNotificationPreferencesRow{Email: "ana@example.com"}, nil)
+	repo.EXPECT().ListUserReports(gomock.Any(), userID).Return([]db.VideoReport{{ID: uuid.New(), ReporterID: userID, Reason: "spam", Status: models.ReportOpen}}, nil)
+	store.EXPECT().ListBuckets(gomock.Any()).Return([]minio.BucketInfo{{Name: bucket}}, nil)
+	store.EXPECT().
+		FPutObject(gomock.Any(), bucket, key, gomock.Any(), minio.PutObjectOptions{ContentType: "application/zip"}).
+		DoAndReturn(func(_ context.Context, _, _, path string, _ minio.PutObjectOptions) (minio.UploadInfo, error) {
+			archive, err := zip.OpenReader(path)
+			require.NoError(t, err)
+			defer archive.Close()
+
+			var manifest models.ExportManifest
+			readArchive(t, archive, "manifest.json", &manifest)
+			require.Equal(t, exportID, manifest.ExportID)
+			require.Contains(t, manifest.Files, "watch_history.json")
+
+			profile := map[string]interface{}{}
+			readArchive(t, archive, "profile.json", &profile)
+			require.Equal(t, "ana", profile["username"])
+			require.NotContains(t, profile, "password")
+
+			var videos []models.ExportedVideo
+			readArchive(t, archive, "videos.json", &videos)
+			require.Len(t, videos, 1)
+			require.Equal(t, original.String(), videos[0].OriginalURL)
+			require.Equal(t, "720p", videos[0].Variants[0].Name)
+			require.Equal(t, "extrait", videos[0].Translations[0].Title)
+
+			var history []models.WatchHistoryEntry
+			readArchive(t, archive, "watch_history.json", &history)
+			require.Equal(t, int64(1000), history[0].PositionMs)
+			return minio.UploadInfo{}, nil
+		})
+	repo.EXPECT().
+		CompleteDataExport(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, arg db.CompleteDataExportParams) (db.DataExport, error) {
+			require.Equal(t, exportID, arg.ID)
+			require.Equal(t, key, arg.Key)
+			require.Positive(t, arg.SizeBytes)
+			require.WithinDuration(t, time.Now().Add(exportRetention), arg.ExpiresAt.Time, time.Minute)
+			return db.DataExport{}, nil
+		})
+	vp.export(context.Background(), db.DataExport{ID: exportID, UserID: userID, Status: models.ExportRunning})
+}
+
+func TestExportFailure(t *testing.T) {
+	vp, repo, _ := newExportProcessor(t)
+	userID, exportID := uuid.New(), uuid.New()
+
+	repo.EXPECT().GetUser(gomock.Any(), userID).Return(db.User{}, errors.New("connection reset"))
+	repo.EXPECT().
+		FailDataExport(gomock.Any(), db.FailDataExportParams{Error: "failed to export profile.json: connection reset", ID: exportID}).
+		Return(nil)
+	vp.export(context.Background(), db.DataExport{ID: exportID, UserID: userID})
+}
+
+func TestExportDataReturnsExportInProgress(t *testing.T) {
+	vp, repo, _ := newExportProcessor(t)
+	userID, exportID := uuid.New(), uuid.New()
+	pending := db.DataExport{ID: exportID, UserID: userID, Status: models.ExportPending}
+
+	repo.EXPECT().CreateDataExport(gomock.Any(), userID).Return(db.DataExport{}, pgx.ErrNoRows)
+	repo.EXPECT().GetActiveDataExport(gomock.Any(), userID).Return(pending, nil)
+	export, err := vp.ExportData(context.Background(), userID)
+	require.NoError(t, err)
+	require.Equal(t, exportID, export.ID)
+	require.Empty(t, export.DownloadURL)
+}
+
+func TestGetDataExport(t *testing.T) {
+	vp, repo, store := newExportProcessor(t)
+	userID, exportID := uuid.New(), uuid.New()
+	expiresAt := time.Now().Add(exportRetention)
+	download, _ := url.Parse("https://minio.example.com/export.zip")
+
+	repo.EXPECT().GetDataExport(gomock.Any(), db.GetDataExportParams{ID: exportID, UserID: userID}).Return(db.DataExport{
+		ID:        exportID,
+		UserID:    userID,
+		Status:    models.ExportCompleted,
+		Bucket:    userID.String(),
+		Key:       "exports/" + exportID.String() + ".zip",
+		ExpiresAt: pgtype.Timestamptz{Time: expiresAt, Valid: true},
+	}, nil)
+	store.EXPECT().PresignedGetObject(gomock.Any(), userID.String(), "exports/"+exportID.String()+".zip", time.Hour, gomock.Nil()).Return(download, nil)
+	export, err := vp.GetDataExport(context.Background(), userID, exportID)
+	require.NoError(t, err)
+	require.Equal(t, download.String(), export.DownloadURL)
+	require.Equal(t, expiresAt, *export.ExpiresAt)
+
+	// exports of other users are not found
+	repo.EXPECT().GetDataExport(gomock.Any(), gomock.Any()).Return(db.DataExport{}, pgx.ErrNoRows)
+	_, err = vp.GetDataExport(context.Background(), uuid.New(), exportID)
+	var e models.Error
+	require.ErrorAs(t, err, &e)
+	require.Equal(t, http.StatusNotFound, e.Code)
+}
+
+func TestExpireExportsDeletesArchives(t *testing.T) {
+	vp, repo, store := newExportProcessor(t)
+	expired := db.ExpireDataExportsRow{ID: uuid.New(), Bucket: "user", Key: "exports/old.zip"}
+
+	repo.EXPECT().ExpireDataExports(gomock.Any(), int32(exportExpiryBatch)).Return([]db.ExpireDataExportsRow{expired}, nil)
+	store.EXPECT().RemoveObject(gomock.Any(), "user", "exports/old.zip", gomock.Any()).Return(nil)
+	vp.expireExports(context.Background())
+}
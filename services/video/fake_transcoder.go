@@ -0,0 +1,94 @@
+package video
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// FakeTranscoder stands in for ffmpeg in tests and dry runs. It records every command and fabricates
+// placeholder outputs instead of encoding anything: the output file named by the last
+// argument is created, and HLS packaging also gets a playlist with a single segment.
+type FakeTranscoder struct {
+	// ProbeOutput is returned for every ffprobe command
+	ProbeOutput []byte
+	// StreamOutput is what streamed commands write to stdout
+	StreamOutput []byte
+	// FailOn makes every command with an argument containing it fail
+	FailOn string
+
+	mu    sync.Mutex
+	calls [][]string
+}
+
+func NewFakeTranscoder() *FakeTranscoder {
+	return &FakeTranscoder{}
+}
+
+// Calls returns the arguments of the commands run so far, ffprobe commands included
+func (f *FakeTranscoder) Calls() [][]string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return slices.Clone(f.calls)
+}
+
+func (f *FakeTranscoder) record(args []string) error {
+	f.mu.Lock()
+	f.calls = append(f.calls, args)
+	f.mu.Unlock()
+	if f.FailOn != "" && slices.ContainsFunc(args, func(a string) bool { return strings.Contains(a, f.FailOn) }) {
+		return fmt.Errorf("fake failure: %s", strings.Join(args, " "))
+	}
+	return nil
+}
+
+func (f *FakeTranscoder) Run(ctx context.Context, args ...string) error {
+	if err := f.record(args); err != nil {
+		return err
+	}
+	return fabricate(args)
+}
+
+// fabricate creates the placeholder outputs of the ffmpeg command args
+func fabricate(args []string) error {
+	if len(args) == 0 {
+		return nil
+	}
+	out := args[len(args)-1]
+	// commands ending in a flag, like -encoders, only print
+	if strings.HasPrefix(out, "-") || strings.HasPrefix(out, "pipe:") {
+		return nil
+	}
+	if i := slices.Index(args, "-hls_segment_filename"); i >= 0 && i+1 < len(args) {
+		segment := fmt.Sprintf(args[i+1], 0)
+		if err := os.WriteFile(segment, []byte("fake segment"), 0o644); err != nil {
+			return err
+		}
+		playlist := fmt.Sprintf("#EXTM3U\n#EXT-X-VERSION:3\n#EXT-X-TARGETDURATION:6\n#EXT-X-PLAYLIST-TYPE:VOD\n#EXTINF:6.000000,\n%s\n#EXT-X-ENDLIST\n", filepath.Base(segment))
+		return os.WriteFile(out, []byte(playlist), 0o644)
+	}
+	return os.WriteFile(out, []byte("fake output"), 0o644)
+}
+
+func (f *FakeTranscoder) Stream(ctx context.Context, read func(io.Reader) error, args ...string) error {
+	if err := f.record(args); err != nil {
+		return err
+	}
+	if err := fabricate(args); err != nil {
+		return err
+	}
+	return read(bytes.NewReader(f.StreamOutput))
+}
+
+func (f *FakeTranscoder) Probe(ctx context.Context, args ...string) ([]byte, error) {
+	if err := f.record(args); err != nil {
+		return nil, err
+	}
+	return f.ProbeOutput, nil
+}
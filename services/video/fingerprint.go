@@ -0,0 +1,141 @@
+package video
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/bits"
+	"video-processing/database/db"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// fingerprintFrames is the number of frames sampled evenly across the video
+	fingerprintFrames = 64
+	// dHash works on a 9x8 grayscale thumbnail: 8 horizontal gradients per row
+	dHashWidth  = 9
+	dHashHeight = 8
+	// fingerprintMaxDistance is the Hamming distance up to which two frame hashes count as the same picture
+	fingerprintMaxDistance = 10
+)
+
+// dHash computes the difference hash of a 9x8 grayscale frame.
+// Each bit records whether a pixel is brighter than its right neighbour.
+func dHash(frame []byte) uint64 {
+	var h uint64
+	for y := 0; y < dHashHeight; y++ {
+		row := frame[y*dHashWidth : (y+1)*dHashWidth]
+		for x := 0; x < dHashWidth-1; x++ {
+			h <<= 1
+			if row[x] > row[x+1] {
+				h |= 1
+			}
+		}
+	}
+	return h
+}
+
+// readFrameHashes hashes consecutive 9x8 gray frames from r.
+// Flat frames (black or single-color slates) hash to zero and are skipped,
+// otherwise every video with a black intro would look alike.
+func readFrameHashes(r io.Reader) ([]uint64, error) {
+	br := bufio.NewReader(r)
+	frame := make([]byte, dHashWidth*dHashHeight)
+	var hashes []uint64
+	for {
+		if _, err := io.ReadFull(br, frame); err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				return hashes, nil
+			}
+			return nil, fmt.Errorf("failed to read frame: %w", err)
+		}
+		if h := dHash(frame); h != 0 {
+			hashes = append(hashes, h)
+		}
+	}
+}
+
+// generateFingerprint samples frames evenly over durationSeconds and returns their dHashes.
+func generateFingerprint(ctx context.Context, t Transcoder, inputPath string, durationSeconds float64) ([]uint64, error) {
+	if durationSeconds <= 0 {
+		return nil, fmt.Errorf("unknown source duration")
+	}
+	// ffmpeg -i input -vf fps=N/DURATION,scale=9:8,format=gray -f rawvideo pipe:1
+	args := []string{
+		"-nostdin",
+		"-v", "error",
+		"-i", inputPath,
+		"-an",
+		"-vf", fmt.Sprintf("fps=%d/%.3f,scale=%d:%d:flags=area,format=gray", fingerprintFrames, durationSeconds, dHashWidth, dHashHeight),
+		"-frames:v", fmt.Sprint(fingerprintFrames),
+		"-f", "rawvideo",
+		"pipe:1",
+	}
+	var hashes []uint64
+	err := t.Stream(ctx, func(r io.Reader) (err error) {
+		hashes, err = readFrameHashes(r)
+		return err
+	}, args...)
+	if err != nil {
+		return nil, fmt.Errorf("ffmpeg fingerprint error: %w", err)
+	}
+	return hashes, nil
+}
+
+// CompareFingerprints returns the share of frames of the shorter fingerprint that have a
+// near-identical frame anywhere in the other one, between 0 and 1.
+// Matching is order independent so re-cut or trimmed copies are still found.
+func CompareFingerprints(a, b []uint64) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	matches := 0
+	for _, ha := range a {
+		for _, hb := range b {
+			if bits.OnesCount64(ha^hb) <= fingerprintMaxDistance {
+				matches++
+				break
+			}
+		}
+	}
+	return float64(matches) / float64(len(a))
+}
+
+// processFingerprint computes and stores the perceptual fingerprint of the source video.
+// Failures are logged only; fingerprints are not needed for playback.
+func (rc *redisConsumer) processFingerprint(ctx context.Context, task ProcessingTask, durationSeconds float64) {
+	hashes, err := generateFingerprint(ctx, rc.transcoder, task.SourcePath, durationSeconds)
+	if err != nil {
+		rc.logger.Warn("fingerprint generation failed", "error", err, "videoID", task.VideoID)
+		return
+	}
+	if len(hashes) == 0 {
+		rc.logger.Info("no usable frames for fingerprint", "videoID", task.VideoID)
+		return
+	}
+	videoUUID, err := uuid.Parse(task.VideoID)
+	if err != nil {
+		rc.logger.Error("invalid video ID for fingerprint", "error", err, "videoID", task.VideoID)
+		return
+	}
+
+	frameHashes := make([]int64, len(hashes))
+	for i, h := range hashes {
+		frameHashes[i] = int64(h)
+	}
+	err = rc.db.SaveVideoFingerprint(ctx, db.SaveVideoFingerprintParams{
+		VideoID:     videoUUID,
+		FrameHashes: frameHashes,
+	})
+	if err != nil {
+		rc.logger.Error("failed to save fingerprint", "error", err, "videoID", task.VideoID)
+		return
+	}
+	rc.logger.Info("saved video fingerprint", "videoID", task.VideoID, "frames", len(hashes))
+}
@@ -0,0 +1,28 @@
+package video_test
+
+import (
+	"testing"
+	"video-processing/services/video"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompareFingerprints(t *testing.T) {
+	original := []uint64{0xF0F0F0F0F0F0F0F0, 0x0123456789ABCDEF, 0xAAAAAAAAAAAAAAAA, 0x5555555555555555}
+	testCases := []struct {
+		name string
+		a, b []uint64
+		want float64
+	}{
+		{name: "identical", a: original, b: original, want: 1},
+		{name: "trimmed copy", a: original[1:3], b: original, want: 1},
+		{name: "re-encoded copy with a few flipped bits", a: []uint64{0xF0F0F0F0F0F0F0F1, 0x0123456789ABCDE0}, b: original, want: 1},
+		{name: "half unrelated", a: []uint64{original[0], 0xFFFFFFFF00000000}, b: original, want: 0.5},
+		{name: "empty", a: nil, b: original, want: 0},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, video.CompareFingerprints(tc.a, tc.b))
+		})
+	}
+}
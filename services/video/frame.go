@@ -0,0 +1,185 @@
+package video
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+	"video-processing/database/db"
+	"video-processing/models"
+	"video-processing/storage"
+
+	"github.com/google/uuid"
+	"github.com/minio/minio-go/v7"
+)
+
+const (
+	// frameSourceName is the Source of frames taken from the stored upload
+	frameSourceName = "source"
+	// frameURLExpiry bounds how long ffmpeg may read the video a frame is taken from
+	frameURLExpiry = 10 * time.Minute
+)
+
+// framesPrefix is where the frames extracted from a video are stored, under the prefix of its
+// owner like its results
+func framesPrefix(owner, sourceKey string, videoID uuid.UUID) string {
+	return fmt.Sprintf("%sframes/%s/", storage.OwnerPrefix(owner, sourceKey), videoID)
+}
+
+// frameKey is where the frame at ms milliseconds into a video is stored
+func frameKey(owner string, video db.Video, ms int64) string {
+	return fmt.Sprintf("%s%d.jpg", framesPrefix(owner, video.Key, video.ID), ms)
+}
+
+// frameArgs take the frame atSecond into the input as a JPEG, tone mapped from HDR inputs
+func frameArgs(inputPath, outPath string, atSecond float64, hdrFormat string) []string {
+	args := []string{
+		"-y",
+		"-nostdin",
+		"-ss", fmt.Sprintf("%.3f", atSecond),
+		"-i", inputPath,
+		"-frames:v", "1",
+	}
+	if hdrFormat != "" {
+		args = append(args, "-vf", toneMapFilter)
+	}
+	return append(args, "-q:v", "2", outPath)
+}
+
+// bestVariant is the MP4 variant with the most pixels, for videos whose source is gone
+func bestVariant(variants []db.VideoVariant) (db.VideoVariant, bool) {
+	var best db.VideoVariant
+	found := false
+	for _, v := range variants {
+		if v.Format != FormatMP4 {
+			continue
+		}
+		if !found || v.Width.Int32*v.Height.Int32 > best.Width.Int32*best.Height.Int32 {
+			best, found = v, true
+		}
+	}
+	return best, found
+}
+
+// ExtractFrame returns a JPEG of the frame of a video of the user at query.At seconds. The
+// frame is taken from the stored source, or from the largest variant when the source is gone,
+// and stored, so later requests for the same moment are served without ffmpeg.
+func (vp *videoProcessor) ExtractFrame(ctx context.Context, userID, videoID uuid.UUID, query models.FrameQuery) (models.VideoFrame, error) {
+	params := fmt.Sprintf("userID: %v, videoID: %v", userID, videoID)
+	if err := query.Validate(); err != nil {
+		return models.VideoFrame{}, models.Error{
+			Code:    http.StatusBadRequest,
+			Message: "invalid input data",
+			Params:  params,
+			Err:     err,
+		}
+	}
+	params = fmt.Sprintf("%s, t: %v", params, *query.At)
+	video, err := vp.getOwnedVideo(ctx, userID, videoID)
+	if err != nil {
+		return models.VideoFrame{}, err
+	}
+	pastEnd := models.Error{
+		Code:    http.StatusBadRequest,
+		Message: "invalid input data",
+		Params:  params,
+		Err:     errors.Join(errors.New("t is past the end of the video"), models.ErrInvalidInputData),
+	}
+	ms := int64(*query.At * 1000)
+	if video.DurationMs.Valid && ms >= video.DurationMs.Int64 {
+		return models.VideoFrame{}, pastEnd
+	}
+
+	frame := models.VideoFrame{At: float64(ms) / 1000, Key: frameKey(userID.String(), video, ms)}
+	if info, err := vp.minioClient.StatObject(ctx, video.Bucket, frame.Key, minio.StatObjectOptions{}); err == nil {
+		frame.Source = info.UserMetadata["Source"]
+		frame.URL, err = vp.getVideoURL(ctx, video.Bucket, frame.Key, vp.urlExpiry)
+		return frame, err
+	}
+
+	bucket, key := video.Bucket, video.Key
+	frame.Source = frameSourceName
+	if _, err := vp.minioClient.StatObject(ctx, bucket, key, minio.StatObjectOptions{}); err != nil {
+		variants, err := vp.db.ListVideoVariants(ctx, videoID)
+		if err != nil {
+			return models.VideoFrame{}, models.IndentifyDbError(err).AddParams(params)
+		}
+		variant, ok := bestVariant(variants)
+		if !ok {
+			return models.VideoFrame{}, models.Error{
+				Code:    http.StatusNotFound,
+				Message: "resource not found",
+				Params:  params,
+				Err:     errors.Join(errors.New("neither the source nor a variant of the video is stored"), models.ErrResourceNotFound),
+			}
+		}
+		bucket, key, frame.Source = variant.Bucket, variant.Key, variant.VariantName
+	}
+
+	url, err := vp.minioClient.PresignedGetObject(ctx, bucket, key, frameURLExpiry, nil)
+	if err != nil {
+		return models.VideoFrame{}, models.Error{
+			Code:        http.StatusInternalServerError,
+			Message:     "internal server error",
+			Description: "failed to presign the video",
+			Params:      params,
+			Err:         err,
+		}
+	}
+	probe, err := probeSource(ctx, vp.transcoder, url.String())
+	if err != nil {
+		return models.VideoFrame{}, models.Error{
+			Code:        http.StatusUnprocessableEntity,
+			Message:     "probe failed",
+			Description: "ffprobe could not read the video",
+			Params:      params,
+			Err:         err,
+		}
+	}
+	// videos processed before their duration was stored are checked against the probe
+	if d := probe.Duration(); d > 0 && frame.At >= d {
+		return models.VideoFrame{}, pastEnd
+	}
+	stream, _ := probe.VideoStream()
+
+	dir, err := os.MkdirTemp("", "video-frame-*")
+	if err != nil {
+		return models.VideoFrame{}, models.Error{
+			Code:        http.StatusInternalServerError,
+			Message:     "internal server error",
+			Description: "failed to create a working directory",
+			Params:      params,
+			Err:         err,
+		}
+	}
+	defer os.RemoveAll(dir)
+	outPath := filepath.Join(dir, path.Base(frame.Key))
+	if err := vp.transcoder.Run(ctx, frameArgs(url.String(), outPath, frame.At, stream.HDRFormat())...); err != nil {
+		return models.VideoFrame{}, models.Error{
+			Code:        http.StatusUnprocessableEntity,
+			Message:     "frame extraction failed",
+			Description: "ffmpeg could not extract the frame",
+			Params:      params,
+			Err:         fmt.Errorf("ffmpeg frame error: %w", err),
+		}
+	}
+	_, err = vp.minioClient.FPutObject(ctx, video.Bucket, frame.Key, outPath, minio.PutObjectOptions{
+		ContentType:  "image/jpeg",
+		UserMetadata: map[string]string{"Source": frame.Source},
+	})
+	if err != nil {
+		return models.VideoFrame{}, models.Error{
+			Code:        http.StatusInternalServerError,
+			Message:     "internal server error",
+			Description: "failed to store the frame",
+			Params:      params,
+			Err:         err,
+		}
+	}
+	frame.URL, err = vp.getVideoURL(ctx, video.Bucket, frame.Key, vp.urlExpiry)
+	return frame, err
+}
@@ -0,0 +1,107 @@
+package video
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+	"video-processing/database/db"
+	"video-processing/mocks"
+	"video-processing/models"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/minio/minio-go/v7"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestFrameArgs(t *testing.T) {
+	require.Equal(t, []string{"-y", "-nostdin", "-ss", "12.500", "-i", "in.mp4", "-frames:v", "1", "-q:v", "2", "out.jpg"},
+		frameArgs("in.mp4", "out.jpg", 12.5, ""))
+	require.Contains(t, frameArgs("in.mp4", "out.jpg", 0, "hdr10"), toneMapFilter)
+}
+
+func TestBestVariant(t *testing.T) {
+	_, ok := bestVariant(nil)
+	require.False(t, ok)
+
+	best, ok := bestVariant([]db.VideoVariant{
+		{VariantName: "480p", Format: FormatMP4, Width: pgtype.Int4{Int32: 854, Valid: true}, Height: pgtype.Int4{Int32: 480, Valid: true}},
+		{VariantName: "1080p", Format: FormatWebM, Width: pgtype.Int4{Int32: 1920, Valid: true}, Height: pgtype.Int4{Int32: 1080, Valid: true}},
+		{VariantName: "720p", Format: FormatMP4, Width: pgtype.Int4{Int32: 1280, Valid: true}, Height: pgtype.Int4{Int32: 720, Valid: true}},
+	})
+	require.True(t, ok)
+	require.Equal(t, "720p", best.VariantName)
+}
+
+func TestExtractFrame(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	repo := mocks.NewMockVideoRepo(ctrl)
+	store := mocks.NewMockObjectStore(ctrl)
+	fake := NewFakeTranscoder()
+	fake.ProbeOutput = []byte(sampleProbe)
+	vp := &videoProcessor{logger: slog.New(slog.NewTextHandler(io.Discard, nil)), db: repo, minioClient: store, transcoder: fake, urlExpiry: time.Hour}
+	ctx := context.Background()
+
+	owner := uuid.New()
+	video := db.Video{ID: uuid.New(), UserID: owner, Bucket: "videos", Key: owner.String() + "/clip.mov", DurationMs: pgtype.Int8{Int64: 12500, Valid: true}}
+	repo.EXPECT().GetVideo(gomock.Any(), video.ID).Return(video, nil).AnyTimes()
+	signed, _ := url.Parse("http://minio:9000/signed")
+	store.EXPECT().PresignedGetObject(gomock.Any(), "videos", gomock.Any(), gomock.Any(), nil).Return(signed, nil).AnyTimes()
+	at := func(seconds float64) models.FrameQuery { return models.FrameQuery{At: &seconds} }
+
+	// the frame is taken from the source and stored under the prefix of the owner
+	key := owner.String() + "/frames/" + video.ID.String() + "/4250.jpg"
+	store.EXPECT().StatObject(gomock.Any(), "videos", key, gomock.Any()).Return(minio.ObjectInfo{}, errors.New("not found"))
+	store.EXPECT().StatObject(gomock.Any(), "videos", video.Key, gomock.Any()).Return(minio.ObjectInfo{}, nil)
+	store.EXPECT().FPutObject(gomock.Any(), "videos", key, gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, _, _, filePath string, opts minio.PutObjectOptions) (minio.UploadInfo, error) {
+			require.FileExists(t, filePath)
+			require.Equal(t, "image/jpeg", opts.ContentType)
+			require.Equal(t, frameSourceName, opts.UserMetadata["Source"])
+			return minio.UploadInfo{}, nil
+		})
+	frame, err := vp.ExtractFrame(ctx, owner, video.ID, at(4.25))
+	require.NoError(t, err)
+	require.Equal(t, models.VideoFrame{At: 4.25, Source: frameSourceName, Key: key, URL: signed.String()}, frame)
+	require.Len(t, fake.Calls(), 2)
+	require.Contains(t, fake.Calls()[1], "4.250")
+
+	// stored frames are served again without ffmpeg
+	store.EXPECT().StatObject(gomock.Any(), "videos", key, gomock.Any()).Return(minio.ObjectInfo{UserMetadata: map[string]string{"Source": frameSourceName}}, nil)
+	frame, err = vp.ExtractFrame(ctx, owner, video.ID, at(4.25))
+	require.NoError(t, err)
+	require.Equal(t, frameSourceName, frame.Source)
+	require.Len(t, fake.Calls(), 2)
+
+	// the largest variant stands in for a removed source
+	key = owner.String() + "/frames/" + video.ID.String() + "/0.jpg"
+	store.EXPECT().StatObject(gomock.Any(), "videos", key, gomock.Any()).Return(minio.ObjectInfo{}, errors.New("not found"))
+	store.EXPECT().StatObject(gomock.Any(), "videos", video.Key, gomock.Any()).Return(minio.ObjectInfo{}, errors.New("not found"))
+	repo.EXPECT().ListVideoVariants(gomock.Any(), video.ID).Return([]db.VideoVariant{
+		{VariantName: "720p", Format: FormatMP4, Bucket: "videos", Key: "720p.mp4", Width: pgtype.Int4{Int32: 1280, Valid: true}, Height: pgtype.Int4{Int32: 720, Valid: true}},
+	}, nil)
+	store.EXPECT().FPutObject(gomock.Any(), "videos", key, gomock.Any(), gomock.Any()).Return(minio.UploadInfo{}, nil)
+	frame, err = vp.ExtractFrame(ctx, owner, video.ID, at(0))
+	require.NoError(t, err)
+	require.Equal(t, "720p", frame.Source)
+
+	// times past the end and missing times are rejected
+	var apiErr models.Error
+	_, err = vp.ExtractFrame(ctx, owner, video.ID, at(12.5))
+	require.True(t, errors.As(err, &apiErr))
+	require.Equal(t, http.StatusBadRequest, apiErr.Code)
+	_, err = vp.ExtractFrame(ctx, owner, video.ID, models.FrameQuery{})
+	require.True(t, errors.As(err, &apiErr))
+	require.Equal(t, http.StatusBadRequest, apiErr.Code)
+
+	// other users do not see it
+	_, err = vp.ExtractFrame(ctx, uuid.New(), video.ID, at(1))
+	require.True(t, errors.As(err, &apiErr))
+	require.Equal(t, http.StatusNotFound, apiErr.Code)
+}
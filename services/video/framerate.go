@@ -0,0 +1,57 @@
+package video
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// FrameRatePassthrough keeps the frame rate of every source, the default of
+// ProcessingConfig.FrameRate
+const FrameRatePassthrough = "passthrough"
+
+// outputFrameRate is the rate the variants of a source with rate sourceRate, as ffprobe's
+// r_frame_rate, are encoded at under the configured target. It is empty when the source keeps
+// its rate: with passthrough, for sources at or below the target and for unknown rates.
+// Faster sources keep every nth frame when that lands close below the target, so motion stays
+// regular and 59.94 becomes 29.97 rather than 30. Otherwise they are set to the target.
+func outputFrameRate(target, sourceRate string) (string, error) {
+	if target == "" || target == FrameRatePassthrough {
+		return "", nil
+	}
+	fps, err := strconv.ParseFloat(target, 64)
+	if err != nil || fps <= 0 {
+		return "", fmt.Errorf("frame rate must be %q or a positive number, got %q", FrameRatePassthrough, target)
+	}
+	numText, denText, _ := strings.Cut(sourceRate, "/")
+	num, err := strconv.Atoi(numText)
+	if err != nil || num <= 0 {
+		return "", nil
+	}
+	den := 1
+	if denText != "" {
+		if den, err = strconv.Atoi(denText); err != nil || den <= 0 {
+			return "", nil
+		}
+	}
+	source := float64(num) / float64(den)
+	// 30000/1001 is 30 to a viewer
+	if source <= fps*1.001 {
+		return "", nil
+	}
+	n := int(math.Ceil(source/fps - 1e-3))
+	if source/float64(n) >= fps*0.9 {
+		return fmt.Sprintf("%d/%d", num, den*n), nil
+	}
+	return formatFactor(fps), nil
+}
+
+// frameRateChain puts the task's frame rate conversion in front of the video filter chain vf,
+// so the filters after it see fewer frames
+func frameRateChain(vf string, task ProcessingTask) string {
+	if task.FrameRate == "" {
+		return vf
+	}
+	return "fps=" + task.FrameRate + "," + vf
+}
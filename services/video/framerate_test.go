@@ -0,0 +1,51 @@
+package video
+
+import (
+	"context"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOutputFrameRate(t *testing.T) {
+	testCases := []struct {
+		name   string
+		target string
+		source string
+		want   string
+	}{
+		{name: "passthrough", target: FrameRatePassthrough, source: "120/1", want: ""},
+		{name: "unset", target: "", source: "120/1", want: ""},
+		{name: "slower source", target: "30", source: "25/1", want: ""},
+		{name: "NTSC source at the target", target: "30", source: "30000/1001", want: ""},
+		{name: "every fourth frame", target: "30", source: "120/1", want: "120/4"},
+		{name: "every other NTSC frame", target: "30", source: "60000/1001", want: "60000/2002"},
+		{name: "no even fraction", target: "24", source: "30/1", want: "24"},
+		{name: "unknown source", target: "30", source: "0/0", want: ""},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := outputFrameRate(tc.target, tc.source)
+			require.NoError(t, err)
+			require.Equal(t, tc.want, got)
+		})
+	}
+
+	_, err := outputFrameRate("fast", "60/1")
+	require.Error(t, err)
+	_, err = outputFrameRate("-30", "60/1")
+	require.Error(t, err)
+}
+
+func TestTranscodeToMP4FrameRate(t *testing.T) {
+	fake := NewFakeTranscoder()
+	task := ProcessingTask{Variant: testLadder.regular[1], SourcePath: "source.mp4", FrameRate: "120/4"}
+	require.NoError(t, transcodeToMP4(context.Background(), fake, task, path.Join(t.TempDir(), "720p.mp4")))
+	require.Contains(t, fake.Calls()[0], "fps=120/4,scale=1280:720")
+
+	// frames are dropped after deinterlacing
+	task.Deinterlace = true
+	require.NoError(t, transcodeToMP4(context.Background(), fake, task, path.Join(t.TempDir(), "720p.mp4")))
+	require.Contains(t, fake.Calls()[1], "yadif,fps=120/4,scale=1280:720")
+}
@@ -0,0 +1,130 @@
+package video
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+	"video-processing/database/db"
+	"video-processing/models"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// SavePosition records how far the user got in a video, moving it to the top of the history
+func (vp *videoProcessor) SavePosition(ctx context.Context, userID, videoID uuid.UUID, req models.WatchPositionRequest) (models.WatchPosition, error) {
+	params := fmt.Sprintf("userID: %v, videoID: %v", userID, videoID)
+	if err := req.Validate(); err != nil {
+		return models.WatchPosition{}, models.Error{
+			Code:    http.StatusBadRequest,
+			Message: "invalid input data",
+			Params:  params,
+			Err:     err,
+		}
+	}
+	if _, err := vp.getVisibleVideo(ctx, userID, videoID); err != nil {
+		return models.WatchPosition{}, err
+	}
+	row, err := vp.db.SaveWatchPosition(ctx, db.SaveWatchPositionParams{
+		UserID:     userID,
+		VideoID:    videoID,
+		PositionMs: req.PositionMs,
+		DurationMs: req.DurationMs,
+	})
+	if err != nil {
+		return models.WatchPosition{}, models.IndentifyDbError(err).AddParams(params)
+	}
+	return watchPositionFromRow(row.VideoID, row.PositionMs, row.DurationMs, row.WatchedAt), nil
+}
+
+// GetPosition returns where playback of a video resumes, the start when the user never watched it
+func (vp *videoProcessor) GetPosition(ctx context.Context, userID, videoID uuid.UUID) (models.WatchPosition, error) {
+	params := fmt.Sprintf("userID: %v, videoID: %v", userID, videoID)
+	if _, err := vp.getVisibleVideo(ctx, userID, videoID); err != nil {
+		return models.WatchPosition{}, err
+	}
+	row, err := vp.db.GetWatchPosition(ctx, db.GetWatchPositionParams{UserID: userID, VideoID: videoID})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.WatchPosition{VideoID: videoID}, nil
+	}
+	if err != nil {
+		return models.WatchPosition{}, models.IndentifyDbError(err).AddParams(params)
+	}
+	return watchPositionFromRow(row.VideoID, row.PositionMs, row.DurationMs, row.WatchedAt), nil
+}
+
+// ListHistory lists the videos the user watched, last watched first
+func (vp *videoProcessor) ListHistory(ctx context.Context, userID uuid.UUID, query models.ListVideosQuery) ([]models.WatchHistoryEntry, error) {
+	params := fmt.Sprintf("userID: %v, query: %v", userID, query)
+	if err := query.Validate(); err != nil {
+		return nil, models.Error{
+			Code:    http.StatusBadRequest,
+			Message: "invalid input data",
+			Params:  params,
+			Err:     err,
+		}
+	}
+	rows, err := vp.db.ListWatchHistory(ctx, db.ListWatchHistoryParams{
+		UserID: userID,
+		Limit:  int32(query.Limit),
+		Offset: int32(query.Offset),
+	})
+	if err != nil {
+		return nil, models.IndentifyDbError(err).AddParams(params)
+	}
+	history := make([]models.WatchHistoryEntry, 0, len(rows))
+	for _, row := range rows {
+		entry := models.WatchHistoryEntry{
+			WatchPosition: watchPositionFromRow(row.VideoID, row.PositionMs, row.DurationMs, row.WatchedAt),
+			Title:         row.Title,
+		}
+		if row.PreviewKey.Valid {
+			entry.PreviewURL, err = vp.getVideoURL(ctx, row.PreviewBucket.String, row.PreviewKey.String, vp.urlExpiry)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if row.AnimatedPreviewKey.Valid {
+			entry.AnimatedPreviewURL, err = vp.getVideoURL(ctx, row.AnimatedPreviewBucket.String, row.AnimatedPreviewKey.String, vp.urlExpiry)
+			if err != nil {
+				return nil, err
+			}
+		}
+		history = append(history, entry)
+	}
+	return history, nil
+}
+
+// DeleteHistoryEntry forgets that the user watched a video, including where they stopped
+func (vp *videoProcessor) DeleteHistoryEntry(ctx context.Context, userID, videoID uuid.UUID) error {
+	err := vp.db.DeleteWatchHistoryEntry(ctx, db.DeleteWatchHistoryEntryParams{UserID: userID, VideoID: videoID})
+	if err != nil {
+		return models.IndentifyDbError(err).AddParams(fmt.Sprintf("userID: %v, videoID: %v", userID, videoID))
+	}
+	return nil
+}
+
+// ClearHistory forgets every video the user watched
+func (vp *videoProcessor) ClearHistory(ctx context.Context, userID uuid.UUID) error {
+	if err := vp.db.ClearWatchHistory(ctx, userID); err != nil {
+		return models.IndentifyDbError(err).AddParams(fmt.Sprintf("userID: %v", userID))
+	}
+	return nil
+}
+
+// watchPositionFromRow resumes finished videos from the start
+func watchPositionFromRow(videoID uuid.UUID, positionMs, durationMs int64, watchedAt time.Time) models.WatchPosition {
+	position := models.WatchPosition{
+		VideoID:    videoID,
+		PositionMs: positionMs,
+		DurationMs: durationMs,
+		Finished:   models.Finished(positionMs, durationMs),
+		WatchedAt:  watchedAt,
+	}
+	if position.Finished {
+		position.PositionMs = 0
+	}
+	return position
+}
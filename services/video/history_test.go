@@ -0,0 +1,96 @@
+package video
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"testing"
+	"time"
+	"video-processing/database/db"
+	"video-processing/mocks"
+	"video-processing/models"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestWatchPositionRequestValidate(t *testing.T) {
+	require.NoError(t, models.WatchPositionRequest{PositionMs: 1000, DurationMs: 60000}.Validate())
+	require.NoError(t, models.WatchPositionRequest{PositionMs: 1000}.Validate()) // duration unknown
+	require.Error(t, models.WatchPositionRequest{PositionMs: -1}.Validate())
+	require.Error(t, models.WatchPositionRequest{PositionMs: 61000, DurationMs: 60000}.Validate())
+}
+
+func TestSavePosition(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	repo := mocks.NewMockVideoRepo(ctrl)
+	vp := NewVideoProcessor(slog.New(slog.NewTextHandler(io.Discard, nil)), mocks.NewMockObjectStore(ctrl), repo, mocks.NewMockStreamer(ctrl), NewFakeTranscoder(), VideoProcessorOptions{URLExpiry: time.Hour})
+	userID, videoID := uuid.New(), uuid.New()
+	watchedAt := time.Now()
+	var e models.Error
+
+	// positions of videos of other users are not recorded
+	repo.EXPECT().GetVideo(gomock.Any(), videoID).Return(db.Video{ID: videoID, UserID: uuid.New()}, nil)
+	_, err := vp.SavePosition(context.Background(), userID, videoID, models.WatchPositionRequest{PositionMs: 1000})
+	require.ErrorAs(t, err, &e)
+	require.Equal(t, http.StatusNotFound, e.Code)
+
+	repo.EXPECT().GetVideo(gomock.Any(), videoID).Return(db.Video{ID: videoID, UserID: userID}, nil).Times(2)
+	repo.EXPECT().
+		SaveWatchPosition(gomock.Any(), db.SaveWatchPositionParams{UserID: userID, VideoID: videoID, PositionMs: 30000, DurationMs: 60000}).
+		Return(db.WatchHistory{UserID: userID, VideoID: videoID, PositionMs: 30000, DurationMs: 60000, WatchedAt: watchedAt}, nil)
+	position, err := vp.SavePosition(context.Background(), userID, videoID, models.WatchPositionRequest{PositionMs: 30000, DurationMs: 60000})
+	require.NoError(t, err)
+	require.Equal(t, models.WatchPosition{VideoID: videoID, PositionMs: 30000, DurationMs: 60000, WatchedAt: watchedAt}, position)
+
+	// finished videos resume from the start
+	repo.EXPECT().
+		SaveWatchPosition(gomock.Any(), gomock.Any()).
+		Return(db.WatchHistory{UserID: userID, VideoID: videoID, PositionMs: 59000, DurationMs: 60000, WatchedAt: watchedAt}, nil)
+	position, err = vp.SavePosition(context.Background(), userID, videoID, models.WatchPositionRequest{PositionMs: 59000, DurationMs: 60000})
+	require.NoError(t, err)
+	require.True(t, position.Finished)
+	require.Zero(t, position.PositionMs)
+}
+
+func TestGetPositionNeverWatched(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	repo := mocks.NewMockVideoRepo(ctrl)
+	vp := NewVideoProcessor(slog.New(slog.NewTextHandler(io.Discard, nil)), mocks.NewMockObjectStore(ctrl), repo, mocks.NewMockStreamer(ctrl), NewFakeTranscoder(), VideoProcessorOptions{URLExpiry: time.Hour})
+	userID, videoID := uuid.New(), uuid.New()
+
+	repo.EXPECT().GetVideo(gomock.Any(), videoID).Return(db.Video{ID: videoID, UserID: userID}, nil)
+	repo.EXPECT().GetWatchPosition(gomock.Any(), db.GetWatchPositionParams{UserID: userID, VideoID: videoID}).Return(db.WatchHistory{}, pgx.ErrNoRows)
+	position, err := vp.GetPosition(context.Background(), userID, videoID)
+	require.NoError(t, err)
+	require.Equal(t, models.WatchPosition{VideoID: videoID}, position)
+}
+
+func TestListHistory(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	repo := mocks.NewMockVideoRepo(ctrl)
+	vp := NewVideoProcessor(slog.New(slog.NewTextHandler(io.Discard, nil)), mocks.NewMockObjectStore(ctrl), repo, mocks.NewMockStreamer(ctrl), NewFakeTranscoder(), VideoProcessorOptions{URLExpiry: time.Hour})
+	userID := uuid.New()
+
+	_, err := vp.ListHistory(context.Background(), userID, models.ListVideosQuery{Limit: 500})
+	var e models.Error
+	require.ErrorAs(t, err, &e)
+	require.Equal(t, http.StatusBadRequest, e.Code)
+
+	first, second := uuid.New(), uuid.New()
+	repo.EXPECT().
+		ListWatchHistory(gomock.Any(), db.ListWatchHistoryParams{UserID: userID, Limit: 20}).
+		Return([]db.ListWatchHistoryRow{
+			{VideoID: first, Title: "recent", PositionMs: 5000, DurationMs: 10000},
+			{VideoID: second, Title: "older", PositionMs: 10000, DurationMs: 10000},
+		}, nil)
+	history, err := vp.ListHistory(context.Background(), userID, models.ListVideosQuery{Limit: 20})
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	require.Equal(t, "recent", history[0].Title)
+	require.Equal(t, int64(5000), history[0].PositionMs)
+	require.True(t, history[1].Finished)
+}
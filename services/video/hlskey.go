@@ -0,0 +1,193 @@
+package video
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"video-processing/database/db"
+	"video-processing/models"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// hlsKeyBytes is the length of the AES-128 keys HLS segments are encrypted with
+const hlsKeyBytes = 16
+
+// hlsKeyURI is the address of a video's key that the playlists point players at
+func hlsKeyURI(baseURL string, videoID uuid.UUID) string {
+	return fmt.Sprintf("%s/v1/videos/%s/key", strings.TrimSuffix(baseURL, "/"), videoID)
+}
+
+// playbackKeyURI is the key URI of a video with the playback token in its query, for players
+// that cannot send the token as a header
+func playbackKeyURI(baseURL string, videoID uuid.UUID, token string) string {
+	return hlsKeyURI(baseURL, videoID) + "?token=" + url.QueryEscape(token)
+}
+
+// hlsKeyArgs are the ffmpeg arguments encrypting HLS segments with the key of keyInfo, none
+// for clear segments
+func hlsKeyArgs(keyInfo string) []string {
+	if keyInfo == "" {
+		return nil
+	}
+	return []string{"-hls_key_info_file", keyInfo}
+}
+
+// writeHLSKeyInfo writes key and the key info file of ffmpeg's -hls_key_info_file into
+// workDir and returns the path of the key info file. No IV is given, so every segment uses
+// its media sequence number, as the HLS spec has players expect.
+func writeHLSKeyInfo(workDir, uri string, key []byte) (string, error) {
+	keyPath := filepath.Join(workDir, "hls.key")
+	if err := os.WriteFile(keyPath, key, 0o600); err != nil {
+		return "", fmt.Errorf("failed to write HLS key: %w", err)
+	}
+	infoPath := filepath.Join(workDir, "hls.keyinfo")
+	if err := os.WriteFile(infoPath, []byte(uri+"\n"+keyPath+"\n"), 0o600); err != nil {
+		return "", fmt.Errorf("failed to write HLS key info: %w", err)
+	}
+	return infoPath, nil
+}
+
+// newKeyWrapper returns the cipher the HLS keys are stored encrypted with, from the hex encoded
+// key encryption key; nil when none is configured
+func newKeyWrapper(kek string) (cipher.AEAD, error) {
+	if kek == "" {
+		return nil, nil
+	}
+	key, err := hex.DecodeString(kek)
+	if err != nil || len(key) != 32 {
+		return nil, fmt.Errorf("hls key encryption key must be 32 hex encoded bytes")
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// wrapHLSKey encrypts the key of a video for storage, as the nonce followed by the sealed key.
+// The video ID is authenticated with it, so the stored key does not open on another video's row.
+func wrapHLSKey(wrapper cipher.AEAD, videoID uuid.UUID, key []byte) ([]byte, error) {
+	if wrapper == nil {
+		return nil, errors.New("no key encryption key configured")
+	}
+	nonce := make([]byte, wrapper.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return wrapper.Seal(nonce, nonce, key, videoID[:]), nil
+}
+
+// unwrapHLSKey decrypts a stored key
+func unwrapHLSKey(wrapper cipher.AEAD, videoID uuid.UUID, stored []byte) ([]byte, error) {
+	if wrapper == nil {
+		return nil, errors.New("no key encryption key configured")
+	}
+	if len(stored) < wrapper.NonceSize() {
+		return nil, fmt.Errorf("stored HLS key of %d bytes is too short", len(stored))
+	}
+	nonce, sealed := stored[:wrapper.NonceSize()], stored[wrapper.NonceSize():]
+	key, err := wrapper.Open(nil, nonce, sealed, videoID[:])
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt HLS key: %w", err)
+	}
+	return key, nil
+}
+
+// hlsKeyInfo prepares the encryption of a video's segments: it creates the key of the video,
+// or loads the one an earlier run stored, and writes it into the job's work dir. The key
+// files sit outside the variant directories, so they are never uploaded with the segments.
+func (rc *redisConsumer) hlsKeyInfo(ctx context.Context, videoID, workDir string) (string, error) {
+	id, err := uuid.Parse(videoID)
+	if err != nil {
+		return "", fmt.Errorf("invalid video ID: %w", err)
+	}
+	key := make([]byte, hlsKeyBytes)
+	if _, err := rand.Read(key); err != nil {
+		return "", fmt.Errorf("failed to generate HLS key: %w", err)
+	}
+	wrapped, err := wrapHLSKey(rc.keyWrapper, id, key)
+	if err != nil {
+		return "", fmt.Errorf("failed to encrypt HLS key: %w", err)
+	}
+	stored, err := rc.db.CreateVideoKey(ctx, db.CreateVideoKeyParams{VideoID: id, Key: wrapped})
+	if err != nil {
+		return "", fmt.Errorf("failed to store HLS key: %w", err)
+	}
+	if key, err = unwrapHLSKey(rc.keyWrapper, id, stored); err != nil {
+		return "", err
+	}
+	return writeHLSKeyInfo(workDir, hlsKeyURI(rc.processing.HLSEncryption.KeyURL, id), key)
+}
+
+// GetVideoKey returns the AES-128 key the HLS segments of a video are encrypted with, to the
+// holder of a playback token of the video or, without one, to its owner
+func (vp *videoProcessor) GetVideoKey(ctx context.Context, userID, videoID uuid.UUID, token string) ([]byte, error) {
+	params := fmt.Sprintf("userID: %v, videoID: %v", userID, videoID)
+	switch {
+	case token != "":
+		if err := vp.verifyPlaybackToken(videoID, token); err != nil {
+			return nil, err
+		}
+		if _, err := vp.getPlayableVideo(ctx, videoID); err != nil {
+			return nil, err
+		}
+	case userID != uuid.Nil:
+		if _, err := vp.getOwnedVideo(ctx, userID, videoID); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, models.Error{
+			Code:        http.StatusUnauthorized,
+			Message:     "access denied",
+			Description: "playback token not found",
+			Params:      params,
+			Err:         errors.New("playback token not found"),
+		}
+	}
+	stored, err := vp.db.GetVideoKey(ctx, videoID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, models.Error{
+				Code:        http.StatusNotFound,
+				Message:     "resource not found",
+				Description: "the segments of the video are not encrypted",
+				Params:      params,
+				Err:         models.ErrResourceNotFound,
+			}
+		}
+		return nil, models.IndentifyDbError(err).AddParams(params)
+	}
+	key, err := unwrapHLSKey(vp.keyWrapper, videoID, stored)
+	if err != nil {
+		return nil, models.Error{
+			Code:    http.StatusInternalServerError,
+			Message: "internal server error",
+			Params:  params,
+			Err:     err,
+		}
+	}
+	return key, nil
+}
+
+// isEncrypted tells whether the segments of a video are encrypted, which is when it has a key
+func (vp *videoProcessor) isEncrypted(ctx context.Context, videoID uuid.UUID) (bool, error) {
+	_, err := vp.db.GetVideoKey(ctx, videoID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, models.IndentifyDbError(err).AddParams(fmt.Sprintf("videoID: %v", videoID))
+	}
+	return true, nil
+}
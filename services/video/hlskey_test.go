@@ -0,0 +1,127 @@
+package video
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"video-processing/database/db"
+	"video-processing/mocks"
+	"video-processing/models"
+	"video-processing/utils"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+// testKEK is a key encryption key for tests
+const testKEK = "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f"
+
+func TestHLSKeyInfo(t *testing.T) {
+	repo := mocks.NewMockVideoRepo(gomock.NewController(t))
+	wrapper, err := newKeyWrapper(testKEK)
+	require.NoError(t, err)
+	rc := &redisConsumer{logger: slog.New(slog.NewTextHandler(io.Discard, nil)), db: repo, keyWrapper: wrapper, processing: models.ProcessingConfig{
+		HLSEncryption: models.HLSEncryptionConfig{Enabled: true, KeyURL: "https://api.example.com/", KeyEncryptionKey: testKEK},
+	}}
+	videoID := uuid.New()
+	workDir := t.TempDir()
+
+	// the key is stored encrypted, and written into the work dir in plain
+	var stored []byte
+	repo.EXPECT().CreateVideoKey(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, arg db.CreateVideoKeyParams) ([]byte, error) {
+			require.Equal(t, videoID, arg.VideoID)
+			stored = arg.Key
+			return arg.Key, nil
+		})
+	info, err := rc.hlsKeyInfo(context.Background(), videoID.String(), workDir)
+	require.NoError(t, err)
+	content, err := os.ReadFile(info)
+	require.NoError(t, err)
+	keyPath := filepath.Join(workDir, "hls.key")
+	require.Equal(t, "https://api.example.com/v1/videos/"+videoID.String()+"/key\n"+keyPath+"\n", string(content))
+	key, err := os.ReadFile(keyPath)
+	require.NoError(t, err)
+	require.Len(t, key, hlsKeyBytes)
+	require.NotContains(t, string(stored), string(key))
+	unwrapped, err := unwrapHLSKey(wrapper, videoID, stored)
+	require.NoError(t, err)
+	require.Equal(t, key, unwrapped)
+	// the stored key does not open on another video
+	_, err = unwrapHLSKey(wrapper, uuid.New(), stored)
+	require.Error(t, err)
+
+	// a truncated key is refused rather than taken for a key
+	_, err = unwrapHLSKey(wrapper, videoID, stored[:hlsKeyBytes])
+	require.Error(t, err)
+
+	// reprocessed videos keep the key they were first encrypted with
+	repo.EXPECT().CreateVideoKey(gomock.Any(), gomock.Any()).Return(stored, nil)
+	_, err = rc.hlsKeyInfo(context.Background(), videoID.String(), workDir)
+	require.NoError(t, err)
+	reused, err := os.ReadFile(keyPath)
+	require.NoError(t, err)
+	require.Equal(t, key, reused)
+
+	// every packaging step encrypts its segments
+	fake := NewFakeTranscoder()
+	v := Variant{Name: "720p", Width: 1280, Height: 720, Bitrate: "2500k"}
+	require.NoError(t, generateHLS(context.Background(), fake, "720p.mp4", t.TempDir(), v, 0, hlsOptions{StreamCopy: true, KeyInfo: info}))
+	hevc := Variant{Name: "1080p-hevc", Width: 1920, Height: 1080, Codec: "hevc", Bitrate: "2500k"}
+	require.NoError(t, generateHLS(context.Background(), fake, "1080p-hevc.mp4", t.TempDir(), hevc, 0, hlsOptions{KeyInfo: info}))
+	require.NoError(t, generateAudioHLS(context.Background(), fake, "audio.m4a", t.TempDir(), 1, info))
+	for _, call := range fake.Calls() {
+		require.Contains(t, strings.Join(call, " "), "-hls_key_info_file "+info+" -hls_segment_filename")
+	}
+}
+
+func TestGetVideoKey(t *testing.T) {
+	vp, repo, _ := newPlaybackProcessor(t)
+	wrapper, err := newKeyWrapper(testKEK)
+	require.NoError(t, err)
+	vp.(*videoProcessor).keyWrapper = wrapper
+	owner, viewer := uuid.New(), uuid.New()
+	video := db.Video{ID: uuid.New(), UserID: owner, Visibility: models.VisibilityPublic,
+		PlaybackPasswordHash: pgtype.Text{String: "hash", Valid: true}}
+	repo.EXPECT().GetVideo(gomock.Any(), video.ID).Return(video, nil).AnyTimes()
+	plain := []byte("0123456789abcdef")
+	stored, err := wrapHLSKey(wrapper, video.ID, plain)
+	require.NoError(t, err)
+	repo.EXPECT().GetVideoKey(gomock.Any(), video.ID).Return(stored, nil).Times(2)
+
+	key, err := vp.GetVideoKey(context.Background(), owner, video.ID, "")
+	require.NoError(t, err)
+	require.Equal(t, plain, key)
+
+	// viewers of a protected video need the playback token its password unlocks, signed in or not
+	var apiErr models.Error
+	_, err = vp.GetVideoKey(context.Background(), viewer, video.ID, "")
+	require.ErrorAs(t, err, &apiErr)
+	require.Equal(t, http.StatusNotFound, apiErr.Code)
+	_, err = vp.GetVideoKey(context.Background(), uuid.Nil, video.ID, "")
+	require.ErrorAs(t, err, &apiErr)
+	require.Equal(t, http.StatusUnauthorized, apiErr.Code)
+	_, err = vp.GetVideoKey(context.Background(), uuid.Nil, video.ID, "forged")
+	require.ErrorAs(t, err, &apiErr)
+	require.Equal(t, http.StatusUnauthorized, apiErr.Code)
+	token, err := vp.(*videoProcessor).playbackTokens.CreateToken(utils.Payload{ID: video.ID, IssuedAt: time.Now(), Purpose: utils.PurposePlayback})
+	require.NoError(t, err)
+	key, err = vp.GetVideoKey(context.Background(), uuid.Nil, video.ID, token)
+	require.NoError(t, err)
+	require.Equal(t, plain, key)
+
+	// videos processed without encryption have no key
+	repo.EXPECT().GetVideoKey(gomock.Any(), video.ID).Return(nil, pgx.ErrNoRows)
+	_, err = vp.GetVideoKey(context.Background(), owner, video.ID, "")
+	require.ErrorAs(t, err, &apiErr)
+	require.Equal(t, http.StatusNotFound, apiErr.Code)
+}
@@ -0,0 +1,195 @@
+package video
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/exec"
+	"slices"
+	"sync"
+	"time"
+	"video-processing/database/db"
+	"video-processing/models"
+)
+
+// HookPoint is a place in the processing pipeline where custom steps can run
+type HookPoint string
+
+const (
+	// HookAfterDownload runs once the source is available to ffmpeg, before anything is encoded
+	HookAfterDownload HookPoint = "after_download"
+	// HookAfterVariant runs when a variant is encoded and packaged, before its files are uploaded
+	HookAfterVariant HookPoint = "after_variant"
+	// HookBeforeMetadataSave runs before the metadata row of a variant is written
+	HookBeforeMetadataSave HookPoint = "before_metadata_save"
+	// HookAfterCompletion runs when all variants are processed and uploaded
+	HookAfterCompletion HookPoint = "after_completion"
+)
+
+var hookPoints = []HookPoint{HookAfterDownload, HookAfterVariant, HookBeforeMetadataSave, HookAfterCompletion}
+
+// defaultHookTimeout bounds external hooks that configure no timeout
+const defaultHookTimeout = 30 * time.Second
+
+// HookEvent describes the job at a hook point. External hooks receive it as JSON.
+type HookEvent struct {
+	Point      HookPoint `json:"point"`
+	VideoID    string    `json:"video_id"`
+	Bucket     string    `json:"bucket"`
+	SourceKey  string    `json:"source_key,omitempty"`
+	SourcePath string    `json:"source_path,omitempty"` // local path or URL ffmpeg reads the source from
+	WorkDir    string    `json:"work_dir,omitempty"`
+	DestPrefix string    `json:"dest_prefix,omitempty"`
+	Variant    string    `json:"variant,omitempty"`
+	// Files are the local files of the variant that are about to be uploaded
+	Files []UploadTask `json:"files,omitempty"`
+	// Metadata is the variant row about to be saved; Go hooks may change it
+	Metadata *db.SaveProcessedVideoMetadataParams `json:"metadata,omitempty"`
+}
+
+// Hook is a custom pipeline step. An error fails what the hook point guards: the job after
+// download and completion, the variant otherwise.
+type Hook func(ctx context.Context, event HookEvent) error
+
+type namedHook struct {
+	name     string
+	point    HookPoint
+	run      Hook
+	required bool // errors of optional hooks are only logged
+}
+
+var (
+	registryMu sync.Mutex
+	registry   []namedHook
+)
+
+// RegisterHook adds a Go hook to every consumer created afterwards. Call it from an init
+// function of the package that provides the hook. Errors of Go hooks always fail the step.
+func RegisterHook(point HookPoint, name string, hook Hook) {
+	if !slices.Contains(hookPoints, point) {
+		panic(fmt.Sprintf("video: unknown hook point %q for hook %s", point, name))
+	}
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry = append(registry, namedHook{name: name, point: point, run: hook, required: true})
+}
+
+// hookRunner runs the hooks of a consumer
+type hookRunner struct {
+	logger *slog.Logger
+	hooks  []namedHook
+}
+
+// newHookRunner combines the registered Go hooks with the external hooks from the configuration.
+// Invalid hook configurations are logged and skipped.
+func newHookRunner(logger *slog.Logger, configs []models.HookConfig) *hookRunner {
+	registryMu.Lock()
+	hooks := slices.Clone(registry)
+	registryMu.Unlock()
+
+	for i, c := range configs {
+		hook, err := externalHook(c)
+		if err != nil {
+			logger.Error("hook disabled", "error", err, "index", i, "point", c.Point)
+			continue
+		}
+		hooks = append(hooks, hook)
+	}
+	return &hookRunner{logger: logger, hooks: hooks}
+}
+
+// run calls the hooks of the event's point in order and stops at the first failing required hook
+func (h *hookRunner) run(ctx context.Context, event HookEvent) error {
+	if h == nil {
+		return nil
+	}
+	for _, hook := range h.hooks {
+		if hook.point != event.Point {
+			continue
+		}
+		err := hook.run(ctx, event)
+		if err == nil {
+			continue
+		}
+		if hook.required {
+			return fmt.Errorf("hook %s at %s: %w", hook.name, event.Point, err)
+		}
+		h.logger.Warn("optional hook failed", "error", err, "hook", hook.name, "point", event.Point, "videoID", event.VideoID)
+	}
+	return nil
+}
+
+// externalHook turns a configured command or webhook into a hook
+func externalHook(c models.HookConfig) (namedHook, error) {
+	point := HookPoint(c.Point)
+	if !slices.Contains(hookPoints, point) {
+		return namedHook{}, fmt.Errorf("unknown hook point %q", c.Point)
+	}
+	timeout := c.Timeout
+	if timeout <= 0 {
+		timeout = defaultHookTimeout
+	}
+	hook := namedHook{point: point, required: c.Required}
+	switch {
+	case len(c.Command) > 0 && c.URL != "":
+		return namedHook{}, errors.New("a hook runs either a command or a webhook, not both")
+	case len(c.Command) > 0:
+		hook.name = c.Command[0]
+		hook.run = commandHook(c.Command, timeout)
+	case c.URL != "":
+		hook.name = c.URL
+		hook.run = webhook(c.URL, timeout)
+	default:
+		return namedHook{}, errors.New("hook has neither a command nor a url")
+	}
+	return hook, nil
+}
+
+// commandHook runs a command with the event as JSON on stdin. A non-zero exit status fails the hook.
+func commandHook(command []string, timeout time.Duration) Hook {
+	return func(ctx context.Context, event HookEvent) error {
+		payload, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		cmd := exec.CommandContext(ctx, command[0], command[1:]...)
+		cmd.Stdin = bytes.NewReader(payload)
+		cmd.Env = append(os.Environ(), "VIDEO_HOOK_POINT="+string(event.Point), "VIDEO_ID="+event.VideoID)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("%w, output: %s", err, string(out))
+		}
+		return nil
+	}
+}
+
+// webhook posts the event as JSON to url. Any status other than 2xx fails the hook.
+func webhook(url string, timeout time.Duration) Hook {
+	client := &http.Client{Timeout: timeout}
+	return func(ctx context.Context, event HookEvent) error {
+		payload, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return fmt.Errorf("webhook answered %s", resp.Status)
+		}
+		return nil
+	}
+}
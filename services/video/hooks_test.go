@@ -0,0 +1,99 @@
+package video
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"video-processing/database/db"
+	"video-processing/mocks"
+	"video-processing/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestHookRunnerExternalHooks(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	out := filepath.Join(t.TempDir(), "event.json")
+
+	var posted HookEvent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&posted))
+		if posted.Variant == "144p" {
+			w.WriteHeader(http.StatusForbidden)
+		}
+	}))
+	defer server.Close()
+
+	runner := newHookRunner(logger, []models.HookConfig{
+		{Point: string(HookAfterDownload), Command: []string{"sh", "-c", `cat > "$0"`, out}, Required: true},
+		{Point: string(HookAfterVariant), URL: server.URL, Required: true},
+		{Point: "before_everything", URL: server.URL},                    // unknown point, skipped
+		{Point: string(HookAfterCompletion)},                             // nothing to run, skipped
+		{Point: string(HookAfterCompletion), Command: []string{"false"}}, // optional, only logged
+	})
+	require.Len(t, runner.hooks, 3)
+
+	ctx := context.Background()
+	require.NoError(t, runner.run(ctx, HookEvent{Point: HookAfterDownload, VideoID: "v1", SourcePath: "/tmp/src.mp4"}))
+	data, err := os.ReadFile(out)
+	require.NoError(t, err)
+	var got HookEvent
+	require.NoError(t, json.Unmarshal(data, &got))
+	require.Equal(t, "v1", got.VideoID)
+	require.Equal(t, "/tmp/src.mp4", got.SourcePath)
+
+	require.NoError(t, runner.run(ctx, HookEvent{Point: HookAfterVariant, VideoID: "v1", Variant: "720p"}))
+	require.Equal(t, "720p", posted.Variant)
+	require.ErrorContains(t, runner.run(ctx, HookEvent{Point: HookAfterVariant, VideoID: "v1", Variant: "144p"}), "403")
+
+	require.NoError(t, runner.run(ctx, HookEvent{Point: HookAfterCompletion, VideoID: "v1"}))
+}
+
+func TestBeforeMetadataSaveHook(t *testing.T) {
+	videoID := uuid.New()
+	repo := mocks.NewMockVideoRepo(gomock.NewController(t))
+	rc := &redisConsumer{
+		logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+		db:     repo,
+		hooks: &hookRunner{hooks: []namedHook{{
+			name:     "tag",
+			point:    HookBeforeMetadataSave,
+			required: true,
+			run: func(ctx context.Context, event HookEvent) error {
+				if event.Variant == "144p" {
+					return errors.New("rejected")
+				}
+				event.Metadata.ContentType = "video/mp4; codecs=avc1"
+				return nil
+			},
+		}}},
+	}
+
+	repo.EXPECT().
+		SaveProcessedVideoMetadata(gomock.Any(), db.SaveProcessedVideoMetadataParams{VideoID: videoID, VariantName: "720p", ContentType: "video/mp4; codecs=avc1"}).
+		Return(db.VideoVariant{}, nil)
+	rc.saveVariantMetadata(context.Background(), ProcessingResult{
+		Variant:  Variant{Name: "720p"},
+		Success:  true,
+		Metadata: db.SaveProcessedVideoMetadataParams{VideoID: videoID, VariantName: "720p", ContentType: "video/mp4"},
+	})
+	// a rejected variant is not saved; the mock fails on an unexpected call
+	rc.saveVariantMetadata(context.Background(), ProcessingResult{
+		Variant:  Variant{Name: "144p"},
+		Success:  true,
+		Metadata: db.SaveProcessedVideoMetadataParams{VideoID: videoID, VariantName: "144p"},
+	})
+}
+
+func TestRegisterHookRejectsUnknownPoint(t *testing.T) {
+	require.Panics(t, func() { RegisterHook("whenever", "noop", func(context.Context, HookEvent) error { return nil }) })
+}
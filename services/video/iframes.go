@@ -0,0 +1,150 @@
+package video
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const iframePlaylistName = "iframe.m3u8"
+
+// iframeEntry is one keyframe of an MPEG-TS segment, addressed by byte range
+type iframeEntry struct {
+	Segment  string  // segment file name
+	Time     float64 // presentation time in seconds
+	Duration float64 // until the next keyframe or the end of the video
+	Offset   int64
+	Length   int64
+}
+
+// probePacket is a packet entry as reported by ffprobe -show_packets
+type probePacket struct {
+	PtsTime      string `json:"pts_time"`
+	DurationTime string `json:"duration_time"`
+	Pos          string `json:"pos"`
+	Flags        string `json:"flags"`
+}
+
+// segmentKeyframes lists the keyframes of a TS segment. A keyframe's byte range runs up to
+// the next video packet, so it covers the whole frame including interleaved audio packets.
+// end is the time the last video packet of the segment ends.
+func segmentKeyframes(segment string, packets []probePacket, size int64) (frames []iframeEntry, end float64) {
+	for i, p := range packets {
+		pts, err := strconv.ParseFloat(p.PtsTime, 64)
+		if err != nil {
+			continue
+		}
+		if d, err := strconv.ParseFloat(p.DurationTime, 64); err == nil {
+			end = math.Max(end, pts+d)
+		}
+		if !strings.Contains(p.Flags, "K") {
+			continue
+		}
+		pos, err := strconv.ParseInt(p.Pos, 10, 64)
+		if err != nil {
+			continue
+		}
+		next := size
+		if i+1 < len(packets) {
+			if n, err := strconv.ParseInt(packets[i+1].Pos, 10, 64); err == nil {
+				next = n
+			}
+		}
+		frames = append(frames, iframeEntry{Segment: segment, Time: pts, Offset: pos, Length: next - pos})
+	}
+	return frames, end
+}
+
+// buildIFramePlaylist renders an I-frame only media playlist and returns it together with its
+// peak bitrate in bits per second, which the master playlist must announce.
+func buildIFramePlaylist(frames []iframeEntry, end float64) (string, int64) {
+	var peak, maxDuration float64
+	for i := range frames {
+		if i+1 < len(frames) {
+			frames[i].Duration = frames[i+1].Time - frames[i].Time
+		} else {
+			frames[i].Duration = end - frames[i].Time
+		}
+		if frames[i].Duration > 0 {
+			peak = math.Max(peak, float64(frames[i].Length*8)/frames[i].Duration)
+		}
+		maxDuration = math.Max(maxDuration, frames[i].Duration)
+	}
+
+	var b strings.Builder
+	b.WriteString("#EXTM3U\n#EXT-X-VERSION:4\n")
+	// segment durations rounded to the nearest integer must not exceed the target duration
+	fmt.Fprintf(&b, "#EXT-X-TARGETDURATION:%d\n", int(math.Round(maxDuration)))
+	b.WriteString("#EXT-X-PLAYLIST-TYPE:VOD\n#EXT-X-I-FRAMES-ONLY\n")
+	for _, f := range frames {
+		fmt.Fprintf(&b, "#EXTINF:%.6f,\n#EXT-X-BYTERANGE:%d@%d\n%s\n", f.Duration, f.Length, f.Offset, f.Segment)
+	}
+	b.WriteString("#EXT-X-ENDLIST\n")
+	return b.String(), int64(math.Ceil(peak))
+}
+
+// generateIFramePlaylist writes iframe.m3u8 next to the TS segments in hlsDir so players can
+// scrub through keyframes only. It returns the peak bitrate of the I-frame stream.
+func generateIFramePlaylist(ctx context.Context, t Transcoder, hlsDir string) (int64, error) {
+	segments, err := filepath.Glob(filepath.Join(hlsDir, "segment_*.ts"))
+	if err != nil {
+		return 0, err
+	}
+	if len(segments) == 0 {
+		return 0, fmt.Errorf("no TS segments in %s", hlsDir)
+	}
+	sort.Strings(segments)
+
+	var frames []iframeEntry
+	var end float64
+	for _, segment := range segments {
+		info, err := os.Stat(segment)
+		if err != nil {
+			return 0, err
+		}
+		packets, err := probeVideoPackets(ctx, t, segment)
+		if err != nil {
+			return 0, err
+		}
+		segFrames, segEnd := segmentKeyframes(filepath.Base(segment), packets, info.Size())
+		frames = append(frames, segFrames...)
+		end = math.Max(end, segEnd)
+	}
+	if len(frames) == 0 {
+		return 0, fmt.Errorf("no keyframes found in %s", hlsDir)
+	}
+
+	playlist, bandwidth := buildIFramePlaylist(frames, end)
+	if err := os.WriteFile(filepath.Join(hlsDir, iframePlaylistName), []byte(playlist), 0o644); err != nil {
+		return 0, fmt.Errorf("failed to write I-frame playlist: %w", err)
+	}
+	return bandwidth, nil
+}
+
+// probeVideoPackets lists the video packets of a media file in decode order
+func probeVideoPackets(ctx context.Context, t Transcoder, path string) ([]probePacket, error) {
+	args := []string{
+		"-v", "error",
+		"-select_streams", "v:0",
+		"-show_entries", "packet=pts_time,duration_time,pos,flags",
+		"-print_format", "json",
+		path,
+	}
+	out, err := t.Probe(ctx, args...)
+	if err != nil {
+		return nil, fmt.Errorf("ffprobe packets error: %w", err)
+	}
+	var result struct {
+		Packets []probePacket `json:"packets"`
+	}
+	if err := json.Unmarshal(out, &result); err != nil {
+		return nil, fmt.Errorf("failed to decode ffprobe packets: %w", err)
+	}
+	return result.Packets, nil
+}
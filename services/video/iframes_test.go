@@ -0,0 +1,42 @@
+package video
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIFramePlaylist(t *testing.T) {
+	packets := []probePacket{
+		{PtsTime: "1.400000", DurationTime: "0.040000", Pos: "564", Flags: "K__"},
+		{PtsTime: "1.440000", DurationTime: "0.040000", Pos: "40044", Flags: "___"},
+		{PtsTime: "3.400000", DurationTime: "0.040000", Pos: "80840", Flags: "K__"},
+		{PtsTime: "3.440000", DurationTime: "0.040000", Pos: "120320", Flags: "___"},
+		{PtsTime: "5.360000", DurationTime: "0.040000", Pos: "150400", Flags: "___"},
+	}
+	frames, end := segmentKeyframes("segment_000.ts", packets, 160000)
+	require.InDelta(t, 5.4, end, 1e-9)
+	require.Equal(t, []iframeEntry{
+		{Segment: "segment_000.ts", Time: 1.4, Offset: 564, Length: 39480},
+		{Segment: "segment_000.ts", Time: 3.4, Offset: 80840, Length: 39480},
+	}, frames)
+
+	playlist, bandwidth := buildIFramePlaylist(frames, end)
+	require.Equal(t, "#EXTM3U\n#EXT-X-VERSION:4\n#EXT-X-TARGETDURATION:2\n#EXT-X-PLAYLIST-TYPE:VOD\n#EXT-X-I-FRAMES-ONLY\n"+
+		"#EXTINF:2.000000,\n#EXT-X-BYTERANGE:39480@564\nsegment_000.ts\n"+
+		"#EXTINF:2.000000,\n#EXT-X-BYTERANGE:39480@80840\nsegment_000.ts\n"+
+		"#EXT-X-ENDLIST\n", playlist)
+	require.Equal(t, int64(157920), bandwidth)
+}
+
+func TestMasterPlaylist(t *testing.T) {
+	results := []ProcessingResult{
+		{Variant: testLadder.vertical[0], IFrameBandwidth: 310000},
+		{Variant: testLadder.vertical[1]},
+	}
+	want := "#EXTM3U\n#EXT-X-VERSION:4\n#EXT-X-INDEPENDENT-SEGMENTS\n" +
+		"#EXT-X-STREAM-INF:BANDWIDTH=4628000,RESOLUTION=1080x1920\n1080p-vertical/index.m3u8\n" +
+		"#EXT-X-STREAM-INF:BANDWIDTH=2628000,RESOLUTION=720x1280\n720p-vertical/index.m3u8\n" +
+		"#EXT-X-I-FRAME-STREAM-INF:BANDWIDTH=310000,RESOLUTION=1080x1920,URI=\"1080p-vertical/iframe.m3u8\"\n"
+	require.Equal(t, want, masterPlaylist(results))
+}
@@ -0,0 +1,222 @@
+package video
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+	"video-processing/database/db"
+	"video-processing/models"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/redis/go-redis/v9"
+)
+
+// processedPrefix is where the worker stores the outputs of a job in the job's bucket
+const processedPrefix = "processed/"
+
+// Ingest queues processing jobs for the video objects a bucket notification reports as
+// created in the ingest bucket. authToken must match the configured ingest token.
+// Objects already known, outputs of the worker and non-video objects are skipped.
+func (vp *videoProcessor) Ingest(ctx context.Context, authToken string, event models.S3Event) (models.IngestResult, error) {
+	result := models.IngestResult{Queued: []uuid.UUID{}}
+	if vp.ingest.Bucket == "" || vp.ingest.Token == "" {
+		return result, models.Error{
+			Code:    http.StatusNotFound,
+			Message: "ingest disabled",
+			Err:     errors.New("no ingest bucket or token configured"),
+		}
+	}
+	token := strings.TrimSpace(strings.TrimPrefix(authToken, "Bearer "))
+	if subtle.ConstantTimeCompare([]byte(token), []byte(vp.ingest.Token)) != 1 {
+		return result, models.Error{
+			Code:    http.StatusUnauthorized,
+			Message: "invalid ingest token",
+			Err:     errors.New("ingest token mismatch"),
+		}
+	}
+	return vp.ingestEvent(ctx, event)
+}
+
+func (vp *videoProcessor) ingestEvent(ctx context.Context, event models.S3Event) (models.IngestResult, error) {
+	result := models.IngestResult{Queued: []uuid.UUID{}}
+	for _, record := range event.Records {
+		id, err := vp.ingestRecord(ctx, record)
+		if err != nil {
+			return result, err
+		}
+		if id == uuid.Nil {
+			result.Skipped++
+			continue
+		}
+		result.Queued = append(result.Queued, id)
+	}
+	return result, nil
+}
+
+// ingestRecord creates and queues a video for a created object, or returns uuid.Nil when
+// the record is not for a new video
+func (vp *videoProcessor) ingestRecord(ctx context.Context, record models.S3EventRecord) (uuid.UUID, error) {
+	bucket := record.S3.Bucket.Name
+	key, err := url.QueryUnescape(record.S3.Object.Key)
+	if err != nil {
+		key = record.S3.Object.Key
+	}
+	paramsInString := fmt.Sprintf("bucket: %v, key: %v, event: %v", bucket, key, record.EventName)
+
+	if !strings.Contains(record.EventName, "ObjectCreated:") || bucket != vp.ingest.Bucket ||
+		!strings.HasPrefix(key, vp.ingest.Prefix) || strings.HasPrefix(key, processedPrefix) {
+		return uuid.Nil, nil
+	}
+	contentType := record.S3.Object.ContentType
+	if !strings.HasPrefix(contentType, "video/") {
+		contentType = mime.TypeByExtension(path.Ext(key))
+	}
+	if !strings.HasPrefix(contentType, "video/") {
+		vp.logger.Info("skipping ingested object that is not a video", "bucket", bucket, "key", key, "contentType", record.S3.Object.ContentType)
+		return uuid.Nil, nil
+	}
+
+	// notifications are delivered at least once, and the worker's own derived renders land here too
+	_, err = vp.db.GetVideoByObject(ctx, db.GetVideoByObjectParams{Bucket: bucket, Key: key})
+	if err == nil {
+		return uuid.Nil, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return uuid.Nil, models.Error{
+			Code:        http.StatusInternalServerError,
+			Message:     "internal server error",
+			Description: "failed to look up ingested object",
+			Params:      paramsInString,
+			Err:         err,
+		}
+	}
+
+	owner, err := vp.ingestOwner(key)
+	if err != nil {
+		return uuid.Nil, models.Error{
+			Code:        http.StatusUnprocessableEntity,
+			Message:     "no owner for ingested object",
+			Description: "the key does not start with a user ID and no ingest owner is configured",
+			Params:      paramsInString,
+			Err:         err,
+		}
+	}
+
+	title := strings.TrimSuffix(path.Base(key), path.Ext(key))
+	created, err := vp.db.CreateVideo(ctx, db.CreateVideoParams{
+		UserID:        owner,
+		Title:         title,
+		Description:   fmt.Sprintf("Ingested from s3://%s/%s", bucket, key),
+		Bucket:        bucket,
+		Key:           key,
+		FileSizeBytes: record.S3.Object.Size,
+		ContentType:   contentType,
+	})
+	if err != nil {
+		return uuid.Nil, models.Error{
+			Code:        http.StatusInternalServerError,
+			Message:     "internal server error",
+			Description: "failed to save ingested video to database",
+			Params:      paramsInString,
+			Err:         fmt.Errorf("failed to save ingested video to database: %w", err),
+		}
+	}
+	recordStorage(ctx, vp.logger, vp.db, owner)
+	err = vp.streamer.Stream(ctx, map[string]interface{}{
+		"bucket":   bucket,
+		"key":      key,
+		"video_id": created.ID.String(),
+		"user_id":  owner.String(),
+	})
+	if err != nil {
+		return uuid.Nil, models.Error{
+			Code:        http.StatusInternalServerError,
+			Message:     "internal server error",
+			Description: "failed to stream event to redis for video processing",
+			Params:      paramsInString,
+			Err:         fmt.Errorf("failed to stream event to redis for video processing: %w", err),
+		}
+	}
+	vp.logger.Info("ingested video", "bucket", bucket, "key", key, "videoID", created.ID, "owner", owner)
+	return created.ID, nil
+}
+
+// ingestOwner is the user ID the key starts with (below the ingest prefix), or the configured owner
+func (vp *videoProcessor) ingestOwner(key string) (uuid.UUID, error) {
+	first, _, found := strings.Cut(strings.TrimPrefix(key, vp.ingest.Prefix), "/")
+	if found {
+		if id, err := uuid.Parse(first); err == nil {
+			return id, nil
+		}
+	}
+	return uuid.Parse(vp.ingest.OwnerID)
+}
+
+// ListenIngest processes the bucket notifications a MinIO Redis target pushes to key until
+// ctx is done. Entries that fail are logged and dropped.
+func (vp *videoProcessor) ListenIngest(ctx context.Context, queue *redis.Client, key string) error {
+	for {
+		entry, err := queue.BLPop(ctx, 5*time.Second, key).Result()
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if !errors.Is(err, redis.Nil) {
+				vp.logger.Error("failed to read ingest queue", "error", err, "key", key)
+				time.Sleep(time.Second)
+			}
+			continue
+		}
+		// BLPop answers with the key and the value
+		events, err := parseQueuedEvents([]byte(entry[1]))
+		if err != nil {
+			vp.logger.Error("dropping malformed ingest event", "error", err, "key", key)
+			continue
+		}
+		for _, event := range events {
+			if _, err := vp.ingestEvent(ctx, event); err != nil {
+				vp.logger.Error("failed to ingest object", "error", err, "key", key)
+			}
+		}
+	}
+}
+
+// parseQueuedEvents decodes a Redis target entry. In access format MinIO pushes a list of
+// {"Event": [records], "EventTime": ...} objects; a plain notification is accepted as well.
+func parseQueuedEvents(data []byte) ([]models.S3Event, error) {
+	type accessEntry struct {
+		Event []models.S3EventRecord `json:"Event"`
+	}
+	trimmed := strings.TrimSpace(string(data))
+	if strings.HasPrefix(trimmed, "[") {
+		var entries []accessEntry
+		if err := json.Unmarshal(data, &entries); err != nil {
+			return nil, err
+		}
+		events := make([]models.S3Event, 0, len(entries))
+		for _, e := range entries {
+			events = append(events, models.S3Event{Records: e.Event})
+		}
+		return events, nil
+	}
+	var event models.S3Event
+	if err := json.Unmarshal(data, &event); err != nil {
+		return nil, err
+	}
+	if len(event.Records) == 0 {
+		var entry accessEntry
+		if err := json.Unmarshal(data, &entry); err == nil {
+			event.Records = entry.Event
+		}
+	}
+	return []models.S3Event{event}, nil
+}
@@ -0,0 +1,99 @@
+package video
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"testing"
+	"time"
+	"video-processing/database/db"
+	"video-processing/mocks"
+	"video-processing/models"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+const ingestEvent = `{
+	"EventName": "s3:ObjectCreated:Put",
+	"Key": "ingest/drop/%s",
+	"Records": [
+		{"eventName": "s3:ObjectCreated:Put", "s3": {"bucket": {"name": "ingest"}, "object": {"key": "drop/%s/My+Trip.mp4", "size": 1024, "contentType": "video/mp4"}}},
+		{"eventName": "s3:ObjectCreated:Put", "s3": {"bucket": {"name": "ingest"}, "object": {"key": "drop/notes.txt", "size": 10, "contentType": "text/plain"}}},
+		{"eventName": "s3:ObjectCreated:Put", "s3": {"bucket": {"name": "ingest"}, "object": {"key": "drop/known.mov", "size": 10}}},
+		{"eventName": "s3:ObjectCreated:Put", "s3": {"bucket": {"name": "ingest"}, "object": {"key": "processed/abc/720p/out.mp4", "size": 10, "contentType": "video/mp4"}}},
+		{"eventName": "s3:ObjectRemoved:Delete", "s3": {"bucket": {"name": "ingest"}, "object": {"key": "drop/gone.mp4"}}},
+		{"eventName": "s3:ObjectCreated:Put", "s3": {"bucket": {"name": "other"}, "object": {"key": "drop/x.mp4", "contentType": "video/mp4"}}}
+	]
+}`
+
+func TestIngest(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	repo := mocks.NewMockVideoRepo(ctrl)
+	streamer := mocks.NewMockStreamer(ctrl)
+	config := models.IngestConfig{Bucket: "ingest", Prefix: "drop/", Token: "secret"}
+	vp := NewVideoProcessor(slog.New(slog.NewTextHandler(io.Discard, nil)), mocks.NewMockObjectStore(ctrl), repo, streamer, NewFakeTranscoder(), VideoProcessorOptions{URLExpiry: time.Hour, Ingest: config})
+
+	owner, videoID := uuid.New(), uuid.New()
+	var event models.S3Event
+	require.NoError(t, json.Unmarshal([]byte(fmtEvent(owner)), &event))
+
+	var e models.Error
+	_, err := vp.Ingest(context.Background(), "Bearer wrong", event)
+	require.ErrorAs(t, err, &e)
+	require.Equal(t, http.StatusUnauthorized, e.Code)
+
+	key := "drop/" + owner.String() + "/My Trip.mp4"
+	repo.EXPECT().GetVideoByObject(gomock.Any(), db.GetVideoByObjectParams{Bucket: "ingest", Key: key}).Return(db.Video{}, pgx.ErrNoRows)
+	repo.EXPECT().GetVideoByObject(gomock.Any(), db.GetVideoByObjectParams{Bucket: "ingest", Key: "drop/known.mov"}).Return(db.Video{ID: uuid.New()}, nil)
+	repo.EXPECT().CreateVideo(gomock.Any(), db.CreateVideoParams{
+		UserID:        owner,
+		Title:         "My Trip",
+		Description:   "Ingested from s3://ingest/" + key,
+		Bucket:        "ingest",
+		Key:           key,
+		FileSizeBytes: 1024,
+		ContentType:   "video/mp4",
+	}).Return(db.Video{ID: videoID}, nil)
+	repo.EXPECT().RecordStorageHeld(gomock.Any(), owner)
+	streamer.EXPECT().Stream(gomock.Any(), map[string]interface{}{"bucket": "ingest", "key": key, "video_id": videoID.String(), "user_id": owner.String()}).Return(nil)
+
+	result, err := vp.Ingest(context.Background(), "Bearer secret", event)
+	require.NoError(t, err)
+	require.Equal(t, []uuid.UUID{videoID}, result.Queued)
+	require.Equal(t, 5, result.Skipped)
+}
+
+func TestIngestDisabled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	vp := NewVideoProcessor(slog.New(slog.NewTextHandler(io.Discard, nil)), mocks.NewMockObjectStore(ctrl), mocks.NewMockVideoRepo(ctrl), mocks.NewMockStreamer(ctrl), NewFakeTranscoder(), VideoProcessorOptions{URLExpiry: time.Hour})
+	_, err := vp.Ingest(context.Background(), "", models.S3Event{})
+	var e models.Error
+	require.ErrorAs(t, err, &e)
+	require.Equal(t, http.StatusNotFound, e.Code)
+}
+
+func TestParseQueuedEvents(t *testing.T) {
+	access := `[{"Event": [{"eventName": "s3:ObjectCreated:Put", "s3": {"bucket": {"name": "ingest"}, "object": {"key": "a.mp4"}}}], "EventTime": "2024-01-01T00:00:00Z"}]`
+	events, err := parseQueuedEvents([]byte(access))
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	require.Equal(t, "a.mp4", events[0].Records[0].S3.Object.Key)
+
+	events, err = parseQueuedEvents([]byte(fmtEvent(uuid.New())))
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	require.Len(t, events[0].Records, 6)
+
+	_, err = parseQueuedEvents([]byte("not json"))
+	require.Error(t, err)
+}
+
+func fmtEvent(owner uuid.UUID) string {
+	return fmt.Sprintf(ingestEvent, owner, owner)
+}
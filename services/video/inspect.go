@@ -0,0 +1,201 @@
+package video
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"video-processing/database/db"
+	"video-processing/models"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// probeURLExpiry bounds how long ffprobe may read the source of a fresh analysis
+const probeURLExpiry = 10 * time.Minute
+
+// ProbeVideo returns the ffprobe analysis of a video's source. The analysis the worker stored
+// is returned unless refresh is set or there is none, e.g. for videos processed before it was
+// stored; the source is then probed again and the result stored. userID uuid.Nil skips the
+// ownership check, for admins.
+func (vp *videoProcessor) ProbeVideo(ctx context.Context, userID, videoID uuid.UUID, refresh bool) (models.ProbeReport, error) {
+	params := fmt.Sprintf("videoID: %v", videoID)
+	var video db.Video
+	var err error
+	if userID == uuid.Nil {
+		video, err = vp.db.GetVideo(ctx, videoID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.ProbeReport{}, models.Error{
+				Code:    http.StatusNotFound,
+				Message: "resource not found",
+				Params:  params,
+				Err:     models.ErrResourceNotFound,
+			}
+		}
+		if err != nil {
+			return models.ProbeReport{}, models.IndentifyDbError(err).AddParams(params)
+		}
+	} else if video, err = vp.getOwnedVideo(ctx, userID, videoID); err != nil {
+		return models.ProbeReport{}, err
+	}
+
+	if !refresh {
+		stored, err := vp.db.GetVideoProbe(ctx, videoID)
+		switch {
+		case err == nil:
+			var probe ProbeResult
+			if err := json.Unmarshal(stored.Probe, &probe); err != nil {
+				return models.ProbeReport{}, models.IndentifyDbError(err).AddParams(params)
+			}
+			return probeReport(videoID, stored.ProbedAt, probe), nil
+		case !errors.Is(err, pgx.ErrNoRows):
+			return models.ProbeReport{}, models.IndentifyDbError(err).AddParams(params)
+		}
+	}
+
+	url, err := vp.minioClient.PresignedGetObject(ctx, video.Bucket, video.Key, probeURLExpiry, nil)
+	if err != nil {
+		return models.ProbeReport{}, models.Error{
+			Code:        http.StatusInternalServerError,
+			Message:     "internal server error",
+			Description: "failed to presign the source",
+			Params:      params,
+			Err:         err,
+		}
+	}
+	probe, err := probeSource(ctx, vp.transcoder, url.String())
+	if err != nil {
+		return models.ProbeReport{}, models.Error{
+			Code:        http.StatusUnprocessableEntity,
+			Message:     "probe failed",
+			Description: "ffprobe could not read the source",
+			Params:      params,
+			Err:         err,
+		}
+	}
+	if raw, err := json.Marshal(probe); err == nil {
+		if err := vp.db.SaveVideoProbe(ctx, db.SaveVideoProbeParams{VideoID: videoID, Probe: raw}); err != nil {
+			vp.logger.Warn("failed to save probe", "error", err, "videoID", videoID)
+		}
+	}
+	return probeReport(videoID, time.Now(), probe), nil
+}
+
+// saveProbe keeps the analysis of the source for the probe endpoint
+func (rc *redisConsumer) saveProbe(ctx context.Context, videoID uuid.UUID, probe ProbeResult) {
+	raw, err := json.Marshal(probe)
+	if err == nil {
+		err = rc.db.SaveVideoProbe(ctx, db.SaveVideoProbeParams{VideoID: videoID, Probe: raw})
+	}
+	if err != nil {
+		rc.logger.Error("failed to save probe", "error", err, "videoID", videoID)
+	}
+}
+
+// saveSourceMetadata stores the technical metadata of the source on the video, for the video APIs
+func (rc *redisConsumer) saveSourceMetadata(ctx context.Context, videoID uuid.UUID, probe ProbeResult) {
+	arg := db.UpdateVideoSourceMetadataParams{
+		Container: pgtype.Text{String: probe.Format.FormatName, Valid: probe.Format.FormatName != ""},
+		ID:        videoID,
+	}
+	if d := probe.Duration(); d > 0 {
+		arg.DurationMs = pgtype.Int8{Int64: int64(math.Round(d * 1000)), Valid: true}
+	}
+	if stream, ok := probe.VideoStream(); ok {
+		arg.SourceCodec = pgtype.Text{String: stream.CodecName, Valid: stream.CodecName != ""}
+		if fps := frameRate(stream.RFrameRate); fps > 0 {
+			arg.SourceFps = pgtype.Float8{Float64: fps, Valid: true}
+		}
+	}
+	for _, s := range probe.Streams {
+		if s.CodecType == "audio" {
+			arg.AudioChannels = pgtype.Int4{Int32: int32(s.Channels), Valid: s.Channels > 0}
+			break
+		}
+	}
+	if err := rc.db.UpdateVideoSourceMetadata(ctx, arg); err != nil {
+		rc.logger.Error("failed to save source metadata", "error", err, "videoID", videoID)
+	}
+}
+
+func probeReport(videoID uuid.UUID, probedAt time.Time, probe ProbeResult) models.ProbeReport {
+	report := models.ProbeReport{
+		VideoID:  videoID,
+		ProbedAt: probedAt,
+		Format: models.ProbeReportFormat{
+			Name:            probe.Format.FormatName,
+			DurationSeconds: probe.Duration(),
+			BitrateKbps:     kbps(probe.Format.BitRate),
+		},
+		Streams: make([]models.ProbeReportStream, 0, len(probe.Streams)),
+	}
+	report.Format.SizeBytes, _ = strconv.ParseInt(probe.Format.Size, 10, 64)
+	for _, s := range probe.Streams {
+		stream := models.ProbeReportStream{
+			Index:         s.Index,
+			Type:          s.CodecType,
+			Codec:         s.CodecName,
+			Profile:       s.Profile,
+			BitrateKbps:   kbps(s.BitRate),
+			Channels:      s.Channels,
+			ChannelLayout: s.ChannelLayout,
+			Language:      s.Tags["language"],
+			AttachedPic:   s.Disposition["attached_pic"] == 1,
+		}
+		stream.SampleRate, _ = strconv.Atoi(s.SampleRate)
+		if s.CodecType == "video" {
+			stream.Width = s.Width
+			stream.Height = s.Height
+			stream.PixelFormat = s.PixFmt
+			stream.FrameRate = frameRate(s.RFrameRate)
+			stream.ColorRange = signalled(s.ColorRange)
+			stream.ColorSpace = signalled(s.ColorSpace)
+			stream.ColorTransfer = signalled(s.ColorTransfer)
+			stream.ColorPrimaries = signalled(s.ColorPrimaries)
+			stream.HDRFormat = s.HDRFormat()
+		}
+		report.Streams = append(report.Streams, stream)
+	}
+	return report
+}
+
+// kbps converts ffprobe's bits per second, 0 when unknown
+func kbps(bitsPerSecond string) int64 {
+	bps, err := strconv.ParseInt(bitsPerSecond, 10, 64)
+	if err != nil {
+		return 0
+	}
+	return bps / 1000
+}
+
+// frameRate evaluates ffprobe's fractional frame rates like "30000/1001", 0 when unknown
+func frameRate(fraction string) float64 {
+	num, den, ok := strings.Cut(fraction, "/")
+	n, err := strconv.ParseFloat(num, 64)
+	if err != nil {
+		return 0
+	}
+	if !ok {
+		return n
+	}
+	d, err := strconv.ParseFloat(den, 64)
+	if err != nil || d == 0 {
+		return 0
+	}
+	return n / d
+}
+
+// signalled drops the placeholder ffprobe reports for color properties the stream leaves out
+func signalled(value string) string {
+	if value == "unknown" {
+		return ""
+	}
+	return value
+}
@@ -0,0 +1,145 @@
+package video
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+	"video-processing/database/db"
+	"video-processing/mocks"
+	"video-processing/models"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+const sampleProbe = `{
+	"format": {"format_name": "mov,mp4,m4a,3gp,3g2,mj2", "duration": "12.500000", "bit_rate": "4512000", "size": "7050000"},
+	"streams": [
+		{"index": 0, "codec_type": "video", "codec_name": "hevc", "profile": "Main 10", "width": 3840, "height": 2160,
+		 "pix_fmt": "yuv420p10le", "r_frame_rate": "30000/1001", "bit_rate": "4380000", "color_range": "tv",
+		 "color_space": "bt2020nc", "color_transfer": "smpte2084", "color_primaries": "bt2020"},
+		{"index": 1, "codec_type": "audio", "codec_name": "aac", "profile": "LC", "bit_rate": "128000",
+		 "sample_rate": "48000", "channels": 2, "channel_layout": "stereo", "tags": {"language": "eng"}},
+		{"index": 2, "codec_type": "video", "codec_name": "mjpeg", "width": 600, "height": 600,
+		 "color_space": "unknown", "disposition": {"attached_pic": 1}}
+	]
+}`
+
+func TestProbeReport(t *testing.T) {
+	fake := NewFakeTranscoder()
+	fake.ProbeOutput = []byte(sampleProbe)
+	probe, err := probeSource(context.Background(), fake, "source.mp4")
+	require.NoError(t, err)
+
+	videoID := uuid.New()
+	report := probeReport(videoID, time.Unix(0, 0), probe)
+	require.Equal(t, models.ProbeReportFormat{
+		Name:            "mov,mp4,m4a,3gp,3g2,mj2",
+		DurationSeconds: 12.5,
+		BitrateKbps:     4512,
+		SizeBytes:       7050000,
+	}, report.Format)
+	require.Len(t, report.Streams, 3)
+
+	video := report.Streams[0]
+	require.Equal(t, "hevc", video.Codec)
+	require.Equal(t, "Main 10", video.Profile)
+	require.InDelta(t, 29.97, video.FrameRate, 0.01)
+	require.Equal(t, HDRFormatHDR10, video.HDRFormat)
+	require.Equal(t, "tv", video.ColorRange)
+
+	audio := report.Streams[1]
+	require.Equal(t, models.ProbeReportStream{
+		Index: 1, Type: "audio", Codec: "aac", Profile: "LC", BitrateKbps: 128,
+		SampleRate: 48000, Channels: 2, ChannelLayout: "stereo", Language: "eng",
+	}, audio)
+
+	cover := report.Streams[2]
+	require.True(t, cover.AttachedPic)
+	require.Empty(t, cover.ColorSpace)
+}
+
+func TestSaveSourceMetadata(t *testing.T) {
+	fake := NewFakeTranscoder()
+	fake.ProbeOutput = []byte(sampleProbe)
+	probe, err := probeSource(context.Background(), fake, "source.mp4")
+	require.NoError(t, err)
+	repo := mocks.NewMockVideoRepo(gomock.NewController(t))
+	rc := &redisConsumer{logger: slog.New(slog.NewTextHandler(io.Discard, nil)), db: repo}
+	videoID := uuid.New()
+
+	repo.EXPECT().UpdateVideoSourceMetadata(gomock.Any(), db.UpdateVideoSourceMetadataParams{
+		DurationMs:    pgtype.Int8{Int64: 12500, Valid: true},
+		SourceCodec:   pgtype.Text{String: "hevc", Valid: true},
+		SourceFps:     pgtype.Float8{Float64: 30000.0 / 1001, Valid: true},
+		AudioChannels: pgtype.Int4{Int32: 2, Valid: true},
+		Container:     pgtype.Text{String: "mov,mp4,m4a,3gp,3g2,mj2", Valid: true},
+		ID:            videoID,
+	})
+	rc.saveSourceMetadata(context.Background(), videoID, probe)
+
+	// what the probe lacks stays NULL
+	repo.EXPECT().UpdateVideoSourceMetadata(gomock.Any(), db.UpdateVideoSourceMetadataParams{ID: videoID})
+	rc.saveSourceMetadata(context.Background(), videoID, ProbeResult{})
+
+	detail := convertDbVideoToVideoDetail(db.Video{
+		DurationMs:    pgtype.Int8{Int64: 12500, Valid: true},
+		SourceCodec:   pgtype.Text{String: "hevc", Valid: true},
+		AudioChannels: pgtype.Int4{Int32: 2, Valid: true},
+	})
+	require.Equal(t, 12.5, detail.DurationSeconds)
+	require.Equal(t, "hevc", detail.SourceCodec)
+	require.Equal(t, int32(2), detail.AudioChannels)
+}
+
+func TestProbeVideo(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	repo := mocks.NewMockVideoRepo(ctrl)
+	store := mocks.NewMockObjectStore(ctrl)
+	fake := NewFakeTranscoder()
+	fake.ProbeOutput = []byte(sampleProbe)
+	vp := &videoProcessor{logger: slog.New(slog.NewTextHandler(io.Discard, nil)), db: repo, minioClient: store, transcoder: fake}
+	ctx := context.Background()
+
+	owner := uuid.New()
+	video := db.Video{ID: uuid.New(), UserID: owner, Bucket: owner.String(), Key: "clip.mov"}
+	repo.EXPECT().GetVideo(gomock.Any(), video.ID).Return(video, nil).AnyTimes()
+
+	// the stored analysis is returned as it is
+	probedAt := time.Now().Add(-time.Hour)
+	repo.EXPECT().GetVideoProbe(gomock.Any(), video.ID).Return(db.VideoProbe{VideoID: video.ID, Probe: []byte(sampleProbe), ProbedAt: probedAt}, nil)
+	report, err := vp.ProbeVideo(ctx, owner, video.ID, false)
+	require.NoError(t, err)
+	require.Equal(t, probedAt, report.ProbedAt)
+	require.Len(t, fake.Calls(), 0)
+
+	// admins refresh it from the source of any video
+	sourceURL, _ := url.Parse("http://minio:9000/source")
+	store.EXPECT().PresignedGetObject(gomock.Any(), video.Bucket, video.Key, probeURLExpiry, nil).Return(sourceURL, nil)
+	repo.EXPECT().SaveVideoProbe(gomock.Any(), gomock.Any()).Return(nil)
+	report, err = vp.ProbeVideo(ctx, uuid.Nil, video.ID, true)
+	require.NoError(t, err)
+	require.Len(t, report.Streams, 3)
+	require.Len(t, fake.Calls(), 1)
+	require.Contains(t, fake.Calls()[0], sourceURL.String())
+
+	// other users do not see it
+	var apiErr models.Error
+	_, err = vp.ProbeVideo(ctx, uuid.New(), video.ID, false)
+	require.True(t, errors.As(err, &apiErr))
+	require.Equal(t, http.StatusNotFound, apiErr.Code)
+
+	missing := uuid.New()
+	repo.EXPECT().GetVideo(gomock.Any(), missing).Return(db.Video{}, pgx.ErrNoRows)
+	_, err = vp.ProbeVideo(ctx, uuid.Nil, missing, false)
+	require.True(t, errors.As(err, &apiErr))
+	require.Equal(t, http.StatusNotFound, apiErr.Code)
+}
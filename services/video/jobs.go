@@ -0,0 +1,48 @@
+package video
+
+import (
+	"context"
+	"fmt"
+)
+
+// Job types carried in the "type" field of stream messages.
+// Messages without a type are treated as processing jobs.
+const (
+	JobTypeProcess   = "process"
+	JobTypeEdit      = "edit"
+	JobTypeOverlay   = "overlay"
+	JobTypeAudiogram = "audiogram"
+	JobTypeClip      = "clip"
+	JobTypeSlideshow = "slideshow"
+	JobTypeSpeed     = "speed"
+)
+
+// handleJob dispatches a stream message to the handler for its job type
+func (rc *redisConsumer) handleJob(ctx context.Context, values map[string]interface{}) error {
+	if rc.processing.DryRun {
+		return rc.dryRun(ctx, values)
+	}
+	jobType, _ := values["type"].(string)
+	switch jobType {
+	case "", JobTypeProcess:
+		if err := rc.ProcessVideo(ctx, values); err != nil {
+			return err
+		}
+		rc.notifyProcessed(ctx, values)
+		return nil
+	case JobTypeEdit:
+		return rc.RenderEdit(ctx, values)
+	case JobTypeOverlay:
+		return rc.RenderOverlay(ctx, values)
+	case JobTypeAudiogram:
+		return rc.RenderAudiogram(ctx, values)
+	case JobTypeClip:
+		return rc.RenderClip(ctx, values)
+	case JobTypeSlideshow:
+		return rc.RenderSlideshow(ctx, values)
+	case JobTypeSpeed:
+		return rc.RenderSpeed(ctx, values)
+	default:
+		return fmt.Errorf("unknown job type %q", jobType)
+	}
+}
@@ -0,0 +1,127 @@
+package video
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// variantMP4Path is where the MP4 of the task's variant is written, in the variant directory
+func variantMP4Path(task ProcessingTask) string {
+	return filepath.Join(task.WorkDir, task.Variant.Name, task.Variant.Name+".mp4")
+}
+
+// ladderArgs encode the variants of tasks into mp4Paths, in task order, in one ffmpeg run
+// that decodes the source once. The frames are deinterlaced, cropped and their rate changed
+// once, then split into a branch per variant with its own filters and encoder. The SDR variants
+// of an HDR source share a single tone mapping. Watermarks are left out, see encodeLadder.
+func ladderArgs(tasks []ProcessingTask, mp4Paths []string) []string {
+	first := tasks[0]
+	var args []string
+	for _, task := range tasks {
+		// a hardware backend opens its device once for all outputs
+		if in := task.encoder().inputArgs(); len(in) > 0 {
+			args = in
+			break
+		}
+	}
+	args = append(args, "-y", "-nostdin", "-i", first.SourcePath)
+
+	labels := func(indexes []int) string {
+		var b strings.Builder
+		for _, i := range indexes {
+			fmt.Fprintf(&b, "[in%d]", i)
+		}
+		return b.String()
+	}
+	var direct, toneMapped []int
+	for i, task := range tasks {
+		if task.HDRFormat != "" && !task.Variant.HDR {
+			toneMapped = append(toneMapped, i)
+		} else {
+			direct = append(direct, i)
+		}
+	}
+	trunk := fmt.Sprintf("split=%d", len(direct))
+	outputs := labels(direct)
+	var graph []string
+	if len(toneMapped) > 0 {
+		trunk = fmt.Sprintf("split=%d", len(direct)+1)
+		outputs += "[sdr]"
+		graph = append(graph, fmt.Sprintf("[sdr]%s,split=%d%s", toneMapFilter, len(toneMapped), labels(toneMapped)))
+	}
+	graph = append([]string{"[0:V:0]" + deinterlaceChain(frameRateChain(cropChain(trunk, first), first), first) + outputs}, graph...)
+	for i, task := range tasks {
+		branch := task
+		branch.Deinterlace, branch.FrameRate, branch.Crop = false, "", nil
+		if task.HDRFormat != "" && !task.Variant.HDR {
+			branch.HDRFormat = ""
+		}
+		graph = append(graph, fmt.Sprintf("[in%d]%s[v%d]", i, variantFilter(branch), i))
+	}
+	args = append(args, "-filter_complex", strings.Join(graph, ";"))
+
+	for i, task := range tasks {
+		args = append(args, "-map", fmt.Sprintf("[v%d]", i))
+		if len(task.AudioLanguages) > 1 {
+			args = append(args, "-map", "0:a")
+		} else {
+			args = append(args, "-map", "0:a:0?")
+		}
+		args = append(args, encoderThreadArgs(task.Threads)...)
+		args = append(args, variantCodecArgs(task)...)
+		args = append(args, audioArgs(task.Variant.AudioChannels)...)
+		args = append(args, variantMuxArgs(task)...)
+		args = append(args, mp4Paths[i])
+	}
+	return args
+}
+
+// encodeLadder encodes the variants of tasks that can share a decode of the source in a single
+// ffmpeg run, and marks them Transcoded. Remuxed and chunked variants are left to
+// processVariant, as are watermarked ones since the watermark graph brings a source of its
+// own. When the run fails every variant is encoded on its own, which also retries hardware
+// encodes on libx264.
+func (rc *redisConsumer) encodeLadder(ctx context.Context, tasks []ProcessingTask) {
+	var shared []int
+	for i, task := range tasks {
+		if !task.Remux && task.Watermark == nil && len(task.Chunks) <= 1 {
+			shared = append(shared, i)
+		}
+	}
+	if len(shared) < 2 {
+		return
+	}
+	batch := make([]ProcessingTask, len(shared))
+	paths := make([]string, len(shared))
+	names := make([]string, len(shared))
+	for j, i := range shared {
+		batch[j], paths[j], names[j] = tasks[i], variantMP4Path(tasks[i]), tasks[i].Variant.Name
+		if err := os.MkdirAll(filepath.Dir(paths[j]), 0o755); err != nil {
+			rc.logger.Warn("failed to create variant directory, encoding the variants one by one", "error", err, "videoID", tasks[i].VideoID)
+			return
+		}
+	}
+	videoID := batch[0].VideoID
+	rc.logger.Info("encoding variants in a single pass", "videoID", videoID, "variants", names)
+	// every output of the run is as far along as the decode
+	var report func(seconds float64)
+	if batch[0].Progress != nil {
+		report = func(seconds float64) {
+			for _, task := range batch {
+				if task.Progress != nil {
+					task.Progress(seconds)
+				}
+			}
+		}
+	}
+	if err := runWithProgress(ctx, rc.transcoder, report, ladderArgs(batch, paths)); err != nil {
+		rc.logger.Warn("single pass encode failed, encoding the variants one by one", "error", err, "videoID", videoID)
+		return
+	}
+	for _, i := range shared {
+		tasks[i].Transcoded = true
+	}
+}
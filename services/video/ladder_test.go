@@ -0,0 +1,67 @@
+package video
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLadderArgs(t *testing.T) {
+	sd := Variant{Name: "480p", Width: 854, Height: 480, Bitrate: "1000k"}
+	hd := Variant{Name: "720p", Width: 1280, Height: 720, Bitrate: "2500k"}
+	tasks := []ProcessingTask{
+		{Variant: sd, SourcePath: "source.mp4", Threads: 2},
+		{Variant: hd, SourcePath: "source.mp4", Threads: 2},
+	}
+	require.Equal(t, "-y -nostdin -i source.mp4 "+
+		"-filter_complex [0:V:0]split=2[in0][in1];[in0]scale=854:480[v0];[in1]scale=1280:720[v1] "+
+		"-map [v0] -map 0:a:0? -threads 2 -c:v libx264 -b:v 1000k -preset fast -pix_fmt yuv420p -force_key_frames expr:gte(t,n_forced*6) -sc_threshold 0 -c:a aac -ac 2 -ar 44100 480p.mp4 "+
+		"-map [v1] -map 0:a:0? -threads 2 -c:v libx264 -b:v 2500k -preset fast -pix_fmt yuv420p -force_key_frames expr:gte(t,n_forced*6) -sc_threshold 0 -c:a aac -ac 2 -ar 44100 720p.mp4",
+		strings.Join(ladderArgs(tasks, []string{"480p.mp4", "720p.mp4"}), " "))
+
+	// deinterlacing and the frame rate run once, the SDR variants share the tone mapping
+	hdr := Variant{Name: "hdr", Width: 1920, Height: 1080, Bitrate: "6000k", HDR: true}
+	tasks = []ProcessingTask{
+		{Variant: sd, SourcePath: "source.mkv", HDRFormat: HDRFormatHLG, Deinterlace: true, FrameRate: "30"},
+		{Variant: hdr, SourcePath: "source.mkv", HDRFormat: HDRFormatHLG, Deinterlace: true, FrameRate: "30"},
+		{Variant: hd, SourcePath: "source.mkv", HDRFormat: HDRFormatHLG, Deinterlace: true, FrameRate: "30"},
+	}
+	args := strings.Join(ladderArgs(tasks, []string{"480p.mp4", "hdr.mp4", "720p.mp4"}), " ")
+	require.Contains(t, args, "-filter_complex [0:V:0]yadif,fps=30,split=2[in1][sdr];"+
+		"[sdr]"+toneMapFilter+",split=2[in0][in2];"+
+		"[in0]scale=854:480[v0];[in1]scale=1920:1080,format=yuv420p10le[v1];[in2]scale=1280:720[v2] ")
+	require.Contains(t, args, "-preset fast -color_primaries bt709 -color_trc bt709 -colorspace bt709")
+	require.Contains(t, args, "-pix_fmt yuv420p10le -color_primaries bt2020 -color_trc arib-std-b67")
+}
+
+func TestEncodeLadder(t *testing.T) {
+	fake := NewFakeTranscoder()
+	rc := &redisConsumer{logger: slog.New(slog.NewTextHandler(io.Discard, nil)), transcoder: fake}
+	workDir := t.TempDir()
+	tasks := []ProcessingTask{
+		{Variant: Variant{Name: "360p", Width: 640, Height: 360, Bitrate: "600k"}, WorkDir: workDir, SourcePath: "source.mp4", Remux: true},
+		{Variant: Variant{Name: "480p", Width: 854, Height: 480, Bitrate: "1000k"}, WorkDir: workDir, SourcePath: "source.mp4"},
+		{Variant: Variant{Name: "720p", Width: 1280, Height: 720, Bitrate: "2500k"}, WorkDir: workDir, SourcePath: "source.mp4"},
+	}
+	rc.encodeLadder(context.Background(), tasks)
+	require.Len(t, fake.Calls(), 1)
+	require.Equal(t, []bool{false, true, true}, []bool{tasks[0].Transcoded, tasks[1].Transcoded, tasks[2].Transcoded})
+	require.Contains(t, fake.Calls()[0], variantMP4Path(tasks[1]))
+
+	// a single variant gains nothing
+	tasks[2].Watermark = &watermark{Path: "watermark.png"}
+	tasks[1].Transcoded, tasks[2].Transcoded = false, false
+	rc.encodeLadder(context.Background(), tasks)
+	require.Len(t, fake.Calls(), 1)
+
+	// a failed run leaves every variant to its own encode
+	tasks[2].Watermark = nil
+	fake.FailOn = "filter_complex"
+	rc.encodeLadder(context.Background(), tasks)
+	require.False(t, tasks[1].Transcoded)
+	require.False(t, tasks[2].Transcoded)
+}
@@ -0,0 +1,335 @@
+package video
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"video-processing/models"
+
+	"github.com/google/uuid"
+	"github.com/minio/minio-go/v7"
+)
+
+const (
+	// defaultPartDuration is the part target of low-latency playlists when the configuration sets none
+	defaultPartDuration = time.Second
+	// defaultPartsPerSegment is how many parts make a segment when the configuration sets none
+	defaultPartsPerSegment = 4
+	// liveEdgeSegments is how many full segments before the live edge keep their parts listed;
+	// players joining later start from whole segments
+	liveEdgeSegments = 3
+	// livePartsPlaylist is the playlist ffmpeg lists the finished parts in. Its EVENT playlist
+	// knows nothing of parts, so it is only read, never uploaded.
+	livePartsPlaylist = "parts.m3u8"
+)
+
+// livePart is a partial segment ffmpeg finished
+type livePart struct {
+	URI      string
+	Duration float64
+}
+
+// liveArgs encode the task's variant into fMP4 parts of partDuration in dir. Every part
+// starts with a keyframe, so each can be played on its own; ffmpeg appends a part to
+// parts.m3u8 once it is written.
+func liveArgs(task ProcessingTask, dir string, partDuration time.Duration) []string {
+	seconds := strconv.FormatFloat(partDuration.Seconds(), 'f', -1, 64)
+	args := []string{
+		"-y",
+		"-nostdin",
+	}
+	args = append(args, filterThreadArgs(task.Threads)...)
+	args = append(args, "-i", task.SourcePath)
+	args = append(args, encoderThreadArgs(task.Threads)...)
+	args = append(args,
+		"-map", "0:v:0",
+		"-map", "0:a:0?",
+		"-vf", variantFilter(task),
+	)
+	args = append(args, x264Encoder{}.codecArgs(task.Variant)...)
+	args = append(args, "-force_key_frames", "expr:gte(t,n_forced*"+seconds+")", "-sc_threshold", "0")
+	args = append(args, audioArgs(task.Variant.AudioChannels)...)
+	args = append(args,
+		"-f", "hls",
+		"-hls_time", seconds,
+		"-hls_list_size", "0",
+		"-hls_playlist_type", "event",
+		"-hls_segment_type", "fmp4",
+		"-hls_fmp4_init_filename", "init.mp4",
+		"-hls_segment_filename", filepath.Join(dir, "part_%05d.m4s"),
+		filepath.Join(dir, livePartsPlaylist),
+	)
+	return args
+}
+
+// parseLiveParts returns the parts an ffmpeg media playlist lists, in order
+func parseLiveParts(r io.Reader) ([]livePart, error) {
+	var parts []livePart
+	duration := -1.0
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case strings.HasPrefix(line, "#EXTINF:"):
+			value, _, _ := strings.Cut(strings.TrimPrefix(line, "#EXTINF:"), ",")
+			d, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid part duration %q: %w", value, err)
+			}
+			duration = d
+		case line == "" || strings.HasPrefix(line, "#"):
+		case duration >= 0:
+			parts = append(parts, livePart{URI: line, Duration: duration})
+			duration = -1
+		}
+	}
+	return parts, scanner.Err()
+}
+
+// liveSegmentName is the file the parts of the segment at index are joined into
+func liveSegmentName(index int) string {
+	return fmt.Sprintf("segment_%05d.m4s", index)
+}
+
+// liveMediaPlaylist is the low-latency HLS media playlist of parts, grouped perSegment into
+// full segments. The parts of the segments near the live edge, and those of the segment still
+// being encoded, are listed as EXT-X-PART, and the next part is hinted so players can request
+// it ahead. A final playlist lists whole segments only and ends the stream.
+func liveMediaPlaylist(parts []livePart, perSegment int, partTarget float64, final bool) string {
+	segments := len(parts) / perSegment
+	if final && len(parts)%perSegment > 0 {
+		segments++
+	}
+	target := math.Ceil(partTarget * float64(perSegment))
+	durations := make([]float64, segments)
+	for i := range durations {
+		for _, part := range parts[i*perSegment : min((i+1)*perSegment, len(parts))] {
+			durations[i] += part.Duration
+		}
+		target = max(target, math.Round(durations[i]))
+	}
+
+	var b strings.Builder
+	b.WriteString("#EXTM3U\n#EXT-X-VERSION:6\n")
+	fmt.Fprintf(&b, "#EXT-X-TARGETDURATION:%d\n", int(target))
+	b.WriteString("#EXT-X-PLAYLIST-TYPE:EVENT\n#EXT-X-INDEPENDENT-SEGMENTS\n")
+	// players hold back three part targets from the live edge, the least the spec allows
+	fmt.Fprintf(&b, "#EXT-X-SERVER-CONTROL:PART-HOLD-BACK=%.3f\n", 3*partTarget)
+	fmt.Fprintf(&b, "#EXT-X-PART-INF:PART-TARGET=%.3f\n", partTarget)
+	b.WriteString("#EXT-X-MAP:URI=\"init.mp4\"\n")
+	writeParts := func(parts []livePart) {
+		for _, part := range parts {
+			fmt.Fprintf(&b, "#EXT-X-PART:DURATION=%.3f,URI=\"%s\",INDEPENDENT=YES\n", part.Duration, part.URI)
+		}
+	}
+	for i, duration := range durations {
+		if !final && i >= segments-liveEdgeSegments {
+			writeParts(parts[i*perSegment : (i+1)*perSegment])
+		}
+		fmt.Fprintf(&b, "#EXTINF:%.6f,\n%s\n", duration, liveSegmentName(i))
+	}
+	if final {
+		b.WriteString("#EXT-X-ENDLIST\n")
+		return b.String()
+	}
+	writeParts(parts[segments*perSegment:])
+	fmt.Fprintf(&b, "#EXT-X-PRELOAD-HINT:TYPE=PART,URI=\"part_%05d.m4s\"\n", len(parts))
+	return b.String()
+}
+
+// livePublisher uploads the parts ffmpeg finishes, joins them into segments and uploads the
+// playlist after them, so every file a playlist names is stored before players read it
+type livePublisher struct {
+	rc         *redisConsumer
+	dir        string
+	destPrefix string
+	bucket     string
+	partTarget float64
+	perSegment int
+	parts      []livePart
+	segments   int // segments joined and uploaded
+}
+
+// publish uploads what ffmpeg finished since the last call and the playlist listing it.
+// The final call, once ffmpeg exited, also joins the last, shorter segment and ends the playlist.
+func (p *livePublisher) publish(ctx context.Context, final bool) error {
+	listing, err := os.Open(filepath.Join(p.dir, livePartsPlaylist))
+	if errors.Is(err, os.ErrNotExist) && !final {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open parts playlist: %w", err)
+	}
+	parts, err := parseLiveParts(listing)
+	listing.Close()
+	if err != nil {
+		return err
+	}
+	if len(parts) == len(p.parts) && !final {
+		return nil
+	}
+	if len(p.parts) == 0 && len(parts) > 0 {
+		if err := p.upload(ctx, "init.mp4"); err != nil {
+			return err
+		}
+	}
+	for _, part := range parts[len(p.parts):] {
+		if err := p.upload(ctx, part.URI); err != nil {
+			return err
+		}
+	}
+	p.parts = parts
+
+	segments := len(parts) / p.perSegment
+	if final && len(parts)%p.perSegment > 0 {
+		segments++
+	}
+	for ; p.segments < segments; p.segments++ {
+		if err := p.joinSegment(p.segments); err != nil {
+			return err
+		}
+		if err := p.upload(ctx, liveSegmentName(p.segments)); err != nil {
+			return err
+		}
+	}
+
+	playlist := liveMediaPlaylist(parts, p.perSegment, p.partTarget, final)
+	if err := os.WriteFile(filepath.Join(p.dir, "index.m3u8"), []byte(playlist), 0o644); err != nil {
+		return fmt.Errorf("failed to write live playlist: %w", err)
+	}
+	return p.upload(ctx, "index.m3u8")
+}
+
+// joinSegment concatenates the parts of the segment at index. fMP4 parts are fragments of
+// the same init segment, so the joined fragments make a valid segment.
+func (p *livePublisher) joinSegment(index int) error {
+	out, err := os.Create(filepath.Join(p.dir, liveSegmentName(index)))
+	if err != nil {
+		return fmt.Errorf("failed to create live segment: %w", err)
+	}
+	defer out.Close()
+	for _, part := range p.parts[index*p.perSegment : min((index+1)*p.perSegment, len(p.parts))] {
+		in, err := os.Open(filepath.Join(p.dir, part.URI))
+		if err != nil {
+			return fmt.Errorf("failed to open live part: %w", err)
+		}
+		_, err = io.Copy(out, in)
+		in.Close()
+		if err != nil {
+			return fmt.Errorf("failed to join live segment: %w", err)
+		}
+	}
+	return out.Close()
+}
+
+// upload stores a file of dir right away, instead of queueing it, since the playlist
+// naming it must not be stored first
+func (p *livePublisher) upload(ctx context.Context, name string) error {
+	key := filepath.ToSlash(filepath.Join(p.destPrefix, name))
+	_, err := p.rc.mc.FPutObject(ctx, p.bucket, key, filepath.Join(p.dir, name), minio.PutObjectOptions{
+		ContentType: mimeTypeByExt(filepath.Ext(name)),
+	})
+	if err != nil {
+		return fmt.Errorf("FPutObject %s: %w", key, err)
+	}
+	return nil
+}
+
+// publishLive encodes the task's variant as low-latency HLS under live/ of the results and
+// publishes it while it encodes. Once the first parts are stored, a master playlist of its
+// own is stored as the live_playlist asset and the video is marked playable. The final
+// master playlist of the ladder replaces it as the video's playlist when the job completes.
+func (rc *redisConsumer) publishLive(ctx context.Context, task ProcessingTask, playlistTask ProcessingTask) {
+	cfg := rc.processing.LowLatency
+	partDuration := cfg.PartDuration
+	if partDuration <= 0 {
+		partDuration = defaultPartDuration
+	}
+	perSegment := cfg.PartsPerSegment
+	if perSegment <= 0 {
+		perSegment = defaultPartsPerSegment
+	}
+	videoUUID, err := uuid.Parse(task.VideoID)
+	if err != nil {
+		rc.logger.Error("invalid video ID for live playlist", "error", err, "videoID", task.VideoID)
+		return
+	}
+	// parts are cut at forced keyframes, which libx264 places exactly
+	task.Encoder = EncoderX264
+	liveDir := filepath.Join(task.WorkDir, "live")
+	p := &livePublisher{
+		rc:         rc,
+		dir:        filepath.Join(liveDir, task.Variant.Name),
+		destPrefix: filepath.ToSlash(filepath.Join(task.DestPrefix, "live", task.Variant.Name)),
+		bucket:     task.Bucket,
+		partTarget: partDuration.Seconds(),
+		perSegment: perSegment,
+	}
+	if err := os.MkdirAll(p.dir, 0o755); err != nil {
+		rc.logger.Error("failed to create live directory", "error", err, "videoID", task.VideoID)
+		return
+	}
+	rc.logger.Info("publishing the proxy variant while it encodes", "variant", task.Variant.Name, "videoID", task.VideoID)
+
+	encoded := make(chan error, 1)
+	go func() {
+		encoded <- rc.transcoder.Run(ctx, liveArgs(task, p.dir, partDuration)...)
+	}()
+	announced := false
+	announce := func() {
+		if announced || len(p.parts) == 0 {
+			return
+		}
+		announced = true
+		path := filepath.Join(liveDir, "master.m3u8")
+		if err := os.WriteFile(path, []byte(masterPlaylist([]ProcessingResult{{Variant: task.Variant}})), 0o644); err != nil {
+			rc.logger.Error("failed to write live master playlist", "error", err, "videoID", task.VideoID)
+			return
+		}
+		file := UploadTask{
+			SourcePath:  path,
+			ObjectKey:   filepath.ToSlash(filepath.Join(playlistTask.DestPrefix, "live", "master.m3u8")),
+			ContentType: mimeTypeByExt(".m3u8"),
+			Bucket:      playlistTask.Bucket,
+		}
+		if _, err := rc.mc.FPutObject(ctx, file.Bucket, file.ObjectKey, path, minio.PutObjectOptions{ContentType: file.ContentType}); err != nil {
+			rc.logger.Error("live master playlist upload failed", "error", err, "videoID", task.VideoID)
+			return
+		}
+		rc.saveVideoAsset(ctx, videoUUID, AssetKindLivePlaylist, file)
+		rc.setVideoStatus(ctx, task.VideoID, models.VideoPlayable)
+	}
+
+	ticker := time.NewTicker(segmentPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := p.publish(ctx, false); err != nil {
+				rc.logger.Warn("live publishing failed", "error", err, "videoID", task.VideoID)
+				continue
+			}
+			announce()
+		case err := <-encoded:
+			if err != nil {
+				rc.logger.Error("live encode failed", "error", err, "variant", task.Variant.Name, "videoID", task.VideoID)
+				return
+			}
+			if err := p.publish(ctx, true); err != nil {
+				rc.logger.Error("live publishing failed", "error", err, "videoID", task.VideoID)
+				return
+			}
+			announce()
+			rc.logger.Info("live publishing completed", "variant", task.Variant.Name, "videoID", task.VideoID, "parts", len(p.parts))
+			return
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package video
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+	"video-processing/database/db"
+	"video-processing/mocks"
+	"video-processing/models"
+
+	"github.com/google/uuid"
+	"github.com/minio/minio-go/v7"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestParseLiveParts(t *testing.T) {
+	parts, err := parseLiveParts(strings.NewReader("#EXTM3U\n#EXT-X-VERSION:7\n#EXT-X-TARGETDURATION:1\n#EXT-X-PLAYLIST-TYPE:EVENT\n" +
+		"#EXT-X-MAP:URI=\"init.mp4\"\n#EXTINF:1.000000,\npart_00000.m4s\n#EXTINF:0.966667,\npart_00001.m4s\n"))
+	require.NoError(t, err)
+	require.Equal(t, []livePart{{URI: "part_00000.m4s", Duration: 1}, {URI: "part_00001.m4s", Duration: 0.966667}}, parts)
+
+	_, err = parseLiveParts(strings.NewReader("#EXTINF:soon,\npart_00000.m4s\n"))
+	require.Error(t, err)
+}
+
+func TestLiveMediaPlaylist(t *testing.T) {
+	var parts []livePart
+	for i := range 9 {
+		parts = append(parts, livePart{URI: fmt.Sprintf("part_%05d.m4s", i), Duration: 1})
+	}
+
+	// while encoding, two full segments and the parts of the third are listed
+	playlist := liveMediaPlaylist(parts, 4, 1, false)
+	require.Equal(t, "#EXTM3U\n#EXT-X-VERSION:6\n#EXT-X-TARGETDURATION:4\n#EXT-X-PLAYLIST-TYPE:EVENT\n#EXT-X-INDEPENDENT-SEGMENTS\n"+
+		"#EXT-X-SERVER-CONTROL:PART-HOLD-BACK=3.000\n#EXT-X-PART-INF:PART-TARGET=1.000\n#EXT-X-MAP:URI=\"init.mp4\"\n"+
+		"#EXT-X-PART:DURATION=1.000,URI=\"part_00000.m4s\",INDEPENDENT=YES\n"+
+		"#EXT-X-PART:DURATION=1.000,URI=\"part_00001.m4s\",INDEPENDENT=YES\n"+
+		"#EXT-X-PART:DURATION=1.000,URI=\"part_00002.m4s\",INDEPENDENT=YES\n"+
+		"#EXT-X-PART:DURATION=1.000,URI=\"part_00003.m4s\",INDEPENDENT=YES\n"+
+		"#EXTINF:4.000000,\nsegment_00000.m4s\n"+
+		"#EXT-X-PART:DURATION=1.000,URI=\"part_00004.m4s\",INDEPENDENT=YES\n"+
+		"#EXT-X-PART:DURATION=1.000,URI=\"part_00005.m4s\",INDEPENDENT=YES\n"+
+		"#EXT-X-PART:DURATION=1.000,URI=\"part_00006.m4s\",INDEPENDENT=YES\n"+
+		"#EXT-X-PART:DURATION=1.000,URI=\"part_00007.m4s\",INDEPENDENT=YES\n"+
+		"#EXTINF:4.000000,\nsegment_00001.m4s\n"+
+		"#EXT-X-PART:DURATION=1.000,URI=\"part_00008.m4s\",INDEPENDENT=YES\n"+
+		"#EXT-X-PRELOAD-HINT:TYPE=PART,URI=\"part_00009.m4s\"\n", playlist)
+
+	// once encoded, the last part makes a shorter segment and the stream ends
+	playlist = liveMediaPlaylist(parts, 4, 1, true)
+	require.NotContains(t, playlist, "#EXT-X-PART:")
+	require.NotContains(t, playlist, "PRELOAD-HINT")
+	require.True(t, strings.HasSuffix(playlist, "#EXTINF:1.000000,\nsegment_00002.m4s\n#EXT-X-ENDLIST\n"))
+
+	// parts leave the playlist three segments behind the live edge
+	for i := 9; i < 16; i++ {
+		parts = append(parts, livePart{URI: fmt.Sprintf("part_%05d.m4s", i), Duration: 1})
+	}
+	require.Equal(t, 12, strings.Count(liveMediaPlaylist(parts, 4, 1, false), "#EXT-X-PART:"))
+}
+
+func TestPublishLive(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	repo := mocks.NewMockVideoRepo(ctrl)
+	store := mocks.NewMockObjectStore(ctrl)
+	fake := NewFakeTranscoder()
+	rc := &redisConsumer{
+		logger:     slog.New(slog.NewTextHandler(io.Discard, nil)),
+		db:         repo,
+		mc:         store,
+		transcoder: fake,
+		processing: models.ProcessingConfig{LowLatency: models.LowLatencyConfig{Enabled: true, PartDuration: 500 * time.Millisecond}},
+	}
+	videoID := uuid.New()
+	task := ProcessingTask{
+		Variant:    testLadder.regular[3],
+		WorkDir:    t.TempDir(),
+		SourcePath: "source.mp4",
+		DestPrefix: "processed/job",
+		Bucket:     "videos",
+		VideoID:    videoID.String(),
+		Encoder:    EncoderNVENC,
+	}
+	playlistTask := ProcessingTask{WorkDir: task.WorkDir, DestPrefix: task.DestPrefix, Bucket: task.Bucket, VideoID: task.VideoID}
+
+	var uploaded []string
+	store.EXPECT().FPutObject(gomock.Any(), "videos", gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, _, key, _ string, _ minio.PutObjectOptions) (minio.UploadInfo, error) {
+			uploaded = append(uploaded, key)
+			return minio.UploadInfo{}, nil
+		}).AnyTimes()
+	repo.EXPECT().SaveVideoAsset(gomock.Any(), db.SaveVideoAssetParams{
+		VideoID: videoID, Kind: AssetKindLivePlaylist, Bucket: "videos", Key: "processed/job/live/master.m3u8", ContentType: "application/vnd.apple.mpegurl",
+	})
+	repo.EXPECT().UpdateVideoStatus(gomock.Any(), db.UpdateVideoStatusParams{Status: models.VideoPlayable, ID: videoID})
+
+	rc.publishLive(context.Background(), task, playlistTask)
+
+	// the parts are cut by libx264 whatever the job's encoder
+	args := strings.Join(fake.Calls()[0], " ")
+	require.Contains(t, args, "-c:v libx264")
+	require.Contains(t, args, "-force_key_frames expr:gte(t,n_forced*0.5) -sc_threshold 0")
+	require.Contains(t, args, "-hls_time 0.5 -hls_list_size 0 -hls_playlist_type event -hls_segment_type fmp4")
+
+	// every file is stored before the playlist naming it
+	require.Equal(t, []string{
+		"processed/job/live/360p/init.mp4",
+		"processed/job/live/360p/part_00000.m4s",
+		"processed/job/live/360p/segment_00000.m4s",
+		"processed/job/live/360p/index.m3u8",
+		"processed/job/live/master.m3u8",
+	}, uploaded)
+}
@@ -0,0 +1,176 @@
+package video
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"video-processing/database/db"
+	"video-processing/storage"
+
+	"github.com/google/uuid"
+	"github.com/minio/minio-go/v7"
+)
+
+// StorageMigration copies the objects of users from one storage backend or bucket layout to
+// another, e.g. from MinIO to S3 or from a bucket per user to a shared bucket, and then points
+// the database at the copies.
+type StorageMigration struct {
+	Logger     *slog.Logger
+	DB         VideoRepo
+	From, To   ObjectStore
+	FromLayout storage.Layout
+	ToLayout   storage.Layout
+	// WorkDir holds each object while it is copied
+	WorkDir string
+	// Delete removes the source objects once the database points at their copies
+	Delete bool
+}
+
+// MigrationReport is what the migration of a user copied and rewrote
+type MigrationReport struct {
+	Objects int
+	Bytes   int64
+	// Moved counts the rows whose objects now point at the destination
+	Moved db.MoveUserObjectsRow
+	// Removed counts the source objects deleted after the move
+	Removed int
+}
+
+// MigrateUser copies every object of a user and verifies the checksum of each copy before the
+// database moves to the destination. A failure before the move leaves the database on the source,
+// so running the migration again starts over; objects outside the source layout, such as videos
+// of the ingest bucket, are neither copied nor rewritten.
+func (m StorageMigration) MigrateUser(ctx context.Context, userID uuid.UUID) (MigrationReport, error) {
+	var report MigrationReport
+	fromBucket, fromPrefix := m.FromLayout.Root(userID)
+	toBucket, toPrefix := m.ToLayout.Root(userID)
+
+	exists, err := bucketExists(ctx, m.From, fromBucket)
+	if err != nil {
+		return report, err
+	}
+	var copied []string
+	if exists {
+		for object := range m.From.ListObjects(ctx, fromBucket, minio.ListObjectsOptions{Prefix: fromPrefix, Recursive: true}) {
+			if object.Err != nil {
+				return report, fmt.Errorf("failed to list %s/%s: %w", fromBucket, fromPrefix, object.Err)
+			}
+			if report.Objects == 0 {
+				if err := ensureStoreBucket(ctx, m.To, toBucket); err != nil {
+					return report, err
+				}
+			}
+			toKey := toPrefix + strings.TrimPrefix(object.Key, fromPrefix)
+			if err := m.copyObject(ctx, fromBucket, object.Key, toBucket, toKey); err != nil {
+				return report, err
+			}
+			copied = append(copied, object.Key)
+			report.Objects++
+			report.Bytes += object.Size
+		}
+	}
+
+	report.Moved, err = m.DB.MoveUserObjects(ctx, db.MoveUserObjectsParams{
+		NewBucket: toBucket,
+		NewPrefix: toPrefix,
+		OldPrefix: fromPrefix,
+		UserID:    userID,
+		OldBucket: fromBucket,
+	})
+	if err != nil {
+		return report, fmt.Errorf("failed to move the objects of %s in the database: %w", userID, err)
+	}
+
+	if m.Delete {
+		// the copies are in use now, so a source object that stays behind is only wasted space
+		for _, key := range copied {
+			if err := m.From.RemoveObject(ctx, fromBucket, key, minio.RemoveObjectOptions{}); err != nil {
+				m.Logger.Error("failed to remove migrated object", "error", err, "bucket", fromBucket, "key", key)
+				continue
+			}
+			report.Removed++
+		}
+	}
+	return report, nil
+}
+
+// copyObject copies an object through a file of the work dir, then reads the copy back and
+// compares its checksum with the source's
+func (m StorageMigration) copyObject(ctx context.Context, fromBucket, fromKey, toBucket, toKey string) error {
+	info, err := m.From.StatObject(ctx, fromBucket, fromKey, minio.StatObjectOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to stat %s/%s: %w", fromBucket, fromKey, err)
+	}
+	source := filepath.Join(m.WorkDir, "source")
+	defer os.Remove(source)
+	if err := m.From.FGetObject(ctx, fromBucket, fromKey, source, minio.GetObjectOptions{}); err != nil {
+		return fmt.Errorf("failed to download %s/%s: %w", fromBucket, fromKey, err)
+	}
+	want, err := fileChecksum(source)
+	if err != nil {
+		return err
+	}
+	if _, err := m.To.FPutObject(ctx, toBucket, toKey, source, minio.PutObjectOptions{ContentType: info.ContentType}); err != nil {
+		return fmt.Errorf("failed to upload %s/%s: %w", toBucket, toKey, err)
+	}
+
+	copied := filepath.Join(m.WorkDir, "copy")
+	defer os.Remove(copied)
+	if err := m.To.FGetObject(ctx, toBucket, toKey, copied, minio.GetObjectOptions{}); err != nil {
+		return fmt.Errorf("failed to read back %s/%s: %w", toBucket, toKey, err)
+	}
+	got, err := fileChecksum(copied)
+	if err != nil {
+		return err
+	}
+	if got != want {
+		return fmt.Errorf("checksum mismatch copying %s/%s to %s/%s: sha256 %s, copy %s", fromBucket, fromKey, toBucket, toKey, want, got)
+	}
+	m.Logger.Debug("migrated object", "from", fromBucket+"/"+fromKey, "to", toBucket+"/"+toKey, "sha256", want)
+	return nil
+}
+
+// fileChecksum returns the hex SHA-256 of a file
+func fileChecksum(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", fmt.Errorf("failed to hash %s: %w", path, err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func bucketExists(ctx context.Context, store ObjectStore, bucketName string) (bool, error) {
+	buckets, err := store.ListBuckets(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to list buckets: %w", err)
+	}
+	for _, bucket := range buckets {
+		if bucket.Name == bucketName {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// ensureStoreBucket creates the bucket of a store unless it already exists
+func ensureStoreBucket(ctx context.Context, store ObjectStore, bucketName string) error {
+	exists, err := bucketExists(ctx, store, bucketName)
+	if err != nil || exists {
+		return err
+	}
+	if err := store.MakeBucket(ctx, bucketName, minio.MakeBucketOptions{}); err != nil {
+		return fmt.Errorf("failed to create bucket %s: %w", bucketName, err)
+	}
+	return nil
+}
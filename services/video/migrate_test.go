@@ -0,0 +1,134 @@
+package video
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"video-processing/database/db"
+	"video-processing/mocks"
+	"video-processing/storage"
+
+	"github.com/google/uuid"
+	"github.com/minio/minio-go/v7"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func newStorageMigration(t *testing.T, shared string) (StorageMigration, *mocks.MockVideoRepo) {
+	from, err := storage.NewLocal(t.TempDir(), "http://localhost:8888")
+	require.NoError(t, err)
+	to, err := storage.NewLocal(t.TempDir(), "http://localhost:8888")
+	require.NoError(t, err)
+	repo := mocks.NewMockVideoRepo(gomock.NewController(t))
+	return StorageMigration{
+		Logger:   slog.New(slog.NewTextHandler(io.Discard, nil)),
+		DB:       repo,
+		From:     from,
+		To:       to,
+		ToLayout: storage.Layout{Bucket: shared},
+		WorkDir:  t.TempDir(),
+	}, repo
+}
+
+func putObjects(t *testing.T, store ObjectStore, bucket string, objects map[string]string) {
+	ctx := context.Background()
+	require.NoError(t, ensureStoreBucket(ctx, store, bucket))
+	for key, data := range objects {
+		_, err := store.PutObject(ctx, bucket, key, strings.NewReader(data), int64(len(data)), minio.PutObjectOptions{})
+		require.NoError(t, err)
+	}
+}
+
+func readObject(t *testing.T, store ObjectStore, bucket, key string) string {
+	path := filepath.Join(t.TempDir(), "object")
+	require.NoError(t, store.FGetObject(context.Background(), bucket, key, path, minio.GetObjectOptions{}))
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	return string(data)
+}
+
+func TestMigrateUserToSharedBucket(t *testing.T) {
+	m, repo := newStorageMigration(t, "videos")
+	m.Delete = true
+	userID := uuid.New()
+	objects := map[string]string{
+		"clip.mp4":                       "source",
+		"processed/run/720p/index.m3u8":  "#EXTM3U\n",
+		"processed/run/720p/segment0.ts": "segment",
+	}
+	putObjects(t, m.From, userID.String(), objects)
+
+	repo.EXPECT().
+		MoveUserObjects(gomock.Any(), db.MoveUserObjectsParams{NewBucket: "videos", NewPrefix: userID.String() + "/", OldPrefix: "", UserID: userID, OldBucket: userID.String()}).
+		Return(db.MoveUserObjectsRow{Videos: 1, Variants: 1}, nil)
+	report, err := m.MigrateUser(context.Background(), userID)
+	require.NoError(t, err)
+	require.Equal(t, 3, report.Objects)
+	require.EqualValues(t, 1, report.Moved.Videos)
+	require.Equal(t, 3, report.Removed)
+
+	for key, data := range objects {
+		require.Equal(t, data, readObject(t, m.To, "videos", userID.String()+"/"+key))
+	}
+	for range m.From.ListObjects(context.Background(), userID.String(), minio.ListObjectsOptions{Recursive: true}) {
+		t.Fatal("a migrated source object was kept")
+	}
+}
+
+// truncatingStore drops the last byte of every upload
+type truncatingStore struct {
+	*storage.Local
+}
+
+func (s truncatingStore) FPutObject(ctx context.Context, bucketName, objectName, filePath string, opts minio.PutObjectOptions) (minio.UploadInfo, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return minio.UploadInfo{}, err
+	}
+	data = data[:len(data)-1]
+	return s.PutObject(ctx, bucketName, objectName, strings.NewReader(string(data)), int64(len(data)), opts)
+}
+
+func TestMigrateUserVerifiesChecksums(t *testing.T) {
+	m, _ := newStorageMigration(t, "videos")
+	m.To = truncatingStore{m.To.(*storage.Local)}
+	m.Delete = true
+	userID := uuid.New()
+	putObjects(t, m.From, userID.String(), map[string]string{"clip.mp4": "source"})
+
+	// the database stays on the source, so no query is expected
+	_, err := m.MigrateUser(context.Background(), userID)
+	require.ErrorContains(t, err, "checksum mismatch")
+	require.Equal(t, "source", readObject(t, m.From, userID.String(), "clip.mp4"))
+}
+
+func TestMigrateUserFromSharedBucket(t *testing.T) {
+	m, repo := newStorageMigration(t, "")
+	m.FromLayout = storage.Layout{Bucket: "videos"}
+	userID, other := uuid.New(), uuid.New()
+	putObjects(t, m.From, "videos", map[string]string{
+		userID.String() + "/clip.mp4": "mine",
+		other.String() + "/clip.mp4":  "theirs",
+	})
+
+	repo.EXPECT().
+		MoveUserObjects(gomock.Any(), db.MoveUserObjectsParams{NewBucket: userID.String(), NewPrefix: "", OldPrefix: userID.String() + "/", UserID: userID, OldBucket: "videos"}).
+		Return(db.MoveUserObjectsRow{Videos: 1}, nil)
+	report, err := m.MigrateUser(context.Background(), userID)
+	require.NoError(t, err)
+	require.Equal(t, 1, report.Objects)
+	require.Equal(t, "mine", readObject(t, m.To, userID.String(), "clip.mp4"))
+	// without Delete the source is kept
+	require.Equal(t, "mine", readObject(t, m.From, "videos", userID.String()+"/clip.mp4"))
+
+	// users without a bucket have nothing to copy, only rows to move
+	repo.EXPECT().MoveUserObjects(gomock.Any(), gomock.Any()).Return(db.MoveUserObjectsRow{}, nil)
+	m.FromLayout = storage.Layout{}
+	report, err = m.MigrateUser(context.Background(), uuid.New())
+	require.NoError(t, err)
+	require.Zero(t, report.Objects)
+}
@@ -0,0 +1,227 @@
+package video
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+	"video-processing/database/db"
+	"video-processing/models"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// moderationMessages tell owners what happened to their video
+var moderationMessages = map[string]string{
+	models.ModerationHide:        "Your video %q was hidden after it was reported for %s. Only you can see it.",
+	models.ModerationAgeRestrict: "Your video %q was age-restricted after it was reported for %s. It is no longer shown in feeds.",
+	models.ModerationRemove:      "Your video %q was removed after it was reported for %s.",
+}
+
+// ReportVideo files a report of a video the user can see for the moderators
+func (vp *videoProcessor) ReportVideo(ctx context.Context, userID, videoID uuid.UUID, req models.ReportRequest) (models.VideoReport, error) {
+	params := fmt.Sprintf("userID: %v, videoID: %v", userID, videoID)
+	if err := req.Validate(); err != nil {
+		return models.VideoReport{}, models.Error{
+			Code:    http.StatusBadRequest,
+			Message: "invalid input data",
+			Params:  params,
+			Err:     err,
+		}
+	}
+	video, err := vp.getVisibleVideo(ctx, userID, videoID)
+	if err != nil {
+		return models.VideoReport{}, err
+	}
+	if video.UserID == userID {
+		return models.VideoReport{}, models.Error{
+			Code:    http.StatusBadRequest,
+			Message: "cannot report your own video",
+			Params:  params,
+			Err:     errors.Join(errors.New("reporter owns the video"), models.ErrInvalidInputData),
+		}
+	}
+	row, err := vp.db.CreateVideoReport(ctx, db.CreateVideoReportParams{
+		VideoID:    videoID,
+		ReporterID: userID,
+		Reason:     req.Reason,
+		Details:    req.Details,
+	})
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return models.VideoReport{}, models.Error{
+			Code:    http.StatusConflict,
+			Message: "video already reported",
+			Params:  params,
+			Err:     err,
+		}
+	}
+	if err != nil {
+		return models.VideoReport{}, models.IndentifyDbError(err).AddParams(params)
+	}
+	return reportFromRow(row), nil
+}
+
+// ListReports returns the moderation queue, open reports by default, oldest first
+func (vp *videoProcessor) ListReports(ctx context.Context, query models.ReportQuery) ([]models.VideoReport, error) {
+	params := fmt.Sprintf("query: %v", query)
+	if err := query.Validate(); err != nil {
+		return nil, models.Error{
+			Code:    http.StatusBadRequest,
+			Message: "invalid input data",
+			Params:  params,
+			Err:     err,
+		}
+	}
+	if query.Status == "" {
+		query.Status = models.ReportOpen
+	}
+	rows, err := vp.db.ListVideoReports(ctx, db.ListVideoReportsParams{
+		Status: query.Status,
+		Limit:  int32(query.Limit),
+		Offset: int32(query.Offset),
+	})
+	if err != nil {
+		return nil, models.IndentifyDbError(err).AddParams(params)
+	}
+	reports := make([]models.VideoReport, 0, len(rows))
+	for _, row := range rows {
+		report := reportFromRow(db.VideoReport{
+			ID:         row.ID,
+			VideoID:    row.VideoID,
+			ReporterID: row.ReporterID,
+			Reason:     row.Reason,
+			Details:    row.Details,
+			Status:     row.Status,
+			Action:     row.Action,
+			ResolvedBy: row.ResolvedBy,
+			ResolvedAt: row.ResolvedAt,
+			CreatedAt:  row.CreatedAt,
+		})
+		report.Video = &models.ReportedVideo{
+			OwnerID:       row.OwnerID,
+			Title:         row.Title,
+			Visibility:    row.Visibility,
+			AgeRestricted: row.AgeRestricted,
+		}
+		reports = append(reports, report)
+	}
+	return reports, nil
+}
+
+// ModerateReport acts on the video of a report and closes every open report of the video.
+// The owner is notified unless the reports are dismissed.
+func (vp *videoProcessor) ModerateReport(ctx context.Context, moderatorID, reportID uuid.UUID, req models.ModerationRequest) (models.VideoReport, error) {
+	params := fmt.Sprintf("moderatorID: %v, reportID: %v", moderatorID, reportID)
+	if err := req.Validate(); err != nil {
+		return models.VideoReport{}, models.Error{
+			Code:    http.StatusBadRequest,
+			Message: "invalid input data",
+			Params:  params,
+			Err:     err,
+		}
+	}
+	report, err := vp.db.GetVideoReport(ctx, reportID)
+	if err != nil {
+		return models.VideoReport{}, reportDbError(err, params)
+	}
+	if report.Status != models.ReportOpen {
+		return models.VideoReport{}, models.Error{
+			Code:    http.StatusConflict,
+			Message: "report already closed",
+			Params:  params,
+			Err:     fmt.Errorf("report is %s", report.Status),
+		}
+	}
+	video, err := vp.db.GetVideo(ctx, report.VideoID)
+	if err != nil {
+		return models.VideoReport{}, reportDbError(err, params)
+	}
+
+	switch req.Action {
+	case models.ModerationHide, models.ModerationRemove:
+		visibility := models.VisibilityHidden
+		if req.Action == models.ModerationRemove {
+			visibility = models.VisibilityRemoved
+		}
+		_, err = vp.db.SetVideoVisibility(ctx, db.SetVideoVisibilityParams{Visibility: visibility, ID: video.ID})
+	case models.ModerationAgeRestrict:
+		err = vp.db.SetVideoAgeRestricted(ctx, db.SetVideoAgeRestrictedParams{AgeRestricted: true, ID: video.ID})
+	}
+	if err != nil {
+		return models.VideoReport{}, models.IndentifyDbError(err).AddParams(params)
+	}
+
+	status := models.ReportResolved
+	action := pgtype.Text{String: req.Action, Valid: true}
+	if req.Action == models.ModerationDismiss {
+		status, action = models.ReportDismissed, pgtype.Text{}
+	}
+	if _, err := vp.db.ResolveVideoReports(ctx, db.ResolveVideoReportsParams{
+		Status:     status,
+		Action:     action,
+		ResolvedBy: moderatorID,
+		VideoID:    video.ID,
+	}); err != nil {
+		return models.VideoReport{}, models.IndentifyDbError(err).AddParams(params)
+	}
+
+	if format, ok := moderationMessages[req.Action]; ok {
+		message := fmt.Sprintf(format, video.Title, report.Reason)
+		if req.Note != "" {
+			message += " Moderator note: " + req.Note
+		}
+		// the action stands when the owner cannot be told
+		vp.notifier.notify(ctx, models.NotificationEvent{
+			Kind:      models.NotificationModeration,
+			UserID:    video.UserID,
+			VideoID:   &video.ID,
+			Message:   message,
+			CreatedAt: time.Now(),
+		})
+	}
+
+	report, err = vp.db.GetVideoReport(ctx, reportID)
+	if err != nil {
+		return models.VideoReport{}, reportDbError(err, params)
+	}
+	return reportFromRow(report), nil
+}
+
+func reportFromRow(row db.VideoReport) models.VideoReport {
+	report := models.VideoReport{
+		ID:         row.ID,
+		VideoID:    row.VideoID,
+		ReporterID: row.ReporterID,
+		Reason:     row.Reason,
+		Details:    row.Details,
+		Status:     row.Status,
+		Action:     row.Action.String,
+		CreatedAt:  row.CreatedAt,
+	}
+	if row.ResolvedBy.Valid {
+		resolvedBy := uuid.UUID(row.ResolvedBy.Bytes)
+		report.ResolvedBy = &resolvedBy
+	}
+	if row.ResolvedAt.Valid {
+		report.ResolvedAt = &row.ResolvedAt.Time
+	}
+	return report
+}
+
+// reportDbError maps missing reports and videos to 404
+func reportDbError(err error, params string) error {
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.Error{
+			Code:    http.StatusNotFound,
+			Message: "resource not found",
+			Params:  params,
+			Err:     models.ErrResourceNotFound,
+		}
+	}
+	return models.IndentifyDbError(err).AddParams(params)
+}
@@ -0,0 +1,131 @@
+package video
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"video-processing/database/db"
+	"video-processing/models"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestReportRequestValidate(t *testing.T) {
+	require.NoError(t, models.ReportRequest{Reason: "spam"}.Validate())
+	require.Error(t, models.ReportRequest{Reason: "boring"}.Validate())
+	require.Error(t, models.ReportRequest{Reason: "other"}.Validate())
+	require.NoError(t, models.ReportRequest{Reason: "other", Details: "misleading thumbnail"}.Validate())
+}
+
+func TestReportVideo(t *testing.T) {
+	vp, repo := newSubscriptionsProcessor(t)
+	reporter, owner, videoID := uuid.New(), uuid.New(), uuid.New()
+	public := db.Video{ID: videoID, UserID: owner, Visibility: models.VisibilityPublic}
+	req := models.ReportRequest{Reason: "spam"}
+	var e models.Error
+
+	// private videos of others cannot be seen, so not reported
+	repo.EXPECT().GetVideo(gomock.Any(), videoID).Return(db.Video{ID: videoID, UserID: owner, Visibility: models.VisibilityPrivate}, nil)
+	_, err := vp.ReportVideo(context.Background(), reporter, videoID, req)
+	require.ErrorAs(t, err, &e)
+	require.Equal(t, http.StatusNotFound, e.Code)
+
+	repo.EXPECT().GetVideo(gomock.Any(), videoID).Return(public, nil)
+	_, err = vp.ReportVideo(context.Background(), owner, videoID, req)
+	require.ErrorAs(t, err, &e)
+	require.Equal(t, http.StatusBadRequest, e.Code)
+
+	repo.EXPECT().GetVideo(gomock.Any(), videoID).Return(public, nil).Times(2)
+	repo.EXPECT().
+		CreateVideoReport(gomock.Any(), db.CreateVideoReportParams{VideoID: videoID, ReporterID: reporter, Reason: "spam"}).
+		Return(db.VideoReport{ID: uuid.New(), VideoID: videoID, ReporterID: reporter, Reason: "spam", Status: models.ReportOpen}, nil)
+	report, err := vp.ReportVideo(context.Background(), reporter, videoID, req)
+	require.NoError(t, err)
+	require.Equal(t, models.ReportOpen, report.Status)
+
+	repo.EXPECT().CreateVideoReport(gomock.Any(), gomock.Any()).Return(db.VideoReport{}, &pgconn.PgError{Code: "23505"})
+	_, err = vp.ReportVideo(context.Background(), reporter, videoID, req)
+	require.ErrorAs(t, err, &e)
+	require.Equal(t, http.StatusConflict, e.Code)
+}
+
+func TestModerateReport(t *testing.T) {
+	vp, repo := newSubscriptionsProcessor(t)
+	moderator, owner, reportID, videoID := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	open := db.VideoReport{ID: reportID, VideoID: videoID, Reason: "spam", Status: models.ReportOpen}
+	resolved := open
+	resolved.Status = models.ReportResolved
+	resolved.Action = pgtype.Text{String: models.ModerationHide, Valid: true}
+
+	gomock.InOrder(
+		repo.EXPECT().GetVideoReport(gomock.Any(), reportID).Return(open, nil),
+		repo.EXPECT().GetVideo(gomock.Any(), videoID).Return(db.Video{ID: videoID, UserID: owner, Title: "clip"}, nil),
+		repo.EXPECT().
+			SetVideoVisibility(gomock.Any(), db.SetVideoVisibilityParams{Visibility: models.VisibilityHidden, ID: videoID}).
+			Return(db.Video{}, nil),
+		repo.EXPECT().
+			ResolveVideoReports(gomock.Any(), db.ResolveVideoReportsParams{
+				Status:     models.ReportResolved,
+				Action:     pgtype.Text{String: models.ModerationHide, Valid: true},
+				ResolvedBy: moderator,
+				VideoID:    videoID,
+			}).
+			Return(int64(2), nil),
+		repo.EXPECT().GetNotificationPreferences(gomock.Any(), owner).Return(db.GetNotificationPreferencesRow{}, nil),
+		repo.EXPECT().
+			CreateNotification(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, arg db.CreateNotificationParams) error {
+				require.Equal(t, owner, arg.UserID)
+				require.Equal(t, models.NotificationModeration, arg.Kind)
+				require.Contains(t, arg.Message, `"clip" was hidden`)
+				require.Contains(t, arg.Message, "Moderator note: misleading")
+				return nil
+			}),
+		repo.EXPECT().GetVideoReport(gomock.Any(), reportID).Return(resolved, nil),
+	)
+	report, err := vp.ModerateReport(context.Background(), moderator, reportID, models.ModerationRequest{Action: models.ModerationHide, Note: "misleading"})
+	require.NoError(t, err)
+	require.Equal(t, models.ModerationHide, report.Action)
+
+	// closed reports are not moderated twice
+	repo.EXPECT().GetVideoReport(gomock.Any(), reportID).Return(resolved, nil)
+	_, err = vp.ModerateReport(context.Background(), moderator, reportID, models.ModerationRequest{Action: models.ModerationRemove})
+	var e models.Error
+	require.ErrorAs(t, err, &e)
+	require.Equal(t, http.StatusConflict, e.Code)
+}
+
+func TestModerateReportDismissDoesNotNotify(t *testing.T) {
+	vp, repo := newSubscriptionsProcessor(t)
+	moderator, reportID, videoID := uuid.New(), uuid.New(), uuid.New()
+	open := db.VideoReport{ID: reportID, VideoID: videoID, Reason: "spam", Status: models.ReportOpen}
+
+	repo.EXPECT().GetVideoReport(gomock.Any(), reportID).Return(open, nil).Times(2)
+	repo.EXPECT().GetVideo(gomock.Any(), videoID).Return(db.Video{ID: videoID, UserID: uuid.New()}, nil)
+	repo.EXPECT().
+		ResolveVideoReports(gomock.Any(), db.ResolveVideoReportsParams{Status: models.ReportDismissed, ResolvedBy: moderator, VideoID: videoID}).
+		Return(int64(1), nil)
+	_, err := vp.ModerateReport(context.Background(), moderator, reportID, models.ModerationRequest{Action: models.ModerationDismiss})
+	require.NoError(t, err)
+}
+
+func TestRemovedVideosAreHiddenFromOwners(t *testing.T) {
+	vp, repo := newSubscriptionsProcessor(t)
+	owner, videoID := uuid.New(), uuid.New()
+
+	repo.EXPECT().GetVideo(gomock.Any(), videoID).Return(db.Video{ID: videoID, UserID: owner, Visibility: models.VisibilityRemoved}, nil)
+	_, err := vp.GetVideo(context.Background(), owner, videoID, nil)
+	var e models.Error
+	require.ErrorAs(t, err, &e)
+	require.Equal(t, http.StatusNotFound, e.Code)
+
+	// hidden videos cannot be published again by their owner
+	repo.EXPECT().GetVideo(gomock.Any(), videoID).Return(db.Video{ID: videoID, UserID: owner, Visibility: models.VisibilityHidden}, nil)
+	_, err = vp.SetVisibility(context.Background(), owner, videoID, models.SetVisibilityRequest{Visibility: models.VisibilityPublic})
+	require.ErrorAs(t, err, &e)
+	require.Equal(t, http.StatusConflict, e.Code)
+}
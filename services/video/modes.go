@@ -0,0 +1,50 @@
+package video
+
+import (
+	"slices"
+	"video-processing/models"
+
+	"github.com/google/uuid"
+)
+
+// userMode returns the processing mode of a job of the user: the requested one, or else the
+// mode of the user's delivery plan
+func (vp *videoProcessor) userMode(userID uuid.UUID, requested string) string {
+	if requested != "" {
+		return requested
+	}
+	return vp.modes.ModeOf(vp.delivery.PlanOf(userID))
+}
+
+// jobMode returns the processing mode a job names and its configuration. Jobs that name
+// none, e.g. ones queued before modes existed, get the default mode.
+func (rc *redisConsumer) jobMode(values map[string]interface{}) (string, models.ModeConfig) {
+	name, _ := values["mode"].(string)
+	if !slices.Contains(models.ProcessingModes, interface{}(name)) {
+		name = rc.processing.Modes.DefaultMode()
+	}
+	return name, rc.processing.Modes.Mode(name)
+}
+
+// withEncoderPreset returns the ladder with every variant encoded at the x264/x265 speed
+// preset, the ladder itself when preset is empty
+func (l ladder) withEncoderPreset(preset string) ladder {
+	if preset == "" {
+		return l
+	}
+	set := func(variants []Variant) []Variant {
+		variants = slices.Clone(variants)
+		for i := range variants {
+			variants[i].EncoderPreset = preset
+		}
+		return variants
+	}
+	return ladder{regular: set(l.regular), hdr: set(l.hdr), vertical: set(l.vertical)}
+}
+
+// twoPass reports whether the task's MP4 is encoded in two passes. Only libx264 encodes at a
+// bitrate gain from it; chunks are left in one pass, since each would be planned on its own.
+func (t ProcessingTask) twoPass() bool {
+	_, x264 := t.encoder().(x264Encoder)
+	return t.TwoPass && x264 && t.Chunk == nil && t.Variant.CRF == 0
+}
@@ -0,0 +1,68 @@
+package video
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"video-processing/models"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestJobMode(t *testing.T) {
+	rc := &redisConsumer{processing: models.ProcessingConfig{Modes: models.ModesConfig{
+		Fast:    models.ModeConfig{EncoderPreset: "veryfast"},
+		Quality: models.ModeConfig{EncoderPreset: "slow", TwoPass: true},
+		Default: models.ModeFast,
+	}}}
+
+	name, mode := rc.jobMode(map[string]interface{}{"mode": models.ModeQuality})
+	require.Equal(t, models.ModeQuality, name)
+	require.True(t, mode.TwoPass)
+	// jobs queued without a mode get the default one
+	name, mode = rc.jobMode(map[string]interface{}{})
+	require.Equal(t, models.ModeFast, name)
+	require.Equal(t, "veryfast", mode.EncoderPreset)
+
+	l := testLadder.withEncoderPreset(mode.EncoderPreset)
+	for _, v := range l.all() {
+		require.Equal(t, "veryfast", v.encoderPreset(), v.Name)
+	}
+	require.Equal(t, "fast", testLadder.regular[0].encoderPreset(), "the preset's ladder is left alone")
+}
+
+func TestTranscodeToMP4TwoPass(t *testing.T) {
+	fake := NewFakeTranscoder()
+	dir := t.TempDir()
+	mp4Path := filepath.Join(dir, "720p.mp4")
+	task := ProcessingTask{Variant: testLadder.regular[1], SourcePath: "source.mp4", TwoPass: true}
+	require.Zero(t, task.Variant.CRF)
+	passLog := filepath.Join(dir, "x264pass")
+	// what libx264 would have written, it must not be uploaded with the variant
+	require.NoError(t, os.WriteFile(passLog+"-0.log", nil, 0o644))
+
+	require.NoError(t, transcodeToMP4(context.Background(), fake, task, mp4Path))
+	require.NoFileExists(t, passLog+"-0.log")
+	calls := fake.Calls()
+	require.Len(t, calls, 2)
+	first, second := calls[0], calls[1]
+	require.Equal(t, []string{"-pass", "1", "-passlogfile", passLog, "-an", "-f", "null", "-"}, first[len(first)-8:])
+	require.Equal(t, []string{"-b:v", task.Variant.Bitrate}, first[slices.Index(first, "-b:v"):slices.Index(first, "-b:v")+2])
+	i := slices.Index(second, "-pass")
+	require.Equal(t, []string{"-pass", "2", "-passlogfile", passLog}, second[i:i+4])
+	require.Equal(t, mp4Path, second[len(second)-1])
+
+	// constant quality and HEVC encodes take one pass
+	fake = NewFakeTranscoder()
+	crf := task
+	crf.Variant.CRF = 23
+	require.NoError(t, transcodeToMP4(context.Background(), fake, crf, mp4Path))
+	hevc := task
+	hevc.Variant.Codec = models.CodecHEVC
+	require.NoError(t, transcodeToMP4(context.Background(), fake, hevc, mp4Path))
+	for _, call := range fake.Calls() {
+		require.NotContains(t, call, "-pass")
+	}
+}
@@ -0,0 +1,305 @@
+package video
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/smtp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+	"video-processing/database/db"
+	"video-processing/models"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// defaultWebhookTimeout bounds webhook deliveries when the configuration leaves it unset
+const defaultWebhookTimeout = 10 * time.Second
+
+// notificationSubjects are the subjects of notification emails
+var notificationSubjects = map[string]string{
+	models.NotificationProcessingDone: "Your video is ready",
+	models.NotificationNewSubscriber:  "You have a new subscriber",
+	models.NotificationNewVideo:       "New video from a creator you follow",
+	models.NotificationModeration:     "A moderator acted on your video",
+}
+
+// notifier delivers notifications on the channels each user chose: in the app, by email and
+// to their webhook. Emails and webhooks are sent in the background and only logged on failure.
+// A nil notifier notifies nobody.
+type notifier struct {
+	logger   *slog.Logger
+	db       VideoRepo
+	config   models.NotificationConfig
+	client   *http.Client
+	sendMail func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+	pending  sync.WaitGroup
+}
+
+func newNotifier(logger *slog.Logger, db VideoRepo, config models.NotificationConfig) *notifier {
+	timeout := config.WebhookTimeout
+	if timeout <= 0 {
+		timeout = defaultWebhookTimeout
+	}
+	dialer := &net.Dialer{Timeout: timeout}
+	if !config.AllowPrivateWebhooks {
+		dialer.Control = publicAddressesOnly
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil // a proxy would dial the internal addresses for us
+	transport.DialContext = dialer.DialContext
+	client := &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		// a redirect could send the delivery on to an internal address; 3xx fails it instead
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+	return &notifier{
+		logger:   logger,
+		db:       db,
+		config:   config,
+		client:   client,
+		sendMail: smtp.SendMail,
+	}
+}
+
+// publicAddressesOnly keeps user webhooks from reaching the internal network. It checks the
+// address actually dialed, after DNS resolution, so a public name resolving to an internal
+// address is refused as well.
+func publicAddressesOnly(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || !models.PublicIP(ip) {
+		return fmt.Errorf("webhook address %s is not public", host)
+	}
+	return nil
+}
+
+// notify delivers a notification to its user
+func (n *notifier) notify(ctx context.Context, event models.NotificationEvent) {
+	if n == nil {
+		return
+	}
+	row, err := n.db.GetNotificationPreferences(ctx, event.UserID)
+	if err != nil {
+		n.logger.Error("failed to load notification preferences", "error", err, "userID", event.UserID, "kind", event.Kind)
+		return
+	}
+	prefs := preferencesFromRow(row.Channels, row.WebhookUrl.String)
+	if slices.Contains(prefs.ChannelsOf(event.Kind), models.ChannelInApp) {
+		var videoID pgtype.UUID
+		if event.VideoID != nil {
+			videoID = pgtype.UUID{Bytes: *event.VideoID, Valid: true}
+		}
+		err := n.db.CreateNotification(ctx, db.CreateNotificationParams{
+			UserID:  event.UserID,
+			Kind:    event.Kind,
+			VideoID: videoID,
+			Message: event.Message,
+		})
+		if err != nil {
+			n.logger.Error("failed to save notification", "error", err, "userID", event.UserID, "kind", event.Kind)
+		}
+	}
+	n.deliver(event, row.Email, prefs)
+}
+
+// notifySubscribers fans a newly published video out to the subscribers of its owner.
+// The video stays published when this fails, the subscribers still find it in their feed.
+func (n *notifier) notifySubscribers(ctx context.Context, video db.Video) {
+	if n == nil {
+		return
+	}
+	count, err := n.db.CreateVideoNotifications(ctx, db.CreateVideoNotificationsParams{VideoID: video.ID, CreatorID: video.UserID})
+	if err != nil {
+		n.logger.Error("failed to notify subscribers", "error", err, "videoID", video.ID, "creatorID", video.UserID)
+	} else {
+		n.logger.Info("subscribers notified", "videoID", video.ID, "creatorID", video.UserID, "notifications", count)
+	}
+
+	// subscribers on the defaults only get the in-app notification
+	rows, err := n.db.ListSubscriberPreferences(ctx, video.UserID)
+	if err != nil {
+		n.logger.Error("failed to load subscriber preferences", "error", err, "creatorID", video.UserID)
+		return
+	}
+	title := video.Title
+	for _, row := range rows {
+		if title == "" {
+			// scheduled publications only know the ids of the video
+			v, err := n.db.GetVideo(ctx, video.ID)
+			if err != nil {
+				n.logger.Error("failed to load published video", "error", err, "videoID", video.ID)
+				return
+			}
+			title = v.Title
+		}
+		videoID := video.ID
+		n.deliver(models.NotificationEvent{
+			Kind:      models.NotificationNewVideo,
+			UserID:    row.SubscriberID,
+			VideoID:   &videoID,
+			Message:   fmt.Sprintf("%q was published by a creator you subscribed to.", title),
+			CreatedAt: time.Now(),
+		}, row.Email, preferencesFromRow(row.Channels, row.WebhookUrl))
+	}
+}
+
+// notifyProcessed tells the owner of a video that it finished processing. Reprocessing
+// the library does not notify.
+func (rc *redisConsumer) notifyProcessed(ctx context.Context, values map[string]interface{}) {
+	if _, reprocessed := values["reprocess_run_id"]; reprocessed || rc.notifier == nil {
+		return
+	}
+	videoID, err := uuid.Parse(fmt.Sprint(values["video_id"]))
+	if err != nil {
+		return
+	}
+	video, err := rc.db.GetVideo(ctx, videoID)
+	if err != nil {
+		rc.logger.Error("failed to load processed video", "error", err, "videoID", videoID)
+		return
+	}
+	rc.notifier.notify(ctx, models.NotificationEvent{
+		Kind:      models.NotificationProcessingDone,
+		UserID:    video.UserID,
+		VideoID:   &video.ID,
+		Message:   fmt.Sprintf("%q finished processing.", video.Title),
+		CreatedAt: time.Now(),
+	})
+}
+
+// deliver sends a notification by email and to the webhook of the user, as they chose
+func (n *notifier) deliver(event models.NotificationEvent, email string, prefs models.NotificationPreferences) {
+	channels := prefs.ChannelsOf(event.Kind)
+	if slices.Contains(channels, models.ChannelEmail) && n.config.SMTP.Host != "" && email != "" {
+		n.background(event, models.ChannelEmail, func() error { return n.mail(email, event) })
+	}
+	if slices.Contains(channels, models.ChannelWebhook) && prefs.WebhookURL != "" {
+		n.background(event, models.ChannelWebhook, func() error { return n.post(prefs.WebhookURL, event) })
+	}
+}
+
+func (n *notifier) background(event models.NotificationEvent, channel string, send func() error) {
+	n.pending.Add(1)
+	go func() {
+		defer n.pending.Done()
+		if err := send(); err != nil {
+			n.logger.Error("failed to deliver notification", "error", err, "userID", event.UserID, "kind", event.Kind, "channel", channel)
+		}
+	}()
+}
+
+// allowsPrivateWebhooks reports whether webhooks may be plain http and reach internal hosts
+func (n *notifier) allowsPrivateWebhooks() bool {
+	return n != nil && n.config.AllowPrivateWebhooks
+}
+
+// wait blocks until the background deliveries are done
+func (n *notifier) wait() {
+	n.pending.Wait()
+}
+
+func (n *notifier) mail(to string, event models.NotificationEvent) error {
+	cfg := n.config.SMTP
+	var auth smtp.Auth
+	if cfg.Username != "" {
+		auth = smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.Host)
+	}
+	msg := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\nContent-Type: text/plain; charset=UTF-8\r\n\r\n%s\r\n",
+		cfg.From, to, notificationSubjects[event.Kind], event.Message)
+	return n.sendMail(net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port)), auth, cfg.From, []string{to}, []byte(msg))
+}
+
+// post posts the notification as JSON to url. Any status other than 2xx fails the delivery,
+// and so does a plain http URL saved before webhooks had to be https.
+func (n *notifier) post(url string, event models.NotificationEvent) error {
+	if !n.allowsPrivateWebhooks() && !strings.HasPrefix(url, "https://") {
+		return errors.New("webhook URL is not https")
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook answered %s", resp.Status)
+	}
+	return nil
+}
+
+// preferencesFromRow decodes stored preferences; users without a row get the defaults
+func preferencesFromRow(channels []byte, webhookURL string) models.NotificationPreferences {
+	prefs := models.NotificationPreferences{WebhookURL: webhookURL}
+	if len(channels) > 0 {
+		// written by SetNotificationPreferences, so only unreadable when edited by hand
+		_ = json.Unmarshal(channels, &prefs.Channels)
+	}
+	return prefs
+}
+
+// GetNotificationPreferences returns the channels of every kind of notification of the user
+func (vp *videoProcessor) GetNotificationPreferences(ctx context.Context, userID uuid.UUID) (models.NotificationPreferences, error) {
+	row, err := vp.db.GetNotificationPreferences(ctx, userID)
+	if err != nil {
+		return models.NotificationPreferences{}, models.IndentifyDbError(err).AddParams(fmt.Sprintf("userID: %v", userID))
+	}
+	return preferencesFromRow(row.Channels, row.WebhookUrl.String).WithDefaults(), nil
+}
+
+// SetNotificationPreferences replaces the notification preferences of the user
+func (vp *videoProcessor) SetNotificationPreferences(ctx context.Context, userID uuid.UUID, prefs models.NotificationPreferences) (models.NotificationPreferences, error) {
+	params := fmt.Sprintf("userID: %v", userID)
+	// validated as strictly as deliveries are made, or saved webhooks would never be delivered
+	if err := prefs.Validate(vp.notifier.allowsPrivateWebhooks()); err != nil {
+		return models.NotificationPreferences{}, models.Error{
+			Code:    http.StatusBadRequest,
+			Message: "invalid input data",
+			Params:  params,
+			Err:     err,
+		}
+	}
+	if prefs.Channels == nil {
+		prefs.Channels = map[string][]string{}
+	}
+	channels, err := json.Marshal(prefs.Channels)
+	if err != nil {
+		return models.NotificationPreferences{}, models.Error{
+			Code:    http.StatusInternalServerError,
+			Message: "internal server error",
+			Params:  params,
+			Err:     errors.Join(err, errors.New("failed to encode preferences")),
+		}
+	}
+	row, err := vp.db.SetNotificationPreferences(ctx, db.SetNotificationPreferencesParams{
+		UserID:     userID,
+		Channels:   channels,
+		WebhookUrl: prefs.WebhookURL,
+	})
+	if err != nil {
+		return models.NotificationPreferences{}, models.IndentifyDbError(err).AddParams(params)
+	}
+	return preferencesFromRow(row.Channels, row.WebhookUrl).WithDefaults(), nil
+}
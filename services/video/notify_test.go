@@ -0,0 +1,187 @@
+package video
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/smtp"
+	"testing"
+	"video-processing/database/db"
+	"video-processing/mocks"
+	"video-processing/models"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestNotificationPreferencesValidate(t *testing.T) {
+	require.NoError(t, models.NotificationPreferences{}.Validate(false))
+	require.NoError(t, models.NotificationPreferences{Channels: map[string][]string{
+		models.NotificationProcessingDone: {models.ChannelEmail},
+		models.NotificationNewVideo:       {},
+	}}.Validate(false))
+	require.Error(t, models.NotificationPreferences{Channels: map[string][]string{"comment": {models.ChannelInApp}}}.Validate(false))
+	require.Error(t, models.NotificationPreferences{Channels: map[string][]string{models.NotificationModeration: {"sms"}}}.Validate(false))
+
+	webhook := map[string][]string{models.NotificationNewSubscriber: {models.ChannelWebhook}}
+	require.Error(t, models.NotificationPreferences{Channels: webhook}.Validate(false))
+	require.Error(t, models.NotificationPreferences{Channels: webhook, WebhookURL: "ftp://example.com/hook"}.Validate(false))
+	require.NoError(t, models.NotificationPreferences{Channels: webhook, WebhookURL: "https://example.com/hook"}.Validate(false))
+	// webhooks are https and may not name internal hosts
+	for _, url := range []string{
+		"http://example.com/hook",
+		"https://localhost/hook",
+		"https://127.0.0.1:9000/hook",
+		"https://169.254.169.254/latest/meta-data",
+		"https://10.0.0.5/hook",
+		"https://[::1]/hook",
+		"https://100.64.0.1/hook",
+	} {
+		require.Error(t, models.NotificationPreferences{Channels: webhook, WebhookURL: url}.Validate(false), url)
+		// with private webhooks allowed, as in development, they are saved as they are delivered
+		require.NoError(t, models.NotificationPreferences{Channels: webhook, WebhookURL: url}.Validate(true), url)
+	}
+	require.Error(t, models.NotificationPreferences{Channels: webhook, WebhookURL: "ftp://example.com/hook"}.Validate(true))
+
+	prefs := models.NotificationPreferences{Channels: map[string][]string{models.NotificationNewVideo: {}}}.WithDefaults()
+	require.Len(t, prefs.Channels, len(models.NotificationKinds))
+	require.Empty(t, prefs.Channels[models.NotificationNewVideo])
+	require.Equal(t, []string{models.ChannelInApp}, prefs.Channels[models.NotificationModeration])
+}
+
+func TestNotifierDeliversOnChosenChannels(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	repo := mocks.NewMockVideoRepo(ctrl)
+	userID, videoID := uuid.New(), uuid.New()
+
+	var posted models.NotificationEvent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&posted))
+	}))
+	defer server.Close()
+
+	config := models.NotificationConfig{AllowPrivateWebhooks: true} // the test server listens on loopback
+	config.SMTP.Host, config.SMTP.Port, config.SMTP.From = "mail.example.com", 587, "videos@example.com"
+	n := newNotifier(slog.New(slog.NewTextHandler(io.Discard, nil)), repo, config)
+	var mailedTo []string
+	var mail string
+	n.sendMail = func(addr string, _ smtp.Auth, from string, to []string, msg []byte) error {
+		require.Equal(t, "mail.example.com:587", addr)
+		require.Equal(t, "videos@example.com", from)
+		mailedTo, mail = to, string(msg)
+		return nil
+	}
+
+	// email and webhook only, so nothing is saved in the app
+	repo.EXPECT().GetNotificationPreferences(gomock.Any(), userID).Return(db.GetNotificationPreferencesRow{
+		Email:      "owner@example.com",
+		Channels:   []byte(`{"processing_done": ["email", "webhook"]}`),
+		WebhookUrl: pgtype.Text{String: server.URL, Valid: true},
+	}, nil)
+	n.notify(context.Background(), models.NotificationEvent{Kind: models.NotificationProcessingDone, UserID: userID, VideoID: &videoID, Message: `"clip" finished processing.`})
+	n.wait()
+	require.Equal(t, []string{"owner@example.com"}, mailedTo)
+	require.Contains(t, mail, "Subject: Your video is ready\r\n")
+	require.Contains(t, mail, `"clip" finished processing.`)
+	require.Equal(t, models.NotificationProcessingDone, posted.Kind)
+	require.Equal(t, videoID, *posted.VideoID)
+
+	// kinds left to the defaults are saved in the app only
+	mailedTo = nil
+	repo.EXPECT().GetNotificationPreferences(gomock.Any(), userID).Return(db.GetNotificationPreferencesRow{
+		Email:    "owner@example.com",
+		Channels: []byte(`{"processing_done": ["email"]}`),
+	}, nil)
+	repo.EXPECT().
+		CreateNotification(gomock.Any(), db.CreateNotificationParams{UserID: userID, Kind: models.NotificationNewSubscriber, Message: "You have a new subscriber."}).
+		Return(nil)
+	n.notify(context.Background(), models.NotificationEvent{Kind: models.NotificationNewSubscriber, UserID: userID, Message: "You have a new subscriber."})
+	n.wait()
+	require.Nil(t, mailedTo)
+}
+
+func TestWebhooksCannotReachPrivateAddresses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("webhook reached a loopback address")
+	}))
+	defer server.Close()
+
+	n := newNotifier(slog.New(slog.NewTextHandler(io.Discard, nil)), nil, models.NotificationConfig{})
+	require.ErrorContains(t, n.post(server.URL, models.NotificationEvent{Kind: models.NotificationNewVideo}), "not https")
+	tlsServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("webhook reached a loopback address")
+	}))
+	defer tlsServer.Close()
+	require.ErrorContains(t, n.post(tlsServer.URL, models.NotificationEvent{Kind: models.NotificationNewVideo}), "not public")
+}
+
+func TestWebhooksDoNotFollowRedirects(t *testing.T) {
+	internal := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("webhook followed a redirect")
+	}))
+	defer internal.Close()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, internal.URL, http.StatusTemporaryRedirect)
+	}))
+	defer server.Close()
+
+	n := newNotifier(slog.New(slog.NewTextHandler(io.Discard, nil)), nil, models.NotificationConfig{AllowPrivateWebhooks: true})
+	require.ErrorContains(t, n.post(server.URL, models.NotificationEvent{Kind: models.NotificationNewVideo}), "307")
+}
+
+func TestNotifySubscribersByEmail(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	repo := mocks.NewMockVideoRepo(ctrl)
+	creator, videoID := uuid.New(), uuid.New()
+	var config models.NotificationConfig
+	config.SMTP.Host, config.SMTP.Port = "mail.example.com", 25
+	n := newNotifier(slog.New(slog.NewTextHandler(io.Discard, nil)), repo, config)
+	mailed := make(chan string, 2)
+	n.sendMail = func(_ string, _ smtp.Auth, _ string, to []string, _ []byte) error {
+		mailed <- to[0]
+		return nil
+	}
+
+	repo.EXPECT().CreateVideoNotifications(gomock.Any(), db.CreateVideoNotificationsParams{VideoID: videoID, CreatorID: creator}).Return(int64(2), nil)
+	repo.EXPECT().ListSubscriberPreferences(gomock.Any(), creator).Return([]db.ListSubscriberPreferencesRow{
+		{SubscriberID: uuid.New(), Email: "fan@example.com", Channels: []byte(`{"new_video": ["in_app", "email"]}`)},
+		{SubscriberID: uuid.New(), Email: "quiet@example.com", Channels: []byte(`{"new_video": []}`)},
+	}, nil)
+	// scheduled publications only carry the ids of the video
+	repo.EXPECT().GetVideo(gomock.Any(), videoID).Return(db.Video{ID: videoID, UserID: creator, Title: "clip"}, nil)
+	n.notifySubscribers(context.Background(), db.Video{ID: videoID, UserID: creator})
+	n.wait()
+	close(mailed)
+	var to []string
+	for address := range mailed {
+		to = append(to, address)
+	}
+	require.Equal(t, []string{"fan@example.com"}, to)
+}
+
+func TestNotifyProcessedSkipsReprocessing(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	repo := mocks.NewMockVideoRepo(ctrl)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	rc := &redisConsumer{logger: logger, db: repo, notifier: newNotifier(logger, repo, models.NotificationConfig{})}
+	owner, videoID := uuid.New(), uuid.New()
+
+	rc.notifyProcessed(context.Background(), map[string]interface{}{"video_id": videoID.String(), "reprocess_run_id": uuid.NewString()})
+
+	repo.EXPECT().GetVideo(gomock.Any(), videoID).Return(db.Video{ID: videoID, UserID: owner, Title: "clip"}, nil)
+	repo.EXPECT().GetNotificationPreferences(gomock.Any(), owner).Return(db.GetNotificationPreferencesRow{}, nil)
+	repo.EXPECT().
+		CreateNotification(gomock.Any(), db.CreateNotificationParams{
+			UserID:  owner,
+			Kind:    models.NotificationProcessingDone,
+			VideoID: pgtype.UUID{Bytes: videoID, Valid: true},
+			Message: `"clip" finished processing.`,
+		}).
+		Return(nil)
+	rc.notifyProcessed(context.Background(), map[string]interface{}{"video_id": videoID.String()})
+}
@@ -0,0 +1,84 @@
+package video
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"video-processing/models"
+)
+
+// buildOverlayFilter builds the filter_complex that composes input 1 over input 0.
+// The overlay is scaled relative to the base width keeping its own aspect ratio,
+// and an overlay video is delayed so it starts playing when it appears.
+func buildOverlayFilter(o models.OverlayRequest) string {
+	var chains []string
+	overlayIn := "[1:v]"
+	if !o.IsImage && o.Start > 0 {
+		chains = append(chains, fmt.Sprintf("[1:v]setpts=PTS-STARTPTS+%s/TB[delayed]", formatFactor(o.Start)))
+		overlayIn = "[delayed]"
+	}
+	chains = append(chains, fmt.Sprintf("%s[0:v]scale2ref=w=main_w*%s:h=ow/a[ov][base]", overlayIn, formatFactor(o.Scale)))
+
+	overlay := fmt.Sprintf("[base][ov]overlay=x=%d:y=%d", o.X, o.Y)
+	if o.IsImage {
+		// the looped image never ends, stop with the base video
+		overlay += ":shortest=1"
+	} else {
+		// a shorter overlay video disappears instead of freezing on its last frame
+		overlay += ":eof_action=pass"
+	}
+	switch {
+	case o.End > 0:
+		overlay += fmt.Sprintf(":enable='between(t,%s,%s)'", formatFactor(o.Start), formatFactor(o.End))
+	case o.Start > 0:
+		overlay += fmt.Sprintf(":enable='gte(t,%s)'", formatFactor(o.Start))
+	}
+	chains = append(chains, overlay+"[v]")
+	return strings.Join(chains, ";")
+}
+
+// renderOverlay composes overlayPath over basePath and writes an H.264/AAC MP4 to outPath.
+// The audio of the base video is kept.
+func renderOverlay(ctx context.Context, t Transcoder, basePath, overlayPath, outPath string, o models.OverlayRequest) error {
+	args := []string{
+		"-y",
+		"-nostdin",
+		"-i", basePath,
+	}
+	if o.IsImage {
+		args = append(args, "-loop", "1")
+	}
+	args = append(args,
+		"-i", overlayPath,
+		"-filter_complex", buildOverlayFilter(o),
+		"-map", "[v]",
+		"-map", "0:a?",
+		"-c:v", "libx264",
+		"-crf", "18",
+		"-preset", "fast",
+		"-c:a", "aac",
+		"-movflags", "+faststart",
+		outPath,
+	)
+	if err := t.Run(ctx, args...); err != nil {
+		return fmt.Errorf("ffmpeg overlay error: %w", err)
+	}
+	return nil
+}
+
+// RenderOverlay composes the overlay source of the recipe over the parent video and
+// processes the result as the derived video's source.
+func (rc *redisConsumer) RenderOverlay(ctx context.Context, values map[string]interface{}) error {
+	return rc.renderDerived(ctx, values, func(ctx context.Context, job derivedJob) error {
+		o := job.Recipe.Overlay
+		if o == nil {
+			return fmt.Errorf("overlay job carries no overlay recipe")
+		}
+		overlayPath := filepath.Join(job.WorkDir, "overlay"+filepath.Ext(o.OverlayKey))
+		if err := downloadFromMinio(ctx, rc.mc, o.OverlayBucket, o.OverlayKey, overlayPath); err != nil {
+			return fmt.Errorf("failed to download overlay source: %w", err)
+		}
+		return renderOverlay(ctx, rc.transcoder, job.SourcePath, overlayPath, job.OutPath, *o)
+	})
+}
@@ -0,0 +1,41 @@
+package video
+
+import (
+	"testing"
+	"video-processing/models"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildOverlayFilter(t *testing.T) {
+	testCases := []struct {
+		name    string
+		overlay models.OverlayRequest
+		want    string
+	}{
+		{
+			name:    "image for the whole video",
+			overlay: models.OverlayRequest{IsImage: true, X: 20, Y: 10, Scale: 0.2},
+			want: "[1:v][0:v]scale2ref=w=main_w*0.2:h=ow/a[ov][base];" +
+				"[base][ov]overlay=x=20:y=10:shortest=1[v]",
+		},
+		{
+			name:    "image within a time range",
+			overlay: models.OverlayRequest{IsImage: true, Scale: 0.5, Start: 2, End: 7.5},
+			want: "[1:v][0:v]scale2ref=w=main_w*0.5:h=ow/a[ov][base];" +
+				"[base][ov]overlay=x=0:y=0:shortest=1:enable='between(t,2,7.5)'[v]",
+		},
+		{
+			name:    "picture in picture starting later",
+			overlay: models.OverlayRequest{X: 100, Y: 50, Scale: 0.25, Start: 3},
+			want: "[1:v]setpts=PTS-STARTPTS+3/TB[delayed];" +
+				"[delayed][0:v]scale2ref=w=main_w*0.25:h=ow/a[ov][base];" +
+				"[base][ov]overlay=x=100:y=50:eof_action=pass:enable='gte(t,3)'[v]",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, buildOverlayFilter(tc.overlay))
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package video
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	// perTitleSamples is the number of excerpts probe encoded to measure the complexity of a source
+	perTitleSamples = 3
+	// perTitleSampleSeconds is the length of each excerpt
+	perTitleSampleSeconds = 4.0
+	// perTitleCRF is the constant quality of the probe encodes, the quality the default ladder
+	// aims for at its bitrates
+	perTitleCRF = 23
+	// the bitrates of the ladder are scaled by at least perTitleMinFactor and at most
+	// perTitleMaxFactor, so a black screen or confetti do not produce absurd ladders
+	perTitleMinFactor = 0.4
+	perTitleMaxFactor = 1.5
+	// perTitleMinKbps is the lowest bitrate a scaled rung gets
+	perTitleMinKbps = 50
+)
+
+// measureComplexity probe encodes excerpts of the source at the resolution of ref with a
+// constant quality and compares the bitrate they take with the bitrate of ref. The factor is
+// below 1 for content that reaches the quality with fewer bits (slides, animation, static
+// shots) and above it for content that needs more (sports, grain, water).
+func measureComplexity(ctx context.Context, t Transcoder, sourcePath, workDir string, duration float64, ref Variant) (float64, error) {
+	refKbps, err := strconv.ParseFloat(strings.TrimSuffix(ref.Bitrate, "k"), 64)
+	if err != nil || refKbps <= 0 {
+		return 0, fmt.Errorf("invalid bitrate %q of %s", ref.Bitrate, ref.Name)
+	}
+	var bytes int64
+	var seconds float64
+	for i, start := range perTitleSampleStarts(duration) {
+		length := math.Min(perTitleSampleSeconds, duration-start)
+		samplePath := filepath.Join(workDir, fmt.Sprintf("pertitle-%d.mp4", i))
+		// ffmpeg -ss START -t 4 -i input -vf scale=W:H -c:v libx264 -crf 23 -preset veryfast -an sample.mp4
+		err := t.Run(ctx,
+			"-y",
+			"-nostdin",
+			"-ss", fmt.Sprintf("%.3f", start),
+			"-t", fmt.Sprintf("%.3f", length),
+			"-i", sourcePath,
+			"-vf", fmt.Sprintf("scale=%d:%d", ref.Width, ref.Height),
+			"-c:v", "libx264",
+			"-crf", strconv.Itoa(perTitleCRF),
+			"-preset", "veryfast",
+			"-an",
+			samplePath,
+		)
+		if err != nil {
+			return 0, fmt.Errorf("probe encode failed: %w", err)
+		}
+		info, err := os.Stat(samplePath)
+		if err != nil {
+			return 0, err
+		}
+		os.Remove(samplePath)
+		bytes += info.Size()
+		seconds += length
+	}
+	if seconds <= 0 {
+		return 0, fmt.Errorf("source too short to measure")
+	}
+	kbps := float64(bytes) * 8 / 1000 / seconds
+	return math.Max(perTitleMinFactor, math.Min(perTitleMaxFactor, kbps/refKbps)), nil
+}
+
+// perTitleSampleStarts spreads the excerpts evenly over the source, away from its start and
+// end, which are often titles or black. Short sources are measured whole.
+func perTitleSampleStarts(duration float64) []float64 {
+	if duration <= 0 {
+		return nil
+	}
+	if duration <= perTitleSamples*perTitleSampleSeconds*2 {
+		return []float64{0}
+	}
+	starts := make([]float64, perTitleSamples)
+	for i := range starts {
+		starts[i] = duration*float64(i+1)/(perTitleSamples+1) - perTitleSampleSeconds/2
+	}
+	return starts
+}
+
+// perTitleReference is the rung the source is measured at, the largest regular one
+func perTitleReference(variants []Variant) (Variant, bool) {
+	var ref Variant
+	found := false
+	for _, v := range variants {
+		if v.HDR || v.Vertical || v.hevc() {
+			continue
+		}
+		if !found || v.Width*v.Height > ref.Width*ref.Height {
+			ref, found = v, true
+		}
+	}
+	return ref, found
+}
+
+// perTitleLadder scales the bitrates of the variants by factor, rounded to 10k. CRF rungs keep
+// the scaled bitrate as their cap.
+func perTitleLadder(variants []Variant, factor float64) []Variant {
+	scaled := make([]Variant, 0, len(variants))
+	for _, v := range variants {
+		if kbps, err := strconv.ParseFloat(strings.TrimSuffix(v.Bitrate, "k"), 64); err == nil {
+			kbps = math.Max(perTitleMinKbps, math.Round(kbps*factor/10)*10)
+			v.Bitrate = fmt.Sprintf("%dk", int64(kbps))
+		}
+		scaled = append(scaled, v)
+	}
+	return scaled
+}
+
+// perTitle fits the bitrates of the job's variants to the complexity of the source. The ladder
+// is left alone when the source cannot be measured.
+func (rc *redisConsumer) perTitle(ctx context.Context, videoID, sourcePath, workDir string, duration float64, hdrFormat string, variants []Variant) []Variant {
+	ref, ok := perTitleReference(variants)
+	if !ok || hdrFormat != "" {
+		// HDR excerpts would need tone mapping to be comparable with the SDR ladder
+		return variants
+	}
+	factor, err := measureComplexity(ctx, rc.transcoder, sourcePath, workDir, duration, ref)
+	if err != nil {
+		rc.logger.Warn("complexity measurement failed, keeping the preset bitrates", "error", err, "videoID", videoID)
+		return variants
+	}
+	scaled := perTitleLadder(variants, factor)
+	bitrates := make([]string, 0, len(scaled))
+	for _, v := range scaled {
+		bitrates = append(bitrates, v.Name+"="+v.Bitrate)
+	}
+	rc.logger.Info("per-title ladder", "videoID", videoID, "factor", factor, "bitrates", bitrates)
+	return scaled
+}
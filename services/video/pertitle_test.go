@@ -0,0 +1,93 @@
+package video
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// sizedTranscoder writes outputs of a given size, like encodes of content of some complexity
+type sizedTranscoder struct {
+	*FakeTranscoder
+	size int
+}
+
+func (s sizedTranscoder) Run(ctx context.Context, args ...string) error {
+	if err := s.FakeTranscoder.Run(ctx, args...); err != nil {
+		return err
+	}
+	return os.WriteFile(args[len(args)-1], make([]byte, s.size), 0o644)
+}
+
+func TestPerTitleSampleStarts(t *testing.T) {
+	require.Empty(t, perTitleSampleStarts(0))
+	require.Equal(t, []float64{0}, perTitleSampleStarts(20))
+	require.Equal(t, []float64{23, 48, 73}, perTitleSampleStarts(100))
+}
+
+func TestMeasureComplexity(t *testing.T) {
+	ref := Variant{Name: "1080p", Width: 1920, Height: 1080, Bitrate: "4000k"}
+	// 3 excerpts of 4 seconds at 1000 kbps
+	transcoder := sizedTranscoder{FakeTranscoder: NewFakeTranscoder(), size: 500000}
+	factor, err := measureComplexity(context.Background(), transcoder, "source.mp4", t.TempDir(), 100, ref)
+	require.NoError(t, err)
+	require.InDelta(t, 0.4, factor, 0.001)
+	calls := transcoder.Calls()
+	require.Len(t, calls, 3)
+	require.Contains(t, calls[0], "scale=1920:1080")
+	require.Contains(t, calls[0], "23.000")
+
+	transcoder.size = 1500000 // 3000 kbps
+	factor, err = measureComplexity(context.Background(), transcoder, "source.mp4", t.TempDir(), 100, ref)
+	require.NoError(t, err)
+	require.InDelta(t, 0.75, factor, 0.001)
+
+	// beyond the bounds
+	transcoder.size = 10000000
+	factor, err = measureComplexity(context.Background(), transcoder, "source.mp4", t.TempDir(), 100, ref)
+	require.NoError(t, err)
+	require.Equal(t, perTitleMaxFactor, factor)
+
+	// short sources are measured whole
+	transcoder.size = 100000
+	factor, err = measureComplexity(context.Background(), transcoder, "source.mp4", t.TempDir(), 2, ref)
+	require.NoError(t, err)
+	require.Equal(t, perTitleMinFactor, factor)
+
+	_, err = measureComplexity(context.Background(), transcoder, "source.mp4", t.TempDir(), 0, ref)
+	require.Error(t, err)
+}
+
+func TestPerTitleLadder(t *testing.T) {
+	ref, ok := perTitleReference(testLadder.all())
+	require.True(t, ok)
+	require.Equal(t, "1080p", ref.Name)
+
+	scaled := perTitleLadder(testLadder.regular, 0.75)
+	var bitrates []string
+	for _, v := range scaled {
+		bitrates = append(bitrates, v.Bitrate)
+	}
+	require.Equal(t, []string{"3000k", "1500k", "750k", "380k", "190k", "80k"}, bitrates)
+	require.Equal(t, "4000k", testLadder.regular[0].Bitrate, "the preset ladder is left alone")
+	require.Equal(t, "50k", perTitleLadder(testLadder.regular[5:], 0.4)[0].Bitrate)
+}
+
+func TestPerTitle(t *testing.T) {
+	fake := NewFakeTranscoder()
+	rc := &redisConsumer{logger: slog.New(slog.NewTextHandler(io.Discard, nil)), transcoder: sizedTranscoder{FakeTranscoder: fake, size: 1500000}}
+
+	variants := rc.perTitle(context.Background(), "video", "source.mp4", t.TempDir(), 100, "", testLadder.regular)
+	require.Equal(t, "3000k", variants[0].Bitrate)
+
+	// HDR sources and failed measurements keep the preset bitrates
+	variants = rc.perTitle(context.Background(), "video", "source.mp4", t.TempDir(), 100, HDRFormatHDR10, testLadder.regular)
+	require.Equal(t, testLadder.regular, variants)
+	fake.FailOn = "libx264"
+	variants = rc.perTitle(context.Background(), "video", "source.mp4", t.TempDir(), 100, "", testLadder.regular)
+	require.Equal(t, testLadder.regular, variants)
+}
@@ -0,0 +1,235 @@
+package video
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+	"video-processing/database/db"
+	"video-processing/models"
+	"video-processing/utils"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	// maxPlaybackFailures is how many wrong passwords a client may send for a video per window
+	maxPlaybackFailures   = 5
+	playbackFailureWindow = 15 * time.Minute
+)
+
+// SetPlaybackPassword protects a video of the user with a password. Anyone who knows it can
+// play the video, even without an account or while it is private.
+func (vp *videoProcessor) SetPlaybackPassword(ctx context.Context, userID, videoID uuid.UUID, req models.PlaybackPasswordRequest) (models.VideoDetail, error) {
+	params := fmt.Sprintf("userID: %v, videoID: %v", userID, videoID)
+	if err := req.Validate(); err != nil {
+		return models.VideoDetail{}, models.Error{
+			Code:    http.StatusBadRequest,
+			Message: "invalid input data",
+			Params:  params,
+			Err:     err,
+		}
+	}
+	if _, err := vp.getOwnedVideo(ctx, userID, videoID); err != nil {
+		return models.VideoDetail{}, err
+	}
+	hash, err := utils.HashPassword(req.Password)
+	if err != nil {
+		return models.VideoDetail{}, err
+	}
+	return vp.setPlaybackPassword(ctx, userID, videoID, pgtype.Text{String: hash, Valid: true})
+}
+
+// RemovePlaybackPassword lifts the password of a video of the user
+func (vp *videoProcessor) RemovePlaybackPassword(ctx context.Context, userID, videoID uuid.UUID) (models.VideoDetail, error) {
+	if _, err := vp.getOwnedVideo(ctx, userID, videoID); err != nil {
+		return models.VideoDetail{}, err
+	}
+	return vp.setPlaybackPassword(ctx, userID, videoID, pgtype.Text{})
+}
+
+func (vp *videoProcessor) setPlaybackPassword(ctx context.Context, userID, videoID uuid.UUID, hash pgtype.Text) (models.VideoDetail, error) {
+	_, err := vp.db.SetVideoPlaybackPassword(ctx, db.SetVideoPlaybackPasswordParams{PlaybackPasswordHash: hash, ID: videoID})
+	if err != nil {
+		return models.VideoDetail{}, models.IndentifyDbError(err).AddParams(fmt.Sprintf("userID: %v, videoID: %v", userID, videoID))
+	}
+	return vp.GetVideo(ctx, userID, videoID, nil)
+}
+
+// AuthorizePlayback issues a playback token for a public video, or for a protected video
+// given its password. A client that sends too many wrong passwords is locked out of the
+// video for the rest of the window.
+func (vp *videoProcessor) AuthorizePlayback(ctx context.Context, videoID uuid.UUID, client string, req models.PlaybackRequest) (models.PlaybackToken, error) {
+	params := fmt.Sprintf("videoID: %v, client: %v", videoID, client)
+	video, err := vp.getPlayableVideo(ctx, videoID)
+	if err != nil {
+		return models.PlaybackToken{}, err
+	}
+	if video.PlaybackPasswordHash.Valid {
+		if err := vp.checkPlaybackPassword(ctx, video, client, req.Password); err != nil {
+			return models.PlaybackToken{}, err
+		}
+	}
+	token, err := vp.playbackTokens.CreateToken(utils.Payload{
+		ID:       videoID,
+		IssuedAt: time.Now(),
+		Purpose:  utils.PurposePlayback,
+	})
+	if err != nil {
+		var e models.Error
+		if errors.As(err, &e) {
+			return models.PlaybackToken{}, e.AddParams(params)
+		}
+		return models.PlaybackToken{}, err
+	}
+	return models.PlaybackToken{Token: token}, nil
+}
+
+// checkPlaybackPassword compares the password of a protected video, counting failures of the client
+func (vp *videoProcessor) checkPlaybackPassword(ctx context.Context, video db.Video, client, password string) error {
+	params := fmt.Sprintf("videoID: %v, client: %v", video.ID, client)
+	windowStart := time.Now().Add(-playbackFailureWindow)
+	failures, err := vp.db.GetPlaybackFailures(ctx, db.GetPlaybackFailuresParams{VideoID: video.ID, Client: client, WindowStart: windowStart})
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return models.IndentifyDbError(err).AddParams(params)
+	}
+	if failures >= maxPlaybackFailures {
+		return models.Error{
+			Code:        http.StatusTooManyRequests,
+			Message:     "too many attempts",
+			Description: fmt.Sprintf("try again in %v", playbackFailureWindow),
+			Params:      params,
+			Err:         errors.New("playback password attempts exhausted"),
+		}
+	}
+	if !utils.CheckPassword(video.PlaybackPasswordHash.String, password) {
+		_, err := vp.db.RecordPlaybackFailure(ctx, db.RecordPlaybackFailureParams{VideoID: video.ID, Client: client, WindowStart: windowStart})
+		if err != nil {
+			return models.IndentifyDbError(err).AddParams(params)
+		}
+		return models.Error{
+			Code:    http.StatusUnauthorized,
+			Message: "wrong password",
+			Params:  params,
+			Err:     errors.New("playback password mismatch"),
+		}
+	}
+	if failures > 0 {
+		if err := vp.db.ClearPlaybackFailures(ctx, db.ClearPlaybackFailuresParams{VideoID: video.ID, Client: client}); err != nil {
+			vp.logger.Error("failed to clear playback failures", "error", err, "videoID", video.ID)
+		}
+	}
+	return nil
+}
+
+// FormatHLS is the format of the variants an encrypted video is played from, their HLS playlists
+const FormatHLS = "hls"
+
+// Playback returns presigned URLs of the variants and subtitles of a video for the holder of a
+// playback token. Encrypted videos are played from their HLS playlists only, the clear files of
+// their variants are not handed out.
+func (vp *videoProcessor) Playback(ctx context.Context, videoID uuid.UUID, token string) (models.Playback, error) {
+	params := fmt.Sprintf("videoID: %v", videoID)
+	if err := vp.verifyPlaybackToken(videoID, token); err != nil {
+		return models.Playback{}, err
+	}
+	video, err := vp.getPlayableVideo(ctx, videoID)
+	if err != nil {
+		return models.Playback{}, err
+	}
+	variants, err := vp.db.ListVideoVariants(ctx, videoID)
+	if err != nil {
+		return models.Playback{}, models.IndentifyDbError(err).AddParams(params)
+	}
+	subtitles, err := vp.db.ListVideoSubtitles(ctx, videoID)
+	if err != nil {
+		return models.Playback{}, models.IndentifyDbError(err).AddParams(params)
+	}
+	encrypted, err := vp.isEncrypted(ctx, videoID)
+	if err != nil {
+		return models.Playback{}, err
+	}
+	playback := models.Playback{
+		VideoID:   videoID,
+		Title:     video.Title,
+		Variants:  make([]models.PlaybackVariant, 0, len(variants)),
+		Subtitles: make([]models.PlaybackSubtitle, 0, len(subtitles)),
+		ExpiresAt: time.Now().Add(vp.urlExpiry),
+	}
+	if encrypted {
+		playback.KeyURL = playbackKeyURI(vp.keyURL, videoID, token)
+	}
+	for _, v := range variants {
+		key, format, contentType := v.Key, v.Format, v.ContentType
+		if encrypted {
+			if !v.HlsPlaylistKey.Valid {
+				continue
+			}
+			key, format, contentType = v.HlsPlaylistKey.String, FormatHLS, mimeTypeByExt(".m3u8")
+		}
+		url, err := vp.getVideoURL(ctx, v.Bucket, key, vp.urlExpiry)
+		if err != nil {
+			return models.Playback{}, err
+		}
+		playback.Variants = append(playback.Variants, models.PlaybackVariant{
+			Name:           v.VariantName,
+			Width:          v.Width.Int32,
+			Height:         v.Height.Int32,
+			BitrateKbps:    v.BitrateKbps.Int32,
+			Format:         format,
+			ContentType:    contentType,
+			URL:            url,
+			AudioLanguages: v.AudioLanguages,
+		})
+	}
+	for _, s := range subtitles {
+		url, err := vp.getVideoURL(ctx, s.Bucket, s.Key, vp.urlExpiry)
+		if err != nil {
+			return models.Playback{}, err
+		}
+		playback.Subtitles = append(playback.Subtitles, models.PlaybackSubtitle{
+			Language: s.Language,
+			Label:    s.Label,
+			Forced:   s.Forced,
+			URL:      url,
+		})
+	}
+	return playback, nil
+}
+
+// verifyPlaybackToken checks that token is a playback token of the video
+func (vp *videoProcessor) verifyPlaybackToken(videoID uuid.UUID, token string) error {
+	payload, err := vp.playbackTokens.VerifyToken(token)
+	if err != nil || payload.Purpose != utils.PurposePlayback || payload.ID != videoID {
+		return models.Error{
+			Code:        http.StatusUnauthorized,
+			Message:     "access denied",
+			Description: "invalid playback token",
+			Params:      fmt.Sprintf("videoID: %v", videoID),
+			Err:         errors.Join(errors.New("invalid playback token"), err),
+		}
+	}
+	return nil
+}
+
+// getPlayableVideo returns a video that can be played without an account: public or password
+// protected, and not moderated away
+func (vp *videoProcessor) getPlayableVideo(ctx context.Context, videoID uuid.UUID) (db.Video, error) {
+	video, err := vp.db.GetVideo(ctx, videoID)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return db.Video{}, models.IndentifyDbError(err).AddParams(fmt.Sprintf("videoID: %v", videoID))
+	}
+	moderated := video.Visibility == models.VisibilityHidden || video.Visibility == models.VisibilityRemoved
+	if err != nil || moderated || (video.Visibility != models.VisibilityPublic && !video.PlaybackPasswordHash.Valid) {
+		return db.Video{}, models.Error{
+			Code:    http.StatusNotFound,
+			Message: "resource not found",
+			Params:  fmt.Sprintf("videoID: %v", videoID),
+			Err:     models.ErrResourceNotFound,
+		}
+	}
+	return video, nil
+}
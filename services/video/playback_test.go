@@ -0,0 +1,147 @@
+package video
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+	"video-processing/database/db"
+	"video-processing/mocks"
+	"video-processing/models"
+	"video-processing/utils"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/o1egl/paseto"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func newPlaybackProcessor(t *testing.T) (VideoProcessor, *mocks.MockVideoRepo, *mocks.MockObjectStore) {
+	ctrl := gomock.NewController(t)
+	repo := mocks.NewMockVideoRepo(ctrl)
+	store := mocks.NewMockObjectStore(ctrl)
+	tokens := utils.NewTokenManager("qwertyuiopasdfghjklzxcvbnm123456", time.Hour, *paseto.NewV2())
+	vp := NewVideoProcessor(slog.New(slog.NewTextHandler(io.Discard, nil)), store, repo, mocks.NewMockStreamer(ctrl), NewFakeTranscoder(), VideoProcessorOptions{URLExpiry: time.Hour, PlaybackTokens: tokens})
+	return vp, repo, store
+}
+
+func TestSetPlaybackPassword(t *testing.T) {
+	vp, repo, _ := newPlaybackProcessor(t)
+	owner, videoID := uuid.New(), uuid.New()
+	video := db.Video{ID: videoID, UserID: owner}
+
+	_, err := vp.SetPlaybackPassword(context.Background(), owner, videoID, models.PlaybackPasswordRequest{Password: "abc"})
+	var e models.Error
+	require.ErrorAs(t, err, &e)
+	require.Equal(t, http.StatusBadRequest, e.Code)
+
+	repo.EXPECT().GetVideo(gomock.Any(), videoID).Return(video, nil)
+	repo.EXPECT().
+		SetVideoPlaybackPassword(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, arg db.SetVideoPlaybackPasswordParams) (db.Video, error) {
+			// only the hash is stored
+			require.True(t, utils.CheckPassword(arg.PlaybackPasswordHash.String, "secret"))
+			video.PlaybackPasswordHash = arg.PlaybackPasswordHash
+			return video, nil
+		})
+	repo.EXPECT().GetVideo(gomock.Any(), videoID).DoAndReturn(func(context.Context, uuid.UUID) (db.Video, error) { return video, nil }).Times(2)
+	repo.EXPECT().ListVideoVariants(gomock.Any(), videoID).Return(nil, nil)
+	repo.EXPECT().ListVideoAssets(gomock.Any(), videoID).Return(nil, nil)
+	repo.EXPECT().ListVideoThumbnails(gomock.Any(), videoID).Return(nil, nil)
+	repo.EXPECT().ListVideoProgress(gomock.Any(), videoID).Return(nil, nil)
+	repo.EXPECT().ListVideoChapters(gomock.Any(), videoID).Return(nil, nil)
+	detail, err := vp.SetPlaybackPassword(context.Background(), owner, videoID, models.PlaybackPasswordRequest{Password: "secret"})
+	require.NoError(t, err)
+	require.True(t, detail.PasswordProtected)
+}
+
+func TestAuthorizePlayback(t *testing.T) {
+	vp, repo, store := newPlaybackProcessor(t)
+	videoID := uuid.New()
+	hash, err := utils.HashPassword("secret")
+	require.NoError(t, err)
+	protected := db.Video{ID: videoID, Title: "clip", Visibility: models.VisibilityPrivate, PlaybackPasswordHash: pgtype.Text{String: hash, Valid: true}}
+	failures := db.GetPlaybackFailuresParams{VideoID: videoID, Client: "10.0.0.1"}
+	var e models.Error
+
+	// private videos without a password are not played without an account
+	repo.EXPECT().GetVideo(gomock.Any(), videoID).Return(db.Video{ID: videoID, Visibility: models.VisibilityPrivate}, nil)
+	_, err = vp.AuthorizePlayback(context.Background(), videoID, "10.0.0.1", models.PlaybackRequest{})
+	require.ErrorAs(t, err, &e)
+	require.Equal(t, http.StatusNotFound, e.Code)
+
+	repo.EXPECT().GetVideo(gomock.Any(), videoID).Return(protected, nil).AnyTimes()
+	repo.EXPECT().
+		GetPlaybackFailures(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, arg db.GetPlaybackFailuresParams) (int32, error) {
+			require.Equal(t, failures.Client, arg.Client)
+			require.WithinDuration(t, time.Now().Add(-playbackFailureWindow), arg.WindowStart, time.Minute)
+			return 0, pgx.ErrNoRows
+		})
+	repo.EXPECT().RecordPlaybackFailure(gomock.Any(), gomock.Any()).Return(int32(1), nil)
+	_, err = vp.AuthorizePlayback(context.Background(), videoID, "10.0.0.1", models.PlaybackRequest{Password: "guess"})
+	require.ErrorAs(t, err, &e)
+	require.Equal(t, http.StatusUnauthorized, e.Code)
+
+	// locked out even with the right password
+	repo.EXPECT().GetPlaybackFailures(gomock.Any(), gomock.Any()).Return(int32(maxPlaybackFailures), nil)
+	_, err = vp.AuthorizePlayback(context.Background(), videoID, "10.0.0.1", models.PlaybackRequest{Password: "secret"})
+	require.ErrorAs(t, err, &e)
+	require.Equal(t, http.StatusTooManyRequests, e.Code)
+
+	repo.EXPECT().GetPlaybackFailures(gomock.Any(), gomock.Any()).Return(int32(2), nil)
+	repo.EXPECT().ClearPlaybackFailures(gomock.Any(), db.ClearPlaybackFailuresParams{VideoID: videoID, Client: "10.0.0.1"}).Return(nil)
+	token, err := vp.AuthorizePlayback(context.Background(), videoID, "10.0.0.1", models.PlaybackRequest{Password: "secret"})
+	require.NoError(t, err)
+
+	// tokens only play the video they were issued for
+	_, err = vp.Playback(context.Background(), uuid.New(), token.Token)
+	require.ErrorAs(t, err, &e)
+	require.Equal(t, http.StatusUnauthorized, e.Code)
+
+	variantURL, _ := url.Parse("http://minio/user/processed/720p.mp4")
+	repo.EXPECT().ListVideoVariants(gomock.Any(), videoID).Return([]db.VideoVariant{{VariantName: "720p", Bucket: "user", Key: "processed/720p.mp4"}}, nil)
+	store.EXPECT().PresignedGetObject(gomock.Any(), "user", "processed/720p.mp4", time.Hour, nil).Return(variantURL, nil)
+	subtitleURL, _ := url.Parse("http://minio/user/processed/subtitles/0-eng.vtt")
+	repo.EXPECT().ListVideoSubtitles(gomock.Any(), videoID).Return([]db.VideoSubtitle{{Language: "eng", Label: "English", Bucket: "user", Key: "processed/subtitles/0-eng.vtt"}}, nil)
+	store.EXPECT().PresignedGetObject(gomock.Any(), "user", "processed/subtitles/0-eng.vtt", time.Hour, nil).Return(subtitleURL, nil)
+	repo.EXPECT().GetVideoKey(gomock.Any(), videoID).Return(nil, pgx.ErrNoRows)
+	playback, err := vp.Playback(context.Background(), videoID, token.Token)
+	require.NoError(t, err)
+	require.Equal(t, "clip", playback.Title)
+	require.Equal(t, variantURL.String(), playback.Variants[0].URL)
+	require.Empty(t, playback.KeyURL)
+	require.Equal(t, []models.PlaybackSubtitle{{Language: "eng", Label: "English", URL: subtitleURL.String()}}, playback.Subtitles)
+}
+
+func TestPlaybackEncrypted(t *testing.T) {
+	vp, repo, store := newPlaybackProcessor(t)
+	vp.(*videoProcessor).keyURL = "https://api.example.com"
+	videoID := uuid.New()
+	repo.EXPECT().GetVideo(gomock.Any(), videoID).Return(db.Video{ID: videoID, Title: "clip", Visibility: models.VisibilityPublic}, nil)
+	repo.EXPECT().ListVideoVariants(gomock.Any(), videoID).Return([]db.VideoVariant{
+		{VariantName: "720p", Bucket: "user", Key: "processed/720p/720p.mp4", Format: FormatMP4, ContentType: "video/mp4",
+			HlsPlaylistKey: pgtype.Text{String: "processed/720p/index.m3u8", Valid: true}},
+		{VariantName: "720p", Bucket: "user", Key: "processed/720p/720p.webm", Format: FormatWebM, ContentType: "video/webm"},
+	}, nil)
+	repo.EXPECT().ListVideoSubtitles(gomock.Any(), videoID).Return(nil, nil)
+	repo.EXPECT().GetVideoKey(gomock.Any(), videoID).Return([]byte("wrapped"), nil)
+	playlistURL, _ := url.Parse("http://minio/user/processed/720p/index.m3u8")
+	store.EXPECT().PresignedGetObject(gomock.Any(), "user", "processed/720p/index.m3u8", time.Hour, nil).Return(playlistURL, nil)
+	token, err := vp.(*videoProcessor).playbackTokens.CreateToken(utils.Payload{ID: videoID, IssuedAt: time.Now(), Purpose: utils.PurposePlayback})
+	require.NoError(t, err)
+
+	// the clear MP4 and WebM are not handed out, only the playlist of the encrypted segments
+	playback, err := vp.Playback(context.Background(), videoID, token)
+	require.NoError(t, err)
+	require.Len(t, playback.Variants, 1)
+	require.Equal(t, FormatHLS, playback.Variants[0].Format)
+	require.Equal(t, "application/vnd.apple.mpegurl", playback.Variants[0].ContentType)
+	require.Equal(t, playlistURL.String(), playback.Variants[0].URL)
+	require.Equal(t, "https://api.example.com/v1/videos/"+videoID.String()+"/key?token="+url.QueryEscape(token), playback.KeyURL)
+}
@@ -0,0 +1,344 @@
+package video
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"video-processing/database/db"
+	"video-processing/models"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// defaultSegmentSeconds is the HLS segment length of presets that set none
+const defaultSegmentSeconds = 6
+
+// CRF of the rungs of crf presets that set none, about the quality of the default bitrates.
+// x265 reaches the quality of x264 at a higher CRF.
+const (
+	defaultCRFH264 = 23
+	defaultCRFHEVC = 28
+)
+
+// ladder is the rendition ladder of a job, split by the sources each family applies to
+type ladder struct {
+	regular  []Variant
+	hdr      []Variant // added for HDR sources
+	vertical []Variant // added for landscape sources when vertical variants are enabled
+}
+
+// all is every variant of the ladder, whatever the source
+func (l ladder) all() []Variant {
+	return append(append(append([]Variant{}, l.regular...), l.hdr...), l.vertical...)
+}
+
+// newLadder turns the variants of a preset into the variants the worker encodes
+func newLadder(preset models.TranscodingPreset) ladder {
+	var l ladder
+	for _, pv := range preset.Variants {
+		v := Variant{
+			Name:           pv.Name,
+			Width:          pv.Width,
+			Height:         pv.Height,
+			Bitrate:        pv.Bitrate,
+			HDR:            pv.HDR,
+			Vertical:       pv.Vertical,
+			Codec:          pv.Codec,
+			CRF:            variantCRF(preset, pv),
+			EncoderPreset:  pv.EncoderPreset,
+			Profile:        pv.Profile,
+			Level:          pv.Level,
+			Tune:           pv.Tune,
+			SegmentSeconds: preset.HLS.SegmentSeconds,
+		}
+		switch {
+		case v.HDR:
+			l.hdr = append(l.hdr, v)
+		case v.Vertical:
+			l.vertical = append(l.vertical, v)
+		default:
+			l.regular = append(l.regular, v)
+		}
+	}
+	return l
+}
+
+// variantCRF is the constant quality of a rung of the preset, 0 to encode it at its bitrate
+func variantCRF(preset models.TranscodingPreset, pv models.PresetVariant) int {
+	if pv.CRF > 0 || preset.RateControl.Mode != models.RateControlCRF {
+		return pv.CRF
+	}
+	switch {
+	case preset.RateControl.CRF > 0:
+		return preset.RateControl.CRF
+	case pv.HDR || pv.Codec == models.CodecHEVC:
+		return defaultCRFHEVC
+	}
+	return defaultCRFH264
+}
+
+func presetFromRow(row db.TranscodingPreset) (models.TranscodingPreset, error) {
+	preset := models.TranscodingPreset{
+		ID:          row.ID,
+		Name:        row.Name,
+		Description: row.Description,
+		IsDefault:   row.IsDefault,
+		HLS:         models.HLSOptions{SegmentSeconds: int(row.HlsSegmentSeconds)},
+		RateControl: models.RateControlOptions{Mode: row.RateControl, CRF: int(row.Crf)},
+		CreatedAt:   row.CreatedAt,
+		UpdatedAt:   row.UpdatedAt,
+	}
+	if err := json.Unmarshal(row.Variants, &preset.Variants); err != nil {
+		return preset, fmt.Errorf("invalid variants of preset %s: %w", row.Name, err)
+	}
+	return preset, nil
+}
+
+// loadLadder reads the ladder of the preset the job names, of the preset of the job's
+// processing mode, or of the default preset. Jobs whose preset was deleted since they were
+// queued fall back to the default.
+func (rc *redisConsumer) loadLadder(ctx context.Context, values map[string]interface{}) (ladder, error) {
+	var row db.TranscodingPreset
+	err := pgx.ErrNoRows
+	if id, parseErr := uuid.Parse(fmt.Sprint(values["preset_id"])); parseErr == nil {
+		row, err = rc.db.GetTranscodingPreset(ctx, id)
+		if errors.Is(err, pgx.ErrNoRows) {
+			rc.logger.Warn("preset of job no longer exists, using the default preset", "presetID", id, "videoID", values["video_id"])
+		}
+	} else if modeName, mode := rc.jobMode(values); mode.Preset != "" {
+		row, err = rc.db.GetTranscodingPresetByName(ctx, mode.Preset)
+		if errors.Is(err, pgx.ErrNoRows) {
+			rc.logger.Warn("preset of processing mode does not exist, using the default preset", "mode", modeName, "preset", mode.Preset, "videoID", values["video_id"])
+		}
+	}
+	if errors.Is(err, pgx.ErrNoRows) {
+		row, err = rc.db.GetDefaultTranscodingPreset(ctx)
+	}
+	if err != nil {
+		return ladder{}, fmt.Errorf("failed to load transcoding preset: %w", err)
+	}
+	preset, err := presetFromRow(row)
+	if err != nil {
+		return ladder{}, err
+	}
+	return newLadder(preset), nil
+}
+
+func (vp *videoProcessor) ListPresets(ctx context.Context) ([]models.TranscodingPreset, error) {
+	rows, err := vp.db.ListTranscodingPresets(ctx)
+	if err != nil {
+		return nil, models.IndentifyDbError(err)
+	}
+	presets := make([]models.TranscodingPreset, 0, len(rows))
+	for _, row := range rows {
+		preset, err := presetFromRow(row)
+		if err != nil {
+			return nil, models.IndentifyDbError(err)
+		}
+		presets = append(presets, preset)
+	}
+	return presets, nil
+}
+
+func (vp *videoProcessor) GetPreset(ctx context.Context, id uuid.UUID) (models.TranscodingPreset, error) {
+	row, err := vp.db.GetTranscodingPreset(ctx, id)
+	if err != nil {
+		return models.TranscodingPreset{}, presetDbError(err, fmt.Sprintf("presetID: %v", id))
+	}
+	preset, err := presetFromRow(row)
+	if err != nil {
+		return preset, models.IndentifyDbError(err)
+	}
+	return preset, nil
+}
+
+func (vp *videoProcessor) CreatePreset(ctx context.Context, req models.PresetRequest) (models.TranscodingPreset, error) {
+	params := fmt.Sprintf("name: %v", req.Name)
+	if err := req.Validate(); err != nil {
+		return models.TranscodingPreset{}, models.Error{
+			Code:    http.StatusBadRequest,
+			Message: "invalid input data",
+			Params:  params,
+			Err:     err,
+		}
+	}
+	variants, err := json.Marshal(req.Variants)
+	if err != nil {
+		return models.TranscodingPreset{}, models.IndentifyDbError(err).AddParams(params)
+	}
+	row, err := vp.db.CreateTranscodingPreset(ctx, db.CreateTranscodingPresetParams{
+		Name:              req.Name,
+		Description:       req.Description,
+		Variants:          variants,
+		HlsSegmentSeconds: segmentSeconds(req.HLS),
+		RateControl:       rateControlMode(req.RateControl),
+		Crf:               int32(req.RateControl.CRF),
+	})
+	if err != nil {
+		return models.TranscodingPreset{}, presetDbError(err, params)
+	}
+	return vp.finishPresetWrite(ctx, row, req.IsDefault)
+}
+
+func (vp *videoProcessor) UpdatePreset(ctx context.Context, id uuid.UUID, req models.PresetRequest) (models.TranscodingPreset, error) {
+	params := fmt.Sprintf("presetID: %v, name: %v", id, req.Name)
+	if err := req.Validate(); err != nil {
+		return models.TranscodingPreset{}, models.Error{
+			Code:    http.StatusBadRequest,
+			Message: "invalid input data",
+			Params:  params,
+			Err:     err,
+		}
+	}
+	current, err := vp.db.GetTranscodingPreset(ctx, id)
+	if err != nil {
+		return models.TranscodingPreset{}, presetDbError(err, params)
+	}
+	// the default moves by making another preset the default
+	if current.IsDefault && !req.IsDefault {
+		return models.TranscodingPreset{}, models.Error{
+			Code:        http.StatusConflict,
+			Message:     "default preset required",
+			Description: "make another preset the default instead",
+			Params:      params,
+			Err:         errors.New("cannot unset the default preset"),
+		}
+	}
+	variants, err := json.Marshal(req.Variants)
+	if err != nil {
+		return models.TranscodingPreset{}, models.IndentifyDbError(err).AddParams(params)
+	}
+	row, err := vp.db.UpdateTranscodingPreset(ctx, db.UpdateTranscodingPresetParams{
+		ID:                id,
+		Name:              req.Name,
+		Description:       req.Description,
+		Variants:          variants,
+		HlsSegmentSeconds: segmentSeconds(req.HLS),
+		RateControl:       rateControlMode(req.RateControl),
+		Crf:               int32(req.RateControl.CRF),
+	})
+	if err != nil {
+		return models.TranscodingPreset{}, presetDbError(err, params)
+	}
+	return vp.finishPresetWrite(ctx, row, req.IsDefault && !current.IsDefault)
+}
+
+// finishPresetWrite makes the written preset the default when asked to and converts it
+func (vp *videoProcessor) finishPresetWrite(ctx context.Context, row db.TranscodingPreset, makeDefault bool) (models.TranscodingPreset, error) {
+	if makeDefault {
+		if err := vp.db.SetDefaultTranscodingPreset(ctx, row.ID); err != nil {
+			return models.TranscodingPreset{}, models.IndentifyDbError(err).AddParams(fmt.Sprintf("presetID: %v", row.ID))
+		}
+		row.IsDefault = true
+	}
+	preset, err := presetFromRow(row)
+	if err != nil {
+		return preset, models.IndentifyDbError(err)
+	}
+	return preset, nil
+}
+
+// DeletePreset removes a preset. The default preset cannot be deleted; queued jobs that
+// name a deleted preset are processed with the default.
+func (vp *videoProcessor) DeletePreset(ctx context.Context, id uuid.UUID) error {
+	params := fmt.Sprintf("presetID: %v", id)
+	row, err := vp.db.GetTranscodingPreset(ctx, id)
+	if err != nil {
+		return presetDbError(err, params)
+	}
+	if row.IsDefault {
+		return models.Error{
+			Code:        http.StatusConflict,
+			Message:     "default preset required",
+			Description: "make another preset the default before deleting this one",
+			Params:      params,
+			Err:         errors.New("cannot delete the default preset"),
+		}
+	}
+	if err := vp.db.DeleteTranscodingPreset(ctx, id); err != nil {
+		return models.IndentifyDbError(err).AddParams(params)
+	}
+	return nil
+}
+
+// SyncPresets creates the presets of the configuration, or replaces those of the same name, so
+// operators change the ladder in config.yaml. Workers read the preset of a job when it starts,
+// so the next jobs use it. A preset that is the default stays the default.
+func (vp *videoProcessor) SyncPresets(ctx context.Context, presets []models.PresetRequest) error {
+	for _, req := range presets {
+		row, err := vp.db.GetTranscodingPresetByName(ctx, req.Name)
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			_, err = vp.CreatePreset(ctx, req)
+		case err == nil:
+			req.IsDefault = req.IsDefault || row.IsDefault
+			_, err = vp.UpdatePreset(ctx, row.ID, req)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to sync preset %q from the configuration: %w", req.Name, err)
+		}
+		vp.logger.Info("preset synced from the configuration", "preset", req.Name, "variants", len(req.Variants))
+	}
+	return nil
+}
+
+// presetID resolves the preset an upload names, uuid.Nil when it names none
+func (vp *videoProcessor) presetID(ctx context.Context, name string) (uuid.UUID, error) {
+	if name == "" {
+		return uuid.Nil, nil
+	}
+	row, err := vp.db.GetTranscodingPresetByName(ctx, name)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return uuid.Nil, models.Error{
+			Code:    http.StatusBadRequest,
+			Message: "unknown preset",
+			Params:  fmt.Sprintf("preset: %v", name),
+			Err:     errors.Join(fmt.Errorf("no preset named %q", name), models.ErrInvalidInputData),
+		}
+	}
+	if err != nil {
+		return uuid.Nil, models.IndentifyDbError(err).AddParams(fmt.Sprintf("preset: %v", name))
+	}
+	return row.ID, nil
+}
+
+func segmentSeconds(hls models.HLSOptions) int32 {
+	if hls.SegmentSeconds <= 0 {
+		return defaultSegmentSeconds
+	}
+	return int32(hls.SegmentSeconds)
+}
+
+func rateControlMode(rc models.RateControlOptions) string {
+	if rc.Mode == "" {
+		return models.RateControlBitrate
+	}
+	return rc.Mode
+}
+
+// presetDbError maps missing presets to 404 and taken names to 409
+func presetDbError(err error, params string) error {
+	var pgErr *pgconn.PgError
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return models.Error{
+			Code:    http.StatusNotFound,
+			Message: "resource not found",
+			Params:  params,
+			Err:     models.ErrResourceNotFound,
+		}
+	case errors.As(err, &pgErr) && pgErr.Code == "23505":
+		return models.Error{
+			Code:    http.StatusConflict,
+			Message: "preset name already taken",
+			Params:  params,
+			Err:     err,
+		}
+	default:
+		return models.IndentifyDbError(err).AddParams(params)
+	}
+}
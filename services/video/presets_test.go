@@ -0,0 +1,224 @@
+package video
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"testing"
+	"video-processing/database/db"
+	"video-processing/mocks"
+	"video-processing/models"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+// testLadder is the ladder of the seeded default preset
+var testLadder = newLadder(models.DefaultPreset)
+
+func defaultPresetRow(t *testing.T) db.TranscodingPreset {
+	variants, err := json.Marshal(models.DefaultPreset.Variants)
+	require.NoError(t, err)
+	return db.TranscodingPreset{
+		ID:                uuid.New(),
+		Name:              models.DefaultPreset.Name,
+		IsDefault:         true,
+		Variants:          variants,
+		HlsSegmentSeconds: int32(models.DefaultPreset.HLS.SegmentSeconds),
+		RateControl:       models.RateControlBitrate,
+	}
+}
+
+func TestPresetRequestValidate(t *testing.T) {
+	valid := func() models.PresetRequest {
+		return models.PresetRequest{
+			Name: "mobile",
+			Variants: []models.PresetVariant{
+				{Name: "720p", Width: 1280, Height: 720, Bitrate: "2000k", CRF: 23, EncoderPreset: "slow", Profile: models.ProfileHigh, Level: "4", Tune: "film"},
+				{Name: "720p-hdr", Width: 1280, Height: 720, Codec: models.CodecHEVC, Bitrate: "3000k", HDR: true, Tune: "grain"},
+			},
+			HLS: models.HLSOptions{SegmentSeconds: 4},
+		}
+	}
+	require.NoError(t, valid().Validate())
+
+	tests := []struct {
+		name   string
+		modify func(*models.PresetRequest)
+	}{
+		{"no name", func(p *models.PresetRequest) { p.Name = "" }},
+		{"no variants", func(p *models.PresetRequest) { p.Variants = nil }},
+		{"odd width", func(p *models.PresetRequest) { p.Variants[0].Width = 1281 }},
+		{"bad bitrate", func(p *models.PresetRequest) { p.Variants[0].Bitrate = "2M" }},
+		{"crf out of range", func(p *models.PresetRequest) { p.Variants[0].CRF = 60 }},
+		{"unknown encoder preset", func(p *models.PresetRequest) { p.Variants[0].EncoderPreset = "warp" }},
+		{"unknown profile", func(p *models.PresetRequest) { p.Variants[0].Profile = "extended" }},
+		{"unknown level", func(p *models.PresetRequest) { p.Variants[0].Level = "3.3" }},
+		{"unknown tune", func(p *models.PresetRequest) { p.Variants[0].Tune = "cartoon" }},
+		{"hevc profile", func(p *models.PresetRequest) { p.Variants[1].Profile = models.ProfileMain }},
+		{"tune x265 lacks", func(p *models.PresetRequest) { p.Variants[1].Tune = "film" }},
+		{"unknown codec", func(p *models.PresetRequest) { p.Variants[0].Codec = "vp9" }},
+		{"h264 hdr", func(p *models.PresetRequest) { p.Variants[1].Codec = models.CodecH264 }},
+		{"duplicate names", func(p *models.PresetRequest) { p.Variants[1].Name = "720p" }},
+		{"reserved name", func(p *models.PresetRequest) { p.Variants[0].Name = models.AudioVariantName }},
+		{"path in name", func(p *models.PresetRequest) { p.Variants[0].Name = "../720p" }},
+		{"only hdr", func(p *models.PresetRequest) { p.Variants = p.Variants[1:] }},
+		{"long segments", func(p *models.PresetRequest) { p.HLS.SegmentSeconds = 120 }},
+		{"unknown rate control", func(p *models.PresetRequest) { p.RateControl.Mode = "vbr" }},
+		{"preset crf out of range", func(p *models.PresetRequest) { p.RateControl.CRF = 52 }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := valid()
+			tt.modify(&req)
+			require.ErrorIs(t, req.Validate(), models.ErrInvalidInputData)
+		})
+	}
+}
+
+func TestNewLadder(t *testing.T) {
+	require.Len(t, testLadder.regular, 6)
+	require.Len(t, testLadder.hdr, 1)
+	require.Len(t, testLadder.vertical, 3)
+	require.Len(t, testLadder.all(), 10)
+	require.True(t, testLadder.hdr[0].hevc())
+	require.Equal(t, 6, testLadder.regular[0].segmentSeconds())
+	require.Equal(t, "fast", testLadder.regular[0].encoderPreset())
+	require.Zero(t, testLadder.regular[0].CRF)
+}
+
+func TestNewLadderRateControl(t *testing.T) {
+	preset := models.TranscodingPreset{
+		Variants: []models.PresetVariant{
+			{Name: "1080p", Width: 1920, Height: 1080, Bitrate: "4000k"},
+			{Name: "720p", Width: 1280, Height: 720, Bitrate: "2000k", CRF: 20},
+			{Name: "1080p-hevc", Width: 1920, Height: 1080, Codec: models.CodecHEVC, Bitrate: "2500k"},
+		},
+	}
+	crfs := func() []int {
+		var crfs []int
+		for _, v := range newLadder(preset).all() {
+			crfs = append(crfs, v.CRF)
+		}
+		return crfs
+	}
+
+	// bitrate presets keep the crf of the rungs that set one
+	require.Equal(t, []int{0, 20, 0}, crfs())
+	preset.RateControl.Mode = models.RateControlBitrate
+	require.Equal(t, []int{0, 20, 0}, crfs())
+
+	// crf presets encode every rung at constant quality, by default per codec
+	preset.RateControl.Mode = models.RateControlCRF
+	require.Equal(t, []int{defaultCRFH264, 20, defaultCRFHEVC}, crfs())
+	preset.RateControl.CRF = 25
+	require.Equal(t, []int{25, 20, 25}, crfs())
+
+	// the bitrate caps the quality
+	args := x26xRateArgs(newLadder(preset).regular[0])
+	require.Equal(t, []string{"-crf", "25", "-maxrate", "4000k", "-bufsize", "8000k", "-preset", "fast"}, args)
+}
+
+func TestLoadLadderFallsBackToDefault(t *testing.T) {
+	repo := mocks.NewMockVideoRepo(gomock.NewController(t))
+	rc := &redisConsumer{logger: slog.New(slog.NewTextHandler(io.Discard, nil)), db: repo}
+	ctx := context.Background()
+
+	custom := defaultPresetRow(t)
+	custom.ID = uuid.New()
+	custom.Variants = []byte(`[{"name": "540p", "width": 960, "height": 540, "bitrate": "1500k", "crf": 21}]`)
+	custom.HlsSegmentSeconds = 2
+	repo.EXPECT().GetTranscodingPreset(gomock.Any(), custom.ID).Return(custom, nil)
+	l, err := rc.loadLadder(ctx, map[string]interface{}{"preset_id": custom.ID.String()})
+	require.NoError(t, err)
+	require.Len(t, l.all(), 1)
+	require.Equal(t, Variant{Name: "540p", Width: 960, Height: 540, Bitrate: "1500k", CRF: 21, SegmentSeconds: 2}, l.regular[0])
+
+	// jobs without a preset and jobs whose preset was deleted get the default
+	deleted := uuid.New()
+	repo.EXPECT().GetTranscodingPreset(gomock.Any(), deleted).Return(db.TranscodingPreset{}, pgx.ErrNoRows)
+	repo.EXPECT().GetDefaultTranscodingPreset(gomock.Any()).Return(defaultPresetRow(t), nil).Times(2)
+	for _, values := range []map[string]interface{}{{}, {"preset_id": deleted.String()}} {
+		l, err := rc.loadLadder(ctx, values)
+		require.NoError(t, err)
+		require.Equal(t, testLadder, l)
+	}
+}
+
+func TestPresetConflicts(t *testing.T) {
+	repo := mocks.NewMockVideoRepo(gomock.NewController(t))
+	vp := &videoProcessor{db: repo}
+	ctx := context.Background()
+	row := defaultPresetRow(t)
+	req := models.PresetRequest{Name: "default", Variants: models.DefaultPreset.Variants}
+	var apiErr models.Error
+
+	// the default preset can neither be deleted nor stop being the default
+	repo.EXPECT().GetTranscodingPreset(gomock.Any(), row.ID).Return(row, nil).Times(2)
+	require.True(t, errors.As(vp.DeletePreset(ctx, row.ID), &apiErr))
+	require.Equal(t, http.StatusConflict, apiErr.Code)
+	_, err := vp.UpdatePreset(ctx, row.ID, req)
+	require.True(t, errors.As(err, &apiErr))
+	require.Equal(t, http.StatusConflict, apiErr.Code)
+
+	// names are unique
+	repo.EXPECT().CreateTranscodingPreset(gomock.Any(), gomock.Any()).Return(db.TranscodingPreset{}, &pgconn.PgError{Code: "23505"})
+	_, err = vp.CreatePreset(ctx, req)
+	require.True(t, errors.As(err, &apiErr))
+	require.Equal(t, http.StatusConflict, apiErr.Code)
+
+	// a new default replaces the old one
+	created := row
+	created.ID, created.Name, created.IsDefault = uuid.New(), "mobile", false
+	repo.EXPECT().CreateTranscodingPreset(gomock.Any(), db.CreateTranscodingPresetParams{
+		Name: "mobile", Variants: created.Variants, HlsSegmentSeconds: defaultSegmentSeconds, RateControl: models.RateControlBitrate,
+	}).Return(created, nil)
+	repo.EXPECT().SetDefaultTranscodingPreset(gomock.Any(), created.ID).Return(nil)
+	req.Name, req.IsDefault = "mobile", true
+	preset, err := vp.CreatePreset(ctx, req)
+	require.NoError(t, err)
+	require.True(t, preset.IsDefault)
+	require.Len(t, preset.Variants, 10)
+
+	// uploads naming an unknown preset are rejected
+	repo.EXPECT().GetTranscodingPresetByName(gomock.Any(), "missing").Return(db.TranscodingPreset{}, pgx.ErrNoRows)
+	_, err = vp.presetID(ctx, "missing")
+	require.True(t, errors.As(err, &apiErr))
+	require.Equal(t, http.StatusBadRequest, apiErr.Code)
+}
+
+func TestSyncPresets(t *testing.T) {
+	repo := mocks.NewMockVideoRepo(gomock.NewController(t))
+	vp := &videoProcessor{logger: slog.New(slog.NewTextHandler(io.Discard, nil)), db: repo}
+	ctx := context.Background()
+	ladder := []models.PresetVariant{{Name: "720p", Width: 1280, Height: 720, Bitrate: "2500k"}}
+	variants, err := json.Marshal(ladder)
+	require.NoError(t, err)
+	current := defaultPresetRow(t)
+
+	// existing presets are replaced and the default stays the default, new ones are created
+	repo.EXPECT().GetTranscodingPresetByName(gomock.Any(), "default").Return(current, nil)
+	repo.EXPECT().GetTranscodingPreset(gomock.Any(), current.ID).Return(current, nil)
+	repo.EXPECT().UpdateTranscodingPreset(gomock.Any(), db.UpdateTranscodingPresetParams{
+		ID: current.ID, Name: "default", Variants: variants, HlsSegmentSeconds: 4, RateControl: models.RateControlCRF, Crf: 24,
+	}).Return(current, nil)
+	repo.EXPECT().GetTranscodingPresetByName(gomock.Any(), "mobile").Return(db.TranscodingPreset{}, pgx.ErrNoRows)
+	repo.EXPECT().CreateTranscodingPreset(gomock.Any(), db.CreateTranscodingPresetParams{
+		Name: "mobile", Variants: variants, HlsSegmentSeconds: defaultSegmentSeconds, RateControl: models.RateControlBitrate,
+	}).Return(db.TranscodingPreset{Name: "mobile", Variants: variants}, nil)
+	require.NoError(t, vp.SyncPresets(ctx, []models.PresetRequest{
+		{Name: "default", Variants: ladder, HLS: models.HLSOptions{SegmentSeconds: 4}, RateControl: models.RateControlOptions{Mode: models.RateControlCRF, CRF: 24}},
+		{Name: "mobile", Variants: ladder},
+	}))
+
+	// invalid ladders stop the startup
+	repo.EXPECT().GetTranscodingPresetByName(gomock.Any(), "broken").Return(db.TranscodingPreset{}, pgx.ErrNoRows)
+	err = vp.SyncPresets(ctx, []models.PresetRequest{{Name: "broken"}})
+	require.ErrorContains(t, err, "broken")
+}
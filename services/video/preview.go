@@ -0,0 +1,145 @@
+package video
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+const (
+	previewFileName = "preview.mp4"
+	// previewSeconds is the length of the preview, taken from the start of short videos
+	previewSeconds = 15
+	// videos of at least previewMontageMinSeconds get a montage of previewSegments
+	// evenly spread segments instead, so the preview shows more than the intro
+	previewMontageMinSeconds = 60
+	previewSegments          = 5
+	previewBitrate           = "300k"
+	previewWidth             = 480
+
+	animatedPreviewFileName = "preview.webp"
+	// animatedPreviewSeconds is the length of the animated preview, sampled from the middle
+	animatedPreviewSeconds = 3
+	animatedPreviewFPS     = 10
+	animatedPreviewWidth   = 320
+)
+
+// previewFilter builds the video filter chain of the preview clip, tone mapped for HDR sources
+func previewFilter(durationSeconds float64, hdrFormat string) string {
+	scale := toneMapChain(fmt.Sprintf("scale=%d:-2", previewWidth), hdrFormat)
+	if durationSeconds < previewMontageMinSeconds {
+		return scale
+	}
+	segment := float64(previewSeconds) / previewSegments
+	ranges := make([]string, previewSegments)
+	for i := range ranges {
+		start := durationSeconds*(float64(i)+0.5)/previewSegments - segment/2
+		ranges[i] = fmt.Sprintf("between(t\\,%s\\,%s)", formatFactor(start), formatFactor(start+segment))
+	}
+	return fmt.Sprintf("select=%s,setpts=N/FRAME_RATE/TB,%s", strings.Join(ranges, "+"), scale)
+}
+
+// generatePreview encodes a short, silent, low bitrate clip for instant previews on browsing pages
+func generatePreview(ctx context.Context, t Transcoder, inputPath, outPath string, durationSeconds float64, hdrFormat string) error {
+	args := []string{
+		"-y",
+		"-nostdin",
+		"-i", inputPath,
+		"-an",
+		"-vf", previewFilter(durationSeconds, hdrFormat),
+		"-t", fmt.Sprint(previewSeconds),
+		"-c:v", "libx264",
+		"-b:v", previewBitrate,
+		"-preset", "veryfast",
+		"-pix_fmt", "yuv420p",
+		"-movflags", "+faststart",
+		outPath,
+	}
+	if err := t.Run(ctx, args...); err != nil {
+		return fmt.Errorf("ffmpeg preview error: %w", err)
+	}
+	return nil
+}
+
+// processPreview generates the preview clip and queues it for upload next to the renditions.
+// Failures are logged only; listings fall back to the thumbnail.
+func (rc *redisConsumer) processPreview(ctx context.Context, task ProcessingTask, durationSeconds float64, uploadCh chan<- UploadTask) {
+	outPath := filepath.Join(task.WorkDir, previewFileName)
+	if err := generatePreview(ctx, rc.transcoder, task.SourcePath, outPath, durationSeconds, task.HDRFormat); err != nil {
+		rc.logger.Warn("preview generation failed", "error", err, "videoID", task.VideoID)
+		return
+	}
+
+	upload := UploadTask{
+		SourcePath:  outPath,
+		ObjectKey:   filepath.ToSlash(filepath.Join(task.DestPrefix, previewFileName)),
+		ContentType: "video/mp4",
+		Bucket:      task.Bucket,
+	}
+	select {
+	case <-ctx.Done():
+		return
+	case uploadCh <- upload:
+	}
+
+	videoUUID, err := uuid.Parse(task.VideoID)
+	if err != nil {
+		rc.logger.Error("invalid video ID for preview", "error", err, "videoID", task.VideoID)
+		return
+	}
+	rc.saveVideoAsset(ctx, videoUUID, AssetKindPreview, upload)
+}
+
+// animatedPreviewArgs encode a few silent seconds from the middle of the video into a looping
+// animated WebP, small enough to play on hover in listings
+func animatedPreviewArgs(inputPath, outPath string, durationSeconds float64, hdrFormat string) []string {
+	start := durationSeconds/2 - animatedPreviewSeconds/2.0
+	if start < 0 {
+		start = 0
+	}
+	return []string{
+		"-y",
+		"-nostdin",
+		"-ss", formatFactor(start),
+		"-t", fmt.Sprint(animatedPreviewSeconds),
+		"-i", inputPath,
+		"-an",
+		"-vf", fmt.Sprintf("fps=%d,%s", animatedPreviewFPS, toneMapChain(fmt.Sprintf("scale=%d:-2", animatedPreviewWidth), hdrFormat)),
+		"-c:v", "libwebp",
+		"-quality", "60",
+		"-loop", "0",
+		outPath,
+	}
+}
+
+// processAnimatedPreview generates the animated preview and queues it for upload next to the
+// renditions. Failures are logged only; listings fall back to the preview clip.
+func (rc *redisConsumer) processAnimatedPreview(ctx context.Context, task ProcessingTask, durationSeconds float64, uploadCh chan<- UploadTask) {
+	outPath := filepath.Join(task.WorkDir, animatedPreviewFileName)
+	if err := rc.transcoder.Run(ctx, animatedPreviewArgs(task.SourcePath, outPath, durationSeconds, task.HDRFormat)...); err != nil {
+		rc.logger.Warn("animated preview generation failed", "error", err, "videoID", task.VideoID)
+		return
+	}
+
+	upload := UploadTask{
+		SourcePath:  outPath,
+		ObjectKey:   filepath.ToSlash(filepath.Join(task.DestPrefix, animatedPreviewFileName)),
+		ContentType: mimeTypeByExt(".webp"),
+		Bucket:      task.Bucket,
+	}
+	select {
+	case <-ctx.Done():
+		return
+	case uploadCh <- upload:
+	}
+
+	videoUUID, err := uuid.Parse(task.VideoID)
+	if err != nil {
+		rc.logger.Error("invalid video ID for animated preview", "error", err, "videoID", task.VideoID)
+		return
+	}
+	rc.saveVideoAsset(ctx, videoUUID, AssetKindAnimatedPreview, upload)
+}
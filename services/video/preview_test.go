@@ -0,0 +1,46 @@
+package video
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPreviewFilter(t *testing.T) {
+	testCases := []struct {
+		name     string
+		duration float64
+		want     string
+	}{
+		{
+			name:     "short video keeps its start",
+			duration: 42,
+			want:     "scale=480:-2",
+		},
+		{
+			name:     "long video gets a montage",
+			duration: 100,
+			want: `select=between(t\,8.5\,11.5)+between(t\,28.5\,31.5)+between(t\,48.5\,51.5)+` +
+				`between(t\,68.5\,71.5)+between(t\,88.5\,91.5),setpts=N/FRAME_RATE/TB,scale=480:-2`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, previewFilter(tc.duration, ""))
+		})
+	}
+	require.Equal(t, toneMapFilter+",scale=480:-2", previewFilter(42, HDRFormatHDR10))
+}
+
+func TestAnimatedPreviewArgs(t *testing.T) {
+	require.Equal(t, "-y -nostdin -ss 58.5 -t 3 -i source.mp4 -an -vf fps=10,scale=320:-2 -c:v libwebp -quality 60 -loop 0 preview.webp",
+		strings.Join(animatedPreviewArgs("source.mp4", "preview.webp", 120, ""), " "))
+
+	// videos shorter than the preview start at the beginning
+	require.Contains(t, strings.Join(animatedPreviewArgs("source.mp4", "preview.webp", 2, ""), " "), "-ss 0 ")
+
+	// HDR sources are tone mapped after the frame rate drops, on fewer frames
+	require.Contains(t, strings.Join(animatedPreviewArgs("source.mkv", "preview.webp", 120, HDRFormatHLG), " "),
+		"-vf fps=10,"+toneMapFilter+",scale=320:-2 ")
+}
@@ -0,0 +1,129 @@
+package video
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+	"video-processing/models"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/redis/go-redis/v9"
+)
+
+// prioritizeScript moves the job of a video that no worker has read yet from the stream to the
+// priority stream. KEYS[1] stream, KEYS[2] priority stream; ARGV video ID.
+// Returns the ID of the new entry, nil when no such job waits.
+const prioritizeScript = `
+if redis.call('EXISTS', KEYS[1]) == 0 then
+	return false
+end
+local function after(a, b)
+	local ams, aseq = string.match(a, '^(%d+)-(%d+)$')
+	local bms, bseq = string.match(b, '^(%d+)-(%d+)$')
+	ams, aseq, bms, bseq = tonumber(ams), tonumber(aseq), tonumber(bms), tonumber(bseq)
+	return ams > bms or (ams == bms and aseq > bseq)
+end
+-- entries up to the last one handed to a consumer group are running or done
+local start = '0-0'
+for _, group in ipairs(redis.call('XINFO', 'GROUPS', KEYS[1])) do
+	for i = 1, #group, 2 do
+		if group[i] == 'last-delivered-id' and after(group[i + 1], start) then
+			start = group[i + 1]
+		end
+	end
+end
+for _, entry in ipairs(redis.call('XRANGE', KEYS[1], start, '+')) do
+	local id, fields = entry[1], entry[2]
+	if after(id, start) then
+		for i = 1, #fields, 2 do
+			if fields[i] == 'video_id' and fields[i + 1] == ARGV[1] then
+				redis.call('XDEL', KEYS[1], id)
+				return redis.call('XADD', KEYS[2], '*', unpack(fields))
+			end
+		end
+	end
+end
+return false`
+
+// priorityStream is where boosted jobs wait; workers drain it before the stream itself
+func priorityStream(streamName string) string {
+	return streamName + ":priority"
+}
+
+// Prioritize moves the queued job of a video to the priority stream and reports false when
+// no job of the video waits in the stream, e.g. because a worker already picked it up.
+func (rs *redisStreamer) Prioritize(ctx context.Context, videoID string) (bool, error) {
+	id, err := rs.rc.Eval(ctx, prioritizeScript, []string{rs.streamName, priorityStream(rs.streamName)}, videoID).Text()
+	if err == redis.Nil {
+		return false, nil
+	}
+	if err != nil {
+		return false, models.Error{
+			Code:    http.StatusInternalServerError,
+			Message: "internal server error",
+			Params:  fmt.Sprintf("videoID: %v", videoID),
+			Err:     fmt.Errorf("failed to prioritize job: %w", err),
+		}
+	}
+	rs.logger.Info("job moved to the priority stream", "videoID", videoID, "id", id)
+	return true, nil
+}
+
+// BoostJob moves the queued processing job of a video ahead of every other queued job,
+// for support escalations.
+func (vp *videoProcessor) BoostJob(ctx context.Context, videoID uuid.UUID) error {
+	params := fmt.Sprintf("videoID: %v", videoID)
+	if _, err := vp.db.GetVideo(ctx, videoID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.Error{
+				Code:    http.StatusNotFound,
+				Message: "resource not found",
+				Params:  params,
+				Err:     models.ErrResourceNotFound,
+			}
+		}
+		return models.IndentifyDbError(err).AddParams(params)
+	}
+	moved, err := vp.streamer.Prioritize(ctx, videoID.String())
+	if err != nil {
+		return err
+	}
+	if !moved {
+		return models.Error{
+			Code:        http.StatusConflict,
+			Message:     "job not queued",
+			Description: "the video has no job waiting in the queue; it is running, finished or parked by the user limit",
+			Params:      params,
+			Err:         errors.New("no queued job of the video"),
+		}
+	}
+	return nil
+}
+
+// readJobs reads the next batch of jobs. Boosted jobs come first; only when there are none
+// the worker waits on the stream itself.
+func (rc *redisConsumer) readJobs(ctx context.Context) ([]redis.XStream, error) {
+	entries, err := rc.rc.XReadGroup(ctx, &redis.XReadGroupArgs{
+		Group:    rc.groupName,
+		Consumer: rc.consumerName,
+		Streams:  []string{priorityStream(rc.streamName), ">"},
+		Count:    10,
+		Block:    -1, // never block, the stream is read next
+	}).Result()
+	if err != nil && err != redis.Nil {
+		return nil, err
+	}
+	if len(entries) > 0 && len(entries[0].Messages) > 0 {
+		return entries, nil
+	}
+	return rc.rc.XReadGroup(ctx, &redis.XReadGroupArgs{
+		Group:    rc.groupName,
+		Consumer: rc.consumerName,
+		Streams:  []string{rc.streamName, ">"}, // ">" means "give me new messages not yet delivered to anyone"
+		Count:    10,                           // Batch size
+		Block:    2 * time.Second,              // Long polling: block for 2s if no data
+	}).Result()
+}
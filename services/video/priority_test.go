@@ -0,0 +1,102 @@
+package video
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"testing"
+	"time"
+	"video-processing/database/db"
+	"video-processing/mocks"
+	"video-processing/models"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestRedisStreamerPrioritize(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	broker := mocks.NewMockBroker(ctrl)
+	streamer := NewRedisStreamer("video_stream", slog.New(slog.NewTextHandler(io.Discard, nil)), broker)
+	videoID := uuid.NewString()
+
+	broker.EXPECT().
+		Eval(gomock.Any(), prioritizeScript, []string{"video_stream", "video_stream:priority"}, videoID).
+		Return(redis.NewCmdResult("5-0", nil))
+	moved, err := streamer.Prioritize(context.Background(), videoID)
+	require.NoError(t, err)
+	require.True(t, moved)
+
+	// the script returns nil when no job of the video waits
+	broker.EXPECT().Eval(gomock.Any(), prioritizeScript, gomock.Any(), videoID).Return(redis.NewCmdResult(nil, redis.Nil))
+	moved, err = streamer.Prioritize(context.Background(), videoID)
+	require.NoError(t, err)
+	require.False(t, moved)
+
+	broker.EXPECT().Eval(gomock.Any(), prioritizeScript, gomock.Any(), videoID).Return(redis.NewCmdResult(nil, redis.ErrClosed))
+	_, err = streamer.Prioritize(context.Background(), videoID)
+	var e models.Error
+	require.ErrorAs(t, err, &e)
+	require.Equal(t, http.StatusInternalServerError, e.Code)
+}
+
+func TestBoostJob(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	repo := mocks.NewMockVideoRepo(ctrl)
+	streamer := mocks.NewMockStreamer(ctrl)
+	vp := NewVideoProcessor(slog.New(slog.NewTextHandler(io.Discard, nil)), mocks.NewMockObjectStore(ctrl), repo, streamer, NewFakeTranscoder(), VideoProcessorOptions{URLExpiry: time.Hour})
+	videoID := uuid.New()
+	var e models.Error
+
+	repo.EXPECT().GetVideo(gomock.Any(), videoID).Return(db.Video{}, pgx.ErrNoRows)
+	err := vp.BoostJob(context.Background(), videoID)
+	require.ErrorAs(t, err, &e)
+	require.Equal(t, http.StatusNotFound, e.Code)
+
+	repo.EXPECT().GetVideo(gomock.Any(), videoID).Return(db.Video{ID: videoID}, nil).Times(2)
+	streamer.EXPECT().Prioritize(gomock.Any(), videoID.String()).Return(true, nil)
+	require.NoError(t, vp.BoostJob(context.Background(), videoID))
+
+	// running or finished jobs cannot be boosted
+	streamer.EXPECT().Prioritize(gomock.Any(), videoID.String()).Return(false, nil)
+	err = vp.BoostJob(context.Background(), videoID)
+	require.ErrorAs(t, err, &e)
+	require.Equal(t, http.StatusConflict, e.Code)
+}
+
+func TestReadJobsPrefersPriorityStream(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	broker := mocks.NewMockBroker(ctrl)
+	rc := &redisConsumer{streamName: "video_stream", groupName: "workers", consumerName: "w1", rc: broker}
+	boosted := []redis.XStream{{Stream: "video_stream:priority", Messages: []redis.XMessage{{ID: "1-0"}}}}
+
+	// boosted jobs are read without waiting on the stream
+	broker.EXPECT().
+		XReadGroup(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, args *redis.XReadGroupArgs) *redis.XStreamSliceCmd {
+			require.Equal(t, []string{"video_stream:priority", ">"}, args.Streams)
+			require.Negative(t, args.Block)
+			return redis.NewXStreamSliceCmdResult(boosted, nil)
+		})
+	entries, err := rc.readJobs(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, boosted, entries)
+
+	// without boosted jobs the worker blocks on the stream
+	gomock.InOrder(
+		broker.EXPECT().XReadGroup(gomock.Any(), gomock.Any()).Return(redis.NewXStreamSliceCmdResult(nil, redis.Nil)),
+		broker.EXPECT().
+			XReadGroup(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, args *redis.XReadGroupArgs) *redis.XStreamSliceCmd {
+				require.Equal(t, []string{"video_stream", ">"}, args.Streams)
+				require.Positive(t, args.Block)
+				return redis.NewXStreamSliceCmdResult(nil, redis.Nil)
+			}),
+	)
+	_, err = rc.readJobs(context.Background())
+	require.ErrorIs(t, err, redis.Nil)
+}
@@ -0,0 +1,215 @@
+package video
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ProbeChapter is a chapter entry as reported by ffprobe -show_chapters
+type ProbeChapter struct {
+	ID        int64             `json:"id"`
+	StartTime string            `json:"start_time"`
+	EndTime   string            `json:"end_time"`
+	Tags      map[string]string `json:"tags"`
+}
+
+// ProbeStream is a stream entry as reported by ffprobe -show_streams
+type ProbeStream struct {
+	Index          int               `json:"index"`
+	CodecName      string            `json:"codec_name"`
+	CodecType      string            `json:"codec_type"`
+	Profile        string            `json:"profile"`
+	Level          int               `json:"level"` // H.264: 10 x level, HEVC: 30 x level
+	Width          int               `json:"width"`
+	Height         int               `json:"height"`
+	PixFmt         string            `json:"pix_fmt"`
+	FieldOrder     string            `json:"field_order"`  // progressive, tt, bb, tb or bt; often missing
+	RFrameRate     string            `json:"r_frame_rate"` // a fraction, e.g. "30000/1001"
+	BitRate        string            `json:"bit_rate"`     // bits per second, missing for some containers
+	SampleRate     string            `json:"sample_rate"`
+	Channels       int               `json:"channels"`
+	ChannelLayout  string            `json:"channel_layout"`
+	ColorRange     string            `json:"color_range"`
+	ColorSpace     string            `json:"color_space"`
+	ColorTransfer  string            `json:"color_transfer"`
+	ColorPrimaries string            `json:"color_primaries"`
+	ClosedCaptions int               `json:"closed_captions"` // 1 when CEA-608/708 captions are embedded
+	Disposition    map[string]int    `json:"disposition"`
+	Tags           map[string]string `json:"tags"`
+	SideData       []ProbeSideData   `json:"side_data_list"`
+}
+
+// ProbeSideData is a stream side data entry; only the spherical, stereo 3D and display matrix
+// fields are decoded
+type ProbeSideData struct {
+	SideDataType string  `json:"side_data_type"`
+	Projection   string  `json:"projection"` // Spherical Mapping: equirectangular, cubemap, ...
+	Type         string  `json:"type"`       // Stereo 3D: 2D, top and bottom, side by side, ...
+	Rotation     float64 `json:"rotation"`   // Display Matrix: counterclockwise degrees, e.g. -90
+}
+
+// ProbeFormat is the container entry as reported by ffprobe -show_format
+type ProbeFormat struct {
+	FormatName string `json:"format_name"`
+	Duration   string `json:"duration"`
+	BitRate    string `json:"bit_rate"`
+	Size       string `json:"size"` // bytes
+}
+
+// ProbeResult is the subset of ffprobe's JSON output the pipeline uses
+type ProbeResult struct {
+	Format   ProbeFormat    `json:"format"`
+	Streams  []ProbeStream  `json:"streams"`
+	Chapters []ProbeChapter `json:"chapters"`
+}
+
+// Duration returns the container duration in seconds, or 0 when unknown
+func (p ProbeResult) Duration() float64 {
+	d, err := parseProbeSeconds(p.Format.Duration)
+	if err != nil {
+		return 0
+	}
+	return d
+}
+
+// VideoStream returns the first real video stream, skipping embedded cover art.
+func (p ProbeResult) VideoStream() (ProbeStream, bool) {
+	for _, s := range p.Streams {
+		if s.CodecType == "video" && s.Disposition["attached_pic"] == 0 {
+			return s, true
+		}
+	}
+	return ProbeStream{}, false
+}
+
+// HasAudio reports whether the source has an audio stream
+func (p ProbeResult) HasAudio() bool {
+	for _, s := range p.Streams {
+		if s.CodecType == "audio" {
+			return true
+		}
+	}
+	return false
+}
+
+// AudioLanguages returns the language of every audio stream in stream order, "und" for
+// streams without a language tag, so the tracks can be told apart in the outputs
+func (p ProbeResult) AudioLanguages() []string {
+	var languages []string
+	for _, s := range p.Streams {
+		if s.CodecType != "audio" {
+			continue
+		}
+		languages = append(languages, s.Language())
+	}
+	return languages
+}
+
+// undeterminedLanguage is the ISO 639-2 code ffmpeg writes for untagged tracks
+const undeterminedLanguage = "und"
+
+// languageTagPattern matches the language tags kept from streams, which end up in object keys
+var languageTagPattern = regexp.MustCompile(`^[a-z0-9-]{1,20}$`)
+
+// Language returns the lowercased language tag of the stream, "und" when it has none or an
+// unusable one
+func (s ProbeStream) Language() string {
+	language := strings.ToLower(strings.TrimSpace(s.Tags["language"]))
+	if !languageTagPattern.MatchString(language) {
+		return undeterminedLanguage
+	}
+	return language
+}
+
+// HDR formats detected from the transfer characteristics of the video stream
+const (
+	HDRFormatHDR10 = "HDR10"
+	HDRFormatHLG   = "HLG"
+)
+
+// HDRFormat reports whether the stream carries HDR video and which kind.
+// An empty string means SDR.
+func (s ProbeStream) HDRFormat() string {
+	switch s.ColorTransfer {
+	case "smpte2084":
+		return HDRFormatHDR10
+	case "arib-std-b67":
+		return HDRFormatHLG
+	default:
+		return ""
+	}
+}
+
+// Rotation returns the clockwise degrees the picture is turned for display: 0, 90, 180 or 270.
+// Phones store portrait videos as landscape frames with a display matrix, which older ffprobe
+// versions report as a rotate tag instead.
+func (s ProbeStream) Rotation() int {
+	var degrees float64
+	if tag, err := strconv.ParseFloat(s.Tags["rotate"], 64); err == nil {
+		degrees = tag
+	}
+	for _, sd := range s.SideData {
+		if sd.SideDataType == "Display Matrix" {
+			degrees = -sd.Rotation
+		}
+	}
+	rotation := int(math.Round(degrees/90)) * 90 % 360
+	if rotation < 0 {
+		rotation += 360
+	}
+	return rotation
+}
+
+// DisplaySize returns the size of the picture as it is shown, after the rotation
+func (s ProbeStream) DisplaySize() (width, height int) {
+	if s.Rotation()%180 != 0 {
+		return s.Height, s.Width
+	}
+	return s.Width, s.Height
+}
+
+// Spherical returns the 360° projection and the stereo layout signalled by the stream, if any.
+func (s ProbeStream) Spherical() (projection, stereoMode string, ok bool) {
+	for _, sd := range s.SideData {
+		switch sd.SideDataType {
+		case "Spherical Mapping":
+			projection = sd.Projection
+		case "Stereo 3D":
+			stereoMode = sd.Type
+		}
+	}
+	return projection, stereoMode, projection != ""
+}
+
+// probeSource runs ffprobe against a local file and decodes its JSON report.
+func probeSource(ctx context.Context, t Transcoder, inputPath string) (ProbeResult, error) {
+	// ffprobe -v error -print_format json -show_format -show_streams -show_chapters input
+	args := []string{
+		"-v", "error",
+		"-print_format", "json",
+		"-show_format",
+		"-show_streams",
+		"-show_chapters",
+		inputPath,
+	}
+	out, err := t.Probe(ctx, args...)
+	if err != nil {
+		return ProbeResult{}, fmt.Errorf("ffprobe error: %w", err)
+	}
+
+	var result ProbeResult
+	if err := json.Unmarshal(out, &result); err != nil {
+		return ProbeResult{}, fmt.Errorf("failed to decode ffprobe output: %w", err)
+	}
+	return result, nil
+}
+
+// parseProbeSeconds parses ffprobe's decimal second strings, e.g. "12.345000"
+func parseProbeSeconds(s string) (float64, error) {
+	return strconv.ParseFloat(s, 64)
+}
@@ -0,0 +1,36 @@
+package video
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamRotation(t *testing.T) {
+	var probe ProbeResult
+	require.NoError(t, json.Unmarshal([]byte(`{"streams":[{"codec_type":"video","width":1920,"height":1080,
+		"side_data_list":[{"side_data_type":"Display Matrix","displaymatrix":"...","rotation":-90}]}]}`), &probe))
+	stream, ok := probe.VideoStream()
+	require.True(t, ok)
+	require.Equal(t, 90, stream.Rotation())
+	width, height := stream.DisplaySize()
+	require.Equal(t, []int{1080, 1920}, []int{width, height})
+
+	testCases := []struct {
+		name   string
+		stream ProbeStream
+		want   int
+	}{
+		{name: "none", want: 0},
+		{name: "rotate tag of older ffprobe", stream: ProbeStream{Tags: map[string]string{"rotate": "270"}}, want: 270},
+		{name: "upside down", stream: ProbeStream{SideData: []ProbeSideData{{SideDataType: "Display Matrix", Rotation: 180}}}, want: 180},
+		{name: "counterclockwise", stream: ProbeStream{SideData: []ProbeSideData{{SideDataType: "Display Matrix", Rotation: 90}}}, want: 270},
+		{name: "other side data", stream: ProbeStream{SideData: []ProbeSideData{{SideDataType: "Stereo 3D", Type: "2D"}}}, want: 0},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, tc.stream.Rotation())
+		})
+	}
+}
@@ -2,18 +2,23 @@ package video
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io/fs"
 	"log"
 	"net/http"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"runtime"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 	"video-processing/database/db"
 	"video-processing/models"
+	"video-processing/storage"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgtype"
@@ -36,12 +41,44 @@ Example:
   go run main.go my-bucket uploads/input.mp4 processed/input-uuid/
 */
 
-// Variant represents a video variant configuration
+// Variant represents a video variant configuration, one rung of a transcoding preset
 type Variant struct {
-	Name    string // logical name like "1080p"
-	Width   int
-	Height  int
-	Bitrate string // e.g., "4000k"
+	Name     string // logical name like "1080p"
+	Width    int
+	Height   int
+	Bitrate  string // e.g., "4000k"; caps the bitrate of CRF encodes
+	HDR      bool   // keep the source's HDR signal instead of tone mapping to SDR
+	Vertical bool   // 9:16 crop of a landscape source
+	Codec    string // models.CodecH264 or models.CodecHEVC, H.264 when empty
+	CRF      int    // constant quality encode, 0 encodes at the bitrate
+	// EncoderPreset is the x264/x265 speed preset, "fast" when empty
+	EncoderPreset  string
+	Profile        string // H.264 profile, empty for the encoder's choice
+	Level          string // H.264 level like "3.1", empty for the encoder's choice
+	Tune           string // x264/x265 tune, empty for none
+	SegmentSeconds int    // HLS segment length, defaultSegmentSeconds when 0
+	AudioOnly      bool   // the audio-only rendition, listed without a resolution
+	// AudioChannels are the channels of surround audio the variant keeps, 0 for the stereo downmix
+	AudioChannels int
+}
+
+// hevc reports whether the variant is encoded as HEVC, which HLS carries in fMP4 segments
+func (v Variant) hevc() bool {
+	return v.HDR || v.Codec == models.CodecHEVC
+}
+
+func (v Variant) encoderPreset() string {
+	if v.EncoderPreset == "" {
+		return "fast"
+	}
+	return v.EncoderPreset
+}
+
+func (v Variant) segmentSeconds() int {
+	if v.SegmentSeconds <= 0 {
+		return defaultSegmentSeconds
+	}
+	return v.SegmentSeconds
 }
 
 // ProcessingTask represents a single video processing task
@@ -52,14 +89,68 @@ type ProcessingTask struct {
 	DestPrefix string
 	Bucket     string
 	VideoID    string
+	HDRFormat  string  // HDR format of the source, empty for SDR sources
+	Spherical  bool    // source carries 360° projection metadata that must survive transcoding
+	CropFocusX float64 // horizontal center of vertical crops as a share of the source width
+	// ClosedCaptions is set when the source carries CEA-608/708 captions in its video stream
+	ClosedCaptions bool
+	Threads        int     // ffmpeg encoder and filter threads, 0 for ffmpeg's default
+	ThumbnailAt    float64 // seconds into the video the variant thumbnail is taken from
+	// SmartThumbnail picks the variant thumbnail from the frames after ThumbnailAt instead
+	SmartThumbnail bool
+	// Chunks splits long sources into time slices that are transcoded in parallel, nil encodes in one go
+	Chunks   []chunkRange
+	Chunk    *chunkRange   // the slice transcodeToMP4 encodes, video only; nil for the whole source
+	HasAudio bool          // the source has an audio stream, needed to mux chunked encodes
+	Slots    chan struct{} // bounds the encodes running at once, taken per chunk for chunked variants
+	// AudioLanguages are the languages of the source's audio tracks; the MP4 and WebM keep them all
+	AudioLanguages []string
+	// Remux copies the source into the variant's MP4 since it already fits the rung
+	Remux bool
+	// Encoder encodes the H.264 variants, EncoderX264 when empty
+	Encoder string
+	// WebM also encodes the variant into VP9 and Opus for browsers that prefer WebM
+	WebM bool
+	// BurnSubtitles is the subtitles filter that burns captions into the picture, empty for none
+	BurnSubtitles string
+	// Watermark is composed over the picture, nil for none
+	Watermark *watermark
+	// Deinterlace runs the deinterlacer before any other filter
+	Deinterlace bool
+	// Denoise is the denoise filter run on the scaled picture, empty for none
+	Denoise string
+	// Crop cuts the black bars off the picture before any filter but deinterlacing and the
+	// frame rate, nil for none
+	Crop *cropArea
+	// FrameRate is the rate every variant is encoded at, as an fps filter rate, empty for the
+	// source's rate
+	FrameRate string
+	// Transcoded is set when the job's single pass already encoded the variant's MP4
+	Transcoded bool
+	// TwoPass encodes the MP4 in two passes where that helps, see twoPass
+	TwoPass bool
+	// Progress is told the seconds of the source transcoded so far, nil to run ffmpeg without
+	// progress reporting. It is never called concurrently, chunked variants serialize their calls.
+	Progress func(seconds float64)
+	// KeyInfo is the -hls_key_info_file the HLS segments are encrypted with, empty for clear segments
+	KeyInfo string
+}
+
+// encoder returns the backend that encodes the task's variant. HEVC variants stay on libx265
+// whatever the encoder.
+func (t ProcessingTask) encoder() videoEncoder {
+	if t.Variant.hevc() {
+		return x265Encoder{}
+	}
+	return videoEncoderFor(t.Encoder)
 }
 
 // UploadTask represents a file to be uploaded to MinIO
 type UploadTask struct {
-	SourcePath  string
-	ObjectKey   string
-	ContentType string
-	Bucket      string
+	SourcePath  string `json:"source_path"`
+	ObjectKey   string `json:"object_key"`
+	ContentType string `json:"content_type"`
+	Bucket      string `json:"bucket"`
 }
 
 // ProcessingResult represents the result of processing a single variant
@@ -71,19 +162,38 @@ type ProcessingResult struct {
 	Error    error
 	Files    []UploadTask
 	Metadata db.SaveProcessedVideoMetadataParams
+	Quality  *QualityScores // set when quality metrics are enabled and measured
+	// WebM is the row of the variant's WebM rendition, nil when none was encoded
+	WebM *db.SaveProcessedVideoMetadataParams
+	// MP3 is the row of the audio-only mp3 rendition, nil when none was encoded
+	MP3 *db.SaveProcessedVideoMetadataParams
+	// IFrameBandwidth is the peak bitrate of the variant's I-frame playlist, 0 when it has none
+	IFrameBandwidth int64
+	// AudioTracks are the audio tracks of a source with several, set on the audio variant
+	AudioTracks []audioTrack
+	// Codecs are the RFC 6381 codecs of the packaged variant, e.g. "avc1.640028,mp4a.40.2"
+	Codecs         string
+	ClosedCaptions bool // the renditions carry the source's embedded captions
 }
 
-var variants = []Variant{
-	{Name: "1080p", Width: 1920, Height: 1080, Bitrate: "4000k"},
-	{Name: "720p", Width: 1280, Height: 720, Bitrate: "2000k"},
-	{Name: "480p", Width: 854, Height: 480, Bitrate: "1000k"},
-	{Name: "360p", Width: 640, Height: 360, Bitrate: "500k"},
-	{Name: "240p", Width: 426, Height: 240, Bitrate: "250k"},
-	{Name: "144p", Width: 256, Height: 144, Bitrate: "100k"},
-}
+// Asset kinds stored in video_assets, one row per kind per video
+const (
+	AssetKindWaveform         = "waveform"
+	AssetKindWaveformImage    = "waveform_image"
+	AssetKindMasterPlaylist   = "master_playlist"
+	AssetKindVerticalPlaylist = "vertical_playlist"
+	AssetKindPreview          = "preview"
+	AssetKindCaptions         = "captions"
+	AssetKindAnimatedPreview  = "animated_preview"
+	AssetKindSprite           = "sprite"
+	AssetKindThumbnailsVTT    = "thumbnails_vtt"
+	AssetKindDRMPlaylist      = "drm_playlist"
+	AssetKindDRMManifest      = "drm_manifest"
+	AssetKindLivePlaylist     = "live_playlist"
+)
 
 // processVariant processes a single video variant
-func (rc *redisConsumer) processVariant(ctx context.Context, task ProcessingTask, resultChan chan<- ProcessingResult, wg *sync.WaitGroup) {
+func (rc *redisConsumer) processVariant(ctx context.Context, task ProcessingTask, resultChan chan<- ProcessingResult, uploadCh chan<- UploadTask, wg *sync.WaitGroup) {
 	defer wg.Done()
 
 	result := ProcessingResult{
@@ -91,6 +201,8 @@ func (rc *redisConsumer) processVariant(ctx context.Context, task ProcessingTask
 		VideoID: task.VideoID,
 		WorkDir: task.WorkDir,
 		Success: true,
+		// libx264 keeps A53 captions; HEVC variants are encoded without them
+		ClosedCaptions: task.ClosedCaptions && !task.Variant.hevc(),
 	}
 
 	// Create variant-specific directory
@@ -102,15 +214,46 @@ func (rc *redisConsumer) processVariant(ctx context.Context, task ProcessingTask
 		return
 	}
 
-	// 1. Transcode to MP4
-	mp4Path := filepath.Join(varDir, fmt.Sprintf("%s.mp4", task.Variant.Name))
-	if err := transcodeToMP4(ctx, task.SourcePath, mp4Path, task.Variant); err != nil {
+	// 1. Transcode to MP4, long sources in parallel chunks, compatible sources are only remuxed.
+	// Variants of the job's single pass already have theirs.
+	mp4Path := variantMP4Path(task)
+	var err error
+	if !task.Transcoded {
+		transcode := transcodeToMP4
+		switch {
+		case task.Remux:
+			rc.logger.Info("source matches variant, remuxing", "variant", task.Variant.Name, "videoID", task.VideoID)
+			transcode = remuxToMP4
+		case len(task.Chunks) > 1:
+			transcode = transcodeChunked
+		}
+		err = transcode(ctx, rc.transcoder, task, mp4Path)
+		if err != nil && !task.Remux && task.encoder().hardware() && ctx.Err() == nil {
+			// the device may be busy or lack a feature the variant needs, libx264 can do it all
+			rc.logger.Warn("hardware encode failed, falling back to libx264", "error", err, "encoder", task.Encoder, "variant", task.Variant.Name, "videoID", task.VideoID)
+			task.Encoder = EncoderX264
+			err = transcode(ctx, rc.transcoder, task, mp4Path)
+		}
+	}
+	if err != nil {
 		result.Success = false
 		result.Error = fmt.Errorf("transcode failed: %w", err)
 		resultChan <- result
 		return
 	}
 
+	// Score the rendition against the source. Tone mapped and HDR renditions
+	// cannot be compared with an HDR source meaningfully, so they are skipped.
+	if rc.processing.QualityMetrics && task.HDRFormat == "" {
+		logPath := filepath.Join(task.WorkDir, fmt.Sprintf("%s-vmaf.json", task.Variant.Name))
+		if scores, err := measureQuality(ctx, rc.transcoder, mp4Path, task.SourcePath, logPath); err != nil {
+			rc.logger.Warn("quality measurement failed", "error", err, "variant", task.Variant.Name)
+		} else {
+			result.Quality = &scores
+			rc.logger.Info("measured variant quality", "variant", task.Variant.Name, "vmaf", scores.VMAF, "psnr", scores.PSNR)
+		}
+	}
+
 	// 2. Generate HLS in the variant directory (same level as thumbnail)
 	hlsDir := varDir // Store HLS files directly in the variant directory
 	if err := os.MkdirAll(hlsDir, 0o755); err != nil {
@@ -120,24 +263,70 @@ func (rc *redisConsumer) processVariant(ctx context.Context, task ProcessingTask
 		return
 	}
 
-	if err := generateHLS(ctx, mp4Path, hlsDir); err != nil {
+	// Upload finished segments while the rest of the variant is still being packaged
+	destPrefix := filepath.ToSlash(filepath.Join(task.DestPrefix, task.Variant.Name))
+	watcher := newSegmentWatcher(hlsDir, "segment_*", destPrefix, task.Bucket, uploadCh)
+	packaged := make(chan struct{})
+	watched := make(chan struct{})
+	go func() {
+		watcher.watch(ctx, packaged)
+		close(watched)
+	}()
+	// remuxed variants keep the keyframes of the source, which do not line up with the other variants
+	packaging := hlsOptions{
+		StreamCopy: rc.processing.HLSStreamCopy && !task.Remux,
+		KeyInfo:    task.KeyInfo,
+	}
+	packaging.FMP4, err = fmp4Segments(rc.processing.HLSSegmentType)
+	if err == nil {
+		err = generateHLS(ctx, rc.transcoder, mp4Path, hlsDir, task.Variant, task.Threads, packaging)
+	}
+	close(packaged)
+	<-watched
+	if err != nil {
 		result.Success = false
 		result.Error = fmt.Errorf("HLS generation failed: %w", err)
 		resultChan <- result
 		return
 	}
 
+	// I-frame playlist for trick play; fMP4 segments, like the HEVC ones, are left without one,
+	// and so are encrypted segments, whose keyframes cannot be found
+	if !task.Variant.hevc() && !packaging.FMP4 && packaging.KeyInfo == "" {
+		if bandwidth, err := generateIFramePlaylist(ctx, rc.transcoder, hlsDir); err != nil {
+			rc.logger.Warn("I-frame playlist generation failed", "error", err, "variant", task.Variant.Name)
+		} else {
+			result.IFrameBandwidth = bandwidth
+		}
+	}
+
+	result.Codecs = renditionCodecs(ctx, rc.transcoder, task.Variant, hlsDir, mp4Path, task.HasAudio, packaging)
+
 	// 3. Generate thumbnail
 	thumbPath := filepath.Join(varDir, fmt.Sprintf("%s-thumb.jpg", task.Variant.Name))
-	if err := generateThumbnail(ctx, mp4Path, thumbPath, 5); err != nil {
+	if err := generateVariantThumbnail(ctx, rc.transcoder, mp4Path, thumbPath, task.ThumbnailAt, task.SmartThumbnail); err != nil {
 		rc.logger.Warn("thumbnail generation failed", "error", err, "variant", task.Variant.Name)
 		// Don't fail the whole process if thumbnail fails
 	}
 
-	// Prepare upload tasks
-	destPrefix := filepath.Join(task.DestPrefix, task.Variant.Name)
-	destPrefix = filepath.ToSlash(destPrefix) // Normalize to forward slashes
+	// 4. WebM rendition, optional like the thumbnail
+	webmPath := filepath.Join(varDir, fmt.Sprintf("%s.webm", task.Variant.Name))
+	webm := false
+	if task.WebM {
+		if err := transcodeToWebM(ctx, rc.transcoder, task, mp4Path, webmPath); err != nil {
+			rc.logger.Warn("WebM encode failed", "error", err, "variant", task.Variant.Name)
+		} else {
+			webm = true
+			result.Files = append(result.Files, UploadTask{
+				SourcePath:  webmPath,
+				ObjectKey:   filepath.ToSlash(filepath.Join(destPrefix, fmt.Sprintf("%s.webm", task.Variant.Name))),
+				ContentType: "video/webm",
+				Bucket:      task.Bucket,
+			})
+		}
+	}
 
+	// Prepare upload tasks
 	// Add MP4 file to upload tasks
 	result.Files = append(result.Files, UploadTask{
 		SourcePath:  mp4Path,
@@ -162,8 +351,9 @@ func (rc *redisConsumer) processVariant(ctx context.Context, task ProcessingTask
 		rc.logger.Warn("failed to list HLS files", "error", err, "variant", task.Variant.Name)
 	} else {
 		for _, hlsFile := range hlsFiles {
-			// Skip the MP4 and thumbnail files that are already added
-			if strings.HasSuffix(hlsFile, ".mp4") || strings.HasSuffix(hlsFile, "-thumb.jpg") {
+			// Skip the MP4 and thumbnail files that are already added,
+			// and the segments uploaded while packaging
+			if hlsFile == mp4Path || hlsFile == thumbPath || hlsFile == webmPath || watcher.isQueued(hlsFile) {
 				continue
 			}
 			ext := filepath.Ext(hlsFile)
@@ -221,14 +411,35 @@ func (rc *redisConsumer) processVariant(ctx context.Context, task ProcessingTask
 			Int32: int32(bitrate),
 			Valid: true,
 		},
+		Format:         FormatMP4,
+		AudioLanguages: task.AudioLanguages,
+	}
+	if webm {
+		metadata := webmMetadata(result.Metadata, destPrefix, task.Variant.Name)
+		result.WebM = &metadata
 	}
 
-	rc.logger.Info("prepared variant metadata", 
+	rc.logger.Info("prepared variant metadata",
 		"variant", task.Variant.Name,
 		"hls_playlist", hlsPlaylistPath,
 		"thumbnail", thumbnailPath,
 	)
 
+	err = rc.hooks.run(ctx, HookEvent{
+		Point:      HookAfterVariant,
+		VideoID:    task.VideoID,
+		Bucket:     task.Bucket,
+		SourcePath: task.SourcePath,
+		WorkDir:    task.WorkDir,
+		DestPrefix: destPrefix,
+		Variant:    task.Variant.Name,
+		Files:      result.Files,
+	})
+	if err != nil {
+		result.Success = false
+		result.Error = err
+	}
+
 	resultChan <- result
 }
 
@@ -265,16 +476,116 @@ func (rc *redisConsumer) saveVariantMetadata(ctx context.Context, result Process
 		return
 	}
 
-	_, err := rc.db.SaveProcessedVideoMetadata(ctx, result.Metadata)
+	metadata := result.Metadata
+	err := rc.hooks.run(ctx, HookEvent{
+		Point:    HookBeforeMetadataSave,
+		VideoID:  result.VideoID,
+		Bucket:   metadata.Bucket,
+		WorkDir:  result.WorkDir,
+		Variant:  result.Variant.Name,
+		Metadata: &metadata,
+	})
+	if err != nil {
+		rc.logger.Error("skipping metadata save rejected by hook",
+			"variant", result.Variant.Name,
+			"error", err)
+		return
+	}
+
+	_, err = rc.db.SaveProcessedVideoMetadata(ctx, metadata)
 	if err != nil {
 		rc.logger.Error("failed to save variant metadata",
 			"variant", result.Variant.Name,
 			"error", err)
+		return
+	}
+	rc.logger.Info("saved variant metadata",
+		"variant", result.Variant.Name,
+		"videoID", result.VideoID)
+
+	// rows of the other containers of the variant
+	for _, other := range []*db.SaveProcessedVideoMetadataParams{result.WebM, result.MP3} {
+		if other == nil {
+			continue
+		}
+		if _, err := rc.db.SaveProcessedVideoMetadata(ctx, *other); err != nil {
+			rc.logger.Error("failed to save variant metadata",
+				"variant", result.Variant.Name,
+				"format", other.Format,
+				"error", err)
+		}
+	}
+
+	if result.Quality != nil {
+		err := rc.db.UpdateVariantQuality(ctx, db.UpdateVariantQualityParams{
+			Vmaf:        pgtype.Float8{Float64: result.Quality.VMAF, Valid: true},
+			Psnr:        pgtype.Float8{Float64: result.Quality.PSNR, Valid: true},
+			VideoID:     result.Metadata.VideoID,
+			VariantName: result.Metadata.VariantName,
+		})
+		if err != nil {
+			rc.logger.Error("failed to save variant quality",
+				"variant", result.Variant.Name,
+				"error", err)
+		}
+	}
+}
+
+// saveVideoAsset records a per-video artifact that has been queued for upload
+func (rc *redisConsumer) saveVideoAsset(ctx context.Context, videoID uuid.UUID, kind string, file UploadTask) {
+	_, err := rc.db.SaveVideoAsset(ctx, db.SaveVideoAssetParams{
+		VideoID:     videoID,
+		Kind:        kind,
+		Bucket:      file.Bucket,
+		Key:         file.ObjectKey,
+		ContentType: file.ContentType,
+	})
+	if err != nil {
+		rc.logger.Error("failed to save video asset",
+			"kind", kind,
+			"videoID", videoID,
+			"error", err)
 	} else {
-		rc.logger.Info("saved variant metadata",
-			"variant", result.Variant.Name,
-			"videoID", result.VideoID)
+		rc.logger.Info("saved video asset",
+			"kind", kind,
+			"videoID", videoID)
+	}
+}
+
+// saveColorMetadata stores the color description of the source video stream
+func (rc *redisConsumer) saveColorMetadata(ctx context.Context, videoID uuid.UUID, stream ProbeStream) {
+	text := func(s string) pgtype.Text {
+		return pgtype.Text{String: s, Valid: s != "" && s != "unknown"}
+	}
+	err := rc.db.UpdateVideoColorMetadata(ctx, db.UpdateVideoColorMetadataParams{
+		ColorPrimaries: text(stream.ColorPrimaries),
+		ColorTransfer:  text(stream.ColorTransfer),
+		ColorSpace:     text(stream.ColorSpace),
+		HdrFormat:      text(stream.HDRFormat()),
+		ID:             videoID,
+	})
+	if err != nil {
+		rc.logger.Error("failed to save color metadata", "error", err, "videoID", videoID)
+	}
+}
+
+// saveProjection stores the 360° metadata of the source video stream and
+// reports whether the source is spherical.
+func (rc *redisConsumer) saveProjection(ctx context.Context, videoID uuid.UUID, stream ProbeStream) bool {
+	projection, stereoMode, ok := stream.Spherical()
+	if !ok {
+		return false
+	}
+	rc.logger.Info("spherical source detected", "videoID", videoID, "projection", projection, "stereo_mode", stereoMode)
+	err := rc.db.UpdateVideoProjection(ctx, db.UpdateVideoProjectionParams{
+		Projection: pgtype.Text{String: projection, Valid: true},
+		StereoMode: pgtype.Text{String: stereoMode, Valid: stereoMode != ""},
+		ID:         videoID,
+	})
+	if err != nil {
+		rc.logger.Error("failed to save projection metadata", "error", err, "videoID", videoID)
 	}
+	return true
 }
 
 func (rc *redisConsumer) ProcessVideo(ctx context.Context, values map[string]interface{}) error {
@@ -282,33 +593,40 @@ func (rc *redisConsumer) ProcessVideo(ctx context.Context, values map[string]int
 	bucket := values["bucket"].(string)
 	sourceObj := values["key"].(string)
 	videoID := values["video_id"].(string)
-	resultsPrefix := fmt.Sprintf("processed/%s", uuid.New().String())
+	userID, _ := values["user_id"].(string)
+	resultsPrefix := storage.OwnerPrefix(userID, sourceObj) + processedPrefix + uuid.New().String()
 
-	// Create a temp working dir for the job; cleaned up on exit
-	workDir, err := os.MkdirTemp("", "video-job-*")
+	// The ladder of the job's preset; the HDR and vertical rungs depend on the source
+	presetLadder, err := rc.loadLadder(ctx, values)
 	if err != nil {
 		return models.Error{
 			Code:        http.StatusInternalServerError,
 			Message:     "internal server error",
-			Description: "failed to create working directory",
-			Params:      fmt.Sprintf("bucket: %v, sourceObj: %v", bucket, sourceObj),
-			Err:         fmt.Errorf("failed to create temp dir: %w", err),
+			Description: "failed to load the transcoding preset",
+			Params:      fmt.Sprintf("videoID: %v, preset: %v", videoID, values["preset_id"]),
+			Err:         err,
 		}
 	}
-	defer os.RemoveAll(workDir)
+	// The processing mode trades encoding time for quality
+	modeName, mode := rc.jobMode(values)
+	presetLadder = presetLadder.withEncoderPreset(mode.EncoderPreset)
+
+	// Create a working dir for the job on the scratch space; cleaned up on exit
+	workDir, release, err := rc.acquireWorkDir(ctx, bucket, sourceObj, "video-job-*")
+	if err != nil {
+		return workDirError(err, fmt.Sprintf("bucket: %v, sourceObj: %v", bucket, sourceObj))
+	}
+	defer release()
 
 	rc.logger.Info("starting video processing",
 		"videoID", videoID,
 		"source", sourceObj,
+		"mode", modeName,
 		"workDir", workDir)
 
-	// Step 1: Download source video from MinIO
-	localSourcePath := filepath.Join(workDir, "source"+filepath.Ext(sourceObj))
-	rc.logger.Info("downloading source video",
-		"source", fmt.Sprintf("s3://%s/%s", bucket, sourceObj),
-		"destination", localSourcePath)
-
-	if err := downloadFromMinio(ctx, rc.mc, bucket, sourceObj, localSourcePath); err != nil {
+	// Step 1: Fetch the source video from MinIO, or let ffmpeg stream it
+	sourcePath, err := rc.fetchSource(ctx, bucket, sourceObj, workDir)
+	if err != nil {
 		return models.Error{
 			Code:        http.StatusInternalServerError,
 			Message:     "download failed",
@@ -317,11 +635,184 @@ func (rc *redisConsumer) ProcessVideo(ctx context.Context, values map[string]int
 			Err:         err,
 		}
 	}
+	err = rc.hooks.run(ctx, HookEvent{
+		Point:      HookAfterDownload,
+		VideoID:    videoID,
+		Bucket:     bucket,
+		SourceKey:  sourceObj,
+		SourcePath: sourcePath,
+		WorkDir:    workDir,
+		DestPrefix: resultsPrefix,
+	})
+	if err != nil {
+		return models.Error{
+			Code:        http.StatusInternalServerError,
+			Message:     "hook failed",
+			Description: "processing stopped by an after download hook",
+			Params:      fmt.Sprintf("bucket: %v, source: %v", bucket, sourceObj),
+			Err:         err,
+		}
+	}
 
-	rc.logger.Info("source download complete", "path", localSourcePath)
+	// Cut the source to the range the uploader kept, everything below reads the cut
+	sourcePath, err = rc.trimSource(ctx, values, sourcePath, workDir)
+	if err != nil {
+		return models.Error{
+			Code:        http.StatusInternalServerError,
+			Message:     "trim failed",
+			Description: "failed to trim the source video",
+			Params:      fmt.Sprintf("videoID: %v, start: %v, end: %v", videoID, values["trim_start"], values["trim_end"]),
+			Err:         err,
+		}
+	}
+
+	// Steady shaky handheld sources once, so every variant and preview reads the stabilized picture
+	sourcePath, err = rc.stabilizeSource(ctx, values, sourcePath, workDir)
+	if err != nil {
+		return models.Error{
+			Code:        http.StatusInternalServerError,
+			Message:     "stabilization failed",
+			Description: "failed to stabilize the source video",
+			Params:      fmt.Sprintf("videoID: %v, source: %v", videoID, sourcePath),
+			Err:         err,
+		}
+	}
+
+	// Join the intro and the outro, so every variant and preview plays them
+	sourcePath = rc.attachBumpers(ctx, values, sourcePath, workDir)
+
+	// Reject sources that are not a video the pipeline can encode before fanning out variants
+	probe, err := rc.checkSource(ctx, videoID, sourcePath)
+	if err != nil {
+		return err
+	}
+
+	// Inspect the source: chapter markers and color metadata
+	jobVariants := presetLadder.regular
+	var spherical bool
+	// ffmpeg turns rotated pictures upright while decoding, so the
+	// ladder follows the size they are displayed at
+	sourceStream, _ := probe.VideoStream()
+	sourceStream.Width, sourceStream.Height = sourceStream.DisplaySize()
+	closedCaptions := sourceStream.ClosedCaptions == 1
+	hdrFormat := sourceStream.HDRFormat()
+	if videoUUID, err := uuid.Parse(videoID); err == nil {
+		rc.saveProbe(ctx, videoUUID, probe)
+		rc.saveSourceMetadata(ctx, videoUUID, probe)
+		rc.saveSourceChapters(ctx, videoUUID, probe)
+		if rc.processing.SceneChapters {
+			rc.saveSceneChapters(ctx, videoUUID, sourcePath, probe)
+		}
+		rc.saveSourceResolution(ctx, videoUUID, sourceStream)
+		rc.saveColorMetadata(ctx, videoUUID, sourceStream)
+		spherical = rc.saveProjection(ctx, videoUUID, sourceStream)
+	}
+	if hdrFormat != "" {
+		rc.logger.Info("HDR source detected, tone mapping SDR variants", "videoID", videoID, "hdr_format", hdrFormat)
+		if !rc.processing.DisableHDRVariant {
+			jobVariants = append(append([]Variant{}, jobVariants...), presetLadder.hdr...)
+		}
+	}
+	// Interlaced sources look combed once scaled, so they are deinterlaced first
+	deinterlace := rc.shouldDeinterlace(ctx, values, sourcePath, probe)
+	if deinterlace {
+		rc.logger.Info("deinterlacing the source", "videoID", videoID, "field_order", sourceStream.FieldOrder)
+	}
+	// Fast sources, e.g. 120fps screen recordings, are brought down to the configured rate.
+	// Every variant gets the same rate, so their keyframes line up.
+	frameRate, err := outputFrameRate(rc.processing.FrameRate, sourceStream.RFrameRate)
+	if err != nil {
+		rc.logger.Warn("invalid frame rate, keeping the source's", "error", err)
+	} else if frameRate != "" {
+		rc.logger.Info("normalizing the frame rate", "videoID", videoID, "source", sourceStream.RFrameRate, "frame_rate", frameRate)
+	}
+	// Letterboxed and pillarboxed sources lose their bars when the job asks, so the variants hold
+	// only picture. The ladder then follows the size of the picture.
+	var crop *cropArea
+	if cropRequested(values) && !spherical {
+		area, ok, err := detectBlackBars(ctx, rc.transcoder, sourcePath, probe.Duration(), sourceStream.Width, sourceStream.Height)
+		switch {
+		case err != nil:
+			rc.logger.Warn("black bar detection failed, keeping the full frame", "error", err, "videoID", videoID)
+		case ok:
+			rc.logger.Info("cropping black bars", "videoID", videoID, "width", sourceStream.Width, "height", sourceStream.Height,
+				"crop", area.filter())
+			crop = &area
+			sourceStream.Width, sourceStream.Height = area.Width, area.Height
+		}
+	}
+	// Noisy low-light sources waste the bitrate of the variants on grain
+	denoise := rc.jobDenoise(values)
+	if denoise != "" {
+		rc.logger.Info("denoising variants", "videoID", videoID, "filter", denoise)
+	}
+	// Vertical crops of a 360° picture make no sense, and portrait sources already fit
+	cropFocusX := 0.5
+	if rc.processing.VerticalVariants && len(presetLadder.vertical) > 0 && !spherical && sourceStream.Width > sourceStream.Height {
+		if rc.processing.VerticalCrop == VerticalCropSmart {
+			if focus, err := detectCropFocus(ctx, rc.transcoder, sourcePath, probe.Duration(), sourceStream.Width, sourceStream.Height); err != nil {
+				rc.logger.Warn("crop focus detection failed, cropping the center", "error", err, "videoID", videoID)
+			} else {
+				cropFocusX = focus
+			}
+		}
+		rc.logger.Info("adding vertical variants", "videoID", videoID, "crop_focus_x", cropFocusX)
+		jobVariants = append(append([]Variant{}, jobVariants...), presetLadder.vertical...)
+	}
+
+	// Portrait sources, and phone videos rotated for display, get upright variants
+	if sourceStream.Height > sourceStream.Width {
+		rc.logger.Info("portrait source, turning the ladder upright", "videoID", videoID,
+			"width", sourceStream.Width, "height", sourceStream.Height, "rotation", sourceStream.Rotation())
+		jobVariants = orientLadder(jobVariants, sourceStream.Width, sourceStream.Height)
+	}
+
+	if crop != nil {
+		jobVariants = fitLadder(jobVariants, sourceStream.Width, sourceStream.Height)
+	}
+
+	// Upscaling adds bytes without detail, so variants larger than the source are left out
+	jobVariants, upscaled := withoutUpscaling(jobVariants, sourceStream.Width, sourceStream.Height)
+	if len(upscaled) > 0 {
+		rc.logger.Info("skipping variants larger than the source", "videoID", videoID,
+			"width", sourceStream.Width, "height", sourceStream.Height, "skipped", upscaled)
+	}
+	// Per-title ladder: simple content gets lower bitrates, complex content higher ones
+	if rc.processing.PerTitle {
+		jobVariants = rc.perTitle(ctx, videoID, sourcePath, workDir, probe.Duration(), hdrFormat, jobVariants)
+	}
+	// The largest variants keep the 5.1 or 7.1 audio of surround sources
+	if channels := surroundChannels(probe); rc.processing.SurroundAudio && channels > 0 {
+		n := rc.processing.SurroundVariants
+		if n <= 0 {
+			n = defaultSurroundVariants
+		}
+		rc.logger.Info("keeping surround audio", "videoID", videoID, "channels", channels, "variants", n)
+		jobVariants = withSurround(jobVariants, channels, n)
+	}
+
+	// Keep the rendition set this run replaces, so the video can be rolled back to it
+	firstRun := false
+	if videoUUID, err := uuid.Parse(videoID); err == nil {
+		firstRun = rc.archiveRenditions(ctx, videoUUID)
+		rc.resetProgress(ctx, videoUUID)
+	}
+	// The segments of every rendition are encrypted with the video's key
+	var keyInfo string
+	if rc.processing.HLSEncryption.Enabled {
+		if keyInfo, err = rc.hlsKeyInfo(ctx, videoID, workDir); err != nil {
+			return models.Error{
+				Code:        http.StatusInternalServerError,
+				Message:     "encryption failed",
+				Description: "failed to prepare the HLS key",
+				Params:      fmt.Sprintf("videoID: %v", videoID),
+				Err:         err,
+			}
+		}
+	}
 
 	// Create channels for the pipeline
-	resultCh := make(chan ProcessingResult, len(variants))
+	resultCh := make(chan ProcessingResult, len(jobVariants)+1)
 	uploadCh := make(chan UploadTask, 100) // Buffer some upload tasks
 
 	// Start the upload workers
@@ -333,6 +824,7 @@ func (rc *redisConsumer) ProcessVideo(ctx context.Context, values map[string]int
 	}
 
 	// Start a goroutine to process results and queue uploads
+	var completed []ProcessingResult // owned by the result goroutine until resultWg is done
 	var resultWg sync.WaitGroup
 	resultWg.Add(1)
 	go func() {
@@ -351,6 +843,8 @@ func (rc *redisConsumer) ProcessVideo(ctx context.Context, values map[string]int
 				}
 				// Save metadata to database
 				rc.saveVariantMetadata(ctx, result)
+				rc.finishProgress(ctx, result)
+				completed = append(completed, result)
 			} else if !result.Success {
 				rc.logger.Error("variant processing failed",
 					"variant", result.Variant.Name,
@@ -361,18 +855,295 @@ func (rc *redisConsumer) ProcessVideo(ctx context.Context, values map[string]int
 
 	// Process each variant in parallel
 	var processWg sync.WaitGroup
-	for _, variant := range variants {
+
+	// Generate the audio waveform alongside the variants
+	processWg.Add(1)
+	go func() {
+		defer processWg.Done()
+		rc.processWaveform(ctx, ProcessingTask{
+			WorkDir:    workDir,
+			SourcePath: sourcePath,
+			DestPrefix: resultsPrefix,
+			Bucket:     bucket,
+			VideoID:    videoID,
+		}, uploadCh)
+	}()
+
+	// Extract embedded captions into a WebVTT sidecar
+	if closedCaptions && rc.processing.ExtractCaptions {
+		processWg.Add(1)
+		go func() {
+			defer processWg.Done()
+			rc.processCaptions(ctx, ProcessingTask{
+				WorkDir:    workDir,
+				SourcePath: sourcePath,
+				DestPrefix: resultsPrefix,
+				Bucket:     bucket,
+				VideoID:    videoID,
+			}, uploadCh)
+		}()
+	}
+
+	// Audio-only renditions for podcast playback and the HLS audio fallback
+	audioLanguages := probe.AudioLanguages()
+	if len(audioLanguages) > 1 {
+		rc.logger.Info("keeping every audio track", "videoID", videoID, "languages", audioLanguages)
+	}
+	if rc.processing.AudioRendition && probe.HasAudio() {
+		processWg.Add(1)
+		go func() {
+			defer processWg.Done()
+			rc.processAudio(ctx, ProcessingTask{
+				WorkDir:        workDir,
+				SourcePath:     sourcePath,
+				DestPrefix:     resultsPrefix,
+				Bucket:         bucket,
+				VideoID:        videoID,
+				AudioLanguages: audioLanguages,
+				KeyInfo:        keyInfo,
+			}, resultCh)
+		}()
+	}
+
+	// Subtitles burned into the picture for platforms without sidecar captions
+	burnIn := rc.burnInSubtitles(ctx, values, bucket, sourcePath, workDir, probe)
+	if burnIn != "" {
+		rc.logger.Info("burning in subtitles", "videoID", videoID)
+	}
+	// The owner's or the platform watermark, composed onto every variant
+	mark := rc.loadWatermark(ctx, values, workDir)
+	if mark != nil {
+		rc.logger.Info("watermarking variants", "videoID", videoID, "position", mark.Position)
+	}
+
+	// Convert the text subtitle tracks into WebVTT sidecars
+	if rc.processing.ExtractSubtitles {
+		tracks, skipped := subtitleTracks(probe)
+		if len(skipped) > 0 {
+			rc.logger.Info("skipping bitmap subtitle tracks", "videoID", videoID, "codecs", skipped)
+		}
+		if len(tracks) > 0 {
+			processWg.Add(1)
+			go func() {
+				defer processWg.Done()
+				rc.processSubtitles(ctx, ProcessingTask{
+					WorkDir:    workDir,
+					SourcePath: sourcePath,
+					DestPrefix: resultsPrefix,
+					Bucket:     bucket,
+					VideoID:    videoID,
+				}, tracks, uploadCh)
+			}()
+		}
+	}
+
+	// Encode the preview clip shown on browsing pages
+	processWg.Add(1)
+	go func() {
+		defer processWg.Done()
+		rc.processPreview(ctx, ProcessingTask{
+			WorkDir:    workDir,
+			SourcePath: sourcePath,
+			DestPrefix: resultsPrefix,
+			Bucket:     bucket,
+			VideoID:    videoID,
+			HDRFormat:  hdrFormat,
+		}, probe.Duration(), uploadCh)
+	}()
+
+	// Encode the animated preview played on hover in listings
+	if rc.processing.AnimatedPreview {
 		processWg.Add(1)
-		task := ProcessingTask{
+		go func() {
+			defer processWg.Done()
+			rc.processAnimatedPreview(ctx, ProcessingTask{
+				WorkDir:    workDir,
+				SourcePath: sourcePath,
+				DestPrefix: resultsPrefix,
+				Bucket:     bucket,
+				VideoID:    videoID,
+				HDRFormat:  hdrFormat,
+			}, probe.Duration(), uploadCh)
+		}()
+	}
+
+	// Take the thumbnails the owner picks the primary one from
+	processWg.Add(1)
+	go func() {
+		defer processWg.Done()
+		rc.processThumbnails(ctx, ProcessingTask{
+			WorkDir:    workDir,
+			SourcePath: sourcePath,
+			DestPrefix: resultsPrefix,
+			Bucket:     bucket,
+			VideoID:    videoID,
+			HDRFormat:  hdrFormat,
+		}, probe.Duration(), uploadCh)
+	}()
+
+	// Tile seek previews into a sprite sheet described by thumbnails.vtt
+	processWg.Add(1)
+	go func() {
+		defer processWg.Done()
+		rc.processStoryboard(ctx, ProcessingTask{
+			WorkDir:    workDir,
+			SourcePath: sourcePath,
+			DestPrefix: resultsPrefix,
+			Bucket:     bucket,
+			VideoID:    videoID,
+			HDRFormat:  hdrFormat,
+		}, probe, uploadCh)
+	}()
+
+	// Fingerprint the source for duplicate detection
+	processWg.Add(1)
+	go func() {
+		defer processWg.Done()
+		rc.processFingerprint(ctx, ProcessingTask{
+			SourcePath: sourcePath,
+			VideoID:    videoID,
+		}, probe.Duration())
+	}()
+
+	// Long sources are split into chunks that are encoded in parallel across all variants
+	var chunks []chunkRange
+	if threshold := rc.processing.ChunkedMinDuration; threshold > 0 && probe.Duration() >= threshold.Seconds() {
+		chunkSeconds := rc.processing.ChunkDuration.Seconds()
+		if chunkSeconds <= 0 {
+			chunkSeconds = defaultChunkSeconds
+		}
+		chunks = splitChunks(probe.Duration(), chunkSeconds)
+		rc.logger.Info("transcoding in chunks", "videoID", videoID, "duration", probe.Duration(), "chunks", len(chunks))
+	}
+	chunked := len(chunks) > 1
+
+	// Bound the number of encodes running at once and split the cores between them.
+	// A chunked variant runs one encode per chunk, otherwise one per variant.
+	cpus := runtime.NumCPU()
+	if limit := rc.processing.FFmpeg.Threads; limit > 0 && limit < cpus {
+		cpus = limit
+	}
+	parallel := len(jobVariants)
+	if chunked {
+		parallel = min(len(jobVariants)*len(chunks), cpus)
+	}
+	if limit := rc.processing.MaxParallelVariants; limit > 0 && limit < parallel {
+		parallel = limit
+	}
+	threads := encoderThreads(cpus, parallel)
+	thumbnailPosition := rc.processing.ThumbnailAt
+	if thumbnailPosition == "" {
+		thumbnailPosition = ThumbnailAuto
+	}
+	smartThumbnail := thumbnailPosition == ThumbnailAuto
+	if smartThumbnail {
+		thumbnailPosition = smartThumbnailFrom
+	}
+	thumbnailAt, err := thumbnailOffset(thumbnailPosition, probe.Duration())
+	if err != nil {
+		rc.logger.Warn("invalid thumbnail position, using default", "error", err)
+		thumbnailAt, _ = thumbnailOffset(defaultThumbnailAt, probe.Duration())
+	}
+	slots := make(chan struct{}, parallel)
+	rc.logger.Info("encoding variants", "videoID", videoID, "variants", len(jobVariants), "parallel", parallel, "threads", threads)
+
+	tasks := make([]ProcessingTask, 0, len(jobVariants))
+	for _, variant := range jobVariants {
+		tasks = append(tasks, ProcessingTask{
 			Variant:    variant,
 			WorkDir:    workDir,
-			SourcePath: localSourcePath,
+			SourcePath: sourcePath,
 			DestPrefix: resultsPrefix,
 			Bucket:     bucket,
 			VideoID:    videoID,
+			HDRFormat:  hdrFormat,
+			Spherical:  spherical,
+			CropFocusX: cropFocusX,
+			// captions survive cropping, so vertical variants keep them as well
+			ClosedCaptions: closedCaptions,
+			Threads:        threads,
+			ThumbnailAt:    thumbnailAt,
+			SmartThumbnail: smartThumbnail,
+			Chunks:         chunks,
+			HasAudio:       probe.HasAudio(),
+			AudioLanguages: audioLanguages,
+			Slots:          slots,
+			Remux:          canRemux(probe, variant) && burnIn == "" && mark == nil && !deinterlace && frameRate == "" && denoise == "" && crop == nil, // burned in subtitles, watermarks, deinterlacing, frame rate changes, denoising and cropping need a re-encode
+			Encoder:        rc.processing.Encoder,
+			// the HDR variant stays HEVC only, VP9 would need its own 10-bit signaling
+			WebM:          rc.processing.WebM && !variant.HDR,
+			BurnSubtitles: burnIn,
+			Watermark:     mark,
+			Deinterlace:   deinterlace,
+			Denoise:       denoise,
+			Crop:          crop,
+			FrameRate:     frameRate,
+			TwoPass:       mode.TwoPass,
+			Progress:      rc.variantProgress(ctx, videoID, variant.Name, probe.Duration()),
+			KeyInfo:       keyInfo,
+		})
+	}
+	// A newly uploaded video plays from a small variant while the rest of the ladder encodes.
+	// Reprocessed videos keep playing their previous set meanwhile.
+	playlistTask := ProcessingTask{
+		WorkDir:    workDir,
+		DestPrefix: resultsPrefix,
+		Bucket:     bucket,
+		VideoID:    videoID,
+	}
+	var proxy *ProcessingResult
+	proxyName := rc.processing.ProxyVariant
+	if proxyName == "" {
+		proxyName = defaultProxyVariant
+	}
+	// In low-latency mode the proxy is published as it encodes, next to the ladder, which
+	// still encodes the proxy variant for the final set. live tracks the proxy in either mode.
+	var live sync.WaitGroup
+	if rc.processing.LowLatency.Enabled && firstRun {
+		if i := proxyTask(tasks, proxyName); i >= 0 {
+			live.Add(1)
+			go func(task ProcessingTask) {
+				defer live.Done()
+				rc.publishLive(ctx, task, playlistTask)
+			}(tasks[i])
 		}
+	} else if rc.processing.ProxyFirst && firstRun && len(tasks) > 1 {
+		// the proxy encodes next to the rest of the ladder and is published as soon as it is done
+		if i := proxyTask(tasks, proxyName); i >= 0 {
+			task := tasks[i]
+			tasks = slices.Delete(tasks, i, i+1)
+			// the proxy reserves a slot before the ladder starts, so the ladder never holds it up
+			slots <- struct{}{}
+			if chunked {
+				// its chunks take turns in that slot
+				task.Slots = make(chan struct{}, 1)
+			}
+			live.Add(1)
+			go func() {
+				defer live.Done()
+				defer func() { <-slots }()
+				result := rc.publishProxy(ctx, task, playlistTask)
+				if result.Success {
+					proxy = &result
+				} else {
+					rc.logger.Error("variant processing failed", "variant", result.Variant.Name, "error", result.Error)
+				}
+			}()
+		}
+	}
+	// Decode the source once for the variants that can share it, chunks are already parallel.
+	// Two-pass encodes are planned per variant.
+	if rc.processing.SinglePass && !chunked && !mode.TwoPass {
+		rc.encodeLadder(ctx, tasks)
+	}
+	for _, task := range tasks {
+		processWg.Add(1)
 		go func(t ProcessingTask) {
-			rc.processVariant(ctx, t, resultCh, &processWg)
+			if !chunked {
+				slots <- struct{}{}
+				defer func() { <-slots }()
+			}
+			rc.processVariant(ctx, t, resultCh, uploadCh, &processWg)
 		}(task)
 	}
 
@@ -382,6 +1153,34 @@ func (rc *redisConsumer) ProcessVideo(ctx context.Context, values map[string]int
 
 	// Wait for all processing to complete
 	resultWg.Wait()
+	// the proxy must not mark the video playable after it is processed, nor replace its master playlist
+	live.Wait()
+	if proxy != nil {
+		completed = append(completed, *proxy)
+	}
+
+	// Master playlists for the regular ladder and the vertical family, both falling back to the audio
+	var ladder, vertical, audio []ProcessingResult
+	for _, result := range completed {
+		switch {
+		case result.Variant.AudioOnly:
+			audio = append(audio, result)
+		case result.Variant.Vertical:
+			vertical = append(vertical, result)
+		default:
+			ladder = append(ladder, result)
+		}
+	}
+	if len(ladder) > 0 {
+		rc.publishMasterPlaylist(ctx, playlistTask, "master.m3u8", AssetKindMasterPlaylist, append(ladder, audio...), uploadCh)
+	}
+	if len(vertical) > 0 {
+		rc.publishMasterPlaylist(ctx, playlistTask, "vertical.m3u8", AssetKindVerticalPlaylist, append(vertical, audio...), uploadCh)
+	}
+	// Premium modes also get the ladder encrypted for DRM playback
+	if mode.DRM && len(ladder) > 0 {
+		rc.publishDRM(ctx, playlistTask, ladder, audio, probe.HasAudio(), uploadCh)
+	}
 
 	rc.logger.Debug("all variants processed, waiting for uploads to complete", "videoID", videoID)
 
@@ -390,6 +1189,29 @@ func (rc *redisConsumer) ProcessVideo(ctx context.Context, values map[string]int
 	uploadWg.Wait()
 
 	rc.logger.Info("all processing and uploads completed", "videoID", videoID)
+	if len(completed) > 0 {
+		rc.setVideoStatus(ctx, videoID, models.VideoProcessed)
+	}
+	rc.meterProcessing(ctx, values, bucket, resultsPrefix, probe.Duration(), len(completed))
+
+	err = rc.hooks.run(ctx, HookEvent{
+		Point:      HookAfterCompletion,
+		VideoID:    videoID,
+		Bucket:     bucket,
+		SourceKey:  sourceObj,
+		SourcePath: sourcePath,
+		WorkDir:    workDir,
+		DestPrefix: resultsPrefix,
+	})
+	if err != nil {
+		return models.Error{
+			Code:        http.StatusInternalServerError,
+			Message:     "hook failed",
+			Description: "after completion hook failed",
+			Params:      fmt.Sprintf("bucket: %v, source: %v", bucket, sourceObj),
+			Err:         err,
+		}
+	}
 
 	// Clean up working directory
 	if err := os.RemoveAll(workDir); err != nil {
@@ -402,9 +1224,86 @@ func (rc *redisConsumer) ProcessVideo(ctx context.Context, values map[string]int
 	return nil
 }
 
+// sourceURLExpiry bounds how long a streamed source stays readable; it must outlast the job
+const sourceURLExpiry = 12 * time.Hour
+
+// acquireWorkDir creates a job working directory on the scratch space, reserving room
+// for the job based on the size of its source object.
+func (rc *redisConsumer) acquireWorkDir(ctx context.Context, bucket, object, pattern string) (string, func(), error) {
+	var need int64
+	if info, err := rc.mc.StatObject(ctx, bucket, object, minio.StatObjectOptions{}); err != nil {
+		rc.logger.Warn("failed to stat source, not reserving scratch space", "error", err, "source", object)
+	} else {
+		need = info.Size * scratchSourceFactor
+	}
+	return rc.scratch.Acquire(pattern, need)
+}
+
+// workDirError wraps a failure to set up a job working directory
+func workDirError(err error, params string) error {
+	if errors.Is(err, errScratchExhausted) {
+		return models.Error{
+			Code:        http.StatusServiceUnavailable,
+			Message:     "worker busy",
+			Description: "not enough scratch space for the job",
+			Params:      params,
+			Err:         err,
+		}
+	}
+	return models.Error{
+		Code:        http.StatusInternalServerError,
+		Message:     "internal server error",
+		Description: "failed to create working directory",
+		Params:      params,
+		Err:         err,
+	}
+}
+
+// fetchSource returns the input ffmpeg reads the source object from. By default the object is
+// downloaded into workDir first, or linked from the source cache when it was downloaded before. With StreamSource ffmpeg reads a presigned URL instead, so work
+// starts right away and the source never takes scratch space; ffmpeg seeks with range requests.
+func (rc *redisConsumer) fetchSource(ctx context.Context, bucket, object, workDir string) (string, error) {
+	if rc.processing.StreamSource {
+		url, err := rc.mc.PresignedGetObject(ctx, bucket, object, sourceURLExpiry, nil)
+		if err != nil {
+			return "", fmt.Errorf("failed to presign source: %w", err)
+		}
+		rc.logger.Info("streaming source video", "source", fmt.Sprintf("s3://%s/%s", bucket, object))
+		return url.String(), nil
+	}
+
+	localPath := filepath.Join(workDir, "source"+filepath.Ext(object))
+	var cacheKey string
+	if rc.sources != nil {
+		if info, err := rc.mc.StatObject(ctx, bucket, object, minio.StatObjectOptions{}); err != nil {
+			rc.logger.Warn("failed to stat source, skipping cache", "error", err, "source", object)
+		} else {
+			cacheKey = sourceCacheKey(info.ETag, info.Size)
+			if rc.sources.Get(cacheKey, localPath) {
+				rc.logger.Info("source served from cache", "source", fmt.Sprintf("s3://%s/%s", bucket, object))
+				return localPath, nil
+			}
+		}
+	}
+
+	rc.logger.Info("downloading source video",
+		"source", fmt.Sprintf("s3://%s/%s", bucket, object),
+		"destination", localPath)
+	if err := downloadFromMinio(ctx, rc.mc, bucket, object, localPath); err != nil {
+		return "", err
+	}
+	rc.logger.Info("source download complete", "path", localPath)
+	if cacheKey != "" {
+		if err := rc.sources.Put(cacheKey, localPath); err != nil {
+			rc.logger.Warn("failed to cache source", "error", err, "source", object)
+		}
+	}
+	return localPath, nil
+}
+
 // ...
 // downloadFromMinio downloads an object to a local file path using FGetObject (server-side streaming to disk)
-func downloadFromMinio(ctx context.Context, client *minio.Client, bucket, object, destPath string) error {
+func downloadFromMinio(ctx context.Context, client ObjectStore, bucket, object, destPath string) error {
 	// FGetObject will stream object directly to the destination path on disk.
 	// This avoids loading the whole object into memory.
 	opts := minio.GetObjectOptions{}
@@ -417,7 +1316,7 @@ func downloadFromMinio(ctx context.Context, client *minio.Client, bucket, object
 // uploadDirToMinio walks a local directory and uploads files preserving relative paths under destPrefix.
 // Example: uploadDirToMinio(..., "processed/uuid/1080p", "/tmp/job/1080p")
 // will upload "/tmp/job/1080p/index.m3u8" -> "processed/uuid/1080p/index.m3u8" in bucket
-func (rc *redisConsumer) uploadDirToMinio(ctx context.Context, client *minio.Client, bucket, destPrefix, dir string, videoID uuid.UUID) error {
+func (rc *redisConsumer) uploadDirToMinio(ctx context.Context, client ObjectStore, bucket, destPrefix, dir string, videoID uuid.UUID) error {
 	// Walk local directory
 	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
@@ -454,80 +1353,403 @@ func (rc *redisConsumer) uploadDirToMinio(ctx context.Context, client *minio.Cli
    FFmpeg helpers
    ---------------------------- */
 
-// transcodeToMP4 transcodes input -> output MP4 using x264 + aac with scaling and bitrate.
+// toneMapFilter converts HDR (PQ or HLG) input to BT.709 SDR. It needs an ffmpeg built with zimg.
+const toneMapFilter = "zscale=t=linear:npl=100,format=gbrpf32le,zscale=p=bt709,tonemap=tonemap=hable:desat=0,zscale=t=bt709:m=bt709:r=tv,format=yuv420p"
+
+// toneMapChain puts the tone mapping in front of the filter chain vf for HDR sources. Previews,
+// thumbnails and sprite sheets are always SDR, whatever the source.
+func toneMapChain(vf, hdrFormat string) string {
+	if hdrFormat == "" {
+		return vf
+	}
+	return toneMapFilter + "," + vf
+}
+
+// transcodeToMP4 transcodes the task source -> output MP4 using the variant's codec + aac with scaling and bitrate.
+// HDR sources are tone mapped for SDR variants and re-encoded as 10-bit HEVC for the HDR variant.
 // This writes to a local output file (mp4Path).
-func transcodeToMP4(ctx context.Context, inputPath, mp4Path string, v Variant) error {
+func transcodeToMP4(ctx context.Context, t Transcoder, task ProcessingTask, mp4Path string) error {
 	// ffmpeg command:
 	// ffmpeg -y -i input -vf scale=WIDTH:HEIGHT -c:v libx264 -b:v BITRATE -preset fast -c:a aac -ac 2 -ar 44100 output.mp4
-	args := []string{
+	// with the codec flags of the task's encoder backend, see videoEncoder
+	args := append(task.encoder().inputArgs(),
 		"-y", // overwrite output if exists
 		"-nostdin",
-		"-i", inputPath,
-		"-vf", fmt.Sprintf("scale=%d:%d", v.Width, v.Height),
-		"-c:v", "libx264",
-		"-b:v", v.Bitrate,
-		"-preset", "fast",
-		"-c:a", "aac",
-		"-ac", "2",
-		"-ar", "44100",
-		mp4Path,
-	}
-	cmd := exec.CommandContext(ctx, "ffmpeg", args...)
-	// Optional: capture combined output for logging
-	out, err := cmd.CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("ffmpeg transcode error: %v, output: %s", err, string(out))
+	)
+	args = append(args, filterThreadArgs(task.Threads)...)
+	if c := task.Chunk; c != nil {
+		args = append(args, "-ss", fmt.Sprintf("%.3f", c.Start))
+		if c.Duration > 0 {
+			args = append(args, "-t", fmt.Sprintf("%.3f", c.Duration))
+		}
+	}
+	args = append(args, "-i", task.SourcePath)
+	args = append(args, encoderThreadArgs(task.Threads)...)
+	args = append(args, "-vf", variantFilter(task))
+	args = append(args, variantCodecArgs(task)...)
+	if task.twoPass() {
+		// the first pass only writes the statistics the second one plans the bitrate with.
+		// They sit next to the MP4, whose directory is uploaded, so they are removed after.
+		passLog := filepath.Join(filepath.Dir(mp4Path), "x264pass")
+		defer func() {
+			logs, _ := filepath.Glob(passLog + "*")
+			for _, name := range logs {
+				os.Remove(name)
+			}
+		}()
+		firstPass := append(slices.Clone(args), "-pass", "1", "-passlogfile", passLog, "-an", "-f", "null", "-")
+		if err := t.Run(ctx, firstPass...); err != nil {
+			return fmt.Errorf("ffmpeg first pass error: %w", err)
+		}
+		args = append(args, "-pass", "2", "-passlogfile", passLog)
+	}
+	if task.Chunk != nil {
+		// the audio of chunked encodes is encoded separately, see transcodeChunked
+		args = append(args, "-an")
+	} else {
+		args = append(args, trackMapArgs(task.AudioLanguages)...)
+		args = append(args, audioArgs(task.Variant.AudioChannels)...)
+	}
+	args = append(args, variantMuxArgs(task)...)
+	args = append(args, mp4Path)
+	if err := runWithProgress(ctx, t, task.Progress, args); err != nil {
+		return fmt.Errorf("ffmpeg transcode error: %w", err)
 	}
 	return nil
 }
 
-// generateHLS creates HLS playlist and .ts segments from an mp4.
-// It outputs index.m3u8 and segment_###.ts files into outDir.
-func generateHLS(ctx context.Context, mp4Path, outDir string) error {
+// variantFilter is the video filter chain of the task's variant: deinterlacing, frame rate,
+// black bars, tone mapping, cropping, scaling, denoising, burned in subtitles and the watermark, completed
+// for the encoder
+func variantFilter(task ProcessingTask) string {
+	v := task.Variant
+	scale := fmt.Sprintf("scale=%d:%d", v.Width, v.Height)
+	if v.Vertical {
+		scale = verticalCropFilter(task.CropFocusX) + "," + scale
+	}
+	scale = burnInChain(denoiseChain(scale, task), task)
+	// deinterlacing and dropping frames come first, so the rest filters fewer, whole frames
+	prepare := func(vf string) string { return deinterlaceChain(frameRateChain(cropChain(vf, task), task), task) }
+	switch {
+	case v.HDR:
+		return watermarkGraph(prepare(scale), task) + ",format=yuv420p10le"
+	case task.HDRFormat != "":
+		return task.encoder().filter(watermarkGraph(prepare(toneMapFilter+","+scale), task))
+	default:
+		return task.encoder().filter(watermarkGraph(prepare(scale), task))
+	}
+}
+
+// variantCodecArgs select the encoder of the task's variant with its rate control, color
+// signaling, keyframes and captions
+func variantCodecArgs(task ProcessingTask) []string {
+	v := task.Variant
+	enc := task.encoder()
+	args := enc.codecArgs(v)
+	switch {
+	case v.HDR:
+		args = append(args, "-pix_fmt", "yuv420p10le")
+		args = append(args, hdrColorArgs(task.HDRFormat)...)
+	case task.HDRFormat != "":
+		args = append(args,
+			"-color_primaries", "bt709",
+			"-color_trc", "bt709",
+			"-colorspace", "bt709",
+		)
+	case !enc.hardware():
+		// 10-bit and 4:2:2 sources would give profiles few players decode, and the HLS
+		// segments are copied from this encode
+		args = append(args, "-pix_fmt", "yuv420p")
+	}
+	args = append(args, enc.keyframeArgs(v.segmentSeconds())...)
+	if task.ClosedCaptions {
+		// carry the embedded CEA-608/708 captions over as A53 SEI messages
+		args = append(args, enc.captionArgs()...)
+	}
+	return args
+}
+
+// variantAudioArgs encode the audio of the variants into a stereo downmix, see audioArgs
+var variantAudioArgs = []string{
+	"-c:a", "aac",
+	"-ac", "2",
+	"-ar", "44100",
+}
+
+// variantMuxArgs are the MP4 muxer flags of the task's variant
+func variantMuxArgs(task ProcessingTask) []string {
+	if task.Spherical {
+		// the mp4 muxer only writes the sv3d/st3d spherical boxes in unofficial mode
+		return []string{"-strict", "unofficial"}
+	}
+	return nil
+}
+
+// crfCapArgs caps a constant quality encode at the variant bitrate
+func crfCapArgs(v Variant) []string {
+	kbps, _ := strconv.ParseInt(strings.TrimSuffix(v.Bitrate, "k"), 10, 64)
+	return []string{"-maxrate", v.Bitrate, "-bufsize", fmt.Sprintf("%dk", 2*kbps)}
+}
+
+// hdrColorArgs returns the encoder flags that signal the source's HDR format in the output
+func hdrColorArgs(hdrFormat string) []string {
+	transfer := "smpte2084"
+	x265Params := "repeat-headers=1:colorprim=bt2020:transfer=smpte2084:colormatrix=bt2020nc:hdr10=1:hdr10-opt=1"
+	if hdrFormat == HDRFormatHLG {
+		transfer = "arib-std-b67"
+		x265Params = "repeat-headers=1:colorprim=bt2020:transfer=arib-std-b67:colormatrix=bt2020nc"
+	}
+	return []string{
+		"-color_primaries", "bt2020",
+		"-color_trc", transfer,
+		"-colorspace", "bt2020nc",
+		"-x265-params", x265Params,
+	}
+}
+
+// Segment formats of the HLS variants, ProcessingConfig.HLSSegmentType
+const (
+	HLSSegmentTypeMPEGTS = "mpegts"
+	HLSSegmentTypeFMP4   = "fmp4"
+)
+
+// fmp4Segments reports whether the configured segment type packages the H.264 variants as
+// fMP4. An empty setting is MPEG-TS.
+func fmp4Segments(segmentType string) (bool, error) {
+	switch segmentType {
+	case "", HLSSegmentTypeMPEGTS:
+		return false, nil
+	case HLSSegmentTypeFMP4:
+		return true, nil
+	}
+	return false, fmt.Errorf("hls segment type must be %q or %q, got %q", HLSSegmentTypeMPEGTS, HLSSegmentTypeFMP4, segmentType)
+}
+
+// hlsOptions are the packaging choices of a variant's HLS
+type hlsOptions struct {
+	// StreamCopy cuts the segments out of the MP4 instead of re-encoding it
+	StreamCopy bool
+	// FMP4 packages H.264 segments as fMP4 instead of MPEG-TS
+	FMP4 bool
+	// KeyInfo is the -hls_key_info_file the segments are encrypted with, empty for clear segments
+	KeyInfo string
+}
+
+// generateHLS creates HLS playlist and .ts segments from an mp4, copied when opts.StreamCopy
+// is set and re-encoded otherwise.
+// It outputs index.m3u8 and segment_###.ts files into outDir, or init.mp4 and segment_###.m4s
+// with opts.FMP4. Only the first audio track is packaged, the others are alternate renditions
+// of the audio variant.
+// HEVC variants are segmented without re-encoding into fMP4 segments (init.mp4 + segment_###.m4s),
+// which is what players require for HEVC.
+func generateHLS(ctx context.Context, t Transcoder, mp4Path, outDir string, v Variant, threads int, opts hlsOptions) error {
+	if v.hevc() {
+		return generateHEVCHLS(ctx, t, mp4Path, outDir, v.segmentSeconds(), opts.KeyInfo)
+	}
+
 	// ffmpeg command:
 	// ffmpeg -y -i input.mp4 -c:v libx264 -c:a aac -vf "format=yuv420p" -hls_time 6 -hls_playlist_type vod \
 	//   -hls_segment_filename "outDir/segment_%03d.ts" outDir/index.m3u8
 	playlistPath := filepath.Join(outDir, "index.m3u8")
 	segmentPattern := filepath.Join(outDir, "segment_%03d.ts")
+	if opts.FMP4 {
+		segmentPattern = filepath.Join(outDir, "segment_%03d.m4s")
+	}
 
 	args := []string{
 		"-y",
 		"-nostdin",
-		"-i", mp4Path,
-		"-c:v", "libx264",
-		"-c:a", "aac",
-		"-vf", "format=yuv420p",
-		"-hls_time", "6", // segment length in seconds
+	}
+	args = append(args, filterThreadArgs(threads)...)
+	args = append(args, "-i", mp4Path)
+	args = append(args, encoderThreadArgs(threads)...)
+	args = append(args,
+		"-map", "0:v:0",
+		"-map", "0:a:0?",
+	)
+	if opts.StreamCopy {
+		// the transcode step put keyframes where the segments are cut
+		args = append(args, "-c", "copy")
+	} else {
+		args = append(args,
+			"-c:v", "libx264",
+			"-c:a", "aac",
+			"-vf", "format=yuv420p",
+		)
+		// the re-encode keeps the keyframes of the transcode step where the segments are cut
+		args = append(args, x264Encoder{}.keyframeArgs(v.segmentSeconds())...)
+	}
+	args = append(args,
+		"-hls_time", strconv.Itoa(v.segmentSeconds()), // segment length in seconds
 		"-hls_playlist_type", "vod", // VOD playlist (complete)
+	)
+	if opts.FMP4 {
+		args = append(args,
+			"-hls_segment_type", "fmp4",
+			"-hls_fmp4_init_filename", "init.mp4",
+		)
+	}
+	args = append(args, hlsKeyArgs(opts.KeyInfo)...)
+	args = append(args,
 		"-hls_segment_filename", segmentPattern,
 		playlistPath,
+	)
+
+	if err := t.Run(ctx, args...); err != nil {
+		return fmt.Errorf("ffmpeg hls error: %w", err)
 	}
+	return nil
+}
+
+// masterPlaylist lists the packaged variants, each at <name>/index.m3u8, in an HLS master
+// playlist together with their I-frame playlists. The bandwidth adds the AAC audio, 128k for
+// stereo, to the video bitrate. Variants carrying embedded captions reference a closed captions group.
+// The codecs let players skip the variants they cannot decode, e.g. HEVC ones. The audio-only
+// rendition is the fallback players switch to when the bandwidth cannot carry any picture.
+// The tracks of a source with several audio tracks are listed in an audio group, the first
+// carried by the variants and the others as their own playlists.
+func masterPlaylist(results []ProcessingResult) string {
+	var b strings.Builder
+	// every segment of every variant starts with a keyframe, see forceKeyFramesArgs
+	b.WriteString("#EXTM3U\n#EXT-X-VERSION:4\n#EXT-X-INDEPENDENT-SEGMENTS\n")
+	for _, r := range results {
+		if r.ClosedCaptions {
+			fmt.Fprintf(&b, "#EXT-X-MEDIA:TYPE=CLOSED-CAPTIONS,GROUP-ID=\"%s\",NAME=\"CC1\",INSTREAM-ID=\"CC1\",DEFAULT=YES,AUTOSELECT=YES\n", closedCaptionsGroup)
+			break
+		}
+	}
+	var tracks []audioTrack
+	for _, r := range results {
+		if len(r.AudioTracks) > 0 {
+			tracks = r.AudioTracks
+			break
+		}
+	}
+	for i, name := range audioTrackNames(tracks) {
+		track := tracks[i]
+		fmt.Fprintf(&b, "#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID=\"%s\",NAME=\"%s\"", audioGroup, name)
+		if track.Language != undeterminedLanguage {
+			fmt.Fprintf(&b, ",LANGUAGE=\"%s\"", track.Language)
+		}
+		if i == 0 {
+			b.WriteString(",DEFAULT=YES,AUTOSELECT=YES\n")
+		} else {
+			fmt.Fprintf(&b, ",DEFAULT=NO,AUTOSELECT=YES,URI=\"%s\"\n", track.URI)
+		}
+	}
+	for _, r := range results {
+		v := r.Variant
+		kbps, _ := strconv.ParseInt(strings.TrimSuffix(v.Bitrate, "k"), 10, 64)
+		if v.AudioOnly {
+			fmt.Fprintf(&b, "#EXT-X-STREAM-INF:BANDWIDTH=%d", kbps*1000)
+		} else {
+			fmt.Fprintf(&b, "#EXT-X-STREAM-INF:BANDWIDTH=%d,RESOLUTION=%dx%d", (kbps+audioKbps(v.AudioChannels))*1000, v.Width, v.Height)
+		}
+		if r.Codecs != "" {
+			fmt.Fprintf(&b, ",CODECS=\"%s\"", r.Codecs)
+		}
+		if len(tracks) > 0 {
+			fmt.Fprintf(&b, ",AUDIO=\"%s\"", audioGroup)
+		}
+		if r.ClosedCaptions {
+			fmt.Fprintf(&b, ",CLOSED-CAPTIONS=\"%s\"", closedCaptionsGroup)
+		}
+		fmt.Fprintf(&b, "\n%s/index.m3u8\n", v.Name)
+	}
+	for _, r := range results {
+		if r.IFrameBandwidth > 0 {
+			fmt.Fprintf(&b, "#EXT-X-I-FRAME-STREAM-INF:BANDWIDTH=%d,RESOLUTION=%dx%d", r.IFrameBandwidth, r.Variant.Width, r.Variant.Height)
+			if video, _, _ := strings.Cut(r.Codecs, ","); video != "" {
+				fmt.Fprintf(&b, ",CODECS=\"%s\"", video)
+			}
+			fmt.Fprintf(&b, ",URI=\"%s/%s\"\n", r.Variant.Name, iframePlaylistName)
+		}
+	}
+	return b.String()
+}
 
-	cmd := exec.CommandContext(ctx, "ffmpeg", args...)
-	out, err := cmd.CombinedOutput()
+// publishMasterPlaylist writes and uploads a master playlist over the given variants
+// and records it as a video asset of the given kind. The key of the ladder's playlist is
+// also stored on the video row.
+func (rc *redisConsumer) publishMasterPlaylist(ctx context.Context, task ProcessingTask, fileName, kind string, results []ProcessingResult, uploadCh chan<- UploadTask) {
+	videoUUID, err := uuid.Parse(task.VideoID)
 	if err != nil {
-		return fmt.Errorf("ffmpeg hls error: %v, output: %s", err, string(out))
+		rc.logger.Error("invalid video ID for master playlist", "error", err, "videoID", task.VideoID)
+		return
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Variant.Height > results[j].Variant.Height })
+	path := filepath.Join(task.WorkDir, fileName)
+	if err := os.WriteFile(path, []byte(masterPlaylist(results)), 0o644); err != nil {
+		rc.logger.Error("failed to write master playlist", "error", err, "videoID", task.VideoID)
+		return
+	}
+	file := UploadTask{
+		SourcePath:  path,
+		ObjectKey:   filepath.ToSlash(filepath.Join(task.DestPrefix, fileName)),
+		ContentType: mimeTypeByExt(".m3u8"),
+		Bucket:      task.Bucket,
+	}
+	select {
+	case <-ctx.Done():
+		return
+	case uploadCh <- file:
+	}
+	rc.saveVideoAsset(ctx, videoUUID, kind, file)
+	if kind != AssetKindMasterPlaylist {
+		return
+	}
+	// the row names the playlist players start from
+	err = rc.db.SetVideoMasterPlaylist(ctx, db.SetVideoMasterPlaylistParams{
+		ID:                videoUUID,
+		MasterPlaylistKey: pgtype.Text{String: file.ObjectKey, Valid: true},
+	})
+	if err != nil {
+		rc.logger.Error("failed to save the master playlist key", "error", err, "videoID", task.VideoID)
+	}
+}
+
+// generateHEVCHLS packages an HEVC mp4 as fMP4 HLS without touching the encoded stream
+func generateHEVCHLS(ctx context.Context, t Transcoder, mp4Path, outDir string, segmentSeconds int, keyInfo string) error {
+	args := []string{
+		"-y",
+		"-nostdin",
+		"-i", mp4Path,
+		"-map", "0:v:0",
+		"-map", "0:a:0?",
+		"-c", "copy",
+		"-tag:v", "hvc1",
+		"-hls_time", strconv.Itoa(segmentSeconds),
+		"-hls_playlist_type", "vod",
+		"-hls_segment_type", "fmp4",
+		"-hls_fmp4_init_filename", "init.mp4",
+	}
+	args = append(args, hlsKeyArgs(keyInfo)...)
+	args = append(args,
+		"-hls_segment_filename", filepath.Join(outDir, "segment_%03d.m4s"),
+		filepath.Join(outDir, "index.m3u8"),
+	)
+	if err := t.Run(ctx, args...); err != nil {
+		return fmt.Errorf("ffmpeg hevc hls error: %w", err)
 	}
 	return nil
 }
 
-// generateThumbnail captures a single frame at `atSecond` from input and writes to outImagePath (jpeg).
-func generateThumbnail(ctx context.Context, inputPath, outImagePath string, atSecond int) error {
-	// ffmpeg -y -i input -ss 00:00:05 -vframes 1 -q:v 2 out.jpg
-	ss := fmt.Sprintf("00:00:%02d", atSecond)
+// generateThumbnail captures a single frame `atSecond` into input and writes it to outImagePath (jpeg).
+// Seeking happens on the input so ffmpeg jumps to the nearest keyframe instead of decoding
+// everything before the offset.
+func generateThumbnail(ctx context.Context, t Transcoder, inputPath, outImagePath string, atSecond float64) error {
+	// ffmpeg -y -ss 5.000 -i input -frames:v 1 -q:v 2 out.jpg
 	args := []string{
 		"-y",
 		"-nostdin",
+		"-ss", fmt.Sprintf("%.3f", atSecond),
 		"-i", inputPath,
-		"-ss", ss,
-		"-vframes", "1",
+		"-frames:v", "1",
 		"-q:v", "2", // quality (lower is better)
 		outImagePath,
 	}
-	cmd := exec.CommandContext(ctx, "ffmpeg", args...)
-	out, err := cmd.CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("ffmpeg thumb error: %v, output: %s", err, string(out))
+	if err := t.Run(ctx, args...); err != nil {
+		return fmt.Errorf("ffmpeg thumb error: %w", err)
 	}
 	return nil
 }
@@ -544,10 +1766,26 @@ func mimeTypeByExt(ext string) string {
 		return "application/vnd.apple.mpegurl"
 	case ".ts":
 		return "video/mp2t"
+	case ".m4s":
+		return "video/iso.segment"
 	case ".mp4":
 		return "video/mp4"
+	case ".webm":
+		return "video/webm"
+	case ".m4a":
+		return "audio/mp4"
+	case ".mp3":
+		return "audio/mpeg"
 	case ".jpg", ".jpeg":
 		return "image/jpeg"
+	case ".webp":
+		return "image/webp"
+	case ".json":
+		return "application/json"
+	case ".vtt":
+		return "text/vtt"
+	case ".mpd":
+		return "application/dash+xml"
 	default:
 		return "application/octet-stream"
 	}
@@ -0,0 +1,204 @@
+package video
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"video-processing/database/db"
+	"video-processing/models"
+
+	"github.com/google/uuid"
+)
+
+// progressArgs make ffmpeg write key=value progress blocks to its output, see readProgress
+var progressArgs = []string{"-progress", "pipe:1", "-nostats"}
+
+// progressStep is how many percent a variant advances before its progress is saved again
+const progressStep = 5
+
+// readProgress reads the progress blocks ffmpeg writes with progressArgs and reports the
+// seconds of output encoded at the end of every block
+func readProgress(r io.Reader, report func(seconds float64)) error {
+	scanner := bufio.NewScanner(r)
+	var seconds float64
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(strings.TrimSpace(scanner.Text()), "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "out_time_us":
+			// N/A until the first frame is written
+			if us, err := strconv.ParseInt(value, 10, 64); err == nil && us >= 0 {
+				seconds = float64(us) / 1e6
+			}
+		case "progress":
+			report(seconds)
+		}
+	}
+	return scanner.Err()
+}
+
+// runWithProgress runs the ffmpeg command args, passing its progress to report when set
+func runWithProgress(ctx context.Context, t Transcoder, report func(seconds float64), args []string) error {
+	if report == nil {
+		return t.Run(ctx, args...)
+	}
+	args = append(append([]string{}, progressArgs...), args...)
+	return t.Stream(ctx, func(r io.Reader) error { return readProgress(r, report) }, args...)
+}
+
+// variantProgress returns the progress reporter of a variant's transcode, nil when the length of
+// the source is unknown. It saves the share of the source's durationSeconds encoded in steps of
+// progressStep, and stays below 100 until the variant is done, see finishProgress. The first
+// report is saved as well, so the row's started_at is when the encode started.
+func (rc *redisConsumer) variantProgress(ctx context.Context, videoID, variant string, durationSeconds float64) func(seconds float64) {
+	videoUUID, err := uuid.Parse(videoID)
+	if err != nil || durationSeconds <= 0 {
+		return nil
+	}
+	var (
+		mu      sync.Mutex
+		started bool
+		saved   int
+	)
+	return func(seconds float64) {
+		percent := min(99, int(seconds*100/durationSeconds))
+		mu.Lock()
+		if started && percent < saved+progressStep {
+			mu.Unlock()
+			return
+		}
+		started, saved = true, percent
+		mu.Unlock()
+		rc.saveProgress(ctx, videoUUID, variant, percent)
+	}
+}
+
+// finishProgress marks the variant of a processed result as done
+func (rc *redisConsumer) finishProgress(ctx context.Context, result ProcessingResult) {
+	if videoUUID, err := uuid.Parse(result.VideoID); err == nil {
+		rc.saveProgress(ctx, videoUUID, result.Variant.Name, 100)
+	}
+}
+
+func (rc *redisConsumer) saveProgress(ctx context.Context, videoID uuid.UUID, variant string, percent int) {
+	err := rc.db.SaveVideoProgress(ctx, db.SaveVideoProgressParams{
+		VideoID: videoID,
+		Variant: variant,
+		Percent: int16(percent),
+	})
+	if err != nil {
+		rc.logger.Warn("failed to save variant progress", "error", err, "videoID", videoID, "variant", variant)
+	}
+}
+
+// resetProgress forgets the progress of the video's previous processing run
+func (rc *redisConsumer) resetProgress(ctx context.Context, videoID uuid.UUID) {
+	if err := rc.db.DeleteVideoProgress(ctx, videoID); err != nil {
+		rc.logger.Warn("failed to reset video progress", "error", err, "videoID", videoID)
+	}
+}
+
+// videoProgress is the progress of the latest processing run of a video, nil before the first
+// one. The throughput of past encodes is only loaded while a variant is still running.
+func (vp *videoProcessor) videoProgress(ctx context.Context, video db.Video) (*models.VideoProgress, error) {
+	params := fmt.Sprintf("videoID: %v", video.ID)
+	rows, err := vp.db.ListVideoProgress(ctx, video.ID)
+	if err != nil {
+		return nil, models.IndentifyDbError(err).AddParams(params)
+	}
+	var speeds map[string]float64
+	running := slices.ContainsFunc(rows, func(row db.VideoProgress) bool { return row.Percent < 100 })
+	if running && video.DurationMs.Valid {
+		throughput, err := vp.db.ListVariantThroughput(ctx)
+		if err != nil {
+			return nil, models.IndentifyDbError(err).AddParams(params)
+		}
+		speeds = make(map[string]float64, len(throughput))
+		for _, t := range throughput {
+			speeds[t.Variant] = t.Speed
+		}
+	}
+	return progressFromRows(rows, float64(video.DurationMs.Int64)/1000, speeds), nil
+}
+
+// GetProgress returns the status of the user's video and the progress of its latest
+// processing run
+func (vp *videoProcessor) GetProgress(ctx context.Context, userID, videoID uuid.UUID) (models.JobStatus, error) {
+	video, err := vp.getOwnedVideo(ctx, userID, videoID)
+	if err != nil {
+		return models.JobStatus{}, err
+	}
+	progress, err := vp.videoProgress(ctx, video)
+	if err != nil {
+		return models.JobStatus{}, err
+	}
+	return models.JobStatus{VideoID: video.ID, Status: video.Status, Progress: progress}, nil
+}
+
+// progressFromRows is the progress of a video's variants, nil before its first processing run.
+// The ETA of the video is that of its slowest variant; variants still queued have no row yet,
+// so it only covers the ones already encoding. speeds are the seconds of source per second
+// past encodes of each variant went at, for a source of durationSeconds.
+func progressFromRows(rows []db.VideoProgress, durationSeconds float64, speeds map[string]float64) *models.VideoProgress {
+	if len(rows) == 0 {
+		return nil
+	}
+	progress := &models.VideoProgress{Variants: make([]models.VariantProgress, 0, len(rows))}
+	total := 0
+	estimated := true
+	for _, row := range rows {
+		total += int(row.Percent)
+		variant := models.VariantProgress{
+			Variant:   row.Variant,
+			Percent:   int(row.Percent),
+			UpdatedAt: row.UpdatedAt.Time,
+			ETA:       variantETA(row, durationSeconds, speeds[row.Variant]),
+		}
+		switch {
+		case row.Percent >= 100:
+		case variant.ETA == nil:
+			estimated = false
+		case progress.ETA == nil || variant.ETA.After(*progress.ETA):
+			progress.ETA = variant.ETA
+		}
+		progress.Variants = append(progress.Variants, variant)
+	}
+	progress.Percent = total / len(rows)
+	if !estimated {
+		progress.ETA = nil
+	}
+	return progress
+}
+
+// variantETA is when a running variant should be done, nil when it is done. With the speed
+// past encodes of the variant went at, the rest of the source is encoded at that speed from
+// the last save. Without one, the rate the variant advanced at from its start to its last save
+// is extrapolated, which needs it to have advanced.
+func variantETA(row db.VideoProgress, durationSeconds, speed float64) *time.Time {
+	if row.Percent >= 100 || !row.UpdatedAt.Valid {
+		return nil
+	}
+	if speed > 0 && durationSeconds > 0 {
+		remaining := durationSeconds * float64(100-row.Percent) / 100 / speed
+		eta := row.UpdatedAt.Time.Add(time.Duration(remaining * float64(time.Second)))
+		return &eta
+	}
+	if row.Percent <= 0 || !row.StartedAt.Valid {
+		return nil
+	}
+	elapsed := row.UpdatedAt.Time.Sub(row.StartedAt.Time)
+	if elapsed <= 0 {
+		return nil
+	}
+	remaining := time.Duration(float64(elapsed) * float64(100-row.Percent) / float64(row.Percent))
+	eta := row.UpdatedAt.Time.Add(remaining)
+	return &eta
+}
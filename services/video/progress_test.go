@@ -0,0 +1,153 @@
+package video
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"path"
+	"strings"
+	"testing"
+	"time"
+	"video-processing/database/db"
+	"video-processing/mocks"
+	"video-processing/models"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+// ffmpegProgress is what ffmpeg writes with progressArgs, a block per update
+const ffmpegProgress = "frame=0\nout_time_us=N/A\nprogress=continue\n" +
+	"frame=120\nfps=60.00\nout_time_us=4000000\nout_time=00:00:04.000000\nprogress=continue\n" +
+	"frame=300\nout_time_us=10000000\nprogress=end\n"
+
+func TestReadProgress(t *testing.T) {
+	var reported []float64
+	require.NoError(t, readProgress(strings.NewReader(ffmpegProgress), func(seconds float64) {
+		reported = append(reported, seconds)
+	}))
+	require.Equal(t, []float64{0, 4, 10}, reported)
+}
+
+func TestTranscodeToMP4Progress(t *testing.T) {
+	fake := NewFakeTranscoder()
+	fake.StreamOutput = []byte(ffmpegProgress)
+	var reported []float64
+	task := ProcessingTask{Variant: testLadder.regular[1], SourcePath: "source.mp4", Progress: func(seconds float64) {
+		reported = append(reported, seconds)
+	}}
+	mp4Path := path.Join(t.TempDir(), "720p.mp4")
+	require.NoError(t, transcodeToMP4(context.Background(), fake, task, mp4Path))
+	require.Equal(t, []float64{0, 4, 10}, reported)
+	require.Equal(t, progressArgs, fake.Calls()[0][:len(progressArgs)])
+	require.FileExists(t, mp4Path)
+
+	// chunks add up to the progress of the variant
+	reported = nil
+	task.Chunks = []chunkRange{{Start: 0, Duration: 10}, {Start: 10}}
+	require.NoError(t, transcodeChunked(context.Background(), fake, task, mp4Path))
+	require.Equal(t, 20.0, reported[len(reported)-1])
+}
+
+func TestVariantProgress(t *testing.T) {
+	repo := mocks.NewMockVideoRepo(gomock.NewController(t))
+	rc := &redisConsumer{logger: slog.New(slog.NewTextHandler(io.Discard, nil)), db: repo}
+	videoID := uuid.New()
+	ctx := context.Background()
+
+	require.Nil(t, rc.variantProgress(ctx, videoID.String(), "720p", 0))
+
+	// saved as it starts and then in steps, never done before the variant is
+	gomock.InOrder(
+		repo.EXPECT().SaveVideoProgress(gomock.Any(), db.SaveVideoProgressParams{VideoID: videoID, Variant: "720p", Percent: 0}),
+		repo.EXPECT().SaveVideoProgress(gomock.Any(), db.SaveVideoProgressParams{VideoID: videoID, Variant: "720p", Percent: 10}),
+		repo.EXPECT().SaveVideoProgress(gomock.Any(), db.SaveVideoProgressParams{VideoID: videoID, Variant: "720p", Percent: 99}),
+	)
+	report := rc.variantProgress(ctx, videoID.String(), "720p", 100)
+	for _, seconds := range []float64{0, 2, 10, 12, 14, 100} {
+		report(seconds)
+	}
+}
+
+func TestProgressFromRows(t *testing.T) {
+	require.Nil(t, progressFromRows(nil, 0, nil))
+
+	updated := time.Date(2025, 12, 23, 10, 0, 0, 0, time.UTC)
+	progress := progressFromRows([]db.VideoProgress{
+		{Variant: "480p", Percent: 100, UpdatedAt: pgtype.Timestamptz{Time: updated, Valid: true}},
+		{Variant: "720p", Percent: 45, UpdatedAt: pgtype.Timestamptz{Time: updated, Valid: true}},
+	}, 0, nil)
+	require.Equal(t, 72, progress.Percent)
+	require.Len(t, progress.Variants, 2)
+	require.Equal(t, "720p", progress.Variants[1].Variant)
+	require.Equal(t, updated, progress.Variants[1].UpdatedAt)
+	// without a start the rate is unknown
+	require.Nil(t, progress.ETA)
+}
+
+func TestProgressETA(t *testing.T) {
+	started := pgtype.Timestamptz{Time: time.Date(2025, 12, 23, 10, 0, 0, 0, time.UTC), Valid: true}
+	at := func(d time.Duration) pgtype.Timestamptz {
+		return pgtype.Timestamptz{Time: started.Time.Add(d), Valid: true}
+	}
+
+	// a quarter in 30 seconds leaves 90 more, a half in 60 seconds 60 more
+	progress := progressFromRows([]db.VideoProgress{
+		{Variant: "1080p", Percent: 25, StartedAt: started, UpdatedAt: at(30 * time.Second)},
+		{Variant: "480p", Percent: 100, StartedAt: started, UpdatedAt: at(40 * time.Second)},
+		{Variant: "720p", Percent: 50, StartedAt: started, UpdatedAt: at(60 * time.Second)},
+	}, 0, nil)
+	require.Equal(t, started.Time.Add(2*time.Minute), *progress.Variants[0].ETA)
+	require.Nil(t, progress.Variants[1].ETA)
+	require.Equal(t, started.Time.Add(2*time.Minute), *progress.Variants[2].ETA)
+	require.Equal(t, started.Time.Add(2*time.Minute), *progress.ETA)
+
+	// a variant that has only just started leaves the video without an estimate
+	justStarted := []db.VideoProgress{
+		{Variant: "1080p", Percent: 0, StartedAt: started, UpdatedAt: started},
+		{Variant: "720p", Percent: 50, StartedAt: started, UpdatedAt: at(60 * time.Second)},
+	}
+	progress = progressFromRows(justStarted, 0, nil)
+	require.Nil(t, progress.Variants[0].ETA)
+	require.NotNil(t, progress.Variants[1].ETA)
+	require.Nil(t, progress.ETA)
+
+	// past encodes of the variant give an estimate from the start: 120 seconds of source at 2x
+	// take 60 seconds, and half of it 30 from the last save. Variants without history stay linear.
+	progress = progressFromRows(justStarted, 120, map[string]float64{"1080p": 2})
+	require.Equal(t, started.Time.Add(time.Minute), *progress.Variants[0].ETA)
+	require.Equal(t, started.Time.Add(2*time.Minute), *progress.Variants[1].ETA)
+	require.Equal(t, started.Time.Add(2*time.Minute), *progress.ETA)
+	progress = progressFromRows(justStarted, 120, map[string]float64{"1080p": 2, "720p": 2})
+	require.Equal(t, started.Time.Add(90*time.Second), *progress.Variants[1].ETA)
+}
+
+func TestGetProgress(t *testing.T) {
+	vp, repo, _ := newPlaybackProcessor(t)
+	owner, videoID := uuid.New(), uuid.New()
+	video := db.Video{ID: videoID, UserID: owner, Status: models.VideoPending, DurationMs: pgtype.Int8{Int64: 60000, Valid: true}}
+	updated := pgtype.Timestamptz{Time: time.Date(2025, 12, 23, 10, 0, 0, 0, time.UTC), Valid: true}
+	repo.EXPECT().GetVideo(gomock.Any(), videoID).Return(video, nil).Times(2)
+	repo.EXPECT().ListVideoProgress(gomock.Any(), videoID).Return([]db.VideoProgress{
+		{VideoID: videoID, Variant: "720p", Percent: 50, StartedAt: updated, UpdatedAt: updated},
+	}, nil)
+	repo.EXPECT().ListVariantThroughput(gomock.Any()).Return([]db.ListVariantThroughputRow{{Variant: "720p", Speed: 3}}, nil)
+
+	status, err := vp.GetProgress(context.Background(), owner, videoID)
+	require.NoError(t, err)
+	require.False(t, status.Done())
+	require.Equal(t, updated.Time.Add(10*time.Second), *status.Progress.ETA)
+
+	// the progress of other users' videos is not found
+	var e models.Error
+	_, err = vp.GetProgress(context.Background(), uuid.New(), videoID)
+	require.ErrorAs(t, err, &e)
+	require.Equal(t, http.StatusNotFound, e.Code)
+
+	require.True(t, models.JobStatus{Status: models.VideoProcessed, Progress: &models.VideoProgress{Percent: 100}}.Done())
+	// reprocessed videos stay processed while their variants run again
+	require.False(t, models.JobStatus{Status: models.VideoProcessed, Progress: &models.VideoProgress{Percent: 40}}.Done())
+}
@@ -0,0 +1,89 @@
+package video
+
+import (
+	"context"
+	"sync"
+	"video-processing/database/db"
+	"video-processing/models"
+
+	"github.com/google/uuid"
+)
+
+// defaultProxyVariant is the variant published first when the configuration names none
+const defaultProxyVariant = "360p"
+
+// proxyTask returns the index of the task published before the rest of the ladder:
+// the regular variant of the given name, otherwise the smallest regular one. It is -1 when
+// the job has no regular variant.
+func proxyTask(tasks []ProcessingTask, name string) int {
+	proxy := -1
+	for i, task := range tasks {
+		v := task.Variant
+		if v.Vertical || v.HDR || v.AudioOnly {
+			continue
+		}
+		if v.Name == name {
+			return i
+		}
+		if proxy < 0 || v.Height < tasks[proxy].Variant.Height {
+			proxy = i
+		}
+	}
+	return proxy
+}
+
+// publishProxy encodes the proxy variant alongside the rest of the ladder, uploads it with a
+// master playlist of its own and marks the video playable, so it can be watched while the other
+// variants encode. The final master playlist replaces this one. The result is that of the
+// variant, which keeps its place in the ladder.
+func (rc *redisConsumer) publishProxy(ctx context.Context, task ProcessingTask, playlistTask ProcessingTask) ProcessingResult {
+	rc.logger.Info("encoding the proxy variant", "variant", task.Variant.Name, "videoID", task.VideoID)
+	resultCh := make(chan ProcessingResult, 1)
+	uploadCh := make(chan UploadTask, 100)
+	var uploadWg sync.WaitGroup
+	numUploadWorkers := 3
+	for i := 0; i < numUploadWorkers; i++ {
+		uploadWg.Add(1)
+		go rc.uploadWorker(ctx, uploadCh, &uploadWg)
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	rc.processVariant(ctx, task, resultCh, uploadCh, &wg)
+	result := <-resultCh
+	if !result.Success {
+		close(uploadCh)
+		uploadWg.Wait()
+		return result
+	}
+	for _, file := range result.Files {
+		select {
+		case <-ctx.Done():
+		case uploadCh <- file:
+		}
+	}
+	rc.saveVariantMetadata(ctx, result)
+	rc.finishProgress(ctx, result)
+	rc.publishMasterPlaylist(ctx, playlistTask, "master.m3u8", AssetKindMasterPlaylist, []ProcessingResult{result}, uploadCh)
+	// the video is only playable once every file of the proxy is stored
+	close(uploadCh)
+	uploadWg.Wait()
+
+	if ctx.Err() == nil {
+		rc.setVideoStatus(ctx, task.VideoID, models.VideoPlayable)
+	}
+	return result
+}
+
+// setVideoStatus moves the video to status
+func (rc *redisConsumer) setVideoStatus(ctx context.Context, videoID, status string) {
+	videoUUID, err := uuid.Parse(videoID)
+	if err != nil {
+		return
+	}
+	if _, err := rc.db.UpdateVideoStatus(ctx, db.UpdateVideoStatusParams{Status: status, ID: videoUUID}); err != nil {
+		rc.logger.Error("failed to update video status", "error", err, "videoID", videoID, "status", status)
+		return
+	}
+	rc.logger.Info("video status updated", "videoID", videoID, "status", status)
+}
@@ -0,0 +1,256 @@
+package video
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+	"video-processing/database/db"
+	"video-processing/mocks"
+	"video-processing/models"
+	"video-processing/storage"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/minio/minio-go/v7"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestProxyTask(t *testing.T) {
+	tasks := []ProcessingTask{
+		{Variant: testLadder.vertical[0]},
+		{Variant: testLadder.regular[0]},
+		{Variant: testLadder.regular[3]},
+		{Variant: testLadder.regular[4]},
+	}
+	require.Equal(t, 2, proxyTask(tasks, "360p"))
+	// without the named variant the smallest regular one is published first
+	require.Equal(t, 3, proxyTask(tasks, "540p"))
+	require.Equal(t, -1, proxyTask(tasks[:1], "360p"))
+}
+
+func TestPublishProxy(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	repo := mocks.NewMockVideoRepo(ctrl)
+	store := mocks.NewMockObjectStore(ctrl)
+	rc := &redisConsumer{logger: slog.New(slog.NewTextHandler(io.Discard, nil)), db: repo, mc: store, transcoder: NewFakeTranscoder()}
+	videoID := uuid.New()
+	task := ProcessingTask{
+		Variant:    testLadder.regular[3],
+		WorkDir:    t.TempDir(),
+		SourcePath: "source.mp4",
+		DestPrefix: "processed/job",
+		Bucket:     "videos",
+		VideoID:    videoID.String(),
+	}
+	playlistTask := ProcessingTask{WorkDir: task.WorkDir, DestPrefix: task.DestPrefix, Bucket: task.Bucket, VideoID: task.VideoID}
+
+	var mu sync.Mutex
+	uploaded := map[string]bool{}
+	store.EXPECT().PutObject(gomock.Any(), "videos", gomock.Any(), gomock.Any(), int64(-1), gomock.Any()).
+		DoAndReturn(func(_ context.Context, _, key string, _ io.Reader, _ int64, _ minio.PutObjectOptions) (minio.UploadInfo, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			uploaded[key] = true
+			return minio.UploadInfo{}, nil
+		}).AnyTimes()
+	repo.EXPECT().SaveProcessedVideoMetadata(gomock.Any(), gomock.Any()).Return(db.VideoVariant{}, nil)
+	repo.EXPECT().SaveVideoProgress(gomock.Any(), db.SaveVideoProgressParams{VideoID: videoID, Variant: "360p", Percent: 100})
+	repo.EXPECT().SaveVideoAsset(gomock.Any(), gomock.Any()).Return(db.VideoAsset{}, nil)
+	repo.EXPECT().SetVideoMasterPlaylist(gomock.Any(), db.SetVideoMasterPlaylistParams{
+		ID: videoID, MasterPlaylistKey: pgtype.Text{String: "processed/job/master.m3u8", Valid: true},
+	})
+	// the video is playable only once the proxy and its master playlist are stored
+	repo.EXPECT().UpdateVideoStatus(gomock.Any(), db.UpdateVideoStatusParams{Status: models.VideoPlayable, ID: videoID}).
+		DoAndReturn(func(context.Context, db.UpdateVideoStatusParams) (db.Video, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			require.True(t, uploaded["processed/job/master.m3u8"])
+			require.True(t, uploaded["processed/job/360p/index.m3u8"])
+			return db.Video{}, nil
+		})
+
+	result := rc.publishProxy(context.Background(), task, playlistTask)
+	require.True(t, result.Success, result.Error)
+	require.Equal(t, "360p", result.Variant.Name)
+}
+
+// firstRunRepo records the writes of a job like a dry run, for a video processed the first time
+type firstRunRepo struct {
+	*planRepo
+}
+
+func (r firstRunRepo) ArchiveRenditions(ctx context.Context, videoID uuid.UUID) (db.VideoRenditionVersion, error) {
+	return db.VideoRenditionVersion{}, pgx.ErrNoRows
+}
+
+var variantDir = regexp.MustCompile(`^\d+p$`)
+
+// ladderGate holds the encode of the proxy until another variant starts encoding
+type ladderGate struct {
+	*FakeTranscoder
+	proxy  string
+	ladder chan struct{}
+	once   sync.Once
+}
+
+func (g *ladderGate) wait(args []string) error {
+	for _, a := range args {
+		dirs := strings.Split(filepath.ToSlash(a), "/")
+		switch {
+		case slices.Contains(dirs, g.proxy):
+			select {
+			case <-g.ladder:
+				return nil
+			case <-time.After(5 * time.Second):
+				return errors.New("the proxy encoded before the rest of the ladder")
+			}
+		case slices.ContainsFunc(dirs, variantDir.MatchString):
+			g.once.Do(func() { close(g.ladder) })
+			return nil
+		}
+	}
+	return nil
+}
+
+func (g *ladderGate) Run(ctx context.Context, args ...string) error {
+	if err := g.wait(args); err != nil {
+		return err
+	}
+	return g.FakeTranscoder.Run(ctx, args...)
+}
+
+func (g *ladderGate) Stream(ctx context.Context, read func(io.Reader) error, args ...string) error {
+	if err := g.wait(args); err != nil {
+		return err
+	}
+	return g.FakeTranscoder.Stream(ctx, read, args...)
+}
+
+func TestProxyEncodesAlongsideLadder(t *testing.T) {
+	ctx := context.Background()
+	store, err := storage.NewLocal(t.TempDir(), "")
+	require.NoError(t, err)
+	require.NoError(t, store.MakeBucket(ctx, "b1", minio.MakeBucketOptions{}))
+	_, err = store.PutObject(ctx, "b1", "in.mp4", strings.NewReader("source"), 6, minio.PutObjectOptions{})
+	require.NoError(t, err)
+
+	fake := NewFakeTranscoder()
+	fake.ProbeOutput = []byte(`{"streams":[{"codec_type":"video","codec_name":"h264","width":1280,"height":720}],"format":{"duration":"30.0"}}`)
+	repo := mocks.NewMockVideoRepo(gomock.NewController(t))
+	repo.EXPECT().GetDefaultTranscodingPreset(gomock.Any()).Return(defaultPresetRow(t), nil).AnyTimes()
+	planner := &jobPlanner{objects: map[string]bool{}}
+	rc := &redisConsumer{
+		logger:     slog.New(slog.NewTextHandler(io.Discard, nil)),
+		mc:         store,
+		db:         firstRunRepo{&planRepo{VideoRepo: repo, planner: planner}},
+		transcoder: &ladderGate{FakeTranscoder: fake, proxy: defaultProxyVariant, ladder: make(chan struct{})},
+		scratch:    &scratchSpace{dir: t.TempDir()},
+	}
+	rc.processing.ProxyFirst = true
+
+	videoID := uuid.New()
+	require.NoError(t, rc.ProcessVideo(ctx, map[string]interface{}{"bucket": "b1", "key": "in.mp4", "video_id": videoID.String()}))
+
+	// the proxy waited for the ladder to start, then made the video playable before it was processed
+	var statuses []string
+	for _, w := range planner.plan.Writes {
+		if w.Query == "UpdateVideoStatus" {
+			statuses = append(statuses, w.Params.(db.UpdateVideoStatusParams).Status)
+		}
+	}
+	require.Equal(t, []string{models.VideoPlayable, models.VideoProcessed}, statuses)
+}
+
+// proxyGate holds the encodes of the ladder until the proxy starts encoding
+type proxyGate struct {
+	*FakeTranscoder
+	proxy   string
+	started chan struct{}
+	once    sync.Once
+	held    atomic.Bool
+}
+
+func (g *proxyGate) wait(args []string) error {
+	for _, a := range args {
+		dirs := strings.Split(filepath.ToSlash(a), "/")
+		switch {
+		case slices.Contains(dirs, g.proxy):
+			g.once.Do(func() { close(g.started) })
+			return nil
+		case slices.ContainsFunc(dirs, variantDir.MatchString):
+			select {
+			case <-g.started:
+				return nil
+			case <-time.After(5 * time.Second):
+				g.held.Store(true)
+				return errors.New("the ladder held the slot of the proxy")
+			}
+		}
+	}
+	return nil
+}
+
+func (g *proxyGate) Run(ctx context.Context, args ...string) error {
+	if err := g.wait(args); err != nil {
+		return err
+	}
+	return g.FakeTranscoder.Run(ctx, args...)
+}
+
+func (g *proxyGate) Stream(ctx context.Context, read func(io.Reader) error, args ...string) error {
+	if err := g.wait(args); err != nil {
+		return err
+	}
+	return g.FakeTranscoder.Stream(ctx, read, args...)
+}
+
+func TestProxyReservesSlot(t *testing.T) {
+	ctx := context.Background()
+	store, err := storage.NewLocal(t.TempDir(), "")
+	require.NoError(t, err)
+	require.NoError(t, store.MakeBucket(ctx, "b1", minio.MakeBucketOptions{}))
+	_, err = store.PutObject(ctx, "b1", "in.mp4", strings.NewReader("source"), 6, minio.PutObjectOptions{})
+	require.NoError(t, err)
+
+	fake := NewFakeTranscoder()
+	fake.ProbeOutput = []byte(`{"streams":[{"codec_type":"video","codec_name":"h264","width":1280,"height":720}],"format":{"duration":"30.0"}}`)
+	repo := mocks.NewMockVideoRepo(gomock.NewController(t))
+	repo.EXPECT().GetDefaultTranscodingPreset(gomock.Any()).Return(defaultPresetRow(t), nil).AnyTimes()
+	planner := &jobPlanner{objects: map[string]bool{}}
+	gate := &proxyGate{FakeTranscoder: fake, proxy: defaultProxyVariant, started: make(chan struct{})}
+	rc := &redisConsumer{
+		logger:     slog.New(slog.NewTextHandler(io.Discard, nil)),
+		mc:         store,
+		db:         firstRunRepo{&planRepo{VideoRepo: repo, planner: planner}},
+		transcoder: gate,
+		scratch:    &scratchSpace{dir: t.TempDir()},
+	}
+	rc.processing.ProxyFirst = true
+	// the chunks of every variant compete for a single slot
+	rc.processing.ChunkedMinDuration = 10 * time.Second
+	rc.processing.ChunkDuration = 10 * time.Second
+	rc.processing.MaxParallelVariants = 1
+
+	videoID := uuid.New()
+	require.NoError(t, rc.ProcessVideo(ctx, map[string]interface{}{"bucket": "b1", "key": "in.mp4", "video_id": videoID.String()}))
+	require.False(t, gate.held.Load())
+
+	var statuses []string
+	for _, w := range planner.plan.Writes {
+		if w.Query == "UpdateVideoStatus" {
+			statuses = append(statuses, w.Params.(db.UpdateVideoStatusParams).Status)
+		}
+	}
+	require.Equal(t, []string{models.VideoPlayable, models.VideoProcessed}, statuses)
+}
@@ -0,0 +1,73 @@
+package video
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+// vmafSubsample scores every nth frame only; pooled scores barely move while the
+// measurement runs several times faster
+const vmafSubsample = 5
+
+// QualityScores are the pooled objective quality scores of a rendition against its source
+type QualityScores struct {
+	VMAF float64 // 0-100
+	PSNR float64 // dB, luma
+}
+
+// vmafLog is the subset of the libvmaf JSON log holding the pooled scores
+type vmafLog struct {
+	PooledMetrics map[string]struct {
+		Mean float64 `json:"mean"`
+	} `json:"pooled_metrics"`
+}
+
+// parseVMAFLog reads the pooled VMAF and luma PSNR means from a libvmaf JSON log
+func parseVMAFLog(r io.Reader) (QualityScores, error) {
+	var log vmafLog
+	if err := json.NewDecoder(r).Decode(&log); err != nil {
+		return QualityScores{}, fmt.Errorf("failed to decode vmaf log: %w", err)
+	}
+	vmaf, ok := log.PooledMetrics["vmaf"]
+	if !ok {
+		return QualityScores{}, fmt.Errorf("vmaf log has no pooled vmaf score")
+	}
+	psnr, ok := log.PooledMetrics["psnr_y"]
+	if !ok {
+		return QualityScores{}, fmt.Errorf("vmaf log has no pooled psnr_y score")
+	}
+	return QualityScores{VMAF: vmaf.Mean, PSNR: psnr.Mean}, nil
+}
+
+// measureQuality scores the encoded rendition against the source with libvmaf.
+// The rendition is upscaled to the source resolution first, as VMAF expects.
+// The libvmaf per-frame log is written to logPath.
+func measureQuality(ctx context.Context, t Transcoder, renditionPath, sourcePath, logPath string) (QualityScores, error) {
+	filter := fmt.Sprintf(
+		"[0:v][1:v]scale2ref=flags=bicubic[dist][ref];"+
+			"[dist]settb=AVTB,setpts=PTS-STARTPTS[d];"+
+			"[ref]settb=AVTB,setpts=PTS-STARTPTS[r];"+
+			"[d][r]libvmaf=log_fmt=json:log_path=%s:n_subsample=%d:feature=name=psnr",
+		logPath, vmafSubsample)
+	args := []string{
+		"-nostdin",
+		"-v", "error",
+		"-i", renditionPath,
+		"-i", sourcePath,
+		"-lavfi", filter,
+		"-f", "null",
+		"-",
+	}
+	if err := t.Run(ctx, args...); err != nil {
+		return QualityScores{}, fmt.Errorf("ffmpeg vmaf error: %w", err)
+	}
+	f, err := os.Open(logPath)
+	if err != nil {
+		return QualityScores{}, fmt.Errorf("failed to open vmaf log: %w", err)
+	}
+	defer f.Close()
+	return parseVMAFLog(f)
+}
@@ -0,0 +1,47 @@
+package video
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseVMAFLog(t *testing.T) {
+	testCases := []struct {
+		name    string
+		log     string
+		want    QualityScores
+		wantErr bool
+	}{
+		{
+			name: "pooled scores",
+			log: `{"version":"2.3.1","frames":[],"pooled_metrics":{
+				"psnr_y":{"min":31.2,"max":44.9,"mean":38.75,"harmonic_mean":38.5},
+				"psnr_cb":{"min":40.1,"max":48.2,"mean":44.1,"harmonic_mean":44.0},
+				"vmaf":{"min":71.3,"max":99.1,"mean":93.42,"harmonic_mean":93.1}}}`,
+			want: QualityScores{VMAF: 93.42, PSNR: 38.75},
+		},
+		{
+			name:    "psnr feature missing",
+			log:     `{"pooled_metrics":{"vmaf":{"mean":90}}}`,
+			wantErr: true,
+		},
+		{
+			name:    "truncated log",
+			log:     `{"pooled_metrics":{`,
+			wantErr: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := parseVMAFLog(strings.NewReader(tc.log))
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.want, got)
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package video
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// canRemux reports whether the source can be copied into variant v's MP4 without re-encoding:
+// 8-bit 4:2:0 H.264 video no larger and no heavier than the rung, with AAC audio tracks or none.
+// HEVC and vertical rungs always need their own encode.
+func canRemux(probe ProbeResult, v Variant) bool {
+	if v.hevc() || v.Vertical {
+		return false
+	}
+	video, ok := probe.VideoStream()
+	// MPEG-TS segments cannot carry the display matrix of rotated sources
+	if !ok || video.CodecName != "h264" || video.PixFmt != "yuv420p" || video.HDRFormat() != "" || video.Rotation() != 0 {
+		return false
+	}
+	if video.Width > v.Width || video.Height > v.Height {
+		return false
+	}
+	for _, s := range probe.Streams {
+		if s.CodecType == "audio" && s.CodecName != "aac" {
+			return false
+		}
+	}
+
+	// the stream bitrate is missing from some containers, the container bitrate includes the audio
+	bitrate := video.BitRate
+	if bitrate == "" {
+		bitrate = probe.Format.BitRate
+	}
+	bps, err := strconv.ParseInt(bitrate, 10, 64)
+	if err != nil || bps <= 0 {
+		return false
+	}
+	kbps, err := strconv.ParseInt(strings.TrimSuffix(v.Bitrate, "k"), 10, 64)
+	if err != nil {
+		return false
+	}
+	return bps <= kbps*1000
+}
+
+// remuxToMP4 copies the source's video and audio tracks into mp4Path as they are
+func remuxToMP4(ctx context.Context, t Transcoder, task ProcessingTask, mp4Path string) error {
+	// ffmpeg -y -i input -map 0:V:0 -map 0:a? -c copy -movflags +faststart output.mp4
+	args := []string{
+		"-y",
+		"-nostdin",
+		"-i", task.SourcePath,
+		"-map", "0:V:0", // V skips cover art
+		"-map", "0:a?",
+		"-c", "copy",
+		"-movflags", "+faststart",
+	}
+	if task.Spherical {
+		args = append(args, "-strict", "unofficial")
+	}
+	args = append(args, mp4Path)
+	if err := t.Run(ctx, args...); err != nil {
+		return fmt.Errorf("ffmpeg remux error: %w", err)
+	}
+	return nil
+}
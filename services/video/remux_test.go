@@ -0,0 +1,44 @@
+package video
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCanRemux(t *testing.T) {
+	source := func(codec, pixFmt string, width, height int, bitrate, audio string) ProbeResult {
+		p := ProbeResult{
+			Format: ProbeFormat{BitRate: "9000000"},
+			Streams: []ProbeStream{{
+				CodecType: "video", CodecName: codec, PixFmt: pixFmt,
+				Width: width, Height: height, BitRate: bitrate,
+			}},
+		}
+		if audio != "" {
+			p.Streams = append(p.Streams, ProbeStream{CodecType: "audio", CodecName: audio})
+		}
+		return p
+	}
+	hd := Variant{Name: "720p", Width: 1280, Height: 720, Bitrate: "2000k"}
+
+	require.True(t, canRemux(source("h264", "yuv420p", 1280, 720, "1800000", "aac"), hd))
+	require.True(t, canRemux(source("h264", "yuv420p", 854, 480, "900000", ""), hd))
+	require.False(t, canRemux(source("h264", "yuv420p", 1280, 720, "2500000", "aac"), hd), "bitrate above the rung")
+	require.False(t, canRemux(source("h264", "yuv420p", 1920, 1080, "1800000", "aac"), hd), "larger than the rung")
+	require.False(t, canRemux(source("hevc", "yuv420p", 1280, 720, "1800000", "aac"), hd))
+	require.False(t, canRemux(source("h264", "yuv422p", 1280, 720, "1800000", "aac"), hd))
+	require.False(t, canRemux(source("h264", "yuv420p", 1280, 720, "1800000", "opus"), hd))
+	// every track is copied, so every track must be AAC
+	dubbed := source("h264", "yuv420p", 1280, 720, "1800000", "aac")
+	dubbed.Streams = append(dubbed.Streams, ProbeStream{CodecType: "audio", CodecName: "ac3"})
+	require.False(t, canRemux(dubbed, hd))
+	// falls back to the container bitrate, which is too high here
+	require.False(t, canRemux(source("h264", "yuv420p", 1280, 720, "", "aac"), hd))
+	// phone videos play rotated by their display matrix, which HLS segments drop
+	rotated := source("h264", "yuv420p", 1280, 720, "1800000", "aac")
+	rotated.Streams[0].SideData = []ProbeSideData{{SideDataType: "Display Matrix", Rotation: -90}}
+	require.False(t, canRemux(rotated, Variant{Name: "720p", Width: 720, Height: 1280, Bitrate: "2000k"}))
+	require.False(t, canRemux(source("h264", "yuv420p", 720, 1280, "1800000", "aac"),
+		Variant{Name: "720p-vertical", Width: 720, Height: 1280, Bitrate: "2500k", Vertical: true}))
+}
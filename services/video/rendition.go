@@ -0,0 +1,225 @@
+package video
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+	"video-processing/database/db"
+	"video-processing/models"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	// defaultVersionRetention is how long replaced rendition sets are kept when the config
+	// leaves it unset
+	defaultVersionRetention = 7 * 24 * time.Hour
+	// versionCleanupInterval is how often instances look for expired rendition versions
+	versionCleanupInterval = time.Hour
+	// versionExpiryBatch is how many versions one query expires
+	versionExpiryBatch = 100
+)
+
+// ListRenditionVersions lists the rendition sets reprocessing replaced on a video of the user,
+// newest first
+func (vp *videoProcessor) ListRenditionVersions(ctx context.Context, userID, videoID uuid.UUID) ([]models.RenditionVersion, error) {
+	params := fmt.Sprintf("userID: %v, videoID: %v", userID, videoID)
+	if _, err := vp.getOwnedVideo(ctx, userID, videoID); err != nil {
+		return nil, err
+	}
+	rows, err := vp.db.ListRenditionVersions(ctx, videoID)
+	if err != nil {
+		return nil, models.IndentifyDbError(err).AddParams(params)
+	}
+	versions := make([]models.RenditionVersion, 0, len(rows))
+	for _, row := range rows {
+		version, err := renditionVersionFromRow(row)
+		if err != nil {
+			return nil, models.Error{
+				Code:    http.StatusInternalServerError,
+				Message: "internal server error",
+				Params:  params,
+				Err:     err,
+			}
+		}
+		versions = append(versions, version)
+	}
+	return versions, nil
+}
+
+// RollbackRenditions makes a version the current rendition set of a video of the user. The set
+// it replaces is kept as a new version, so a rollback can be rolled back in turn.
+func (vp *videoProcessor) RollbackRenditions(ctx context.Context, userID, videoID uuid.UUID, version int32) (models.VideoDetail, error) {
+	params := fmt.Sprintf("userID: %v, videoID: %v, version: %v", userID, videoID, version)
+	if _, err := vp.getOwnedVideo(ctx, userID, videoID); err != nil {
+		return models.VideoDetail{}, err
+	}
+	row, err := vp.db.GetRenditionVersion(ctx, db.GetRenditionVersionParams{VideoID: videoID, Version: version})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.VideoDetail{}, renditionVersionNotFound(params)
+		}
+		return models.VideoDetail{}, models.IndentifyDbError(err).AddParams(params)
+	}
+	if _, err := vp.db.ArchiveRenditions(ctx, videoID); err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return models.VideoDetail{}, models.IndentifyDbError(err).AddParams(params)
+	}
+	restored, err := vp.db.RestoreRenditionVersion(ctx, row.ID)
+	if err != nil {
+		return models.VideoDetail{}, models.IndentifyDbError(err).AddParams(params)
+	}
+	if restored.Variants == 0 {
+		// a concurrent rollback restored it, or it expired, in the meantime
+		return models.VideoDetail{}, renditionVersionNotFound(params)
+	}
+	vp.logger.Info("rendition version restored", "videoID", videoID, "version", version,
+		"variants", restored.Variants, "assets", restored.Assets)
+	return vp.GetVideo(ctx, userID, videoID, nil)
+}
+
+func renditionVersionNotFound(params string) error {
+	return models.Error{
+		Code:    http.StatusNotFound,
+		Message: "rendition version not found",
+		Params:  params,
+		Err:     models.ErrResourceNotFound,
+	}
+}
+
+// RunVersionCleanup deletes the rendition versions replaced more than retention ago, and the
+// objects only they point at, until ctx is done. Every instance may run it: the query skips
+// the rows another instance is deleting.
+func (vp *videoProcessor) RunVersionCleanup(ctx context.Context, retention time.Duration) error {
+	if retention <= 0 {
+		retention = defaultVersionRetention
+	}
+	ticker := time.NewTicker(versionCleanupInterval)
+	defer ticker.Stop()
+	for {
+		vp.expireVersions(ctx, retention)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// expireVersions deletes the versions older than retention. A version whose objects fail to
+// be removed is gone all the same; its objects are logged and left behind.
+func (vp *videoProcessor) expireVersions(ctx context.Context, retention time.Duration) {
+	for {
+		rows, err := vp.db.ExpireRenditionVersions(ctx, db.ExpireRenditionVersionsParams{
+			CreatedAt: time.Now().Add(-retention),
+			Limit:     versionExpiryBatch,
+		})
+		if err != nil {
+			if ctx.Err() == nil {
+				vp.logger.Error("failed to expire rendition versions", "error", err)
+			}
+			return
+		}
+		for _, row := range rows {
+			if err := vp.removeVersion(ctx, row); err != nil {
+				vp.logger.Error("failed to delete the objects of an expired rendition version", "error", err, "videoID", row.VideoID, "version", row.Version)
+				continue
+			}
+			vp.logger.Info("rendition version expired", "videoID", row.VideoID, "version", row.Version)
+		}
+		if len(rows) < versionExpiryBatch {
+			return
+		}
+	}
+}
+
+// removeVersion deletes the results of the processing runs of a deleted version, except those
+// the video or its other versions still point at
+func (vp *videoProcessor) removeVersion(ctx context.Context, version db.VideoRenditionVersion) error {
+	variants, assets, err := versionRows(version)
+	if err != nil {
+		return err
+	}
+	// results prefix -> bucket
+	prefixes := map[string]string{}
+	for _, v := range variants {
+		if prefix, ok := resultsPrefixOf(v.Key); ok {
+			prefixes[prefix] = v.Bucket
+		}
+	}
+	for _, a := range assets {
+		if prefix, ok := resultsPrefixOf(a.Key); ok {
+			prefixes[prefix] = a.Bucket
+		}
+	}
+	for prefix, bucket := range prefixes {
+		inUse, err := vp.db.RenditionPrefixInUse(ctx, db.RenditionPrefixInUseParams{VideoID: version.VideoID, Prefix: prefix})
+		if err != nil {
+			return err
+		}
+		if inUse {
+			continue
+		}
+		if err := vp.removePrefix(ctx, bucket, prefix); err != nil {
+			return err
+		}
+		// the objects no longer count towards the owner's storage
+		if err := vp.db.DeleteResultSize(ctx, db.DeleteResultSizeParams{VideoID: version.VideoID, Prefix: prefix}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// archiveRenditions keeps the current variants and assets of a video as a version before a
+// processing run replaces them. A run that fails still leaves its version behind; it points at
+// objects the video keeps using, which its expiry does not delete. It reports whether this is
+// the first processing of the video, which has no renditions to keep.
+func (rc *redisConsumer) archiveRenditions(ctx context.Context, videoID uuid.UUID) (first bool) {
+	version, err := rc.db.ArchiveRenditions(ctx, videoID)
+	switch {
+	case err == nil:
+		rc.logger.Info("rendition set archived", "videoID", videoID, "version", version.Version)
+	case errors.Is(err, pgx.ErrNoRows):
+		return true
+	default:
+		rc.logger.Error("failed to archive the rendition set, it cannot be rolled back to", "error", err, "videoID", videoID)
+	}
+	return false
+}
+
+// versionRows decodes the variant and asset rows a version keeps
+func versionRows(version db.VideoRenditionVersion) ([]db.VideoVariant, []db.VideoAsset, error) {
+	var variants []db.VideoVariant
+	if err := json.Unmarshal(version.Variants, &variants); err != nil {
+		return nil, nil, fmt.Errorf("failed to decode the variants of version %d of %s: %w", version.Version, version.VideoID, err)
+	}
+	var assets []db.VideoAsset
+	if err := json.Unmarshal(version.Assets, &assets); err != nil {
+		return nil, nil, fmt.Errorf("failed to decode the assets of version %d of %s: %w", version.Version, version.VideoID, err)
+	}
+	return variants, assets, nil
+}
+
+func renditionVersionFromRow(row db.VideoRenditionVersion) (models.RenditionVersion, error) {
+	variants, assets, err := versionRows(row)
+	if err != nil {
+		return models.RenditionVersion{}, err
+	}
+	version := models.RenditionVersion{
+		Version:    row.Version,
+		ReplacedAt: row.CreatedAt,
+		Variants:   make([]models.VideoVariant, 0, len(variants)),
+		Assets:     map[string]string{},
+	}
+	for _, v := range variants {
+		version.Variants = append(version.Variants, variantFromRow(v))
+	}
+	for _, a := range assets {
+		version.Assets[a.Kind] = a.Key
+	}
+	return version, nil
+}
@@ -0,0 +1,141 @@
+package video
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"testing"
+	"time"
+	"video-processing/database/db"
+	"video-processing/mocks"
+	"video-processing/models"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/minio/minio-go/v7"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+// renditionVersion builds a version the way ArchiveRenditions stores it
+func renditionVersion(t *testing.T, videoID uuid.UUID, version int32, variants []db.VideoVariant, assets []db.VideoAsset) db.VideoRenditionVersion {
+	if assets == nil {
+		assets = []db.VideoAsset{}
+	}
+	variantsJSON, err := json.Marshal(variants)
+	require.NoError(t, err)
+	assetsJSON, err := json.Marshal(assets)
+	require.NoError(t, err)
+	return db.VideoRenditionVersion{ID: uuid.New(), VideoID: videoID, Version: version, Variants: variantsJSON, Assets: assetsJSON, CreatedAt: time.Now()}
+}
+
+func TestListRenditionVersions(t *testing.T) {
+	vp, repo := newSubscriptionsProcessor(t)
+	owner, videoID := uuid.New(), uuid.New()
+	run := "processed/" + uuid.NewString() + "/"
+
+	repo.EXPECT().GetVideo(gomock.Any(), videoID).Return(db.Video{ID: videoID, UserID: owner}, nil)
+	repo.EXPECT().ListRenditionVersions(gomock.Any(), videoID).Return([]db.VideoRenditionVersion{
+		renditionVersion(t, videoID, 1,
+			[]db.VideoVariant{{VariantName: "720p", Key: run + "720p/720p.mp4", Width: pgtype.Int4{Int32: 1280, Valid: true}, HlsPlaylistKey: pgtype.Text{String: run + "720p/index.m3u8", Valid: true}}},
+			[]db.VideoAsset{{Kind: AssetKindMasterPlaylist, Key: run + "master.m3u8"}}),
+	}, nil)
+	versions, err := vp.ListRenditionVersions(context.Background(), owner, videoID)
+	require.NoError(t, err)
+	require.Len(t, versions, 1)
+	require.Equal(t, int32(1), versions[0].Version)
+	require.Equal(t, "720p", versions[0].Variants[0].Name)
+	require.Equal(t, int32(1280), versions[0].Variants[0].Width)
+	require.Equal(t, run+"720p/index.m3u8", versions[0].Variants[0].HlsPlaylistKey)
+	require.Equal(t, run+"master.m3u8", versions[0].Assets[AssetKindMasterPlaylist])
+
+	// versions of other users' videos are not found
+	repo.EXPECT().GetVideo(gomock.Any(), videoID).Return(db.Video{ID: videoID, UserID: owner, Visibility: models.VisibilityPublic}, nil)
+	_, err = vp.ListRenditionVersions(context.Background(), uuid.New(), videoID)
+	var e models.Error
+	require.ErrorAs(t, err, &e)
+	require.Equal(t, http.StatusNotFound, e.Code)
+}
+
+func TestRollbackRenditions(t *testing.T) {
+	vp, repo := newSubscriptionsProcessor(t)
+	owner, videoID := uuid.New(), uuid.New()
+	video := db.Video{ID: videoID, UserID: owner}
+	version := renditionVersion(t, videoID, 2, []db.VideoVariant{{VariantName: "720p"}}, nil)
+	var e models.Error
+
+	repo.EXPECT().GetVideo(gomock.Any(), videoID).Return(video, nil)
+	repo.EXPECT().GetRenditionVersion(gomock.Any(), db.GetRenditionVersionParams{VideoID: videoID, Version: 2}).Return(version, nil)
+	// the current set is kept before it is replaced
+	archive := repo.EXPECT().ArchiveRenditions(gomock.Any(), videoID).Return(db.VideoRenditionVersion{Version: 3}, nil)
+	repo.EXPECT().RestoreRenditionVersion(gomock.Any(), version.ID).Return(db.RestoreRenditionVersionRow{Variants: 1}, nil).After(archive)
+	expectVideoDetail(repo, video)
+	_, err := vp.RollbackRenditions(context.Background(), owner, videoID, 2)
+	require.NoError(t, err)
+
+	// unknown versions
+	repo.EXPECT().GetVideo(gomock.Any(), videoID).Return(video, nil)
+	repo.EXPECT().GetRenditionVersion(gomock.Any(), gomock.Any()).Return(db.VideoRenditionVersion{}, pgx.ErrNoRows)
+	_, err = vp.RollbackRenditions(context.Background(), owner, videoID, 7)
+	require.ErrorAs(t, err, &e)
+	require.Equal(t, http.StatusNotFound, e.Code)
+
+	// a version restored by a concurrent rollback is gone
+	repo.EXPECT().GetVideo(gomock.Any(), videoID).Return(video, nil)
+	repo.EXPECT().GetRenditionVersion(gomock.Any(), gomock.Any()).Return(version, nil)
+	repo.EXPECT().ArchiveRenditions(gomock.Any(), videoID).Return(db.VideoRenditionVersion{}, pgx.ErrNoRows)
+	repo.EXPECT().RestoreRenditionVersion(gomock.Any(), version.ID).Return(db.RestoreRenditionVersionRow{}, nil)
+	_, err = vp.RollbackRenditions(context.Background(), owner, videoID, 2)
+	require.ErrorAs(t, err, &e)
+	require.Equal(t, http.StatusNotFound, e.Code)
+}
+
+func TestExpireVersionsKeepsObjectsInUse(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	repo := mocks.NewMockVideoRepo(ctrl)
+	store := mocks.NewMockObjectStore(ctrl)
+	vp := &videoProcessor{logger: slog.New(slog.NewTextHandler(io.Discard, nil)), db: repo, minioClient: store}
+	videoID := uuid.New()
+	owner := uuid.NewString() + "/"
+	old, current := owner+"processed/"+uuid.NewString()+"/", owner+"processed/"+uuid.NewString()+"/"
+	version := renditionVersion(t, videoID, 1,
+		[]db.VideoVariant{{Bucket: "shared", Key: old + "720p/720p.mp4"}, {Bucket: "shared", Key: current + "480p/480p.mp4"}},
+		[]db.VideoAsset{{Bucket: "shared", Key: old + "preview.mp4"}})
+
+	repo.EXPECT().
+		ExpireRenditionVersions(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, arg db.ExpireRenditionVersionsParams) ([]db.VideoRenditionVersion, error) {
+			require.WithinDuration(t, time.Now().Add(-48*time.Hour), arg.CreatedAt, time.Minute)
+			require.Equal(t, int32(versionExpiryBatch), arg.Limit)
+			return []db.VideoRenditionVersion{version}, nil
+		})
+	repo.EXPECT().RenditionPrefixInUse(gomock.Any(), db.RenditionPrefixInUseParams{VideoID: videoID, Prefix: old}).Return(false, nil)
+	// a variant that failed to reprocess still plays from the run it was encoded by
+	repo.EXPECT().RenditionPrefixInUse(gomock.Any(), db.RenditionPrefixInUseParams{VideoID: videoID, Prefix: current}).Return(true, nil)
+	objects := make(chan minio.ObjectInfo, 2)
+	objects <- minio.ObjectInfo{Key: old + "720p/720p.mp4"}
+	objects <- minio.ObjectInfo{Key: old + "preview.mp4"}
+	close(objects)
+	store.EXPECT().ListObjects(gomock.Any(), "shared", minio.ListObjectsOptions{Prefix: old, Recursive: true}).Return(objects)
+	store.EXPECT().RemoveObject(gomock.Any(), "shared", old+"720p/720p.mp4", gomock.Any()).Return(nil)
+	store.EXPECT().RemoveObject(gomock.Any(), "shared", old+"preview.mp4", gomock.Any()).Return(nil)
+	// the deleted run stops counting towards the owner's storage
+	repo.EXPECT().DeleteResultSize(gomock.Any(), db.DeleteResultSizeParams{VideoID: videoID, Prefix: old}).Return(nil)
+	vp.expireVersions(context.Background(), 48*time.Hour)
+}
+
+func TestArchiveRenditionsBeforeProcessing(t *testing.T) {
+	repo := mocks.NewMockVideoRepo(gomock.NewController(t))
+	rc := &redisConsumer{logger: slog.New(slog.NewTextHandler(io.Discard, nil)), db: repo}
+	videoID := uuid.New()
+
+	repo.EXPECT().ArchiveRenditions(gomock.Any(), videoID).Return(db.VideoRenditionVersion{VideoID: videoID, Version: 1}, nil)
+	rc.archiveRenditions(context.Background(), videoID)
+
+	// first processing, nothing to keep
+	repo.EXPECT().ArchiveRenditions(gomock.Any(), videoID).Return(db.VideoRenditionVersion{}, pgx.ErrNoRows)
+	rc.archiveRenditions(context.Background(), videoID)
+}
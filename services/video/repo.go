@@ -0,0 +1,183 @@
+package video
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"time"
+	"video-processing/database/db"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/minio/minio-go/v7"
+	"github.com/redis/go-redis/v9"
+)
+
+//go:generate mockgen -destination=../../mocks/video.go -package=mocks . ObjectStore,Broker,VideoRepo,Streamer,VideoProcessor
+
+// ObjectStore is the subset of the MinIO client the video services use
+type ObjectStore interface {
+	MakeBucket(ctx context.Context, bucketName string, opts minio.MakeBucketOptions) error
+	ListBuckets(ctx context.Context) ([]minio.BucketInfo, error)
+	PutObject(ctx context.Context, bucketName, objectName string, reader io.Reader, size int64, opts minio.PutObjectOptions) (minio.UploadInfo, error)
+	FPutObject(ctx context.Context, bucketName, objectName, filePath string, opts minio.PutObjectOptions) (minio.UploadInfo, error)
+	FGetObject(ctx context.Context, bucketName, objectName, filePath string, opts minio.GetObjectOptions) error
+	StatObject(ctx context.Context, bucketName, objectName string, opts minio.StatObjectOptions) (minio.ObjectInfo, error)
+	PresignedGetObject(ctx context.Context, bucketName, objectName string, expires time.Duration, reqParams url.Values) (*url.URL, error)
+	ListObjects(ctx context.Context, bucketName string, opts minio.ListObjectsOptions) <-chan minio.ObjectInfo
+	RemoveObject(ctx context.Context, bucketName, objectName string, opts minio.RemoveObjectOptions) error
+}
+
+// Broker is the subset of the Redis client used to queue and consume processing jobs
+type Broker interface {
+	XAdd(ctx context.Context, a *redis.XAddArgs) *redis.StringCmd
+	XGroupCreateMkStream(ctx context.Context, stream, group, start string) *redis.StatusCmd
+	XReadGroup(ctx context.Context, a *redis.XReadGroupArgs) *redis.XStreamSliceCmd
+	XAck(ctx context.Context, stream, group string, ids ...string) *redis.IntCmd
+	Eval(ctx context.Context, script string, keys []string, args ...interface{}) *redis.Cmd
+}
+
+// VideoRepo holds the video queries used by the video service and the processing worker
+type VideoRepo interface {
+	CreateVideo(ctx context.Context, arg db.CreateVideoParams) (db.Video, error)
+	CreateDerivedVideo(ctx context.Context, arg db.CreateDerivedVideoParams) (db.Video, error)
+	GetVideo(ctx context.Context, id uuid.UUID) (db.Video, error)
+	GetVideoByObject(ctx context.Context, arg db.GetVideoByObjectParams) (db.Video, error)
+	ListUserVideos(ctx context.Context, arg db.ListUserVideosParams) ([]db.ListUserVideosRow, error)
+	UpdateVideoFileSize(ctx context.Context, arg db.UpdateVideoFileSizeParams) error
+	UpdateVideoColorMetadata(ctx context.Context, arg db.UpdateVideoColorMetadataParams) error
+	UpdateVideoProjection(ctx context.Context, arg db.UpdateVideoProjectionParams) error
+	UpdateVideoSourceResolution(ctx context.Context, arg db.UpdateVideoSourceResolutionParams) error
+	UpdateVideoSourceMetadata(ctx context.Context, arg db.UpdateVideoSourceMetadataParams) error
+	FailVideo(ctx context.Context, arg db.FailVideoParams) error
+	ClearVideoFailure(ctx context.Context, id uuid.UUID) error
+	UpdateVideoStatus(ctx context.Context, arg db.UpdateVideoStatusParams) (db.Video, error)
+
+	SaveProcessedVideoMetadata(ctx context.Context, arg db.SaveProcessedVideoMetadataParams) (db.VideoVariant, error)
+	ListVideoVariants(ctx context.Context, videoID uuid.UUID) ([]db.VideoVariant, error)
+	UpdateVariantQuality(ctx context.Context, arg db.UpdateVariantQualityParams) error
+	ListVariantQualityReport(ctx context.Context) ([]db.ListVariantQualityReportRow, error)
+
+	SaveVideoAsset(ctx context.Context, arg db.SaveVideoAssetParams) (db.VideoAsset, error)
+	SetVideoMasterPlaylist(ctx context.Context, arg db.SetVideoMasterPlaylistParams) error
+	ListVideoAssets(ctx context.Context, videoID uuid.UUID) ([]db.VideoAsset, error)
+
+	CreateVideoChapter(ctx context.Context, arg db.CreateVideoChapterParams) (db.VideoChapter, error)
+	ListVideoChapters(ctx context.Context, videoID uuid.UUID) ([]db.VideoChapter, error)
+	DeleteVideoChapters(ctx context.Context, videoID uuid.UUID) error
+
+	SaveVideoSubtitle(ctx context.Context, arg db.SaveVideoSubtitleParams) (db.VideoSubtitle, error)
+	ListVideoSubtitles(ctx context.Context, videoID uuid.UUID) ([]db.VideoSubtitle, error)
+	DeleteVideoSubtitles(ctx context.Context, videoID uuid.UUID) error
+
+	SaveVideoThumbnail(ctx context.Context, arg db.SaveVideoThumbnailParams) (db.VideoThumbnail, error)
+	ListVideoThumbnails(ctx context.Context, videoID uuid.UUID) ([]db.VideoThumbnail, error)
+	DeleteVideoThumbnailsFrom(ctx context.Context, arg db.DeleteVideoThumbnailsFromParams) error
+	SetPrimaryVideoThumbnail(ctx context.Context, arg db.SetPrimaryVideoThumbnailParams) (int64, error)
+
+	SaveVideoProgress(ctx context.Context, arg db.SaveVideoProgressParams) error
+	ListVideoProgress(ctx context.Context, videoID uuid.UUID) ([]db.VideoProgress, error)
+	ListVariantThroughput(ctx context.Context) ([]db.ListVariantThroughputRow, error)
+	DeleteVideoProgress(ctx context.Context, videoID uuid.UUID) error
+
+	SaveVideoFingerprint(ctx context.Context, arg db.SaveVideoFingerprintParams) error
+	SaveVideoProbe(ctx context.Context, arg db.SaveVideoProbeParams) error
+	GetVideoProbe(ctx context.Context, videoID uuid.UUID) (db.VideoProbe, error)
+	ListVideoFingerprints(ctx context.Context) ([]db.ListVideoFingerprintsRow, error)
+
+	CreateTranscodingPreset(ctx context.Context, arg db.CreateTranscodingPresetParams) (db.TranscodingPreset, error)
+	GetTranscodingPreset(ctx context.Context, id uuid.UUID) (db.TranscodingPreset, error)
+	GetTranscodingPresetByName(ctx context.Context, name string) (db.TranscodingPreset, error)
+	GetDefaultTranscodingPreset(ctx context.Context) (db.TranscodingPreset, error)
+	ListTranscodingPresets(ctx context.Context) ([]db.TranscodingPreset, error)
+	UpdateTranscodingPreset(ctx context.Context, arg db.UpdateTranscodingPresetParams) (db.TranscodingPreset, error)
+	SetDefaultTranscodingPreset(ctx context.Context, id uuid.UUID) error
+	DeleteTranscodingPreset(ctx context.Context, id uuid.UUID) error
+
+	CreateReprocessRun(ctx context.Context, arg db.CreateReprocessRunParams) (db.ReprocessRun, error)
+	GetReprocessRun(ctx context.Context, id uuid.UUID) (db.ReprocessRun, error)
+	ListReprocessRuns(ctx context.Context) ([]db.ReprocessRun, error)
+	CountReprocessCandidates(ctx context.Context, arg db.CountReprocessCandidatesParams) (int64, error)
+	ListReprocessCandidates(ctx context.Context, arg db.ListReprocessCandidatesParams) ([]db.ListReprocessCandidatesRow, error)
+	ClaimReprocessRun(ctx context.Context, leaseUntil time.Time) (db.ReprocessRun, error)
+	AdvanceReprocessRun(ctx context.Context, arg db.AdvanceReprocessRunParams) (db.ReprocessRun, error)
+	ReleaseReprocessRun(ctx context.Context, arg db.ReleaseReprocessRunParams) error
+	FinishReprocessRun(ctx context.Context, arg db.FinishReprocessRunParams) (db.ReprocessRun, error)
+	RecordReprocessResult(ctx context.Context, arg db.RecordReprocessResultParams) error
+
+	SaveWatchPosition(ctx context.Context, arg db.SaveWatchPositionParams) (db.WatchHistory, error)
+	GetWatchPosition(ctx context.Context, arg db.GetWatchPositionParams) (db.WatchHistory, error)
+	ListWatchHistory(ctx context.Context, arg db.ListWatchHistoryParams) ([]db.ListWatchHistoryRow, error)
+	DeleteWatchHistoryEntry(ctx context.Context, arg db.DeleteWatchHistoryEntryParams) error
+	ClearWatchHistory(ctx context.Context, userID uuid.UUID) error
+
+	SetVideoVisibility(ctx context.Context, arg db.SetVideoVisibilityParams) (db.Video, error)
+	Subscribe(ctx context.Context, arg db.SubscribeParams) (int64, error)
+	Unsubscribe(ctx context.Context, arg db.UnsubscribeParams) error
+	ListSubscriptions(ctx context.Context, subscriberID uuid.UUID) ([]db.ListSubscriptionsRow, error)
+	ListFeed(ctx context.Context, arg db.ListFeedParams) ([]db.ListFeedRow, error)
+	CreateVideoNotifications(ctx context.Context, arg db.CreateVideoNotificationsParams) (int64, error)
+	ListNotifications(ctx context.Context, arg db.ListNotificationsParams) ([]db.ListNotificationsRow, error)
+	MarkNotificationsRead(ctx context.Context, userID uuid.UUID) error
+
+	CreateVideoReport(ctx context.Context, arg db.CreateVideoReportParams) (db.VideoReport, error)
+	GetVideoReport(ctx context.Context, id uuid.UUID) (db.VideoReport, error)
+	ListVideoReports(ctx context.Context, arg db.ListVideoReportsParams) ([]db.ListVideoReportsRow, error)
+	ResolveVideoReports(ctx context.Context, arg db.ResolveVideoReportsParams) (int64, error)
+	SetVideoAgeRestricted(ctx context.Context, arg db.SetVideoAgeRestrictedParams) error
+	CreateNotification(ctx context.Context, arg db.CreateNotificationParams) error
+
+	SetVideoSchedule(ctx context.Context, arg db.SetVideoScheduleParams) (db.Video, error)
+	PublishDueVideos(ctx context.Context, limit int32) ([]db.PublishDueVideosRow, error)
+	ExpireDueVideos(ctx context.Context, limit int32) ([]db.ExpireDueVideosRow, error)
+	DeferVideoPurge(ctx context.Context, arg db.DeferVideoPurgeParams) (int32, error)
+	DeleteVideo(ctx context.Context, id uuid.UUID) (db.Video, error)
+
+	SetVideoTranslation(ctx context.Context, arg db.SetVideoTranslationParams) (db.VideoTranslation, error)
+	DeleteVideoTranslation(ctx context.Context, arg db.DeleteVideoTranslationParams) (int64, error)
+	ListVideoTranslations(ctx context.Context, videoID uuid.UUID) ([]db.VideoTranslation, error)
+	ListTranslationsOfVideos(ctx context.Context, videoIds []uuid.UUID) ([]db.VideoTranslation, error)
+
+	CreateVideoKey(ctx context.Context, arg db.CreateVideoKeyParams) ([]byte, error)
+	GetVideoKey(ctx context.Context, videoID uuid.UUID) ([]byte, error)
+
+	SetVideoPlaybackPassword(ctx context.Context, arg db.SetVideoPlaybackPasswordParams) (db.Video, error)
+	GetPlaybackFailures(ctx context.Context, arg db.GetPlaybackFailuresParams) (int32, error)
+	RecordPlaybackFailure(ctx context.Context, arg db.RecordPlaybackFailureParams) (int32, error)
+	ClearPlaybackFailures(ctx context.Context, arg db.ClearPlaybackFailuresParams) error
+
+	GetNotificationPreferences(ctx context.Context, userID uuid.UUID) (db.GetNotificationPreferencesRow, error)
+	SetNotificationPreferences(ctx context.Context, arg db.SetNotificationPreferencesParams) (db.NotificationPreference, error)
+	ListSubscriberPreferences(ctx context.Context, creatorID uuid.UUID) ([]db.ListSubscriberPreferencesRow, error)
+
+	CreateDataExport(ctx context.Context, userID uuid.UUID) (db.DataExport, error)
+	GetActiveDataExport(ctx context.Context, userID uuid.UUID) (db.DataExport, error)
+	GetDataExport(ctx context.Context, arg db.GetDataExportParams) (db.DataExport, error)
+	ClaimDataExport(ctx context.Context, leaseUntil time.Time) (db.DataExport, error)
+	CompleteDataExport(ctx context.Context, arg db.CompleteDataExportParams) (db.DataExport, error)
+	FailDataExport(ctx context.Context, arg db.FailDataExportParams) error
+	ExpireDataExports(ctx context.Context, limit int32) ([]db.ExpireDataExportsRow, error)
+	GetUser(ctx context.Context, id uuid.UUID) (db.User, error)
+	ListAllUserVideos(ctx context.Context, userID uuid.UUID) ([]db.Video, error)
+	ListUserReports(ctx context.Context, reporterID uuid.UUID) ([]db.VideoReport, error)
+
+	ListUserIDs(ctx context.Context) ([]uuid.UUID, error)
+	MoveUserObjects(ctx context.Context, arg db.MoveUserObjectsParams) (db.MoveUserObjectsRow, error)
+
+	ArchiveRenditions(ctx context.Context, videoID uuid.UUID) (db.VideoRenditionVersion, error)
+	ListRenditionVersions(ctx context.Context, videoID uuid.UUID) ([]db.VideoRenditionVersion, error)
+	GetRenditionVersion(ctx context.Context, arg db.GetRenditionVersionParams) (db.VideoRenditionVersion, error)
+	RestoreRenditionVersion(ctx context.Context, id uuid.UUID) (db.RestoreRenditionVersionRow, error)
+	ExpireRenditionVersions(ctx context.Context, arg db.ExpireRenditionVersionsParams) ([]db.VideoRenditionVersion, error)
+	RenditionPrefixInUse(ctx context.Context, arg db.RenditionPrefixInUseParams) (bool, error)
+
+	RecordUsage(ctx context.Context, arg db.RecordUsageParams) error
+	ListUserUsage(ctx context.Context, arg db.ListUserUsageParams) ([]db.UsageMonthly, error)
+	ListMonthUsage(ctx context.Context, month pgtype.Date) ([]db.UsageMonthly, error)
+	ClaimUsageToEmit(ctx context.Context, arg db.ClaimUsageToEmitParams) ([]db.UsageMonthly, error)
+	MarkUsageEmitted(ctx context.Context, arg db.MarkUsageEmittedParams) error
+	RecordStorageHeld(ctx context.Context, userID uuid.UUID) error
+	SnapshotStorageHeld(ctx context.Context) error
+	SetResultSize(ctx context.Context, arg db.SetResultSizeParams) error
+	DeleteResultSize(ctx context.Context, arg db.DeleteResultSizeParams) error
+}
@@ -0,0 +1,282 @@
+package video
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+	"video-processing/database/db"
+	"video-processing/models"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	// reprocessPollInterval is how often idle instances look for runs to queue
+	reprocessPollInterval = 10 * time.Second
+	// reprocessLease is how long a run stays with an instance that stopped renewing it,
+	// on top of the wait between two videos
+	reprocessLease = time.Minute
+	// reprocessBatch bounds the videos read from the database at once
+	reprocessBatch = 100
+)
+
+// StartReprocess records a run that queues every video matching the request for processing
+// again. The run is picked up by RunReprocessing of any instance.
+func (vp *videoProcessor) StartReprocess(ctx context.Context, req models.ReprocessRequest) (models.ReprocessRun, error) {
+	if err := req.Validate(); err != nil {
+		return models.ReprocessRun{}, models.Error{
+			Code:    http.StatusBadRequest,
+			Message: "invalid input data",
+			Err:     err,
+		}
+	}
+	var arg db.CreateReprocessRunParams
+	if req.PresetID != nil {
+		if _, err := vp.db.GetTranscodingPreset(ctx, *req.PresetID); err != nil {
+			return models.ReprocessRun{}, presetDbError(err, fmt.Sprintf("presetID: %v", *req.PresetID))
+		}
+		arg.PresetID = pgtype.UUID{Bytes: *req.PresetID, Valid: true}
+	}
+	if req.UserID != nil {
+		arg.UserID = pgtype.UUID{Bytes: *req.UserID, Valid: true}
+	}
+	if req.CreatedAfter != nil {
+		arg.CreatedAfter = pgtype.Timestamptz{Time: *req.CreatedAfter, Valid: true}
+	}
+	// the set of videos is fixed when the run starts, later uploads use the new settings anyway
+	arg.CreatedBefore = time.Now()
+	if req.CreatedBefore != nil {
+		arg.CreatedBefore = *req.CreatedBefore
+	}
+	arg.RatePerMinute = int32(req.RatePerMinute)
+	if arg.RatePerMinute == 0 {
+		arg.RatePerMinute = models.DefaultReprocessRate
+	}
+	total, err := vp.db.CountReprocessCandidates(ctx, db.CountReprocessCandidatesParams{
+		UserID:        arg.UserID,
+		CreatedAfter:  arg.CreatedAfter,
+		CreatedBefore: arg.CreatedBefore,
+	})
+	if err != nil {
+		return models.ReprocessRun{}, models.IndentifyDbError(err)
+	}
+	arg.Total = int32(total)
+	row, err := vp.db.CreateReprocessRun(ctx, arg)
+	if err != nil {
+		return models.ReprocessRun{}, models.IndentifyDbError(err)
+	}
+	vp.logger.Info("reprocess run started", "runID", row.ID, "total", row.Total, "ratePerMinute", row.RatePerMinute)
+	return reprocessRunFromRow(row), nil
+}
+
+func (vp *videoProcessor) ListReprocessRuns(ctx context.Context) ([]models.ReprocessRun, error) {
+	rows, err := vp.db.ListReprocessRuns(ctx)
+	if err != nil {
+		return nil, models.IndentifyDbError(err)
+	}
+	runs := make([]models.ReprocessRun, 0, len(rows))
+	for _, row := range rows {
+		runs = append(runs, reprocessRunFromRow(row))
+	}
+	return runs, nil
+}
+
+func (vp *videoProcessor) GetReprocessRun(ctx context.Context, id uuid.UUID) (models.ReprocessRun, error) {
+	row, err := vp.db.GetReprocessRun(ctx, id)
+	if err != nil {
+		return models.ReprocessRun{}, reprocessDbError(err, id)
+	}
+	return reprocessRunFromRow(row), nil
+}
+
+// CancelReprocessRun stops queueing the videos of a run. Videos already queued are still processed.
+func (vp *videoProcessor) CancelReprocessRun(ctx context.Context, id uuid.UUID) (models.ReprocessRun, error) {
+	row, err := vp.db.FinishReprocessRun(ctx, db.FinishReprocessRunParams{ID: id, Status: models.ReprocessCancelled})
+	if errors.Is(err, pgx.ErrNoRows) {
+		// either there is no such run or it is no longer running
+		if row, err = vp.db.GetReprocessRun(ctx, id); err == nil {
+			return models.ReprocessRun{}, models.Error{
+				Code:    http.StatusConflict,
+				Message: "reprocess run not running",
+				Params:  fmt.Sprintf("runID: %v, status: %v", id, row.Status),
+				Err:     fmt.Errorf("reprocess run is %s", row.Status),
+			}
+		}
+	}
+	if err != nil {
+		return models.ReprocessRun{}, reprocessDbError(err, id)
+	}
+	return reprocessRunFromRow(row), nil
+}
+
+// RunReprocessing queues the videos of running reprocess runs until ctx is done. Every instance
+// may run it: a run is leased to one instance at a time, and the runs of stopped instances are
+// resumed after the last queued video once their lease expires.
+func (vp *videoProcessor) RunReprocessing(ctx context.Context) error {
+	ticker := time.NewTicker(reprocessPollInterval)
+	defer ticker.Stop()
+	for {
+		run, err := vp.db.ClaimReprocessRun(ctx, time.Now().Add(reprocessLease))
+		switch {
+		case err == nil:
+			vp.reprocess(ctx, run)
+		case errors.Is(err, pgx.ErrNoRows):
+		case ctx.Err() != nil:
+			return ctx.Err()
+		default:
+			vp.logger.Error("failed to claim reprocess run", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// reprocess queues the remaining videos of a claimed run at its rate. A video is queued again
+// when the instance stops between queueing it and recording it.
+func (vp *videoProcessor) reprocess(ctx context.Context, run db.ReprocessRun) {
+	interval := time.Minute / time.Duration(max(run.RatePerMinute, 1))
+	limiter := time.NewTicker(interval)
+	defer limiter.Stop()
+	vp.logger.Info("reprocessing videos", "runID", run.ID, "enqueued", run.Enqueued, "total", run.Total)
+	for {
+		videos, err := vp.db.ListReprocessCandidates(ctx, db.ListReprocessCandidatesParams{
+			UserID:        run.UserID,
+			CreatedAfter:  run.CreatedAfter,
+			CreatedBefore: run.CreatedBefore,
+			AfterID:       run.LastVideoID,
+			Limit:         reprocessBatch,
+		})
+		if err != nil {
+			vp.releaseReprocess(ctx, run.ID, fmt.Errorf("failed to list videos: %w", err))
+			return
+		}
+		if len(videos) == 0 {
+			if _, err := vp.db.FinishReprocessRun(ctx, db.FinishReprocessRunParams{ID: run.ID, Status: models.ReprocessCompleted}); err != nil && !errors.Is(err, pgx.ErrNoRows) {
+				vp.releaseReprocess(ctx, run.ID, fmt.Errorf("failed to complete run: %w", err))
+				return
+			}
+			vp.logger.Info("reprocess run completed", "runID", run.ID, "enqueued", run.Enqueued)
+			return
+		}
+		for _, v := range videos {
+			job := map[string]interface{}{
+				"bucket":           v.Bucket,
+				"key":              v.Key,
+				"video_id":         v.ID.String(),
+				"user_id":          v.UserID.String(),
+				"reprocess_run_id": run.ID.String(),
+				"mode":             vp.userMode(v.UserID, ""),
+			}
+			if run.PresetID.Valid {
+				job["preset_id"] = uuid.UUID(run.PresetID.Bytes).String()
+			}
+			if err := vp.addWatermark(ctx, v.UserID, job); err != nil {
+				vp.logger.Warn("reprocessing without the user's watermark", "error", err, "videoID", v.ID)
+			}
+			if err := vp.addBumpers(ctx, v.UserID, job); err != nil {
+				vp.logger.Warn("reprocessing without the user's bumpers", "error", err, "videoID", v.ID)
+			}
+			if err := vp.streamer.Stream(ctx, job); err != nil {
+				vp.releaseReprocess(ctx, run.ID, fmt.Errorf("failed to queue video %s: %w", v.ID, err))
+				return
+			}
+			advanced, err := vp.db.AdvanceReprocessRun(ctx, db.AdvanceReprocessRunParams{
+				LastVideoID: v.ID,
+				LeaseUntil:  time.Now().Add(interval + reprocessLease),
+				ID:          run.ID,
+			})
+			if errors.Is(err, pgx.ErrNoRows) {
+				vp.logger.Info("reprocess run cancelled", "runID", run.ID)
+				return
+			}
+			if err != nil {
+				vp.releaseReprocess(ctx, run.ID, fmt.Errorf("failed to record queued video %s: %w", v.ID, err))
+				return
+			}
+			run = advanced
+			select {
+			case <-ctx.Done():
+				vp.releaseReprocess(ctx, run.ID, nil)
+				return
+			case <-limiter.C:
+			}
+		}
+	}
+}
+
+// releaseReprocess hands a run back so it is resumed, by this or another instance
+func (vp *videoProcessor) releaseReprocess(ctx context.Context, runID uuid.UUID, cause error) {
+	var reason string
+	if cause != nil {
+		reason = cause.Error()
+		vp.logger.Error("reprocess run stalled, retrying later", "error", cause, "runID", runID)
+	}
+	// shutting down is the common reason to release, the lease must go regardless
+	err := vp.db.ReleaseReprocessRun(context.WithoutCancel(ctx), db.ReleaseReprocessRunParams{ID: runID, Error: reason})
+	if err != nil {
+		vp.logger.Error("failed to release reprocess run", "error", err, "runID", runID)
+	}
+}
+
+// recordReprocessResult counts a finished job towards the reprocess run that queued it
+func (rc *redisConsumer) recordReprocessResult(ctx context.Context, values map[string]interface{}, jobErr error) {
+	runID, err := uuid.Parse(fmt.Sprint(values["reprocess_run_id"]))
+	if err != nil || rc.processing.DryRun {
+		return
+	}
+	err = rc.db.RecordReprocessResult(ctx, db.RecordReprocessResultParams{Failed: jobErr != nil, ID: runID})
+	if err != nil {
+		rc.logger.Warn("failed to record reprocess result", "error", err, "runID", runID, "videoID", values["video_id"])
+	}
+}
+
+func reprocessRunFromRow(row db.ReprocessRun) models.ReprocessRun {
+	run := models.ReprocessRun{
+		ID:            row.ID,
+		CreatedBefore: row.CreatedBefore,
+		RatePerMinute: int(row.RatePerMinute),
+		Status:        row.Status,
+		Total:         int(row.Total),
+		Enqueued:      int(row.Enqueued),
+		Succeeded:     int(row.Succeeded),
+		Failed:        int(row.Failed),
+		Error:         row.Error,
+		CreatedAt:     row.CreatedAt,
+		UpdatedAt:     row.UpdatedAt,
+	}
+	if row.PresetID.Valid {
+		id := uuid.UUID(row.PresetID.Bytes)
+		run.PresetID = &id
+	}
+	if row.UserID.Valid {
+		id := uuid.UUID(row.UserID.Bytes)
+		run.UserID = &id
+	}
+	if row.CreatedAfter.Valid {
+		run.CreatedAfter = &row.CreatedAfter.Time
+	}
+	if row.FinishedAt.Valid {
+		run.FinishedAt = &row.FinishedAt.Time
+	}
+	return run
+}
+
+func reprocessDbError(err error, id uuid.UUID) error {
+	params := fmt.Sprintf("runID: %v", id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.Error{
+			Code:    http.StatusNotFound,
+			Message: "resource not found",
+			Params:  params,
+			Err:     models.ErrResourceNotFound,
+		}
+	}
+	return models.IndentifyDbError(err).AddParams(params)
+}
@@ -0,0 +1,143 @@
+package video
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"testing"
+	"time"
+	"video-processing/database/db"
+	"video-processing/mocks"
+	"video-processing/models"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/minio/minio-go/v7"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestReprocessResumesAfterLastVideo(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	repo := mocks.NewMockVideoRepo(ctrl)
+	streamer := mocks.NewMockStreamer(ctrl)
+	store := mocks.NewMockObjectStore(ctrl)
+	presetID, userID := uuid.New(), uuid.New()
+	vp := &videoProcessor{
+		logger:      slog.New(slog.NewTextHandler(io.Discard, nil)),
+		db:          repo,
+		streamer:    streamer,
+		minioClient: store,
+		// the videos are reprocessed in the mode of their owner's plan
+		delivery: models.DeliveryConfig{Users: map[string]string{userID.String(): "pro"}},
+		modes:    models.ModesConfig{Plans: map[string]string{"pro": models.ModeQuality}},
+	}
+	run := db.ReprocessRun{
+		ID:            uuid.New(),
+		PresetID:      pgtype.UUID{Bytes: presetID, Valid: true},
+		CreatedBefore: time.Now(),
+		RatePerMinute: 60000,
+		Total:         3,
+		Enqueued:      1,
+		LastVideoID:   uuid.New(), // queued before the previous instance stopped
+	}
+	videos := []db.ListReprocessCandidatesRow{
+		{ID: uuid.New(), UserID: userID, Bucket: userID.String(), Key: "a.mp4"},
+		{ID: uuid.New(), UserID: userID, Bucket: userID.String(), Key: "b.mp4"},
+	}
+	repo.EXPECT().ListReprocessCandidates(gomock.Any(), db.ListReprocessCandidatesParams{
+		CreatedBefore: run.CreatedBefore, AfterID: run.LastVideoID, Limit: reprocessBatch,
+	}).Return(videos, nil)
+	// the owner's watermark is composed onto the new renditions
+	store.EXPECT().StatObject(gomock.Any(), userID.String(), watermarkKey, gomock.Any()).Return(minio.ObjectInfo{}, nil).Times(2)
+	for _, key := range bumperKeys {
+		store.EXPECT().StatObject(gomock.Any(), userID.String(), key, gomock.Any()).Return(minio.ObjectInfo{}, minio.ErrorResponse{Code: "NoSuchKey"}).Times(2)
+	}
+	for _, v := range videos {
+		streamer.EXPECT().Stream(gomock.Any(), map[string]interface{}{
+			"bucket":           v.Bucket,
+			"key":              v.Key,
+			"video_id":         v.ID.String(),
+			"user_id":          userID.String(),
+			"reprocess_run_id": run.ID.String(),
+			"mode":             models.ModeQuality,
+			"preset_id":        presetID.String(),
+			"watermark_bucket": userID.String(),
+			"watermark_key":    watermarkKey,
+		}).Return(nil)
+		repo.EXPECT().AdvanceReprocessRun(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, arg db.AdvanceReprocessRunParams) (db.ReprocessRun, error) {
+				require.Equal(t, v.ID, arg.LastVideoID)
+				advanced := run
+				advanced.LastVideoID = arg.LastVideoID
+				return advanced, nil
+			})
+	}
+	repo.EXPECT().ListReprocessCandidates(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, arg db.ListReprocessCandidatesParams) ([]db.ListReprocessCandidatesRow, error) {
+			require.Equal(t, videos[1].ID, arg.AfterID)
+			return nil, nil
+		})
+	repo.EXPECT().FinishReprocessRun(gomock.Any(), db.FinishReprocessRunParams{ID: run.ID, Status: models.ReprocessCompleted}).Return(run, nil)
+	vp.reprocess(context.Background(), run)
+}
+
+func TestReprocessStops(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	repo := mocks.NewMockVideoRepo(ctrl)
+	streamer := mocks.NewMockStreamer(ctrl)
+	store := mocks.NewMockObjectStore(ctrl)
+	vp := &videoProcessor{logger: slog.New(slog.NewTextHandler(io.Discard, nil)), db: repo, streamer: streamer, minioClient: store}
+	run := db.ReprocessRun{ID: uuid.New(), CreatedBefore: time.Now(), RatePerMinute: 60000}
+	store.EXPECT().StatObject(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(minio.ObjectInfo{}, minio.ErrorResponse{Code: "NoSuchKey"}).AnyTimes()
+	videos := []db.ListReprocessCandidatesRow{{ID: uuid.New(), UserID: uuid.New(), Bucket: "b", Key: "a.mp4"}}
+	repo.EXPECT().ListReprocessCandidates(gomock.Any(), gomock.Any()).Return(videos, nil).Times(2)
+
+	// a cancelled run is left as it is
+	streamer.EXPECT().Stream(gomock.Any(), gomock.Any()).Return(nil)
+	repo.EXPECT().AdvanceReprocessRun(gomock.Any(), gomock.Any()).Return(db.ReprocessRun{}, pgx.ErrNoRows)
+	vp.reprocess(context.Background(), run)
+
+	// a run that cannot be queued is released to be retried
+	streamer.EXPECT().Stream(gomock.Any(), gomock.Any()).Return(errors.New("redis down"))
+	repo.EXPECT().ReleaseReprocessRun(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, arg db.ReleaseReprocessRunParams) error {
+			require.Equal(t, run.ID, arg.ID)
+			require.Contains(t, arg.Error, "redis down")
+			return nil
+		})
+	vp.reprocess(context.Background(), run)
+}
+
+func TestCancelReprocessRun(t *testing.T) {
+	repo := mocks.NewMockVideoRepo(gomock.NewController(t))
+	vp := &videoProcessor{db: repo}
+	var apiErr models.Error
+
+	done, missing := uuid.New(), uuid.New()
+	repo.EXPECT().FinishReprocessRun(gomock.Any(), gomock.Any()).Return(db.ReprocessRun{}, pgx.ErrNoRows).Times(2)
+	repo.EXPECT().GetReprocessRun(gomock.Any(), done).Return(db.ReprocessRun{ID: done, Status: models.ReprocessCompleted}, nil)
+	repo.EXPECT().GetReprocessRun(gomock.Any(), missing).Return(db.ReprocessRun{}, pgx.ErrNoRows)
+
+	_, err := vp.CancelReprocessRun(context.Background(), done)
+	require.True(t, errors.As(err, &apiErr))
+	require.Equal(t, http.StatusConflict, apiErr.Code)
+	_, err = vp.CancelReprocessRun(context.Background(), missing)
+	require.True(t, errors.As(err, &apiErr))
+	require.Equal(t, http.StatusNotFound, apiErr.Code)
+}
+
+func TestRecordReprocessResult(t *testing.T) {
+	repo := mocks.NewMockVideoRepo(gomock.NewController(t))
+	rc := &redisConsumer{logger: slog.New(slog.NewTextHandler(io.Discard, nil)), db: repo}
+	runID := uuid.New()
+	repo.EXPECT().RecordReprocessResult(gomock.Any(), db.RecordReprocessResultParams{Failed: true, ID: runID}).Return(nil)
+	rc.recordReprocessResult(context.Background(), map[string]interface{}{"reprocess_run_id": runID.String()}, errors.New("failed"))
+
+	// jobs of uploads are not counted anywhere
+	rc.recordReprocessResult(context.Background(), map[string]interface{}{"video_id": uuid.NewString()}, nil)
+}
@@ -0,0 +1,61 @@
+package video
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+	"video-processing/database/db"
+	"video-processing/mocks"
+	"video-processing/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestParseScenes(t *testing.T) {
+	out := "frame:0    pts:90000   pts_time:75.5\n" + sceneKey + "=0.612\n" +
+		"frame:1    pts:150000  pts_time:125\n" + sceneKey + "=0.48\n"
+	cuts, err := parseScenes(strings.NewReader(out))
+	require.NoError(t, err)
+	require.Equal(t, []float64{75.5, 125}, cuts)
+}
+
+func TestSceneChapterStarts(t *testing.T) {
+	// cuts close to the previous chapter or to the end are merged
+	require.Equal(t, []float64{0, 75.5, 190}, sceneChapterStarts([]float64{10, 75.5, 100, 190, 260}, 300, 60))
+	// the length is unknown, every spaced cut counts
+	require.Equal(t, []float64{0, 75.5, 260}, sceneChapterStarts([]float64{75.5, 100, 260}, 0, 60))
+	require.Nil(t, sceneChapterStarts([]float64{20, 40}, 90, 60))
+	require.Nil(t, sceneChapterStarts(nil, 600, 60))
+}
+
+func TestSaveSceneChapters(t *testing.T) {
+	repo := mocks.NewMockVideoRepo(gomock.NewController(t))
+	fake := NewFakeTranscoder()
+	rc := &redisConsumer{
+		logger:     slog.New(slog.NewTextHandler(io.Discard, nil)),
+		db:         repo,
+		transcoder: fake,
+		processing: models.ProcessingConfig{SceneChapterMin: 30 * time.Second},
+	}
+	videoID := uuid.New()
+	probe := ProbeResult{Format: ProbeFormat{Duration: "120"}}
+	fake.StreamOutput = []byte("frame:0 pts:1 pts_time:45\n" + sceneKey + "=0.9\n")
+
+	gomock.InOrder(
+		repo.EXPECT().ListVideoChapters(gomock.Any(), videoID).Return(nil, nil),
+		repo.EXPECT().CreateVideoChapter(gomock.Any(), db.CreateVideoChapterParams{VideoID: videoID, Title: "Chapter 1", StartMs: 0, Source: ChapterSourceScene}),
+		repo.EXPECT().CreateVideoChapter(gomock.Any(), db.CreateVideoChapterParams{VideoID: videoID, Title: "Chapter 2", StartMs: 45000, Source: ChapterSourceScene}),
+	)
+	rc.saveSceneChapters(context.Background(), videoID, "source.mp4", probe)
+	require.Contains(t, fake.Calls()[0], "scale=320:-2,select=gt(scene\\,0.4),metadata=mode=print:key=lavfi.scene_score:file=-")
+
+	// chapters of the owner or the source are kept, and nothing is detected
+	repo.EXPECT().ListVideoChapters(gomock.Any(), videoID).Return([]db.VideoChapter{{Title: "Intro"}}, nil)
+	rc.saveSceneChapters(context.Background(), videoID, "source.mp4", probe)
+	require.Len(t, fake.Calls(), 1)
+}
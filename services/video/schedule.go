@@ -0,0 +1,242 @@
+package video
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+	"video-processing/database/db"
+	"video-processing/models"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/minio/minio-go/v7"
+)
+
+const (
+	// schedulePollInterval is how often the scheduler looks for videos to publish or expire
+	schedulePollInterval = 30 * time.Second
+	// scheduleBatch is how many videos one query publishes or expires
+	scheduleBatch = 100
+	// purgeRetryDelay is how long an expired video whose purge failed waits for another try
+	purgeRetryDelay = 15 * time.Minute
+)
+
+// SetSchedule sets when a video of the user is published and when it expires. Processing
+// may finish long before: the video stays private until the publish time.
+func (vp *videoProcessor) SetSchedule(ctx context.Context, userID, videoID uuid.UUID, req models.ScheduleRequest) (models.VideoDetail, error) {
+	params := fmt.Sprintf("userID: %v, videoID: %v", userID, videoID)
+	if err := req.Validate(); err != nil {
+		return models.VideoDetail{}, models.Error{
+			Code:    http.StatusBadRequest,
+			Message: "invalid input data",
+			Params:  params,
+			Err:     err,
+		}
+	}
+	video, err := vp.getOwnedVideo(ctx, userID, videoID)
+	if err != nil {
+		return models.VideoDetail{}, err
+	}
+	if video.Visibility == models.VisibilityHidden {
+		return models.VideoDetail{}, models.Error{
+			Code:        http.StatusConflict,
+			Message:     "video hidden by a moderator",
+			Description: "moderated videos cannot be scheduled",
+			Params:      params,
+			Err:         errors.New("video is hidden"),
+		}
+	}
+	arg := db.SetVideoScheduleParams{ID: videoID, PurgeOnExpiry: req.PurgeOnExpiry}
+	if req.PublishAt != nil {
+		arg.PublishAt = pgtype.Timestamptz{Time: *req.PublishAt, Valid: true}
+	}
+	if req.ExpiresAt != nil {
+		arg.ExpiresAt = pgtype.Timestamptz{Time: *req.ExpiresAt, Valid: true}
+	}
+	if _, err := vp.db.SetVideoSchedule(ctx, arg); err != nil {
+		return models.VideoDetail{}, models.IndentifyDbError(err).AddParams(params)
+	}
+	return vp.GetVideo(ctx, userID, videoID, nil)
+}
+
+// RunScheduler publishes and expires videos on time until ctx is done. Every instance may
+// run it: the queries skip the rows another instance is working on.
+func (vp *videoProcessor) RunScheduler(ctx context.Context) error {
+	ticker := time.NewTicker(schedulePollInterval)
+	defer ticker.Stop()
+	for {
+		vp.publishDue(ctx)
+		vp.expireDue(ctx)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// publishDue publishes the videos whose publish time has come, notifying subscribers of
+// first publications
+func (vp *videoProcessor) publishDue(ctx context.Context) {
+	for {
+		rows, err := vp.db.PublishDueVideos(ctx, scheduleBatch)
+		if err != nil {
+			if ctx.Err() == nil {
+				vp.logger.Error("failed to publish scheduled videos", "error", err)
+			}
+			return
+		}
+		for _, row := range rows {
+			vp.logger.Info("scheduled video published", "videoID", row.ID)
+			if row.FirstPublication {
+				vp.notifier.notifySubscribers(ctx, db.Video{ID: row.ID, UserID: row.UserID})
+			}
+		}
+		if len(rows) < scheduleBatch {
+			return
+		}
+	}
+}
+
+// expireDue makes expired videos private and purges the ones that asked for it. A purge
+// that fails is retried after purgeRetryDelay, so failing purges cannot keep the round
+// selecting the same rows. Should deferring them fail as well, the round ends once a batch
+// makes no progress.
+func (vp *videoProcessor) expireDue(ctx context.Context) {
+	for {
+		rows, err := vp.db.ExpireDueVideos(ctx, scheduleBatch)
+		if err != nil {
+			if ctx.Err() == nil {
+				vp.logger.Error("failed to expire videos", "error", err)
+			}
+			return
+		}
+		stuck := 0
+		for _, row := range rows {
+			if !row.PurgeOnExpiry {
+				vp.logger.Info("video expired", "videoID", row.ID)
+				continue
+			}
+			if err := vp.purgeVideo(ctx, row); err != nil {
+				vp.logger.Error("failed to purge expired video", "error", err, "videoID", row.ID)
+				if !vp.deferPurge(ctx, row.ID) {
+					stuck++
+				}
+				continue
+			}
+			vp.logger.Info("expired video purged", "videoID", row.ID)
+		}
+		if len(rows) < scheduleBatch || stuck == len(rows) {
+			return
+		}
+	}
+}
+
+// deferPurge leaves a video whose purge failed out of the expiry rounds for purgeRetryDelay.
+// It reports whether the video was deferred.
+func (vp *videoProcessor) deferPurge(ctx context.Context, videoID uuid.UUID) bool {
+	attempts, err := vp.db.DeferVideoPurge(ctx, db.DeferVideoPurgeParams{
+		VideoID: videoID,
+		RetryAt: pgtype.Timestamptz{Time: time.Now().Add(purgeRetryDelay), Valid: true},
+	})
+	if err != nil {
+		vp.logger.Error("failed to defer video purge", "error", err, "videoID", videoID)
+		return false
+	}
+	vp.logger.Info("video purge deferred", "videoID", videoID, "attempts", attempts, "retryIn", purgeRetryDelay)
+	return true
+}
+
+// purgeVideo deletes the source, the processing results, including those of rendition versions,
+// the extracted frames and the row of a video. The row goes last, so the objects of a failed purge are still known
+// on the next try.
+func (vp *videoProcessor) purgeVideo(ctx context.Context, video db.ExpireDueVideosRow) error {
+	variants, err := vp.db.ListVideoVariants(ctx, video.ID)
+	if err != nil {
+		return err
+	}
+	assets, err := vp.db.ListVideoAssets(ctx, video.ID)
+	if err != nil {
+		return err
+	}
+	versions, err := vp.db.ListRenditionVersions(ctx, video.ID)
+	if err != nil {
+		return err
+	}
+	for _, version := range versions {
+		versionVariants, versionAssets, err := versionRows(version)
+		if err != nil {
+			return err
+		}
+		variants = append(variants, versionVariants...)
+		assets = append(assets, versionAssets...)
+	}
+	// bucket -> results prefix of each processing run, e.g. processed/<uuid>/
+	prefixes := map[string]map[string]bool{}
+	addPrefix := func(bucket, key string) {
+		if prefix, ok := resultsPrefixOf(key); ok {
+			if prefixes[bucket] == nil {
+				prefixes[bucket] = map[string]bool{}
+			}
+			prefixes[bucket][prefix] = true
+		}
+	}
+	for _, v := range variants {
+		addPrefix(v.Bucket, v.Key)
+	}
+	for _, a := range assets {
+		addPrefix(a.Bucket, a.Key)
+	}
+	for bucket, bucketPrefixes := range prefixes {
+		for prefix := range bucketPrefixes {
+			if err := vp.removePrefix(ctx, bucket, prefix); err != nil {
+				return err
+			}
+		}
+	}
+	if err := vp.removePrefix(ctx, video.Bucket, framesPrefix(video.UserID.String(), video.Key, video.ID)); err != nil {
+		return err
+	}
+	if err := vp.minioClient.RemoveObject(ctx, video.Bucket, video.Key, minio.RemoveObjectOptions{}); err != nil {
+		return fmt.Errorf("failed to remove source %s/%s: %w", video.Bucket, video.Key, err)
+	}
+	_, err = vp.db.DeleteVideo(ctx, video.ID)
+	return err
+}
+
+// removePrefix removes every object under prefix of a bucket
+func (vp *videoProcessor) removePrefix(ctx context.Context, bucket, prefix string) error {
+	for object := range vp.minioClient.ListObjects(ctx, bucket, minio.ListObjectsOptions{Prefix: prefix, Recursive: true}) {
+		if object.Err != nil {
+			return fmt.Errorf("failed to list %s/%s: %w", bucket, prefix, object.Err)
+		}
+		if err := vp.minioClient.RemoveObject(ctx, bucket, object.Key, minio.RemoveObjectOptions{}); err != nil {
+			return fmt.Errorf("failed to remove %s/%s: %w", bucket, object.Key, err)
+		}
+	}
+	return nil
+}
+
+// resultsPrefixOf returns the prefix of the processing run that stored key, which is under the
+// "<user id>/" prefix of the owner in a shared bucket
+func resultsPrefixOf(key string) (string, bool) {
+	owner := ""
+	if first, rest, ok := strings.Cut(key, "/"); ok {
+		if _, err := uuid.Parse(first); err == nil {
+			owner, key = first+"/", rest
+		}
+	}
+	rest, ok := strings.CutPrefix(key, processedPrefix)
+	if !ok {
+		return "", false
+	}
+	run, _, ok := strings.Cut(rest, "/")
+	if !ok || run == "" || run == "." || run == ".." {
+		return "", false
+	}
+	return owner + path.Join(processedPrefix, run) + "/", true
+}
@@ -0,0 +1,177 @@
+package video
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"testing"
+	"time"
+	"video-processing/database/db"
+	"video-processing/mocks"
+	"video-processing/models"
+
+	"github.com/google/uuid"
+	"github.com/minio/minio-go/v7"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestScheduleRequestValidate(t *testing.T) {
+	now := time.Now()
+	later, muchLater := now.Add(time.Hour), now.Add(2*time.Hour)
+
+	require.NoError(t, models.ScheduleRequest{}.Validate()) // clears the schedule
+	require.NoError(t, models.ScheduleRequest{PublishAt: &later, ExpiresAt: &muchLater, PurgeOnExpiry: true}.Validate())
+	require.Error(t, models.ScheduleRequest{ExpiresAt: &now}.Validate())
+	require.Error(t, models.ScheduleRequest{PublishAt: &muchLater, ExpiresAt: &later}.Validate())
+	require.Error(t, models.ScheduleRequest{PublishAt: &later, PurgeOnExpiry: true}.Validate())
+}
+
+func TestSetSchedule(t *testing.T) {
+	vp, repo := newSubscriptionsProcessor(t)
+	owner, videoID := uuid.New(), uuid.New()
+	publishAt := time.Now().Add(time.Hour)
+	video := db.Video{ID: videoID, UserID: owner, Visibility: models.VisibilityPrivate}
+	var e models.Error
+
+	repo.EXPECT().GetVideo(gomock.Any(), videoID).Return(video, nil)
+	repo.EXPECT().
+		SetVideoSchedule(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, arg db.SetVideoScheduleParams) (db.Video, error) {
+			require.Equal(t, videoID, arg.ID)
+			require.True(t, arg.PublishAt.Time.Equal(publishAt))
+			require.False(t, arg.ExpiresAt.Valid)
+			return video, nil
+		})
+	expectVideoDetail(repo, video)
+	_, err := vp.SetSchedule(context.Background(), owner, videoID, models.ScheduleRequest{PublishAt: &publishAt})
+	require.NoError(t, err)
+
+	// moderated videos stay as the moderator left them
+	repo.EXPECT().GetVideo(gomock.Any(), videoID).Return(db.Video{ID: videoID, UserID: owner, Visibility: models.VisibilityHidden}, nil)
+	_, err = vp.SetSchedule(context.Background(), owner, videoID, models.ScheduleRequest{PublishAt: &publishAt})
+	require.ErrorAs(t, err, &e)
+	require.Equal(t, http.StatusConflict, e.Code)
+}
+
+func TestPublishDueNotifiesFirstPublications(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	repo := mocks.NewMockVideoRepo(ctrl)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	vp := &videoProcessor{logger: logger, db: repo, notifier: newNotifier(logger, repo, models.NotificationConfig{})}
+	creator, first, again := uuid.New(), uuid.New(), uuid.New()
+
+	repo.EXPECT().PublishDueVideos(gomock.Any(), int32(scheduleBatch)).Return([]db.PublishDueVideosRow{
+		{ID: first, UserID: creator, FirstPublication: true},
+		{ID: again, UserID: creator},
+	}, nil)
+	repo.EXPECT().CreateVideoNotifications(gomock.Any(), db.CreateVideoNotificationsParams{VideoID: first, CreatorID: creator}).Return(int64(2), nil)
+	repo.EXPECT().ListSubscriberPreferences(gomock.Any(), creator).Return(nil, nil)
+	vp.publishDue(context.Background())
+}
+
+func TestExpireDuePurgesVideos(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	repo := mocks.NewMockVideoRepo(ctrl)
+	store := mocks.NewMockObjectStore(ctrl)
+	vp := &videoProcessor{logger: slog.New(slog.NewTextHandler(io.Discard, nil)), db: repo, minioClient: store}
+	kept, purged, failed := uuid.New(), uuid.New(), uuid.New()
+	run := "processed/" + uuid.NewString() + "/"
+
+	repo.EXPECT().ExpireDueVideos(gomock.Any(), int32(scheduleBatch)).Return([]db.ExpireDueVideosRow{
+		{ID: kept},
+		{ID: purged, Bucket: "user", Key: "clip.mp4", PurgeOnExpiry: true},
+		{ID: failed, Bucket: "user", Key: "other.mp4", PurgeOnExpiry: true},
+	}, nil)
+
+	repo.EXPECT().ListVideoVariants(gomock.Any(), purged).Return([]db.VideoVariant{
+		{Bucket: "user", Key: run + "720p/720p.mp4"},
+		{Bucket: "user", Key: run + "480p/480p.mp4"},
+	}, nil)
+	repo.EXPECT().ListVideoAssets(gomock.Any(), purged).Return([]db.VideoAsset{{Bucket: "user", Key: run + "preview.jpg"}}, nil)
+	// the runs kept for rollbacks go as well
+	previous := "processed/" + uuid.NewString() + "/"
+	repo.EXPECT().ListRenditionVersions(gomock.Any(), purged).Return([]db.VideoRenditionVersion{
+		renditionVersion(t, purged, 1, []db.VideoVariant{{Bucket: "user", Key: previous + "720p/720p.mp4"}}, nil),
+	}, nil)
+	objects := make(chan minio.ObjectInfo, 2)
+	objects <- minio.ObjectInfo{Key: run + "720p/720p.mp4"}
+	objects <- minio.ObjectInfo{Key: run + "720p/segment0.ts"}
+	close(objects)
+	store.EXPECT().ListObjects(gomock.Any(), "user", minio.ListObjectsOptions{Prefix: run, Recursive: true}).Return(objects)
+	previousObjects := make(chan minio.ObjectInfo, 1)
+	previousObjects <- minio.ObjectInfo{Key: previous + "720p/720p.mp4"}
+	close(previousObjects)
+	store.EXPECT().ListObjects(gomock.Any(), "user", minio.ListObjectsOptions{Prefix: previous, Recursive: true}).Return(previousObjects)
+	store.EXPECT().RemoveObject(gomock.Any(), "user", previous+"720p/720p.mp4", gomock.Any()).Return(nil)
+	store.EXPECT().RemoveObject(gomock.Any(), "user", run+"720p/720p.mp4", gomock.Any()).Return(nil)
+	store.EXPECT().RemoveObject(gomock.Any(), "user", run+"720p/segment0.ts", gomock.Any()).Return(nil)
+	frames := make(chan minio.ObjectInfo, 1)
+	frames <- minio.ObjectInfo{Key: "frames/" + purged.String() + "/1500.jpg"}
+	close(frames)
+	store.EXPECT().ListObjects(gomock.Any(), "user", minio.ListObjectsOptions{Prefix: "frames/" + purged.String() + "/", Recursive: true}).Return(frames)
+	store.EXPECT().RemoveObject(gomock.Any(), "user", "frames/"+purged.String()+"/1500.jpg", gomock.Any()).Return(nil)
+	store.EXPECT().RemoveObject(gomock.Any(), "user", "clip.mp4", gomock.Any()).Return(nil)
+	repo.EXPECT().DeleteVideo(gomock.Any(), purged).Return(db.Video{}, nil)
+
+	// the row of a failed purge is kept for the next round
+	repo.EXPECT().ListVideoVariants(gomock.Any(), failed).Return(nil, nil)
+	repo.EXPECT().ListVideoAssets(gomock.Any(), failed).Return(nil, nil)
+	repo.EXPECT().ListRenditionVersions(gomock.Any(), failed).Return(nil, nil)
+	noFrames := make(chan minio.ObjectInfo)
+	close(noFrames)
+	store.EXPECT().ListObjects(gomock.Any(), "user", minio.ListObjectsOptions{Prefix: "frames/" + failed.String() + "/", Recursive: true}).Return(noFrames)
+	store.EXPECT().RemoveObject(gomock.Any(), "user", "other.mp4", gomock.Any()).Return(errors.New("unreachable"))
+	repo.EXPECT().DeferVideoPurge(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, arg db.DeferVideoPurgeParams) (int32, error) {
+			require.Equal(t, failed, arg.VideoID)
+			require.WithinDuration(t, time.Now().Add(purgeRetryDelay), arg.RetryAt.Time, time.Minute)
+			return 1, nil
+		})
+
+	vp.expireDue(context.Background())
+}
+
+func TestExpireDueStopsWithoutProgress(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	repo := mocks.NewMockVideoRepo(ctrl)
+	vp := &videoProcessor{logger: slog.New(slog.NewTextHandler(io.Discard, nil)), db: repo}
+	rows := make([]db.ExpireDueVideosRow, scheduleBatch)
+	for i := range rows {
+		rows[i] = db.ExpireDueVideosRow{ID: uuid.New(), PurgeOnExpiry: true}
+	}
+	dbDown := errors.New("connection refused")
+	repo.EXPECT().ListVideoVariants(gomock.Any(), gomock.Any()).Return(nil, dbDown).AnyTimes()
+
+	// deferred purges leave the next batch
+	gomock.InOrder(
+		repo.EXPECT().ExpireDueVideos(gomock.Any(), int32(scheduleBatch)).Return(rows, nil),
+		repo.EXPECT().ExpireDueVideos(gomock.Any(), int32(scheduleBatch)).Return(nil, nil),
+	)
+	repo.EXPECT().DeferVideoPurge(gomock.Any(), gomock.Any()).Return(int32(1), nil).Times(scheduleBatch)
+	vp.expireDue(context.Background())
+
+	// a full batch that can neither be purged nor deferred ends the round
+	repo.EXPECT().ExpireDueVideos(gomock.Any(), int32(scheduleBatch)).Return(rows, nil).Times(1)
+	repo.EXPECT().DeferVideoPurge(gomock.Any(), gomock.Any()).Return(int32(0), dbDown).Times(scheduleBatch)
+	vp.expireDue(context.Background())
+}
+
+func TestResultsPrefixOf(t *testing.T) {
+	prefix, ok := resultsPrefixOf("processed/abc/720p/720p.mp4")
+	require.True(t, ok)
+	require.Equal(t, "processed/abc/", prefix)
+
+	// a shared bucket keeps the runs under the owner's prefix
+	owner := uuid.NewString()
+	prefix, ok = resultsPrefixOf(owner + "/processed/abc/720p/720p.mp4")
+	require.True(t, ok)
+	require.Equal(t, owner+"/processed/abc/", prefix)
+
+	for _, key := range []string{"clip.mp4", "processed/abc", "processed//x.mp4", "processed/../x.mp4", "user/processed/abc/x.mp4"} {
+		_, ok := resultsPrefixOf(key)
+		require.False(t, ok, key)
+	}
+}
@@ -0,0 +1,79 @@
+package video
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// scratchSourceFactor estimates the scratch space of a job from its source size: the
+// downloaded source plus the MP4 renditions and their HLS copies.
+const scratchSourceFactor = 3
+
+// errScratchExhausted is returned when a job does not fit in the remaining scratch budget
+var errScratchExhausted = errors.New("scratch space exhausted")
+
+// scratchSpace hands out job working directories under one root and keeps the space the
+// running jobs may fill within maxBytes. Jobs reserve their estimated size up front, so
+// concurrent jobs can't together overrun a small tmpfs or NVMe scratch disk.
+type scratchSpace struct {
+	dir      string
+	maxBytes int64 // 0 disables the budget
+
+	mu       sync.Mutex
+	reserved int64
+	jobs     int
+}
+
+// newScratchSpace prepares the scratch root; an empty dir uses the system temp directory
+func newScratchSpace(dir string, maxBytes int64) (*scratchSpace, error) {
+	if dir == "" {
+		dir = os.TempDir()
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create scratch dir: %w", err)
+	}
+	return &scratchSpace{dir: dir, maxBytes: maxBytes}, nil
+}
+
+// Acquire reserves need bytes and creates a job directory named after pattern (see os.MkdirTemp).
+// A job larger than the whole budget is still let through when nothing else is running,
+// otherwise it could never be processed. release removes the directory and returns the
+// reservation; it is safe to call more than once.
+func (s *scratchSpace) Acquire(pattern string, need int64) (dir string, release func(), err error) {
+	s.mu.Lock()
+	if s.maxBytes > 0 && s.jobs > 0 && s.reserved+need > s.maxBytes {
+		s.mu.Unlock()
+		return "", nil, fmt.Errorf("%w: need %d bytes, %d of %d reserved", errScratchExhausted, need, s.reserved, s.maxBytes)
+	}
+	s.reserved += need
+	s.jobs++
+	s.mu.Unlock()
+
+	var once sync.Once
+	release = func() {
+		once.Do(func() {
+			if dir != "" {
+				os.RemoveAll(dir)
+			}
+			s.mu.Lock()
+			s.reserved -= need
+			s.jobs--
+			s.mu.Unlock()
+		})
+	}
+	dir, err = os.MkdirTemp(s.dir, pattern)
+	if err != nil {
+		release()
+		return "", nil, fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	return dir, release, nil
+}
+
+// Exhausted reports whether the running jobs have reserved the whole budget
+func (s *scratchSpace) Exhausted() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.maxBytes > 0 && s.reserved >= s.maxBytes
+}
@@ -0,0 +1,48 @@
+package video
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestScratchSpace(t *testing.T) {
+	s, err := newScratchSpace(t.TempDir(), 100)
+	require.NoError(t, err)
+
+	// a job larger than the budget runs when the scratch space is idle
+	big, releaseBig, err := s.Acquire("job-*", 150)
+	require.NoError(t, err)
+	require.DirExists(t, big)
+	require.True(t, s.Exhausted())
+	_, _, err = s.Acquire("job-*", 10)
+	require.ErrorIs(t, err, errScratchExhausted)
+	releaseBig()
+	releaseBig() // releasing twice must not free the budget twice
+	require.NoDirExists(t, big)
+	require.False(t, s.Exhausted())
+
+	_, releaseA, err := s.Acquire("job-*", 60)
+	require.NoError(t, err)
+	_, _, err = s.Acquire("job-*", 60)
+	require.ErrorIs(t, err, errScratchExhausted)
+	dirB, releaseB, err := s.Acquire("job-*", 40)
+	require.NoError(t, err)
+	require.True(t, s.Exhausted())
+	releaseA()
+	releaseB()
+	_, err = os.Stat(dirB)
+	require.True(t, os.IsNotExist(err))
+	require.Zero(t, s.reserved)
+}
+
+func TestScratchSpaceUnlimited(t *testing.T) {
+	s, err := newScratchSpace(t.TempDir(), 0)
+	require.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		_, _, err := s.Acquire("job-*", 1<<40)
+		require.NoError(t, err)
+	}
+	require.False(t, s.Exhausted())
+}
@@ -0,0 +1,94 @@
+package video
+
+import (
+	"context"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// segmentPollInterval is how often the HLS output directory is checked for finished segments
+const segmentPollInterval = 500 * time.Millisecond
+
+// segmentWatcher queues HLS segments for upload while ffmpeg is still packaging the variant.
+// ffmpeg writes segments one after another, so a segment is complete as soon as the next
+// one exists; the last one is complete when ffmpeg exits.
+type segmentWatcher struct {
+	dir        string
+	pattern    string // glob of the segment files inside dir
+	destPrefix string
+	bucket     string
+	uploadCh   chan<- UploadTask
+	queued     map[string]bool
+}
+
+func newSegmentWatcher(dir, pattern, destPrefix, bucket string, uploadCh chan<- UploadTask) *segmentWatcher {
+	return &segmentWatcher{
+		dir:        dir,
+		pattern:    pattern,
+		destPrefix: destPrefix,
+		bucket:     bucket,
+		uploadCh:   uploadCh,
+		queued:     make(map[string]bool),
+	}
+}
+
+// completed returns the finished segments that have not been queued yet, in order.
+// Unless final is set the newest segment is assumed to be still written.
+func (w *segmentWatcher) completed(final bool) []string {
+	segments, err := filepath.Glob(filepath.Join(w.dir, w.pattern))
+	if err != nil {
+		return nil
+	}
+	sort.Strings(segments)
+	if !final && len(segments) > 0 {
+		segments = segments[:len(segments)-1]
+	}
+	var fresh []string
+	for _, s := range segments {
+		if !w.queued[s] {
+			fresh = append(fresh, s)
+		}
+	}
+	return fresh
+}
+
+// queue hands the finished segments to the upload workers
+func (w *segmentWatcher) queue(ctx context.Context, final bool) {
+	for _, segment := range w.completed(final) {
+		select {
+		case <-ctx.Done():
+			return
+		case w.uploadCh <- UploadTask{
+			SourcePath:  segment,
+			ObjectKey:   filepath.ToSlash(filepath.Join(w.destPrefix, filepath.Base(segment))),
+			ContentType: mimeTypeByExt(filepath.Ext(segment)),
+			Bucket:      w.bucket,
+		}:
+			w.queued[segment] = true
+		}
+	}
+}
+
+// watch queues segments until done is closed, then queues the remaining ones.
+// It must not be used concurrently with isQueued.
+func (w *segmentWatcher) watch(ctx context.Context, done <-chan struct{}) {
+	ticker := time.NewTicker(segmentPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-done:
+			w.queue(ctx, true)
+			return
+		case <-ticker.C:
+			w.queue(ctx, false)
+		}
+	}
+}
+
+// isQueued reports whether the file has already been handed to the upload workers
+func (w *segmentWatcher) isQueued(path string) bool {
+	return w.queued[path]
+}
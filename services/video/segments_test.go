@@ -0,0 +1,45 @@
+package video
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSegmentWatcher(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte("ts"), 0o644))
+		return path
+	}
+	uploadCh := make(chan UploadTask, 10)
+	w := newSegmentWatcher(dir, "segment_*", "processed/abc/720p", "bucket", uploadCh)
+
+	first := write("segment_000.ts")
+	w.queue(context.Background(), false)
+	require.Empty(t, uploadCh, "the only segment may still be written")
+
+	second := write("segment_001.ts")
+	write("index.m3u8")
+	w.queue(context.Background(), false)
+	require.Len(t, uploadCh, 1)
+	require.Equal(t, UploadTask{
+		SourcePath:  first,
+		ObjectKey:   "processed/abc/720p/segment_000.ts",
+		ContentType: "video/mp2t",
+		Bucket:      "bucket",
+	}, <-uploadCh)
+
+	w.queue(context.Background(), true)
+	require.Len(t, uploadCh, 1)
+	require.Equal(t, second, (<-uploadCh).SourcePath)
+	require.True(t, w.isQueued(first))
+	require.True(t, w.isQueued(second))
+
+	w.queue(context.Background(), true)
+	require.Empty(t, uploadCh, "segments are queued once")
+}
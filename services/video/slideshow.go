@@ -0,0 +1,187 @@
+package video
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"video-processing/database/db"
+	"video-processing/models"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// defaultSlideSeconds is how long each image of a slideshow without audio is shown when
+	// the request sets no length
+	defaultSlideSeconds = 5.0
+	// slideshowFrameRate is the frame rate of rendered slideshows; the frames repeat the image,
+	// so a regular rate costs little with the stillimage tune and keeps players happy
+	slideshowFrameRate = 25
+)
+
+// slideDurations returns how long each of n slides is shown. Slides without a set length
+// share the length of the audio, or get defaultSlideSeconds without audio. The last slide
+// stays up until audio that outlasts the slides ends.
+func slideDurations(n int, slideSeconds, audioSeconds float64) []float64 {
+	if n == 0 {
+		return nil
+	}
+	d := slideSeconds
+	if d <= 0 {
+		d = defaultSlideSeconds
+		if audioSeconds > 0 {
+			d = audioSeconds / float64(n)
+		}
+	}
+	durations := make([]float64, n)
+	for i := range durations {
+		durations[i] = d
+	}
+	if rest := audioSeconds - d*float64(n-1); rest > d {
+		durations[n-1] = rest
+	}
+	return durations
+}
+
+// slideshowArgs builds the ffmpeg arguments that show images one after the other, each for its
+// duration, letterboxed into a 1080p frame, with the audio of audioPath if set.
+// Audio shorter than the slides leaves the rest of the video silent.
+func slideshowArgs(images []string, durations []float64, audioPath, outPath string) []string {
+	args := []string{"-y", "-nostdin"}
+	var graph []string
+	var pads string
+	for i, image := range images {
+		args = append(args,
+			"-loop", "1",
+			"-framerate", strconv.Itoa(slideshowFrameRate),
+			"-t", fmt.Sprintf("%.3f", durations[i]),
+			"-i", image,
+		)
+		graph = append(graph, fmt.Sprintf("[%[1]d:v]scale=%[2]d:%[3]d:force_original_aspect_ratio=decrease,pad=%[2]d:%[3]d:(ow-iw)/2:(oh-ih)/2,setsar=1,format=yuv420p[v%[1]d]",
+			i, audiogramWidth, audiogramHeight))
+		pads += fmt.Sprintf("[v%d]", i)
+	}
+	if audioPath != "" {
+		args = append(args, "-i", audioPath)
+	}
+	graph = append(graph, fmt.Sprintf("%sconcat=n=%d:v=1:a=0[v]", pads, len(images)))
+	args = append(args, "-filter_complex", strings.Join(graph, ";"), "-map", "[v]")
+	if audioPath != "" {
+		args = append(args, "-map", fmt.Sprintf("%d:a:0", len(images)))
+	}
+	args = append(args,
+		"-c:v", "libx264",
+		"-crf", "18",
+		"-preset", "fast",
+		"-tune", "stillimage",
+	)
+	if audioPath != "" {
+		args = append(args, "-c:a", "aac")
+	}
+	return append(args, "-movflags", "+faststart", outPath)
+}
+
+// RenderSlideshow renders the video of a slideshow job from its images and audio, then
+// processes it like an uploaded video. The first image is the source of the job.
+func (rc *redisConsumer) RenderSlideshow(ctx context.Context, values map[string]interface{}) error {
+	return rc.renderDerived(ctx, values, func(ctx context.Context, job derivedJob) error {
+		s := job.Recipe.Slideshow
+		if s == nil || len(s.ImageKeys) == 0 {
+			return fmt.Errorf("slideshow job carries no slideshow recipe")
+		}
+		images := []string{job.SourcePath}
+		for i, key := range s.ImageKeys[1:] {
+			imagePath := filepath.Join(job.WorkDir, fmt.Sprintf("image-%03d%s", i+1, filepath.Ext(key)))
+			if err := downloadFromMinio(ctx, rc.mc, job.Bucket, key, imagePath); err != nil {
+				return fmt.Errorf("failed to download slideshow image: %w", err)
+			}
+			images = append(images, imagePath)
+		}
+		var audioPath string
+		var audioSeconds float64
+		if s.AudioKey != "" {
+			audioPath = filepath.Join(job.WorkDir, "audio"+filepath.Ext(s.AudioKey))
+			if err := downloadFromMinio(ctx, rc.mc, job.Bucket, s.AudioKey, audioPath); err != nil {
+				return fmt.Errorf("failed to download slideshow audio: %w", err)
+			}
+			probe, err := probeSource(ctx, rc.transcoder, audioPath)
+			if err != nil {
+				return fmt.Errorf("failed to probe slideshow audio: %w", err)
+			}
+			audioSeconds = probe.Duration()
+		}
+		durations := slideDurations(len(images), s.SlideSeconds, audioSeconds)
+		if err := rc.transcoder.Run(ctx, slideshowArgs(images, durations, audioPath, job.OutPath)...); err != nil {
+			return fmt.Errorf("ffmpeg slideshow error: %w", err)
+		}
+		return nil
+	})
+}
+
+// CreateSlideshow stores a set of images and an optional audio file and queues the render of
+// a video showing the images in the order they were uploaded.
+func (vp *videoProcessor) CreateSlideshow(ctx context.Context, userID uuid.UUID, req models.SlideshowRequest) (models.VideoDetail, error) {
+	params := fmt.Sprintf("userID: %v, title: %v", userID, req.Title)
+	if err := req.Validate(); err != nil {
+		return models.VideoDetail{}, models.Error{
+			Code:    http.StatusBadRequest,
+			Message: "invalid input data",
+			Params:  params,
+			Err:     err,
+		}
+	}
+	for _, image := range req.Images {
+		if !isOverlayImage(image) {
+			return models.VideoDetail{}, models.Error{
+				Code:    http.StatusBadRequest,
+				Message: "invalid input data",
+				Params:  params,
+				Err:     errors.Join(fmt.Errorf("unsupported image type %q of %s", image.Header.Get("Content-Type"), image.Filename), models.ErrInvalidInputData),
+			}
+		}
+	}
+	if req.Audio != nil {
+		if contentType := req.Audio.Header.Get("Content-Type"); !strings.HasPrefix(contentType, "audio/") {
+			return models.VideoDetail{}, models.Error{
+				Code:    http.StatusBadRequest,
+				Message: "invalid input data",
+				Params:  params,
+				Err:     errors.Join(fmt.Errorf("unsupported audio type %q", contentType), models.ErrInvalidInputData),
+			}
+		}
+	}
+
+	bucket, prefix := vp.layout.Place(userID, fmt.Sprintf("slideshows/%s", uuid.New()))
+	if err := vp.ensureBucket(ctx, bucket); err != nil {
+		return models.VideoDetail{}, err
+	}
+	for i, image := range req.Images {
+		key := fmt.Sprintf("%s/image-%03d%s", prefix, i, filepath.Ext(image.Filename))
+		if err := vp.storeFormFile(ctx, bucket, key, image); err != nil {
+			return models.VideoDetail{}, err
+		}
+		req.ImageKeys = append(req.ImageKeys, key)
+	}
+	if req.Audio != nil {
+		req.AudioKey = prefix + "/audio" + filepath.Ext(req.Audio.Filename)
+		if err := vp.storeFormFile(ctx, bucket, req.AudioKey, req.Audio); err != nil {
+			return models.VideoDetail{}, err
+		}
+	}
+
+	return vp.createRenderedVideo(ctx, db.CreateDerivedVideoParams{
+		UserID:      userID,
+		Title:       req.Title,
+		Description: req.Description,
+		Bucket:      bucket,
+		Key:         prefix + "/video.mp4",
+		ContentType: "video/mp4",
+	}, req.ImageKeys[0], models.Recipe{
+		Type:      models.RecipeTypeSlideshow,
+		Slideshow: &req,
+	}, JobTypeSlideshow)
+}
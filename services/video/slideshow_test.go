@@ -0,0 +1,63 @@
+package video
+
+import (
+	"context"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"strings"
+	"testing"
+	"video-processing/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlideDurations(t *testing.T) {
+	require.Nil(t, slideDurations(0, 0, 60))
+	// without audio every slide gets the default length
+	require.Equal(t, []float64{5, 5}, slideDurations(2, 0, 0))
+	// slides share the length of the audio
+	require.Equal(t, []float64{20, 20, 20}, slideDurations(3, 0, 60))
+	// the last slide stays up until the audio ends
+	require.Equal(t, []float64{10, 10, 40}, slideDurations(3, 10, 60))
+	// audio shorter than the slides leaves them as they are
+	require.Equal(t, []float64{30, 30}, slideDurations(2, 30, 45))
+}
+
+func TestSlideshowArgs(t *testing.T) {
+	args := slideshowArgs([]string{"a.png", "b.jpg"}, []float64{2.5, 40}, "talk.mp3", "out.mp4")
+	require.Equal(t, []string{
+		"-loop", "1", "-framerate", "25", "-t", "2.500", "-i", "a.png",
+		"-loop", "1", "-framerate", "25", "-t", "40.000", "-i", "b.jpg",
+		"-i", "talk.mp3",
+	}, args[2:20])
+	frame := "scale=1920:1080:force_original_aspect_ratio=decrease,pad=1920:1080:(ow-iw)/2:(oh-ih)/2,setsar=1,format=yuv420p"
+	require.Equal(t, "[0:v]"+frame+"[v0];[1:v]"+frame+"[v1];[v0][v1]concat=n=2:v=1:a=0[v]", args[21])
+	require.Equal(t, []string{"-map", "[v]", "-map", "2:a:0"}, args[22:26])
+	require.Contains(t, strings.Join(args, " "), "-tune stillimage -c:a aac -movflags +faststart out.mp4")
+
+	// silent slideshows have no audio to map
+	args = slideshowArgs([]string{"a.png"}, []float64{5}, "", "out.mp4")
+	require.NotContains(t, args, "-c:a")
+	require.Equal(t, []string{"-map", "[v]", "-c:v"}, args[12:15])
+}
+
+func TestCreateSlideshowValidation(t *testing.T) {
+	file := func(name, contentType string) *multipart.FileHeader {
+		return &multipart.FileHeader{Filename: name, Header: textproto.MIMEHeader{"Content-Type": {contentType}}}
+	}
+	vp := &videoProcessor{}
+	var apiErr models.Error
+	for _, req := range []models.SlideshowRequest{
+		{Title: "Episode 12"},
+		{Title: "Episode 12", Images: []*multipart.FileHeader{file("cover.gif", "image/gif")}},
+		{Title: "Episode 12", Images: []*multipart.FileHeader{file("cover.png", "image/png")}, Audio: file("talk.txt", "text/plain")},
+		{Title: "Episode 12", Images: []*multipart.FileHeader{file("cover.png", "image/png")}, SlideSeconds: 0.1},
+	} {
+		_, err := vp.CreateSlideshow(context.Background(), uuid.New(), req)
+		require.True(t, errors.As(err, &apiErr))
+		require.Equal(t, http.StatusBadRequest, apiErr.Code)
+	}
+}
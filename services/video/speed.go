@@ -0,0 +1,94 @@
+package video
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"video-processing/models"
+
+	"github.com/google/uuid"
+)
+
+// Speeds the audio of a speed change is kept between. atempo keeps the pitch, but outside this
+// range speech is no longer intelligible, so timelapses and strong slow motion are silent.
+const (
+	minAudioSpeed = 0.25
+	maxAudioSpeed = 4.0
+)
+
+// speedArgs render the first video and audio stream of inputPath at speed times the original
+// speed into an H.264/AAC MP4. The frames are retimed and then resampled to the frame rate of
+// the source, so timelapses drop frames instead of playing hundreds per second and slow motion
+// repeats them.
+func speedArgs(probe ProbeResult, inputPath, outPath string, req models.SpeedRequest) []string {
+	vf := fmt.Sprintf("setpts=PTS/%s", formatFactor(req.Speed))
+	if stream, ok := probe.VideoStream(); ok && stream.RFrameRate != "" && stream.RFrameRate != "0/0" {
+		vf += ",fps=" + stream.RFrameRate
+	}
+	audio := !req.DropAudio && probe.HasAudio() && req.Speed >= minAudioSpeed && req.Speed <= maxAudioSpeed
+
+	args := []string{
+		"-y",
+		"-nostdin",
+		"-i", inputPath,
+		"-map", "0:V:0",
+	}
+	if audio {
+		args = append(args, "-map", "0:a:0")
+	}
+	args = append(args, "-vf", vf)
+	if audio {
+		args = append(args, "-af", strings.Join(atempoChain(req.Speed), ","))
+	}
+	// the result is a new source for the ladder, so keep it close to lossless
+	args = append(args,
+		"-c:v", "libx264",
+		"-crf", "18",
+		"-preset", "fast",
+	)
+	if audio {
+		args = append(args, "-c:a", "aac")
+	}
+	return append(args, "-movflags", "+faststart", outPath)
+}
+
+// RenderSpeed renders a speed changed copy of the parent's source, stores it as the derived
+// video's source and then runs it through the regular processing pipeline.
+func (rc *redisConsumer) RenderSpeed(ctx context.Context, values map[string]interface{}) error {
+	return rc.renderDerived(ctx, values, func(ctx context.Context, job derivedJob) error {
+		if job.Recipe.Speed == nil {
+			return fmt.Errorf("speed job carries no speed recipe")
+		}
+		probe, err := probeSource(ctx, rc.transcoder, job.SourcePath)
+		if err != nil {
+			return fmt.Errorf("failed to probe source: %w", err)
+		}
+		if err := rc.transcoder.Run(ctx, speedArgs(probe, job.SourcePath, job.OutPath, *job.Recipe.Speed)...); err != nil {
+			return fmt.Errorf("ffmpeg speed error: %w", err)
+		}
+		return nil
+	})
+}
+
+// ChangeSpeed creates a derived video playing the video at another speed and queues its
+// render. The derived video is processed like a regular upload once the render finishes.
+func (vp *videoProcessor) ChangeSpeed(ctx context.Context, userID, videoID uuid.UUID, req models.SpeedRequest) (models.VideoDetail, error) {
+	params := fmt.Sprintf("userID: %v, videoID: %v, req: %v", userID, videoID, req)
+	if err := req.Validate(); err != nil {
+		return models.VideoDetail{}, models.Error{
+			Code:    http.StatusBadRequest,
+			Message: "invalid input data",
+			Params:  params,
+			Err:     err,
+		}
+	}
+	parent, err := vp.getOwnedVideo(ctx, userID, videoID)
+	if err != nil {
+		return models.VideoDetail{}, err
+	}
+	return vp.createDerivedVideo(ctx, parent, req.Title, models.Recipe{
+		Type:  models.RecipeTypeSpeed,
+		Speed: &req,
+	}, JobTypeSpeed)
+}
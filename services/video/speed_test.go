@@ -0,0 +1,42 @@
+package video
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"video-processing/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSpeedArgs(t *testing.T) {
+	probe := ProbeResult{Streams: []ProbeStream{
+		{CodecType: "video", CodecName: "h264", Width: 1920, Height: 1080, RFrameRate: "30/1"},
+		{CodecType: "audio", CodecName: "aac"},
+	}}
+
+	// a timelapse drops frames and the audio
+	args := strings.Join(speedArgs(probe, "source.mp4", "out.mp4", models.SpeedRequest{Speed: 60}), " ")
+	require.Equal(t, "-y -nostdin -i source.mp4 -map 0:V:0 -vf setpts=PTS/60,fps=30/1 -c:v libx264 -crf 18 -preset fast -movflags +faststart out.mp4", args)
+
+	// moderate speeds keep the audio at its pitch
+	args = strings.Join(speedArgs(probe, "source.mp4", "out.mp4", models.SpeedRequest{Speed: 3}), " ")
+	require.Contains(t, args, "-map 0:a:0 -vf setpts=PTS/3,fps=30/1 -af atempo=2,atempo=1.5")
+	require.Contains(t, args, "-c:a aac")
+	args = strings.Join(speedArgs(probe, "source.mp4", "out.mp4", models.SpeedRequest{Speed: 0.5, DropAudio: true}), " ")
+	require.Contains(t, args, "-vf setpts=PTS/0.5,fps=30/1 -c:v")
+	require.NotContains(t, args, "0:a:0")
+}
+
+func TestChangeSpeedValidation(t *testing.T) {
+	vp := &videoProcessor{}
+	var apiErr models.Error
+	for _, speed := range []float64{0, 0.05, 1, 250} {
+		_, err := vp.ChangeSpeed(context.Background(), uuid.New(), uuid.New(), models.SpeedRequest{Speed: speed})
+		require.True(t, errors.As(err, &apiErr))
+		require.Equal(t, http.StatusBadRequest, apiErr.Code)
+	}
+}
@@ -0,0 +1,112 @@
+package video
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strconv"
+)
+
+const (
+	// defaultStabilizeShakiness is how shaky vidstabdetect takes the source to be, from 1 to 10,
+	// when the configuration sets none
+	defaultStabilizeShakiness = 5
+	// defaultStabilizeSmoothing is the number of frames before and after each frame that
+	// vidstabtransform averages the camera path over when the configuration sets none. Higher
+	// values keep the picture steadier but lag behind intended pans.
+	defaultStabilizeSmoothing = 10
+)
+
+// stabilizeRequested is true when the job asks for its source to be stabilized
+func stabilizeRequested(values map[string]interface{}) bool {
+	s, _ := values["stabilize"].(string)
+	on, _ := strconv.ParseBool(s)
+	return on
+}
+
+// deinterlacePrefix deinterlaces interlaced sources ahead of the stabilization filters, which
+// track motion between whole frames
+func deinterlacePrefix(probe ProbeResult) string {
+	if stream, ok := probe.VideoStream(); ok {
+		if interlaced, _ := stream.Interlaced(); interlaced {
+			return deinterlaceFilter + ","
+		}
+	}
+	return ""
+}
+
+// stabilizeDetectArgs run the first vidstab pass, which writes the camera motion of every frame
+// of the source to transformsPath
+func stabilizeDetectArgs(probe ProbeResult, sourcePath, transformsPath string, shakiness int) []string {
+	return []string{
+		"-y",
+		"-nostdin",
+		"-v", "error",
+		"-i", sourcePath,
+		"-map", "0:V:0",
+		"-an",
+		"-sn",
+		"-vf", fmt.Sprintf("%svidstabdetect=shakiness=%d:result=%s", deinterlacePrefix(probe), shakiness, transformsPath),
+		"-f", "null",
+		"-",
+	}
+}
+
+// stabilizeArgs run the second vidstab pass, which moves every frame against the smoothed
+// camera path read from transformsPath into a Matroska file that keeps every audio and
+// subtitle track. The zoom hides the borders the moves uncover, and the unsharp filter
+// restores the detail the interpolation softens. The stabilized frames are progressive.
+func stabilizeArgs(probe ProbeResult, sourcePath, transformsPath, outPath string, smoothing int, hdrFormat string) []string {
+	args := []string{
+		"-y",
+		"-nostdin",
+		"-i", sourcePath,
+		"-map", "0:V:0",
+		"-map", "0:a?",
+		"-map", "0:s?",
+		"-vf", fmt.Sprintf("%svidstabtransform=input=%s:smoothing=%d:optzoom=1,unsharp=5:5:0.8:3:3:0.4", deinterlacePrefix(probe), transformsPath, smoothing),
+	}
+	// the frames are progressive once stabilized, whatever the field order of the source
+	args = append(args, cutVideoArgs(ProbeResult{}, hdrFormat)...)
+	args = append(args, copyTracksArgs(probe)...)
+	return append(args, outPath)
+}
+
+// stabilizeSource steadies the picture of shaky handheld sources when the job asks for it, and
+// returns the path of the stabilized file, which the rest of the job reads in place of the
+// source. Otherwise the source is returned as it is.
+func (rc *redisConsumer) stabilizeSource(ctx context.Context, values map[string]interface{}, sourcePath, workDir string) (string, error) {
+	if !stabilizeRequested(values) {
+		return sourcePath, nil
+	}
+	if !rc.processing.Stabilization {
+		rc.logger.Warn("stabilization is turned off, processing the source as it is", "videoID", values["video_id"])
+		return sourcePath, nil
+	}
+	var hdrFormat string
+	probe, err := probeSource(ctx, rc.transcoder, sourcePath)
+	if err != nil {
+		rc.logger.Warn("source probe failed, stabilizing as SDR", "error", err, "videoID", values["video_id"])
+	} else if stream, ok := probe.VideoStream(); ok {
+		hdrFormat = stream.HDRFormat()
+	}
+	shakiness := rc.processing.StabilizeShakiness
+	if shakiness <= 0 {
+		shakiness = defaultStabilizeShakiness
+	}
+	smoothing := rc.processing.StabilizeSmoothing
+	if smoothing <= 0 {
+		smoothing = defaultStabilizeSmoothing
+	}
+
+	rc.logger.Info("stabilizing source", "videoID", values["video_id"], "shakiness", shakiness, "smoothing", smoothing)
+	transformsPath := filepath.Join(workDir, "transforms.trf")
+	if err := rc.transcoder.Run(ctx, stabilizeDetectArgs(probe, sourcePath, transformsPath, shakiness)...); err != nil {
+		return "", fmt.Errorf("ffmpeg vidstabdetect error: %w", err)
+	}
+	outPath := filepath.Join(workDir, "stabilized.mkv")
+	if err := rc.transcoder.Run(ctx, stabilizeArgs(probe, sourcePath, transformsPath, outPath, smoothing, hdrFormat)...); err != nil {
+		return "", fmt.Errorf("ffmpeg vidstabtransform error: %w", err)
+	}
+	return outPath, nil
+}
@@ -0,0 +1,71 @@
+package video
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"path"
+	"strings"
+	"testing"
+	"video-processing/models"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStabilizeArgs(t *testing.T) {
+	probe := ProbeResult{Streams: []ProbeStream{
+		{CodecType: "video", CodecName: "h264"},
+		{CodecType: "subtitle", CodecName: "mov_text"},
+	}}
+	args := strings.Join(stabilizeDetectArgs(probe, "source.mp4", "transforms.trf", 5), " ")
+	require.Equal(t, "-y -nostdin -v error -i source.mp4 -map 0:V:0 -an -sn "+
+		"-vf vidstabdetect=shakiness=5:result=transforms.trf -f null -", args)
+	args = strings.Join(stabilizeArgs(probe, "source.mp4", "transforms.trf", "stabilized.mkv", 10, ""), " ")
+	require.Equal(t, "-y -nostdin -i source.mp4 -map 0:V:0 -map 0:a? -map 0:s? "+
+		"-vf vidstabtransform=input=transforms.trf:smoothing=10:optzoom=1,unsharp=5:5:0.8:3:3:0.4 "+
+		"-c:v libx264 -crf 18 -preset fast -c:a copy -c:s copy -c:s:0 srt stabilized.mkv", args)
+
+	// interlaced sources are deinterlaced before the motion is tracked, and stay progressive
+	interlaced := ProbeResult{Streams: []ProbeStream{{CodecType: "video", CodecName: "mpeg2video", FieldOrder: "tt"}}}
+	require.Contains(t, stabilizeDetectArgs(interlaced, "source.ts", "transforms.trf", 5), "yadif,vidstabdetect=shakiness=5:result=transforms.trf")
+	args = strings.Join(stabilizeArgs(interlaced, "source.ts", "transforms.trf", "stabilized.mkv", 10, ""), " ")
+	require.Contains(t, args, "-vf yadif,vidstabtransform=")
+	require.NotContains(t, args, "+ildct")
+
+	// HDR sources keep their signal
+	args = strings.Join(stabilizeArgs(ProbeResult{}, "source.mkv", "transforms.trf", "stabilized.mkv", 10, HDRFormatHLG), " ")
+	require.Contains(t, args, "-c:v libx265 -crf 18 -preset fast -pix_fmt yuv420p10le")
+}
+
+func TestStabilizeSource(t *testing.T) {
+	fake := NewFakeTranscoder()
+	rc := &redisConsumer{
+		logger:     slog.New(slog.NewTextHandler(io.Discard, nil)),
+		transcoder: fake,
+		processing: models.ProcessingConfig{Stabilization: true, StabilizeSmoothing: 20},
+	}
+	workDir := t.TempDir()
+
+	sourcePath, err := rc.stabilizeSource(context.Background(), map[string]interface{}{}, "source.mp4", workDir)
+	require.NoError(t, err)
+	require.Equal(t, "source.mp4", sourcePath)
+	require.Empty(t, fake.Calls())
+
+	// the rest of the job reads the stabilized file
+	fake.ProbeOutput = []byte(`{"streams":[{"codec_type":"video","codec_name":"h264"}]}`)
+	sourcePath, err = rc.stabilizeSource(context.Background(), map[string]interface{}{"stabilize": "true"}, "source.mp4", workDir)
+	require.NoError(t, err)
+	require.Equal(t, path.Join(workDir, "stabilized.mkv"), sourcePath)
+	require.Len(t, fake.Calls(), 3) // the probe and both passes
+	require.Contains(t, strings.Join(fake.Calls()[2], " "), "smoothing=20")
+
+	fake.FailOn = "stabilized.mkv"
+	_, err = rc.stabilizeSource(context.Background(), map[string]interface{}{"stabilize": "true"}, "source.mp4", workDir)
+	require.Error(t, err)
+
+	// turned off, or by ffmpeg lacking vidstab, the job asking for it keeps its source
+	rc.processing.Stabilization = false
+	sourcePath, err = rc.stabilizeSource(context.Background(), map[string]interface{}{"stabilize": "true"}, "source.mp4", workDir)
+	require.NoError(t, err)
+	require.Equal(t, "source.mp4", sourcePath)
+}
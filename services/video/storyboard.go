@@ -0,0 +1,131 @@
+package video
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+const (
+	spriteFileName        = "sprite.jpg"
+	thumbnailsVTTFileName = "thumbnails.vtt"
+	// spriteInterval is the seconds between two frames of the sprite sheet. Long videos space
+	// them further so the sheet has at most spriteMaxFrames.
+	spriteInterval  = 10
+	spriteMaxFrames = 200
+	spriteColumns   = 10
+	spriteTileWidth = 160
+)
+
+// spriteLayout places the seek preview frames of a video on its sprite sheet, row by row
+type spriteLayout struct {
+	Interval   float64 // seconds between two frames
+	Frames     int
+	Columns    int
+	Rows       int
+	TileWidth  int
+	TileHeight int
+}
+
+// newSpriteLayout lays out the sprite sheet of a video of the given length and picture size.
+// The tiles keep the aspect ratio of the picture.
+func newSpriteLayout(durationSeconds float64, width, height int) spriteLayout {
+	l := spriteLayout{
+		Interval:   math.Max(spriteInterval, durationSeconds/spriteMaxFrames),
+		TileWidth:  spriteTileWidth,
+		TileHeight: spriteTileWidth * 9 / 16,
+	}
+	l.Frames = max(1, int(math.Ceil(durationSeconds/l.Interval)))
+	l.Columns = min(spriteColumns, l.Frames)
+	l.Rows = (l.Frames + l.Columns - 1) / l.Columns
+	if width > 0 && height > 0 {
+		l.TileHeight = max(2, int(math.Round(float64(spriteTileWidth*height)/float64(width)/2))*2)
+	}
+	return l
+}
+
+// spriteArgs tile one frame per interval of the input into a single JPEG. Only keyframes are
+// decoded, which is much faster and close enough for seek previews.
+func spriteArgs(inputPath, outPath string, l spriteLayout, hdrFormat string) []string {
+	scale := toneMapChain(fmt.Sprintf("scale=%d:%d", l.TileWidth, l.TileHeight), hdrFormat)
+	return []string{
+		"-y",
+		"-nostdin",
+		"-skip_frame", "nokey",
+		"-i", inputPath,
+		"-an",
+		"-sn",
+		"-vf", fmt.Sprintf("fps=1/%s,%s,tile=%dx%d", formatFactor(l.Interval), scale, l.Columns, l.Rows),
+		"-frames:v", "1",
+		"-q:v", "5",
+		outPath,
+	}
+}
+
+// thumbnailsVTT renders the WebVTT track that maps every interval of the video to its tile of
+// the sprite sheet, as a media fragment of spriteName relative to the track
+func thumbnailsVTT(l spriteLayout, durationSeconds float64, spriteName string) string {
+	var b strings.Builder
+	b.WriteString("WEBVTT\n")
+	for i := 0; i < l.Frames; i++ {
+		start := float64(i) * l.Interval
+		end := math.Min(start+l.Interval, durationSeconds)
+		x, y := i%l.Columns*l.TileWidth, i/l.Columns*l.TileHeight
+		fmt.Fprintf(&b, "\n%s --> %s\n%s#xywh=%d,%d,%d,%d\n",
+			formatVTTTimestamp(secondsToDuration(start)), formatVTTTimestamp(secondsToDuration(end)),
+			spriteName, x, y, l.TileWidth, l.TileHeight)
+	}
+	return b.String()
+}
+
+// processStoryboard generates the sprite sheet and its thumbnails.vtt for seek previews and
+// queues both for upload next to the HLS output. Failures are logged only; players then seek
+// without previews.
+func (rc *redisConsumer) processStoryboard(ctx context.Context, task ProcessingTask, probe ProbeResult, uploadCh chan<- UploadTask) {
+	duration := probe.Duration()
+	stream, ok := probe.VideoStream()
+	if !ok || duration <= 0 {
+		return
+	}
+	width, height := stream.DisplaySize()
+	l := newSpriteLayout(duration, width, height)
+
+	spritePath := filepath.Join(task.WorkDir, spriteFileName)
+	if err := rc.transcoder.Run(ctx, spriteArgs(task.SourcePath, spritePath, l, task.HDRFormat)...); err != nil {
+		rc.logger.Warn("sprite sheet generation failed", "error", err, "videoID", task.VideoID)
+		return
+	}
+	vttPath := filepath.Join(task.WorkDir, thumbnailsVTTFileName)
+	if err := os.WriteFile(vttPath, []byte(thumbnailsVTT(l, duration, spriteFileName)), 0o644); err != nil {
+		rc.logger.Warn("thumbnails.vtt write failed", "error", err, "videoID", task.VideoID)
+		return
+	}
+
+	videoUUID, err := uuid.Parse(task.VideoID)
+	if err != nil {
+		rc.logger.Error("invalid video ID for storyboard", "error", err, "videoID", task.VideoID)
+		return
+	}
+	for _, f := range []struct{ path, kind string }{
+		{spritePath, AssetKindSprite},
+		{vttPath, AssetKindThumbnailsVTT},
+	} {
+		upload := UploadTask{
+			SourcePath:  f.path,
+			ObjectKey:   filepath.ToSlash(filepath.Join(task.DestPrefix, filepath.Base(f.path))),
+			ContentType: mimeTypeByExt(filepath.Ext(f.path)),
+			Bucket:      task.Bucket,
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case uploadCh <- upload:
+		}
+		rc.saveVideoAsset(ctx, videoUUID, f.kind, upload)
+	}
+}
@@ -0,0 +1,43 @@
+package video
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewSpriteLayout(t *testing.T) {
+	require.Equal(t, spriteLayout{Interval: 10, Frames: 13, Columns: 10, Rows: 2, TileWidth: 160, TileHeight: 90},
+		newSpriteLayout(125, 1920, 1080))
+
+	// short portrait videos get a single row of tall tiles
+	require.Equal(t, spriteLayout{Interval: 10, Frames: 3, Columns: 3, Rows: 1, TileWidth: 160, TileHeight: 284},
+		newSpriteLayout(24, 1080, 1920))
+
+	// long videos space their frames out
+	l := newSpriteLayout(4*3600, 1280, 720)
+	require.Equal(t, 72.0, l.Interval)
+	require.Equal(t, spriteMaxFrames, l.Frames)
+	require.Equal(t, 20, l.Rows)
+}
+
+func TestSpriteArgs(t *testing.T) {
+	l := newSpriteLayout(125, 1920, 1080)
+	require.Equal(t, "-y -nostdin -skip_frame nokey -i source.mp4 -an -sn -vf fps=1/10,scale=160:90,tile=10x2 -frames:v 1 -q:v 5 sprite.jpg",
+		strings.Join(spriteArgs("source.mp4", "sprite.jpg", l, ""), " "))
+
+	require.Contains(t, strings.Join(spriteArgs("source.mkv", "sprite.jpg", l, HDRFormatHDR10), " "),
+		"fps=1/10,"+toneMapFilter+",scale=160:90,tile=10x2")
+}
+
+func TestThumbnailsVTT(t *testing.T) {
+	l := newSpriteLayout(25, 1920, 1080)
+	l.Columns = 2
+	l.Rows = 2
+	require.Equal(t, "WEBVTT\n"+
+		"\n00:00:00.000 --> 00:00:10.000\nsprite.jpg#xywh=0,0,160,90\n"+
+		"\n00:00:10.000 --> 00:00:20.000\nsprite.jpg#xywh=160,0,160,90\n"+
+		"\n00:00:20.000 --> 00:00:25.000\nsprite.jpg#xywh=0,90,160,90\n",
+		thumbnailsVTT(l, 25, "sprite.jpg"))
+}
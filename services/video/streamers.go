@@ -2,28 +2,29 @@ package video
 
 import (
 	"context"
+	"crypto/cipher"
 	"fmt"
 	"log/slog"
 	"net/http"
+	"os"
 	"time"
-	"video-processing/database/db"
 	"video-processing/models"
 
-	"github.com/minio/minio-go/v7"
 	"github.com/redis/go-redis/v9"
 )
 
 type Streamer interface {
 	Stream(ctx context.Context, values map[string]interface{}) error
+	Prioritize(ctx context.Context, videoID string) (bool, error)
 }
 
 type redisStreamer struct {
 	streamName string
 	logger     *slog.Logger
-	rc         *redis.Client
+	rc         Broker
 }
 
-func NewRedisStreamer(streamName string, logger *slog.Logger, rc *redis.Client) Streamer {
+func NewRedisStreamer(streamName string, logger *slog.Logger, rc Broker) Streamer {
 	return &redisStreamer{
 		streamName: streamName,
 		logger:     logger,
@@ -62,13 +63,23 @@ type redisConsumer struct {
 	groupName    string
 	consumerName string
 	logger       *slog.Logger
-	rc           *redis.Client
-	mc           *minio.Client
-	db           *db.Queries
+	rc           Broker
+	mc           ObjectStore
+	db           VideoRepo
+	processing   models.ProcessingConfig
+	sources      *sourceCache // nil when source caching is disabled
+	scratch      *scratchSpace
+	transcoder   Transcoder
+	hooks        *hookRunner
+	limits       *userLimiter // nil when jobs per user are not limited
+	notifier     *notifier
+	drm          *drmStage // nil when no DRM key provider is configured
+	// keyWrapper encrypts the stored HLS keys, nil when no key encryption key is configured
+	keyWrapper cipher.AEAD
 }
 
-func NewRedisConsumer(streamName, groupName, consumerName string, logger *slog.Logger, rc *redis.Client, mc *minio.Client, db *db.Queries) Consumer {
-	return &redisConsumer{
+func NewRedisConsumer(streamName, groupName, consumerName string, logger *slog.Logger, rc Broker, mc ObjectStore, db VideoRepo, processing models.ProcessingConfig, transcoder Transcoder, notifications models.NotificationConfig) Consumer {
+	consumer := &redisConsumer{
 		streamName:   streamName,
 		groupName:    groupName,
 		consumerName: consumerName,
@@ -76,42 +87,90 @@ func NewRedisConsumer(streamName, groupName, consumerName string, logger *slog.L
 		rc:           rc,
 		mc:           mc,
 		db:           db,
+		processing:   processing,
+		transcoder:   transcoder,
+		hooks:        newHookRunner(logger, processing.Hooks),
+		limits:       newUserLimiter(rc, streamName, processing.MaxJobsPerUser, processing.UserLimitDelay),
+		notifier:     newNotifier(logger, db, notifications),
 	}
+	scratch, err := newScratchSpace(processing.ScratchDir, processing.ScratchSizeMB<<20)
+	if err != nil {
+		logger.Error("scratch dir unusable, falling back to the system temp dir", "error", err, "dir", processing.ScratchDir)
+		scratch = &scratchSpace{dir: os.TempDir(), maxBytes: processing.ScratchSizeMB << 20}
+	}
+	consumer.scratch = scratch
+	drm, err := newDRMStage(processing.DRM, NewExecPackager(processing.DRM.Packager))
+	if err != nil {
+		logger.Error("DRM packaging disabled", "error", err)
+	}
+	consumer.drm = drm
+	keyWrapper, err := newKeyWrapper(processing.HLSEncryption.KeyEncryptionKey)
+	if err != nil {
+		logger.Error("HLS keys cannot be stored", "error", err)
+	}
+	consumer.keyWrapper = keyWrapper
+	if processing.SourceCacheDir != "" {
+		cache, err := newSourceCache(processing.SourceCacheDir, processing.SourceCacheSizeMB<<20)
+		if err != nil {
+			logger.Error("source cache disabled", "error", err, "dir", processing.SourceCacheDir)
+		} else {
+			consumer.sources = cache
+		}
+	}
+	return consumer
 }
 func (rc *redisConsumer) Consume(ctx context.Context) error {
 	// 1. Create Consumer Group
 	// 'MKSTREAM' ensures the stream exists if it's currently empty.
 	// '$' means "start consuming from the moment this group is created" (ignore old data).
 	// Use '0' if you want to process all historical data.
-	err := rc.rc.XGroupCreateMkStream(ctx, rc.streamName, rc.groupName, "$").Err()
-	if err != nil {
-		// Ignore error if group already exists
-		if err.Error() != "BUSYGROUP Consumer Group name already exists" {
-			return models.Error{
-				Code:    http.StatusInternalServerError,
-				Message: "internal server error",
-				Params:  fmt.Sprintf("streamName:%v, groupName:%v, consumerName:%v", rc.streamName, rc.groupName, rc.consumerName),
-				Err:     fmt.Errorf("failed to create group: %w", err),
+	// Boosted jobs may be moved to the priority stream before any worker ran, so its group
+	// starts at the beginning.
+	for stream, start := range map[string]string{rc.streamName: "$", priorityStream(rc.streamName): "0"} {
+		err := rc.rc.XGroupCreateMkStream(ctx, stream, rc.groupName, start).Err()
+		if err != nil {
+			// Ignore error if group already exists
+			if err.Error() != "BUSYGROUP Consumer Group name already exists" {
+				return models.Error{
+					Code:    http.StatusInternalServerError,
+					Message: "internal server error",
+					Params:  fmt.Sprintf("streamName:%v, groupName:%v, consumerName:%v", stream, rc.groupName, rc.consumerName),
+					Err:     fmt.Errorf("failed to create group: %w", err),
+				}
 			}
 		}
 	}
 
 	// 2. Processing Loop
 	for {
-		// XReadGroup reads data from the stream
-		entries, err := rc.rc.XReadGroup(ctx, &redis.XReadGroupArgs{
-			Group:    rc.groupName,
-			Consumer: rc.consumerName,
-			Streams:  []string{rc.streamName, ">"}, // ">" means "give me new messages not yet delivered to anyone"
-			Count:    10,                           // Batch size
-			Block:    2 * time.Second,              // Long polling: block for 2s if no data
-		}).Result()
+		// Leave new jobs to other workers while the running ones fill the scratch budget
+		if rc.scratch.Exhausted() {
+			rc.logger.Warn("scratch space exhausted, not taking new jobs", "dir", rc.scratch.dir)
+			time.Sleep(2 * time.Second)
+			continue
+		}
+
+		// Requeue the jobs parked by the user limit that are due
+		if rc.limits != nil {
+			if n, err := rc.limits.promote(ctx); err != nil {
+				rc.logger.Error("Failed to requeue parked jobs", "error", err)
+			} else if n > 0 {
+				rc.logger.Info("Requeued parked jobs", "count", n)
+			}
+		}
+
+		// XReadGroup reads data from the priority stream, then the stream
+		entries, err := rc.readJobs(ctx)
 
 		if err != nil {
 			if err == redis.Nil {
 				// Timeout (Block time expired), just loop again
 				continue
 			}
+			if ctx.Err() != nil {
+				// The worker is shutting down
+				return ctx.Err()
+			}
 			rc.logger.Error("Error reading stream", "error", err, "params", fmt.Sprintf("streamName:%v, groupName:%v, consumerName:%v", rc.streamName, rc.groupName, rc.consumerName))
 			continue
 		}
@@ -119,14 +178,14 @@ func (rc *redisConsumer) Consume(ctx context.Context) error {
 		// Process the batch of entries
 		for _, stream := range entries {
 			for _, message := range stream.Messages {
-				rc.ProcessVideo(context.Background(), message.Values)
+				rc.runJob(context.Background(), message)
 
 				// 3. Acknowledge the message
 				// This removes it from the "Pending Entries List" (PEL)
 				// ensuring it won't be redelivered.
-				err := rc.rc.XAck(ctx, rc.streamName, rc.groupName, message.ID).Err()
+				err := rc.rc.XAck(ctx, stream.Stream, rc.groupName, message.ID).Err()
 				if err != nil {
-					rc.logger.Error("Failed to ack message", "error", err, "params", fmt.Sprintf("streamName:%v, groupName:%v, messageID:%v", rc.streamName, rc.groupName, message.ID))
+					rc.logger.Error("Failed to ack message", "error", err, "params", fmt.Sprintf("streamName:%v, groupName:%v, messageID:%v", stream.Stream, rc.groupName, message.ID))
 				}
 			}
 		}
@@ -0,0 +1,214 @@
+package video
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+	"video-processing/database/db"
+	"video-processing/models"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// SetVisibility publishes or unpublishes a video of the user. The first publication
+// notifies the subscribers of the user.
+func (vp *videoProcessor) SetVisibility(ctx context.Context, userID, videoID uuid.UUID, req models.SetVisibilityRequest) (models.VideoDetail, error) {
+	params := fmt.Sprintf("userID: %v, videoID: %v", userID, videoID)
+	if err := req.Validate(); err != nil {
+		return models.VideoDetail{}, models.Error{
+			Code:    http.StatusBadRequest,
+			Message: "invalid input data",
+			Params:  params,
+			Err:     err,
+		}
+	}
+	video, err := vp.getOwnedVideo(ctx, userID, videoID)
+	if err != nil {
+		return models.VideoDetail{}, err
+	}
+	if video.Visibility == models.VisibilityHidden {
+		return models.VideoDetail{}, models.Error{
+			Code:        http.StatusConflict,
+			Message:     "video hidden by a moderator",
+			Description: "the visibility of moderated videos cannot be changed",
+			Params:      params,
+			Err:         errors.New("video is hidden"),
+		}
+	}
+	if _, err := vp.db.SetVideoVisibility(ctx, db.SetVideoVisibilityParams{Visibility: req.Visibility, ID: videoID}); err != nil {
+		return models.VideoDetail{}, models.IndentifyDbError(err).AddParams(params)
+	}
+	if req.Visibility == models.VisibilityPublic && !video.PublishedAt.Valid {
+		vp.notifier.notifySubscribers(ctx, video)
+	}
+	return vp.GetVideo(ctx, userID, videoID, nil)
+}
+
+func (vp *videoProcessor) Subscribe(ctx context.Context, userID, creatorID uuid.UUID) error {
+	params := fmt.Sprintf("userID: %v, creatorID: %v", userID, creatorID)
+	if userID == creatorID {
+		return models.Error{
+			Code:    http.StatusBadRequest,
+			Message: "cannot subscribe to yourself",
+			Params:  params,
+			Err:     errors.Join(errors.New("subscriber is the creator"), models.ErrInvalidInputData),
+		}
+	}
+	subscribed, err := vp.db.Subscribe(ctx, db.SubscribeParams{SubscriberID: userID, CreatorID: creatorID})
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23503" { // the creator does not exist
+		return models.Error{
+			Code:    http.StatusNotFound,
+			Message: "resource not found",
+			Params:  params,
+			Err:     models.ErrResourceNotFound,
+		}
+	}
+	if err != nil {
+		return models.IndentifyDbError(err).AddParams(params)
+	}
+	if subscribed > 0 { // subscribing twice does not notify twice
+		vp.notifier.notify(ctx, models.NotificationEvent{
+			Kind:      models.NotificationNewSubscriber,
+			UserID:    creatorID,
+			Message:   "You have a new subscriber.",
+			CreatedAt: time.Now(),
+		})
+	}
+	return nil
+}
+
+func (vp *videoProcessor) Unsubscribe(ctx context.Context, userID, creatorID uuid.UUID) error {
+	if err := vp.db.Unsubscribe(ctx, db.UnsubscribeParams{SubscriberID: userID, CreatorID: creatorID}); err != nil {
+		return models.IndentifyDbError(err).AddParams(fmt.Sprintf("userID: %v, creatorID: %v", userID, creatorID))
+	}
+	return nil
+}
+
+func (vp *videoProcessor) ListSubscriptions(ctx context.Context, userID uuid.UUID) ([]models.Subscription, error) {
+	rows, err := vp.db.ListSubscriptions(ctx, userID)
+	if err != nil {
+		return nil, models.IndentifyDbError(err).AddParams(fmt.Sprintf("userID: %v", userID))
+	}
+	subscriptions := make([]models.Subscription, 0, len(rows))
+	for _, row := range rows {
+		subscriptions = append(subscriptions, models.Subscription{
+			CreatorID:    row.CreatorID,
+			Username:     row.Username,
+			SubscribedAt: row.CreatedAt,
+		})
+	}
+	return subscriptions, nil
+}
+
+// Feed lists the public videos of the creators the user subscribed to, newest first
+func (vp *videoProcessor) Feed(ctx context.Context, userID uuid.UUID, query models.ListVideosQuery) ([]models.FeedItem, error) {
+	params := fmt.Sprintf("userID: %v, query: %v", userID, query)
+	if err := query.Validate(); err != nil {
+		return nil, models.Error{
+			Code:    http.StatusBadRequest,
+			Message: "invalid input data",
+			Params:  params,
+			Err:     err,
+		}
+	}
+	rows, err := vp.db.ListFeed(ctx, db.ListFeedParams{
+		SubscriberID: userID,
+		Limit:        int32(query.Limit),
+		Offset:       int32(query.Offset),
+	})
+	if err != nil {
+		return nil, models.IndentifyDbError(err).AddParams(params)
+	}
+	videoIDs := make([]uuid.UUID, 0, len(rows))
+	for _, row := range rows {
+		videoIDs = append(videoIDs, row.ID)
+	}
+	translations, err := vp.translationsFor(ctx, query.Languages, videoIDs)
+	if err != nil {
+		return nil, err
+	}
+	feed := make([]models.FeedItem, 0, len(rows))
+	for _, row := range rows {
+		item := models.FeedItem{
+			ID:          row.ID,
+			CreatorID:   row.UserID,
+			Username:    row.Username,
+			Title:       row.Title,
+			Description: row.Description,
+			PublishedAt: row.PublishedAt.Time,
+		}
+		if t, ok := translations[row.ID]; ok {
+			item.Title, item.Description, item.Language = t.Title, t.Description, t.Language
+		}
+		if row.PreviewKey.Valid {
+			item.PreviewURL, err = vp.getVideoURL(ctx, row.PreviewBucket.String, row.PreviewKey.String, vp.urlExpiry)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if row.AnimatedPreviewKey.Valid {
+			item.AnimatedPreviewURL, err = vp.getVideoURL(ctx, row.AnimatedPreviewBucket.String, row.AnimatedPreviewKey.String, vp.urlExpiry)
+			if err != nil {
+				return nil, err
+			}
+		}
+		feed = append(feed, item)
+	}
+	return feed, nil
+}
+
+func (vp *videoProcessor) ListNotifications(ctx context.Context, userID uuid.UUID, query models.ListVideosQuery) ([]models.Notification, error) {
+	params := fmt.Sprintf("userID: %v, query: %v", userID, query)
+	if err := query.Validate(); err != nil {
+		return nil, models.Error{
+			Code:    http.StatusBadRequest,
+			Message: "invalid input data",
+			Params:  params,
+			Err:     err,
+		}
+	}
+	rows, err := vp.db.ListNotifications(ctx, db.ListNotificationsParams{
+		UserID: userID,
+		Limit:  int32(query.Limit),
+		Offset: int32(query.Offset),
+	})
+	if err != nil {
+		return nil, models.IndentifyDbError(err).AddParams(params)
+	}
+	notifications := make([]models.Notification, 0, len(rows))
+	for _, row := range rows {
+		notifications = append(notifications, notificationFromRow(row))
+	}
+	return notifications, nil
+}
+
+func notificationFromRow(row db.ListNotificationsRow) models.Notification {
+	notification := models.Notification{
+		ID:        row.ID,
+		Kind:      row.Kind,
+		Title:     row.Title.String,
+		Message:   row.Message,
+		Read:      row.ReadAt.Valid,
+		CreatedAt: row.CreatedAt,
+	}
+	if row.VideoID.Valid {
+		videoID := uuid.UUID(row.VideoID.Bytes)
+		notification.VideoID = &videoID
+	}
+	if row.CreatorID.Valid {
+		creatorID := uuid.UUID(row.CreatorID.Bytes)
+		notification.CreatorID = &creatorID
+	}
+	return notification
+}
+
+func (vp *videoProcessor) MarkNotificationsRead(ctx context.Context, userID uuid.UUID) error {
+	if err := vp.db.MarkNotificationsRead(ctx, userID); err != nil {
+		return models.IndentifyDbError(err).AddParams(fmt.Sprintf("userID: %v", userID))
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package video
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"testing"
+	"time"
+	"video-processing/database/db"
+	"video-processing/mocks"
+	"video-processing/models"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func newSubscriptionsProcessor(t *testing.T) (VideoProcessor, *mocks.MockVideoRepo) {
+	ctrl := gomock.NewController(t)
+	repo := mocks.NewMockVideoRepo(ctrl)
+	vp := NewVideoProcessor(slog.New(slog.NewTextHandler(io.Discard, nil)), mocks.NewMockObjectStore(ctrl), repo, mocks.NewMockStreamer(ctrl), NewFakeTranscoder(), VideoProcessorOptions{URLExpiry: time.Hour})
+	return vp, repo
+}
+
+// expectVideoDetail expects the queries of GetVideo for a video without variants, assets, thumbnails,
+// progress or chapters
+func expectVideoDetail(repo *mocks.MockVideoRepo, video db.Video) {
+	repo.EXPECT().GetVideo(gomock.Any(), video.ID).Return(video, nil).Times(2)
+	repo.EXPECT().ListVideoVariants(gomock.Any(), video.ID).Return(nil, nil)
+	repo.EXPECT().ListVideoAssets(gomock.Any(), video.ID).Return(nil, nil)
+	repo.EXPECT().ListVideoThumbnails(gomock.Any(), video.ID).Return(nil, nil)
+	repo.EXPECT().ListVideoProgress(gomock.Any(), video.ID).Return(nil, nil)
+	repo.EXPECT().ListVideoChapters(gomock.Any(), video.ID).Return(nil, nil)
+}
+
+func TestSetVisibilityNotifiesOnFirstPublication(t *testing.T) {
+	vp, repo := newSubscriptionsProcessor(t)
+	userID, videoID := uuid.New(), uuid.New()
+	private := db.Video{ID: videoID, UserID: userID, Visibility: models.VisibilityPrivate}
+	public := db.Video{ID: videoID, UserID: userID, Visibility: models.VisibilityPublic, PublishedAt: pgtype.Timestamptz{Time: time.Now(), Valid: true}}
+	req := models.SetVisibilityRequest{Visibility: models.VisibilityPublic}
+
+	repo.EXPECT().GetVideo(gomock.Any(), videoID).Return(private, nil)
+	repo.EXPECT().SetVideoVisibility(gomock.Any(), db.SetVideoVisibilityParams{Visibility: models.VisibilityPublic, ID: videoID}).Return(public, nil)
+	repo.EXPECT().CreateVideoNotifications(gomock.Any(), db.CreateVideoNotificationsParams{VideoID: videoID, CreatorID: userID}).Return(int64(3), nil)
+	repo.EXPECT().ListSubscriberPreferences(gomock.Any(), userID).Return(nil, nil)
+	expectVideoDetail(repo, public)
+	detail, err := vp.SetVisibility(context.Background(), userID, videoID, req)
+	require.NoError(t, err)
+	require.Equal(t, models.VisibilityPublic, detail.Visibility)
+	require.NotNil(t, detail.PublishedAt)
+
+	// videos published before were notified already
+	unpublished := public
+	unpublished.Visibility = models.VisibilityPrivate
+	repo.EXPECT().GetVideo(gomock.Any(), videoID).Return(unpublished, nil)
+	repo.EXPECT().SetVideoVisibility(gomock.Any(), gomock.Any()).Return(public, nil)
+	expectVideoDetail(repo, public)
+	_, err = vp.SetVisibility(context.Background(), userID, videoID, req)
+	require.NoError(t, err)
+
+	_, err = vp.SetVisibility(context.Background(), userID, videoID, models.SetVisibilityRequest{Visibility: "unlisted"})
+	var e models.Error
+	require.ErrorAs(t, err, &e)
+	require.Equal(t, http.StatusBadRequest, e.Code)
+}
+
+func TestGetVideoOfOtherUsers(t *testing.T) {
+	vp, repo := newSubscriptionsProcessor(t)
+	viewer, videoID := uuid.New(), uuid.New()
+
+	public := db.Video{ID: videoID, UserID: uuid.New(), Visibility: models.VisibilityPublic}
+	expectVideoDetail(repo, public)
+	detail, err := vp.GetVideo(context.Background(), viewer, videoID, nil)
+	require.NoError(t, err)
+	require.Equal(t, videoID, detail.ID)
+
+	// public videos are still only changed by their owner
+	repo.EXPECT().GetVideo(gomock.Any(), videoID).Return(public, nil)
+	_, err = vp.SetChapters(context.Background(), viewer, videoID, models.SetChaptersRequest{})
+	var e models.Error
+	require.ErrorAs(t, err, &e)
+	require.Equal(t, http.StatusNotFound, e.Code)
+}
+
+func TestSubscribe(t *testing.T) {
+	vp, repo := newSubscriptionsProcessor(t)
+	userID, creatorID := uuid.New(), uuid.New()
+	var e models.Error
+
+	err := vp.Subscribe(context.Background(), userID, userID)
+	require.ErrorAs(t, err, &e)
+	require.Equal(t, http.StatusBadRequest, e.Code)
+
+	repo.EXPECT().Subscribe(gomock.Any(), db.SubscribeParams{SubscriberID: userID, CreatorID: creatorID}).Return(int64(1), nil)
+	repo.EXPECT().GetNotificationPreferences(gomock.Any(), creatorID).Return(db.GetNotificationPreferencesRow{}, nil)
+	repo.EXPECT().
+		CreateNotification(gomock.Any(), db.CreateNotificationParams{UserID: creatorID, Kind: models.NotificationNewSubscriber, Message: "You have a new subscriber."}).
+		Return(nil)
+	require.NoError(t, vp.Subscribe(context.Background(), userID, creatorID))
+
+	// subscribing again does not notify the creator again
+	repo.EXPECT().Subscribe(gomock.Any(), gomock.Any()).Return(int64(0), nil)
+	require.NoError(t, vp.Subscribe(context.Background(), userID, creatorID))
+
+	repo.EXPECT().Subscribe(gomock.Any(), gomock.Any()).Return(int64(0), &pgconn.PgError{Code: "23503"})
+	err = vp.Subscribe(context.Background(), userID, uuid.New())
+	require.ErrorAs(t, err, &e)
+	require.Equal(t, http.StatusNotFound, e.Code)
+}
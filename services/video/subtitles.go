@@ -0,0 +1,196 @@
+package video
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"video-processing/database/db"
+
+	"github.com/google/uuid"
+)
+
+// subtitlesDir is the directory of the WebVTT sidecars under the results prefix
+const subtitlesDir = "subtitles"
+
+// textSubtitleCodecs are the subtitle codecs ffmpeg converts to WebVTT. Bitmap subtitles
+// (PGS, VobSub, DVB) would need OCR and are skipped.
+var textSubtitleCodecs = map[string]bool{
+	"subrip":   true,
+	"ass":      true,
+	"ssa":      true,
+	"mov_text": true,
+	"webvtt":   true,
+	"text":     true,
+}
+
+// subtitleTrack is a text subtitle stream of the source
+type subtitleTrack struct {
+	Track    int // position among the subtitle streams of the source, as in -map 0:s:N
+	Language string
+	Label    string // title tag of the stream, e.g. "English (SDH)"
+	Forced   bool   // only subtitles foreign dialogue
+}
+
+// fileName is the name of the track's sidecar, e.g. "2-eng.vtt"
+func (s subtitleTrack) fileName() string {
+	return fmt.Sprintf("%d-%s.vtt", s.Track, s.Language)
+}
+
+// subtitleTracks returns the text subtitle streams of the source and the codecs of the
+// bitmap ones it skips
+func subtitleTracks(probe ProbeResult) (tracks []subtitleTrack, skipped []string) {
+	track := 0
+	for _, s := range probe.Streams {
+		if s.CodecType != "subtitle" {
+			continue
+		}
+		if textSubtitleCodecs[s.CodecName] {
+			tracks = append(tracks, subtitleTrack{
+				Track:    track,
+				Language: s.Language(),
+				Label:    s.Tags["title"],
+				Forced:   s.Disposition["forced"] == 1,
+			})
+		} else {
+			skipped = append(skipped, s.CodecName)
+		}
+		track++
+	}
+	return tracks, skipped
+}
+
+// extractSubtitle converts a subtitle stream of the source to WebVTT
+func extractSubtitle(ctx context.Context, t Transcoder, sourcePath string, track int, outPath string) error {
+	// ffmpeg -y -i input -map 0:s:N -c:s webvtt out.vtt
+	args := []string{
+		"-y",
+		"-nostdin",
+		"-i", sourcePath,
+		"-map", fmt.Sprintf("0:s:%d", track),
+		"-c:s", "webvtt",
+		outPath,
+	}
+	if err := t.Run(ctx, args...); err != nil {
+		return fmt.Errorf("ffmpeg subtitle extraction error: %w", err)
+	}
+	return nil
+}
+
+// processSubtitles extracts the text subtitle tracks of the source into WebVTT sidecars under
+// the results prefix and replaces the subtitle rows of the video with them. A track that fails
+// to convert is logged and left out.
+func (rc *redisConsumer) processSubtitles(ctx context.Context, task ProcessingTask, tracks []subtitleTrack, uploadCh chan<- UploadTask) {
+	videoUUID, err := uuid.Parse(task.VideoID)
+	if err != nil {
+		rc.logger.Error("invalid video ID for subtitles", "error", err, "videoID", task.VideoID)
+		return
+	}
+	outDir := filepath.Join(task.WorkDir, subtitlesDir)
+	if err := os.MkdirAll(outDir, 0o755); err != nil {
+		rc.logger.Error("failed to create subtitles directory", "error", err, "videoID", task.VideoID)
+		return
+	}
+
+	var rows []db.SaveVideoSubtitleParams
+	for _, track := range tracks {
+		outPath := filepath.Join(outDir, track.fileName())
+		if err := extractSubtitle(ctx, rc.transcoder, task.SourcePath, track.Track, outPath); err != nil {
+			rc.logger.Warn("subtitle extraction failed", "error", err, "track", track.Track, "videoID", task.VideoID)
+			continue
+		}
+		upload := UploadTask{
+			SourcePath:  outPath,
+			ObjectKey:   filepath.ToSlash(filepath.Join(task.DestPrefix, subtitlesDir, track.fileName())),
+			ContentType: mimeTypeByExt(".vtt"),
+			Bucket:      task.Bucket,
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case uploadCh <- upload:
+		}
+		rows = append(rows, db.SaveVideoSubtitleParams{
+			VideoID:  videoUUID,
+			Track:    int32(track.Track),
+			Language: track.Language,
+			Label:    track.Label,
+			Forced:   track.Forced,
+			Bucket:   upload.Bucket,
+			Key:      upload.ObjectKey,
+		})
+	}
+	if len(rows) == 0 {
+		return
+	}
+
+	// the tracks of an earlier run point at its own results prefix
+	if err := rc.db.DeleteVideoSubtitles(ctx, videoUUID); err != nil {
+		rc.logger.Error("failed to delete previous subtitles", "error", err, "videoID", task.VideoID)
+		return
+	}
+	for _, row := range rows {
+		if _, err := rc.db.SaveVideoSubtitle(ctx, row); err != nil {
+			rc.logger.Error("failed to save subtitle", "error", err, "track", row.Track, "videoID", task.VideoID)
+		}
+	}
+	rc.logger.Info("extracted subtitles", "videoID", task.VideoID, "count", len(rows))
+}
+
+// burnInSubtitles returns the subtitles filter that burns the job's chosen subtitles into the
+// picture, empty when none were asked for. An uploaded SRT is fetched into workDir and moved
+// along with a trimmed start. A track of the source must be a text one, libass cannot render
+// bitmap subtitles. Subtitles that cannot be burned in are logged and the video is processed
+// without them.
+func (rc *redisConsumer) burnInSubtitles(ctx context.Context, values map[string]interface{}, bucket, sourcePath, workDir string, probe ProbeResult) string {
+	videoID := values["video_id"]
+	if key, _ := values["burn_subtitles_key"].(string); key != "" {
+		srtPath := filepath.Join(workDir, "burn-in.srt")
+		if err := downloadFromMinio(ctx, rc.mc, bucket, key, srtPath); err != nil {
+			rc.logger.Error("failed to download subtitles to burn in", "error", err, "key", key, "videoID", videoID)
+			return ""
+		}
+		if r, ok := trimRange(values); ok && r.Start > 0 {
+			// the SRT is timed against the untrimmed upload, seeking into it moves the cues
+			shifted := filepath.Join(workDir, "burn-in-trimmed.srt")
+			if err := rc.transcoder.Run(ctx, "-y", "-nostdin", "-ss", fmt.Sprintf("%.3f", r.Start), "-i", srtPath, shifted); err != nil {
+				rc.logger.Error("failed to shift subtitles to the trimmed video", "error", err, "videoID", videoID)
+				return ""
+			}
+			srtPath = shifted
+		}
+		return "subtitles=filename=" + escapeFilterPath(srtPath)
+	}
+
+	value, _ := values["burn_subtitle_track"].(string)
+	if value == "" {
+		return ""
+	}
+	track, err := strconv.Atoi(value)
+	if err != nil {
+		rc.logger.Warn("invalid subtitle track to burn in", "error", err, "videoID", videoID)
+		return ""
+	}
+	tracks, _ := subtitleTracks(probe)
+	for _, t := range tracks {
+		if t.Track == track {
+			return fmt.Sprintf("subtitles=filename=%s:si=%d", escapeFilterPath(sourcePath), track)
+		}
+	}
+	rc.logger.Warn("source has no text subtitle track to burn in", "track", track, "videoID", videoID)
+	return ""
+}
+
+// burnInChain appends the task's burn-in filter to the video filter chain vf, after scaling
+// so the text is sized for the variant. A chunk starts at 0 after the input seek, so its
+// timestamps are moved to the source's while the subtitles are rendered.
+func burnInChain(vf string, task ProcessingTask) string {
+	if task.BurnSubtitles == "" {
+		return vf
+	}
+	if c := task.Chunk; c != nil && c.Start > 0 {
+		return fmt.Sprintf("%s,setpts=PTS+%.3f/TB,%s,setpts=PTS-STARTPTS", vf, c.Start, task.BurnSubtitles)
+	}
+	return vf + "," + task.BurnSubtitles
+}
@@ -0,0 +1,120 @@
+package video
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"path"
+	"strings"
+	"testing"
+	"video-processing/database/db"
+	"video-processing/mocks"
+
+	"github.com/google/uuid"
+	"github.com/minio/minio-go/v7"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestSubtitleTracks(t *testing.T) {
+	probe := ProbeResult{Streams: []ProbeStream{
+		{CodecType: "video", CodecName: "h264"},
+		{CodecType: "subtitle", CodecName: "subrip", Tags: map[string]string{"language": "eng", "title": "English (SDH)"}},
+		{CodecType: "audio", CodecName: "aac"},
+		{CodecType: "subtitle", CodecName: "hdmv_pgs_subtitle", Tags: map[string]string{"language": "eng"}},
+		{CodecType: "subtitle", CodecName: "ass", Tags: map[string]string{"language": "../fr"}, Disposition: map[string]int{"forced": 1}},
+	}}
+	tracks, skipped := subtitleTracks(probe)
+	require.Equal(t, []subtitleTrack{
+		{Track: 0, Language: "eng", Label: "English (SDH)"},
+		// the bitmap track keeps its position, the index maps the stream in ffmpeg
+		{Track: 2, Language: "und", Forced: true},
+	}, tracks)
+	require.Equal(t, []string{"hdmv_pgs_subtitle"}, skipped)
+	require.Equal(t, "2-und.vtt", tracks[1].fileName())
+}
+
+func TestProcessSubtitles(t *testing.T) {
+	fake := NewFakeTranscoder()
+	repo := mocks.NewMockVideoRepo(gomock.NewController(t))
+	rc := &redisConsumer{logger: slog.New(slog.NewTextHandler(io.Discard, nil)), transcoder: fake, db: repo}
+	videoID := uuid.New()
+	task := ProcessingTask{
+		WorkDir:    t.TempDir(),
+		SourcePath: "source.mkv",
+		DestPrefix: "processed/job",
+		Bucket:     "videos",
+		VideoID:    videoID.String(),
+	}
+	tracks := []subtitleTrack{{Track: 0, Language: "eng"}, {Track: 1, Language: "spa", Label: "Latin America"}}
+	uploads := make(chan UploadTask, 10)
+
+	// a track that fails to convert is left out, the rows of the earlier run are replaced
+	fake.FailOn = "0:s:0"
+	gomock.InOrder(
+		repo.EXPECT().DeleteVideoSubtitles(gomock.Any(), videoID).Return(nil),
+		repo.EXPECT().SaveVideoSubtitle(gomock.Any(), db.SaveVideoSubtitleParams{
+			VideoID:  videoID,
+			Track:    1,
+			Language: "spa",
+			Label:    "Latin America",
+			Bucket:   "videos",
+			Key:      "processed/job/subtitles/1-spa.vtt",
+		}),
+	)
+	rc.processSubtitles(context.Background(), task, tracks, uploads)
+	require.Len(t, uploads, 1)
+	upload := <-uploads
+	require.Equal(t, "processed/job/subtitles/1-spa.vtt", upload.ObjectKey)
+	require.Equal(t, "text/vtt", upload.ContentType)
+
+	// nothing converted, the earlier rows stay
+	fake.FailOn = "webvtt"
+	rc.processSubtitles(context.Background(), task, tracks, uploads)
+	require.Empty(t, uploads)
+}
+
+func TestBurnInSubtitles(t *testing.T) {
+	store := mocks.NewMockObjectStore(gomock.NewController(t))
+	rc := &redisConsumer{logger: slog.New(slog.NewTextHandler(io.Discard, nil)), mc: store}
+	workDir := t.TempDir()
+	probe := ProbeResult{Streams: []ProbeStream{
+		{CodecType: "video", CodecName: "h264"},
+		{CodecType: "subtitle", CodecName: "hdmv_pgs_subtitle"},
+		{CodecType: "subtitle", CodecName: "subrip"},
+	}}
+	burnIn := func(values map[string]interface{}) string {
+		return rc.burnInSubtitles(context.Background(), values, "videos", "/tmp/job/source.mkv", workDir, probe)
+	}
+
+	require.Empty(t, burnIn(map[string]interface{}{}))
+	require.Equal(t, "subtitles=filename=/tmp/job/source.mkv:si=1", burnIn(map[string]interface{}{"burn_subtitle_track": "1"}))
+	// a bitmap track or a missing one is left out
+	require.Empty(t, burnIn(map[string]interface{}{"burn_subtitle_track": "0"}))
+	require.Empty(t, burnIn(map[string]interface{}{"burn_subtitle_track": "5"}))
+
+	srtPath := path.Join(workDir, "burn-in.srt")
+	store.EXPECT().FGetObject(gomock.Any(), "videos", "uploads/a.mp4.srt", srtPath, minio.GetObjectOptions{})
+	require.Equal(t, "subtitles=filename="+escapeFilterPath(srtPath), burnIn(map[string]interface{}{"burn_subtitles_key": "uploads/a.mp4.srt"}))
+
+	// a trimmed upload shifts the cues by the cut
+	fake := NewFakeTranscoder()
+	rc.transcoder = fake
+	store.EXPECT().FGetObject(gomock.Any(), "videos", "uploads/a.mp4.srt", srtPath, minio.GetObjectOptions{})
+	shifted := path.Join(workDir, "burn-in-trimmed.srt")
+	require.Equal(t, "subtitles=filename="+escapeFilterPath(shifted), burnIn(map[string]interface{}{"burn_subtitles_key": "uploads/a.mp4.srt", "trim_start": "30"}))
+	require.Equal(t, []string{"-y", "-nostdin", "-ss", "30.000", "-i", srtPath, shifted}, fake.Calls()[0])
+}
+
+func TestTranscodeBurnIn(t *testing.T) {
+	fake := NewFakeTranscoder()
+	task := ProcessingTask{Variant: testLadder.regular[1], SourcePath: "source.mkv", BurnSubtitles: "subtitles=filename=source.mkv:si=0"}
+	require.NoError(t, transcodeToMP4(context.Background(), fake, task, path.Join(t.TempDir(), "720p.mp4")))
+	require.Contains(t, fake.Calls()[0], "scale=1280:720,subtitles=filename=source.mkv:si=0")
+
+	// a chunk renders the subtitles at its position in the source
+	fake = NewFakeTranscoder()
+	task.Chunk = &chunkRange{Start: 60, Duration: 60}
+	require.NoError(t, transcodeToMP4(context.Background(), fake, task, path.Join(t.TempDir(), "720p.mp4")))
+	require.Contains(t, strings.Join(fake.Calls()[0], " "), "scale=1280:720,setpts=PTS+60.000/TB,subtitles=filename=source.mkv:si=0,setpts=PTS-STARTPTS")
+}
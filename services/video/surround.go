@@ -0,0 +1,81 @@
+package video
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strconv"
+)
+
+// defaultSurroundVariants is how many variants keep surround audio when the configuration
+// sets none
+const defaultSurroundVariants = 1
+
+// surroundChannels returns the channels the surround audio of the source is kept with: 8 for
+// 7.1, and 6 for 5.1 and 6.1, which the AAC encoder has no layout for. It is 0 for sources
+// with fewer channels, and for sources with several audio tracks, which are all downmixed.
+func surroundChannels(probe ProbeResult) int {
+	if len(probe.AudioLanguages()) != 1 {
+		return 0
+	}
+	for _, s := range probe.Streams {
+		if s.CodecType != "audio" {
+			continue
+		}
+		switch {
+		case s.Channels >= 8:
+			return 8
+		case s.Channels >= 6:
+			return 6
+		}
+	}
+	return 0
+}
+
+// withSurround returns the variants with the n largest keeping channels of surround audio.
+// The others carry the stereo downmix.
+func withSurround(variants []Variant, channels, n int) []Variant {
+	if channels == 0 || n <= 0 {
+		return variants
+	}
+	var order []int
+	for i, v := range variants {
+		if !v.AudioOnly {
+			order = append(order, i)
+		}
+	}
+	slices.SortStableFunc(order, func(a, b int) int {
+		return cmp.Compare(variants[b].Width*variants[b].Height, variants[a].Width*variants[a].Height)
+	})
+	variants = slices.Clone(variants)
+	for _, i := range order[:min(n, len(order))] {
+		variants[i].AudioChannels = channels
+	}
+	return variants
+}
+
+// audioArgs encode the audio of a variant with channels: the stereo downmix for 0, the
+// surround channels kept otherwise
+func audioArgs(channels int) []string {
+	if channels == 0 {
+		return variantAudioArgs
+	}
+	return []string{
+		"-c:a", "aac",
+		"-ac", strconv.Itoa(channels),
+		"-ar", "44100",
+		"-b:a", fmt.Sprintf("%dk", audioKbps(channels)),
+	}
+}
+
+// audioKbps is the bitrate of the audio of a variant with channels, which master playlists
+// add to the bitrate of its video
+func audioKbps(channels int) int64 {
+	switch channels {
+	case 8:
+		return 512
+	case 6:
+		return 384
+	}
+	return 128
+}
@@ -0,0 +1,57 @@
+package video
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSurroundChannels(t *testing.T) {
+	source := func(channels ...int) ProbeResult {
+		probe := ProbeResult{Streams: []ProbeStream{{CodecType: "video", CodecName: "h264"}}}
+		for _, c := range channels {
+			probe.Streams = append(probe.Streams, ProbeStream{CodecType: "audio", CodecName: "ac3", Channels: c})
+		}
+		return probe
+	}
+	require.Equal(t, 6, surroundChannels(source(6)))
+	require.Equal(t, 6, surroundChannels(source(7)))
+	require.Equal(t, 8, surroundChannels(source(8)))
+	require.Zero(t, surroundChannels(source(2)))
+	require.Zero(t, surroundChannels(source()))
+	// every track of a source with several is downmixed
+	require.Zero(t, surroundChannels(source(6, 2)))
+}
+
+func TestWithSurround(t *testing.T) {
+	variants := append(slices.Clone(testLadder.regular), Variant{Name: "audio", AudioOnly: true})
+	surround := withSurround(variants, 6, 2)
+	var kept []string
+	for _, v := range surround {
+		if v.AudioChannels > 0 {
+			require.Equal(t, 6, v.AudioChannels)
+			kept = append(kept, v.Name)
+		}
+	}
+	require.Equal(t, []string{"1080p", "720p"}, kept)
+	for _, v := range variants {
+		require.Zero(t, v.AudioChannels, "the ladder is left alone")
+	}
+	require.Equal(t, variants, withSurround(variants, 0, 2))
+}
+
+func TestTranscodeToMP4Surround(t *testing.T) {
+	fake := NewFakeTranscoder()
+	task := ProcessingTask{Variant: testLadder.regular[0], SourcePath: "source.mp4"}
+	task.Variant.AudioChannels = 8
+	require.NoError(t, transcodeToMP4(context.Background(), fake, task, filepath.Join(t.TempDir(), "out.mp4")))
+	require.Contains(t, strings.Join(fake.Calls()[0], " "), "-c:a aac -ac 8 -ar 44100 -b:a 512k")
+
+	// the master playlist counts the surround audio in the bandwidth
+	playlist := masterPlaylist([]ProcessingResult{{Variant: task.Variant}})
+	require.Contains(t, playlist, "BANDWIDTH=4512000,")
+}
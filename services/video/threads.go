@@ -0,0 +1,32 @@
+package video
+
+import "strconv"
+
+// encoderThreads splits the available cores between the variants encoded at the same time,
+// so concurrent ffmpeg processes don't oversubscribe the CPU. Every encode gets at least one thread.
+func encoderThreads(cpus, parallel int) int {
+	if parallel < 1 {
+		parallel = 1
+	}
+	if threads := cpus / parallel; threads > 1 {
+		return threads
+	}
+	return 1
+}
+
+// filterThreadArgs caps the filtergraph threads; it is a global option and goes before the inputs.
+// Zero threads leaves ffmpeg's defaults in place.
+func filterThreadArgs(threads int) []string {
+	if threads <= 0 {
+		return nil
+	}
+	return []string{"-filter_threads", strconv.Itoa(threads)}
+}
+
+// encoderThreadArgs caps the encoder threads (x264/x265 worker threads); it goes after the inputs
+func encoderThreadArgs(threads int) []string {
+	if threads <= 0 {
+		return nil
+	}
+	return []string{"-threads", strconv.Itoa(threads)}
+}
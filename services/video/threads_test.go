@@ -0,0 +1,26 @@
+package video
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncoderThreads(t *testing.T) {
+	testCases := []struct {
+		name     string
+		cpus     int
+		parallel int
+		want     int
+	}{
+		{name: "six variants on sixteen cores", cpus: 16, parallel: 6, want: 2},
+		{name: "one variant at a time", cpus: 16, parallel: 1, want: 16},
+		{name: "more variants than cores", cpus: 4, parallel: 6, want: 1},
+		{name: "no concurrency configured", cpus: 8, parallel: 0, want: 8},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, encoderThreads(tc.cpus, tc.parallel))
+		})
+	}
+}
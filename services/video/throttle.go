@@ -0,0 +1,135 @@
+package video
+
+import (
+	"context"
+	"io"
+	"math"
+	"sync"
+	"time"
+	"video-processing/models"
+
+	"github.com/google/uuid"
+)
+
+// minBurst keeps the reads of slow plans from shrinking to a few bytes
+const minBurst = 32 << 10
+
+// tokenBucket lets rate bytes per second through, and up to burst bytes at once after a pause
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  int
+	tokens float64
+	last   time.Time
+	// readers counts the open downloads sharing the bucket
+	readers int
+}
+
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+	return &tokenBucket{rate: rate, burst: burst, tokens: float64(burst), last: time.Now()}
+}
+
+// wait takes n bytes out of the bucket and sleeps until the bucket has refilled them. Waiters
+// reserve their bytes in turn, so concurrent downloads share the rate.
+func (b *tokenBucket) wait(ctx context.Context, n int) error {
+	b.mu.Lock()
+	now := time.Now()
+	b.tokens = math.Min(float64(b.burst), b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+	b.tokens -= float64(n)
+	deficit := -b.tokens
+	b.mu.Unlock()
+	if deficit <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(time.Duration(deficit / b.rate * float64(time.Second)))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// throttledReader reads no faster than its token bucket allows
+type throttledReader struct {
+	io.ReadCloser
+	ctx     context.Context
+	bucket  *tokenBucket
+	release func()
+	once    sync.Once
+}
+
+func (r *throttledReader) Read(p []byte) (int, error) {
+	if len(p) > r.bucket.burst {
+		p = p[:r.bucket.burst]
+	}
+	n, err := r.ReadCloser.Read(p)
+	if n > 0 {
+		if werr := r.bucket.wait(r.ctx, n); werr != nil {
+			return n, werr
+		}
+	}
+	return n, err
+}
+
+func (r *throttledReader) Close() error {
+	r.once.Do(r.release)
+	return r.ReadCloser.Close()
+}
+
+// bandwidthLimiter holds the token buckets of the users downloading from this instance
+type bandwidthLimiter struct {
+	config  models.DeliveryConfig
+	mu      sync.Mutex
+	buckets map[uuid.UUID]*tokenBucket
+}
+
+// newBandwidthLimiter returns nil when no plan has a rate, which leaves delivery unlimited
+func newBandwidthLimiter(config models.DeliveryConfig) *bandwidthLimiter {
+	for _, rate := range config.Plans {
+		if rate > 0 {
+			return &bandwidthLimiter{config: config, buckets: map[uuid.UUID]*tokenBucket{}}
+		}
+	}
+	return nil
+}
+
+// rate returns the bytes per second of the plan of a user, 0 when unlimited
+func (l *bandwidthLimiter) rate(userID uuid.UUID) float64 {
+	return float64(l.config.Plans[l.config.PlanOf(userID)] << 10)
+}
+
+// limit throttles body to the rate of the user. The user's downloads share one bucket, which
+// is dropped when the last of them is closed.
+func (l *bandwidthLimiter) limit(ctx context.Context, userID uuid.UUID, body io.ReadCloser) io.ReadCloser {
+	if l == nil {
+		return body
+	}
+	rate := l.rate(userID)
+	if rate <= 0 {
+		return body
+	}
+	l.mu.Lock()
+	bucket, ok := l.buckets[userID]
+	if !ok {
+		burst := int(l.config.BurstKB << 10)
+		if burst == 0 {
+			burst = int(rate)
+		}
+		bucket = newTokenBucket(rate, max(burst, minBurst))
+		l.buckets[userID] = bucket
+	}
+	bucket.readers++
+	l.mu.Unlock()
+
+	release := func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if bucket.readers--; bucket.readers == 0 {
+			delete(l.buckets, userID)
+		}
+	}
+	return &throttledReader{ReadCloser: body, ctx: ctx, bucket: bucket, release: release}
+}
@@ -0,0 +1,72 @@
+package video
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+	"time"
+	"video-processing/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenBucketThrottlesReads(t *testing.T) {
+	bucket := newTokenBucket(1<<20, 64<<10) // 1 MB/s after a 64 KB burst
+	released := false
+	r := &throttledReader{
+		ReadCloser: io.NopCloser(bytes.NewReader(make([]byte, 64<<10+512<<10))),
+		ctx:        context.Background(),
+		bucket:     bucket,
+		release:    func() { released = true },
+	}
+
+	start := time.Now()
+	n, err := io.Copy(io.Discard, r)
+	require.NoError(t, err)
+	require.EqualValues(t, 64<<10+512<<10, n)
+	elapsed := time.Since(start)
+	require.GreaterOrEqual(t, elapsed, 450*time.Millisecond)
+	require.Less(t, elapsed, 2*time.Second)
+	require.NoError(t, r.Close())
+	require.True(t, released)
+
+	// waiting stops with the request
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	require.ErrorIs(t, bucket.wait(ctx, 64<<10), context.Canceled)
+}
+
+func TestBandwidthLimiterPlans(t *testing.T) {
+	free, pro, listed := uuid.New(), uuid.New(), uuid.New()
+	config := models.DeliveryConfig{
+		Plans:       map[string]int64{"free": 256, "pro": 0, "partner": 4096},
+		DefaultPlan: "free",
+		Users:       map[string]string{pro.String(): "pro", listed.String(): "partner"},
+	}
+	require.NoError(t, config.Validate())
+	l := newBandwidthLimiter(config)
+	require.Equal(t, float64(256<<10), l.rate(free))
+	require.Zero(t, l.rate(pro))
+	require.Equal(t, float64(4096<<10), l.rate(listed))
+
+	body := io.NopCloser(bytes.NewReader(nil))
+	require.Equal(t, body, l.limit(context.Background(), pro, body))
+
+	// the downloads of a user share a bucket until the last one closes
+	first := l.limit(context.Background(), free, body)
+	second := l.limit(context.Background(), free, body)
+	require.Same(t, first.(*throttledReader).bucket, second.(*throttledReader).bucket)
+	require.Equal(t, 256<<10, first.(*throttledReader).bucket.burst)
+	first.Close()
+	first.Close()
+	require.Len(t, l.buckets, 1)
+	second.Close()
+	require.Empty(t, l.buckets)
+
+	require.Nil(t, newBandwidthLimiter(models.DeliveryConfig{Plans: map[string]int64{"free": 0}}))
+	require.Error(t, models.DeliveryConfig{DefaultPlan: "gold"}.Validate())
+	require.Error(t, models.DeliveryConfig{Plans: map[string]int64{"free": 1}, Users: map[string]string{"someone": "free"}}.Validate())
+	require.Error(t, models.DeliveryConfig{Plans: map[string]int64{"free": -1}}.Validate())
+}
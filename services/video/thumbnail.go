@@ -0,0 +1,211 @@
+package video
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"video-processing/database/db"
+	"video-processing/models"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// ThumbnailAuto lets ffmpeg pick a representative frame for the variant thumbnails. It is
+	// used when nothing is configured.
+	ThumbnailAuto = "auto"
+	// defaultThumbnailAt is where variant thumbnails are taken when the configured position is invalid
+	defaultThumbnailAt = "5"
+	// smart thumbnails are picked from the smartThumbnailWindow seconds after smartThumbnailFrom,
+	// past intros and title cards, sampling a frame per second
+	smartThumbnailFrom   = "10%"
+	smartThumbnailWindow = 30
+	// smartThumbnailMaxBlack is the share of dark pixels, in percent, above which a frame is not picked
+	smartThumbnailMaxBlack = 50
+)
+
+// smartThumbnailArgs pick the most representative of the frames sampled from the window after
+// fromSecond, leaving out mostly black ones: ffmpeg's thumbnail filter takes the frame closest
+// to the average color histogram of the window.
+func smartThumbnailArgs(inputPath, outImagePath string, fromSecond float64) []string {
+	vf := fmt.Sprintf("fps=1,blackframe=amount=0:threshold=32,metadata=mode=select:key=lavfi.blackframe.pblack:value=%d:function=less,thumbnail=n=%d",
+		smartThumbnailMaxBlack, smartThumbnailWindow)
+	return []string{
+		"-y",
+		"-nostdin",
+		"-ss", fmt.Sprintf("%.3f", fromSecond),
+		"-t", fmt.Sprint(smartThumbnailWindow),
+		"-i", inputPath,
+		"-vf", vf,
+		"-frames:v", "1",
+		"-q:v", "2",
+		outImagePath,
+	}
+}
+
+// generateVariantThumbnail writes the thumbnail of a variant. A smart thumbnail falls back to
+// the frame at atSecond when every sampled frame is black or the filters fail.
+func generateVariantThumbnail(ctx context.Context, t Transcoder, inputPath, outImagePath string, atSecond float64, smart bool) error {
+	if smart {
+		err := t.Run(ctx, smartThumbnailArgs(inputPath, outImagePath, atSecond)...)
+		if err == nil {
+			// ffmpeg succeeds without writing anything when the filters drop every frame
+			if _, err = os.Stat(outImagePath); err == nil {
+				return nil
+			}
+		}
+	}
+	return generateThumbnail(ctx, t, inputPath, outImagePath, atSecond)
+}
+
+// thumbnailOffset resolves a thumbnail position to seconds into the video.
+// The position is either an offset in seconds, e.g. "5" or "2.5", or a share of the
+// duration, e.g. "10%". Offsets past the end fall back to the middle of the video so
+// short clips still get a thumbnail.
+func thumbnailOffset(position string, durationSeconds float64) (float64, error) {
+	position = strings.TrimSpace(position)
+	var offset float64
+	if pct, ok := strings.CutSuffix(position, "%"); ok {
+		p, err := strconv.ParseFloat(pct, 64)
+		if err != nil || p < 0 || p > 100 {
+			return 0, fmt.Errorf("invalid thumbnail position %q", position)
+		}
+		offset = durationSeconds * p / 100
+	} else {
+		s, err := strconv.ParseFloat(position, 64)
+		if err != nil || s < 0 {
+			return 0, fmt.Errorf("invalid thumbnail position %q", position)
+		}
+		offset = s
+	}
+	if durationSeconds > 0 && offset >= durationSeconds {
+		offset = durationSeconds / 2
+	}
+	return offset, nil
+}
+
+// thumbnailsDir holds the thumbnails the owner picks the primary one from
+const thumbnailsDir = "thumbnails"
+
+var defaultThumbnailCandidates = []string{"10%", "30%", "60%"}
+
+// candidateThumbnailArgs take the frame at atSecond of the source as a JPEG at most 1280 pixels
+// wide. HDR sources are tone mapped like the SDR variants.
+func candidateThumbnailArgs(inputPath, outPath string, atSecond float64, hdrFormat string) []string {
+	vf := toneMapChain("scale='min(1280,iw)':-2", hdrFormat)
+	return []string{
+		"-y",
+		"-nostdin",
+		"-ss", fmt.Sprintf("%.3f", atSecond),
+		"-i", inputPath,
+		"-vf", vf,
+		"-frames:v", "1",
+		"-q:v", "2",
+		outPath,
+	}
+}
+
+// processThumbnails takes a thumbnail at each candidate position and queues them for upload
+// next to the renditions. The first run makes the first thumbnail the primary one; later runs
+// keep the owner's choice. Failed thumbnails are logged and left out.
+func (rc *redisConsumer) processThumbnails(ctx context.Context, task ProcessingTask, durationSeconds float64, uploadCh chan<- UploadTask) {
+	videoUUID, err := uuid.Parse(task.VideoID)
+	if err != nil {
+		rc.logger.Error("invalid video ID for thumbnails", "error", err, "videoID", task.VideoID)
+		return
+	}
+	outDir := filepath.Join(task.WorkDir, thumbnailsDir)
+	if err := os.MkdirAll(outDir, 0o755); err != nil {
+		rc.logger.Error("failed to create thumbnails directory", "error", err, "videoID", task.VideoID)
+		return
+	}
+	positions := rc.processing.ThumbnailCandidates
+	if len(positions) == 0 {
+		positions = defaultThumbnailCandidates
+	}
+
+	var rows []db.SaveVideoThumbnailParams
+	for _, position := range positions {
+		at, err := thumbnailOffset(position, durationSeconds)
+		if err != nil {
+			rc.logger.Warn("invalid thumbnail candidate", "error", err, "videoID", task.VideoID)
+			continue
+		}
+		name := fmt.Sprintf("thumb_%d.jpg", len(rows))
+		outPath := filepath.Join(outDir, name)
+		if err := rc.transcoder.Run(ctx, candidateThumbnailArgs(task.SourcePath, outPath, at, task.HDRFormat)...); err != nil {
+			rc.logger.Warn("thumbnail generation failed", "error", err, "position", position, "videoID", task.VideoID)
+			continue
+		}
+		upload := UploadTask{
+			SourcePath:  outPath,
+			ObjectKey:   filepath.ToSlash(filepath.Join(task.DestPrefix, thumbnailsDir, name)),
+			ContentType: mimeTypeByExt(".jpg"),
+			Bucket:      task.Bucket,
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case uploadCh <- upload:
+		}
+		rows = append(rows, db.SaveVideoThumbnailParams{
+			VideoID:  videoUUID,
+			Position: int32(len(rows)),
+			AtMs:     int64(math.Round(at * 1000)),
+			Bucket:   upload.Bucket,
+			Key:      upload.ObjectKey,
+		})
+	}
+	if len(rows) == 0 {
+		return
+	}
+
+	// an earlier run may have taken more thumbnails
+	if err := rc.db.DeleteVideoThumbnailsFrom(ctx, db.DeleteVideoThumbnailsFromParams{VideoID: videoUUID, Position: int32(len(rows))}); err != nil {
+		rc.logger.Error("failed to delete previous thumbnails", "error", err, "videoID", task.VideoID)
+		return
+	}
+	for _, row := range rows {
+		if _, err := rc.db.SaveVideoThumbnail(ctx, row); err != nil {
+			rc.logger.Error("failed to save thumbnail", "error", err, "position", row.Position, "videoID", task.VideoID)
+		}
+	}
+	rc.logger.Info("saved thumbnails", "videoID", task.VideoID, "count", len(rows))
+}
+
+// SetPrimaryThumbnail makes one of the thumbnails of the video its primary one
+func (vp *videoProcessor) SetPrimaryThumbnail(ctx context.Context, userID, videoID uuid.UUID, req models.PrimaryThumbnailRequest) (models.VideoDetail, error) {
+	params := fmt.Sprintf("userID: %v, videoID: %v, req: %v", userID, videoID, req)
+	if err := req.Validate(); err != nil {
+		return models.VideoDetail{}, models.Error{
+			Code:    http.StatusBadRequest,
+			Message: "invalid input data",
+			Params:  params,
+			Err:     err,
+		}
+	}
+	if _, err := vp.getOwnedVideo(ctx, userID, videoID); err != nil {
+		return models.VideoDetail{}, err
+	}
+	n, err := vp.db.SetPrimaryVideoThumbnail(ctx, db.SetPrimaryVideoThumbnailParams{
+		Position: int32(*req.Position),
+		VideoID:  videoID,
+	})
+	if err != nil {
+		return models.VideoDetail{}, models.IndentifyDbError(err).AddParams(params)
+	}
+	if n == 0 {
+		return models.VideoDetail{}, models.Error{
+			Code:    http.StatusNotFound,
+			Message: "resource not found",
+			Params:  params,
+			Err:     models.ErrResourceNotFound,
+		}
+	}
+	return vp.GetVideo(ctx, userID, videoID, nil)
+}
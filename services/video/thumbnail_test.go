@@ -0,0 +1,126 @@
+package video
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"path"
+	"strings"
+	"testing"
+	"video-processing/database/db"
+	"video-processing/mocks"
+	"video-processing/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestThumbnailOffset(t *testing.T) {
+	cases := []struct {
+		position string
+		duration float64
+		want     float64
+	}{
+		{"5", 3600, 5},
+		{"2.5", 60, 2.5},
+		{"10%", 3600, 360},
+		{"0%", 60, 0},
+		{"5", 4, 2},    // past the end of a short clip
+		{"100%", 8, 4}, // the very last frame may not decode
+		{"30", 0, 30},  // unknown duration
+	}
+	for _, c := range cases {
+		got, err := thumbnailOffset(c.position, c.duration)
+		require.NoError(t, err, c.position)
+		require.InDelta(t, c.want, got, 1e-9, c.position)
+	}
+
+	for _, bad := range []string{"", "abc", "-1", "150%", "-5%"} {
+		_, err := thumbnailOffset(bad, 60)
+		require.Error(t, err, bad)
+	}
+}
+
+func TestCandidateThumbnailArgs(t *testing.T) {
+	require.Equal(t, "-y -nostdin -ss 12.500 -i source.mkv -vf scale='min(1280,iw)':-2 -frames:v 1 -q:v 2 thumb_0.jpg",
+		strings.Join(candidateThumbnailArgs("source.mkv", "thumb_0.jpg", 12.5, ""), " "))
+	require.Contains(t, strings.Join(candidateThumbnailArgs("source.mkv", "thumb_0.jpg", 12.5, HDRFormatHDR10), " "),
+		"-vf "+toneMapFilter+",scale=")
+}
+
+func TestProcessThumbnails(t *testing.T) {
+	fake := NewFakeTranscoder()
+	repo := mocks.NewMockVideoRepo(gomock.NewController(t))
+	rc := &redisConsumer{logger: slog.New(slog.NewTextHandler(io.Discard, nil)), transcoder: fake, db: repo}
+	videoID := uuid.New()
+	task := ProcessingTask{
+		WorkDir:    t.TempDir(),
+		SourcePath: "source.mkv",
+		DestPrefix: "processed/job",
+		Bucket:     "videos",
+		VideoID:    videoID.String(),
+	}
+	uploads := make(chan UploadTask, 10)
+
+	// a failed thumbnail is left out and the later ones move up
+	fake.FailOn = "30.000"
+	gomock.InOrder(
+		repo.EXPECT().DeleteVideoThumbnailsFrom(gomock.Any(), db.DeleteVideoThumbnailsFromParams{VideoID: videoID, Position: 2}),
+		repo.EXPECT().SaveVideoThumbnail(gomock.Any(), db.SaveVideoThumbnailParams{
+			VideoID: videoID, Position: 0, AtMs: 10000, Bucket: "videos", Key: "processed/job/thumbnails/thumb_0.jpg",
+		}),
+		repo.EXPECT().SaveVideoThumbnail(gomock.Any(), db.SaveVideoThumbnailParams{
+			VideoID: videoID, Position: 1, AtMs: 60000, Bucket: "videos", Key: "processed/job/thumbnails/thumb_1.jpg",
+		}),
+	)
+	rc.processThumbnails(context.Background(), task, 100, uploads)
+	require.Len(t, uploads, 2)
+	require.Equal(t, "image/jpeg", (<-uploads).ContentType)
+}
+
+func TestSetPrimaryThumbnail(t *testing.T) {
+	vp, repo := newSubscriptionsProcessor(t)
+	userID, videoID := uuid.New(), uuid.New()
+	video := db.Video{ID: videoID, UserID: userID}
+	position := 2
+
+	var e models.Error
+	_, err := vp.SetPrimaryThumbnail(context.Background(), userID, videoID, models.PrimaryThumbnailRequest{})
+	require.ErrorAs(t, err, &e)
+	require.Equal(t, http.StatusBadRequest, e.Code)
+
+	repo.EXPECT().GetVideo(gomock.Any(), videoID).Return(video, nil)
+	repo.EXPECT().SetPrimaryVideoThumbnail(gomock.Any(), db.SetPrimaryVideoThumbnailParams{Position: 2, VideoID: videoID}).Return(int64(0), nil)
+	_, err = vp.SetPrimaryThumbnail(context.Background(), userID, videoID, models.PrimaryThumbnailRequest{Position: &position})
+	require.ErrorAs(t, err, &e)
+	require.Equal(t, http.StatusNotFound, e.Code)
+
+	repo.EXPECT().GetVideo(gomock.Any(), videoID).Return(video, nil)
+	repo.EXPECT().SetPrimaryVideoThumbnail(gomock.Any(), db.SetPrimaryVideoThumbnailParams{Position: 2, VideoID: videoID}).Return(int64(3), nil)
+	expectVideoDetail(repo, video)
+	_, err = vp.SetPrimaryThumbnail(context.Background(), userID, videoID, models.PrimaryThumbnailRequest{Position: &position})
+	require.NoError(t, err)
+}
+
+func TestGenerateVariantThumbnail(t *testing.T) {
+	fake := NewFakeTranscoder()
+	out := path.Join(t.TempDir(), "720p-thumb.jpg")
+
+	require.NoError(t, generateVariantThumbnail(context.Background(), fake, "720p.mp4", out, 42, true))
+	require.Len(t, fake.Calls(), 1)
+	require.Contains(t, strings.Join(fake.Calls()[0], " "), "-ss 42.000 -t 30 -i 720p.mp4 -vf fps=1,blackframe=amount=0:threshold=32,"+
+		"metadata=mode=select:key=lavfi.blackframe.pblack:value=50:function=less,thumbnail=n=30 -frames:v 1")
+
+	// the frame at the offset when the filters fail
+	fake = NewFakeTranscoder()
+	fake.FailOn = "blackframe"
+	require.NoError(t, generateVariantThumbnail(context.Background(), fake, "720p.mp4", out, 42, true))
+	require.Len(t, fake.Calls(), 2)
+	require.NotContains(t, strings.Join(fake.Calls()[1], " "), "thumbnail=")
+
+	fake = NewFakeTranscoder()
+	require.NoError(t, generateVariantThumbnail(context.Background(), fake, "720p.mp4", out, 5, false))
+	require.Equal(t, "-y -nostdin -ss 5.000 -i 720p.mp4 -frames:v 1 -q:v 2 "+out, strings.Join(fake.Calls()[0], " "))
+}
@@ -0,0 +1,120 @@
+package video
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"video-processing/models"
+)
+
+// Transcoder runs ffmpeg and ffprobe. The pipeline never executes them directly, so its
+// orchestration can be tested with FakeTranscoder on machines without ffmpeg.
+type Transcoder interface {
+	// Run runs ffmpeg with args. Errors carry ffmpeg's output.
+	Run(ctx context.Context, args ...string) error
+	// Stream runs ffmpeg with args and passes its stdout to read while it runs.
+	// Errors of ffmpeg take precedence over errors of read.
+	Stream(ctx context.Context, read func(io.Reader) error, args ...string) error
+	// Probe runs ffprobe with args and returns its stdout
+	Probe(ctx context.Context, args ...string) ([]byte, error)
+}
+
+// ExecTranscoder runs the ffmpeg and ffprobe binaries found in PATH, ffmpeg under the
+// configured limits
+type ExecTranscoder struct {
+	limits models.FFmpegConfig
+	slots  chan struct{} // taken by every running ffmpeg, nil without a process limit
+}
+
+func NewExecTranscoder(limits models.FFmpegConfig) Transcoder {
+	t := ExecTranscoder{limits: limits}
+	if limits.MaxProcesses > 0 {
+		t.slots = make(chan struct{}, limits.MaxProcesses)
+	}
+	return t
+}
+
+// command prepares ffmpeg with args under the limits once a process slot is free. release
+// gives the slot back and must be called after the command has run.
+func (t ExecTranscoder) command(ctx context.Context, args []string) (cmd *exec.Cmd, release func(), err error) {
+	if t.slots != nil {
+		select {
+		case t.slots <- struct{}{}:
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		}
+	}
+	free := func() {
+		if t.slots != nil {
+			<-t.slots
+		}
+	}
+	if t.limits.Nice > 0 {
+		// nice execs ffmpeg in its place, so cancelling still kills ffmpeg
+		cmd = exec.CommandContext(ctx, "nice", append([]string{"-n", strconv.Itoa(t.limits.Nice), "ffmpeg"}, args...)...)
+	} else {
+		cmd = exec.CommandContext(ctx, "ffmpeg", args...)
+	}
+	leave, err := joinCgroup(cmd, t.limits.Cgroup)
+	if err != nil {
+		free()
+		return nil, nil, err
+	}
+	return cmd, func() {
+		leave()
+		free()
+	}, nil
+}
+
+func (t ExecTranscoder) Run(ctx context.Context, args ...string) error {
+	cmd, release, err := t.command(ctx, args)
+	if err != nil {
+		return err
+	}
+	defer release()
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%w, output: %s", err, string(out))
+	}
+	return nil
+}
+
+func (t ExecTranscoder) Stream(ctx context.Context, read func(io.Reader) error, args ...string) error {
+	cmd, release, err := t.command(ctx, args)
+	if err != nil {
+		return err
+	}
+	defer release()
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("failed to open ffmpeg stdout: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start ffmpeg: %w", err)
+	}
+	readErr := read(stdout)
+	if readErr != nil {
+		// drain the pipe so ffmpeg is not blocked writing and Wait can return
+		io.Copy(io.Discard, stdout)
+	}
+	if err := cmd.Wait(); err != nil {
+		return fmt.Errorf("%w, output: %s", err, stderr.String())
+	}
+	return readErr
+}
+
+func (ExecTranscoder) Probe(ctx context.Context, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "ffprobe", args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%w, output: %s", err, stderr.String())
+	}
+	return out, nil
+}
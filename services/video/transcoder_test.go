@@ -0,0 +1,87 @@
+package video
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"path"
+	"sync"
+	"testing"
+	"time"
+	"video-processing/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProcessVariantWithFakeTranscoder(t *testing.T) {
+	fake := NewFakeTranscoder()
+	fake.ProbeOutput = []byte(`{"packets":[{"pts_time":"0.000000","duration_time":"0.040000","pos":"0","flags":"K__"}]}`)
+	rc := &redisConsumer{logger: slog.New(slog.NewTextHandler(io.Discard, nil)), transcoder: fake}
+
+	task := ProcessingTask{
+		Variant:     testLadder.regular[1],
+		WorkDir:     t.TempDir(),
+		SourcePath:  "source.mp4",
+		DestPrefix:  "processed/job",
+		Bucket:      "videos",
+		VideoID:     uuid.NewString(),
+		ThumbnailAt: 5,
+	}
+	results := make(chan ProcessingResult, 1)
+	uploads := make(chan UploadTask, 100)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	rc.processVariant(context.Background(), task, results, uploads, &wg)
+	close(uploads)
+	result := <-results
+	require.True(t, result.Success, "%v", result.Error)
+	require.Positive(t, result.IFrameBandwidth)
+
+	keys := map[string]bool{}
+	for _, f := range result.Files {
+		keys[path.Base(f.ObjectKey)] = true
+	}
+	for f := range uploads {
+		keys[path.Base(f.ObjectKey)] = true
+	}
+	for _, name := range []string{"720p.mp4", "720p-thumb.jpg", "index.m3u8", "segment_000.ts", "iframe.m3u8"} {
+		require.True(t, keys[name], "missing upload %s", name)
+	}
+	// the probe output has no stream, so the codecs of the ladder are assumed
+	require.Equal(t, "avc1.640028", result.Codecs)
+	// transcode, HLS packaging and thumbnail, plus ffprobes of the segment's keyframes and codec
+	require.Len(t, fake.Calls(), 5)
+}
+
+func TestProcessVariantTranscodeFailure(t *testing.T) {
+	fake := NewFakeTranscoder()
+	fake.FailOn = "libx264"
+	rc := &redisConsumer{logger: slog.New(slog.NewTextHandler(io.Discard, nil)), transcoder: fake}
+
+	results := make(chan ProcessingResult, 1)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	rc.processVariant(context.Background(), ProcessingTask{Variant: testLadder.regular[1], WorkDir: t.TempDir()}, results, make(chan UploadTask, 10), &wg)
+	result := <-results
+	require.False(t, result.Success)
+	require.ErrorContains(t, result.Error, "transcode failed")
+	require.Len(t, fake.Calls(), 1)
+}
+
+func TestExecTranscoderLimits(t *testing.T) {
+	transcoder := NewExecTranscoder(models.FFmpegConfig{Nice: 10, MaxProcesses: 1}).(ExecTranscoder)
+	cmd, release, err := transcoder.command(context.Background(), []string{"-version"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"nice", "-n", "10", "ffmpeg", "-version"}, cmd.Args)
+
+	// the second ffmpeg waits for the first one's slot
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, _, err = transcoder.command(ctx, []string{"-version"})
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	release()
+	_, release, err = transcoder.command(context.Background(), []string{"-version"})
+	require.NoError(t, err)
+	release()
+}
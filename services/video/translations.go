@@ -0,0 +1,139 @@
+package video
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"video-processing/database/db"
+	"video-processing/models"
+
+	"github.com/google/uuid"
+	"golang.org/x/text/language"
+)
+
+// SetTranslation sets the title and description of a video of the user in one language
+func (vp *videoProcessor) SetTranslation(ctx context.Context, userID, videoID uuid.UUID, lang string, req models.TranslationRequest) (models.VideoTranslation, error) {
+	params := fmt.Sprintf("userID: %v, videoID: %v, language: %v", userID, videoID, lang)
+	canonical, err := models.CanonicalLanguage(lang)
+	if err == nil {
+		err = req.Validate()
+	}
+	if err != nil {
+		return models.VideoTranslation{}, models.Error{
+			Code:    http.StatusBadRequest,
+			Message: "invalid input data",
+			Params:  params,
+			Err:     err,
+		}
+	}
+	if _, err := vp.getOwnedVideo(ctx, userID, videoID); err != nil {
+		return models.VideoTranslation{}, err
+	}
+	row, err := vp.db.SetVideoTranslation(ctx, db.SetVideoTranslationParams{
+		VideoID:     videoID,
+		Language:    canonical,
+		Title:       req.Title,
+		Description: req.Description,
+	})
+	if err != nil {
+		return models.VideoTranslation{}, models.IndentifyDbError(err).AddParams(params)
+	}
+	return translationFromRow(row), nil
+}
+
+// DeleteTranslation deletes the translation of a video of the user in one language
+func (vp *videoProcessor) DeleteTranslation(ctx context.Context, userID, videoID uuid.UUID, lang string) error {
+	params := fmt.Sprintf("userID: %v, videoID: %v, language: %v", userID, videoID, lang)
+	canonical, err := models.CanonicalLanguage(lang)
+	if err != nil {
+		return models.Error{
+			Code:    http.StatusBadRequest,
+			Message: "invalid input data",
+			Params:  params,
+			Err:     err,
+		}
+	}
+	if _, err := vp.getOwnedVideo(ctx, userID, videoID); err != nil {
+		return err
+	}
+	n, err := vp.db.DeleteVideoTranslation(ctx, db.DeleteVideoTranslationParams{VideoID: videoID, Language: canonical})
+	if err != nil {
+		return models.IndentifyDbError(err).AddParams(params)
+	}
+	if n == 0 {
+		return models.Error{
+			Code:    http.StatusNotFound,
+			Message: "resource not found",
+			Params:  params,
+			Err:     models.ErrResourceNotFound,
+		}
+	}
+	return nil
+}
+
+// ListTranslations lists the translations of a video the user can see
+func (vp *videoProcessor) ListTranslations(ctx context.Context, userID, videoID uuid.UUID) ([]models.VideoTranslation, error) {
+	if _, err := vp.getVisibleVideo(ctx, userID, videoID); err != nil {
+		return nil, err
+	}
+	rows, err := vp.db.ListVideoTranslations(ctx, videoID)
+	if err != nil {
+		return nil, models.IndentifyDbError(err).AddParams(fmt.Sprintf("videoID: %v", videoID))
+	}
+	translations := make([]models.VideoTranslation, 0, len(rows))
+	for _, row := range rows {
+		translations = append(translations, translationFromRow(row))
+	}
+	return translations, nil
+}
+
+// translationsFor returns, by video, the translation that best matches the preferred
+// languages. Videos without a matching translation are left out.
+func (vp *videoProcessor) translationsFor(ctx context.Context, languages []string, videoIDs []uuid.UUID) (map[uuid.UUID]db.VideoTranslation, error) {
+	if len(languages) == 0 || len(videoIDs) == 0 {
+		return nil, nil
+	}
+	rows, err := vp.db.ListTranslationsOfVideos(ctx, videoIDs)
+	if err != nil {
+		return nil, models.IndentifyDbError(err).AddParams(fmt.Sprintf("videoIDs: %v", videoIDs))
+	}
+	byVideo := map[uuid.UUID][]db.VideoTranslation{}
+	for _, row := range rows {
+		byVideo[row.VideoID] = append(byVideo[row.VideoID], row)
+	}
+	matches := make(map[uuid.UUID]db.VideoTranslation, len(byVideo))
+	for videoID, translations := range byVideo {
+		if t, ok := matchTranslation(languages, translations); ok {
+			matches[videoID] = t
+		}
+	}
+	return matches, nil
+}
+
+// matchTranslation picks the translation closest to the preferred languages, so that a
+// pt-BR translation serves pt and en serves en-GB
+func matchTranslation(languages []string, translations []db.VideoTranslation) (db.VideoTranslation, bool) {
+	supported := make([]language.Tag, 0, len(translations))
+	for _, t := range translations {
+		supported = append(supported, language.Make(t.Language))
+	}
+	preferred := make([]language.Tag, 0, len(languages))
+	for _, l := range languages {
+		preferred = append(preferred, language.Make(l))
+	}
+	_, index, confidence := language.NewMatcher(supported).Match(preferred...)
+	if confidence == language.No {
+		return db.VideoTranslation{}, false
+	}
+	return translations[index], true
+}
+
+func translationFromRow(row db.VideoTranslation) models.VideoTranslation {
+	return models.VideoTranslation{
+		VideoID:     row.VideoID,
+		Language:    row.Language,
+		Title:       row.Title,
+		Description: row.Description,
+		UpdatedAt:   row.UpdatedAt,
+	}
+}
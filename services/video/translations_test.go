@@ -0,0 +1,79 @@
+package video
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"video-processing/database/db"
+	"video-processing/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestMatchTranslation(t *testing.T) {
+	translations := []db.VideoTranslation{{Language: "de"}, {Language: "pt-BR"}, {Language: "en"}}
+	match := func(languages ...string) string {
+		t, ok := matchTranslation(languages, translations)
+		if !ok {
+			return ""
+		}
+		return t.Language
+	}
+
+	require.Equal(t, "de", match("de-AT"))
+	require.Equal(t, "pt-BR", match("pt"))
+	require.Equal(t, "en", match("en-GB", "de"))
+	require.Equal(t, "de", match("fr", "de"))
+	require.Empty(t, match("ja"))
+}
+
+func TestSetTranslation(t *testing.T) {
+	vp, repo := newSubscriptionsProcessor(t)
+	owner, videoID := uuid.New(), uuid.New()
+	var e models.Error
+
+	_, err := vp.SetTranslation(context.Background(), owner, videoID, "not a language", models.TranslationRequest{Title: "Titel"})
+	require.ErrorAs(t, err, &e)
+	require.Equal(t, http.StatusBadRequest, e.Code)
+
+	// tags are stored in their canonical form
+	repo.EXPECT().GetVideo(gomock.Any(), videoID).Return(db.Video{ID: videoID, UserID: owner}, nil)
+	repo.EXPECT().
+		SetVideoTranslation(gomock.Any(), db.SetVideoTranslationParams{VideoID: videoID, Language: "pt-BR", Title: "Título"}).
+		Return(db.VideoTranslation{VideoID: videoID, Language: "pt-BR", Title: "Título"}, nil)
+	translation, err := vp.SetTranslation(context.Background(), owner, videoID, "pt-br", models.TranslationRequest{Title: "Título"})
+	require.NoError(t, err)
+	require.Equal(t, "pt-BR", translation.Language)
+
+	repo.EXPECT().GetVideo(gomock.Any(), videoID).Return(db.Video{ID: videoID, UserID: owner}, nil)
+	repo.EXPECT().DeleteVideoTranslation(gomock.Any(), db.DeleteVideoTranslationParams{VideoID: videoID, Language: "fr"}).Return(int64(0), nil)
+	err = vp.DeleteTranslation(context.Background(), owner, videoID, "fr")
+	require.ErrorAs(t, err, &e)
+	require.Equal(t, http.StatusNotFound, e.Code)
+}
+
+func TestListVideosLocalized(t *testing.T) {
+	vp, repo := newSubscriptionsProcessor(t)
+	owner, translated, original := uuid.New(), uuid.New(), uuid.New()
+
+	repo.EXPECT().ListUserVideos(gomock.Any(), gomock.Any()).Return([]db.ListUserVideosRow{
+		{ID: translated, Title: "clip", Description: "a clip"},
+		{ID: original, Title: "other"},
+	}, nil)
+	repo.EXPECT().
+		ListTranslationsOfVideos(gomock.Any(), []uuid.UUID{translated, original}).
+		Return([]db.VideoTranslation{{VideoID: translated, Language: "fr", Title: "extrait", Description: "un extrait"}}, nil)
+	videos, err := vp.ListVideos(context.Background(), owner, models.ListVideosQuery{Limit: 20, Languages: []string{"fr-CA", "en"}})
+	require.NoError(t, err)
+	require.Equal(t, models.VideoSummary{ID: translated, Title: "extrait", Description: "un extrait", Language: "fr"}, videos[0])
+	require.Equal(t, "other", videos[1].Title)
+	require.Empty(t, videos[1].Language)
+
+	// without Accept-Language no translations are loaded
+	repo.EXPECT().ListUserVideos(gomock.Any(), gomock.Any()).Return([]db.ListUserVideosRow{{ID: translated, Title: "clip"}}, nil)
+	videos, err = vp.ListVideos(context.Background(), owner, models.ListVideosQuery{Limit: 20})
+	require.NoError(t, err)
+	require.Equal(t, "clip", videos[0].Title)
+}
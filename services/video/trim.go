@@ -0,0 +1,114 @@
+package video
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strconv"
+)
+
+// trimRange is the range of the source the uploader kept, from the job's trim_start and
+// trim_end. ok is false when the whole source is kept.
+func trimRange(values map[string]interface{}) (r chunkRange, ok bool) {
+	seconds := func(key string) float64 {
+		s, _ := values[key].(string)
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil || f < 0 {
+			return 0
+		}
+		return f
+	}
+	start, end := seconds("trim_start"), seconds("trim_end")
+	if end != 0 && end <= start {
+		end = 0
+	}
+	if start == 0 && end == 0 {
+		return chunkRange{}, false
+	}
+	r.Start = start
+	if end > 0 {
+		r.Duration = end - start
+	}
+	return r, true
+}
+
+// trimArgs cuts range r out of sourcePath into a Matroska file that keeps every audio and
+// subtitle track. Cutting between keyframes needs the video re-encoded, see cutVideoArgs. The
+// audio and subtitles are copied, see copyTracksArgs.
+func trimArgs(probe ProbeResult, sourcePath, outPath string, r chunkRange, hdrFormat string) []string {
+	args := []string{
+		"-y",
+		"-nostdin",
+		"-ss", fmt.Sprintf("%.3f", r.Start),
+	}
+	if r.Duration > 0 {
+		args = append(args, "-t", fmt.Sprintf("%.3f", r.Duration))
+	}
+	args = append(args,
+		"-i", sourcePath,
+		"-map", "0:V:0",
+		"-map", "0:a?",
+		"-map", "0:s?",
+	)
+	args = append(args, cutVideoArgs(probe, hdrFormat)...)
+	args = append(args, copyTracksArgs(probe)...)
+	return append(args, outPath)
+}
+
+// copyTracksArgs copy the audio and subtitle tracks of the source into a Matroska file, except
+// mov_text subtitles which Matroska cannot carry and are converted to SubRip
+func copyTracksArgs(probe ProbeResult) []string {
+	args := []string{"-c:a", "copy", "-c:s", "copy"}
+	track := 0
+	for _, s := range probe.Streams {
+		if s.CodecType != "subtitle" {
+			continue
+		}
+		if s.CodecName == "mov_text" {
+			args = append(args, fmt.Sprintf("-c:s:%d", track), "srt")
+		}
+		track++
+	}
+	return args
+}
+
+// cutVideoArgs encode the video of a cut that becomes the source of the ladder, close to
+// lossless. HDR sources stay 10-bit HEVC with their HDR signal. Interlaced sources stay
+// interlaced, so the ladder still finds them so and deinterlaces them.
+func cutVideoArgs(probe ProbeResult, hdrFormat string) []string {
+	if hdrFormat == "" {
+		args := []string{"-c:v", "libx264", "-crf", "18", "-preset", "fast"}
+		if stream, ok := probe.VideoStream(); ok {
+			if interlaced, _ := stream.Interlaced(); interlaced {
+				args = append(args, "-flags", "+ildct+ilme")
+			}
+		}
+		return args
+	}
+	args := []string{"-c:v", "libx265", "-crf", "18", "-preset", "fast", "-pix_fmt", "yuv420p10le"}
+	return append(args, hdrColorArgs(hdrFormat)...)
+}
+
+// trimSource cuts the source down to the range the job keeps and returns the path of the cut,
+// which the rest of the job reads in place of the source. Without a range the source is
+// returned as it is.
+func (rc *redisConsumer) trimSource(ctx context.Context, values map[string]interface{}, sourcePath, workDir string) (string, error) {
+	r, ok := trimRange(values)
+	if !ok {
+		return sourcePath, nil
+	}
+	var hdrFormat string
+	probe, err := probeSource(ctx, rc.transcoder, sourcePath)
+	if err != nil {
+		rc.logger.Warn("source probe failed, trimming as SDR", "error", err, "videoID", values["video_id"])
+	} else if stream, ok := probe.VideoStream(); ok {
+		hdrFormat = stream.HDRFormat()
+	}
+
+	rc.logger.Info("trimming source", "videoID", values["video_id"], "start", r.Start, "duration", r.Duration)
+	outPath := filepath.Join(workDir, "trimmed.mkv")
+	if err := rc.transcoder.Run(ctx, trimArgs(probe, sourcePath, outPath, r, hdrFormat)...); err != nil {
+		return "", fmt.Errorf("ffmpeg trim error: %w", err)
+	}
+	return outPath, nil
+}
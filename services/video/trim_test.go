@@ -0,0 +1,73 @@
+package video
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrimRange(t *testing.T) {
+	_, ok := trimRange(map[string]interface{}{})
+	require.False(t, ok)
+	_, ok = trimRange(map[string]interface{}{"trim_start": "0", "trim_end": "0"})
+	require.False(t, ok)
+
+	r, ok := trimRange(map[string]interface{}{"trim_start": "12.5", "trim_end": "70"})
+	require.True(t, ok)
+	require.Equal(t, chunkRange{Start: 12.5, Duration: 57.5}, r)
+
+	// only the start, or an end before the start, keeps the source to its end
+	r, ok = trimRange(map[string]interface{}{"trim_start": "8", "trim_end": "0"})
+	require.True(t, ok)
+	require.Equal(t, chunkRange{Start: 8}, r)
+	r, ok = trimRange(map[string]interface{}{"trim_start": "8", "trim_end": "4"})
+	require.True(t, ok)
+	require.Equal(t, chunkRange{Start: 8}, r)
+}
+
+func TestTrimArgs(t *testing.T) {
+	probe := ProbeResult{Streams: []ProbeStream{
+		{CodecType: "video", CodecName: "h264"},
+		{CodecType: "subtitle", CodecName: "subrip"},
+		{CodecType: "subtitle", CodecName: "mov_text"},
+	}}
+	args := strings.Join(trimArgs(probe, "source.mp4", "trimmed.mkv", chunkRange{Start: 5, Duration: 30}, ""), " ")
+	require.Equal(t, "-y -nostdin -ss 5.000 -t 30.000 -i source.mp4 -map 0:V:0 -map 0:a? -map 0:s? "+
+		"-c:v libx264 -crf 18 -preset fast -c:a copy -c:s copy -c:s:1 srt trimmed.mkv", args)
+
+	// HDR sources keep their signal
+	args = strings.Join(trimArgs(ProbeResult{}, "source.mkv", "trimmed.mkv", chunkRange{Start: 5}, HDRFormatHLG), " ")
+	require.NotContains(t, args, "-t ")
+	require.Contains(t, args, "-c:v libx265 -crf 18 -preset fast -pix_fmt yuv420p10le -color_primaries bt2020 -color_trc arib-std-b67")
+
+	// interlaced sources stay interlaced for the ladder to deinterlace
+	interlaced := ProbeResult{Streams: []ProbeStream{{CodecType: "video", CodecName: "mpeg2video", FieldOrder: "tt"}}}
+	args = strings.Join(trimArgs(interlaced, "source.ts", "trimmed.mkv", chunkRange{Start: 5}, ""), " ")
+	require.Contains(t, args, "-c:v libx264 -crf 18 -preset fast -flags +ildct+ilme")
+}
+
+func TestTrimSource(t *testing.T) {
+	fake := NewFakeTranscoder()
+	rc := &redisConsumer{logger: slog.New(slog.NewTextHandler(io.Discard, nil)), transcoder: fake}
+	workDir := t.TempDir()
+
+	sourcePath, err := rc.trimSource(context.Background(), map[string]interface{}{}, "source.mp4", workDir)
+	require.NoError(t, err)
+	require.Equal(t, "source.mp4", sourcePath)
+	require.Empty(t, fake.Calls())
+
+	// the rest of the job reads the cut
+	fake.ProbeOutput = []byte(`{"streams":[{"codec_type":"video","codec_name":"h264"}]}`)
+	sourcePath, err = rc.trimSource(context.Background(), map[string]interface{}{"trim_start": "3", "trim_end": "9"}, "source.mp4", workDir)
+	require.NoError(t, err)
+	require.Equal(t, path.Join(workDir, "trimmed.mkv"), sourcePath)
+
+	fake.FailOn = "trimmed.mkv"
+	_, err = rc.trimSource(context.Background(), map[string]interface{}{"trim_start": "3"}, "source.mp4", workDir)
+	require.Error(t, err)
+}
@@ -0,0 +1,78 @@
+package video
+
+import (
+	"context"
+	"slices"
+	"video-processing/database/db"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// fitsSource reports whether variant v can be encoded from a width x height source without
+// upscaling. The ladder is landscape, so portrait sources are compared side by side, long with
+// long. Vertical variants crop the full height of a landscape source.
+func fitsSource(v Variant, width, height int) bool {
+	if v.Vertical {
+		return v.Height <= height
+	}
+	long, short := max(v.Width, v.Height), min(v.Width, v.Height)
+	return long <= max(width, height) && short <= min(width, height)
+}
+
+// orientLadder turns the regular variants of a landscape ladder upright for a portrait source
+// of the given display size, so a 1280x720 variant becomes 720x1280 instead of squashing the
+// picture. The vertical variants crop landscape sources only and keep their size.
+func orientLadder(variants []Variant, width, height int) []Variant {
+	if height <= width {
+		return variants
+	}
+	oriented := make([]Variant, len(variants))
+	for i, v := range variants {
+		if !v.Vertical && v.Width > v.Height {
+			v.Width, v.Height = v.Height, v.Width
+		}
+		oriented[i] = v
+	}
+	return oriented
+}
+
+// withoutUpscaling drops the variants larger than a width x height source and returns the
+// names of those dropped. Sources smaller than every regular variant keep the smallest one, so
+// the video still gets a rendition. An unknown size keeps them all.
+func withoutUpscaling(variants []Variant, width, height int) ([]Variant, []string) {
+	if width <= 0 || height <= 0 {
+		return variants, nil
+	}
+	kept := make([]Variant, 0, len(variants))
+	var skipped []string
+	smallest, regular := -1, false
+	for i, v := range variants {
+		if fitsSource(v, width, height) {
+			kept = append(kept, v)
+			regular = regular || !v.Vertical && !v.HDR
+			continue
+		}
+		skipped = append(skipped, v.Name)
+		if !v.Vertical && !v.HDR && (smallest < 0 || v.Height < variants[smallest].Height) {
+			smallest = i
+		}
+	}
+	if !regular && smallest >= 0 {
+		kept = append(kept, variants[smallest])
+		skipped = slices.DeleteFunc(skipped, func(name string) bool { return name == variants[smallest].Name })
+	}
+	return kept, skipped
+}
+
+// saveSourceResolution stores the resolution of the source video stream
+func (rc *redisConsumer) saveSourceResolution(ctx context.Context, videoID uuid.UUID, stream ProbeStream) {
+	err := rc.db.UpdateVideoSourceResolution(ctx, db.UpdateVideoSourceResolutionParams{
+		SourceWidth:  pgtype.Int4{Int32: int32(stream.Width), Valid: stream.Width > 0},
+		SourceHeight: pgtype.Int4{Int32: int32(stream.Height), Valid: stream.Height > 0},
+		ID:           videoID,
+	})
+	if err != nil {
+		rc.logger.Error("failed to save source resolution", "error", err, "videoID", videoID)
+	}
+}
@@ -0,0 +1,49 @@
+package video
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithoutUpscaling(t *testing.T) {
+	all := append(append(append([]Variant{}, testLadder.regular...), testLadder.hdr...), testLadder.vertical...)
+	names := func(variants []Variant) []string {
+		var out []string
+		for _, v := range variants {
+			out = append(out, v.Name)
+		}
+		return out
+	}
+	testCases := []struct {
+		name          string
+		width, height int
+		want          []string
+	}{
+		{name: "4k keeps everything", width: 3840, height: 2160, want: names(all)},
+		{name: "unknown size keeps everything", want: names(all)},
+		{name: "480p", width: 854, height: 480, want: []string{"480p", "360p", "240p", "144p"}},
+		{name: "720p crops vertical variants from its height", width: 1280, height: 720, want: []string{"720p", "480p", "360p", "240p", "144p"}},
+		{name: "1080p", width: 1920, height: 1080, want: []string{"1080p", "720p", "480p", "360p", "240p", "144p", "1080p-hdr", "480p-vertical"}},
+		{name: "portrait compared side by side", width: 720, height: 1280, want: []string{"720p", "480p", "360p", "240p", "144p", "720p-vertical", "480p-vertical"}},
+		{name: "tiny source keeps the smallest", width: 160, height: 120, want: []string{"144p"}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			kept, skipped := withoutUpscaling(all, tc.width, tc.height)
+			require.Equal(t, tc.want, names(kept))
+			require.Len(t, skipped, len(all)-len(kept))
+		})
+	}
+}
+
+func TestOrientLadder(t *testing.T) {
+	variants := []Variant{testLadder.regular[1], testLadder.vertical[0]}
+	require.Equal(t, variants, orientLadder(variants, 1920, 1080))
+
+	oriented := orientLadder(variants, 1080, 1920)
+	require.Equal(t, []int{720, 1280}, []int{oriented[0].Width, oriented[0].Height})
+	require.Equal(t, testLadder.vertical[0], oriented[1])
+	// the ladder of other videos is left alone
+	require.Equal(t, 1280, variants[0].Width)
+}
@@ -0,0 +1,299 @@
+package video
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+	"video-processing/database/db"
+	"video-processing/models"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/minio/minio-go/v7"
+)
+
+const (
+	// usageEmitInterval is how often instances look for closed months to post
+	usageEmitInterval = 5 * time.Minute
+	// usageEmitLease is how long an instance holds the rows it is posting
+	usageEmitLease = 5 * time.Minute
+	// usageEmitBatch is how many records one post carries
+	usageEmitBatch = 500
+	// defaultBillingTimeout bounds posts to the billing webhook when the config leaves it unset
+	defaultBillingTimeout = 30 * time.Second
+)
+
+// GetUsage lists the monthly usage of the user, oldest month first. Months without usage are
+// left out.
+func (vp *videoProcessor) GetUsage(ctx context.Context, userID uuid.UUID, query models.UsageQuery) ([]models.UsageMonth, error) {
+	params := fmt.Sprintf("userID: %v, from: %v, to: %v", userID, query.From, query.To)
+	to, err := parseUsageMonth(query.To, currentMonth())
+	if err != nil {
+		return nil, usageInputError(params, err)
+	}
+	from, err := parseUsageMonth(query.From, to.AddDate(0, -11, 0))
+	if err != nil {
+		return nil, usageInputError(params, err)
+	}
+	if from.After(to) {
+		return nil, usageInputError(params, errors.New("from is after to"))
+	}
+	rows, err := vp.db.ListUserUsage(ctx, db.ListUserUsageParams{
+		UserID:    userID,
+		FromMonth: pgtype.Date{Time: from, Valid: true},
+		ToMonth:   pgtype.Date{Time: to, Valid: true},
+	})
+	if err != nil {
+		return nil, models.IndentifyDbError(err).AddParams(params)
+	}
+	return usageFromRows(rows), nil
+}
+
+// ExportUsage lists the usage of every user in a month, the previous month when empty
+func (vp *videoProcessor) ExportUsage(ctx context.Context, month string) ([]models.UsageMonth, error) {
+	params := fmt.Sprintf("month: %v", month)
+	start, err := parseUsageMonth(month, currentMonth().AddDate(0, -1, 0))
+	if err != nil {
+		return nil, usageInputError(params, err)
+	}
+	rows, err := vp.db.ListMonthUsage(ctx, pgtype.Date{Time: start, Valid: true})
+	if err != nil {
+		return nil, models.IndentifyDbError(err).AddParams(params)
+	}
+	return usageFromRows(rows), nil
+}
+
+func usageInputError(params string, err error) error {
+	return models.Error{
+		Code:    http.StatusBadRequest,
+		Message: "invalid input data",
+		Params:  params,
+		Err:     errors.Join(err, models.ErrInvalidInputData),
+	}
+}
+
+// parseUsageMonth parses a YYYY-MM month, or returns fallback when it is empty
+func parseUsageMonth(month string, fallback time.Time) (time.Time, error) {
+	if month == "" {
+		return fallback, nil
+	}
+	t, err := time.Parse(models.UsageMonthLayout, month)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid month %q, want YYYY-MM", month)
+	}
+	return t, nil
+}
+
+// currentMonth returns the first day of the current month in UTC, which usage is recorded in
+func currentMonth() time.Time {
+	now := time.Now().UTC()
+	return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+func usageFromRows(rows []db.UsageMonthly) []models.UsageMonth {
+	usage := make([]models.UsageMonth, 0, len(rows))
+	for _, row := range rows {
+		usage = append(usage, models.UsageMonth{
+			UserID:             row.UserID,
+			Month:              row.Month.Time.Format(models.UsageMonthLayout),
+			TranscodingMinutes: float64(row.TranscodeMs) / float64(time.Minute/time.Millisecond),
+			StorageBytes:       row.StorageBytes,
+			DeliveryBytes:      row.DeliveryBytes,
+		})
+	}
+	return usage
+}
+
+// recordUsage adds usage to the current month of a user. Metering never fails what it meters,
+// so errors are only logged.
+func recordUsage(ctx context.Context, logger *slog.Logger, repo VideoRepo, usage db.RecordUsageParams) {
+	if err := repo.RecordUsage(ctx, usage); err != nil {
+		logger.Error("failed to record usage", "error", err, "userID", usage.UserID,
+			"transcode_ms", usage.TranscodeMs, "delivery_bytes", usage.DeliveryBytes)
+	}
+}
+
+// recordStorage raises the storage of the current month of a user to the bytes they hold now.
+// Storage is billed at its peak rather than added up, so rewritten and deleted files are not
+// paid for twice. Like recordUsage, it only logs errors.
+func recordStorage(ctx context.Context, logger *slog.Logger, repo VideoRepo, userID uuid.UUID) {
+	if err := repo.RecordStorageHeld(ctx, userID); err != nil {
+		logger.Error("failed to record storage", "error", err, "userID", userID)
+	}
+}
+
+// meterProcessing records the transcoding time and the results of a processing run for the
+// owner of the job: the source duration for each variant encoded, and the bytes under the
+// results prefix, which count towards the storage of the owner until the run is deleted
+func (rc *redisConsumer) meterProcessing(ctx context.Context, values map[string]interface{}, bucket, resultsPrefix string, duration float64, variants int) {
+	owner, err := uuid.Parse(fmt.Sprint(values["user_id"]))
+	if err != nil {
+		rc.logger.Warn("job has no owner, usage not recorded", "videoID", values["video_id"])
+		return
+	}
+	recordUsage(ctx, rc.logger, rc.db, db.RecordUsageParams{
+		UserID:      owner,
+		TranscodeMs: int64(duration*1000) * int64(variants),
+	})
+	videoID, err := uuid.Parse(fmt.Sprint(values["video_id"]))
+	if err != nil {
+		return
+	}
+	var stored int64
+	for object := range rc.mc.ListObjects(ctx, bucket, minio.ListObjectsOptions{Prefix: resultsPrefix, Recursive: true}) {
+		if object.Err != nil {
+			rc.logger.Error("failed to measure processing results", "error", object.Err, "bucket", bucket, "prefix", resultsPrefix)
+			break
+		}
+		stored += object.Size
+	}
+	// keyed like the prefixes removeVersion deletes
+	prefix := strings.TrimSuffix(resultsPrefix, "/") + "/"
+	if err := rc.db.SetResultSize(ctx, db.SetResultSizeParams{VideoID: videoID, Prefix: prefix, Bytes: stored}); err != nil {
+		rc.logger.Error("failed to record the size of processing results", "error", err, "videoID", videoID)
+		return
+	}
+	recordStorage(ctx, rc.logger, rc.db, owner)
+}
+
+// meteredReader counts the bytes read from a download and records them as delivery of the
+// owner of the video once it is closed
+type meteredReader struct {
+	io.ReadCloser
+	read   int64
+	record func(read int64)
+	once   sync.Once
+}
+
+func (r *meteredReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.read += int64(n)
+	return n, err
+}
+
+func (r *meteredReader) Close() error {
+	r.once.Do(func() { r.record(r.read) })
+	return r.ReadCloser.Close()
+}
+
+// meterDelivery records the bytes read from body as delivery of owner. The download may be
+// cut short by the client, so the record is written without the request context.
+func (vp *videoProcessor) meterDelivery(ctx context.Context, owner uuid.UUID, body io.ReadCloser) io.ReadCloser {
+	return &meteredReader{ReadCloser: body, record: func(read int64) {
+		if read > 0 {
+			recordUsage(context.WithoutCancel(ctx), vp.logger, vp.db, db.RecordUsageParams{UserID: owner, DeliveryBytes: read})
+		}
+	}}
+}
+
+// RunUsageEmitter snapshots the storage every user holds into the current month and posts the
+// usage of closed months to the billing webhook, when one is set, until ctx is done. Every
+// instance may run it: snapshots only ever raise the month's storage, an instance leases the
+// rows it posts, and the rows of a failed post are posted again with the same idempotency keys
+// once the lease expires.
+func (vp *videoProcessor) RunUsageEmitter(ctx context.Context, config models.BillingConfig) error {
+	timeout := config.Timeout
+	if timeout <= 0 {
+		timeout = defaultBillingTimeout
+	}
+	client := &http.Client{Timeout: timeout}
+	ticker := time.NewTicker(usageEmitInterval)
+	defer ticker.Stop()
+	for {
+		// months without uploads or processing are billed the storage that stays held
+		if err := vp.db.SnapshotStorageHeld(ctx); err != nil && ctx.Err() == nil {
+			vp.logger.Error("failed to snapshot storage", "error", err)
+		}
+		if config.WebhookURL != "" {
+			vp.emitUsage(ctx, client, config)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// emitUsage posts the unposted records of closed months, one batch at a time
+func (vp *videoProcessor) emitUsage(ctx context.Context, client *http.Client, config models.BillingConfig) {
+	for {
+		rows, err := vp.db.ClaimUsageToEmit(ctx, db.ClaimUsageToEmitParams{
+			LeaseUntil: time.Now().Add(usageEmitLease),
+			MaxRows:    usageEmitBatch,
+		})
+		if err != nil {
+			if ctx.Err() == nil {
+				vp.logger.Error("failed to claim usage to emit", "error", err)
+			}
+			return
+		}
+		if len(rows) == 0 {
+			return
+		}
+		report := models.UsageReport{SentAt: time.Now().UTC()}
+		emitted := db.MarkUsageEmittedParams{}
+		for _, usage := range usageFromRows(rows) {
+			report.Records = append(report.Records, models.UsageRecord{
+				UsageMonth:     usage,
+				IdempotencyKey: usage.UserID.String() + ":" + usage.Month,
+			})
+		}
+		for _, row := range rows {
+			emitted.UserIds = append(emitted.UserIds, row.UserID)
+			emitted.Months = append(emitted.Months, row.Month)
+		}
+		if err := postUsage(ctx, client, config, report); err != nil {
+			vp.logger.Error("failed to post usage to the billing webhook", "error", err, "records", len(rows))
+			return
+		}
+		if err := vp.db.MarkUsageEmitted(ctx, emitted); err != nil {
+			// the records are posted again once their lease expires
+			vp.logger.Error("failed to mark usage emitted", "error", err, "records", len(rows))
+			return
+		}
+		vp.logger.Info("usage posted to the billing webhook", "records", len(rows))
+		if len(rows) < usageEmitBatch {
+			return
+		}
+	}
+}
+
+// postUsage posts a report as JSON, signed when a secret is set. Any status other than 2xx
+// fails the post.
+func postUsage(ctx context.Context, client *http.Client, config models.BillingConfig, report models.UsageReport) error {
+	payload, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, config.WebhookURL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if config.Secret != "" {
+		mac := hmac.New(sha256.New, []byte(config.Secret))
+		mac.Write(payload)
+		req.Header.Set("X-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("billing webhook answered %s", resp.Status)
+	}
+	return nil
+}
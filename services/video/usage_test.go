@@ -0,0 +1,183 @@
+package video
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"video-processing/database/db"
+	"video-processing/mocks"
+	"video-processing/models"
+	"video-processing/storage"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func usageMonth(t *testing.T, month string) pgtype.Date {
+	start, err := time.Parse(models.UsageMonthLayout, month)
+	require.NoError(t, err)
+	return pgtype.Date{Time: start, Valid: true}
+}
+
+func TestGetUsage(t *testing.T) {
+	vp, repo := newSubscriptionsProcessor(t)
+	userID := uuid.New()
+	now := currentMonth()
+
+	// the last 12 months by default
+	repo.EXPECT().ListUserUsage(gomock.Any(), db.ListUserUsageParams{
+		UserID:    userID,
+		FromMonth: pgtype.Date{Time: now.AddDate(0, -11, 0), Valid: true},
+		ToMonth:   pgtype.Date{Time: now, Valid: true},
+	}).Return([]db.UsageMonthly{{UserID: userID, Month: pgtype.Date{Time: now, Valid: true}, TranscodeMs: 90000, StorageBytes: 10, DeliveryBytes: 20}}, nil)
+	usage, err := vp.GetUsage(context.Background(), userID, models.UsageQuery{})
+	require.NoError(t, err)
+	require.Equal(t, []models.UsageMonth{{
+		UserID:             userID,
+		Month:              now.Format(models.UsageMonthLayout),
+		TranscodingMinutes: 1.5,
+		StorageBytes:       10,
+		DeliveryBytes:      20,
+	}}, usage)
+
+	repo.EXPECT().ListUserUsage(gomock.Any(), db.ListUserUsageParams{
+		UserID:    userID,
+		FromMonth: usageMonth(t, "2025-01"),
+		ToMonth:   usageMonth(t, "2025-03"),
+	}).Return(nil, nil)
+	usage, err = vp.GetUsage(context.Background(), userID, models.UsageQuery{From: "2025-01", To: "2025-03"})
+	require.NoError(t, err)
+	require.Empty(t, usage)
+
+	var e models.Error
+	for _, query := range []models.UsageQuery{{From: "2025-13"}, {To: "March"}, {From: "2025-04", To: "2025-03"}} {
+		_, err = vp.GetUsage(context.Background(), userID, query)
+		require.ErrorAs(t, err, &e, "%+v", query)
+		require.Equal(t, http.StatusBadRequest, e.Code)
+	}
+}
+
+func TestExportUsage(t *testing.T) {
+	vp, repo := newSubscriptionsProcessor(t)
+
+	repo.EXPECT().ListMonthUsage(gomock.Any(), pgtype.Date{Time: currentMonth().AddDate(0, -1, 0), Valid: true}).Return(nil, nil)
+	usage, err := vp.ExportUsage(context.Background(), "")
+	require.NoError(t, err)
+	require.Empty(t, usage)
+
+	repo.EXPECT().ListMonthUsage(gomock.Any(), usageMonth(t, "2025-02")).
+		Return([]db.UsageMonthly{{UserID: uuid.New(), Month: usageMonth(t, "2025-02"), StorageBytes: 5}, {UserID: uuid.New(), Month: usageMonth(t, "2025-02")}}, nil)
+	usage, err = vp.ExportUsage(context.Background(), "2025-02")
+	require.NoError(t, err)
+	require.Len(t, usage, 2)
+	require.Equal(t, "2025-02", usage[0].Month)
+
+	_, err = vp.ExportUsage(context.Background(), "2025-2")
+	var e models.Error
+	require.ErrorAs(t, err, &e)
+	require.Equal(t, http.StatusBadRequest, e.Code)
+}
+
+func TestMeterProcessing(t *testing.T) {
+	store, err := storage.NewLocal(t.TempDir(), "http://localhost:8888")
+	require.NoError(t, err)
+	repo := mocks.NewMockVideoRepo(gomock.NewController(t))
+	rc := &redisConsumer{logger: slog.New(slog.NewTextHandler(io.Discard, nil)), db: repo, mc: store}
+	owner, videoID := uuid.New(), uuid.New()
+	putObjects(t, store, "b", map[string]string{
+		"processed/run/720p/720p.mp4": "12345",
+		"processed/run/master.m3u8":   "123",
+		"processed/other/480p.mp4":    "not this run",
+	})
+
+	// the run's bytes replace what an earlier measure of it recorded, and storage is raised to
+	// what the owner holds rather than added to
+	gomock.InOrder(
+		repo.EXPECT().RecordUsage(gomock.Any(), db.RecordUsageParams{UserID: owner, TranscodeMs: 2 * 12500}),
+		repo.EXPECT().SetResultSize(gomock.Any(), db.SetResultSizeParams{VideoID: videoID, Prefix: "processed/run/", Bytes: 8}),
+		repo.EXPECT().RecordStorageHeld(gomock.Any(), owner),
+	)
+	rc.meterProcessing(context.Background(), map[string]interface{}{"user_id": owner.String(), "video_id": videoID.String()}, "b", "processed/run", 12.5, 2)
+
+	// jobs without an owner are not metered
+	rc.meterProcessing(context.Background(), map[string]interface{}{}, "b", "processed/run/", 12.5, 2)
+}
+
+func TestMeteredReader(t *testing.T) {
+	repo := mocks.NewMockVideoRepo(gomock.NewController(t))
+	vp := &videoProcessor{logger: slog.New(slog.NewTextHandler(io.Discard, nil)), db: repo}
+	owner := uuid.New()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// downloads cut short record what was read, once, after the request is gone
+	repo.EXPECT().RecordUsage(gomock.Any(), db.RecordUsageParams{UserID: owner, DeliveryBytes: 4}).
+		DoAndReturn(func(ctx context.Context, _ db.RecordUsageParams) error {
+			require.NoError(t, ctx.Err())
+			return nil
+		})
+	body := vp.meterDelivery(ctx, owner, io.NopCloser(strings.NewReader("partial read")))
+	_, err := io.ReadFull(body, make([]byte, 4))
+	require.NoError(t, err)
+	cancel()
+	require.NoError(t, body.Close())
+	require.NoError(t, body.Close())
+
+	// nothing read, nothing recorded
+	require.NoError(t, vp.meterDelivery(context.Background(), owner, io.NopCloser(strings.NewReader("unread"))).Close())
+}
+
+func TestEmitUsage(t *testing.T) {
+	repo := mocks.NewMockVideoRepo(gomock.NewController(t))
+	vp := &videoProcessor{logger: slog.New(slog.NewTextHandler(io.Discard, nil)), db: repo}
+	userID := uuid.New()
+	month := usageMonth(t, "2025-02")
+	var reports []models.UsageReport
+	status := http.StatusInternalServerError
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		payload, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		mac := hmac.New(sha256.New, []byte("secret"))
+		mac.Write(payload)
+		require.Equal(t, "sha256="+hex.EncodeToString(mac.Sum(nil)), r.Header.Get("X-Signature"))
+		var report models.UsageReport
+		require.NoError(t, json.Unmarshal(payload, &report))
+		reports = append(reports, report)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+	config := models.BillingConfig{WebhookURL: server.URL, Secret: "secret"}
+	rows := []db.UsageMonthly{{UserID: userID, Month: month, TranscodeMs: 60000, DeliveryBytes: 7}}
+
+	// a failed post leaves the rows to be posted again once their lease expires
+	repo.EXPECT().ClaimUsageToEmit(gomock.Any(), gomock.Any()).Return(rows, nil)
+	vp.emitUsage(context.Background(), server.Client(), config)
+
+	status = http.StatusOK
+	repo.EXPECT().ClaimUsageToEmit(gomock.Any(), gomock.Any()).Return(rows, nil)
+	repo.EXPECT().MarkUsageEmitted(gomock.Any(), db.MarkUsageEmittedParams{UserIds: []uuid.UUID{userID}, Months: []pgtype.Date{month}}).Return(nil)
+	vp.emitUsage(context.Background(), server.Client(), config)
+
+	require.Len(t, reports, 2)
+	require.Equal(t, reports[0].Records, reports[1].Records)
+	require.Equal(t, []models.UsageRecord{{
+		UsageMonth:     models.UsageMonth{UserID: userID, Month: "2025-02", TranscodingMinutes: 1, DeliveryBytes: 7},
+		IdempotencyKey: userID.String() + ":2025-02",
+	}}, reports[1].Records)
+
+	// without a webhook nothing is posted, the storage held is still snapshot
+	repo.EXPECT().SnapshotStorageHeld(gomock.Any()).Return(nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	require.ErrorIs(t, vp.RunUsageEmitter(ctx, models.BillingConfig{}), context.Canceled)
+}
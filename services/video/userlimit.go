@@ -0,0 +1,189 @@
+package video
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// userSlotLease is how long a running job holds its slot without renewing it, so the
+	// slots of crashed workers free up
+	userSlotLease = 2 * time.Minute
+	// defaultUserLimitDelay is how long parked jobs wait when no delay is configured
+	defaultUserLimitDelay = 30 * time.Second
+	// promoteBatch bounds the parked jobs moved back to the stream at once
+	promoteBatch = 100
+)
+
+// acquireSlotScript drops expired leases and leases a slot for the job when the user has one free.
+// KEYS[1] slots of the user; ARGV now, limit, job ID, lease end (all ms but the ID)
+const acquireSlotScript = `
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', ARGV[1])
+if redis.call('ZSCORE', KEYS[1], ARGV[3]) or redis.call('ZCARD', KEYS[1]) < tonumber(ARGV[2]) then
+	redis.call('ZADD', KEYS[1], ARGV[4], ARGV[3])
+	redis.call('PEXPIREAT', KEYS[1], ARGV[4])
+	return 1
+end
+return 0`
+
+// renewSlotScript extends the lease of a running job. KEYS[1] slots of the user; ARGV job ID, lease end
+const renewSlotScript = `
+redis.call('ZADD', KEYS[1], 'XX', ARGV[2], ARGV[1])
+redis.call('PEXPIREAT', KEYS[1], ARGV[2])
+return 1`
+
+// releaseSlotScript frees the slot of a finished job. KEYS[1] slots of the user; ARGV job ID
+const releaseSlotScript = `return redis.call('ZREM', KEYS[1], ARGV[1])`
+
+// parkScript queues a job until it is due. KEYS[1] delayed queue; ARGV due (ms), job as JSON
+const parkScript = `return redis.call('ZADD', KEYS[1], ARGV[1], ARGV[2])`
+
+// promoteScript moves the parked jobs that are due back to the stream.
+// KEYS[1] delayed queue, KEYS[2] stream; ARGV now (ms), batch size
+const promoteScript = `
+local due = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, tonumber(ARGV[2]))
+for _, job in ipairs(due) do
+	redis.call('ZREM', KEYS[1], job)
+	local fields = {}
+	for field, value in pairs(cjson.decode(job)) do
+		table.insert(fields, field)
+		table.insert(fields, value)
+	end
+	redis.call('XADD', KEYS[2], '*', unpack(fields))
+end
+return #due`
+
+// userLimiter caps the jobs of one user running at once across all workers. A running job
+// leases a slot in a sorted set per user; jobs over the limit are parked in a delayed queue,
+// a sorted set scored by when they are due, and moved back to the stream by any worker.
+type userLimiter struct {
+	rc         Broker
+	streamName string
+	max        int
+	delay      time.Duration
+	now        func() time.Time
+}
+
+// newUserLimiter returns nil when max is not positive, which disables the limit
+func newUserLimiter(rc Broker, streamName string, max int, delay time.Duration) *userLimiter {
+	if max <= 0 {
+		return nil
+	}
+	if delay <= 0 {
+		delay = defaultUserLimitDelay
+	}
+	return &userLimiter{rc: rc, streamName: streamName, max: max, delay: delay, now: time.Now}
+}
+
+func (l *userLimiter) slotsKey(user string) string {
+	return fmt.Sprintf("%s:running:%s", l.streamName, user)
+}
+
+func (l *userLimiter) delayedKey() string {
+	return l.streamName + ":delayed"
+}
+
+// acquire leases a slot for the job and reports false when the user has none free
+func (l *userLimiter) acquire(ctx context.Context, user, jobID string) (bool, error) {
+	now := l.now()
+	n, err := l.rc.Eval(ctx, acquireSlotScript, []string{l.slotsKey(user)},
+		now.UnixMilli(), l.max, jobID, now.Add(userSlotLease).UnixMilli()).Int()
+	if err != nil {
+		return false, fmt.Errorf("failed to acquire job slot of user %s: %w", user, err)
+	}
+	return n == 1, nil
+}
+
+// hold renews the lease of the job until the returned function is called, which frees the slot
+func (l *userLimiter) hold(ctx context.Context, user, jobID string) (release func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(userSlotLease / 4)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				l.rc.Eval(ctx, renewSlotScript, []string{l.slotsKey(user)}, jobID, l.now().Add(userSlotLease).UnixMilli())
+			}
+		}
+	}()
+	return func() {
+		cancel()
+		<-done
+		// the job context may be gone by now, the slot must be freed regardless
+		l.rc.Eval(context.WithoutCancel(ctx), releaseSlotScript, []string{l.slotsKey(user)}, jobID)
+	}
+}
+
+// park queues the job to be streamed again once the delay has passed
+func (l *userLimiter) park(ctx context.Context, values map[string]interface{}) error {
+	job, err := json.Marshal(values)
+	if err != nil {
+		return err
+	}
+	err = l.rc.Eval(ctx, parkScript, []string{l.delayedKey()},
+		l.now().Add(l.delay).UnixMilli(), string(job)).Err()
+	if err != nil {
+		return fmt.Errorf("failed to park job: %w", err)
+	}
+	return nil
+}
+
+// promote streams the parked jobs that are due and returns how many it moved
+func (l *userLimiter) promote(ctx context.Context) (int, error) {
+	n, err := l.rc.Eval(ctx, promoteScript, []string{l.delayedKey(), l.streamName}, l.now().UnixMilli(), promoteBatch).Int()
+	if err != nil && err != redis.Nil {
+		return 0, fmt.Errorf("failed to promote parked jobs: %w", err)
+	}
+	return n, nil
+}
+
+// jobUser is the user a job runs for. Jobs queued before jobs carried a user ID fall back
+// to the bucket, which is the user ID for uploads.
+func jobUser(values map[string]interface{}) string {
+	if user, _ := values["user_id"].(string); user != "" {
+		return user
+	}
+	bucket, _ := values["bucket"].(string)
+	if _, err := uuid.Parse(bucket); err == nil {
+		return bucket
+	}
+	return ""
+}
+
+// runJob runs a stream message within the job limit of its user. Jobs over the limit are
+// parked instead; when the limit cannot be checked the job runs anyway.
+func (rc *redisConsumer) runJob(ctx context.Context, message redis.XMessage) {
+	user := jobUser(message.Values)
+	if rc.limits != nil && user != "" {
+		ok, err := rc.limits.acquire(ctx, user, message.ID)
+		switch {
+		case err != nil:
+			rc.logger.Error("running job without user limit", "error", err, "messageID", message.ID, "user", user)
+		case !ok:
+			err := rc.limits.park(ctx, message.Values)
+			if err == nil {
+				rc.logger.Info("user job limit reached, job parked", "messageID", message.ID, "user", user, "delay", rc.limits.delay)
+				return
+			}
+			rc.logger.Error("running job over user limit", "error", err, "messageID", message.ID, "user", user)
+		default:
+			release := rc.limits.hold(ctx, user, message.ID)
+			defer release()
+		}
+	}
+	err := rc.handleJob(ctx, message.Values)
+	if err != nil {
+		rc.logger.Error("Failed to process job", "error", err, "messageID", message.ID)
+	}
+	rc.recordReprocessResult(ctx, message.Values, err)
+}
@@ -0,0 +1,68 @@
+package video
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+	"video-processing/mocks"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestJobUser(t *testing.T) {
+	user := uuid.NewString()
+	require.Equal(t, user, jobUser(map[string]interface{}{"user_id": user, "bucket": "ingest"}))
+	require.Equal(t, user, jobUser(map[string]interface{}{"bucket": user}))
+	require.Empty(t, jobUser(map[string]interface{}{"bucket": "ingest"}))
+}
+
+func TestRunJobParksOverLimit(t *testing.T) {
+	broker := mocks.NewMockBroker(gomock.NewController(t))
+	now := time.UnixMilli(1_700_000_000_000)
+	limits := newUserLimiter(broker, "video_stream", 2, 0)
+	limits.now = func() time.Time { return now }
+	rc := &redisConsumer{logger: slog.New(slog.NewTextHandler(io.Discard, nil)), limits: limits}
+
+	user := uuid.NewString()
+	values := map[string]interface{}{"type": "unknown", "user_id": user, "video_id": "v1"}
+	job, err := json.Marshal(values)
+	require.NoError(t, err)
+
+	// over the limit the job is parked for the default delay and not run
+	broker.EXPECT().
+		Eval(gomock.Any(), acquireSlotScript, []string{"video_stream:running:" + user}, now.UnixMilli(), 2, "1-0", now.Add(userSlotLease).UnixMilli()).
+		Return(redis.NewCmdResult(int64(0), nil))
+	broker.EXPECT().
+		Eval(gomock.Any(), parkScript, []string{"video_stream:delayed"}, now.Add(defaultUserLimitDelay).UnixMilli(), string(job)).
+		Return(redis.NewCmdResult(int64(1), nil))
+	rc.runJob(context.Background(), redis.XMessage{ID: "1-0", Values: values})
+
+	// within the limit the job runs and frees its slot afterwards
+	gomock.InOrder(
+		broker.EXPECT().Eval(gomock.Any(), acquireSlotScript, gomock.Any(), gomock.Any()).Return(redis.NewCmdResult(int64(1), nil)),
+		broker.EXPECT().Eval(gomock.Any(), releaseSlotScript, []string{"video_stream:running:" + user}, "2-0").Return(redis.NewCmdResult(int64(1), nil)),
+	)
+	rc.runJob(context.Background(), redis.XMessage{ID: "2-0", Values: values})
+
+	// jobs of unknown users are not limited
+	rc.runJob(context.Background(), redis.XMessage{ID: "3-0", Values: map[string]interface{}{"type": "unknown", "bucket": "ingest"}})
+}
+
+func TestUserLimiterPromote(t *testing.T) {
+	broker := mocks.NewMockBroker(gomock.NewController(t))
+	require.Nil(t, newUserLimiter(broker, "video_stream", 0, time.Minute))
+
+	limits := newUserLimiter(broker, "video_stream", 1, time.Minute)
+	broker.EXPECT().
+		Eval(gomock.Any(), promoteScript, []string{"video_stream:delayed", "video_stream"}, gomock.Any(), promoteBatch).
+		Return(redis.NewCmdResult(int64(3), nil))
+	n, err := limits.promote(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 3, n)
+}
@@ -0,0 +1,83 @@
+package video
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"video-processing/database/db"
+	"video-processing/models"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// unreadableSource is the failure reason of sources ffprobe cannot read at all
+const unreadableSource = "the file is not a video ffmpeg can read"
+
+// validateSource checks that the probed source is a video the pipeline can encode. The error
+// message is the reason the source is rejected, worded for the owner of the video.
+func validateSource(probe ProbeResult, codecs []string) error {
+	stream, ok := probe.VideoStream()
+	if !ok {
+		return errors.New("the file has no video stream")
+	}
+	// ffprobe leaves out the name of codecs it has no descriptor for
+	if stream.CodecName == "" || stream.CodecName == "unknown" {
+		return errors.New("the video codec of the file is not supported")
+	}
+	if len(codecs) > 0 && !slices.Contains(codecs, stream.CodecName) {
+		return fmt.Errorf("the video codec %s is not supported", stream.CodecName)
+	}
+	if probe.Duration() <= 0 {
+		return errors.New("the video has no duration")
+	}
+	return nil
+}
+
+// checkSource probes the source and rejects it unless it is a video the pipeline can encode,
+// before any variant is started. Rejected videos are marked failed with the reason, so their
+// owner learns why instead of every variant failing on an ffmpeg error.
+func (rc *redisConsumer) checkSource(ctx context.Context, videoID, sourcePath string) (ProbeResult, error) {
+	probe, err := probeSource(ctx, rc.transcoder, sourcePath)
+	reason := ""
+	switch {
+	case err != nil && ctx.Err() != nil:
+		return ProbeResult{}, ctx.Err()
+	case err != nil:
+		reason = unreadableSource
+	default:
+		if err = validateSource(probe, rc.processing.SourceCodecs); err != nil {
+			reason = err.Error()
+		}
+	}
+
+	videoUUID, parseErr := uuid.Parse(videoID)
+	if reason == "" {
+		if parseErr == nil {
+			if err := rc.db.ClearVideoFailure(ctx, videoUUID); err != nil {
+				rc.logger.Error("failed to clear video failure", "error", err, "videoID", videoID)
+			}
+		}
+		return probe, nil
+	}
+
+	rc.logger.Warn("source rejected", "error", err, "reason", reason, "videoID", videoID)
+	if parseErr == nil {
+		err := rc.db.FailVideo(ctx, db.FailVideoParams{
+			ID:            videoUUID,
+			FailureReason: pgtype.Text{String: reason, Valid: true},
+		})
+		if err != nil {
+			rc.logger.Error("failed to mark video failed", "error", err, "videoID", videoID)
+		}
+	}
+	return ProbeResult{}, models.Error{
+		Code:        http.StatusUnprocessableEntity,
+		Message:     "invalid source",
+		Description: reason,
+		Params:      fmt.Sprintf("videoID: %v, source: %v", videoID, sourcePath),
+		Err:         err,
+	}
+}
@@ -0,0 +1,72 @@
+package video
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"testing"
+	"video-processing/database/db"
+	"video-processing/mocks"
+	"video-processing/models"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestValidateSource(t *testing.T) {
+	video := func(codec, duration string) ProbeResult {
+		return ProbeResult{
+			Format:  ProbeFormat{Duration: duration},
+			Streams: []ProbeStream{{CodecType: "video", CodecName: codec}},
+		}
+	}
+	require.NoError(t, validateSource(video("h264", "12.5"), nil))
+	require.NoError(t, validateSource(video("hevc", "12.5"), []string{"h264", "hevc"}))
+
+	require.EqualError(t, validateSource(ProbeResult{
+		Format:  ProbeFormat{Duration: "12.5"},
+		Streams: []ProbeStream{{CodecType: "audio", CodecName: "mp3"}},
+	}, nil), "the file has no video stream")
+	require.EqualError(t, validateSource(video("", "12.5"), nil), "the video codec of the file is not supported")
+	require.EqualError(t, validateSource(video("prores", "12.5"), []string{"h264", "hevc"}), "the video codec prores is not supported")
+	require.EqualError(t, validateSource(video("h264", "0.000000"), nil), "the video has no duration")
+	require.EqualError(t, validateSource(video("h264", ""), nil), "the video has no duration")
+}
+
+func TestCheckSource(t *testing.T) {
+	repo := mocks.NewMockVideoRepo(gomock.NewController(t))
+	fake := NewFakeTranscoder()
+	rc := &redisConsumer{logger: slog.New(slog.NewTextHandler(io.Discard, nil)), db: repo, transcoder: fake}
+	videoID := uuid.New()
+	ctx := context.Background()
+
+	fake.ProbeOutput = []byte(sampleProbe)
+	repo.EXPECT().ClearVideoFailure(gomock.Any(), videoID)
+	probe, err := rc.checkSource(ctx, videoID.String(), "source.mp4")
+	require.NoError(t, err)
+	require.Equal(t, 12.5, probe.Duration())
+
+	// a text file, say: ffprobe finds nothing to read
+	fake.ProbeOutput = []byte("not json")
+	repo.EXPECT().FailVideo(gomock.Any(), db.FailVideoParams{
+		ID:            videoID,
+		FailureReason: pgtype.Text{String: unreadableSource, Valid: true},
+	})
+	_, err = rc.checkSource(ctx, videoID.String(), "source.mp4")
+	var appErr models.Error
+	require.True(t, errors.As(err, &appErr))
+	require.Equal(t, http.StatusUnprocessableEntity, appErr.Code)
+	require.Equal(t, unreadableSource, appErr.Description)
+
+	fake.ProbeOutput = []byte(`{"streams":[{"codec_type":"audio","codec_name":"mp3"}],"format":{"duration":"180.0"}}`)
+	repo.EXPECT().FailVideo(gomock.Any(), db.FailVideoParams{
+		ID:            videoID,
+		FailureReason: pgtype.Text{String: "the file has no video stream", Valid: true},
+	})
+	_, err = rc.checkSource(ctx, videoID.String(), "song.mp3")
+	require.Error(t, err)
+}
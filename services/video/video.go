@@ -2,38 +2,171 @@ package video
 
 import (
 	"context"
+	"crypto/cipher"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"math"
+	"mime/multipart"
 	"net/http"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 	"video-processing/database/db"
 	"video-processing/models"
+	"video-processing/storage"
+	"video-processing/utils"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/minio/minio-go/v7"
+	"github.com/redis/go-redis/v9"
 )
 
 type VideoProcessor interface {
 	CreateBucket(ctx context.Context, bucketName string) error
 	ListBuckets(ctx context.Context) ([]minio.BucketInfo, error)
 	Upload(ctx context.Context, userID uuid.UUID, req models.UploadVideoRequest) error
+	ListVideos(ctx context.Context, userID uuid.UUID, query models.ListVideosQuery) ([]models.VideoSummary, error)
+	GetVideo(ctx context.Context, userID, videoID uuid.UUID, languages []string) (models.VideoDetail, error)
+	GetProgress(ctx context.Context, userID, videoID uuid.UUID) (models.JobStatus, error)
+	GetChapters(ctx context.Context, userID, videoID uuid.UUID) ([]models.Chapter, error)
+	SetChapters(ctx context.Context, userID, videoID uuid.UUID, req models.SetChaptersRequest) ([]models.Chapter, error)
+	SetPrimaryThumbnail(ctx context.Context, userID, videoID uuid.UUID, req models.PrimaryThumbnailRequest) (models.VideoDetail, error)
+	EditVideo(ctx context.Context, userID, videoID uuid.UUID, req models.EditRequest) (models.VideoDetail, error)
+	ComposeOverlay(ctx context.Context, userID, videoID uuid.UUID, req models.OverlayRequest) (models.VideoDetail, error)
+	CreateClip(ctx context.Context, userID, videoID uuid.UUID, req models.ClipRequest) (models.VideoDetail, error)
+	CreateAudiogram(ctx context.Context, userID uuid.UUID, req models.AudiogramRequest) (models.VideoDetail, error)
+	CreateSlideshow(ctx context.Context, userID uuid.UUID, req models.SlideshowRequest) (models.VideoDetail, error)
+	ChangeSpeed(ctx context.Context, userID, videoID uuid.UUID, req models.SpeedRequest) (models.VideoDetail, error)
+	SetWatermark(ctx context.Context, userID uuid.UUID, req models.WatermarkRequest) error
+	DeleteWatermark(ctx context.Context, userID uuid.UUID) error
+	SetBumper(ctx context.Context, userID uuid.UUID, req models.BumperRequest) error
+	DeleteBumper(ctx context.Context, userID uuid.UUID, position string) error
+	FindDuplicates(ctx context.Context, query models.DuplicateReportQuery) ([]models.DuplicateMatch, error)
+	QualityReport(ctx context.Context) ([]models.VariantQuality, error)
+	Ingest(ctx context.Context, authToken string, event models.S3Event) (models.IngestResult, error)
+	ListenIngest(ctx context.Context, queue *redis.Client, key string) error
+	ListPresets(ctx context.Context) ([]models.TranscodingPreset, error)
+	GetPreset(ctx context.Context, id uuid.UUID) (models.TranscodingPreset, error)
+	CreatePreset(ctx context.Context, req models.PresetRequest) (models.TranscodingPreset, error)
+	UpdatePreset(ctx context.Context, id uuid.UUID, req models.PresetRequest) (models.TranscodingPreset, error)
+	DeletePreset(ctx context.Context, id uuid.UUID) error
+	SyncPresets(ctx context.Context, presets []models.PresetRequest) error
+	StartReprocess(ctx context.Context, req models.ReprocessRequest) (models.ReprocessRun, error)
+	ListReprocessRuns(ctx context.Context) ([]models.ReprocessRun, error)
+	GetReprocessRun(ctx context.Context, id uuid.UUID) (models.ReprocessRun, error)
+	CancelReprocessRun(ctx context.Context, id uuid.UUID) (models.ReprocessRun, error)
+	RunReprocessing(ctx context.Context) error
+	ProbeVideo(ctx context.Context, userID, videoID uuid.UUID, refresh bool) (models.ProbeReport, error)
+	ExtractFrame(ctx context.Context, userID, videoID uuid.UUID, query models.FrameQuery) (models.VideoFrame, error)
+	BoostJob(ctx context.Context, videoID uuid.UUID) error
+	SavePosition(ctx context.Context, userID, videoID uuid.UUID, req models.WatchPositionRequest) (models.WatchPosition, error)
+	GetPosition(ctx context.Context, userID, videoID uuid.UUID) (models.WatchPosition, error)
+	ListHistory(ctx context.Context, userID uuid.UUID, query models.ListVideosQuery) ([]models.WatchHistoryEntry, error)
+	DeleteHistoryEntry(ctx context.Context, userID, videoID uuid.UUID) error
+	ClearHistory(ctx context.Context, userID uuid.UUID) error
+	SetVisibility(ctx context.Context, userID, videoID uuid.UUID, req models.SetVisibilityRequest) (models.VideoDetail, error)
+	Subscribe(ctx context.Context, userID, creatorID uuid.UUID) error
+	Unsubscribe(ctx context.Context, userID, creatorID uuid.UUID) error
+	ListSubscriptions(ctx context.Context, userID uuid.UUID) ([]models.Subscription, error)
+	Feed(ctx context.Context, userID uuid.UUID, query models.ListVideosQuery) ([]models.FeedItem, error)
+	ListNotifications(ctx context.Context, userID uuid.UUID, query models.ListVideosQuery) ([]models.Notification, error)
+	MarkNotificationsRead(ctx context.Context, userID uuid.UUID) error
+	ReportVideo(ctx context.Context, userID, videoID uuid.UUID, req models.ReportRequest) (models.VideoReport, error)
+	ListReports(ctx context.Context, query models.ReportQuery) ([]models.VideoReport, error)
+	ModerateReport(ctx context.Context, moderatorID, reportID uuid.UUID, req models.ModerationRequest) (models.VideoReport, error)
+	SetTranslation(ctx context.Context, userID, videoID uuid.UUID, lang string, req models.TranslationRequest) (models.VideoTranslation, error)
+	DeleteTranslation(ctx context.Context, userID, videoID uuid.UUID, lang string) error
+	ListTranslations(ctx context.Context, userID, videoID uuid.UUID) ([]models.VideoTranslation, error)
+	SetPlaybackPassword(ctx context.Context, userID, videoID uuid.UUID, req models.PlaybackPasswordRequest) (models.VideoDetail, error)
+	RemovePlaybackPassword(ctx context.Context, userID, videoID uuid.UUID) (models.VideoDetail, error)
+	AuthorizePlayback(ctx context.Context, videoID uuid.UUID, client string, req models.PlaybackRequest) (models.PlaybackToken, error)
+	Playback(ctx context.Context, videoID uuid.UUID, token string) (models.Playback, error)
+	GetVideoKey(ctx context.Context, userID, videoID uuid.UUID, token string) ([]byte, error)
+	Download(ctx context.Context, userID, videoID uuid.UUID, variant, format string) (models.Download, error)
+	SetSchedule(ctx context.Context, userID, videoID uuid.UUID, req models.ScheduleRequest) (models.VideoDetail, error)
+	RunScheduler(ctx context.Context) error
+	ExportData(ctx context.Context, userID uuid.UUID) (models.DataExport, error)
+	GetDataExport(ctx context.Context, userID, exportID uuid.UUID) (models.DataExport, error)
+	RunExports(ctx context.Context) error
+	ListRenditionVersions(ctx context.Context, userID, videoID uuid.UUID) ([]models.RenditionVersion, error)
+	RollbackRenditions(ctx context.Context, userID, videoID uuid.UUID, version int32) (models.VideoDetail, error)
+	RunVersionCleanup(ctx context.Context, retention time.Duration) error
+	GetUsage(ctx context.Context, userID uuid.UUID, query models.UsageQuery) ([]models.UsageMonth, error)
+	ExportUsage(ctx context.Context, month string) ([]models.UsageMonth, error)
+	RunUsageEmitter(ctx context.Context, config models.BillingConfig) error
+	GetNotificationPreferences(ctx context.Context, userID uuid.UUID) (models.NotificationPreferences, error)
+	SetNotificationPreferences(ctx context.Context, userID uuid.UUID, prefs models.NotificationPreferences) (models.NotificationPreferences, error)
 }
 
 type videoProcessor struct {
 	urlExpiry   time.Duration
 	logger      *slog.Logger
-	minioClient *minio.Client
-	db          *db.Queries
+	minioClient ObjectStore
+	db          VideoRepo
 	streamer    Streamer
+	transcoder  Transcoder // probes sources on demand
+	ingest      models.IngestConfig
+	// playbackTokens signs the playback tokens of password protected videos
+	playbackTokens utils.TokenManager
+	notifier       *notifier
+	// layout places the objects users upload
+	layout storage.Layout
+	// bandwidth throttles the downloads streamed through the API, nil when unlimited
+	bandwidth *bandwidthLimiter
+	// delivery holds the plans of users, which pick the processing mode of their uploads
+	delivery models.DeliveryConfig
+	modes    models.ModesConfig
+	// keyWrapper decrypts the stored HLS keys, nil when no key encryption key is configured
+	keyWrapper cipher.AEAD
+	// keyURL is the address of the API the key URIs of encrypted videos start with
+	keyURL string
 }
 
-func NewVideoProcessor(logger *slog.Logger, minioClient *minio.Client, db *db.Queries, streamer Streamer, urlExpiry time.Duration) VideoProcessor {
+// VideoProcessorOptions holds the settings of a video processor beside its dependencies;
+// fields left zero turn their feature off
+type VideoProcessorOptions struct {
+	// URLExpiry is how long presigned URLs stay valid
+	URLExpiry time.Duration
+	Ingest    models.IngestConfig
+	// PlaybackTokens signs the playback tokens of password protected videos
+	PlaybackTokens utils.TokenManager
+	Notifications  models.NotificationConfig
+	// Layout places the objects users upload
+	Layout   storage.Layout
+	Delivery models.DeliveryConfig
+	Modes    models.ModesConfig
+	// HLSEncryption holds the key encryption key the HLS keys are stored with, and the address
+	// they are served from
+	HLSEncryption models.HLSEncryptionConfig
+}
+
+func NewVideoProcessor(logger *slog.Logger, minioClient ObjectStore, db VideoRepo, streamer Streamer, transcoder Transcoder, opts VideoProcessorOptions) VideoProcessor {
+	keyWrapper, err := newKeyWrapper(opts.HLSEncryption.KeyEncryptionKey)
+	if err != nil {
+		logger.Error("HLS keys cannot be served", "error", err)
+	}
 	return &videoProcessor{
-		urlExpiry:   urlExpiry,
-		logger:      logger,
-		minioClient: minioClient,
-		db:          db,
-		streamer:    streamer,
+		urlExpiry:      opts.URLExpiry,
+		logger:         logger,
+		minioClient:    minioClient,
+		db:             db,
+		streamer:       streamer,
+		transcoder:     transcoder,
+		ingest:         opts.Ingest,
+		playbackTokens: opts.PlaybackTokens,
+		notifier:       newNotifier(logger, db, opts.Notifications),
+		layout:         opts.Layout,
+		bandwidth:      newBandwidthLimiter(opts.Delivery),
+		delivery:       opts.Delivery,
+		modes:          opts.Modes,
+		keyWrapper:     keyWrapper,
+		keyURL:         opts.HLSEncryption.KeyURL,
 	}
 }
 
@@ -71,6 +204,10 @@ func (vp *videoProcessor) Upload(ctx context.Context, userID uuid.UUID, req mode
 			Err:     err,
 		}
 	}
+	presetID, err := vp.presetID(ctx, req.Preset)
+	if err != nil {
+		return err
+	}
 	for _, fileHeader := range req.Videos {
 		file, err := fileHeader.Open()
 		if err != nil {
@@ -84,23 +221,11 @@ func (vp *videoProcessor) Upload(ctx context.Context, userID uuid.UUID, req mode
 		}
 		defer file.Close()
 
-		buckets, err := vp.ListBuckets(ctx)
-		if err != nil {
+		bucket, key := vp.layout.Place(userID, fileHeader.Filename)
+		if err := vp.ensureBucket(ctx, bucket); err != nil {
 			return err
 		}
-		bucketExist := false
-		for _, bucket := range buckets {
-			if bucket.Name == userID.String() {
-				bucketExist = true
-			}
-		}
-		if !bucketExist {
-			err := vp.CreateBucket(ctx, userID.String())
-			if err != nil {
-				return err
-			}
-		}
-		_, err = vp.minioClient.PutObject(ctx, userID.String(), fileHeader.Filename, file, fileHeader.Size, minio.PutObjectOptions{
+		_, err = vp.minioClient.PutObject(ctx, bucket, key, file, fileHeader.Size, minio.PutObjectOptions{
 			ContentType: fileHeader.Header.Get("Content-Type"),
 		})
 		if err != nil {
@@ -117,8 +242,8 @@ func (vp *videoProcessor) Upload(ctx context.Context, userID uuid.UUID, req mode
 			UserID:        userID,
 			Title:         req.Title,
 			Description:   req.Description,
-			Bucket:        userID.String(),
-			Key:           fileHeader.Filename,
+			Bucket:        bucket,
+			Key:           key,
 			FileSizeBytes: fileHeader.Size,
 			ContentType:   fileHeader.Header.Get("Content-Type"),
 		})
@@ -131,11 +256,58 @@ func (vp *videoProcessor) Upload(ctx context.Context, userID uuid.UUID, req mode
 				Err:         fmt.Errorf("failed to save video metadata to database: %w", err),
 			}
 		}
-		err = vp.streamer.Stream(ctx, map[string]interface{}{
-			"bucket":   userID.String(),
-			"key":      fileHeader.Filename,
+		recordStorage(ctx, vp.logger, vp.db, userID)
+		job := map[string]interface{}{
+			"bucket":   bucket,
+			"key":      key,
 			"video_id": createdVideo.ID.String(),
-		})
+			"user_id":  userID.String(),
+		}
+		if presetID != uuid.Nil {
+			job["preset_id"] = presetID.String()
+		}
+		job["mode"] = vp.userMode(userID, req.Mode)
+		if req.BurnSubtitleTrack != nil {
+			job["burn_subtitle_track"] = strconv.Itoa(*req.BurnSubtitleTrack)
+		}
+		if req.BurnSubtitles != nil {
+			subtitlesKey := key + ".srt"
+			if err := vp.storeFormFile(ctx, bucket, subtitlesKey, req.BurnSubtitles); err != nil {
+				return err
+			}
+			job["burn_subtitles_key"] = subtitlesKey
+		}
+		if err := vp.addWatermark(ctx, userID, job); err != nil {
+			vp.logger.Warn("processing without the user's watermark", "error", err, "videoID", createdVideo.ID)
+		}
+		if req.WatermarkPosition != "" {
+			job["watermark_position"] = req.WatermarkPosition
+		}
+		if req.WatermarkOpacity != nil {
+			job["watermark_opacity"] = formatFactor(*req.WatermarkOpacity)
+		}
+		if req.TrimStart > 0 || req.TrimEnd > 0 {
+			job["trim_start"] = formatFactor(req.TrimStart)
+			job["trim_end"] = formatFactor(req.TrimEnd)
+		}
+		if req.Deinterlace != "" && req.Deinterlace != models.DeinterlaceAuto {
+			job["deinterlace"] = req.Deinterlace
+		}
+		if req.CropBlackBars {
+			job["crop_black_bars"] = "true"
+		}
+		if req.Denoise != "" {
+			job["denoise"] = req.Denoise
+		}
+		if req.Stabilize {
+			job["stabilize"] = "true"
+		}
+		if req.SkipBumpers {
+			job["skip_bumpers"] = "true"
+		} else if err := vp.addBumpers(ctx, userID, job); err != nil {
+			vp.logger.Warn("processing without the user's bumpers", "error", err, "videoID", createdVideo.ID)
+		}
+		err = vp.streamer.Stream(ctx, job)
 		if err != nil {
 			return models.Error{
 				Code:        http.StatusInternalServerError,
@@ -149,18 +321,634 @@ func (vp *videoProcessor) Upload(ctx context.Context, userID uuid.UUID, req mode
 	return nil
 }
 
-// func (vp *videoProcessor) getVideoURL(bucketName, objectName string, expiry time.Duration) (string, error) {
-// 	// presigned URL, expires in 1 hour
-// 	ctx := context.Background()
-// 	url, err := vp.minioClient.PresignedGetObject(ctx, bucketName, objectName, expiry, nil)
-// 	if err != nil {
-// 		return "", models.Error{
-// 			Code:        http.StatusInternalServerError,
-// 			Message:     "internal server error",
-// 			Description: "failed to generate video url for playback from storage",
-// 			Params:      fmt.Sprintf("bucketName: %v, objectName: %v, expiry: %v", bucketName, objectName, expiry),
-// 			Err:         fmt.Errorf("failed to generate video url for playback from storage: %w", err),
-// 		}
-// 	}
-// 	return url.String(), nil
-// }
+// ensureBucket creates the bucket unless it already exists
+func (vp *videoProcessor) ensureBucket(ctx context.Context, bucketName string) error {
+	buckets, err := vp.ListBuckets(ctx)
+	if err != nil {
+		return err
+	}
+	for _, bucket := range buckets {
+		if bucket.Name == bucketName {
+			return nil
+		}
+	}
+	return vp.CreateBucket(ctx, bucketName)
+}
+
+// ListVideos lists the user's videos, newest first, with a presigned URL of their preview clip
+func (vp *videoProcessor) ListVideos(ctx context.Context, userID uuid.UUID, query models.ListVideosQuery) ([]models.VideoSummary, error) {
+	params := fmt.Sprintf("userID: %v, query: %v", userID, query)
+	if err := query.Validate(); err != nil {
+		return nil, models.Error{
+			Code:    http.StatusBadRequest,
+			Message: "invalid input data",
+			Params:  params,
+			Err:     err,
+		}
+	}
+	rows, err := vp.db.ListUserVideos(ctx, db.ListUserVideosParams{
+		UserID: userID,
+		Limit:  int32(query.Limit),
+		Offset: int32(query.Offset),
+	})
+	if err != nil {
+		return nil, models.IndentifyDbError(err).AddParams(params)
+	}
+	videoIDs := make([]uuid.UUID, 0, len(rows))
+	for _, row := range rows {
+		videoIDs = append(videoIDs, row.ID)
+	}
+	translations, err := vp.translationsFor(ctx, query.Languages, videoIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	videos := make([]models.VideoSummary, 0, len(rows))
+	for _, row := range rows {
+		summary := models.VideoSummary{
+			ID:          row.ID,
+			Title:       row.Title,
+			Description: row.Description,
+			Status:      row.Status,
+			Visibility:  row.Visibility,
+			CreatedAt:   row.CreatedAt.Time,
+		}
+		summary.DurationSeconds = float64(row.DurationMs.Int64) / 1000
+		if t, ok := translations[row.ID]; ok {
+			summary.Title, summary.Description, summary.Language = t.Title, t.Description, t.Language
+		}
+		if row.ParentVideoID.Valid {
+			parentID := uuid.UUID(row.ParentVideoID.Bytes)
+			summary.ParentVideoID = &parentID
+		}
+		if row.PreviewKey.Valid {
+			summary.PreviewURL, err = vp.getVideoURL(ctx, row.PreviewBucket.String, row.PreviewKey.String, vp.urlExpiry)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if row.AnimatedPreviewKey.Valid {
+			summary.AnimatedPreviewURL, err = vp.getVideoURL(ctx, row.AnimatedPreviewBucket.String, row.AnimatedPreviewKey.String, vp.urlExpiry)
+			if err != nil {
+				return nil, err
+			}
+		}
+		videos = append(videos, summary)
+	}
+	return videos, nil
+}
+
+// getOwnedVideo loads a video and makes sure it belongs to userID.
+// Videos of other users are reported as not found.
+func (vp *videoProcessor) getOwnedVideo(ctx context.Context, userID, videoID uuid.UUID) (db.Video, error) {
+	return vp.loadVideo(ctx, userID, videoID, false)
+}
+
+// getVisibleVideo loads a video the user may watch: their own, or a public one.
+// Other videos, and videos removed by a moderator, are reported as not found.
+func (vp *videoProcessor) getVisibleVideo(ctx context.Context, userID, videoID uuid.UUID) (db.Video, error) {
+	return vp.loadVideo(ctx, userID, videoID, true)
+}
+
+// getUnlockedVideo loads a video the user may watch without a playback token: a visible one
+// that is not behind a playback password, or their own. The password guards the files and
+// details of a video, so other users of protected videos are reported as not found.
+func (vp *videoProcessor) getUnlockedVideo(ctx context.Context, userID, videoID uuid.UUID) (db.Video, error) {
+	video, err := vp.getVisibleVideo(ctx, userID, videoID)
+	if err != nil {
+		return db.Video{}, err
+	}
+	if video.PlaybackPasswordHash.Valid && video.UserID != userID {
+		return db.Video{}, models.Error{
+			Code:    http.StatusNotFound,
+			Message: "resource not found",
+			Params:  fmt.Sprintf("userID: %v, videoID: %v", userID, videoID),
+			Err:     models.ErrResourceNotFound,
+		}
+	}
+	return video, nil
+}
+
+func (vp *videoProcessor) loadVideo(ctx context.Context, userID, videoID uuid.UUID, public bool) (db.Video, error) {
+	params := fmt.Sprintf("userID: %v, videoID: %v", userID, videoID)
+	video, err := vp.db.GetVideo(ctx, videoID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return db.Video{}, models.Error{
+				Code:    http.StatusNotFound,
+				Message: "resource not found",
+				Params:  params,
+				Err:     models.ErrResourceNotFound,
+			}
+		}
+		return db.Video{}, models.IndentifyDbError(err).AddParams(params)
+	}
+	if video.Visibility == models.VisibilityRemoved || (video.UserID != userID && !(public && video.Visibility == models.VisibilityPublic)) {
+		return db.Video{}, models.Error{
+			Code:    http.StatusNotFound,
+			Message: "resource not found",
+			Params:  params,
+			Err:     models.ErrResourceNotFound,
+		}
+	}
+	return video, nil
+}
+
+func convertDbVideoToVideoDetail(video db.Video) models.VideoDetail {
+	detail := models.VideoDetail{
+		ID:                video.ID,
+		Title:             video.Title,
+		Description:       video.Description,
+		Status:            video.Status,
+		FailureReason:     video.FailureReason.String,
+		Visibility:        video.Visibility,
+		AgeRestricted:     video.AgeRestricted,
+		PurgeOnExpiry:     video.PurgeOnExpiry,
+		PasswordProtected: video.PlaybackPasswordHash.Valid,
+		Bucket:            video.Bucket,
+		FileSizeBytes:     video.FileSizeBytes,
+		ContentType:       video.ContentType,
+		SourceWidth:       video.SourceWidth.Int32,
+		SourceHeight:      video.SourceHeight.Int32,
+		DurationSeconds:   float64(video.DurationMs.Int64) / 1000,
+		SourceCodec:       video.SourceCodec.String,
+		SourceFPS:         video.SourceFps.Float64,
+		AudioChannels:     video.AudioChannels.Int32,
+		Container:         video.Container.String,
+		CreatedAt:         video.CreatedAt.Time,
+	}
+	if video.ParentVideoID.Valid {
+		parentID := uuid.UUID(video.ParentVideoID.Bytes)
+		detail.ParentVideoID = &parentID
+	}
+	if video.PublishedAt.Valid {
+		detail.PublishedAt = &video.PublishedAt.Time
+	}
+	if video.PublishAt.Valid {
+		detail.PublishAt = &video.PublishAt.Time
+	}
+	if video.ExpiresAt.Valid {
+		detail.ExpiresAt = &video.ExpiresAt.Time
+	}
+	if video.ColorPrimaries.Valid || video.ColorTransfer.Valid || video.ColorSpace.Valid {
+		detail.Color = &models.ColorInfo{
+			Primaries: video.ColorPrimaries.String,
+			Transfer:  video.ColorTransfer.String,
+			Space:     video.ColorSpace.String,
+			HDRFormat: video.HdrFormat.String,
+		}
+	}
+	if video.Projection.Valid {
+		detail.Spherical = &models.SphericalInfo{
+			Projection: video.Projection.String,
+			StereoMode: video.StereoMode.String,
+		}
+	}
+	if len(video.Recipe) > 0 {
+		var recipe models.Recipe
+		if err := json.Unmarshal(video.Recipe, &recipe); err == nil {
+			detail.Recipe = &recipe
+		}
+	}
+	return detail
+}
+
+// GetVideo returns a video the user can see, titled in the best of the preferred languages.
+// Videos behind a playback password are detailed to their owner only, the detail holds the keys
+// of their files.
+func (vp *videoProcessor) GetVideo(ctx context.Context, userID, videoID uuid.UUID, languages []string) (models.VideoDetail, error) {
+	video, err := vp.getUnlockedVideo(ctx, userID, videoID)
+	if err != nil {
+		return models.VideoDetail{}, err
+	}
+	params := fmt.Sprintf("videoID: %v", videoID)
+
+	variants, err := vp.db.ListVideoVariants(ctx, videoID)
+	if err != nil {
+		return models.VideoDetail{}, models.IndentifyDbError(err).AddParams(params)
+	}
+	assets, err := vp.db.ListVideoAssets(ctx, videoID)
+	if err != nil {
+		return models.VideoDetail{}, models.IndentifyDbError(err).AddParams(params)
+	}
+	thumbnails, err := vp.db.ListVideoThumbnails(ctx, videoID)
+	if err != nil {
+		return models.VideoDetail{}, models.IndentifyDbError(err).AddParams(params)
+	}
+	progress, err := vp.videoProgress(ctx, video)
+	if err != nil {
+		return models.VideoDetail{}, err
+	}
+	chapters, err := vp.GetChapters(ctx, userID, videoID)
+	if err != nil {
+		return models.VideoDetail{}, err
+	}
+	translations, err := vp.translationsFor(ctx, languages, []uuid.UUID{videoID})
+	if err != nil {
+		return models.VideoDetail{}, err
+	}
+
+	detail := convertDbVideoToVideoDetail(video)
+	if t, ok := translations[videoID]; ok {
+		detail.Title, detail.Description, detail.Language = t.Title, t.Description, t.Language
+	}
+	detail.Variants = make([]models.VideoVariant, 0, len(variants))
+	detail.Assets = make(map[string]string, len(assets))
+	detail.Chapters = chapters
+	detail.Progress = progress
+	for _, v := range variants {
+		detail.Variants = append(detail.Variants, variantFromRow(v))
+	}
+	for _, a := range assets {
+		detail.Assets[a.Kind] = a.Key
+	}
+	detail.Thumbnails = make([]models.VideoThumbnail, 0, len(thumbnails))
+	for _, t := range thumbnails {
+		detail.Thumbnails = append(detail.Thumbnails, models.VideoThumbnail{
+			Position: t.Position,
+			At:       float64(t.AtMs) / 1000,
+			Key:      t.Key,
+			Primary:  t.IsPrimary,
+		})
+	}
+	return detail, nil
+}
+
+func variantFromRow(v db.VideoVariant) models.VideoVariant {
+	return models.VideoVariant{
+		Name:           v.VariantName,
+		Width:          v.Width.Int32,
+		Height:         v.Height.Int32,
+		BitrateKbps:    v.BitrateKbps.Int32,
+		Key:            v.Key,
+		HlsPlaylistKey: v.HlsPlaylistKey.String,
+		ThumbnailKey:   v.ThumbnailKey.String,
+		Format:         v.Format,
+		AudioLanguages: v.AudioLanguages,
+		VMAF:           float8Ptr(v.Vmaf),
+		PSNR:           float8Ptr(v.Psnr),
+	}
+}
+
+func (vp *videoProcessor) GetChapters(ctx context.Context, userID, videoID uuid.UUID) ([]models.Chapter, error) {
+	if _, err := vp.getUnlockedVideo(ctx, userID, videoID); err != nil {
+		return nil, err
+	}
+	rows, err := vp.db.ListVideoChapters(ctx, videoID)
+	if err != nil {
+		return nil, models.IndentifyDbError(err).AddParams(fmt.Sprintf("videoID: %v", videoID))
+	}
+	chapters := make([]models.Chapter, 0, len(rows))
+	for _, c := range rows {
+		chapters = append(chapters, convertDbChapterToModelChapter(c))
+	}
+	return chapters, nil
+}
+
+// SetChapters replaces all chapters of a video, including ones extracted from the source.
+func (vp *videoProcessor) SetChapters(ctx context.Context, userID, videoID uuid.UUID, req models.SetChaptersRequest) ([]models.Chapter, error) {
+	params := fmt.Sprintf("userID: %v, videoID: %v, req: %v", userID, videoID, req)
+	if err := req.Validate(); err != nil {
+		return nil, models.Error{
+			Code:    http.StatusBadRequest,
+			Message: "invalid input data",
+			Params:  params,
+			Err:     err,
+		}
+	}
+	if _, err := vp.getOwnedVideo(ctx, userID, videoID); err != nil {
+		return nil, err
+	}
+
+	if err := vp.db.DeleteVideoChapters(ctx, videoID); err != nil {
+		return nil, models.IndentifyDbError(err).AddParams(params)
+	}
+	chapters := make([]models.Chapter, 0, len(req.Chapters))
+	for _, c := range req.Chapters {
+		created, err := vp.db.CreateVideoChapter(ctx, db.CreateVideoChapterParams{
+			VideoID: videoID,
+			Title:   c.Title,
+			StartMs: int64(math.Round(c.Start * 1000)),
+			Source:  ChapterSourceManual,
+		})
+		if err != nil {
+			return nil, models.IndentifyDbError(err).AddParams(params)
+		}
+		chapters = append(chapters, convertDbChapterToModelChapter(created))
+	}
+	return chapters, nil
+}
+
+// EditVideo creates a derived video from the given edit recipe and queues its render.
+// The derived video is processed like a regular upload once the render finishes.
+func (vp *videoProcessor) EditVideo(ctx context.Context, userID, videoID uuid.UUID, req models.EditRequest) (models.VideoDetail, error) {
+	params := fmt.Sprintf("userID: %v, videoID: %v, req: %v", userID, videoID, req)
+	if err := req.Validate(); err != nil {
+		return models.VideoDetail{}, models.Error{
+			Code:    http.StatusBadRequest,
+			Message: "invalid input data",
+			Params:  params,
+			Err:     err,
+		}
+	}
+	parent, err := vp.getOwnedVideo(ctx, userID, videoID)
+	if err != nil {
+		return models.VideoDetail{}, err
+	}
+	return vp.createDerivedVideo(ctx, parent, req.Title, models.Recipe{
+		Type: models.RecipeTypeEdit,
+		Edit: &req,
+	}, JobTypeEdit)
+}
+
+// ComposeOverlay creates a derived video showing another video or an image over the given one.
+// An uploaded image is stored next to the user's videos so the worker can fetch it.
+func (vp *videoProcessor) ComposeOverlay(ctx context.Context, userID, videoID uuid.UUID, req models.OverlayRequest) (models.VideoDetail, error) {
+	params := fmt.Sprintf("userID: %v, videoID: %v, req: %v", userID, videoID, req)
+	if err := req.Validate(); err != nil {
+		return models.VideoDetail{}, models.Error{
+			Code:    http.StatusBadRequest,
+			Message: "invalid input data",
+			Params:  params,
+			Err:     err,
+		}
+	}
+	base, err := vp.getOwnedVideo(ctx, userID, videoID)
+	if err != nil {
+		return models.VideoDetail{}, err
+	}
+
+	if req.Image != nil {
+		if !isOverlayImage(req.Image) {
+			return models.VideoDetail{}, models.Error{
+				Code:    http.StatusBadRequest,
+				Message: "invalid input data",
+				Params:  params,
+				Err:     errors.Join(fmt.Errorf("unsupported image type %q", req.Image.Header.Get("Content-Type")), models.ErrInvalidInputData),
+			}
+		}
+		key := fmt.Sprintf("overlays/%s%s", uuid.New(), filepath.Ext(req.Image.Filename))
+		if err := vp.storeFormFile(ctx, base.Bucket, key, req.Image); err != nil {
+			return models.VideoDetail{}, err
+		}
+		req.OverlayBucket, req.OverlayKey, req.IsImage = base.Bucket, key, true
+	} else {
+		overlay, err := vp.getOwnedVideo(ctx, userID, uuid.MustParse(req.OverlayVideoID))
+		if err != nil {
+			return models.VideoDetail{}, err
+		}
+		req.OverlayBucket, req.OverlayKey = overlay.Bucket, overlay.Key
+	}
+
+	return vp.createDerivedVideo(ctx, base, req.Title, models.Recipe{
+		Type:    models.RecipeTypeOverlay,
+		Overlay: &req,
+	}, JobTypeOverlay)
+}
+
+// CreateAudiogram stores an audio file and an optional image and queues the render of a
+// video showing the image, or the audio waveform, for the length of the audio.
+func (vp *videoProcessor) CreateAudiogram(ctx context.Context, userID uuid.UUID, req models.AudiogramRequest) (models.VideoDetail, error) {
+	params := fmt.Sprintf("userID: %v, title: %v", userID, req.Title)
+	if err := req.Validate(); err != nil {
+		return models.VideoDetail{}, models.Error{
+			Code:    http.StatusBadRequest,
+			Message: "invalid input data",
+			Params:  params,
+			Err:     err,
+		}
+	}
+	if contentType := req.Audio.Header.Get("Content-Type"); !strings.HasPrefix(contentType, "audio/") {
+		return models.VideoDetail{}, models.Error{
+			Code:    http.StatusBadRequest,
+			Message: "invalid input data",
+			Params:  params,
+			Err:     errors.Join(fmt.Errorf("unsupported audio type %q", contentType), models.ErrInvalidInputData),
+		}
+	}
+	if req.Image != nil && !isOverlayImage(req.Image) {
+		return models.VideoDetail{}, models.Error{
+			Code:    http.StatusBadRequest,
+			Message: "invalid input data",
+			Params:  params,
+			Err:     errors.Join(fmt.Errorf("unsupported image type %q", req.Image.Header.Get("Content-Type")), models.ErrInvalidInputData),
+		}
+	}
+
+	bucket, prefix := vp.layout.Place(userID, fmt.Sprintf("audiograms/%s", uuid.New()))
+	if err := vp.ensureBucket(ctx, bucket); err != nil {
+		return models.VideoDetail{}, err
+	}
+	audioKey := prefix + "/audio" + filepath.Ext(req.Audio.Filename)
+	if err := vp.storeFormFile(ctx, bucket, audioKey, req.Audio); err != nil {
+		return models.VideoDetail{}, err
+	}
+	if req.Image != nil {
+		req.ImageKey = prefix + "/image" + filepath.Ext(req.Image.Filename)
+		if err := vp.storeFormFile(ctx, bucket, req.ImageKey, req.Image); err != nil {
+			return models.VideoDetail{}, err
+		}
+	}
+
+	return vp.createRenderedVideo(ctx, db.CreateDerivedVideoParams{
+		UserID:      userID,
+		Title:       req.Title,
+		Description: req.Description,
+		Bucket:      bucket,
+		Key:         prefix + "/video.mp4",
+		ContentType: "video/mp4",
+	}, audioKey, models.Recipe{
+		Type:      models.RecipeTypeAudiogram,
+		Audiogram: &req,
+	}, JobTypeAudiogram)
+}
+
+// isOverlayImage reports whether an uploaded image can be composed into a video
+func isOverlayImage(fh *multipart.FileHeader) bool {
+	contentType := fh.Header.Get("Content-Type")
+	return contentType == "image/png" || contentType == "image/jpeg"
+}
+
+// storeFormFile uploads a multipart file to bucket/key
+func (vp *videoProcessor) storeFormFile(ctx context.Context, bucket, key string, fh *multipart.FileHeader) error {
+	params := fmt.Sprintf("bucket: %v, key: %v", bucket, key)
+	file, err := fh.Open()
+	if err != nil {
+		return models.Error{
+			Code:        http.StatusInternalServerError,
+			Message:     "internal server error",
+			Description: "failed to open file",
+			Params:      params,
+			Err:         fmt.Errorf("failed to open file: %w", err),
+		}
+	}
+	defer file.Close()
+	_, err = vp.minioClient.PutObject(ctx, bucket, key, file, fh.Size, minio.PutObjectOptions{
+		ContentType: fh.Header.Get("Content-Type"),
+	})
+	if err != nil {
+		return models.Error{
+			Code:        http.StatusInternalServerError,
+			Message:     "internal server error",
+			Description: "failed to upload file to storage",
+			Params:      params,
+			Err:         fmt.Errorf("failed to upload file to storage: %w", err),
+		}
+	}
+	return nil
+}
+
+// createDerivedVideo stores a pending child video of parent and streams the job that renders it.
+func (vp *videoProcessor) createDerivedVideo(ctx context.Context, parent db.Video, title string, recipe models.Recipe, jobType string) (models.VideoDetail, error) {
+	if title == "" {
+		title = parent.Title
+	}
+	return vp.createRenderedVideo(ctx, db.CreateDerivedVideoParams{
+		UserID:        parent.UserID,
+		Title:         title,
+		Description:   parent.Description,
+		Bucket:        parent.Bucket,
+		Key:           storage.OwnerPrefix(parent.UserID.String(), parent.Key) + fmt.Sprintf("derived/%s.mp4", uuid.New()),
+		ContentType:   "video/mp4",
+		ParentVideoID: pgtype.UUID{Bytes: parent.ID, Valid: true},
+	}, parent.Key, recipe, jobType)
+}
+
+// createRenderedVideo stores a pending video whose source is rendered by the worker from
+// sourceKey, and streams the job that renders it. The recipe is stored on the video.
+func (vp *videoProcessor) createRenderedVideo(ctx context.Context, video db.CreateDerivedVideoParams, sourceKey string, recipe models.Recipe, jobType string) (models.VideoDetail, error) {
+	params := fmt.Sprintf("parentID: %v, source: %v, recipe: %v", video.ParentVideoID, sourceKey, recipe)
+	recipeJSON, err := json.Marshal(recipe)
+	if err != nil {
+		return models.VideoDetail{}, models.Error{
+			Code:    http.StatusInternalServerError,
+			Message: "internal server error",
+			Params:  params,
+			Err:     fmt.Errorf("failed to encode recipe: %w", err),
+		}
+	}
+	video.Recipe = recipeJSON
+
+	created, err := vp.db.CreateDerivedVideo(ctx, video)
+	if err != nil {
+		return models.VideoDetail{}, models.Error{
+			Code:        http.StatusInternalServerError,
+			Message:     "internal server error",
+			Description: "failed to save derived video to database",
+			Params:      params,
+			Err:         fmt.Errorf("failed to save derived video to database: %w", err),
+		}
+	}
+
+	err = vp.streamer.Stream(ctx, map[string]interface{}{
+		"type":       jobType,
+		"bucket":     created.Bucket,
+		"key":        sourceKey,
+		"video_id":   created.ID.String(),
+		"output_key": created.Key,
+		"recipe":     string(recipeJSON),
+		"user_id":    created.UserID.String(),
+	})
+	if err != nil {
+		return models.VideoDetail{}, models.Error{
+			Code:        http.StatusInternalServerError,
+			Message:     "internal server error",
+			Description: "failed to stream event to redis for video processing",
+			Params:      params,
+			Err:         fmt.Errorf("failed to stream event to redis for video processing: %w", err),
+		}
+	}
+	return convertDbVideoToVideoDetail(created), nil
+}
+
+// FindDuplicates compares the fingerprints of all processed videos pairwise and
+// reports the pairs at or above the requested similarity, most similar first.
+func (vp *videoProcessor) FindDuplicates(ctx context.Context, query models.DuplicateReportQuery) ([]models.DuplicateMatch, error) {
+	params := fmt.Sprintf("query: %v", query)
+	if err := query.Validate(); err != nil {
+		return nil, models.Error{
+			Code:    http.StatusBadRequest,
+			Message: "invalid input data",
+			Params:  params,
+			Err:     err,
+		}
+	}
+	rows, err := vp.db.ListVideoFingerprints(ctx)
+	if err != nil {
+		return nil, models.IndentifyDbError(err).AddParams(params)
+	}
+
+	hashes := make([][]uint64, len(rows))
+	for i, row := range rows {
+		hashes[i] = make([]uint64, len(row.FrameHashes))
+		for j, h := range row.FrameHashes {
+			hashes[i][j] = uint64(h)
+		}
+	}
+
+	matches := []models.DuplicateMatch{}
+	for i := range rows {
+		for j := i + 1; j < len(rows); j++ {
+			if query.CrossUserOnly && rows[i].UserID == rows[j].UserID {
+				continue
+			}
+			similarity := CompareFingerprints(hashes[i], hashes[j])
+			if similarity < query.MinSimilarity {
+				continue
+			}
+			matches = append(matches, models.DuplicateMatch{
+				Video:      models.DuplicateVideo{ID: rows[i].VideoID, UserID: rows[i].UserID, Title: rows[i].Title},
+				Duplicate:  models.DuplicateVideo{ID: rows[j].VideoID, UserID: rows[j].UserID, Title: rows[j].Title},
+				Similarity: similarity,
+			})
+		}
+	}
+	sort.SliceStable(matches, func(a, b int) bool {
+		return matches[a].Similarity > matches[b].Similarity
+	})
+	if query.Limit > 0 && len(matches) > query.Limit {
+		matches = matches[:query.Limit]
+	}
+	return matches, nil
+}
+
+func (vp *videoProcessor) getVideoURL(ctx context.Context, bucketName, objectName string, expiry time.Duration) (string, error) {
+	url, err := vp.minioClient.PresignedGetObject(ctx, bucketName, objectName, expiry, nil)
+	if err != nil {
+		return "", models.Error{
+			Code:        http.StatusInternalServerError,
+			Message:     "internal server error",
+			Description: "failed to generate video url for playback from storage",
+			Params:      fmt.Sprintf("bucketName: %v, objectName: %v, expiry: %v", bucketName, objectName, expiry),
+			Err:         fmt.Errorf("failed to generate video url for playback from storage: %w", err),
+		}
+	}
+	return url.String(), nil
+}
+
+// QualityReport summarizes the measured VMAF and PSNR of every ladder rung
+func (vp *videoProcessor) QualityReport(ctx context.Context) ([]models.VariantQuality, error) {
+	rows, err := vp.db.ListVariantQualityReport(ctx)
+	if err != nil {
+		return nil, models.IndentifyDbError(err)
+	}
+	report := make([]models.VariantQuality, 0, len(rows))
+	for _, row := range rows {
+		report = append(report, models.VariantQuality{
+			Variant:        row.VariantName,
+			Videos:         row.Videos,
+			AvgBitrateKbps: row.AvgBitrateKbps,
+			AvgVMAF:        row.AvgVmaf,
+			MinVMAF:        row.MinVmaf,
+			AvgPSNR:        row.AvgPsnr,
+		})
+	}
+	return report, nil
+}
+
+func float8Ptr(f pgtype.Float8) *float64 {
+	if !f.Valid {
+		return nil
+	}
+	return &f.Float64
+}
@@ -0,0 +1,68 @@
+package video
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"testing"
+	"time"
+	"video-processing/database/db"
+	"video-processing/mocks"
+	"video-processing/models"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestGetVideoNotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	repo := mocks.NewMockVideoRepo(ctrl)
+	vp := NewVideoProcessor(slog.New(slog.NewTextHandler(io.Discard, nil)), mocks.NewMockObjectStore(ctrl), repo, mocks.NewMockStreamer(ctrl), NewFakeTranscoder(), VideoProcessorOptions{URLExpiry: time.Hour})
+
+	owner, videoID := uuid.New(), uuid.New()
+	repo.EXPECT().GetVideo(gomock.Any(), videoID).Return(db.Video{}, pgx.ErrNoRows)
+	_, err := vp.GetVideo(context.Background(), owner, videoID, nil)
+	var e models.Error
+	require.ErrorAs(t, err, &e)
+	require.Equal(t, http.StatusNotFound, e.Code)
+
+	// videos of other users look missing as well
+	repo.EXPECT().GetVideo(gomock.Any(), videoID).Return(db.Video{ID: videoID, UserID: uuid.New()}, nil)
+	_, err = vp.GetVideo(context.Background(), owner, videoID, nil)
+	require.ErrorAs(t, err, &e)
+	require.Equal(t, http.StatusNotFound, e.Code)
+
+	// and so do public videos behind a playback password, with their files and chapters
+	protected := db.Video{ID: videoID, UserID: uuid.New(), Visibility: models.VisibilityPublic,
+		PlaybackPasswordHash: pgtype.Text{String: "hash", Valid: true}}
+	repo.EXPECT().GetVideo(gomock.Any(), videoID).Return(protected, nil).Times(2)
+	_, err = vp.GetVideo(context.Background(), owner, videoID, nil)
+	require.ErrorAs(t, err, &e)
+	require.Equal(t, http.StatusNotFound, e.Code)
+	_, err = vp.GetChapters(context.Background(), owner, videoID)
+	require.ErrorAs(t, err, &e)
+	require.Equal(t, http.StatusNotFound, e.Code)
+}
+
+func TestRedisStreamerStream(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	broker := mocks.NewMockBroker(ctrl)
+	streamer := NewRedisStreamer("video_stream", slog.New(slog.NewTextHandler(io.Discard, nil)), broker)
+
+	values := map[string]interface{}{"type": JobTypeProcess, "video_id": uuid.NewString()}
+	broker.EXPECT().
+		XAdd(gomock.Any(), &redis.XAddArgs{Stream: "video_stream", ID: "*", Values: values}).
+		Return(redis.NewStringResult("1-0", nil))
+	require.NoError(t, streamer.Stream(context.Background(), values))
+
+	broker.EXPECT().XAdd(gomock.Any(), gomock.Any()).Return(redis.NewStringResult("", redis.ErrClosed))
+	err := streamer.Stream(context.Background(), values)
+	var e models.Error
+	require.ErrorAs(t, err, &e)
+	require.Equal(t, http.StatusInternalServerError, e.Code)
+}
@@ -0,0 +1,163 @@
+package video
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"video-processing/models"
+
+	"github.com/google/uuid"
+	"github.com/minio/minio-go/v7"
+)
+
+// watermarkKey is where the watermark of a user is stored in their storage
+const watermarkKey = "branding/watermark.png"
+
+// Defaults of the platform watermark settings left unset
+const (
+	defaultWatermarkPosition = models.WatermarkBottomRight
+	defaultWatermarkScale    = 0.15
+)
+
+// watermark is the image composed onto the variants of a job
+type watermark struct {
+	Path     string  // local PNG
+	Position string  // one of models.WatermarkPositions
+	Opacity  float64 // 0 to 1
+	Scale    float64 // width as a share of the variant width
+}
+
+// watermarkPlacement is the overlay position of each models.WatermarkPositions, inset from the
+// edges by a fiftieth of the picture width
+var watermarkPlacement = map[string]string{
+	models.WatermarkTopLeft:     "x=W/50:y=W/50",
+	models.WatermarkTopRight:    "x=W-w-W/50:y=W/50",
+	models.WatermarkBottomLeft:  "x=W/50:y=H-h-W/50",
+	models.WatermarkBottomRight: "x=W-w-W/50:y=H-h-W/50",
+	models.WatermarkCenter:      "x=(W-w)/2:y=(H-h)/2",
+}
+
+// watermarkGraph composes the task's watermark over the output of the filter chain vf. The
+// image is read by a movie source, so the graph still has one input and one output and fits
+// -vf; overlay repeats its single frame for the whole video.
+func watermarkGraph(vf string, task ProcessingTask) string {
+	w := task.Watermark
+	if w == nil {
+		return vf
+	}
+	width := int(float64(task.Variant.Width) * w.Scale)
+	image := fmt.Sprintf("movie=filename=%s,scale=%d:-1,format=rgba", escapeFilterPath(w.Path), max(width, 2))
+	if w.Opacity < 1 {
+		image += fmt.Sprintf(",colorchannelmixer=aa=%s", formatFactor(w.Opacity))
+	}
+	return fmt.Sprintf("%s[wm];%s[base];[base][wm]overlay=%s", image, vf, watermarkPlacement[w.Position])
+}
+
+// loadWatermark fetches the watermark of the job into workDir: the owner's when the job names
+// one, otherwise the platform watermark. The job's position and opacity override the
+// configured ones. Nil when there is no watermark or it cannot be fetched, which is logged.
+func (rc *redisConsumer) loadWatermark(ctx context.Context, values map[string]interface{}, workDir string) *watermark {
+	config := rc.processing.Watermark
+	bucket, key := config.Bucket, config.Key
+	if userKey, _ := values["watermark_key"].(string); userKey != "" {
+		bucket, _ = values["watermark_bucket"].(string)
+		key = userKey
+	}
+	if key == "" {
+		return nil
+	}
+
+	w := &watermark{
+		Path:     filepath.Join(workDir, "watermark.png"),
+		Position: config.Position,
+		Opacity:  config.Opacity,
+		Scale:    config.Scale,
+	}
+	if position, _ := values["watermark_position"].(string); position != "" {
+		w.Position = position
+	}
+	if opacity, err := strconv.ParseFloat(fmt.Sprint(values["watermark_opacity"]), 64); err == nil {
+		w.Opacity = opacity
+	}
+	if _, ok := watermarkPlacement[w.Position]; !ok {
+		w.Position = defaultWatermarkPosition
+	}
+	if w.Opacity <= 0 || w.Opacity > 1 {
+		w.Opacity = 1
+	}
+	if w.Scale <= 0 || w.Scale > 1 {
+		w.Scale = defaultWatermarkScale
+	}
+
+	if err := downloadFromMinio(ctx, rc.mc, bucket, key, w.Path); err != nil {
+		rc.logger.Error("failed to download watermark, processing without it", "error", err, "key", key, "videoID", values["video_id"])
+		return nil
+	}
+	return w
+}
+
+// SetWatermark stores the PNG watermark of the user, composed onto the videos they upload from
+// now on
+func (vp *videoProcessor) SetWatermark(ctx context.Context, userID uuid.UUID, req models.WatermarkRequest) error {
+	params := fmt.Sprintf("userID: %v", userID)
+	if err := req.Validate(); err != nil {
+		return models.Error{
+			Code:    http.StatusBadRequest,
+			Message: "invalid input data",
+			Params:  params,
+			Err:     err,
+		}
+	}
+	if req.Image.Header.Get("Content-Type") != "image/png" {
+		return models.Error{
+			Code:    http.StatusBadRequest,
+			Message: "invalid input data",
+			Params:  params,
+			Err:     errors.Join(fmt.Errorf("unsupported watermark type %q, want image/png", req.Image.Header.Get("Content-Type")), models.ErrInvalidInputData),
+		}
+	}
+	bucket, key := vp.layout.Place(userID, watermarkKey)
+	if err := vp.ensureBucket(ctx, bucket); err != nil {
+		return err
+	}
+	return vp.storeFormFile(ctx, bucket, key, req.Image)
+}
+
+// DeleteWatermark removes the watermark of the user; their later videos get the platform
+// watermark, if any
+func (vp *videoProcessor) DeleteWatermark(ctx context.Context, userID uuid.UUID) error {
+	bucket, key := vp.layout.Place(userID, watermarkKey)
+	if err := vp.minioClient.RemoveObject(ctx, bucket, key, minio.RemoveObjectOptions{}); err != nil && !missingObject(err) {
+		return models.Error{
+			Code:        http.StatusInternalServerError,
+			Message:     "internal server error",
+			Description: "failed to delete watermark",
+			Params:      fmt.Sprintf("userID: %v", userID),
+			Err:         err,
+		}
+	}
+	return nil
+}
+
+// addWatermark names the watermark of the owner in job when they uploaded one
+func (vp *videoProcessor) addWatermark(ctx context.Context, userID uuid.UUID, job map[string]interface{}) error {
+	bucket, key := vp.layout.Place(userID, watermarkKey)
+	if _, err := vp.minioClient.StatObject(ctx, bucket, key, minio.StatObjectOptions{}); err != nil {
+		if missingObject(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to look up watermark: %w", err)
+	}
+	job["watermark_bucket"] = bucket
+	job["watermark_key"] = key
+	return nil
+}
+
+// missingObject reports whether err is MinIO's answer for an object or bucket that does not exist
+func missingObject(err error) bool {
+	code := minio.ToErrorResponse(err).Code
+	return code == "NoSuchKey" || code == "NoSuchBucket"
+}
@@ -0,0 +1,85 @@
+package video
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"path"
+	"testing"
+	"video-processing/mocks"
+	"video-processing/models"
+
+	"github.com/google/uuid"
+	"github.com/minio/minio-go/v7"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestWatermarkGraph(t *testing.T) {
+	task := ProcessingTask{Variant: testLadder.regular[1], SourcePath: "source.mp4"}
+	require.Equal(t, "scale=1280:720", watermarkGraph("scale=1280:720", task))
+
+	task.Watermark = &watermark{Path: "/tmp/job/watermark.png", Position: models.WatermarkTopLeft, Opacity: 0.5, Scale: 0.1}
+	require.Equal(t,
+		"movie=filename=/tmp/job/watermark.png,scale=128:-1,format=rgba,colorchannelmixer=aa=0.5[wm];scale=1280:720[base];[base][wm]overlay=x=W/50:y=W/50",
+		watermarkGraph("scale=1280:720", task))
+
+	// the encoder's own filters follow the overlay
+	fake := NewFakeTranscoder()
+	task.Watermark.Opacity = 1
+	task.Encoder = EncoderNVENC
+	require.NoError(t, transcodeToMP4(context.Background(), fake, task, path.Join(t.TempDir(), "720p.mp4")))
+	require.Contains(t, fake.Calls()[0], "movie=filename=/tmp/job/watermark.png,scale=128:-1,format=rgba[wm];scale=1280:720[base];[base][wm]overlay=x=W/50:y=W/50,format=yuv420p")
+}
+
+func TestLoadWatermark(t *testing.T) {
+	store := mocks.NewMockObjectStore(gomock.NewController(t))
+	rc := &redisConsumer{
+		logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+		mc:     store,
+		processing: models.ProcessingConfig{Watermark: models.WatermarkConfig{
+			Bucket: "branding", Key: "platform.png", Position: models.WatermarkTopRight, Opacity: 0.7,
+		}},
+	}
+	workDir := t.TempDir()
+	localPath := path.Join(workDir, "watermark.png")
+
+	// the platform watermark with the configured placement
+	store.EXPECT().FGetObject(gomock.Any(), "branding", "platform.png", localPath, gomock.Any())
+	require.Equal(t, &watermark{Path: localPath, Position: models.WatermarkTopRight, Opacity: 0.7, Scale: defaultWatermarkScale},
+		rc.loadWatermark(context.Background(), map[string]interface{}{}, workDir))
+
+	// the owner's watermark placed by the job
+	store.EXPECT().FGetObject(gomock.Any(), "user", watermarkKey, localPath, gomock.Any())
+	require.Equal(t, &watermark{Path: localPath, Position: models.WatermarkCenter, Opacity: 0.25, Scale: defaultWatermarkScale},
+		rc.loadWatermark(context.Background(), map[string]interface{}{
+			"watermark_bucket":   "user",
+			"watermark_key":      watermarkKey,
+			"watermark_position": models.WatermarkCenter,
+			"watermark_opacity":  "0.25",
+		}, workDir))
+
+	// a watermark that cannot be fetched is left out
+	store.EXPECT().FGetObject(gomock.Any(), "branding", "platform.png", localPath, gomock.Any()).Return(errors.New("unreachable"))
+	require.Nil(t, rc.loadWatermark(context.Background(), map[string]interface{}{}, workDir))
+
+	rc.processing.Watermark = models.WatermarkConfig{}
+	require.Nil(t, rc.loadWatermark(context.Background(), map[string]interface{}{}, workDir))
+}
+
+func TestAddWatermark(t *testing.T) {
+	store := mocks.NewMockObjectStore(gomock.NewController(t))
+	vp := &videoProcessor{minioClient: store}
+	userID := uuid.New()
+
+	job := map[string]interface{}{}
+	store.EXPECT().StatObject(gomock.Any(), userID.String(), watermarkKey, gomock.Any()).Return(minio.ObjectInfo{}, nil)
+	require.NoError(t, vp.addWatermark(context.Background(), userID, job))
+	require.Equal(t, map[string]interface{}{"watermark_bucket": userID.String(), "watermark_key": watermarkKey}, job)
+
+	job = map[string]interface{}{}
+	store.EXPECT().StatObject(gomock.Any(), userID.String(), watermarkKey, gomock.Any()).Return(minio.ObjectInfo{}, minio.ErrorResponse{Code: "NoSuchBucket"})
+	require.NoError(t, vp.addWatermark(context.Background(), userID, job))
+	require.Empty(t, job)
+}
@@ -0,0 +1,189 @@
+package video
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// waveformSampleRate is the rate the audio is resampled to before peaks are computed.
+	// Waveforms are only drawn, never played, so a low rate keeps decoding cheap.
+	waveformSampleRate = 8000
+	// waveformPixelsPerSecond controls the horizontal resolution of the peak data.
+	waveformPixelsPerSecond = 20
+	waveformFileName        = "waveform.json"
+	waveformImageName       = "waveform.png"
+	// waveformImageSize is wide enough for the timeline of an editor on a full HD screen
+	waveformImageSize = "1800x240"
+	// waveformImageColor shows on light and dark backgrounds alike, the image is transparent
+	waveformImageColor = "0x7f7f7f"
+)
+
+// Waveform is peak data in the audiowaveform JSON format, which players and
+// editors such as peaks.js can render directly.
+// Data holds interleaved min/max pairs, one pair per pixel.
+type Waveform struct {
+	Version         int    `json:"version"`
+	Channels        int    `json:"channels"`
+	SampleRate      int    `json:"sample_rate"`
+	SamplesPerPixel int    `json:"samples_per_pixel"`
+	Bits            int    `json:"bits"`
+	Length          int    `json:"length"`
+	Data            []int8 `json:"data"`
+}
+
+// computeWaveform reads little-endian signed 16-bit mono PCM from r and
+// reduces every samplesPerPixel samples to a single 8-bit min/max pair.
+func computeWaveform(r io.Reader, sampleRate, samplesPerPixel int) (Waveform, error) {
+	wf := Waveform{
+		Version:         2,
+		Channels:        1,
+		SampleRate:      sampleRate,
+		SamplesPerPixel: samplesPerPixel,
+		Bits:            8,
+	}
+
+	br := bufio.NewReader(r)
+	var (
+		minV, maxV int16
+		count      int
+		buf        [2]byte
+	)
+	flush := func() {
+		// scale 16-bit peaks down to 8-bit
+		wf.Data = append(wf.Data, int8(minV>>8), int8(maxV>>8))
+		wf.Length++
+		minV, maxV, count = 0, 0, 0
+	}
+	for {
+		if _, err := io.ReadFull(br, buf[:]); err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				break
+			}
+			return Waveform{}, fmt.Errorf("failed to read pcm samples: %w", err)
+		}
+		s := int16(binary.LittleEndian.Uint16(buf[:]))
+		if count == 0 || s < minV {
+			minV = s
+		}
+		if count == 0 || s > maxV {
+			maxV = s
+		}
+		count++
+		if count == samplesPerPixel {
+			flush()
+		}
+	}
+	if count > 0 {
+		flush()
+	}
+	return wf, nil
+}
+
+// generateWaveform decodes the audio of inputPath to mono PCM via ffmpeg and
+// writes the resulting peak data as JSON to outPath.
+func generateWaveform(ctx context.Context, t Transcoder, inputPath, outPath string) error {
+	// ffmpeg -i input -vn -ac 1 -ar 8000 -f s16le -acodec pcm_s16le pipe:1
+	args := []string{
+		"-nostdin",
+		"-v", "error",
+		"-i", inputPath,
+		"-vn",
+		"-ac", "1",
+		"-ar", fmt.Sprint(waveformSampleRate),
+		"-f", "s16le",
+		"-acodec", "pcm_s16le",
+		"pipe:1",
+	}
+	var wf Waveform
+	err := t.Stream(ctx, func(r io.Reader) (err error) {
+		wf, err = computeWaveform(r, waveformSampleRate, waveformSampleRate/waveformPixelsPerSecond)
+		return err
+	}, args...)
+	if err != nil {
+		return fmt.Errorf("ffmpeg waveform error: %w", err)
+	}
+	if wf.Length == 0 {
+		return fmt.Errorf("source has no audio samples")
+	}
+
+	data, err := json.Marshal(wf)
+	if err != nil {
+		return fmt.Errorf("failed to encode waveform: %w", err)
+	}
+	return os.WriteFile(outPath, data, 0o644)
+}
+
+// waveformImageArgs draw the first audio track of inputPath as a PNG of its whole length with
+// showwavespic. The square root scale keeps quiet passages visible next to loud ones.
+func waveformImageArgs(inputPath, outPath string) []string {
+	return []string{
+		"-y",
+		"-nostdin",
+		"-v", "error",
+		"-i", inputPath,
+		"-filter_complex", fmt.Sprintf("[0:a:0]aformat=channel_layouts=mono,showwavespic=s=%s:colors=%s:scale=sqrt", waveformImageSize, waveformImageColor),
+		"-frames:v", "1",
+		outPath,
+	}
+}
+
+// generateWaveformImage draws the audio of inputPath into a PNG at outPath
+func generateWaveformImage(ctx context.Context, t Transcoder, inputPath, outPath string) error {
+	if err := t.Run(ctx, waveformImageArgs(inputPath, outPath)...); err != nil {
+		return fmt.Errorf("ffmpeg waveform image error: %w", err)
+	}
+	return nil
+}
+
+// processWaveform generates the waveform peaks and image of the source video and queues them
+// for upload next to the renditions. Failures are logged only; a missing waveform never fails
+// the job.
+func (rc *redisConsumer) processWaveform(ctx context.Context, task ProcessingTask, uploadCh chan<- UploadTask) {
+	videoUUID, err := uuid.Parse(task.VideoID)
+	if err != nil {
+		rc.logger.Error("invalid video ID for waveform", "error", err, "videoID", task.VideoID)
+		return
+	}
+	publish := func(path, contentType, kind string) bool {
+		upload := UploadTask{
+			SourcePath:  path,
+			ObjectKey:   filepath.ToSlash(filepath.Join(task.DestPrefix, filepath.Base(path))),
+			ContentType: contentType,
+			Bucket:      task.Bucket,
+		}
+		select {
+		case <-ctx.Done():
+			return false
+		case uploadCh <- upload:
+		}
+		rc.saveVideoAsset(ctx, videoUUID, kind, upload)
+		return true
+	}
+
+	outPath := filepath.Join(task.WorkDir, waveformFileName)
+	if err := generateWaveform(ctx, rc.transcoder, task.SourcePath, outPath); err != nil {
+		rc.logger.Warn("waveform generation failed", "error", err, "videoID", task.VideoID)
+		return
+	}
+	if !publish(outPath, "application/json", AssetKindWaveform) {
+		return
+	}
+
+	// the peaks found audio, so there is something to draw
+	imagePath := filepath.Join(task.WorkDir, waveformImageName)
+	if err := generateWaveformImage(ctx, rc.transcoder, task.SourcePath, imagePath); err != nil {
+		rc.logger.Warn("waveform image generation failed", "error", err, "videoID", task.VideoID)
+		return
+	}
+	publish(imagePath, "image/png", AssetKindWaveformImage)
+}
@@ -0,0 +1,68 @@
+package video
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"video-processing/database/db"
+	"video-processing/mocks"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestComputeWaveform(t *testing.T) {
+	samples := []int16{100, -200, 300, 32767, -32768, 0, 512}
+	var buf bytes.Buffer
+	require.NoError(t, binary.Write(&buf, binary.LittleEndian, samples))
+
+	wf, err := computeWaveform(&buf, 8000, 3)
+	require.NoError(t, err)
+	require.Equal(t, 3, wf.Length)
+	require.Equal(t, 3, wf.SamplesPerPixel)
+	require.Equal(t, 8000, wf.SampleRate)
+	// pixel 1: min -200, max 300; pixel 2: min -32768, max 32767; pixel 3: 512 only
+	require.Equal(t, []int8{-1, 1, -128, 127, 2, 2}, wf.Data)
+}
+
+func TestComputeWaveformEmpty(t *testing.T) {
+	wf, err := computeWaveform(bytes.NewReader(nil), 8000, 400)
+	require.NoError(t, err)
+	require.Zero(t, wf.Length)
+	require.Empty(t, wf.Data)
+}
+
+func TestProcessWaveform(t *testing.T) {
+	repo := mocks.NewMockVideoRepo(gomock.NewController(t))
+	fake := NewFakeTranscoder()
+	rc := &redisConsumer{logger: slog.New(slog.NewTextHandler(io.Discard, nil)), db: repo, transcoder: fake}
+	var pcm bytes.Buffer
+	require.NoError(t, binary.Write(&pcm, binary.LittleEndian, []int16{100, -200, 300}))
+	fake.StreamOutput = pcm.Bytes()
+	task := ProcessingTask{WorkDir: t.TempDir(), SourcePath: "source.mp4", DestPrefix: "processed/job", Bucket: "videos", VideoID: uuid.NewString()}
+
+	gomock.InOrder(
+		repo.EXPECT().SaveVideoAsset(gomock.Any(), gomock.Cond(func(arg db.SaveVideoAssetParams) bool {
+			return arg.Kind == AssetKindWaveform && arg.Key == "processed/job/waveform.json"
+		})),
+		repo.EXPECT().SaveVideoAsset(gomock.Any(), gomock.Cond(func(arg db.SaveVideoAssetParams) bool {
+			return arg.Kind == AssetKindWaveformImage && arg.Key == "processed/job/waveform.png" && arg.ContentType == "image/png"
+		})),
+	)
+	uploads := make(chan UploadTask, 2)
+	rc.processWaveform(context.Background(), task, uploads)
+	require.Len(t, uploads, 2)
+	require.Equal(t, "-y -nostdin -v error -i source.mp4 "+
+		"-filter_complex [0:a:0]aformat=channel_layouts=mono,showwavespic=s=1800x240:colors=0x7f7f7f:scale=sqrt -frames:v 1 "+
+		task.WorkDir+"/waveform.png", strings.Join(fake.Calls()[1], " "))
+
+	// sources without audio get neither
+	fake.StreamOutput = nil
+	rc.processWaveform(context.Background(), task, uploads)
+	require.Len(t, fake.Calls(), 3)
+}
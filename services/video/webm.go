@@ -0,0 +1,70 @@
+package video
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"video-processing/database/db"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Containers of the rendition files, one video_variants row each
+const (
+	FormatMP4  = "mp4"
+	FormatWebM = "webm"
+)
+
+// webmCQLevel is the VP9 constant quality level of WebM renditions, capped at the variant
+// bitrate. VP9 reaches the quality of H.264 with fewer bits, so most encodes stay below it.
+const webmCQLevel = 32
+
+// transcodeToWebM encodes the variant's MP4 into VP9 and Opus. The MP4 is already scaled,
+// cropped and tone mapped, so the filters of the variant are not applied a second time.
+func transcodeToWebM(ctx context.Context, t Transcoder, task ProcessingTask, mp4Path, webmPath string) error {
+	// ffmpeg -y -i variant.mp4 -c:v libvpx-vp9 -crf 32 -b:v BITRATE -deadline good -cpu-used 4 -row-mt 1 \
+	//   -c:a libopus -b:a 128k -ar 48000 variant.webm
+	args := []string{
+		"-y",
+		"-nostdin",
+	}
+	args = append(args, filterThreadArgs(task.Threads)...)
+	args = append(args, "-i", mp4Path)
+	args = append(args, encoderThreadArgs(task.Threads)...)
+	args = append(args, trackMapArgs(task.AudioLanguages)...)
+	args = append(args,
+		"-c:v", "libvpx-vp9",
+		"-crf", fmt.Sprint(webmCQLevel),
+		"-b:v", task.Variant.Bitrate,
+		"-deadline", "good",
+		"-cpu-used", "4",
+		"-row-mt", "1",
+		"-pix_fmt", "yuv420p",
+	)
+	if task.HasAudio {
+		args = append(args, "-c:a", "libopus", "-b:a", "128k", "-ar", "48000")
+		if task.Variant.AudioChannels > 0 {
+			// surround audio stays in the MP4, the WebM gets the stereo downmix
+			args = append(args, "-ac", "2")
+		}
+	} else {
+		args = append(args, "-an")
+	}
+	args = append(args, "-f", "webm", webmPath)
+	if err := t.Run(ctx, args...); err != nil {
+		return fmt.Errorf("ffmpeg webm error: %w", err)
+	}
+	return nil
+}
+
+// webmMetadata is the row of a WebM rendition, next to the MP4 row of the same variant. The
+// HLS playlist and thumbnail stay on the MP4 row.
+func webmMetadata(mp4 db.SaveProcessedVideoMetadataParams, destPrefix, variantName string) db.SaveProcessedVideoMetadataParams {
+	webm := mp4
+	webm.Key = filepath.ToSlash(filepath.Join(destPrefix, variantName+".webm"))
+	webm.ContentType = "video/webm"
+	webm.Format = FormatWebM
+	webm.HlsPlaylistKey = pgtype.Text{}
+	webm.ThumbnailKey = pgtype.Text{}
+	return webm
+}
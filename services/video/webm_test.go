@@ -0,0 +1,99 @@
+package video
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"path"
+	"sync"
+	"testing"
+	"video-processing/database/db"
+	"video-processing/mocks"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestProcessVariantWebM(t *testing.T) {
+	fake := NewFakeTranscoder()
+	rc := &redisConsumer{logger: slog.New(slog.NewTextHandler(io.Discard, nil)), transcoder: fake}
+	task := ProcessingTask{
+		Variant:    testLadder.regular[1],
+		WorkDir:    t.TempDir(),
+		SourcePath: "source.mp4",
+		DestPrefix: "processed/job",
+		Bucket:     "videos",
+		VideoID:    uuid.NewString(),
+		HasAudio:   true,
+		WebM:       true,
+	}
+	run := func() ProcessingResult {
+		results := make(chan ProcessingResult, 1)
+		var wg sync.WaitGroup
+		wg.Add(1)
+		rc.processVariant(context.Background(), task, results, make(chan UploadTask, 100), &wg)
+		return <-results
+	}
+
+	result := run()
+	require.True(t, result.Success, "%v", result.Error)
+	var webm []UploadTask
+	for _, f := range result.Files {
+		if path.Ext(f.ObjectKey) == ".webm" {
+			webm = append(webm, f)
+		}
+	}
+	require.Equal(t, []UploadTask{{
+		SourcePath:  path.Join(task.WorkDir, "720p", "720p.webm"),
+		ObjectKey:   "processed/job/720p/720p.webm",
+		ContentType: "video/webm",
+		Bucket:      "videos",
+	}}, webm)
+	require.Equal(t, FormatMP4, result.Metadata.Format)
+	require.NotNil(t, result.WebM)
+	require.Equal(t, "processed/job/720p/720p.webm", result.WebM.Key)
+	require.Equal(t, FormatWebM, result.WebM.Format)
+	require.Equal(t, result.Metadata.BitrateKbps, result.WebM.BitrateKbps)
+	require.False(t, result.WebM.HlsPlaylistKey.Valid)
+
+	var encode []string
+	for _, call := range fake.Calls() {
+		if path.Ext(call[len(call)-1]) == ".webm" {
+			encode = call
+		}
+	}
+	require.Contains(t, encode, "libvpx-vp9")
+	require.Contains(t, encode, "libopus")
+	require.Contains(t, encode, path.Join(task.WorkDir, "720p", "720p.mp4"))
+
+	// a failed WebM encode leaves the variant to its MP4
+	fake.FailOn = "libvpx-vp9"
+	result = run()
+	require.True(t, result.Success, "%v", result.Error)
+	require.Nil(t, result.WebM)
+	for _, f := range result.Files {
+		require.NotEqual(t, ".webm", path.Ext(f.ObjectKey))
+	}
+}
+
+func TestSaveVariantMetadataWebM(t *testing.T) {
+	repo := mocks.NewMockVideoRepo(gomock.NewController(t))
+	rc := &redisConsumer{logger: slog.New(slog.NewTextHandler(io.Discard, nil)), db: repo}
+	mp4 := db.SaveProcessedVideoMetadataParams{
+		VideoID:        uuid.New(),
+		VariantName:    "720p",
+		Key:            "processed/job/720p/720p.mp4",
+		ContentType:    "video/mp4",
+		HlsPlaylistKey: pgtype.Text{String: "processed/job/720p/index.m3u8", Valid: true},
+		Format:         FormatMP4,
+	}
+	webm := webmMetadata(mp4, "processed/job/720p", "720p")
+
+	gomock.InOrder(
+		repo.EXPECT().SaveProcessedVideoMetadata(gomock.Any(), mp4),
+		repo.EXPECT().SaveProcessedVideoMetadata(gomock.Any(), webm),
+	)
+	rc.saveVariantMetadata(context.Background(), ProcessingResult{Variant: testLadder.regular[1], Success: true, Metadata: mp4, WebM: &webm})
+}
@@ -0,0 +1,36 @@
+package storage
+
+import (
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// Layout places the objects of users. With an empty Bucket every user has a bucket named
+// after their id; otherwise the objects of all users share Bucket under a "<user id>/" prefix.
+type Layout struct {
+	Bucket string
+}
+
+// Root returns the bucket and key prefix holding the objects of owner
+func (l Layout) Root(owner uuid.UUID) (bucket, prefix string) {
+	if l.Bucket == "" {
+		return owner.String(), ""
+	}
+	return l.Bucket, owner.String() + "/"
+}
+
+// Place returns where an object of owner stored under key belongs
+func (l Layout) Place(owner uuid.UUID, key string) (bucket, objectKey string) {
+	bucket, prefix := l.Root(owner)
+	return bucket, prefix + key
+}
+
+// OwnerPrefix returns the "<user id>/" prefix of key when it is an object of owner in a shared
+// bucket, so that the objects derived from it stay under the same prefix
+func OwnerPrefix(owner, key string) string {
+	if owner == "" || !strings.HasPrefix(key, owner+"/") {
+		return ""
+	}
+	return owner + "/"
+}
@@ -0,0 +1,24 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLayout(t *testing.T) {
+	owner := uuid.New()
+
+	bucket, key := Layout{}.Place(owner, "clip.mp4")
+	require.Equal(t, owner.String(), bucket)
+	require.Equal(t, "clip.mp4", key)
+
+	bucket, key = Layout{Bucket: "videos"}.Place(owner, "clip.mp4")
+	require.Equal(t, "videos", bucket)
+	require.Equal(t, owner.String()+"/clip.mp4", key)
+
+	require.Equal(t, owner.String()+"/", OwnerPrefix(owner.String(), key))
+	require.Empty(t, OwnerPrefix(owner.String(), "clip.mp4"))
+	require.Empty(t, OwnerPrefix("", key))
+}
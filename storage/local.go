@@ -0,0 +1,295 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+)
+
+const (
+	TypeMinio = "minio"
+	TypeLocal = "local"
+
+	// Route is where the API serves the objects of the local backend
+	Route = "/storage"
+)
+
+// Local keeps objects as plain files under a directory, one subdirectory per bucket.
+// It stands in for MinIO during development: the API serves the directory under Route,
+// so presigned URLs are plain links to that route that never expire.
+type Local struct {
+	dir     string
+	baseURL string
+}
+
+// NewLocal creates the storage directory if needed. baseURL is the address of the API,
+// e.g. http://localhost:8888, and prefixes every URL handed out.
+func NewLocal(dir, baseURL string) (*Local, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("local storage needs a directory")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create storage directory: %w", err)
+	}
+	return &Local{dir: dir, baseURL: strings.TrimSuffix(baseURL, "/")}, nil
+}
+
+// Dir is the directory the objects are stored under
+func (l *Local) Dir() string {
+	return l.dir
+}
+
+// checkBucketName refuses bucket names that are not a single directory
+func checkBucketName(bucketName string) error {
+	if bucketName == "" || strings.ContainsAny(bucketName, `/\`) || bucketName == "." || bucketName == ".." {
+		return minio.ErrorResponse{StatusCode: http.StatusBadRequest, Code: "InvalidBucketName", BucketName: bucketName, Message: "invalid bucket name"}
+	}
+	return nil
+}
+
+// objectPath maps an object to its file, refusing names that would escape the bucket
+func (l *Local) objectPath(bucketName, objectName string) (string, error) {
+	if err := checkBucketName(bucketName); err != nil {
+		return "", err
+	}
+	clean := path.Clean("/" + objectName)
+	if objectName == "" || clean == "/" {
+		return "", minio.ErrorResponse{StatusCode: http.StatusBadRequest, Code: "XMinioInvalidObjectName", BucketName: bucketName, Key: objectName, Message: "invalid object name"}
+	}
+	return filepath.Join(l.dir, bucketName, filepath.FromSlash(clean)), nil
+}
+
+func (l *Local) bucketExists(bucketName string) error {
+	info, err := os.Stat(filepath.Join(l.dir, bucketName))
+	if err != nil || !info.IsDir() {
+		return minio.ErrorResponse{StatusCode: http.StatusNotFound, Code: "NoSuchBucket", BucketName: bucketName, Message: "the specified bucket does not exist"}
+	}
+	return nil
+}
+
+func (l *Local) MakeBucket(ctx context.Context, bucketName string, opts minio.MakeBucketOptions) error {
+	if err := checkBucketName(bucketName); err != nil {
+		return err
+	}
+	if l.bucketExists(bucketName) == nil {
+		return minio.ErrorResponse{StatusCode: http.StatusConflict, Code: "BucketAlreadyOwnedByYou", BucketName: bucketName, Message: "bucket already exists"}
+	}
+	return os.Mkdir(filepath.Join(l.dir, bucketName), 0o755)
+}
+
+func (l *Local) ListBuckets(ctx context.Context) ([]minio.BucketInfo, error) {
+	entries, err := os.ReadDir(l.dir)
+	if err != nil {
+		return nil, err
+	}
+	var buckets []minio.BucketInfo
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		buckets = append(buckets, minio.BucketInfo{Name: e.Name(), CreationDate: info.ModTime()})
+	}
+	return buckets, nil
+}
+
+// PutObject writes the object through a temporary file, so readers never see a partial object
+func (l *Local) PutObject(ctx context.Context, bucketName, objectName string, reader io.Reader, size int64, opts minio.PutObjectOptions) (minio.UploadInfo, error) {
+	p, err := l.objectPath(bucketName, objectName)
+	if err != nil {
+		return minio.UploadInfo{}, err
+	}
+	if err := l.bucketExists(bucketName); err != nil {
+		return minio.UploadInfo{}, err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return minio.UploadInfo{}, err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(p), ".upload-*")
+	if err != nil {
+		return minio.UploadInfo{}, err
+	}
+	defer os.Remove(tmp.Name())
+	written, err := io.Copy(tmp, reader)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return minio.UploadInfo{}, fmt.Errorf("failed to write object: %w", err)
+	}
+	if size >= 0 && written != size {
+		return minio.UploadInfo{}, fmt.Errorf("short object write: %d of %d bytes", written, size)
+	}
+	if err := os.Rename(tmp.Name(), p); err != nil {
+		return minio.UploadInfo{}, err
+	}
+	info, err := l.StatObject(ctx, bucketName, objectName, minio.StatObjectOptions{})
+	if err != nil {
+		return minio.UploadInfo{}, err
+	}
+	return minio.UploadInfo{Bucket: bucketName, Key: objectName, ETag: info.ETag, Size: info.Size, LastModified: info.LastModified}, nil
+}
+
+func (l *Local) FPutObject(ctx context.Context, bucketName, objectName, filePath string, opts minio.PutObjectOptions) (minio.UploadInfo, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return minio.UploadInfo{}, err
+	}
+	defer f.Close()
+	return l.PutObject(ctx, bucketName, objectName, f, -1, opts)
+}
+
+func (l *Local) FGetObject(ctx context.Context, bucketName, objectName, filePath string, opts minio.GetObjectOptions) error {
+	p, err := l.objectPath(bucketName, objectName)
+	if err != nil {
+		return err
+	}
+	src, err := os.Open(p)
+	if err != nil {
+		return l.notFound(bucketName, objectName, err)
+	}
+	defer src.Close()
+	if err := os.MkdirAll(filepath.Dir(filePath), 0o755); err != nil {
+		return err
+	}
+	dst, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		return err
+	}
+	return dst.Close()
+}
+
+func (l *Local) StatObject(ctx context.Context, bucketName, objectName string, opts minio.StatObjectOptions) (minio.ObjectInfo, error) {
+	p, err := l.objectPath(bucketName, objectName)
+	if err != nil {
+		return minio.ObjectInfo{}, err
+	}
+	info, err := os.Stat(p)
+	if err != nil || info.IsDir() {
+		return minio.ObjectInfo{}, l.notFound(bucketName, objectName, err)
+	}
+	return minio.ObjectInfo{
+		Key:          objectName,
+		Size:         info.Size(),
+		LastModified: info.ModTime(),
+		// changes whenever the file is rewritten, which is all the source cache relies on
+		ETag:        fmt.Sprintf("%x-%x", info.ModTime().UnixNano(), info.Size()),
+		ContentType: contentType(objectName),
+	}, nil
+}
+
+// ListObjects lists the objects under opts.Prefix, in lexical order like MinIO. Without
+// opts.Recursive the objects below the next "/" after the prefix are left out.
+func (l *Local) ListObjects(ctx context.Context, bucketName string, opts minio.ListObjectsOptions) <-chan minio.ObjectInfo {
+	objects := make(chan minio.ObjectInfo)
+	go func() {
+		defer close(objects)
+		if err := l.bucketExists(bucketName); err != nil {
+			objects <- minio.ObjectInfo{Err: err}
+			return
+		}
+		root := filepath.Join(l.dir, bucketName)
+		err := filepath.WalkDir(root, func(p string, d os.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+			rel, err := filepath.Rel(root, p)
+			if err != nil {
+				return err
+			}
+			key := filepath.ToSlash(rel)
+			rest, ok := strings.CutPrefix(key, opts.Prefix)
+			if !ok || !opts.Recursive && strings.Contains(rest, "/") {
+				return nil
+			}
+			info, err := l.StatObject(ctx, bucketName, key, minio.StatObjectOptions{})
+			if err != nil {
+				return err
+			}
+			select {
+			case objects <- info:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+		if err != nil && ctx.Err() == nil {
+			objects <- minio.ObjectInfo{Err: err}
+		}
+	}()
+	return objects
+}
+
+// RemoveObject deletes an object; like MinIO, missing objects are not an error
+func (l *Local) RemoveObject(ctx context.Context, bucketName, objectName string, opts minio.RemoveObjectOptions) error {
+	p, err := l.objectPath(bucketName, objectName)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// OpenObject opens an object for reading; the caller closes it
+func (l *Local) OpenObject(ctx context.Context, bucketName, objectName string, opts minio.GetObjectOptions) (io.ReadCloser, minio.ObjectInfo, error) {
+	info, err := l.StatObject(ctx, bucketName, objectName, minio.StatObjectOptions{})
+	if err != nil {
+		return nil, minio.ObjectInfo{}, err
+	}
+	p, _ := l.objectPath(bucketName, objectName)
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, minio.ObjectInfo{}, l.notFound(bucketName, objectName, err)
+	}
+	return f, info, nil
+}
+
+// PresignedGetObject returns the object's URL on the static route; expires is ignored
+func (l *Local) PresignedGetObject(ctx context.Context, bucketName, objectName string, expires time.Duration, reqParams url.Values) (*url.URL, error) {
+	if _, err := l.objectPath(bucketName, objectName); err != nil {
+		return nil, err
+	}
+	u, err := url.Parse(l.baseURL + Route + "/" + bucketName + "/" + strings.TrimPrefix(objectName, "/"))
+	if err != nil {
+		return nil, err
+	}
+	u.RawQuery = reqParams.Encode()
+	return u, nil
+}
+
+// notFound reports a missing object the way MinIO does, or passes other errors through
+func (l *Local) notFound(bucketName, objectName string, err error) error {
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if berr := l.bucketExists(bucketName); berr != nil {
+		return berr
+	}
+	return minio.ErrorResponse{StatusCode: http.StatusNotFound, Code: "NoSuchKey", BucketName: bucketName, Key: objectName, Message: "the specified key does not exist"}
+}
+
+func contentType(objectName string) string {
+	if t := mime.TypeByExtension(path.Ext(objectName)); t != "" {
+		return t
+	}
+	return "application/octet-stream"
+}
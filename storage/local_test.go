@@ -0,0 +1,96 @@
+package storage
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	l, err := NewLocal(dir, "http://localhost:8888/")
+	require.NoError(t, err)
+
+	_, err = l.PutObject(ctx, "b1", "a.mp4", strings.NewReader("data"), 4, minio.PutObjectOptions{})
+	require.Equal(t, "NoSuchBucket", minio.ToErrorResponse(err).Code)
+
+	require.NoError(t, l.MakeBucket(ctx, "b1", minio.MakeBucketOptions{}))
+	require.Equal(t, "BucketAlreadyOwnedByYou", minio.ToErrorResponse(l.MakeBucket(ctx, "b1", minio.MakeBucketOptions{})).Code)
+	buckets, err := l.ListBuckets(ctx)
+	require.NoError(t, err)
+	require.Len(t, buckets, 1)
+	require.Equal(t, "b1", buckets[0].Name)
+
+	up, err := l.PutObject(ctx, "b1", "processed/x/720p/index.m3u8", strings.NewReader("#EXTM3U\n"), 8, minio.PutObjectOptions{})
+	require.NoError(t, err)
+	require.EqualValues(t, 8, up.Size)
+
+	info, err := l.StatObject(ctx, "b1", "processed/x/720p/index.m3u8", minio.StatObjectOptions{})
+	require.NoError(t, err)
+	require.EqualValues(t, 8, info.Size)
+	require.Equal(t, up.ETag, info.ETag)
+
+	out := filepath.Join(t.TempDir(), "copy.m3u8")
+	require.NoError(t, l.FGetObject(ctx, "b1", "processed/x/720p/index.m3u8", out, minio.GetObjectOptions{}))
+	data, err := os.ReadFile(out)
+	require.NoError(t, err)
+	require.Equal(t, "#EXTM3U\n", string(data))
+
+	_, err = l.StatObject(ctx, "b1", "missing.mp4", minio.StatObjectOptions{})
+	require.Equal(t, "NoSuchKey", minio.ToErrorResponse(err).Code)
+
+	u, err := l.PresignedGetObject(ctx, "b1", "processed/x/720p/index.m3u8", 0, nil)
+	require.NoError(t, err)
+	require.Equal(t, "http://localhost:8888/storage/b1/processed/x/720p/index.m3u8", u.String())
+}
+
+func TestLocalStaysInsideBucket(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	l, err := NewLocal(filepath.Join(dir, "store"), "")
+	require.NoError(t, err)
+	require.NoError(t, l.MakeBucket(ctx, "b1", minio.MakeBucketOptions{}))
+
+	_, err = l.PutObject(ctx, "b1", "../../escape.txt", strings.NewReader("x"), 1, minio.PutObjectOptions{})
+	require.NoError(t, err)
+	_, err = os.Stat(filepath.Join(dir, "store", "b1", "escape.txt"))
+	require.NoError(t, err, "dot segments are resolved inside the bucket")
+
+	require.Error(t, l.MakeBucket(ctx, "../b2", minio.MakeBucketOptions{}))
+}
+
+func TestLocalListAndRemove(t *testing.T) {
+	ctx := context.Background()
+	l, err := NewLocal(t.TempDir(), "")
+	require.NoError(t, err)
+	require.NoError(t, l.MakeBucket(ctx, "b1", minio.MakeBucketOptions{}))
+	for _, key := range []string{"processed/x/master.m3u8", "processed/x/720p/index.m3u8", "processed/y/master.m3u8", "source.mp4"} {
+		_, err := l.PutObject(ctx, "b1", key, strings.NewReader("x"), 1, minio.PutObjectOptions{})
+		require.NoError(t, err)
+	}
+	list := func(opts minio.ListObjectsOptions) []string {
+		var keys []string
+		for object := range l.ListObjects(ctx, "b1", opts) {
+			require.NoError(t, object.Err)
+			keys = append(keys, object.Key)
+		}
+		return keys
+	}
+
+	require.Equal(t, []string{"processed/x/720p/index.m3u8", "processed/x/master.m3u8"}, list(minio.ListObjectsOptions{Prefix: "processed/x/", Recursive: true}))
+	require.Equal(t, []string{"processed/x/master.m3u8"}, list(minio.ListObjectsOptions{Prefix: "processed/x/"}))
+
+	require.NoError(t, l.RemoveObject(ctx, "b1", "processed/x/master.m3u8", minio.RemoveObjectOptions{}))
+	require.NoError(t, l.RemoveObject(ctx, "b1", "processed/x/master.m3u8", minio.RemoveObjectOptions{}))
+	require.Equal(t, []string{"processed/x/720p/index.m3u8"}, list(minio.ListObjectsOptions{Prefix: "processed/x/", Recursive: true}))
+
+	for object := range l.ListObjects(ctx, "missing", minio.ListObjectsOptions{}) {
+		require.Equal(t, "NoSuchBucket", minio.ToErrorResponse(object.Err).Code)
+	}
+}
@@ -16,10 +16,15 @@ var (
 	ErrInvalidToken      = errors.New("invalid token")
 )
 
+// PurposePlayback marks tokens that let anyone play one video, ID is the video id
+const PurposePlayback = "playback"
+
 type Payload struct {
 	ID       uuid.UUID `json:"id"`
 	IssuedAt time.Time `json:"issued_at"`
 	ExpireAt time.Time `json:"expire_at"`
+	// Purpose is empty for access tokens, whose ID is the user id
+	Purpose string `json:"purpose,omitempty"`
 }
 
 func (p Payload) valid() bool {